@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 )
 
 type Config struct {
@@ -10,17 +12,51 @@ type Config struct {
 	DBPath      string // 仅 DBType=sqlite 时使用
 	DBURL       string // DBType=libsql 时使用, 如 libsql://xxx.turso.io 或 file:./local.db
 	DBAuthToken string // DBType=libsql 时使用, Turso 访问令牌 (本地文件可留空)
-	JWTSecret   string
+	JWTSecret   string // empty means a generated secret stored in the database
+	// MasterKey wraps the data keys that encrypt stored credentials; empty
+	// keeps credentials in plaintext
+	MasterKey string
+	// TrustedProxies lists the reverse proxy IPs/CIDRs whose forwarding
+	// headers are believed; empty trusts none for security checks
+	TrustedProxies []string
+	// RegistrationMode is the default sign-up mode (open, invite, disabled)
+	// until an admin changes it
+	RegistrationMode string
+
+	// SchedulerTimezone is the IANA zone cron schedules are evaluated in;
+	// empty means the server's local time
+	SchedulerTimezone string
+	// BackupDir receives scheduled backups, one sub-directory per user
+	BackupDir      string
+	BackupKeep     string // number of scheduled backups kept per user
+	BackupPassword string // optional, encrypts scheduled backups
+
+	// MockProvider "true" registers the in-memory "mock" provider
+	// for demos and integration tests
+	MockProvider    string
+	MockLatency     string // delay added to every mock call, e.g. "200ms"
+	MockFailureRate string // share of mock calls that fail, 0 to 1
 }
 
 func Load() *Config {
+	dbPath := getEnv("DB_PATH", "dns-mng.db")
 	return &Config{
-		ServerPort:  getEnv("SERVER_PORT", "8080"),
-		DBType:      getEnv("DB_TYPE", "sqlite"),
-		DBPath:      getEnv("DB_PATH", "dns-mng.db"),
-		DBURL:       getEnv("DB_URL", ""),
-		DBAuthToken: getEnv("DB_AUTH_TOKEN", ""),
-		JWTSecret:   getEnv("JWT_SECRET", "dns-mng-secret-key-change-in-production"),
+		ServerPort:        getEnv("SERVER_PORT", "8080"),
+		DBType:            getEnv("DB_TYPE", "sqlite"),
+		DBPath:            dbPath,
+		DBURL:             getEnv("DB_URL", ""),
+		DBAuthToken:       getEnv("DB_AUTH_TOKEN", ""),
+		JWTSecret:         getEnv("JWT_SECRET", ""),
+		MasterKey:         getEnv("MASTER_KEY", ""),
+		TrustedProxies:    splitList(getEnv("TRUSTED_PROXIES", "")),
+		RegistrationMode:  getEnv("REGISTRATION_MODE", "disabled"),
+		SchedulerTimezone: getEnv("SCHEDULER_TIMEZONE", ""),
+		BackupDir:         getEnv("BACKUP_DIR", filepath.Join(filepath.Dir(dbPath), "backups")),
+		BackupKeep:        getEnv("BACKUP_KEEP", "7"),
+		BackupPassword:    getEnv("BACKUP_PASSWORD", ""),
+		MockProvider:      getEnv("MOCK_PROVIDER", ""),
+		MockLatency:       getEnv("MOCK_LATENCY", ""),
+		MockFailureRate:   getEnv("MOCK_FAILURE_RATE", ""),
 	}
 }
 
@@ -30,3 +66,14 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// splitList splits a comma-separated value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
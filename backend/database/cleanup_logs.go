@@ -1,6 +1,8 @@
 package database
 
 import (
+	"database/sql"
+	"fmt"
 	"log"
 	"time"
 )
@@ -72,3 +74,96 @@ func VacuumDatabase() error {
 	log.Println("Database vacuumed successfully")
 	return nil
 }
+
+// LogTables lists the tables covered by log retention policies
+var LogTables = []string{"api_call_logs", "login_logs", "scheduler_logs"}
+
+// IsLogTable reports whether name is one of LogTables
+func IsLogTable(name string) bool {
+	for _, t := range LogTables {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+// CleanupLogTable deletes rows of a log table older than maxDays and beyond
+// the newest maxRows. A limit of 0 is not applied. It returns the number of
+// deleted rows.
+func CleanupLogTable(table string, maxDays, maxRows int) (int64, error) {
+	if !IsLogTable(table) {
+		return 0, fmt.Errorf("unknown log table %q", table)
+	}
+
+	var deleted int64
+	if maxDays > 0 {
+		// created_at is CURRENT_TIMESTAMP (UTC), so compare in SQL
+		result, err := DB.Exec(
+			`DELETE FROM `+table+` WHERE created_at < datetime('now', ?)`,
+			fmt.Sprintf("-%d days", maxDays),
+		)
+		if err != nil {
+			return deleted, err
+		}
+		n, _ := result.RowsAffected()
+		deleted += n
+	}
+	if maxRows > 0 {
+		result, err := DB.Exec(
+			`DELETE FROM `+table+` WHERE id <= (SELECT id FROM `+table+` ORDER BY id DESC LIMIT 1 OFFSET ?)`,
+			maxRows,
+		)
+		if err != nil {
+			return deleted, err
+		}
+		n, _ := result.RowsAffected()
+		deleted += n
+	}
+	return deleted, nil
+}
+
+// GetLogTableStats returns the row count and time span of a log table
+func GetLogTableStats(table string) (map[string]interface{}, error) {
+	if !IsLogTable(table) {
+		return nil, fmt.Errorf("unknown log table %q", table)
+	}
+
+	var total int64
+	var oldest, newest sql.NullString
+	err := DB.QueryRow(`SELECT COUNT(*), MIN(created_at), MAX(created_at) FROM ` + table).Scan(&total, &oldest, &newest)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := map[string]interface{}{"total": total}
+	if oldest.Valid {
+		stats["oldest"] = oldest.String
+	}
+	if newest.Valid {
+		stats["newest"] = newest.String
+	}
+	return stats, nil
+}
+
+// GetDatabaseSize returns the page statistics of a local SQLite database;
+// libSQL remotes report nothing
+func GetDatabaseSize() map[string]interface{} {
+	stats := make(map[string]interface{})
+	if IsLibSQL() {
+		return stats
+	}
+
+	var pageCount, pageSize, freePages int64
+	if err := DB.QueryRow(`PRAGMA page_count`).Scan(&pageCount); err != nil {
+		return stats
+	}
+	if err := DB.QueryRow(`PRAGMA page_size`).Scan(&pageSize); err != nil {
+		return stats
+	}
+	DB.QueryRow(`PRAGMA freelist_count`).Scan(&freePages)
+
+	stats["size_bytes"] = pageCount * pageSize
+	stats["free_bytes"] = freePages * pageSize
+	return stats
+}
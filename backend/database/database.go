@@ -66,6 +66,11 @@ func InitWithConfig(dbType, dbPath, dbURL, dbAuthToken string) {
 		log.Printf("Warning: Migration failed: %v", err)
 	}
 
+	// Rebuild the single-token-per-user ddns_tokens table
+	if err := MigrateDDNSTokensToMultiToken(); err != nil {
+		log.Fatalf("Failed to migrate ddns_tokens: %v", err)
+	}
+
 	log.Printf("Database initialized successfully (driver=%s)", dbDriver)
 }
 
@@ -83,6 +88,67 @@ func createTables() {
 			password_hash TEXT NOT NULL,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		)`,
+		`ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'user'`,
+		`ALTER TABLE users ADD COLUMN disabled INTEGER NOT NULL DEFAULT 0`,
+		// TOTP two-factor: secret is encrypted by the secret store, last step blocks code reuse
+		`ALTER TABLE users ADD COLUMN totp_secret TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE users ADD COLUMN totp_enabled INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE users ADD COLUMN totp_last_step INTEGER NOT NULL DEFAULT 0`,
+		`CREATE TABLE IF NOT EXISTS user_recovery_codes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			code_hash TEXT NOT NULL,
+			used_at DATETIME,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user_id ON user_recovery_codes(user_id)`,
+		// Single-use registration codes for invite-only mode, stored hashed
+		`CREATE TABLE IF NOT EXISTS user_invites (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			code_hash TEXT NOT NULL UNIQUE,
+			note TEXT NOT NULL DEFAULT '',
+			created_by INTEGER NOT NULL,
+			expires_at DATETIME,
+			used_by INTEGER,
+			used_at DATETIME,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		// Personal API tokens for scripts; only a SHA-256 hash of the token is kept
+		`CREATE TABLE IF NOT EXISTS api_tokens (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			name TEXT NOT NULL DEFAULT '',
+			token_hash TEXT NOT NULL UNIQUE,
+			prefix TEXT NOT NULL DEFAULT '',
+			scopes TEXT NOT NULL DEFAULT '',
+			account_ids TEXT NOT NULL DEFAULT '',
+			domains TEXT NOT NULL DEFAULT '',
+			expires_at DATETIME,
+			last_used_at DATETIME,
+			last_ip TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_api_tokens_user_id ON api_tokens(user_id)`,
+		// Signed-in clients; a session lives as long as its rotating refresh token
+		`CREATE TABLE IF NOT EXISTS user_sessions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			refresh_hash TEXT NOT NULL UNIQUE,
+			device TEXT NOT NULL DEFAULT '',
+			ip_address TEXT NOT NULL DEFAULT '',
+			user_agent TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			last_used_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			expires_at DATETIME NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions(user_id)`,
+		// Instance-wide settings changed at runtime (e.g. registration_mode);
+		// a missing key falls back to the config default
+		`CREATE TABLE IF NOT EXISTS app_settings (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
 		`CREATE TABLE IF NOT EXISTS accounts (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			user_id INTEGER NOT NULL,
@@ -175,6 +241,57 @@ func createTables() {
 		`CREATE INDEX IF NOT EXISTS idx_email_config_user_id ON email_config(user_id)`,
 		// 为兼容旧版本，添加邮件语言列（如果不存在）
 		`ALTER TABLE email_config ADD COLUMN language TEXT DEFAULT ''`,
+		// 邮件订阅的通知事件，旧配置默认只发到期提醒
+		`ALTER TABLE email_config ADD COLUMN notify_events TEXT NOT NULL DEFAULT 'domain_expiry'`,
+
+		// Notification channels other than SMTP email (Telegram, Slack, webhooks...)
+		`CREATE TABLE IF NOT EXISTS notification_channels (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			type TEXT NOT NULL,
+			config TEXT NOT NULL DEFAULT '{}',
+			events TEXT NOT NULL DEFAULT '',
+			enabled INTEGER NOT NULL DEFAULT 1,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_notification_channels_user_id ON notification_channels(user_id)`,
+
+		// Outbound webhooks for record/domain change events and their delivery log
+		`CREATE TABLE IF NOT EXISTS webhooks (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			url TEXT NOT NULL,
+			secret TEXT NOT NULL,
+			events TEXT NOT NULL DEFAULT '',
+			domains TEXT NOT NULL DEFAULT '',
+			enabled INTEGER NOT NULL DEFAULT 1,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhooks_user_id ON webhooks(user_id)`,
+		`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			webhook_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			event TEXT NOT NULL,
+			domain TEXT NOT NULL DEFAULT '',
+			payload TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			response_status INTEGER NOT NULL DEFAULT 0,
+			error TEXT NOT NULL DEFAULT '',
+			next_attempt_at DATETIME,
+			delivered_at DATETIME,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(status, next_attempt_at)`,
 
 		// WHOIS lookup configuration table (per user, single row)
 		`CREATE TABLE IF NOT EXISTS whois_config (
@@ -215,12 +332,96 @@ func createTables() {
 		`CREATE INDEX IF NOT EXISTS idx_scheduler_logs_task_name ON scheduler_logs(task_name)`,
 		`CREATE INDEX IF NOT EXISTS idx_scheduler_logs_created_at ON scheduler_logs(created_at DESC)`,
 
-		// DDNS tokens table (one token per user, system-wide)
+		// Data keys for secrets stored at rest, each wrapped by a key derived
+		// from MASTER_KEY. Only the active key encrypts new values.
+		`CREATE TABLE IF NOT EXISTS encryption_keys (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			salt TEXT NOT NULL,
+			wrapped_key TEXT NOT NULL,
+			active INTEGER NOT NULL DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// Per-table log retention overrides; NULL falls back to the built-in
+		// default, 0 means no limit
+		`CREATE TABLE IF NOT EXISTS log_retention_policies (
+			table_name TEXT PRIMARY KEY,
+			max_days INTEGER,
+			max_rows INTEGER,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// Scheduler job state; empty schedule / NULL enabled fall back to the
+		// built-in job defaults
+		`CREATE TABLE IF NOT EXISTS scheduler_jobs (
+			name TEXT PRIMARY KEY,
+			schedule TEXT NOT NULL DEFAULT '',
+			enabled INTEGER,
+			last_run_at DATETIME,
+			last_status TEXT NOT NULL DEFAULT '',
+			last_error TEXT NOT NULL DEFAULT '',
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// Domains the scheduled refresh found missing at the provider; they are
+		// only soft-deleted once the user approves
+		`CREATE TABLE IF NOT EXISTS domain_pending_deletions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			account_id INTEGER NOT NULL,
+			domain_id TEXT NOT NULL,
+			domain_name TEXT NOT NULL DEFAULT '',
+			detected_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			dismissed_at DATETIME,
+			UNIQUE(user_id, account_id, domain_id)
+		)`,
+
+		// Record-level cache, refreshed on every live ListRecords. Our own
+		// writes go through to the cache so re-syncs only report external edits.
+		`CREATE TABLE IF NOT EXISTS record_cache (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			account_id INTEGER NOT NULL,
+			domain_id TEXT NOT NULL,
+			record_id TEXT NOT NULL,
+			record_json TEXT NOT NULL,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(account_id, domain_id, record_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS record_cache_zones (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			account_id INTEGER NOT NULL,
+			domain_id TEXT NOT NULL,
+			synced_at DATETIME NOT NULL,
+			UNIQUE(account_id, domain_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS record_changes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			account_id INTEGER NOT NULL,
+			domain_id TEXT NOT NULL,
+			record_id TEXT NOT NULL,
+			change_type TEXT NOT NULL,
+			old_json TEXT NOT NULL DEFAULT '',
+			new_json TEXT NOT NULL DEFAULT '',
+			detected_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_record_changes_zone ON record_changes(account_id, domain_id, detected_at DESC)`,
+
+		// DDNS tokens table (multiple named tokens per user, each optionally
+		// scoped to hostnames / record types / caller IPs).
+		// Older databases with one token per user are rebuilt by MigrateDDNSTokensToMultiToken.
 		`CREATE TABLE IF NOT EXISTS ddns_tokens (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			user_id INTEGER NOT NULL UNIQUE,
+			user_id INTEGER NOT NULL,
+			name TEXT NOT NULL DEFAULT '',
 			token TEXT NOT NULL UNIQUE,
 			enabled INTEGER DEFAULT 1,
+			hostnames TEXT NOT NULL DEFAULT '',
+			record_types TEXT NOT NULL DEFAULT '',
+			allowed_ips TEXT NOT NULL DEFAULT '',
+			expires_at DATETIME,
 			last_used_at DATETIME,
 			last_ip TEXT,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
@@ -228,6 +429,76 @@ func createTables() {
 			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 		)`,
 		`CREATE INDEX IF NOT EXISTS idx_ddns_tokens_token ON ddns_tokens(token)`,
+		`CREATE INDEX IF NOT EXISTS idx_ddns_tokens_user_id ON ddns_tokens(user_id)`,
+		// token is stored encrypted; lookups go through a keyed hash of the value
+		`ALTER TABLE ddns_tokens ADD COLUMN token_hash TEXT NOT NULL DEFAULT ''`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_ddns_tokens_token_hash ON ddns_tokens(token_hash) WHERE token_hash != ''`,
+
+		// DDNS update history (one row per hostname and record type)
+		`CREATE TABLE IF NOT EXISTS ddns_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			token_id INTEGER NOT NULL DEFAULT 0,
+			protocol TEXT NOT NULL DEFAULT '',
+			hostname TEXT NOT NULL,
+			account_id INTEGER NOT NULL DEFAULT 0,
+			domain_id TEXT NOT NULL DEFAULT '',
+			domain_name TEXT NOT NULL DEFAULT '',
+			record_type TEXT NOT NULL DEFAULT '',
+			old_ip TEXT NOT NULL DEFAULT '',
+			new_ip TEXT NOT NULL DEFAULT '',
+			caller_ip TEXT NOT NULL DEFAULT '',
+			user_agent TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL,
+			error TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_ddns_history_user_created ON ddns_history(user_id, created_at DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_ddns_history_token_id ON ddns_history(token_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_ddns_history_hostname ON ddns_history(hostname)`,
+
+		// Last-known A/AAAA value per DDNS hostname, used to answer nochg
+		// without calling the provider. Kept honest by the reconcile job.
+		`CREATE TABLE IF NOT EXISTS ddns_record_state (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			hostname TEXT NOT NULL,
+			record_type TEXT NOT NULL,
+			account_id INTEGER NOT NULL,
+			domain_id TEXT NOT NULL,
+			domain_name TEXT NOT NULL DEFAULT '',
+			node_name TEXT NOT NULL DEFAULT '',
+			ip TEXT NOT NULL,
+			checked_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(user_id, hostname, record_type),
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+			FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_ddns_record_state_zone ON ddns_record_state(account_id, domain_id)`,
+
+		// Server-side DDNS agents (dns-mng detects its own public IP)
+		`CREATE TABLE IF NOT EXISTS ddns_agents (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			enabled INTEGER DEFAULT 1,
+			hostnames TEXT NOT NULL DEFAULT '',
+			ipv4_sources TEXT NOT NULL DEFAULT '',
+			ipv6_sources TEXT NOT NULL DEFAULT '',
+			interval_minutes INTEGER NOT NULL DEFAULT 5,
+			create_missing INTEGER DEFAULT 0,
+			last_run_at DATETIME,
+			last_ipv4 TEXT NOT NULL DEFAULT '',
+			last_ipv6 TEXT NOT NULL DEFAULT '',
+			last_status TEXT NOT NULL DEFAULT '',
+			last_error TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_ddns_agents_user_id ON ddns_agents(user_id)`,
 
 		// Login logs table
 		`CREATE TABLE IF NOT EXISTS login_logs (
@@ -246,6 +517,10 @@ func createTables() {
 		`CREATE INDEX IF NOT EXISTS idx_login_logs_username ON login_logs(username)`,
 		// Add IP location column to login_logs
 		`ALTER TABLE login_logs ADD COLUMN ip_location TEXT DEFAULT ''`,
+		// Country code for new-country login alerts
+		`ALTER TABLE login_logs ADD COLUMN country TEXT DEFAULT ''`,
+		// Session opened by a successful login, for the session list
+		`ALTER TABLE login_logs ADD COLUMN session_id INTEGER NOT NULL DEFAULT 0`,
 
 		// CF Optimize (CDN优选) table
 		`CREATE TABLE IF NOT EXISTS cf_optimize (
@@ -273,6 +548,15 @@ func createTables() {
 		`ALTER TABLE cf_optimize ADD COLUMN intermediate_record_name TEXT DEFAULT ''`,
 		`ALTER TABLE cf_optimize ADD COLUMN intermediate_record_id TEXT DEFAULT ''`,
 		`ALTER TABLE cf_optimize ADD COLUMN validation_record_ids TEXT DEFAULT ''`,
+		// 账户连接检测结果；failure_notified 记录凭据失效是否已通知
+		`ALTER TABLE accounts ADD COLUMN check_code TEXT DEFAULT ''`,
+		`ALTER TABLE accounts ADD COLUMN check_error TEXT DEFAULT ''`,
+		`ALTER TABLE accounts ADD COLUMN check_domains INTEGER DEFAULT 0`,
+		`ALTER TABLE accounts ADD COLUMN checked_at DATETIME`,
+		`ALTER TABLE accounts ADD COLUMN failing_since DATETIME`,
+		`ALTER TABLE accounts ADD COLUMN failure_notified INTEGER DEFAULT 0`,
+		// 结构化凭据（加密 JSON）；api_key 保留为按字段拼接的字符串供服务商调用，旧数据启动时迁移
+		`ALTER TABLE accounts ADD COLUMN credentials TEXT DEFAULT ''`,
 	}
 
 	for _, q := range queries {
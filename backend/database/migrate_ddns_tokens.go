@@ -0,0 +1,68 @@
+package database
+
+import (
+	"log"
+	"strings"
+)
+
+// MigrateDDNSTokensToMultiToken rebuilds ddns_tokens created by older
+// versions, where user_id was UNIQUE (one token per user), into the
+// multi-token schema. SQLite cannot drop a UNIQUE constraint in place, so
+// the table is copied into a new one and swapped. Existing tokens keep
+// their values and are named "default" with no scope restrictions.
+func MigrateDDNSTokensToMultiToken() error {
+	var createSQL string
+	err := DB.QueryRow(`SELECT sql FROM sqlite_master WHERE type='table' AND name='ddns_tokens'`).Scan(&createSQL)
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(createSQL, "user_id INTEGER NOT NULL UNIQUE") {
+		return nil
+	}
+
+	log.Println("Migrating ddns_tokens to multi-token schema...")
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	queries := []string{
+		`CREATE TABLE ddns_tokens_new (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			name TEXT NOT NULL DEFAULT '',
+			token TEXT NOT NULL UNIQUE,
+			token_hash TEXT NOT NULL DEFAULT '',
+			enabled INTEGER DEFAULT 1,
+			hostnames TEXT NOT NULL DEFAULT '',
+			record_types TEXT NOT NULL DEFAULT '',
+			allowed_ips TEXT NOT NULL DEFAULT '',
+			expires_at DATETIME,
+			last_used_at DATETIME,
+			last_ip TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
+		`INSERT INTO ddns_tokens_new (id, user_id, name, token, enabled, last_used_at, last_ip, created_at, updated_at)
+		 SELECT id, user_id, 'default', token, enabled, last_used_at, last_ip, created_at, updated_at FROM ddns_tokens`,
+		`DROP TABLE ddns_tokens`,
+		`ALTER TABLE ddns_tokens_new RENAME TO ddns_tokens`,
+		`CREATE INDEX IF NOT EXISTS idx_ddns_tokens_token ON ddns_tokens(token)`,
+		`CREATE INDEX IF NOT EXISTS idx_ddns_tokens_user_id ON ddns_tokens(user_id)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_ddns_tokens_token_hash ON ddns_tokens(token_hash) WHERE token_hash != ''`,
+	}
+	for _, q := range queries {
+		if _, err := tx.Exec(q); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	log.Println("ddns_tokens migration completed successfully")
+	return nil
+}
@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"dns-mng/middleware"
 	"dns-mng/models"
+	"dns-mng/provider"
 	"dns-mng/service"
 
 	"github.com/gin-gonic/gin"
@@ -32,9 +34,38 @@ func (h *AccountHandler) List(c *gin.Context) {
 	if accounts == nil {
 		accounts = []models.Account{}
 	}
+	for i := range accounts {
+		maskAccount(&accounts[i])
+		accounts[i].Health = provider.Health(accounts[i].ID)
+	}
 	c.JSON(http.StatusOK, accounts)
 }
 
+// GetAPIKey reveals the API key and credentials of one account
+func (h *AccountHandler) GetAPIKey(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	accountID, err := middleware.GetAccountID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account id"})
+		return
+	}
+
+	account, err := h.accountService.Get(userID, accountID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "account not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_key": account.APIKey, "credentials": account.Credentials})
+}
+
+// maskAccount hides the API key and secret credential fields; they are
+// only returned by GetAPIKey
+func maskAccount(account *models.Account) {
+	account.APIKey = service.MaskSecret(account.APIKey)
+	account.Credentials = service.MaskCredentials(account.ProviderType, account.Credentials)
+}
+
 func (h *AccountHandler) Create(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	var req models.CreateAccountRequest
@@ -43,12 +74,15 @@ func (h *AccountHandler) Create(c *gin.Context) {
 		return
 	}
 
-	account, err := h.accountService.Create(userID, &req)
+	account, err := h.accountService.Create(c.Request.Context(), userID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if !respondCredentialError(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
+	maskAccount(account)
 	c.JSON(http.StatusCreated, account)
 }
 
@@ -66,15 +100,78 @@ func (h *AccountHandler) Update(c *gin.Context) {
 		return
 	}
 
-	account, err := h.accountService.Update(userID, accountID, &req)
+	account, err := h.accountService.Update(c.Request.Context(), userID, accountID, &req)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		if !respondCredentialError(c, err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
+	maskAccount(account)
 	c.JSON(http.StatusOK, account)
 }
 
+// respondCredentialError answers a save whose credentials were malformed
+// (400, with the errors by field) or rejected by the provider (422) with
+// the check result, and an unknown provider type with 400. It reports
+// whether err was one of those.
+func respondCredentialError(c *gin.Context, err error) bool {
+	var ce *service.CredentialError
+	switch {
+	case errors.As(err, &ce):
+		status := http.StatusUnprocessableEntity
+		if ce.Check.Code == models.AccountCheckInvalidFormat {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": ce.Error(), "code": ce.Check.Code, "fields": ce.Check.Fields, "check": ce.Check})
+	case errors.Is(err, service.ErrUnknownProvider):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
+
+// Test checks credentials before an account is saved
+func (h *AccountHandler) Test(c *gin.Context) {
+	var req models.TestAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	check, err := h.accountService.TestCredentials(c.Request.Context(), req.ProviderType, req.Credentials, req.APIKey)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, check)
+}
+
+// TestAccount checks a saved account's credentials, or the replacement
+// api_key in the body. A test of the stored credentials is recorded on the
+// account.
+func (h *AccountHandler) TestAccount(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	accountID, err := middleware.GetAccountID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account id"})
+		return
+	}
+
+	var req models.TestAccountRequest
+	// The body is optional
+	_ = c.ShouldBindJSON(&req)
+
+	check, err := h.accountService.TestAccount(c.Request.Context(), userID, accountID, req.Credentials, req.APIKey)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, check)
+}
+
 func (h *AccountHandler) Delete(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	accountID, err := middleware.GetAccountID(c)
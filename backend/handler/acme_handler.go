@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"dns-mng/middleware"
@@ -27,9 +28,9 @@ func (h *AcmeHandler) Present(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.acmeService.Present(c.Request.Context(), userID, &req)
+	resp, err := h.acmeService.Present(c.Request.Context(), userID, &req, middleware.GetAPIToken(c))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondAcmeError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, resp)
@@ -44,11 +45,19 @@ func (h *AcmeHandler) Cleanup(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.acmeService.Cleanup(c.Request.Context(), userID, &req)
+	resp, err := h.acmeService.Cleanup(c.Request.Context(), userID, &req, middleware.GetAPIToken(c))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondAcmeError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, resp)
 }
 
+// respondAcmeError maps token scope errors to 403 and everything else to 400
+func respondAcmeError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrAPITokenScope) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
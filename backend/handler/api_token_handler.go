@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"dns-mng/middleware"
+	"dns-mng/models"
+	"dns-mng/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APITokenHandler manages the current user's personal API tokens
+type APITokenHandler struct {
+	apiTokenService *service.APITokenService
+}
+
+func NewAPITokenHandler(apiTokenService *service.APITokenService) *APITokenHandler {
+	return &APITokenHandler{apiTokenService: apiTokenService}
+}
+
+// respondAPITokenError maps API token errors to status codes
+func respondAPITokenError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrAPITokenNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidAPITokenScope):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// List returns the current user's API tokens without their values
+func (h *APITokenHandler) List(c *gin.Context) {
+	tokens, err := h.apiTokenService.ListTokens(middleware.GetUserID(c))
+	if err != nil {
+		respondAPITokenError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Create issues a token; the value is only in this response
+func (h *APITokenHandler) Create(c *gin.Context) {
+	var req models.CreateAPITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, err := h.apiTokenService.CreateToken(middleware.GetUserID(c), &req)
+	if err != nil {
+		respondAPITokenError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, token)
+}
+
+// Delete revokes a token
+func (h *APITokenHandler) Delete(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.apiTokenService.DeleteToken(middleware.GetUserID(c), id); err != nil {
+		respondAPITokenError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "api token deleted"})
+}
@@ -1,9 +1,13 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
 
+	"dns-mng/middleware"
 	"dns-mng/models"
 	"dns-mng/service"
 
@@ -11,14 +15,16 @@ import (
 )
 
 type AuthHandler struct {
-	userService *service.UserService
-	logService  *service.LogService
+	userService     *service.UserService
+	logService      *service.LogService
+	notifierService *service.NotifierService
 }
 
-func NewAuthHandler(userService *service.UserService, logService *service.LogService) *AuthHandler {
+func NewAuthHandler(userService *service.UserService, logService *service.LogService, notifierService *service.NotifierService) *AuthHandler {
 	return &AuthHandler{
-		userService: userService,
-		logService:  logService,
+		userService:     userService,
+		logService:      logService,
+		notifierService: notifierService,
 	}
 }
 
@@ -29,8 +35,12 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.userService.Register(&req)
+	resp, err := h.userService.Register(&req, sessionClient(c))
 	if err != nil {
+		if errors.Is(err, service.ErrRegistrationDisabled) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -38,6 +48,16 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	c.JSON(http.StatusCreated, resp)
 }
 
+// RegistrationStatus tells the login page whether sign-up is available
+func (h *AuthHandler) RegistrationStatus(c *gin.Context) {
+	status, err := h.userService.RegistrationStatus()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req models.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -45,52 +65,148 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	ip := c.ClientIP()
-	ua := c.Request.UserAgent()
-	device := service.ParseDevice(ua)
+	client := sessionClient(c)
+	loginLog := &models.LoginLog{
+		Username:  req.Username,
+		IPAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+		Device:    client.Device,
+	}
 
-	resp, err := h.userService.Login(&req)
+	resp, err := h.userService.Login(&req, client)
 	if err != nil {
-		// Record failed login
-		loginLog := &models.LoginLog{
-			Username:  req.Username,
-			IPAddress: ip,
-			UserAgent: ua,
-			Device:    device,
-			Status:    "failed",
-			Message:   err.Error(),
+		// The password was right; the client asks for the 2FA code next
+		if errors.Is(err, service.ErrTOTPRequired) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error(), "totp_required": true})
+			return
 		}
-		go func() {
-			if geoInfo := service.IPLookup(ip); geoInfo != nil {
-				loginLog.IPLocation = service.FormatLocation(geoInfo)
-			}
-			if e := h.logService.CreateLoginLog(loginLog); e != nil {
-				log.Printf("Failed to create login log for %s: %v", req.Username, e)
-			}
-		}()
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+
+		loginLog.Status = "failed"
+		loginLog.Message = err.Error()
+		status := http.StatusUnauthorized
+		var locked *service.LoginLockedError
+		switch {
+		case errors.As(err, &locked):
+			loginLog.Status = "blocked"
+			status = http.StatusTooManyRequests
+			c.Header("Retry-After", strconv.Itoa(int(locked.RetryAfter.Seconds())+1))
+		case errors.Is(err, service.ErrUserDisabled):
+			status = http.StatusForbidden
+		}
+		go h.recordLogin(loginLog)
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Record successful login
-	loginLog := &models.LoginLog{
-		UserID:    resp.User.ID,
-		Username:  req.Username,
-		IPAddress: ip,
+	loginLog.UserID = resp.User.ID
+	loginLog.SessionID = resp.SessionID
+	loginLog.Status = "success"
+	go h.recordLogin(loginLog)
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// recordLogin resolves the IP location and writes the login log. A
+// successful login from a new device or country triggers a notification.
+func (h *AuthHandler) recordLogin(loginLog *models.LoginLog) {
+	if geoInfo := service.IPLookup(loginLog.IPAddress); geoInfo != nil {
+		loginLog.IPLocation = service.FormatLocation(geoInfo)
+		loginLog.Country = geoInfo.CountryCode
+	}
+
+	var newDevice, newCountry bool
+	if loginLog.Status == "success" {
+		var err error
+		newDevice, newCountry, err = h.logService.UnfamiliarLogin(loginLog.UserID, loginLog.Device, loginLog.Country)
+		if err != nil {
+			log.Printf("Failed to check login history for %s: %v", loginLog.Username, err)
+		}
+	}
+
+	if err := h.logService.CreateLoginLog(loginLog); err != nil {
+		log.Printf("Failed to create login log for %s: %v", loginLog.Username, err)
+	}
+	if newDevice || newCountry {
+		h.notifierService.NotifyNewLogin(context.Background(), loginLog, newDevice, newCountry)
+	}
+}
+
+// sessionClient describes the caller for a new session
+func sessionClient(c *gin.Context) *models.SessionClient {
+	ua := c.Request.UserAgent()
+	return &models.SessionClient{
+		IPAddress: c.ClientIP(),
+		TrustedIP: middleware.TrustedClientIP(c),
 		UserAgent: ua,
-		Device:    device,
-		Status:    "success",
+		Device:    service.ParseDevice(ua),
 	}
-	go func() {
-		if geoInfo := service.IPLookup(ip); geoInfo != nil {
-			loginLog.IPLocation = service.FormatLocation(geoInfo)
+}
+
+// Refresh exchanges a refresh token for new access and refresh tokens
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.userService.Refresh(req.RefreshToken, c.ClientIP())
+	if err != nil {
+		status := http.StatusUnauthorized
+		if !errors.Is(err, service.ErrInvalidRefreshToken) && !errors.Is(err, service.ErrUserDisabled) {
+			status = http.StatusInternalServerError
 		}
-		if e := h.logService.CreateLoginLog(loginLog); e != nil {
-			log.Printf("Failed to create login log for %s: %v", req.Username, e)
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Logout ends the current session
+func (h *AuthHandler) Logout(c *gin.Context) {
+	err := h.userService.RevokeSession(c.GetInt64("user_id"), c.GetInt64("session_id"))
+	if err != nil && !errors.Is(err, service.ErrSessionNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+// ListSessions returns the current user's signed-in sessions
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	sessions, err := h.userService.ListSessions(c.GetInt64("user_id"), c.GetInt64("session_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession signs out one of the current user's sessions
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userService.RevokeSession(c.GetInt64("user_id"), id); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
 		}
-	}()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "session revoked"})
+}
 
-	c.JSON(http.StatusOK, resp)
+// RevokeAllSessions logs the current user out everywhere, including here
+func (h *AuthHandler) RevokeAllSessions(c *gin.Context) {
+	if err := h.userService.RevokeSessions(c.GetInt64("user_id"), 0); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "all sessions revoked"})
 }
 
 func (h *AuthHandler) GetProfile(c *gin.Context) {
@@ -114,10 +230,90 @@ func (h *AuthHandler) UpdatePassword(c *gin.Context) {
 		return
 	}
 
-	if err := h.userService.UpdatePassword(userID, &req); err != nil {
+	if err := h.userService.UpdatePassword(userID, c.GetInt64("session_id"), &req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Password updated successfully"})
 }
+
+// respondTOTPError maps two-factor errors to status codes
+func respondTOTPError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidTOTP), errors.Is(err, service.ErrTOTPRequired),
+		errors.Is(err, service.ErrTOTPNotEnabled), errors.Is(err, service.ErrTOTPAlreadyEnabled),
+		errors.Is(err, service.ErrTOTPNotSetUp):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetTOTP returns whether 2FA is enabled and how many recovery codes remain
+func (h *AuthHandler) GetTOTP(c *gin.Context) {
+	status, err := h.userService.TOTPStatus(c.GetInt64("user_id"))
+	if err != nil {
+		respondTOTPError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// SetupTOTP starts enrollment and returns the secret for the authenticator app
+func (h *AuthHandler) SetupTOTP(c *gin.Context) {
+	setup, err := h.userService.SetupTOTP(c.GetInt64("user_id"))
+	if err != nil {
+		respondTOTPError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, setup)
+}
+
+// EnableTOTP confirms enrollment with a code and returns the recovery codes
+func (h *AuthHandler) EnableTOTP(c *gin.Context) {
+	var req models.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.userService.EnableTOTP(c.GetInt64("user_id"), req.Code)
+	if err != nil {
+		respondTOTPError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, codes)
+}
+
+func (h *AuthHandler) DisableTOTP(c *gin.Context) {
+	var req models.DisableTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.userService.DisableTOTP(c.GetInt64("user_id"), &req); err != nil {
+		respondTOTPError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes after checking a code
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req models.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.userService.RegenerateRecoveryCodes(c.GetInt64("user_id"), req.Code)
+	if err != nil {
+		respondTOTPError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, codes)
+}
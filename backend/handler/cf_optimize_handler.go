@@ -31,7 +31,7 @@ func (h *CFOptimizeHandler) Create(c *gin.Context) {
 
 	config, err := h.cfOptimizeService.Create(c.Request.Context(), userID, req.AccountID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondCapabilityError(c, err)
 		return
 	}
 
@@ -67,7 +67,7 @@ func (h *CFOptimizeHandler) Refresh(c *gin.Context) {
 
 	config, err := h.cfOptimizeService.Refresh(c.Request.Context(), userID, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondCapabilityError(c, err)
 		return
 	}
 
@@ -88,7 +88,7 @@ func (h *CFOptimizeHandler) Delete(c *gin.Context) {
 	cleanup := c.Query("cleanup") != "false"
 
 	if err := h.cfOptimizeService.Delete(c.Request.Context(), userID, id, cleanup); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondCapabilityError(c, err)
 		return
 	}
 
@@ -113,7 +113,7 @@ func (h *CFOptimizeHandler) Update(c *gin.Context) {
 
 	config, err := h.cfOptimizeService.Update(c.Request.Context(), userID, id, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondCapabilityError(c, err)
 		return
 	}
 
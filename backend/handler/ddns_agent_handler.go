@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"dns-mng/middleware"
+	"dns-mng/models"
+	"dns-mng/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DDNSAgentHandler struct {
+	ddnsAgentService    *service.DDNSAgentService
+	schedulerLogService *service.SchedulerLogService
+}
+
+func NewDDNSAgentHandler(ddnsAgentService *service.DDNSAgentService, schedulerLogService *service.SchedulerLogService) *DDNSAgentHandler {
+	return &DDNSAgentHandler{
+		ddnsAgentService:    ddnsAgentService,
+		schedulerLogService: schedulerLogService,
+	}
+}
+
+// List lists the DDNS agents of the current user
+func (h *DDNSAgentHandler) List(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	agents, err := h.ddnsAgentService.List(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list agents: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, agents)
+}
+
+// Create creates a DDNS agent
+func (h *DDNSAgentHandler) Create(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req models.CreateDDNSAgentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	agent, err := h.ddnsAgentService.Create(userID, &req)
+	if err != nil {
+		respondDDNSAgentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, agent)
+}
+
+// Update updates a DDNS agent
+func (h *DDNSAgentHandler) Update(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.UpdateDDNSAgentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	agent, err := h.ddnsAgentService.Update(userID, id, &req)
+	if err != nil {
+		respondDDNSAgentError(c, err)
+		return
+	}
+	if agent == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "agent not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, agent)
+}
+
+// Delete deletes a DDNS agent
+func (h *DDNSAgentHandler) Delete(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.ddnsAgentService.Delete(userID, id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// Run runs a DDNS agent immediately and returns the outcome
+func (h *DDNSAgentHandler) Run(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	agent, err := h.ddnsAgentService.Get(userID, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if agent == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "agent not found"})
+		return
+	}
+
+	result := h.ddnsAgentService.Run(c.Request.Context(), agent, "manual", h.schedulerLogService)
+	c.JSON(http.StatusOK, result)
+}
+
+func respondDDNSAgentError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidDDNSAgent) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
@@ -1,9 +1,13 @@
 package handler
 
 import (
+	"fmt"
+	"log"
+	"net"
 	"net/http"
 	"strings"
 
+	"dns-mng/middleware"
 	"dns-mng/models"
 	"dns-mng/service"
 
@@ -11,18 +15,18 @@ import (
 )
 
 type DDNSHandler struct {
-	dnsService       *service.DNSService
-	accountService   *service.AccountService
-	logService       *service.LogService
-	ddnsTokenService *service.DDNSTokenService
+	ddnsService        *service.DDNSService
+	logService         *service.LogService
+	ddnsTokenService   *service.DDNSTokenService
+	ddnsHistoryService *service.DDNSHistoryService
 }
 
-func NewDDNSHandler(dnsService *service.DNSService, accountService *service.AccountService, logService *service.LogService, ddnsTokenService *service.DDNSTokenService) *DDNSHandler {
+func NewDDNSHandler(ddnsService *service.DDNSService, logService *service.LogService, ddnsTokenService *service.DDNSTokenService, ddnsHistoryService *service.DDNSHistoryService) *DDNSHandler {
 	return &DDNSHandler{
-		dnsService:       dnsService,
-		accountService:   accountService,
-		logService:       logService,
-		ddnsTokenService: ddnsTokenService,
+		ddnsService:        ddnsService,
+		logService:         logService,
+		ddnsTokenService:   ddnsTokenService,
+		ddnsHistoryService: ddnsHistoryService,
 	}
 }
 
@@ -30,9 +34,14 @@ func NewDDNSHandler(dnsService *service.DNSService, accountService *service.Acco
 // Compatible with DuckDNS API format
 // Query parameters:
 // - token: authentication token (user-level)
-// - domains: required, comma-separated domain names to update
+// - domains: required, comma-separated hostnames (e.g. home.example.com,nas.example.com)
 // - ip: optional IPv4 address (if not provided, uses client IP)
 // - ipv6: optional IPv6 address
+// - create: optional, "true" creates missing A/AAAA records
+// - verbose: optional, "true" appends the addresses and per-record details
+//
+// Each hostname is matched to its zone by longest suffix and only that
+// node's A/AAAA records are updated; a bare zone name updates the apex.
 func (h *DDNSHandler) UpdateDDNS(c *gin.Context) {
 	tokenValue := c.Query("token")
 	if tokenValue == "" {
@@ -52,7 +61,7 @@ func (h *DDNSHandler) UpdateDDNS(c *gin.Context) {
 		return
 	}
 
-	if !token.Enabled {
+	if err := h.ddnsTokenService.Authorize(token, middleware.TrustedClientIP(c)); err != nil {
 		c.String(http.StatusForbidden, "KO")
 		return
 	}
@@ -71,12 +80,16 @@ func (h *DDNSHandler) UpdateDDNS(c *gin.Context) {
 	}
 
 	// Get IP address
-	ip := c.Query("ip")
-	ipv6 := c.Query("ipv6")
+	ip, ok4 := parseFamilyIP(c.Query("ip"), false)
+	ipv6, ok6 := parseFamilyIP(c.Query("ipv6"), true)
+	if !ok4 || !ok6 {
+		c.String(http.StatusBadRequest, "KO")
+		return
+	}
 
 	// If no IP provided, use client IP
 	if ip == "" && ipv6 == "" {
-		clientIP := c.ClientIP()
+		clientIP := middleware.TrustedClientIP(c)
 		if strings.Contains(clientIP, ":") {
 			ipv6 = clientIP
 		} else {
@@ -89,111 +102,264 @@ func (h *DDNSHandler) UpdateDDNS(c *gin.Context) {
 		return
 	}
 
-	userID := token.UserID
+	create := c.Query("create") == "true"
+	caller := h.caller(c, token, models.DDNSProtocolDuckDNS)
 
-	// Track updated domains and records for logging
-	updatedDomains := []string{}
-	updatedRecords := []map[string]interface{}{}
-	skippedDomains := []string{}
-
-	// Update each domain
-	for _, domainName := range domains {
-		domainName = strings.TrimSpace(domainName)
-		if domainName == "" {
+	// Update each hostname; any host that cannot be resolved or fails to
+	// update turns the whole response into KO, like DuckDNS.
+	ok := true
+	var results []*models.DDNSHostResult
+	for _, hostname := range domains {
+		hostname = strings.TrimSpace(hostname)
+		if hostname == "" {
 			continue
 		}
 
-		domainUpdated := false
-
-		// Find the domain and update its records
-		// Get all accounts for this user
-		accounts, err := h.accountService.List(userID)
-		if err != nil {
-			continue
+		result := h.updateHost(c, token, caller, &models.DDNSUpdateRequest{
+			Hostname: hostname,
+			IPv4:     ip,
+			IPv6:     ipv6,
+			Create:   create,
+		})
+		switch result.Status {
+		case models.DDNSStatusNoHost, models.DDNSStatusError, models.DDNSStatusDenied:
+			ok = false
 		}
+		results = append(results, result)
+	}
 
-		for _, account := range accounts {
-			domainList, err := h.dnsService.ListDomains(c.Request.Context(), userID, account.ID)
-			if err != nil {
-				continue
-			}
+	// Update last used timestamp
+	h.ddnsTokenService.UpdateLastUsed(tokenValue, middleware.TrustedClientIP(c))
 
-			for _, domain := range domainList {
-				if domain.Name == domainName || domain.Name == domainName+"." {
-					// Found the domain, update its records
-					records, err := h.dnsService.ListRecords(c.Request.Context(), userID, account.ID, domain.ID)
-					if err != nil {
-						continue
-					}
-
-					for _, record := range records {
-						if record.RecordType == "A" && ip != "" && record.Content != ip {
-							_, err = h.dnsService.UpdateRecord(
-								c.Request.Context(),
-								userID,
-								account.ID,
-								domain.ID,
-								record.ID,
-								&models.UpdateRecordRequest{
-									NodeName:   record.NodeName,
-									RecordType: record.RecordType,
-									TTL:        record.TTL,
-									State:      &record.State,
-									Content:    ip,
-									Priority:   record.Priority,
-								},
-							)
-							if err == nil {
-								domainUpdated = true
-								updatedRecords = append(updatedRecords, map[string]interface{}{
-									"domain":    domain.Name,
-									"type":      "A",
-									"node_name": record.NodeName,
-									"old_ip":    record.Content,
-									"new_ip":    ip,
-								})
-							}
-						} else if record.RecordType == "AAAA" && ipv6 != "" && record.Content != ipv6 {
-							_, err = h.dnsService.UpdateRecord(
-								c.Request.Context(),
-								userID,
-								account.ID,
-								domain.ID,
-								record.ID,
-								&models.UpdateRecordRequest{
-									NodeName:   record.NodeName,
-									RecordType: record.RecordType,
-									TTL:        record.TTL,
-									State:      &record.State,
-									Content:    ipv6,
-									Priority:   record.Priority,
-							},
-							)
-							if err == nil {
-								domainUpdated = true
-								updatedRecords = append(updatedRecords, map[string]interface{}{
-									"domain":    domain.Name,
-									"type":      "AAAA",
-									"node_name": record.NodeName,
-									"old_ip":    record.Content,
-									"new_ip":    ipv6,
-								})
-							}
-						}
-					}
-				}
+	status := "OK"
+	if !ok {
+		status = "KO"
+	}
+	if c.Query("verbose") == "true" {
+		c.String(http.StatusOK, verboseDDNSResponse(status, ip, ipv6, results))
+		return
+	}
+	c.String(http.StatusOK, status)
+}
+
+// verboseDDNSResponse renders the DuckDNS verbose format (status, IPv4, IPv6,
+// UPDATED/NOCHANGE) followed by one line per touched record or failed host.
+func verboseDDNSResponse(status, ip, ipv6 string, results []*models.DDNSHostResult) string {
+	changed := "NOCHANGE"
+	var details []string
+	for _, r := range results {
+		if len(r.Changes) > 0 {
+			changed = "UPDATED"
+		}
+		for _, ch := range r.Changes {
+			details = append(details, fmt.Sprintf("%s %s %s %s -> %s", r.Hostname, ch.RecordType, r.Status, ch.OldIP, ch.NewIP))
+		}
+		if len(r.Changes) == 0 {
+			line := r.Hostname + " " + r.Status
+			if r.Error != "" {
+				line += " " + r.Error
 			}
+			details = append(details, line)
+		}
+	}
+	lines := append([]string{status, ip, ipv6, changed}, details...)
+	return strings.Join(lines, "\n")
+}
+
+// caller describes the current request for the DDNS history
+func (h *DDNSHandler) caller(c *gin.Context, token *models.DDNSToken, protocol string) *models.DDNSCaller {
+	return &models.DDNSCaller{
+		UserID:    token.UserID,
+		TokenID:   token.ID,
+		Protocol:  protocol,
+		IP:        middleware.TrustedClientIP(c),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+// updateHost applies the token's scope, updates one hostname and records
+// the outcome in the DDNS history.
+func (h *DDNSHandler) updateHost(c *gin.Context, token *models.DDNSToken, caller *models.DDNSCaller, req *models.DDNSUpdateRequest) *models.DDNSHostResult {
+	var result *models.DDNSHostResult
+	if hostIP, hostIPv6, allowed := h.scopeAddresses(token, req.Hostname, req.IPv4, req.IPv6); allowed {
+		req.IPv4, req.IPv6 = hostIP, hostIPv6
+		result = h.ddnsService.UpdateHost(c.Request.Context(), token.UserID, req)
+	} else {
+		result = &models.DDNSHostResult{
+			Hostname: req.Hostname,
+			Status:   models.DDNSStatusDenied,
+			Error:    "hostname or record type not allowed for this token",
 		}
+	}
+
+	if err := h.ddnsHistoryService.Record(caller, req, result); err != nil {
+		log.Printf("Failed to record ddns history for %s: %v", req.Hostname, err)
+	}
+	return result
+}
+
+// maxNicUpdateHosts mirrors the DynDNS2 limit on hostnames per request;
+// exceeding it yields "numhost".
+const maxNicUpdateHosts = 20
+
+// NicUpdate implements the DynDNS2 / No-IP update protocol used by most
+// routers (OpenWrt, FritzBox, UniFi, pfSense, ddclient, inadyn).
+// Authentication: HTTP Basic Auth, password is the DDNS token (username is ignored)
+// Query parameters:
+// - hostname: required, comma-separated fully-qualified hostnames
+// - myip: optional, IPv4 and/or IPv6 address (comma-separated); defaults to the client IP
+// - myipv6: optional IPv6 address (sent by some clients instead of a second myip entry)
+//
+// The response body holds one line per hostname: "good <ip>", "nochg <ip>",
+// "nohost", "notfqdn", "!yours" (outside the token's scope) or "911".
+// Authentication failures return "badauth"; a myip or myipv6 that is not an
+// address, or no address at all when the client IP cannot be trusted,
+// returns "badagent".
+func (h *DDNSHandler) NicUpdate(c *gin.Context) {
+	_, tokenValue, ok := c.Request.BasicAuth()
+	if !ok || tokenValue == "" {
+		c.Header("WWW-Authenticate", `Basic realm="dns-mng"`)
+		c.String(http.StatusUnauthorized, "badauth")
+		return
+	}
+
+	token, err := h.ddnsTokenService.GetTokenByValue(tokenValue)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "911")
+		return
+	}
+	if token == nil || h.ddnsTokenService.Authorize(token, middleware.TrustedClientIP(c)) != nil {
+		c.Header("WWW-Authenticate", `Basic realm="dns-mng"`)
+		c.String(http.StatusUnauthorized, "badauth")
+		return
+	}
+
+	var hostnames []string
+	for _, hostname := range strings.Split(c.Query("hostname"), ",") {
+		if hostname = strings.TrimSpace(hostname); hostname != "" {
+			hostnames = append(hostnames, hostname)
+		}
+	}
+	if len(hostnames) == 0 {
+		c.String(http.StatusOK, "notfqdn")
+		return
+	}
+	if len(hostnames) > maxNicUpdateHosts {
+		c.String(http.StatusOK, "numhost")
+		return
+	}
 
-		if domainUpdated {
-			updatedDomains = append(updatedDomains, domainName)
+	ip, ipv6, ok := parseNicUpdateIPs(c.Query("myip"), c.Query("myipv6"))
+	if !ok {
+		c.String(http.StatusBadRequest, "badagent")
+		return
+	}
+	if ip == "" && ipv6 == "" {
+		clientIP := middleware.TrustedClientIP(c)
+		if strings.Contains(clientIP, ":") {
+			ipv6 = clientIP
 		} else {
-			skippedDomains = append(skippedDomains, domainName)
+			ip = clientIP
 		}
 	}
+	if ip == "" && ipv6 == "" {
+		c.String(http.StatusBadRequest, "badagent")
+		return
+	}
 
-	// Update last used timestamp
-	h.ddnsTokenService.UpdateLastUsed(tokenValue, ip)
+	var addrs []string
+	for _, a := range []string{ip, ipv6} {
+		if a != "" {
+			addrs = append(addrs, a)
+		}
+	}
+	addrList := strings.Join(addrs, ",")
+
+	caller := h.caller(c, token, models.DDNSProtocolDynDNS2)
+	lines := make([]string, 0, len(hostnames))
+	for _, hostname := range hostnames {
+		if !strings.Contains(strings.Trim(hostname, "."), ".") {
+			lines = append(lines, "notfqdn")
+			continue
+		}
+
+		result := h.updateHost(c, token, caller, &models.DDNSUpdateRequest{
+			Hostname: hostname,
+			IPv4:     ip,
+			IPv6:     ipv6,
+		})
+		lines = append(lines, nicUpdateLine(result.Status, addrList))
+	}
 
-	c.String(http.StatusOK, "OK")
+	h.ddnsTokenService.UpdateLastUsed(tokenValue, middleware.TrustedClientIP(c))
+
+	c.String(http.StatusOK, strings.Join(lines, "\n"))
+}
+
+// nicUpdateLine maps a host update status to its DynDNS2 return code
+func nicUpdateLine(status, addrList string) string {
+	switch status {
+	case models.DDNSStatusDenied:
+		return "!yours"
+	case models.DDNSStatusUpdated, models.DDNSStatusCreated:
+		return "good " + addrList
+	case models.DDNSStatusNoChg:
+		return "nochg " + addrList
+	case models.DDNSStatusNoHost:
+		return "nohost"
+	}
+	return "911"
+}
+
+// parseNicUpdateIPs splits the DynDNS2 myip/myipv6 parameters into one IPv4
+// and one IPv6 address. ok is false when an entry is not an address; empty
+// entries are skipped.
+func parseNicUpdateIPs(myip, myipv6 string) (ipv4, ipv6 string, ok bool) {
+	for _, raw := range strings.Split(myip+","+myipv6, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		parsed := net.ParseIP(raw)
+		if parsed == nil {
+			return "", "", false
+		}
+		if parsed.To4() != nil {
+			if ipv4 == "" {
+				ipv4 = parsed.String()
+			}
+		} else if ipv6 == "" {
+			ipv6 = parsed.String()
+		}
+	}
+	return ipv4, ipv6, true
+}
+
+// parseFamilyIP normalizes a DuckDNS ip or ipv6 parameter. An empty value
+// is valid; anything else must be an address of the requested family.
+func parseFamilyIP(raw string, v6 bool) (string, bool) {
+	if raw == "" {
+		return "", true
+	}
+	parsed := net.ParseIP(strings.TrimSpace(raw))
+	if parsed == nil || (parsed.To4() == nil) != v6 {
+		return "", false
+	}
+	return parsed.String(), true
+}
+
+// scopeAddresses applies the token's hostname and record-type restrictions.
+// Addresses of a record type the token may not touch are dropped; allowed is
+// false when the hostname is out of scope or no address remains.
+func (h *DDNSHandler) scopeAddresses(token *models.DDNSToken, hostname, ip, ipv6 string) (string, string, bool) {
+	if !h.ddnsTokenService.AllowsHostname(token, hostname) {
+		return "", "", false
+	}
+	if !h.ddnsTokenService.AllowsRecordType(token, "A") {
+		ip = ""
+	}
+	if !h.ddnsTokenService.AllowsRecordType(token, "AAAA") {
+		ipv6 = ""
+	}
+	return ip, ipv6, ip != "" || ipv6 != ""
 }
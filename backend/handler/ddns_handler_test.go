@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"dns-mng/database"
+	"dns-mng/models"
+	"dns-mng/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseNicUpdateIPs(t *testing.T) {
+	tests := []struct {
+		myip, myipv6 string
+		ipv4, ipv6   string
+		ok           bool
+	}{
+		{"", "", "", "", true},
+		{"192.0.2.1", "", "192.0.2.1", "", true},
+		{"2001:db8::1", "", "", "2001:db8::1", true},
+		{"192.0.2.1,2001:db8::1", "", "192.0.2.1", "2001:db8::1", true},
+		{" 192.0.2.1 , 2001:DB8:0::1 ", "", "192.0.2.1", "2001:db8::1", true},
+		{"192.0.2.1", "2001:db8::2", "192.0.2.1", "2001:db8::2", true},
+		// FritzBox leaves <ip6addr> empty on IPv4-only lines
+		{"192.0.2.1,", "", "192.0.2.1", "", true},
+		// The first address of each family wins
+		{"192.0.2.1,192.0.2.2", "", "192.0.2.1", "", true},
+		{"2001:db8::1", "2001:db8::2", "", "2001:db8::1", true},
+		{"::ffff:192.0.2.4", "", "192.0.2.4", "", true},
+		// Garbage is rejected rather than replaced by the client IP
+		{"not-an-ip", "", "", "", false},
+		{"999.0.0.1,192.0.2.3", "", "", "", false},
+		{"192.0.2.3", "fe80::zz", "", "", false},
+	}
+
+	for _, tt := range tests {
+		ipv4, ipv6, ok := parseNicUpdateIPs(tt.myip, tt.myipv6)
+		if ipv4 != tt.ipv4 || ipv6 != tt.ipv6 || ok != tt.ok {
+			t.Errorf("parseNicUpdateIPs(%q, %q) = %q, %q, %v; want %q, %q, %v", tt.myip, tt.myipv6, ipv4, ipv6, ok, tt.ipv4, tt.ipv6, tt.ok)
+		}
+	}
+}
+
+func TestNicUpdateLine(t *testing.T) {
+	tests := []struct {
+		status string
+		want   string
+	}{
+		{models.DDNSStatusUpdated, "good 192.0.2.1,2001:db8::1"},
+		{models.DDNSStatusCreated, "good 192.0.2.1,2001:db8::1"},
+		{models.DDNSStatusNoChg, "nochg 192.0.2.1,2001:db8::1"},
+		{models.DDNSStatusNoHost, "nohost"},
+		{models.DDNSStatusDenied, "!yours"},
+		{models.DDNSStatusError, "911"},
+		{"", "911"},
+	}
+
+	for _, tt := range tests {
+		if got := nicUpdateLine(tt.status, "192.0.2.1,2001:db8::1"); got != tt.want {
+			t.Errorf("nicUpdateLine(%q) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestNicUpdateWithoutCredentials(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/nic/update", (&DDNSHandler{}).NicUpdate)
+
+	for _, password := range []string{"", "-"} {
+		req := httptest.NewRequest(http.MethodGet, "/nic/update?hostname=home.example.com", nil)
+		if password != "-" {
+			req.SetBasicAuth("user", password)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized || w.Body.String() != "badauth" {
+			t.Errorf("password %q: %d %q, want 401 badauth", password, w.Code, w.Body.String())
+		}
+		if w.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("password %q: missing WWW-Authenticate", password)
+		}
+	}
+}
+
+func TestNicUpdateInvalidMyIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	database.Init(filepath.Join(t.TempDir(), "test.db"))
+	t.Cleanup(database.Close)
+	if _, err := database.DB.Exec(`INSERT INTO users (id, username, password_hash) VALUES (1, 'admin', 'x')`); err != nil {
+		t.Fatal(err)
+	}
+	tokens := service.NewDDNSTokenService()
+	if _, err := tokens.CreateToken(1, "nic-update-test-token"); err != nil {
+		t.Fatalf("CreateToken: %v", err)
+	}
+
+	r := gin.New()
+	r.GET("/nic/update", NewDDNSHandler(nil, nil, tokens, nil).NicUpdate)
+
+	for _, query := range []string{"myip=not-an-ip", "myip=192.0.2.1,999.0.0.1", "myip=192.0.2.1&myipv6=fe80::zz"} {
+		req := httptest.NewRequest(http.MethodGet, "/nic/update?hostname=home.example.com&"+query, nil)
+		req.SetBasicAuth("user", "nic-update-test-token")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest || w.Body.String() != "badagent" {
+			t.Errorf("%s: %d %q, want 400 badagent", query, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestParseFamilyIP(t *testing.T) {
+	tests := []struct {
+		raw  string
+		v6   bool
+		want string
+		ok   bool
+	}{
+		{"", false, "", true},
+		{"", true, "", true},
+		{"192.0.2.1", false, "192.0.2.1", true},
+		{"2001:DB8::1", true, "2001:db8::1", true},
+		{"2001:db8::1", false, "", false},
+		{"192.0.2.1", true, "", false},
+		{"1.2.3", false, "", false},
+		{"<script>", true, "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseFamilyIP(tt.raw, tt.v6)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseFamilyIP(%q, %v) = %q, %v; want %q, %v", tt.raw, tt.v6, got, ok, tt.want, tt.ok)
+		}
+	}
+}
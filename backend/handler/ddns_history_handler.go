@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"dns-mng/middleware"
+	"dns-mng/models"
+	"dns-mng/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DDNSHistoryHandler struct {
+	ddnsHistoryService *service.DDNSHistoryService
+}
+
+func NewDDNSHistoryHandler(ddnsHistoryService *service.DDNSHistoryService) *DDNSHistoryHandler {
+	return &DDNSHistoryHandler{ddnsHistoryService: ddnsHistoryService}
+}
+
+// GetHistory lists DDNS update history for the current user
+// Query parameters: token_id, hostname, status, from, to, page, page_size
+func (h *DDNSHistoryHandler) GetHistory(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	var tokenID int64
+	if v := c.Query("token_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid token_id"})
+			return
+		}
+		tokenID = id
+	}
+
+	response, err := h.ddnsHistoryService.List(userID, &models.DDNSHistoryQuery{
+		TokenID:  tokenID,
+		Hostname: c.Query("hostname"),
+		Status:   c.Query("status"),
+		From:     c.Query("from"),
+		To:       c.Query("to"),
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidDDNSHistoryQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Failed to get ddns history for user_id=%d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get DDNS history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -1,7 +1,10 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"dns-mng/middleware"
 	"dns-mng/models"
@@ -40,6 +43,7 @@ func (h *DDNSTokenHandler) GetToken(c *gin.Context) {
 		return
 	}
 
+	maskDDNSToken(token)
 	c.JSON(http.StatusOK, gin.H{
 		"has_token": true,
 		"token":     token,
@@ -65,7 +69,7 @@ func (h *DDNSTokenHandler) UpdateToken(c *gin.Context) {
 
 	var token *models.DDNSToken
 	if existing == nil {
-		// Create new token
+		// Create new token; the value is shown in full this once
 		token, err = h.ddnsTokenService.CreateToken(userID, req.Token)
 	} else {
 		// Update existing token
@@ -73,10 +77,13 @@ func (h *DDNSTokenHandler) UpdateToken(c *gin.Context) {
 	}
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update token"})
+		respondDDNSTokenError(c, err, "failed to update token")
 		return
 	}
 
+	if existing != nil {
+		maskDDNSToken(token)
+	}
 	c.JSON(http.StatusOK, token)
 }
 
@@ -98,9 +105,138 @@ func (h *DDNSTokenHandler) DeleteToken(c *gin.Context) {
 
 	err = h.ddnsTokenService.DeleteToken(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete token"})
+		respondDDNSTokenError(c, err, "failed to delete token")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "token deleted successfully"})
+}
+
+// ListTokens lists all DDNS tokens of the current user
+func (h *DDNSTokenHandler) ListTokens(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	tokens, err := h.ddnsTokenService.ListTokens(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list tokens: " + err.Error()})
+		return
+	}
+
+	for i := range tokens {
+		maskDDNSToken(&tokens[i])
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// RevealToken returns the value of one of the current user's DDNS tokens
+func (h *DDNSTokenHandler) RevealToken(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	token, err := h.ddnsTokenService.GetTokenByID(userID, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get token"})
+		return
+	}
+	if token == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "token not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token.Token})
+}
+
+// CreateToken creates a named, optionally scoped DDNS token
+func (h *DDNSTokenHandler) CreateToken(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req models.CreateDDNSTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, err := h.ddnsTokenService.CreateScopedToken(userID, &req)
+	if err != nil {
+		respondDDNSTokenError(c, err, "failed to create token")
+		return
+	}
+
+	c.JSON(http.StatusCreated, token)
+}
+
+// UpdateTokenByID updates one of the current user's DDNS tokens
+func (h *DDNSTokenHandler) UpdateTokenByID(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.UpdateScopedDDNSTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, err := h.ddnsTokenService.UpdateScopedToken(userID, id, &req)
+	if err != nil {
+		respondDDNSTokenError(c, err, "failed to update token")
+		return
+	}
+	if token == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "token not found"})
+		return
+	}
+
+	maskDDNSToken(token)
+	c.JSON(http.StatusOK, token)
+}
+
+// DeleteTokenByID deletes one of the current user's DDNS tokens
+func (h *DDNSTokenHandler) DeleteTokenByID(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.ddnsTokenService.DeleteTokenByID(userID, id); err != nil {
+		respondDDNSTokenError(c, err, "failed to delete token")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "token deleted successfully"})
 }
+
+// maskDDNSToken hides the token value; only creation and RevealToken return it
+func maskDDNSToken(token *models.DDNSToken) {
+	token.Token = service.MaskSecret(token.Token)
+}
+
+// respondDDNSTokenError maps validation errors to 400, a missing token to
+// 404 and everything else to 500.
+func respondDDNSTokenError(c *gin.Context, err error, msg string) {
+	if errors.Is(err, service.ErrInvalidDDNSTokenScope) || errors.Is(err, service.ErrInvalidDDNSToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrDDNSTokenNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "token not found"})
+		return
+	}
+	if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		c.JSON(http.StatusConflict, gin.H{"error": "token value already in use"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": msg + ": " + err.Error()})
+}
@@ -2,11 +2,13 @@ package handler
 
 import (
 	"context"
-	"net"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
+	"dns-mng/service"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -33,13 +35,6 @@ type DNSCheckResponse struct {
 	DNSServer  string   `json:"dns_server,omitempty"`
 }
 
-// Public DNS servers to use
-var publicDNSServers = []string{
-	"8.8.8.8:53",         // Google DNS
-	"1.1.1.1:53",         // Cloudflare DNS
-	"208.67.222.222:53",  // OpenDNS
-}
-
 // CheckDNS checks if DNS record has propagated
 func (h *DNSCheckHandler) CheckDNS(c *gin.Context) {
 	var req DNSCheckRequest
@@ -59,45 +54,11 @@ func (h *DNSCheckHandler) CheckDNS(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	var values []string
-	var err error
-	var usedDNS string
-
 	// Try multiple DNS servers to avoid local DNS pollution
-	for _, dnsServer := range publicDNSServers {
-		resolver := &net.Resolver{
-			PreferGo: true,
-			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
-				d := net.Dialer{
-					Timeout: 5 * time.Second,
-				}
-				return d.DialContext(ctx, network, dnsServer)
-			},
-		}
-
-		switch recordType {
-		case "A":
-			values, err = lookupAWithResolver(ctx, resolver, domain)
-		case "AAAA":
-			values, err = lookupAAAAWithResolver(ctx, resolver, domain)
-		case "CNAME":
-			values, err = lookupCNAMEWithResolver(ctx, resolver, domain)
-		case "MX":
-			values, err = lookupMXWithResolver(ctx, resolver, domain)
-		case "TXT":
-			values, err = lookupTXTWithResolver(ctx, resolver, domain)
-		case "NS":
-			values, err = lookupNSWithResolver(ctx, resolver, domain)
-		default:
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported record type: " + recordType})
-			return
-		}
-
-		// If successful, use this result
-		if err == nil && len(values) > 0 {
-			usedDNS = dnsServer
-			break
-		}
+	values, usedDNS, err := service.LookupDNS(ctx, service.PublicDNSServers, domain, recordType)
+	if errors.Is(err, service.ErrUnsupportedLookupType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported record type: " + recordType})
+		return
 	}
 
 	response := DNSCheckResponse{
@@ -126,10 +87,10 @@ func (h *DNSCheckHandler) CheckDNS(c *gin.Context) {
 	// Check if expected value matches
 	if expected != "" {
 		matched := false
-		normalizedExpected := normalizeValue(expected)
+		normalizedExpected := service.NormalizeDNSValue(expected)
 
 		for _, value := range values {
-			normalizedValue := normalizeValue(value)
+			normalizedValue := service.NormalizeDNSValue(value)
 			if normalizedValue == normalizedExpected {
 				matched = true
 				break
@@ -149,84 +110,3 @@ func (h *DNSCheckHandler) CheckDNS(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
-
-// normalizeValue normalizes DNS values for comparison
-func normalizeValue(value string) string {
-	// Trim spaces
-	value = strings.TrimSpace(value)
-	// Remove surrounding double quotes (common in DNS provider TXT record display)
-	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
-		value = value[1 : len(value)-1]
-	}
-	// Convert to lowercase
-	value = strings.ToLower(value)
-	// Remove trailing dot (common in DNS responses)
-	value = strings.TrimSuffix(value, ".")
-	return value
-}
-
-// lookupAWithResolver queries A records with custom resolver
-func lookupAWithResolver(ctx context.Context, resolver *net.Resolver, domain string) ([]string, error) {
-	ips, err := resolver.LookupIP(ctx, "ip4", domain)
-	if err != nil {
-		return nil, err
-	}
-	var results []string
-	for _, ip := range ips {
-		results = append(results, ip.String())
-	}
-	return results, nil
-}
-
-// lookupAAAAWithResolver queries AAAA records with custom resolver
-func lookupAAAAWithResolver(ctx context.Context, resolver *net.Resolver, domain string) ([]string, error) {
-	ips, err := resolver.LookupIP(ctx, "ip6", domain)
-	if err != nil {
-		return nil, err
-	}
-	var results []string
-	for _, ip := range ips {
-		results = append(results, ip.String())
-	}
-	return results, nil
-}
-
-// lookupCNAMEWithResolver queries CNAME records with custom resolver
-func lookupCNAMEWithResolver(ctx context.Context, resolver *net.Resolver, domain string) ([]string, error) {
-	cname, err := resolver.LookupCNAME(ctx, domain)
-	if err != nil {
-		return nil, err
-	}
-	return []string{strings.TrimSuffix(cname, ".")}, nil
-}
-
-// lookupMXWithResolver queries MX records with custom resolver
-func lookupMXWithResolver(ctx context.Context, resolver *net.Resolver, domain string) ([]string, error) {
-	mxs, err := resolver.LookupMX(ctx, domain)
-	if err != nil {
-		return nil, err
-	}
-	var results []string
-	for _, mx := range mxs {
-		results = append(results, strings.TrimSuffix(mx.Host, "."))
-	}
-	return results, nil
-}
-
-// lookupTXTWithResolver queries TXT records with custom resolver
-func lookupTXTWithResolver(ctx context.Context, resolver *net.Resolver, domain string) ([]string, error) {
-	return resolver.LookupTXT(ctx, domain)
-}
-
-// lookupNSWithResolver queries NS records with custom resolver
-func lookupNSWithResolver(ctx context.Context, resolver *net.Resolver, domain string) ([]string, error) {
-	nss, err := resolver.LookupNS(ctx, domain)
-	if err != nil {
-		return nil, err
-	}
-	var results []string
-	for _, ns := range nss {
-		results = append(results, strings.TrimSuffix(ns.Host, "."))
-	}
-	return results, nil
-}
@@ -1,14 +1,18 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"dns-mng/middleware"
 	"dns-mng/models"
+	"dns-mng/provider"
 	"dns-mng/service"
 
 	"github.com/gin-gonic/gin"
@@ -81,6 +85,8 @@ func (h *DNSHandler) RefreshAllDomains(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	// Record caches are synced after the response; listing every zone takes a while
+	go h.dnsService.SyncRecordCaches(context.WithoutCancel(c.Request.Context()), userID, domains)
 	if domains == nil {
 		domains = []models.Domain{}
 	}
@@ -235,6 +241,8 @@ func (h *DNSHandler) RefreshDomains(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	// Record caches are synced after the response; listing every zone takes a while
+	go h.dnsService.SyncRecordCaches(context.WithoutCancel(c.Request.Context()), userID, domains)
 	if domains == nil {
 		domains = []models.Domain{}
 	}
@@ -335,17 +343,49 @@ func (h *DNSHandler) ListRecords(c *gin.Context) {
 	}
 	domainID := c.Param("domainId")
 
-	records, err := h.dnsService.ListRecords(c.Request.Context(), userID, accountID, domainID)
+	refresh := c.Query("refresh") == "true"
+	result, err := h.dnsService.ListRecordsCached(c.Request.Context(), userID, accountID, domainID, refresh)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	records := result.Records
 	if records == nil {
 		records = []models.Record{}
 	}
+	// 缓存状态放在响应头里，保持响应体仍为记录数组
+	c.Header("X-Cache-Status", result.CacheStatus)
+	if result.SyncedAt != nil {
+		c.Header("X-Cache-Timestamp", result.SyncedAt.UTC().Format(time.RFC3339))
+	}
+	if len(result.Changes) > 0 {
+		c.Header("X-Record-Changes", strconv.Itoa(len(result.Changes)))
+	}
 	c.JSON(http.StatusOK, records)
 }
 
+// ListRecordChanges returns record edits made outside dns-mng, detected
+// when the record cache is re-synced.
+func (h *DNSHandler) ListRecordChanges(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	accountID, err := middleware.GetAccountID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account id"})
+		return
+	}
+	domainID := c.Param("domainId")
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	changes, err := h.dnsService.ListRecordChanges(userID, accountID, domainID, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, changes)
+}
+
 func (h *DNSHandler) CreateRecord(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	accountID, err := middleware.GetAccountID(c)
@@ -363,7 +403,7 @@ func (h *DNSHandler) CreateRecord(c *gin.Context) {
 
 	record, err := h.dnsService.CreateRecord(c.Request.Context(), userID, accountID, domainID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondCapabilityError(c, err)
 		return
 	}
 
@@ -388,7 +428,7 @@ func (h *DNSHandler) UpdateRecord(c *gin.Context) {
 
 	record, err := h.dnsService.UpdateRecord(c.Request.Context(), userID, accountID, domainID, recordID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondCapabilityError(c, err)
 		return
 	}
 
@@ -412,3 +452,270 @@ func (h *DNSHandler) DeleteRecord(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "record deleted"})
 }
+
+// respondCapabilityError maps provider.ErrNotSupported to 501 so clients can
+// tell a missing capability apart from a provider failure, and an unknown
+// account to 404.
+func respondCapabilityError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrAccountNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, provider.ErrNotSupported) {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+func (h *DNSHandler) GetCapabilities(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	accountID, err := middleware.GetAccountID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account id"})
+		return
+	}
+
+	caps, err := h.dnsService.GetCapabilities(userID, accountID)
+	if err != nil {
+		respondCapabilityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, caps)
+}
+
+func (h *DNSHandler) CreateZone(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	accountID, err := middleware.GetAccountID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account id"})
+		return
+	}
+
+	var req models.CreateZoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	domain, err := h.dnsService.CreateZone(c.Request.Context(), userID, accountID, strings.TrimSpace(req.Name))
+	if err != nil {
+		respondCapabilityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, domain)
+}
+
+func (h *DNSHandler) DeleteZone(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	accountID, err := middleware.GetAccountID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account id"})
+		return
+	}
+	domainID := c.Param("domainId")
+
+	if err := h.dnsService.DeleteZone(c.Request.Context(), userID, accountID, domainID); err != nil {
+		respondCapabilityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "zone deleted"})
+}
+
+func (h *DNSHandler) GetNameservers(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	accountID, err := middleware.GetAccountID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account id"})
+		return
+	}
+	domainID := c.Param("domainId")
+
+	nameservers, err := h.dnsService.GetNameservers(c.Request.Context(), userID, accountID, domainID)
+	if err != nil {
+		respondCapabilityError(c, err)
+		return
+	}
+	if nameservers == nil {
+		nameservers = []string{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"nameservers": nameservers})
+}
+
+func (h *DNSHandler) ListRecordLines(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	accountID, err := middleware.GetAccountID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account id"})
+		return
+	}
+	domainID := c.Param("domainId")
+
+	lines, err := h.dnsService.ListRecordLines(c.Request.Context(), userID, accountID, domainID)
+	if err != nil {
+		respondCapabilityError(c, err)
+		return
+	}
+	if lines == nil {
+		lines = []models.RecordLine{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"lines": lines})
+}
+
+func (h *DNSHandler) RenewDomain(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	accountID, err := middleware.GetAccountID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account id"})
+		return
+	}
+	domainID := c.Param("domainId")
+
+	if err := h.dnsService.RenewDomain(c.Request.Context(), userID, accountID, domainID); err != nil {
+		respondCapabilityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "domain renewed"})
+}
+
+func (h *DNSHandler) BatchCreateRecords(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	accountID, err := middleware.GetAccountID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account id"})
+		return
+	}
+	domainID := c.Param("domainId")
+
+	var req models.BatchCreateRecordsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	records, err := h.dnsService.BatchCreateRecords(c.Request.Context(), userID, accountID, domainID, req.Records)
+	if errors.Is(err, service.ErrAccountNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		// Report what was created before the failure so the client can retry
+		// only the remainder.
+		status := http.StatusInternalServerError
+		if errors.Is(err, provider.ErrNotSupported) {
+			status = http.StatusNotImplemented
+		}
+		c.JSON(status, gin.H{"error": err.Error(), "created": records})
+		return
+	}
+
+	c.JSON(http.StatusCreated, records)
+}
+
+func (h *DNSHandler) SetRecordProxied(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	accountID, err := middleware.GetAccountID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account id"})
+		return
+	}
+	domainID := c.Param("domainId")
+	recordID := c.Param("recordId")
+
+	var req models.SetRecordProxiedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	record, err := h.dnsService.SetRecordProxied(c.Request.Context(), userID, accountID, domainID, recordID, *req.Proxied)
+	if err != nil {
+		respondCapabilityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, record)
+}
+
+// ExportZoneFile downloads a domain's records as a BIND zone file
+func (h *DNSHandler) ExportZoneFile(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	accountID, err := middleware.GetAccountID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account id"})
+		return
+	}
+	domainID := c.Param("domainId")
+
+	zone, data, err := h.dnsService.ExportZoneFile(c.Request.Context(), userID, accountID, domainID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zone"`, zone))
+	c.Data(http.StatusOK, "text/dns; charset=utf-8", data)
+}
+
+// ImportZoneFile diffs a BIND zone file against the domain's records and
+// applies the changes unless dry_run is true (the default).
+func (h *DNSHandler) ImportZoneFile(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	accountID, err := middleware.GetAccountID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account id"})
+		return
+	}
+	domainID := c.Param("domainId")
+
+	var req models.ZoneImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.dnsService.ImportZoneFile(c.Request.Context(), userID, accountID, domainID, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidZoneFile) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// CloneZone copies a domain's records to another account/provider and
+// returns a per-record report.
+func (h *DNSHandler) CloneZone(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	accountID, err := middleware.GetAccountID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account id"})
+		return
+	}
+	domainID := c.Param("domainId")
+
+	var req models.ZoneCloneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.dnsService.CloneZone(c.Request.Context(), userID, accountID, domainID, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidZoneClone) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
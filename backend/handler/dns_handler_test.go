@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"dns-mng/provider"
+	"dns-mng/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRespondCapabilityError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{provider.ErrNotSupported, http.StatusNotImplemented},
+		{fmt.Errorf("CDN optimization on mock accounts: %w", provider.ErrNotSupported), http.StatusNotImplemented},
+		{service.ErrAccountNotFound, http.StatusNotFound},
+		{errors.New("upstream timeout"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		respondCapabilityError(c, tt.err)
+		if w.Code != tt.want {
+			t.Errorf("respondCapabilityError(%v) = %d, want %d", tt.err, w.Code, tt.want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
 
 	"dns-mng/middleware"
 	"dns-mng/models"
@@ -37,6 +38,9 @@ func (h *DNSHEHandler) ListAccounts(c *gin.Context) {
 	if accounts == nil {
 		accounts = []models.Account{}
 	}
+	for i := range accounts {
+		maskAccount(&accounts[i])
+	}
 	c.JSON(http.StatusOK, gin.H{"accounts": accounts})
 }
 
@@ -50,10 +54,10 @@ func (h *DNSHEHandler) GetQuota(c *gin.Context) {
 	}
 	quota, err := h.dnsheService.GetQuota(c.Request.Context(), userID, accountID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondCapabilityError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, quota)
+	c.JSON(http.StatusOK, gin.H{"quota": quota})
 }
 
 type dnsheSubdomainRequest struct {
@@ -74,12 +78,12 @@ func (h *DNSHEHandler) RegisterSubdomain(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	resp, err := h.dnsheService.RegisterSubdomain(c.Request.Context(), userID, accountID, req.Subdomain, req.Rootdomain)
+	domain, err := h.dnsheService.RegisterSubdomain(c.Request.Context(), userID, accountID, req.Subdomain, req.Rootdomain)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondCapabilityError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, resp)
+	c.JSON(http.StatusOK, gin.H{"subdomain_id": domain.ID, "full_domain": domain.Name})
 }
 
 type dnsheSubdomainIDRequest struct {
@@ -99,12 +103,11 @@ func (h *DNSHEHandler) DeleteSubdomain(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	resp, err := h.dnsheService.DeleteSubdomain(c.Request.Context(), userID, accountID, req.SubdomainID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.dnsheService.DeleteSubdomain(c.Request.Context(), userID, accountID, strconv.Itoa(req.SubdomainID)); err != nil {
+		respondCapabilityError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, resp)
+	c.JSON(http.StatusOK, gin.H{"message": "subdomain deleted"})
 }
 
 type dnsheSetResolutionRequest struct {
@@ -156,7 +159,7 @@ func (h *DNSHEHandler) ResolveToCloudflare(c *gin.Context) {
 	}
 	result, err := h.dnsheService.ResolveToCloudflare(c.Request.Context(), userID, accountID, domainID, req.CloudflareAccountID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondCapabilityError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, result)
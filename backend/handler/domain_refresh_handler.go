@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"net/http"
+
+	"dns-mng/middleware"
+	"dns-mng/models"
+	"dns-mng/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DomainRefreshHandler struct {
+	domainRefreshService *service.DomainRefreshService
+}
+
+func NewDomainRefreshHandler(domainRefreshService *service.DomainRefreshService) *DomainRefreshHandler {
+	return &DomainRefreshHandler{domainRefreshService: domainRefreshService}
+}
+
+// ListPendingDeletions lists domains the scheduled refresh found missing at
+// their provider; ?include_dismissed=true also returns dismissed ones
+func (h *DomainRefreshHandler) ListPendingDeletions(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	items, err := h.domainRefreshService.ListPendingDeletions(userID, c.Query("include_dismissed") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// ApprovePendingDeletions soft-deletes the selected queued domains
+func (h *DomainRefreshHandler) ApprovePendingDeletions(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req models.BatchSoftDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Items) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no items provided"})
+		return
+	}
+
+	if err := h.domainRefreshService.ApprovePendingDeletions(userID, req.Items); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "domains soft deleted successfully", "count": len(req.Items)})
+}
+
+// DismissPendingDeletions keeps the selected queued domains
+func (h *DomainRefreshHandler) DismissPendingDeletions(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req models.BatchSoftDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Items) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no items provided"})
+		return
+	}
+
+	if err := h.domainRefreshService.DismissPendingDeletions(userID, req.Items); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "domains kept", "count": len(req.Items)})
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"dns-mng/models"
+	"dns-mng/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LogRetentionHandler struct {
+	logRetentionService *service.LogRetentionService
+}
+
+func NewLogRetentionHandler(logRetentionService *service.LogRetentionService) *LogRetentionHandler {
+	return &LogRetentionHandler{logRetentionService: logRetentionService}
+}
+
+// ListPolicies returns the retention policy of every log table
+func (h *LogRetentionHandler) ListPolicies(c *gin.Context) {
+	policies, err := h.logRetentionService.ListPolicies()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policies)
+}
+
+// UpdatePolicy changes the retention policy of one log table
+func (h *LogRetentionHandler) UpdatePolicy(c *gin.Context) {
+	var req models.UpdateLogRetentionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := h.logRetentionService.UpdatePolicy(c.Param("table"), &req)
+	if errors.Is(err, service.ErrInvalidRetentionPolicy) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// Cleanup applies the retention policies now
+func (h *LogRetentionHandler) Cleanup(c *gin.Context) {
+	results, err := h.logRetentionService.Enforce()
+	if err != nil && results == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// Stats returns log table sizes and the database file size
+func (h *LogRetentionHandler) Stats(c *gin.Context) {
+	stats, err := h.logRetentionService.Stats()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// Vacuum compacts the SQLite database file; skipped under libSQL
+func (h *LogRetentionHandler) Vacuum(c *gin.Context) {
+	skipped, err := h.logRetentionService.Vacuum()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if skipped {
+		c.JSON(http.StatusOK, gin.H{"skipped": true, "message": "VACUUM is not supported on libSQL, skipped"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"skipped": false, "message": "database vacuumed"})
+}
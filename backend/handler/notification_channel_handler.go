@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"dns-mng/middleware"
+	"dns-mng/models"
+	"dns-mng/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationChannelHandler struct {
+	notifierService *service.NotifierService
+}
+
+func NewNotificationChannelHandler(notifierService *service.NotifierService) *NotificationChannelHandler {
+	return &NotificationChannelHandler{notifierService: notifierService}
+}
+
+// ListTypes lists the supported channel types and their config keys
+func (h *NotificationChannelHandler) ListTypes(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"types":  h.notifierService.ChannelTypes(),
+		"events": models.NotifyEvents,
+	})
+}
+
+// List lists the user's notification channels
+func (h *NotificationChannelHandler) List(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	channels, err := h.notifierService.ListChannels(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, channels)
+}
+
+// Create adds a notification channel
+func (h *NotificationChannelHandler) Create(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req models.CreateNotificationChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channel, err := h.notifierService.CreateChannel(userID, &req)
+	if errors.Is(err, service.ErrInvalidNotificationChannel) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, channel)
+}
+
+// Update changes a notification channel
+func (h *NotificationChannelHandler) Update(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.UpdateNotificationChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channel, err := h.notifierService.UpdateChannel(userID, id, &req)
+	if errors.Is(err, service.ErrInvalidNotificationChannel) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if channel == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "notification channel not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, channel)
+}
+
+// Delete removes a notification channel
+func (h *NotificationChannelHandler) Delete(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	found, err := h.notifierService.DeleteChannel(userID, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "notification channel not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// Test sends a test message through one channel
+func (h *NotificationChannelHandler) Test(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	found, err := h.notifierService.TestChannel(c.Request.Context(), userID, id)
+	if !found && err == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "notification channel not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "test notification sent successfully"})
+}
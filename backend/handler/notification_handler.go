@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"dns-mng/middleware"
@@ -123,6 +124,10 @@ func (h *NotificationHandler) UpdateEmailConfig(c *gin.Context) {
 	}
 
 	config, err := h.emailService.UpsertEmailConfig(userID, &req)
+	if errors.Is(err, service.ErrInvalidNotificationChannel) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
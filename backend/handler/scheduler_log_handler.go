@@ -1,9 +1,11 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
+	"dns-mng/models"
 	"dns-mng/service"
 
 	"github.com/gin-gonic/gin"
@@ -54,7 +56,69 @@ func (h *SchedulerLogHandler) GetSchedulerLogsByTask(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-func (h *SchedulerLogHandler) TriggerManualCheck(c *gin.Context) {
-	h.schedulerService.TriggerManualCheck()
-	c.JSON(http.StatusOK, gin.H{"message": "Manual check triggered successfully"})
+// TriggerJob runs a scheduler job now. The job is named by {"job": ...} or
+// ?job=; without one the domain expiry check runs, as before.
+func (h *SchedulerLogHandler) TriggerJob(c *gin.Context) {
+	var req models.TriggerSchedulerJobRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Job == "" {
+		req.Job = c.DefaultQuery("job", models.JobDomainExpiry)
+	}
+
+	err := h.schedulerService.TriggerJob(req.Job)
+	if errors.Is(err, service.ErrUnknownJob) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrJobRunning) || errors.Is(err, service.ErrJobDisabled) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Job triggered successfully", "job": req.Job})
+}
+
+// ListJobs lists the scheduler jobs with their schedules and last runs
+func (h *SchedulerLogHandler) ListJobs(c *gin.Context) {
+	jobs, err := h.schedulerService.ListJobs()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, jobs)
+}
+
+// UpdateJob changes a job's cron expression or enables/disables it
+func (h *SchedulerLogHandler) UpdateJob(c *gin.Context) {
+	var req models.UpdateSchedulerJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := h.schedulerService.UpdateJob(c.Param("name"), &req)
+	if errors.Is(err, service.ErrInvalidCron) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if job == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
 }
@@ -14,10 +14,9 @@ import (
 )
 
 // WHOISHandler exposes WHOIS lookup configuration and the lookup endpoint.
-// The API key is stored per-user in the database and returned in plaintext to
-// the owning user (they configure and view it themselves, same pattern as the
-// DDNS token); lookups are proxied through the backend so the key is used to
-// call WhoisJSON.com server-side and is never embedded in browser-facing logic.
+// The API key is stored per-user in the database and only returned masked;
+// lookups are proxied through the backend so the key is used to call
+// WhoisJSON.com server-side and is never embedded in browser-facing logic.
 type WHOISHandler struct {
 	whoisService *service.WHOISService
 	logService   *service.LogService
@@ -32,7 +31,7 @@ func NewWHOISHandler(whoisService *service.WHOISService, logService *service.Log
 }
 
 // GetConfig returns the user's WHOIS lookup configuration (with the API key
-// cleared) or `{"configured": false}` when no row exists.
+// masked) or `{"configured": false}` when no row exists.
 func (h *WHOISHandler) GetConfig(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
@@ -52,7 +51,7 @@ func (h *WHOISHandler) GetConfig(c *gin.Context) {
 }
 
 // UpdateConfig creates or updates the user's WHOIS lookup configuration.
-// An empty api_key in the request means "keep the existing key".
+// An empty or masked api_key in the request means "keep the existing key".
 func (h *WHOISHandler) UpdateConfig(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
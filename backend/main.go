@@ -2,6 +2,11 @@ package main
 
 import (
 	"log"
+	"os"
+	"strconv"
+	"time"
+	// cron schedules may name an IANA zone; the runtime image has no tzdata
+	_ "time/tzdata"
 
 	"dns-mng/config"
 	"dns-mng/database"
@@ -16,6 +21,7 @@ import (
 	"dns-mng/provider/huaweicloud"
 	"dns-mng/provider/hurricane"
 	"dns-mng/provider/ipv64"
+	"dns-mng/provider/mock"
 	"dns-mng/provider/ndjp"
 	"dns-mng/provider/tencentcloud"
 	"dns-mng/provider/vps8"
@@ -32,6 +38,19 @@ func main() {
 	database.InitWithConfig(cfg.DBType, cfg.DBPath, cfg.DBURL, cfg.DBAuthToken)
 	defer database.Close()
 
+	// Load the data keys and encrypt credentials still stored in plaintext
+	if err := service.InitSecretStore(cfg.MasterKey); err != nil {
+		log.Fatalf("Failed to init secret store: %v", err)
+	}
+
+	// `dns-mng rotate-key` re-encrypts all credentials under NEW_MASTER_KEY and exits
+	if len(os.Args) > 1 && os.Args[1] == "rotate-key" {
+		rotateMasterKey()
+		return
+	}
+	// Keeps rotate-key from running while this server holds the old data keys
+	service.StartServerHeartbeat()
+
 	// Register providers
 	provider.Register(dynu.New())
 	provider.Register(tencentcloud.New())
@@ -44,32 +63,60 @@ func main() {
 	provider.Register(hurricane.New())
 	provider.Register(ipv64.New())
 	provider.Register(vps8.New())
+	if cfg.MockProvider == "true" {
+		provider.Register(mock.New(mockOptions(cfg)))
+	}
 
 	// Init services
 	userService := service.NewUserService(cfg)
+	apiTokenService := service.NewAPITokenService(userService)
+	// Without JWT_SECRET a random signing secret is generated and stored
+	if err := userService.EnsureJWTSecret(); err != nil {
+		log.Fatalf("Failed to init JWT secret: %v", err)
+	}
+	// Instances created before user roles get their first user as admin
+	if err := userService.EnsureAdmin(); err != nil {
+		log.Fatalf("Failed to bootstrap admin user: %v", err)
+	}
 	accountService := service.NewAccountService()
+	// Accounts saved with a single api_key get structured credentials
+	if n, err := accountService.MigrateCredentials(); err != nil {
+		log.Printf("Failed to migrate account credentials: %v", err)
+	} else if n > 0 {
+		log.Printf("Migrated credentials of %d account(s)", n)
+	}
 	domainCacheService := service.NewDomainCacheService()
-	dnsService := service.NewDNSService(accountService, domainCacheService)
+	recordCacheService := service.NewRecordCacheService()
+	webhookService := service.NewWebhookService()
+	dnsService := service.NewDNSService(accountService, domainCacheService, recordCacheService, webhookService)
 	acmeService := service.NewAcmeService(dnsService)
+	ddnsService := service.NewDDNSService(dnsService)
 	logService := service.NewLogService()
 	schedulerLogService := service.NewSchedulerLogService()
 	notificationService := service.NewNotificationService()
 	emailService := service.NewEmailService()
+	notifierService := service.NewNotifierService(emailService)
 
 	ddnsTokenService := service.NewDDNSTokenService()
+	ddnsHistoryService := service.NewDDNSHistoryService()
+	ddnsAgentService := service.NewDDNSAgentService(ddnsService, ddnsHistoryService)
 	backupService := service.NewBackupService(accountService, domainCacheService, ddnsTokenService, emailService, notificationService)
-	cfOptimizeService := service.NewCFOptimizeService()
-	dnsheService := service.NewDNSHEService(accountService, domainCacheService)
+	cfOptimizeService := service.NewCFOptimizeService(dnsService)
+	dnsheService := service.NewDNSHEService(accountService, domainCacheService, dnsService)
 	dnsheAutoRenewService := service.NewDNSHEAutoRenewService(dnsheService)
 	whoisService := service.NewWHOISService()
+	domainRefreshService := service.NewDomainRefreshService(dnsService, accountService, domainCacheService)
+	logRetentionService := service.NewLogRetentionService()
 
-	// Start scheduler for domain expiry notifications
-	schedulerService := service.NewSchedulerService(notificationService, emailService, schedulerLogService, dnsheAutoRenewService)
+	// Start the job scheduler
+	schedulerService := service.NewSchedulerService(cfg, notificationService, notifierService, schedulerLogService, dnsheAutoRenewService, domainRefreshService, ddnsService, ddnsAgentService, webhookService, backupService, logRetentionService, accountService)
 	schedulerService.Start()
 	defer schedulerService.Stop()
 
 	// Init handlers
-	authHandler := handler.NewAuthHandler(userService, logService)
+	authHandler := handler.NewAuthHandler(userService, logService, notifierService)
+	userHandler := handler.NewUserHandler(userService)
+	apiTokenHandler := handler.NewAPITokenHandler(apiTokenService)
 	accountHandler := handler.NewAccountHandler(accountService, logService)
 	dnsHandler := handler.NewDNSHandler(dnsService, logService)
 	providerHandler := handler.NewProviderHandler()
@@ -77,9 +124,15 @@ func main() {
 	schedulerLogHandler := handler.NewSchedulerLogHandler(schedulerLogService, schedulerService)
 	dnsCheckHandler := handler.NewDNSCheckHandler()
 	domainCacheHandler := handler.NewDomainCacheHandler(dnsService, logService)
+	domainRefreshHandler := handler.NewDomainRefreshHandler(domainRefreshService)
+	logRetentionHandler := handler.NewLogRetentionHandler(logRetentionService)
 	notificationHandler := handler.NewNotificationHandler(notificationService, emailService, logService)
+	notificationChannelHandler := handler.NewNotificationChannelHandler(notifierService)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
 	acmeHandler := handler.NewAcmeHandler(acmeService)
-	ddnsHandler := handler.NewDDNSHandler(dnsService, accountService, logService, ddnsTokenService)
+	ddnsHandler := handler.NewDDNSHandler(ddnsService, logService, ddnsTokenService, ddnsHistoryService)
+	ddnsHistoryHandler := handler.NewDDNSHistoryHandler(ddnsHistoryService)
+	ddnsAgentHandler := handler.NewDDNSAgentHandler(ddnsAgentService, schedulerLogService)
 	ddnsTokenHandler := handler.NewDDNSTokenHandler(ddnsTokenService, logService)
 	backupHandler := handler.NewBackupHandler(backupService)
 	cfOptimizeHandler := handler.NewCFOptimizeHandler(cfOptimizeService)
@@ -88,14 +141,26 @@ func main() {
 
 	// Setup router
 	r := gin.Default()
+	// gin trusts forwarding headers from every peer by default. Only the
+	// configured proxies are trusted; with none, ClientIP is the socket peer.
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 	r.Use(middleware.CORSMiddleware())
-	// Recover the real client IP when behind a reverse proxy / CDN (e.g.
-	// Cloudflare). Must run before APILogger so logged IPs are the real
-	// client, not the proxy node.
-	r.Use(middleware.RealIP())
+	if len(cfg.TrustedProxies) == 0 {
+		log.Println("WARNING: TRUSTED_PROXIES is not set; forwarded client IPs are used for logs only and DDNS IP allow-lists reject proxied requests")
+		// Recover the real client IP when behind a reverse proxy / CDN (e.g.
+		// Cloudflare). Must run before APILogger so logged IPs are the real
+		// client, not the proxy node.
+		r.Use(middleware.RealIP())
+	}
 	// Add API logger middleware to record all API calls
 	r.Use(middleware.APILogger(logService))
 
+	// DynDNS2 clients hard-code the /nic/update path, so expose it at the root
+	// as well as under /api.
+	r.GET("/nic/update", ddnsHandler.NicUpdate)
+
 	// Public routes
 	api := r.Group("/api")
 	{
@@ -103,15 +168,20 @@ func main() {
 		{
 			auth.POST("/register", authHandler.Register)
 			auth.POST("/login", authHandler.Login)
+			auth.POST("/refresh", authHandler.Refresh)
+			auth.GET("/registration", authHandler.RegistrationStatus)
 		}
 		api.GET("/providers", providerHandler.List)
 
 		// DDNS API (public, token-based authentication)
 		api.GET("/ddns/update", ddnsHandler.UpdateDDNS)
+		// DynDNS2 / No-IP compatible update (Basic Auth, password = DDNS token)
+		api.GET("/nic/update", ddnsHandler.NicUpdate)
 
-		// External ACME DNS-01 API (HTTP Basic Auth with system user)
+		// External ACME DNS-01 API (HTTP Basic Auth with system user; the
+		// password is an API token with the acme scope)
 		acme := api.Group("/acme")
-		acme.Use(middleware.BasicAuthMiddleware(userService))
+		acme.Use(middleware.BasicAuthMiddleware(apiTokenService))
 		{
 			acme.POST("/dns01/present", acmeHandler.Present)
 			acme.POST("/dns01/cleanup", acmeHandler.Cleanup)
@@ -120,11 +190,27 @@ func main() {
 
 	// Protected routes
 	protected := api.Group("")
-	protected.Use(middleware.AuthMiddleware(cfg))
+	protected.Use(middleware.AuthMiddleware(cfg, userService, apiTokenService))
 	{
+		// Sessions
+		protected.POST("/auth/logout", authHandler.Logout)
+		protected.GET("/user/sessions", authHandler.ListSessions)
+		protected.DELETE("/user/sessions/:id", authHandler.RevokeSession)
+		protected.POST("/user/sessions/revoke-all", authHandler.RevokeAllSessions)
+
 		// User profile
 		protected.GET("/user/profile", authHandler.GetProfile)
 		protected.PUT("/user/password", authHandler.UpdatePassword)
+		protected.GET("/user/totp", authHandler.GetTOTP)
+		protected.POST("/user/totp/setup", authHandler.SetupTOTP)
+		protected.POST("/user/totp/enable", authHandler.EnableTOTP)
+		protected.POST("/user/totp/disable", authHandler.DisableTOTP)
+		protected.POST("/user/totp/recovery-codes", authHandler.RegenerateRecoveryCodes)
+
+		// Personal API tokens (accepted in place of a JWT, limited by scope)
+		protected.GET("/api-tokens", apiTokenHandler.List)
+		protected.POST("/api-tokens", apiTokenHandler.Create)
+		protected.DELETE("/api-tokens/:id", apiTokenHandler.Delete)
 
 		// API call logs
 		protected.GET("/api-logs", logHandler.GetAPICallLogs)
@@ -135,7 +221,7 @@ func main() {
 		// Scheduler logs
 		protected.GET("/scheduler-logs", schedulerLogHandler.GetSchedulerLogs)
 		protected.GET("/scheduler-logs/:taskName", schedulerLogHandler.GetSchedulerLogsByTask)
-		protected.POST("/scheduler/trigger", schedulerLogHandler.TriggerManualCheck)
+		protected.GET("/scheduler/jobs", schedulerLogHandler.ListJobs)
 
 		// All domains
 		protected.GET("/domains", dnsHandler.ListAllDomains)
@@ -144,13 +230,22 @@ func main() {
 		// Accounts
 		protected.GET("/accounts", accountHandler.List)
 		protected.POST("/accounts", accountHandler.Create)
+		protected.POST("/accounts/test", accountHandler.Test)
+		protected.POST("/accounts/:id/test", accountHandler.TestAccount)
 		protected.PUT("/accounts/:id", accountHandler.Update)
 		protected.DELETE("/accounts/:id", accountHandler.Delete)
+		protected.GET("/accounts/:id/capabilities", dnsHandler.GetCapabilities)
+		protected.GET("/accounts/:id/api-key", accountHandler.GetAPIKey)
 
 		// DNS
 		protected.GET("/accounts/:id/domains", dnsHandler.ListDomains)
 		protected.GET("/accounts/:id/domains/refresh", dnsHandler.RefreshDomains)
 		protected.GET("/accounts/:id/domains/:domainId", dnsHandler.GetDomain)
+		protected.POST("/accounts/:id/domains", dnsHandler.CreateZone)
+		protected.DELETE("/accounts/:id/domains/:domainId", dnsHandler.DeleteZone)
+		protected.GET("/accounts/:id/domains/:domainId/nameservers", dnsHandler.GetNameservers)
+		protected.GET("/accounts/:id/domains/:domainId/lines", dnsHandler.ListRecordLines)
+		protected.POST("/accounts/:id/domains/:domainId/renew", dnsHandler.RenewDomain)
 		protected.PUT("/accounts/:id/domains/:domainId/cache", domainCacheHandler.UpdateDomainCache)
 
 		// Domain cache batch operations
@@ -160,6 +255,11 @@ func main() {
 		protected.POST("/domains/batch-soft-delete", domainCacheHandler.BatchSoftDeleteDomains)
 		protected.POST("/domains/batch-restore", domainCacheHandler.BatchRestoreDomains)
 
+		// Domains the scheduled refresh queued for deletion review
+		protected.GET("/domains/pending-deletions", domainRefreshHandler.ListPendingDeletions)
+		protected.POST("/domains/pending-deletions/approve", domainRefreshHandler.ApprovePendingDeletions)
+		protected.POST("/domains/pending-deletions/dismiss", domainRefreshHandler.DismissPendingDeletions)
+
 		// Notification settings
 		protected.GET("/accounts/:id/domains/:domainId/notification", notificationHandler.GetNotificationSetting)
 		protected.PUT("/accounts/:id/domains/:domainId/notification", notificationHandler.UpdateNotificationSetting)
@@ -170,15 +270,53 @@ func main() {
 		protected.PUT("/email/config", notificationHandler.UpdateEmailConfig)
 		protected.POST("/email/test", notificationHandler.TestEmailConfig)
 
+		// Notification channels (Telegram, Slack, DingTalk, WeCom, Feishu, webhook)
+		protected.GET("/notification-channel-types", notificationChannelHandler.ListTypes)
+		protected.GET("/notification-channels", notificationChannelHandler.List)
+		protected.POST("/notification-channels", notificationChannelHandler.Create)
+		protected.PUT("/notification-channels/:id", notificationChannelHandler.Update)
+		protected.DELETE("/notification-channels/:id", notificationChannelHandler.Delete)
+		protected.POST("/notification-channels/:id/test", notificationChannelHandler.Test)
+
+		// Outbound webhooks for change events
+		protected.GET("/webhooks", webhookHandler.List)
+		protected.POST("/webhooks", webhookHandler.Create)
+		protected.GET("/webhook-events", webhookHandler.ListEvents)
+		protected.PUT("/webhooks/:id", webhookHandler.Update)
+		protected.DELETE("/webhooks/:id", webhookHandler.Delete)
+		protected.POST("/webhooks/:id/test", webhookHandler.Test)
+		protected.GET("/webhooks/:id/deliveries", webhookHandler.ListDeliveries)
+		protected.POST("/webhooks/:id/deliveries/:deliveryId/redeliver", webhookHandler.Redeliver)
+
 		protected.GET("/accounts/:id/domains/:domainId/records", dnsHandler.ListRecords)
+		protected.GET("/accounts/:id/domains/:domainId/records/changes", dnsHandler.ListRecordChanges)
+		protected.GET("/accounts/:id/domains/:domainId/zonefile", dnsHandler.ExportZoneFile)
+		protected.POST("/accounts/:id/domains/:domainId/zonefile/import", dnsHandler.ImportZoneFile)
+		protected.POST("/accounts/:id/domains/:domainId/clone", dnsHandler.CloneZone)
 		protected.POST("/accounts/:id/domains/:domainId/records", dnsHandler.CreateRecord)
 		protected.PUT("/accounts/:id/domains/:domainId/records/:recordId", dnsHandler.UpdateRecord)
 		protected.DELETE("/accounts/:id/domains/:domainId/records/:recordId", dnsHandler.DeleteRecord)
-
-		// DDNS Token Management (user-level, one token per user)
+		protected.POST("/accounts/:id/domains/:domainId/records/batch", dnsHandler.BatchCreateRecords)
+		protected.PUT("/accounts/:id/domains/:domainId/records/:recordId/proxied", dnsHandler.SetRecordProxied)
+
+		// DDNS Token Management (user-level, multiple scoped tokens per user)
+		protected.GET("/ddns-tokens", ddnsTokenHandler.ListTokens)
+		protected.POST("/ddns-tokens", ddnsTokenHandler.CreateToken)
+		protected.PUT("/ddns-tokens/:id", ddnsTokenHandler.UpdateTokenByID)
+		protected.DELETE("/ddns-tokens/:id", ddnsTokenHandler.DeleteTokenByID)
+		protected.GET("/ddns-tokens/:id/token", ddnsTokenHandler.RevealToken)
+		// Legacy single-token endpoints, operating on the user's oldest token
 		protected.GET("/ddns-token", ddnsTokenHandler.GetToken)
 		protected.PUT("/ddns-token", ddnsTokenHandler.UpdateToken)
 		protected.DELETE("/ddns-token", ddnsTokenHandler.DeleteToken)
+		// DDNS update history (filter by token_id, hostname, status, from, to)
+		protected.GET("/ddns-history", ddnsHistoryHandler.GetHistory)
+		// Server-side DDNS agents
+		protected.GET("/ddns-agents", ddnsAgentHandler.List)
+		protected.POST("/ddns-agents", ddnsAgentHandler.Create)
+		protected.PUT("/ddns-agents/:id", ddnsAgentHandler.Update)
+		protected.DELETE("/ddns-agents/:id", ddnsAgentHandler.Delete)
+		protected.POST("/ddns-agents/:id/run", ddnsAgentHandler.Run)
 
 		// DNS Check
 		protected.POST("/dns/check", dnsCheckHandler.CheckDNS)
@@ -212,8 +350,71 @@ func main() {
 		protected.POST("/dnshe/auto-renew/trigger", dnsheHandler.TriggerAutoRenew)
 	}
 
+	// Admin-only routes
+	admin := protected.Group("")
+	admin.Use(middleware.AdminMiddleware())
+	{
+		// Scheduler jobs span all users
+		admin.PUT("/scheduler/jobs/:name", schedulerLogHandler.UpdateJob)
+		admin.POST("/scheduler/trigger", schedulerLogHandler.TriggerJob)
+
+		// User management and registration
+		admin.GET("/admin/users", userHandler.ListUsers)
+		admin.POST("/admin/users", userHandler.CreateUser)
+		admin.PUT("/admin/users/:id", userHandler.UpdateUser)
+		admin.POST("/admin/users/:id/reset-password", userHandler.ResetPassword)
+		admin.DELETE("/admin/users/:id", userHandler.DeleteUser)
+		admin.DELETE("/admin/users/:id/totp", userHandler.ResetTOTP)
+		admin.GET("/admin/registration", userHandler.GetRegistration)
+		admin.PUT("/admin/registration", userHandler.UpdateRegistration)
+		admin.GET("/admin/invites", userHandler.ListInvites)
+		admin.POST("/admin/invites", userHandler.CreateInvite)
+		admin.DELETE("/admin/invites/:id", userHandler.DeleteInvite)
+
+		// Log retention and database maintenance
+		admin.GET("/admin/log-retention", logRetentionHandler.ListPolicies)
+		admin.PUT("/admin/log-retention/:table", logRetentionHandler.UpdatePolicy)
+		admin.POST("/admin/log-retention/cleanup", logRetentionHandler.Cleanup)
+		admin.GET("/admin/database/stats", logRetentionHandler.Stats)
+		admin.POST("/admin/database/vacuum", logRetentionHandler.Vacuum)
+	}
+
 	log.Printf("Server starting on :%s", cfg.ServerPort)
 	if err := r.Run(":" + cfg.ServerPort); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+func rotateMasterKey() {
+	newKey := os.Getenv("NEW_MASTER_KEY")
+	if newKey == "" {
+		log.Fatal("NEW_MASTER_KEY is required")
+	}
+	if err := service.RotateMasterKey(newKey); err != nil {
+		log.Fatalf("Failed to rotate master key: %v", err)
+	}
+	log.Println("Master key rotated, set MASTER_KEY to the new key before restarting")
+}
+
+// mockOptions reads the MOCK_* settings, ignoring invalid values
+func mockOptions(cfg *config.Config) mock.Options {
+	var opts mock.Options
+	if cfg.MockLatency != "" {
+		d, err := time.ParseDuration(cfg.MockLatency)
+		if err != nil || d < 0 {
+			log.Printf("Invalid MOCK_LATENCY %q, using no latency", cfg.MockLatency)
+		} else {
+			opts.Latency = d
+		}
+	}
+	if cfg.MockFailureRate != "" {
+		rate, err := strconv.ParseFloat(cfg.MockFailureRate, 64)
+		if err != nil || rate < 0 || rate > 1 {
+			log.Printf("Invalid MOCK_FAILURE_RATE %q, using no injected failures", cfg.MockFailureRate)
+		} else {
+			opts.FailureRate = rate
+		}
+	}
+	log.Printf("Mock provider enabled (latency %s, failure rate %g)", opts.Latency, opts.FailureRate)
+	return opts
+}
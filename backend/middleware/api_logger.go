@@ -33,7 +33,32 @@ func (w *responseWriter) Write(b []byte) (int, error) {
 
 const maxLoggedBodyBytes = 64 * 1024
 
-func shouldSkipAPILogging(path string) bool {
+// Routes whose request or response bodies carry credentials, passwords,
+// signing secrets or invite codes, for every method. Stored secrets are
+// encrypted at rest, so a plaintext copy in api_call_logs would undo that.
+var apiLogSecretRoutes = map[string]bool{
+	"/api/accounts":                       true,
+	"/api/accounts/test":                  true,
+	"/api/accounts/:id":                   true,
+	"/api/email/config":                   true,
+	"/api/whois/config":                   true,
+	"/api/webhooks":                       true,
+	"/api/webhooks/:id":                   true,
+	"/api/notification-channels":          true,
+	"/api/notification-channels/:id":      true,
+	"/api/user/password":                  true,
+	"/api/admin/users":                    true,
+	"/api/admin/users/:id/reset-password": true,
+	"/api/admin/invites":                  true,
+}
+
+// shouldSkipAPILogging decides by request path and, for apiLogSecretRoutes,
+// by the matched route pattern
+func shouldSkipAPILogging(path, route string) bool {
+	if apiLogSecretRoutes[route] {
+		return true
+	}
+
 	if path == "/health" || path == "/ping" {
 		return true
 	}
@@ -46,14 +71,22 @@ func shouldSkipAPILogging(path string) bool {
 
 	// Backup import/export carries sensitive configuration and must never be
 	// persisted in API logs.
-	return path == "/api/backup/export" || path == "/api/backup/import"
+	if path == "/api/backup/export" || path == "/api/backup/import" {
+		return true
+	}
+
+	// Token, 2FA and credential endpoints return or accept secrets
+	if strings.HasPrefix(path, "/api/api-tokens") || strings.HasPrefix(path, "/api/ddns-tokens") || strings.HasPrefix(path, "/api/user/totp") {
+		return true
+	}
+	return strings.HasPrefix(path, "/api/accounts/") && strings.HasSuffix(path, "/api-key")
 }
 
 // APILogger middleware records complete API call information
 func APILogger(logService *service.LogService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Skip logging for certain paths if needed
-		if shouldSkipAPILogging(c.Request.URL.Path) {
+		if shouldSkipAPILogging(c.Request.URL.Path, c.FullPath()) {
 			c.Next()
 			return
 		}
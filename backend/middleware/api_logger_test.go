@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestShouldSkipAPILogging(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		method string
+		route  string
+		path   string
+		skip   bool
+	}{
+		// Account credentials
+		{http.MethodPost, "/api/accounts", "/api/accounts", true},
+		{http.MethodPost, "/api/accounts/test", "/api/accounts/test", true},
+		{http.MethodPut, "/api/accounts/:id", "/api/accounts/3", true},
+		{http.MethodGet, "/api/accounts/:id/api-key", "/api/accounts/3/api-key", true},
+		// SMTP password and WHOIS API key
+		{http.MethodPut, "/api/email/config", "/api/email/config", true},
+		{http.MethodPut, "/api/whois/config", "/api/whois/config", true},
+		// Webhook signing secrets
+		{http.MethodPost, "/api/webhooks", "/api/webhooks", true},
+		{http.MethodPut, "/api/webhooks/:id", "/api/webhooks/4", true},
+		// Notification bot tokens and webhook URLs
+		{http.MethodPost, "/api/notification-channels", "/api/notification-channels", true},
+		{http.MethodPut, "/api/notification-channels/:id", "/api/notification-channels/5", true},
+		// Passwords
+		{http.MethodPut, "/api/user/password", "/api/user/password", true},
+		{http.MethodPost, "/api/admin/users", "/api/admin/users", true},
+		{http.MethodPost, "/api/admin/users/:id/reset-password", "/api/admin/users/6/reset-password", true},
+		// Invite codes
+		{http.MethodPost, "/api/admin/invites", "/api/admin/invites", true},
+		// Tokens, 2FA and backups
+		{http.MethodPost, "/api/api-tokens", "/api/api-tokens", true},
+		{http.MethodGet, "/api/ddns-tokens/:id/token", "/api/ddns-tokens/7/token", true},
+		{http.MethodPost, "/api/user/totp/setup", "/api/user/totp/setup", true},
+		{http.MethodPost, "/api/backup/export", "/api/backup/export", true},
+		// Ordinary routes are logged
+		{http.MethodPost, "/api/accounts/:id/test", "/api/accounts/3/test", false},
+		{http.MethodPut, "/api/admin/users/:id", "/api/admin/users/6", false},
+		{http.MethodPost, "/api/accounts/:id/domains/:domainId/records", "/api/accounts/3/domains/z/records", false},
+		{http.MethodPost, "/api/webhooks/:id/test", "/api/webhooks/4/test", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.route, func(t *testing.T) {
+			var skip bool
+			r := gin.New()
+			// Runs as a global middleware, like APILogger
+			r.Use(func(c *gin.Context) {
+				skip = shouldSkipAPILogging(c.Request.URL.Path, c.FullPath())
+			})
+			r.Handle(tt.method, tt.route, func(c *gin.Context) {})
+
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+			if skip != tt.skip {
+				t.Errorf("shouldSkipAPILogging(%s) = %v, want %v", tt.path, skip, tt.skip)
+			}
+		})
+	}
+}
@@ -6,12 +6,15 @@ import (
 	"strings"
 
 	"dns-mng/config"
+	"dns-mng/models"
+	"dns-mng/service"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+// AuthMiddleware accepts a JWT or a personal API token as the Bearer token
+func AuthMiddleware(cfg *config.Config, userService *service.UserService, apiTokenService *service.APITokenService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -27,9 +30,14 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
+		if service.IsAPIToken(parts[1]) {
+			authenticateAPIToken(c, apiTokenService, parts[1])
+			return
+		}
+
 		token, err := jwt.Parse(parts[1], func(token *jwt.Token) (interface{}, error) {
 			return []byte(cfg.JWTSecret), nil
-		})
+		}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
 
 		if err != nil || !token.Valid {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
@@ -51,7 +59,69 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
-		c.Set("user_id", int64(userIDFloat))
+		// Tokens issued before sessions existed carry no sid and are rejected
+		sessionIDFloat, ok := claims["sid"].(float64)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid session in token"})
+			c.Abort()
+			return
+		}
+		if err := userService.CheckSession(int64(userIDFloat), int64(sessionIDFloat)); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": service.ErrSessionRevoked.Error()})
+			c.Abort()
+			return
+		}
+
+		// Deleted or disabled users lose access even with a valid token
+		user, err := userService.GetUser(int64(userIDFloat))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+			c.Abort()
+			return
+		}
+		if user.Disabled {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": service.ErrUserDisabled.Error()})
+			c.Abort()
+			return
+		}
+
+		c.Set("user_id", user.ID)
+		c.Set("session_id", int64(sessionIDFloat))
+		c.Set("role", user.Role)
+		c.Next()
+	}
+}
+
+// authenticateAPIToken authorizes a request made with a personal API token.
+// The token's scopes decide which routes it reaches. "role" is left unset,
+// so admin routes never accept API tokens.
+func authenticateAPIToken(c *gin.Context, apiTokenService *service.APITokenService, value string) {
+	token, _, err := apiTokenService.Authenticate(value, c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.Abort()
+		return
+	}
+	if !apiTokenService.AllowsRequest(token, c.Request.Method, c.FullPath(), c.Param("id"), c.Param("domainId")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": service.ErrAPITokenScope.Error()})
+		c.Abort()
+		return
+	}
+
+	c.Set("user_id", token.UserID)
+	c.Set("api_token", token)
+	c.Next()
+}
+
+// AdminMiddleware restricts a route group to admins. It must run after
+// AuthMiddleware.
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("role") != models.RoleAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "admin privileges required"})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
@@ -61,6 +131,14 @@ func GetUserID(c *gin.Context) int64 {
 	return id.(int64)
 }
 
+// GetAPIToken returns the API token the request authenticated with, or nil
+// for JWT and password logins
+func GetAPIToken(c *gin.Context) *models.APIToken {
+	token, _ := c.Get("api_token")
+	t, _ := token.(*models.APIToken)
+	return t
+}
+
 func GetAccountID(c *gin.Context) (int64, error) {
 	return strconv.ParseInt(c.Param("id"), 10, 64)
 }
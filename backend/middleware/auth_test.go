@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"dns-mng/config"
+	"dns-mng/database"
+	"dns-mng/models"
+	"dns-mng/service"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestAuthMiddlewareAfterLogout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	database.Init(filepath.Join(t.TempDir(), "test.db"))
+	t.Cleanup(database.Close)
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if _, err := database.DB.Exec(`INSERT INTO users (id, username, password_hash) VALUES (1, 'alice', ?)`, string(hash)); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{JWTSecret: "auth-test-secret"}
+	users := service.NewUserService(cfg)
+	login, err := users.Login(&models.LoginRequest{Username: "alice", Password: "correct horse"}, &models.SessionClient{})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	r := gin.New()
+	r.GET("/api/user", AuthMiddleware(cfg, users, service.NewAPITokenService(users)), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	request := func() int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/user", nil)
+		req.Header.Set("Authorization", "Bearer "+login.Token)
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := request(); code != http.StatusNoContent {
+		t.Fatalf("before logout: status %d, want %d", code, http.StatusNoContent)
+	}
+	if err := users.RevokeSession(1, login.SessionID); err != nil {
+		t.Fatal(err)
+	}
+	// The access token has not expired, but its session is gone
+	if code := request(); code != http.StatusUnauthorized {
+		t.Errorf("after logout: status %d, want %d", code, http.StatusUnauthorized)
+	}
+}
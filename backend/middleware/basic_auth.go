@@ -3,14 +3,17 @@ package middleware
 import (
 	"net/http"
 
+	"dns-mng/models"
 	"dns-mng/service"
 
 	"github.com/gin-gonic/gin"
 )
 
-// BasicAuthMiddleware authenticates using HTTP Basic Auth against system users.
-// It sets "user_id" in gin context on success.
-func BasicAuthMiddleware(userService *service.UserService) gin.HandlerFunc {
+// BasicAuthMiddleware authenticates using HTTP Basic Auth. The username is
+// the system user and the password an API token of that user with the acme
+// scope; account passwords are not accepted since they bypass 2FA. It sets
+// "user_id" in gin context on success.
+func BasicAuthMiddleware(apiTokenService *service.APITokenService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		username, password, ok := c.Request.BasicAuth()
 		if !ok || username == "" || password == "" {
@@ -19,17 +22,22 @@ func BasicAuthMiddleware(userService *service.UserService) gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		if !service.IsAPIToken(password) {
+			c.Header("WWW-Authenticate", `Basic realm="dns-mng"`)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "an API token with the acme scope is required as password"})
+			c.Abort()
+			return
+		}
 
-		user, err := userService.VerifyCredentials(username, password)
-		if err != nil {
+		token, user, err := apiTokenService.Authenticate(password, c.ClientIP())
+		if err != nil || user.Username != username || !apiTokenService.HasScope(token, models.APITokenScopeACME) {
 			c.Header("WWW-Authenticate", `Basic realm="dns-mng"`)
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 			c.Abort()
 			return
 		}
-
 		c.Set("user_id", user.ID)
+		c.Set("api_token", token)
 		c.Next()
 	}
 }
-
@@ -31,17 +31,21 @@ func firstHeaderValue(value string) string {
 	return strings.TrimSpace(value)
 }
 
+// clientIPUnverifiedKey marks requests whose client IP came from a header
+// sent by a peer that is not a configured trusted proxy
+const clientIPUnverifiedKey = "client_ip_unverified"
+
 // RealIP recovers the originating client IP when the service sits behind a
-// reverse proxy or CDN (Cloudflare, Nginx, etc.). It reads the proxy-supplied
-// header, validates that it holds a single IP, and overwrites
-// c.Request.RemoteAddr so downstream handlers and c.ClientIP() report the
-// real client instead of the proxy/CDN node.
+// reverse proxy or CDN (Cloudflare, Nginx, etc.) and TRUSTED_PROXIES is not
+// set. It reads the proxy-supplied header, validates that it holds a single
+// IP, and overwrites c.Request.RemoteAddr so downstream handlers and
+// c.ClientIP() report the real client instead of the proxy/CDN node.
 //
-// This trusts the headers at face value. Make sure the service is only
-// reachable through the intended proxy/CDN (i.e. the proxy/CDN strips or
-// overwrites these headers before forwarding). With Cloudflare, the
-// CF-Connecting-IP header is set by the edge and cannot be spoofed by the
-// client, so it is the safest source.
+// Any caller can set these headers, so the rewritten IP is only good for
+// display and logging. Such requests are marked unverified and
+// TrustedClientIP does not return their IP. With TRUSTED_PROXIES set, gin
+// resolves the client IP from trusted peers itself and this middleware is
+// not installed.
 func RealIP() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		for _, header := range realIPHeaders {
@@ -70,9 +74,20 @@ func RealIP() gin.HandlerFunc {
 			} else {
 				c.Request.RemoteAddr = ip + ":0"
 			}
+			c.Set(clientIPUnverifiedKey, true)
 			break
 		}
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// TrustedClientIP returns the client IP for security decisions such as IP
+// allow-lists. It is empty when the IP came from a header that RealIP could
+// not verify.
+func TrustedClientIP(c *gin.Context) string {
+	if c.GetBool(clientIPUnverifiedKey) {
+		return ""
+	}
+	return c.ClientIP()
+}
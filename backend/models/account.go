@@ -3,22 +3,97 @@ package models
 import "time"
 
 type Account struct {
-	ID           int64     `json:"id"`
-	UserID       int64     `json:"user_id"`
-	Name         string    `json:"name"`
-	ProviderType string    `json:"provider_type"`
-	APIKey       string    `json:"api_key"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           int64  `json:"id"`
+	UserID       int64  `json:"user_id"`
+	Name         string `json:"name"`
+	ProviderType string `json:"provider_type"`
+	// APIKey is the credentials joined into the string providers take
+	APIKey string `json:"api_key"`
+	// Credentials are the values of the provider's credential fields;
+	// secret ones are masked in responses
+	Credentials Credentials `json:"credentials,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+	// Health is the state of recent provider calls, set by GET /accounts
+	Health *AccountHealth `json:"health,omitempty"`
+	// LastCheck is the latest connection test or listing outcome, nil
+	// before the first
+	LastCheck *AccountCheck `json:"last_check,omitempty"`
+	// FailingSince is set while the account's checks fail
+	FailingSince *time.Time `json:"failing_since,omitempty"`
+}
+
+// Account check codes. Checks that end in invalid_format or unauthorized
+// mean the credentials themselves are wrong, expired or revoked.
+const (
+	AccountCheckOK            = "ok"
+	AccountCheckInvalidFormat = "invalid_format"
+	AccountCheckUnauthorized  = "unauthorized"
+	AccountCheckRateLimited   = "rate_limited"
+	AccountCheckUnreachable   = "unreachable"
+	AccountCheckError         = "error"
+)
+
+// AccountCheck is the outcome of testing an account's credentials against
+// its provider
+type AccountCheck struct {
+	OK          bool      `json:"ok"`
+	Code        string    `json:"code"`
+	Error       string    `json:"error,omitempty"`
+	DomainCount int       `json:"domain_count"`
+	CheckedAt   time.Time `json:"checked_at"`
+	// Fields maps credential fields to their format errors
+	Fields map[string]string `json:"fields,omitempty"`
 }
 
+// CredentialsRejected reports whether the check failed on the credentials
+// rather than on the provider or the network
+func (c *AccountCheck) CredentialsRejected() bool {
+	return c.Code == AccountCheckInvalidFormat || c.Code == AccountCheckUnauthorized
+}
+
+// AccountHealth summarises recent provider calls for an account. Status is
+// unknown (no calls since start), healthy, degraded (recent failures) or
+// unhealthy (calls are failed fast until RetryAfter).
+type AccountHealth struct {
+	Status              string     `json:"status"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastSuccessAt       *time.Time `json:"last_success_at,omitempty"`
+	LastFailureAt       *time.Time `json:"last_failure_at,omitempty"`
+	RetryAfter          *time.Time `json:"retry_after,omitempty"`
+}
+
+// CreateAccountRequest takes the provider's credential fields, or the
+// legacy api_key string, which is split into them
 type CreateAccountRequest struct {
-	Name         string `json:"name" binding:"required"`
-	ProviderType string `json:"provider_type" binding:"required"`
-	APIKey       string `json:"api_key" binding:"required"`
+	Name         string      `json:"name" binding:"required"`
+	ProviderType string      `json:"provider_type" binding:"required"`
+	Credentials  Credentials `json:"credentials"`
+	APIKey       string      `json:"api_key"`
 }
 
+// UpdateAccountRequest changes the name or credentials. Empty or masked
+// secret fields keep their value; an empty api_key keeps the credentials.
 type UpdateAccountRequest struct {
-	Name   string `json:"name"`
-	APIKey string `json:"api_key"`
+	Name        string      `json:"name"`
+	Credentials Credentials `json:"credentials"`
+	APIKey      string      `json:"api_key"`
+}
+
+// TestAccountRequest is the body of POST /accounts/test, which checks
+// credentials before an account is saved, and of POST /accounts/:id/test,
+// where credentials are merged into the stored ones like in an update and
+// none at all test the stored ones
+type TestAccountRequest struct {
+	ProviderType string      `json:"provider_type"`
+	Credentials  Credentials `json:"credentials"`
+	APIKey       string      `json:"api_key"`
+}
+
+// AccountCapabilities lists the optional provider features available to an account
+type AccountCapabilities struct {
+	AccountID    int64    `json:"account_id"`
+	ProviderType string   `json:"provider_type"`
+	Capabilities []string `json:"capabilities"`
 }
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// API token scopes
+const (
+	APITokenScopeRead         = "read"          // GET requests on the protected API
+	APITokenScopeRecordsWrite = "records:write" // create, update and delete DNS records
+	APITokenScopeACME         = "acme"          // the /api/acme DNS-01 endpoints
+)
+
+// APITokenScopes lists every API token scope
+var APITokenScopes = []string{APITokenScopeRead, APITokenScopeRecordsWrite, APITokenScopeACME}
+
+// APIToken is a long-lived personal token for scripts and CI. Token is only
+// set in the create response; the database keeps a hash.
+type APIToken struct {
+	ID     int64    `json:"id"`
+	UserID int64    `json:"user_id"`
+	Name   string   `json:"name"`
+	Token  string   `json:"token,omitempty"`
+	Prefix string   `json:"prefix"` // start of the token, to tell tokens apart
+	Scopes []string `json:"scopes"`
+	// AccountIDs and Domains restrict records:write and acme. Empty means any.
+	AccountIDs []int64    `json:"account_ids"`
+	Domains    []string   `json:"domains"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	LastIP     string     `json:"last_ip,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type CreateAPITokenRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Scopes        []string `json:"scopes" binding:"required"`
+	AccountIDs    []int64  `json:"account_ids"`
+	Domains       []string `json:"domains"`
+	ExpiresInDays int      `json:"expires_in_days"` // 0 means no expiry
+}
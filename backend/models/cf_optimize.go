@@ -48,3 +48,22 @@ type CFOptimizeResponse struct {
 	CFOptimize
 	AccountName string `json:"account_name,omitempty"`
 }
+
+// CustomHostname is a customer hostname served through a zone's CDN
+type CustomHostname struct {
+	ID        string
+	Hostname  string
+	Status    string
+	SSLStatus string
+	// Validations are the records the provider wants published before it
+	// activates the hostname and issues its certificate; verified ones are
+	// left out
+	Validations []HostnameValidation
+}
+
+// HostnameValidation is a DNS record proving control of a custom hostname
+type HostnameValidation struct {
+	Type  string
+	Name  string
+	Value string
+}
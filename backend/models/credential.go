@@ -0,0 +1,17 @@
+package models
+
+// CredentialField describes one value of a provider's credentials, so the
+// UI can render a form and errors can point at the field.
+type CredentialField struct {
+	Name        string `json:"name"`
+	Label       string `json:"label"`
+	Secret      bool   `json:"secret"`
+	Required    bool   `json:"required"`
+	Placeholder string `json:"placeholder,omitempty"`
+	Help        string `json:"help,omitempty"`
+	// Pattern is a regular expression a non-empty value must match
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// Credentials are an account's credential values by field name
+type Credentials map[string]string
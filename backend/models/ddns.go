@@ -0,0 +1,106 @@
+package models
+
+import "time"
+
+// DDNS per-host update outcomes
+const (
+	DDNSStatusUpdated = "updated"
+	DDNSStatusCreated = "created"
+	DDNSStatusNoChg   = "nochg"
+	DDNSStatusNoHost  = "nohost"
+	DDNSStatusError   = "error"
+	// DDNSStatusDenied marks a hostname outside the token's scope
+	DDNSStatusDenied = "denied"
+)
+
+// DDNS update protocols, recorded in the history table
+const (
+	DDNSProtocolDuckDNS = "duckdns"
+	DDNSProtocolDynDNS2 = "dyndns2"
+	DDNSProtocolAgent   = "agent"
+)
+
+// DDNSRecordChange describes a single A/AAAA record touched by a DDNS update
+type DDNSRecordChange struct {
+	RecordID   string `json:"record_id"`
+	RecordType string `json:"record_type"`
+	OldIP      string `json:"old_ip,omitempty"`
+	NewIP      string `json:"new_ip"`
+	Created    bool   `json:"created,omitempty"`
+}
+
+// DDNSHostResult is the outcome of updating one hostname
+type DDNSHostResult struct {
+	Hostname   string             `json:"hostname"`
+	AccountID  int64              `json:"account_id,omitempty"`
+	DomainID   string             `json:"domain_id,omitempty"`
+	DomainName string             `json:"domain_name,omitempty"`
+	NodeName   string             `json:"node_name"`
+	Status     string             `json:"status"`
+	Changes    []DDNSRecordChange `json:"changes,omitempty"`
+	Error      string             `json:"error,omitempty"`
+	// Cached is set when nochg was answered from the last-known values
+	// without querying the provider
+	Cached bool `json:"cached,omitempty"`
+}
+
+// DDNSUpdateRequest describes one hostname to point at the given addresses.
+// Either IPv4 or IPv6 may be empty, in which case that family is left alone.
+type DDNSUpdateRequest struct {
+	Hostname string
+	IPv4     string
+	IPv6     string
+	// Create adds missing A/AAAA records instead of reporting nohost
+	Create bool
+}
+
+// DDNSCaller identifies who triggered a DDNS update
+type DDNSCaller struct {
+	UserID    int64
+	TokenID   int64
+	Protocol  string
+	IP        string
+	UserAgent string
+}
+
+// DDNSHistory is one persisted DDNS update attempt for a single record type
+type DDNSHistory struct {
+	ID         int64     `json:"id"`
+	UserID     int64     `json:"user_id"`
+	TokenID    int64     `json:"token_id"`
+	TokenName  string    `json:"token_name"`
+	Protocol   string    `json:"protocol"`
+	Hostname   string    `json:"hostname"`
+	AccountID  int64     `json:"account_id,omitempty"`
+	DomainID   string    `json:"domain_id,omitempty"`
+	DomainName string    `json:"domain_name,omitempty"`
+	RecordType string    `json:"record_type,omitempty"`
+	OldIP      string    `json:"old_ip,omitempty"`
+	NewIP      string    `json:"new_ip,omitempty"`
+	CallerIP   string    `json:"caller_ip"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// DDNSHistoryQuery filters the DDNS history list. Zero values are ignored.
+type DDNSHistoryQuery struct {
+	TokenID  int64
+	Hostname string
+	Status   string
+	// From and To are inclusive bounds: RFC3339, "2006-01-02 15:04:05" (UTC) or a bare date
+	From     string
+	To       string
+	Page     int
+	PageSize int
+}
+
+// DDNSHistoryListResponse represents a paginated list of DDNS history entries
+type DDNSHistoryListResponse struct {
+	Logs       []DDNSHistory `json:"logs"`
+	Total      int           `json:"total"`
+	Page       int           `json:"page"`
+	PageSize   int           `json:"page_size"`
+	TotalPages int           `json:"total_pages"`
+}
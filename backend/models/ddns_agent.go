@@ -0,0 +1,60 @@
+package models
+
+// DDNSAgent is a server-side DDNS job: dns-mng detects its own public IP
+// and points the configured hostnames at it, replacing ddclient on hosts
+// whose router cannot call the DDNS endpoint.
+type DDNSAgent struct {
+	ID        int64    `json:"id"`
+	UserID    int64    `json:"user_id"`
+	Name      string   `json:"name"`
+	Enabled   bool     `json:"enabled"`
+	Hostnames []string `json:"hostnames"`
+	// IPv4Sources / IPv6Sources are tried in order until one yields an
+	// address. Supported forms: an http(s) echo URL, "iface:<name>" and
+	// "stun:<host>:<port>". An empty list leaves that family alone.
+	IPv4Sources     []string `json:"ipv4_sources"`
+	IPv6Sources     []string `json:"ipv6_sources"`
+	IntervalMinutes int      `json:"interval_minutes"`
+	// Create adds missing A/AAAA records instead of reporting nohost
+	Create     bool   `json:"create"`
+	LastRunAt  string `json:"last_run_at,omitempty"`
+	LastIPv4   string `json:"last_ipv4,omitempty"`
+	LastIPv6   string `json:"last_ipv6,omitempty"`
+	LastStatus string `json:"last_status,omitempty"`
+	LastError  string `json:"last_error,omitempty"`
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
+}
+
+// CreateDDNSAgentRequest is the request body for creating a DDNS agent
+type CreateDDNSAgentRequest struct {
+	Name            string   `json:"name" binding:"required"`
+	Enabled         *bool    `json:"enabled"`
+	Hostnames       []string `json:"hostnames" binding:"required"`
+	IPv4Sources     []string `json:"ipv4_sources"`
+	IPv6Sources     []string `json:"ipv6_sources"`
+	IntervalMinutes int      `json:"interval_minutes"`
+	Create          bool     `json:"create"`
+}
+
+// UpdateDDNSAgentRequest is the request body for updating a DDNS agent.
+// Nil fields are left unchanged.
+type UpdateDDNSAgentRequest struct {
+	Name            *string   `json:"name"`
+	Enabled         *bool     `json:"enabled"`
+	Hostnames       *[]string `json:"hostnames"`
+	IPv4Sources     *[]string `json:"ipv4_sources"`
+	IPv6Sources     *[]string `json:"ipv6_sources"`
+	IntervalMinutes *int      `json:"interval_minutes"`
+	Create          *bool     `json:"create"`
+}
+
+// DDNSAgentRunResult is the outcome of one agent run
+type DDNSAgentRunResult struct {
+	AgentID int64             `json:"agent_id"`
+	IPv4    string            `json:"ipv4,omitempty"`
+	IPv6    string            `json:"ipv6,omitempty"`
+	Status  string            `json:"status"` // success, error
+	Hosts   []*DDNSHostResult `json:"hosts"`
+	Errors  []string          `json:"errors,omitempty"`
+}
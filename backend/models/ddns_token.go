@@ -1,18 +1,55 @@
 package models
 
-// DDNSToken is for user-level tokens (one token per user, system-wide)
+// DDNSToken is a named DDNS credential. A user may hold many tokens, each
+// optionally restricted to specific hostnames, record types and caller IPs.
 type DDNSToken struct {
-	ID         int64  `json:"id"`
-	UserID     int64  `json:"user_id"`
-	Token      string `json:"token"`
-	Enabled    bool   `json:"enabled"`
-	LastUsedAt string `json:"last_used_at,omitempty"`
-	LastIP     string `json:"last_ip,omitempty"`
-	CreatedAt  string `json:"created_at"`
-	UpdatedAt  string `json:"updated_at"`
+	ID      int64  `json:"id"`
+	UserID  int64  `json:"user_id"`
+	Name    string `json:"name"`
+	Token   string `json:"token"`
+	Enabled bool   `json:"enabled"`
+	// Hostnames the token may update; "*.example.com" matches any subdomain.
+	// Empty means any hostname.
+	Hostnames []string `json:"hostnames"`
+	// RecordTypes the token may update ("A", "AAAA"). Empty means both.
+	RecordTypes []string `json:"record_types"`
+	// AllowedIPs lists caller IPs or CIDRs allowed to use the token. Empty means any.
+	AllowedIPs []string `json:"allowed_ips"`
+	ExpiresAt  string   `json:"expires_at,omitempty"`
+	LastUsedAt string   `json:"last_used_at,omitempty"`
+	LastIP     string   `json:"last_ip,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+	UpdatedAt  string   `json:"updated_at"`
 }
 
+// UpdateDDNSTokenRequest is used by the legacy single-token endpoints
+// (/ddns-token), which operate on the user's oldest token.
 type UpdateDDNSTokenRequest struct {
 	Token   string `json:"token"`
 	Enabled *bool  `json:"enabled"`
 }
+
+// CreateDDNSTokenRequest is the request body for creating a scoped DDNS token
+type CreateDDNSTokenRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Token       string   `json:"token"`
+	Enabled     *bool    `json:"enabled"`
+	Hostnames   []string `json:"hostnames"`
+	RecordTypes []string `json:"record_types"`
+	AllowedIPs  []string `json:"allowed_ips"`
+	// ExpiresAt is RFC3339 or "2006-01-02 15:04:05"; empty means never
+	ExpiresAt string `json:"expires_at"`
+}
+
+// UpdateScopedDDNSTokenRequest is the request body for updating a scoped
+// DDNS token. Nil fields are left unchanged.
+type UpdateScopedDDNSTokenRequest struct {
+	Name        *string   `json:"name"`
+	Token       string    `json:"token"`
+	Enabled     *bool     `json:"enabled"`
+	Hostnames   *[]string `json:"hostnames"`
+	RecordTypes *[]string `json:"record_types"`
+	AllowedIPs  *[]string `json:"allowed_ips"`
+	// ExpiresAt set to "" clears the expiry
+	ExpiresAt *string `json:"expires_at"`
+}
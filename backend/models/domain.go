@@ -121,6 +121,15 @@ type UpdateDNSHEAutoRenewConfigRequest struct {
 	DaysBefore *int  `json:"days_before,omitempty"`
 }
 
+// SubdomainQuota is how many subdomains an account may register
+type SubdomainQuota struct {
+	Used        int `json:"used"`
+	Base        int `json:"base"`
+	InviteBonus int `json:"invite_bonus"`
+	Total       int `json:"total"`
+	Available   int `json:"available"`
+}
+
 // ResolveToCloudflareRequest is the request body for resolving a DNSHE domain to Cloudflare
 type ResolveToCloudflareRequest struct {
 	CloudflareAccountID int64 `json:"cloudflare_account_id" binding:"required"`
@@ -132,3 +141,8 @@ type ResolveToCloudflareResult struct {
 	ZoneName    string   `json:"zone_name"`
 	NameServers []string `json:"name_servers"`
 }
+
+// CreateZoneRequest is the request body for adding a zone to an account
+type CreateZoneRequest struct {
+	Name string `json:"name" binding:"required"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// DomainPendingDeletion is a cached domain the provider no longer lists,
+// waiting for the user to confirm the soft delete
+type DomainPendingDeletion struct {
+	ID          int64      `json:"id"`
+	AccountID   int64      `json:"account_id"`
+	AccountName string     `json:"account_name"`
+	DomainID    string     `json:"domain_id"`
+	DomainName  string     `json:"domain_name"`
+	DetectedAt  time.Time  `json:"detected_at"`
+	DismissedAt *time.Time `json:"dismissed_at,omitempty"`
+}
+
+// DomainRefreshSummary describes what one scheduled refresh of a user's
+// domains changed
+type DomainRefreshSummary struct {
+	UserID   int64    `json:"user_id"`
+	Total    int      `json:"total"`
+	Added    []string `json:"added"`
+	Restored []string `json:"restored"`
+	// PendingDeletion lists domains newly queued for review in this run
+	PendingDeletion []BatchCacheDeleteItem `json:"pending_deletion"`
+	// FailedAccounts maps account name to the provider error
+	FailedAccounts map[string]string `json:"failed_accounts,omitempty"`
+}
+
+// HasChanges reports whether the refresh found anything worth notifying
+func (s *DomainRefreshSummary) HasChanges() bool {
+	return len(s.Added) > 0 || len(s.Restored) > 0 || len(s.PendingDeletion) > 0
+}
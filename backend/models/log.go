@@ -59,10 +59,12 @@ type LoginLog struct {
 	Username   string    `json:"username"`
 	IPAddress  string    `json:"ip_address"`
 	IPLocation string    `json:"ip_location,omitempty"`
+	Country    string    `json:"country,omitempty"` // ISO country code from the IP lookup
 	UserAgent  string    `json:"user_agent,omitempty"`
 	Device     string    `json:"device"`
-	Status     string    `json:"status"` // success, failed
+	Status     string    `json:"status"` // success, failed, blocked
 	Message    string    `json:"message,omitempty"`
+	SessionID  int64     `json:"session_id,omitempty"` // session opened by a successful login
 	CreatedAt  time.Time `json:"created_at"`
 }
 
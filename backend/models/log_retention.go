@@ -0,0 +1,22 @@
+package models
+
+// LogRetentionPolicy limits how long a log table keeps rows. 0 means no
+// limit for that dimension.
+type LogRetentionPolicy struct {
+	Table   string `json:"table"`
+	MaxDays int    `json:"max_days"`
+	MaxRows int    `json:"max_rows"`
+}
+
+// UpdateLogRetentionRequest changes a policy; nil fields are left unchanged
+type UpdateLogRetentionRequest struct {
+	MaxDays *int `json:"max_days"`
+	MaxRows *int `json:"max_rows"`
+}
+
+// LogCleanupResult is the outcome of enforcing one table's policy
+type LogCleanupResult struct {
+	Table   string `json:"table"`
+	Deleted int64  `json:"deleted"`
+	Error   string `json:"error,omitempty"`
+}
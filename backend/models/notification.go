@@ -28,6 +28,7 @@ type EmailConfig struct {
 	ToEmail      string    `json:"to_email"` // Recipient email
 	Language     string    `json:"language"`  // Email language: zh, en, or empty (follow system)
 	Enabled      bool      `json:"enabled"`
+	Events       []string  `json:"events"` // Notification events sent by email
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
@@ -49,6 +50,8 @@ type UpdateEmailConfigRequest struct {
 	ToEmail      string `json:"to_email" binding:"required,email"`
 	Language     string `json:"language"`
 	Enabled      bool   `json:"enabled"`
+	// Events sent by email; nil keeps the current list
+	Events []string `json:"events"`
 }
 
 // TestEmailRequest is the request body for testing email configuration
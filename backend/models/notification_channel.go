@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// Notification events a channel can subscribe to
+const (
+	NotifyEventDomainExpiry     = "domain_expiry"
+	NotifyEventDNSHEAutoRenew   = "dnshe_auto_renew"
+	NotifyEventSchedulerFailure = "scheduler_failure"
+	NotifyEventDomainChanges    = "domain_changes"
+	NotifyEventNewLogin         = "new_login"
+	NotifyEventAccountFailing   = "account_failing"
+)
+
+// NotifyEvents lists every notification event
+var NotifyEvents = []string{NotifyEventDomainExpiry, NotifyEventDNSHEAutoRenew, NotifyEventSchedulerFailure, NotifyEventDomainChanges, NotifyEventNewLogin, NotifyEventAccountFailing}
+
+// NotificationChannel is a per-user delivery target such as a Telegram chat
+// or a Slack webhook. SMTP email keeps its own config in email_config.
+type NotificationChannel struct {
+	ID     int64             `json:"id"`
+	UserID int64             `json:"user_id"`
+	Name   string            `json:"name"`
+	Type   string            `json:"type"`
+	Config map[string]string `json:"config"`
+	// Events the channel receives; empty means all
+	Events    []string  `json:"events"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateNotificationChannelRequest is the request body for adding a channel
+type CreateNotificationChannelRequest struct {
+	Name    string            `json:"name" binding:"required"`
+	Type    string            `json:"type" binding:"required"`
+	Config  map[string]string `json:"config"`
+	Events  []string          `json:"events"`
+	Enabled *bool             `json:"enabled"`
+}
+
+// UpdateNotificationChannelRequest updates a channel; nil fields are kept.
+// Secret config values left empty or masked keep their stored value.
+type UpdateNotificationChannelRequest struct {
+	Name    *string           `json:"name"`
+	Config  map[string]string `json:"config"`
+	Events  []string          `json:"events"`
+	Enabled *bool             `json:"enabled"`
+}
+
+// NotificationChannelType describes a supported channel type for the UI
+type NotificationChannelType struct {
+	Type     string   `json:"type"`
+	Required []string `json:"required"`
+	Optional []string `json:"optional"`
+}
+
+// NotificationMessage is a channel-neutral notification
+type NotificationMessage struct {
+	Event string `json:"event"`
+	Title string `json:"title"`
+	Text  string `json:"text"`
+	URL   string `json:"url,omitempty"`
+	// Data carries structured details for generic webhooks
+	Data map[string]interface{} `json:"data,omitempty"`
+}
@@ -23,6 +23,9 @@ type CreateRecordRequest struct {
 	State      *bool  `json:"state"`
 	Content    string `json:"content" binding:"required"`
 	Priority   int    `json:"priority,omitempty"`
+	// Line is the resolver line on providers with record lines; empty
+	// means the default line (or, on updates, the current one)
+	Line string `json:"line,omitempty"`
 }
 
 type UpdateRecordRequest struct {
@@ -32,4 +35,21 @@ type UpdateRecordRequest struct {
 	State      *bool  `json:"state"`
 	Content    string `json:"content"`
 	Priority   int    `json:"priority,omitempty"`
+	Line       string `json:"line,omitempty"`
+}
+
+type BatchCreateRecordsRequest struct {
+	Records []CreateRecordRequest `json:"records" binding:"required,min=1,dive"`
+}
+
+// RecordLine is a resolver line a record can be answered on
+type RecordLine struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+	// ParentCode groups lines, e.g. a province under its ISP
+	ParentCode string `json:"parent_code,omitempty"`
+}
+
+type SetRecordProxiedRequest struct {
+	Proxied *bool `json:"proxied" binding:"required"`
 }
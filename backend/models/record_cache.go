@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Record change types detected when the record cache is re-synced
+const (
+	RecordChangeAdded    = "added"
+	RecordChangeRemoved  = "removed"
+	RecordChangeModified = "modified"
+)
+
+// Record cache states reported by ListRecords
+const (
+	RecordCacheHit   = "hit"   // served from the cache, within max age
+	RecordCacheMiss  = "miss"  // fetched live from the provider
+	RecordCacheStale = "stale" // provider unreachable, served an old cache
+)
+
+// RecordChange is a difference between the cached records and the provider,
+// i.e. an edit made outside dns-mng (e.g. in the provider console).
+type RecordChange struct {
+	ID         int64     `json:"id"`
+	UserID     int64     `json:"user_id"`
+	AccountID  int64     `json:"account_id"`
+	DomainID   string    `json:"domain_id"`
+	RecordID   string    `json:"record_id"`
+	ChangeType string    `json:"change_type"`
+	Old        *Record   `json:"old,omitempty"`
+	New        *Record   `json:"new,omitempty"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// RecordListResult is a record listing plus cache metadata
+type RecordListResult struct {
+	Records     []Record
+	CacheStatus string
+	SyncedAt    *time.Time
+	// Changes found while syncing, empty on a cache hit
+	Changes []RecordChange
+}
+
+// RecordChangeListResponse represents a paginated list of record changes
+type RecordChangeListResponse struct {
+	Changes    []RecordChange `json:"changes"`
+	Total      int            `json:"total"`
+	Page       int            `json:"page"`
+	PageSize   int            `json:"page_size"`
+	TotalPages int            `json:"total_pages"`
+}
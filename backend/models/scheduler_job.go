@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Scheduled job names; they double as scheduler_logs task names
+const (
+	JobDomainExpiry   = "domain_expiry_notification"
+	JobDNSHEAutoRenew = "dnshe_auto_renew"
+	JobDomainRefresh  = "domain_refresh"
+	JobDDNSReconcile  = "ddns_reconcile"
+	JobLogCleanup     = "log_cleanup"
+	JobBackup         = "backup"
+	JobAccountCheck   = "account_check"
+)
+
+// SchedulerJob is a registered background job and its cron schedule
+type SchedulerJob struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Schedule    string     `json:"schedule"`
+	Enabled     bool       `json:"enabled"`
+	Running     bool       `json:"running"`
+	Timezone    string     `json:"timezone"`
+	NextRunAt   *time.Time `json:"next_run_at,omitempty"`
+	LastRunAt   *time.Time `json:"last_run_at,omitempty"`
+	LastStatus  string     `json:"last_status,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+}
+
+// UpdateSchedulerJobRequest changes a job's schedule or enables/disables it
+type UpdateSchedulerJobRequest struct {
+	Schedule *string `json:"schedule"`
+	Enabled  *bool   `json:"enabled"`
+}
+
+// TriggerSchedulerJobRequest names the job /scheduler/trigger should run
+type TriggerSchedulerJobRequest struct {
+	Job string `json:"job"`
+}
@@ -2,29 +2,151 @@ package models
 
 import "time"
 
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+// Registration modes for /api/auth/register
+const (
+	RegistrationOpen     = "open"
+	RegistrationInvite   = "invite"
+	RegistrationDisabled = "disabled"
+)
+
 type User struct {
 	ID           int64     `json:"id"`
 	Username     string    `json:"username"`
 	PasswordHash string    `json:"-"`
+	Role         string    `json:"role"`
+	Disabled     bool      `json:"disabled"`
+	TOTPEnabled  bool      `json:"totp_enabled"`
 	CreatedAt    time.Time `json:"created_at"`
 }
 
 type LoginRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=32"`
 	Password string `json:"password" binding:"required,min=6,max=64"`
+	TOTPCode string `json:"totp_code"` // authenticator or recovery code when 2FA is enabled
 }
 
 type RegisterRequest struct {
-	Username string `json:"username" binding:"required,min=3,max=32"`
-	Password string `json:"password" binding:"required,min=6,max=64"`
+	Username   string `json:"username" binding:"required,min=3,max=32"`
+	Password   string `json:"password" binding:"required,min=6,max=64"`
+	InviteCode string `json:"invite_code"` // required in invite mode
 }
 
+// AuthResponse carries a short-lived access token and the refresh token
+// that renews it
 type AuthResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // access token lifetime in seconds
+	User         User   `json:"user"`
+	SessionID    int64  `json:"-"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// SessionClient describes the client a session is opened from
+type SessionClient struct {
+	IPAddress string
+	// TrustedIP is IPAddress when it is not a spoofable forwarded value,
+	// otherwise empty. The per-IP login lockout is keyed on it.
+	TrustedIP string
+	UserAgent string
+	Device    string
+}
+
+// UserSession is a signed-in client holding a refresh token. IPLocation
+// comes from the login log of the sign-in that opened it.
+type UserSession struct {
+	ID         int64     `json:"id"`
+	Device     string    `json:"device"`
+	IPAddress  string    `json:"ip_address"`
+	IPLocation string    `json:"ip_location,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	Current    bool      `json:"current"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
 }
 
 type UpdatePasswordRequest struct {
 	OldPassword string `json:"old_password" binding:"required,min=6,max=64"`
 	NewPassword string `json:"new_password" binding:"required,min=6,max=64"`
 }
+
+// RegistrationStatus tells the login page whether it can offer sign-up.
+// Bootstrap is set while no user exists; the first user becomes admin.
+type RegistrationStatus struct {
+	Mode      string `json:"mode"`
+	Bootstrap bool   `json:"bootstrap"`
+}
+
+type UpdateRegistrationRequest struct {
+	Mode string `json:"mode" binding:"required"`
+}
+
+type CreateUserRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=32"`
+	Password string `json:"password" binding:"required,min=6,max=64"`
+	Role     string `json:"role"` // defaults to user
+}
+
+// UpdateUserRequest changes role or disabled state; nil fields are kept
+type UpdateUserRequest struct {
+	Role     *string `json:"role"`
+	Disabled *bool   `json:"disabled"`
+}
+
+type ResetPasswordRequest struct {
+	NewPassword string `json:"new_password" binding:"required,min=6,max=64"`
+}
+
+// UserInvite is a single-use registration code. Code is only set in the
+// create response; the database keeps a hash.
+type UserInvite struct {
+	ID        int64      `json:"id"`
+	Code      string     `json:"code,omitempty"`
+	Note      string     `json:"note"`
+	CreatedBy int64      `json:"created_by"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	UsedBy    *int64     `json:"used_by,omitempty"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type CreateInviteRequest struct {
+	Note           string `json:"note"`
+	ExpiresInHours int    `json:"expires_in_hours"` // 0 means no expiry
+}
+
+// TOTPStatus is the current user's two-factor state
+type TOTPStatus struct {
+	Enabled           bool `json:"enabled"`
+	Pending           bool `json:"pending"` // setup started but not yet confirmed
+	RecoveryCodesLeft int  `json:"recovery_codes_left"`
+}
+
+// TOTPSetup is returned once when enrollment starts
+type TOTPSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"` // otpauth:// URI for authenticator apps
+}
+
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type DisableTOTPRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// RecoveryCodes are shown once after enabling 2FA or regenerating them
+type RecoveryCodes struct {
+	Codes []string `json:"codes"`
+}
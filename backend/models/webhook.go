@@ -0,0 +1,105 @@
+package models
+
+import "time"
+
+// Webhook event types
+const (
+	WebhookEventRecordCreated = "record.created"
+	WebhookEventRecordUpdated = "record.updated"
+	WebhookEventRecordDeleted = "record.deleted"
+	WebhookEventDDNSIPChanged = "ddns.ip_changed"
+	WebhookEventDomainAdded   = "domain.added"
+	WebhookEventDomainRemoved = "domain.removed"
+	WebhookEventACMEPresent   = "acme.present"
+	WebhookEventACMECleanup   = "acme.cleanup"
+)
+
+// WebhookEvents lists every event a webhook can subscribe to
+var WebhookEvents = []string{
+	WebhookEventRecordCreated,
+	WebhookEventRecordUpdated,
+	WebhookEventRecordDeleted,
+	WebhookEventDDNSIPChanged,
+	WebhookEventDomainAdded,
+	WebhookEventDomainRemoved,
+	WebhookEventACMEPresent,
+	WebhookEventACMECleanup,
+}
+
+// Webhook delivery states
+const (
+	WebhookDeliveryPending = "pending" // waiting for the first attempt or a retry
+	WebhookDeliverySuccess = "success"
+	WebhookDeliveryFailed  = "failed" // gave up after the last retry
+)
+
+// Webhook is a user's subscription to change events. Empty Events or
+// Domains means all events or all domains.
+type Webhook struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret"`
+	Events    []string  `json:"events"`
+	Domains   []string  `json:"domains"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateWebhookRequest represents the request to create a webhook. A secret
+// is generated when none is given.
+type CreateWebhookRequest struct {
+	Name    string   `json:"name" binding:"required"`
+	URL     string   `json:"url" binding:"required"`
+	Secret  string   `json:"secret"`
+	Events  []string `json:"events"`
+	Domains []string `json:"domains"`
+	Enabled *bool    `json:"enabled"`
+}
+
+// UpdateWebhookRequest represents the request to update a webhook; nil
+// fields are left unchanged
+type UpdateWebhookRequest struct {
+	Name    *string  `json:"name"`
+	URL     *string  `json:"url"`
+	Secret  *string  `json:"secret"`
+	Events  []string `json:"events"`
+	Domains []string `json:"domains"`
+	Enabled *bool    `json:"enabled"`
+}
+
+// WebhookEvent is one change to be delivered to matching webhooks
+type WebhookEvent struct {
+	Event     string      `json:"event"`
+	AccountID int64       `json:"account_id,omitempty"`
+	DomainID  string      `json:"domain_id,omitempty"`
+	Domain    string      `json:"domain,omitempty"`
+	Data      interface{} `json:"data,omitempty"`
+}
+
+// WebhookDelivery is one event sent (or to be sent) to one webhook
+type WebhookDelivery struct {
+	ID             int64      `json:"id"`
+	WebhookID      int64      `json:"webhook_id"`
+	Event          string     `json:"event"`
+	Domain         string     `json:"domain,omitempty"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// WebhookDeliveryListResponse represents a paginated list of deliveries
+type WebhookDeliveryListResponse struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+	Total      int               `json:"total"`
+	Page       int               `json:"page"`
+	PageSize   int               `json:"page_size"`
+	TotalPages int               `json:"total_pages"`
+}
@@ -3,19 +3,17 @@ package models
 import "time"
 
 // WHOISConfig represents WHOIS lookup configuration for a user.
-// The API key is returned in plaintext to the owning user (same pattern as the
-// DDNS token: the key belongs to the user and they may view/edit it directly).
-// All endpoints are JWT-protected, so the key is only returned to its owner.
+// The API key is stored encrypted and masked in API responses.
 type WHOISConfig struct {
 	ID        int64     `json:"id"`
 	UserID    int64     `json:"user_id"`
-	APIKey    string    `json:"api_key"` // Returned in plaintext to the owning user
+	APIKey    string    `json:"api_key"` // Masked in API responses
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // UpdateWHOISConfigRequest is the request body for updating WHOIS configuration.
-// APIKey has no binding:"required", so an empty value means "keep existing key".
+// APIKey has no binding:"required", so an empty or masked value means "keep existing key".
 type UpdateWHOISConfigRequest struct {
 	APIKey string `json:"api_key"`
 }
@@ -0,0 +1,53 @@
+package models
+
+// Per-record outcomes of a zone clone
+const (
+	ZoneCloneCreated = "created"
+	ZoneClonePlanned = "planned" // dry run: would be created
+	ZoneCloneExists  = "exists"  // an identical record is already in the target
+	ZoneCloneSkipped = "skipped" // not transferable to the target provider
+	ZoneCloneFailed  = "failed"
+)
+
+// ZoneCloneRequest copies a zone's records to another account/provider.
+// TargetDomainID may be empty to look the zone up by name in the target
+// account, creating it when CreateZone is set and the provider allows it.
+type ZoneCloneRequest struct {
+	TargetAccountID int64  `json:"target_account_id" binding:"required"`
+	TargetDomainID  string `json:"target_domain_id"`
+	CreateZone      bool   `json:"create_zone"`
+	DryRun          bool   `json:"dry_run"`
+	// Verify resolves every cloned record afterwards, against the target's
+	// nameservers when the provider reports them, else public resolvers
+	Verify bool `json:"verify"`
+}
+
+// ZoneCloneRecord is the report line for one source record
+type ZoneCloneRecord struct {
+	Source   Record   `json:"source"`
+	Target   *Record  `json:"target,omitempty"`
+	Status   string   `json:"status"`
+	Notes    []string `json:"notes,omitempty"`
+	Error    string   `json:"error,omitempty"`
+	Verified *bool    `json:"verified,omitempty"`
+	// VerifyMessage explains the verification outcome
+	VerifyMessage string `json:"verify_message,omitempty"`
+}
+
+// ZoneCloneResult is the per-record report of a zone clone
+type ZoneCloneResult struct {
+	SourceAccountID int64             `json:"source_account_id"`
+	SourceDomainID  string            `json:"source_domain_id"`
+	TargetAccountID int64             `json:"target_account_id"`
+	TargetDomainID  string            `json:"target_domain_id"`
+	Zone            string            `json:"zone"`
+	ZoneCreated     bool              `json:"zone_created"`
+	DryRun          bool              `json:"dry_run"`
+	Records         []ZoneCloneRecord `json:"records"`
+	Created         int               `json:"created"`
+	Existing        int               `json:"existing"`
+	Skipped         int               `json:"skipped"`
+	Failed          int               `json:"failed"`
+	// VerifyServers lists the resolvers used for verification
+	VerifyServers []string `json:"verify_servers,omitempty"`
+}
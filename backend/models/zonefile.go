@@ -0,0 +1,35 @@
+package models
+
+// Zone import actions
+const (
+	ZoneChangeCreate = "create"
+	ZoneChangeUpdate = "update"
+	ZoneChangeDelete = "delete"
+)
+
+// ZoneImportRequest carries a BIND zone file to import into a domain.
+// DryRun defaults to true when omitted so a plain call only shows the diff.
+type ZoneImportRequest struct {
+	Content string `json:"content" binding:"required"`
+	DryRun  *bool  `json:"dry_run"`
+}
+
+// ZoneChange is one planned (or applied) change of a zone import
+type ZoneChange struct {
+	Action  string  `json:"action"`
+	Record  *Record `json:"record,omitempty"`  // desired record (create/update)
+	Current *Record `json:"current,omitempty"` // existing record (update/delete)
+	Error   string  `json:"error,omitempty"`
+}
+
+// ZoneImportResult is the diff of a zone file against the current records
+type ZoneImportResult struct {
+	Zone      string       `json:"zone"`
+	DryRun    bool         `json:"dry_run"`
+	Changes   []ZoneChange `json:"changes"`
+	Unchanged int          `json:"unchanged"`
+	// Ignored lists parsed records that are left to the provider (apex NS)
+	Ignored []Record `json:"ignored"`
+	Applied int      `json:"applied"`
+	Failed  int      `json:"failed"`
+}
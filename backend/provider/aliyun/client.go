@@ -3,6 +3,7 @@ package aliyun
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/requests"
@@ -16,7 +17,10 @@ const (
 	domainsPageSz       = 100
 )
 
-type Client struct{}
+type Client struct {
+	// endpoint overrides the Alidns endpoint when set, e.g. "http://127.0.0.1:8080"
+	endpoint string
+}
 
 func NewClient() *Client {
 	return &Client{}
@@ -27,7 +31,11 @@ func (c *Client) parseAPIKey(apiKey string) (accessKeyID, accessKeySecret string
 	if len(parts) != 2 {
 		return "", "", fmt.Errorf("invalid API key format, expected 'AccessKeyId,AccessKeySecret'")
 	}
-	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), nil
+	accessKeyID, accessKeySecret = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	if accessKeyID == "" || accessKeySecret == "" {
+		return "", "", fmt.Errorf("invalid API key: empty AccessKeyId or AccessKeySecret")
+	}
+	return accessKeyID, accessKeySecret, nil
 }
 
 func (c *Client) newDNSClient(apiKey string) (*alidns.Client, error) {
@@ -35,7 +43,17 @@ func (c *Client) newDNSClient(apiKey string) (*alidns.Client, error) {
 	if err != nil {
 		return nil, err
 	}
-	return alidns.NewClientWithAccessKey(aliyunRegion, ak, sk)
+	client, err := alidns.NewClientWithAccessKey(aliyunRegion, ak, sk)
+	if err != nil || c.endpoint == "" {
+		return client, err
+	}
+	u, err := url.Parse(c.endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	client.Domain = u.Host
+	client.GetConfig().Scheme = strings.ToUpper(u.Scheme)
+	return client, nil
 }
 
 func rrToNodeName(rr string) string {
@@ -153,6 +171,22 @@ func (c *Client) ListAllRecords(ctx context.Context, apiKey, domainName string)
 	return out, nil
 }
 
+// ListSupportLines returns the resolver lines a domain's records may use.
+func (c *Client) ListSupportLines(ctx context.Context, apiKey, domainName string) ([]alidns.RecordLine, error) {
+	_ = ctx
+	client, err := c.newDNSClient(apiKey)
+	if err != nil {
+		return nil, err
+	}
+	req := alidns.CreateDescribeSupportLinesRequest()
+	req.DomainName = domainName
+	resp, err := client.DescribeSupportLines(req)
+	if err != nil {
+		return nil, fmt.Errorf("describe support lines: %w", err)
+	}
+	return resp.RecordLines.RecordLine, nil
+}
+
 func (c *Client) AddDomainRecord(ctx context.Context, apiKey, domainName, rr, line, recordType, value string, ttl int64, priority int64) (string, error) {
 	_ = ctx
 	client, err := c.newDNSClient(apiKey)
@@ -20,6 +20,14 @@ func New() *Provider {
 	}
 }
 
+// NewWithEndpoint returns a provider that sends Alidns calls to endpoint
+// instead of alidns.aliyuncs.com, e.g. a stand-in server in tests
+func NewWithEndpoint(endpoint string) *Provider {
+	p := New()
+	p.client.endpoint = endpoint
+	return p
+}
+
 func (p *Provider) Name() string {
 	return "aliyun"
 }
@@ -36,6 +44,27 @@ func (p *Provider) DefaultTTL() int {
 	return 600
 }
 
+func (p *Provider) CredentialFields() []models.CredentialField {
+	return []models.CredentialField{
+		{Name: "access_key_id", Label: "AccessKey ID", Required: true},
+		{Name: "access_key_secret", Label: "AccessKey Secret", Secret: true, Required: true},
+	}
+}
+
+func (p *Provider) ValidateAPIKey(apiKey string) error {
+	_, _, err := p.client.parseAPIKey(apiKey)
+	return err
+}
+
+// The free Alibaba Cloud DNS plan does not accept TTLs below 600.
+func (p *Provider) MinTTL() int {
+	return 600
+}
+
+func (p *Provider) ApexCNAME() bool {
+	return false
+}
+
 func (p *Provider) ListDomains(ctx context.Context, apiKey string) ([]models.Domain, error) {
 	list, err := p.client.ListDomains(ctx, apiKey)
 	if err != nil {
@@ -135,6 +164,23 @@ func (p *Provider) ListRecords(ctx context.Context, apiKey string, domainID stri
 	return out, nil
 }
 
+// ListRecordLines implements provider.RecordLineLister.
+func (p *Provider) ListRecordLines(ctx context.Context, apiKey string, domainID string) ([]models.RecordLine, error) {
+	lines, err := p.client.ListSupportLines(ctx, apiKey, domainID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]models.RecordLine, 0, len(lines))
+	for _, l := range lines {
+		name := l.LineDisplayName
+		if name == "" {
+			name = l.LineName
+		}
+		out = append(out, models.RecordLine{Code: l.LineCode, Name: name, ParentCode: l.FatherCode})
+	}
+	return out, nil
+}
+
 func (p *Provider) CreateRecord(ctx context.Context, apiKey string, domainID string, record *models.Record) (*models.Record, error) {
 	ttl := int64(record.TTL)
 	if ttl <= 0 {
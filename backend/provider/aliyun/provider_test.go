@@ -0,0 +1,224 @@
+package aliyun
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"dns-mng/models"
+	"dns-mng/provider/providertest"
+
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/alidns"
+)
+
+const (
+	testAccessKeyID     = "LTAItestkey"
+	testAccessKeySecret = "test-secret"
+)
+
+// fakeAlidns serves Alidns RPC calls: the action and access key arrive as
+// query parameters, API parameters as a form, and errors carry a Code.
+// Records use RR "@" at the apex and carry an ENABLE/DISABLE status.
+type fakeAlidns struct {
+	zone *providertest.Zone
+}
+
+func fail(w http.ResponseWriter, status int, code, msg string) {
+	providertest.WriteJSON(w, status, map[string]string{
+		"RequestId": "00000000-0000-0000-0000-000000000000", "Code": code, "Message": msg,
+	})
+}
+
+func (f *fakeAlidns) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		fail(w, http.StatusBadRequest, "InvalidParameter", err.Error())
+		return
+	}
+	if r.Form.Get("AccessKeyId") != testAccessKeyID {
+		fail(w, http.StatusNotFound, "InvalidAccessKeyId.NotFound", "Specified access key is not found.")
+		return
+	}
+
+	switch r.Form.Get("Action") {
+	case "DescribeDomains":
+		providertest.WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"TotalCount": 1, "PageNumber": 1, "PageSize": domainsPageSz,
+			"Domains": map[string]interface{}{"Domain": []alidns.DomainInDescribeDomains{{
+				DomainId: "domain-1", DomainName: f.zone.Name, CreateTime: "2026-01-01T00:00Z",
+			}}},
+		})
+	case "DescribeDomainNs":
+		if !f.ownsDomain(w, r) {
+			return
+		}
+		providertest.WriteJSON(w, http.StatusOK, map[string]interface{}{"AllAliDns": true, "IncludeAliDns": true})
+	case "DescribeDomainInfo":
+		if !f.ownsDomain(w, r) {
+			return
+		}
+		providertest.WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"DomainId": "domain-1", "DomainName": f.zone.Name, "CreateTime": "2026-01-01T00:00Z",
+		})
+	case "DescribeSupportLines":
+		if !f.ownsDomain(w, r) {
+			return
+		}
+		providertest.WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"RecordLines": map[string]interface{}{"RecordLine": []alidns.RecordLine{
+				{LineCode: "default", LineName: "默认", LineDisplayName: "默认"},
+				{LineCode: "telecom", LineName: "电信", LineDisplayName: "中国电信"},
+				{LineCode: "cn_telecom_beijing", LineName: "北京", FatherCode: "telecom"},
+			}},
+		})
+	case "DescribeDomainRecords":
+		if !f.ownsDomain(w, r) {
+			return
+		}
+		records := []alidns.Record{}
+		for _, rec := range f.zone.Records() {
+			records = append(records, f.toAlidns(rec))
+		}
+		providertest.WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"TotalCount": len(records), "PageNumber": 1, "PageSize": domainRecordsPageSz,
+			"DomainRecords": map[string]interface{}{"Record": records},
+		})
+	case "AddDomainRecord":
+		if !f.ownsDomain(w, r) {
+			return
+		}
+		rec, ok := f.formRecord(w, r)
+		if !ok {
+			return
+		}
+		rec = f.zone.Add(rec)
+		providertest.WriteJSON(w, http.StatusOK, map[string]string{"RecordId": rec.ID})
+	case "UpdateDomainRecord":
+		old, ok := f.zone.Get(r.Form.Get("RecordId"))
+		if !ok {
+			fail(w, http.StatusBadRequest, "DomainRecordNotBelongToUser", "The DNS record does not exist.")
+			return
+		}
+		rec, ok := f.formRecord(w, r)
+		if !ok {
+			return
+		}
+		rec.ID, rec.Disabled = old.ID, old.Disabled
+		if rec == old {
+			fail(w, http.StatusBadRequest, "DomainRecordDuplicate", "The DNS record already exists.")
+			return
+		}
+		f.zone.Update(rec)
+		providertest.WriteJSON(w, http.StatusOK, map[string]string{"RecordId": rec.ID})
+	case "SetDomainRecordStatus":
+		rec, ok := f.zone.Get(r.Form.Get("RecordId"))
+		if !ok {
+			fail(w, http.StatusBadRequest, "DomainRecordNotBelongToUser", "The DNS record does not exist.")
+			return
+		}
+		rec.Disabled = r.Form.Get("Status") == "DISABLE"
+		f.zone.Update(rec)
+		providertest.WriteJSON(w, http.StatusOK, map[string]string{"Status": r.Form.Get("Status")})
+	case "DeleteDomainRecord":
+		if !f.zone.Delete(r.Form.Get("RecordId")) {
+			fail(w, http.StatusBadRequest, "DomainRecordNotBelongToUser", "The DNS record does not exist.")
+			return
+		}
+		providertest.WriteJSON(w, http.StatusOK, map[string]string{"RecordId": r.Form.Get("RecordId")})
+	default:
+		fail(w, http.StatusNotFound, "InvalidAction.NotFound", "Specified api is not found, please check your url and method.")
+	}
+}
+
+func (f *fakeAlidns) ownsDomain(w http.ResponseWriter, r *http.Request) bool {
+	if r.Form.Get("DomainName") != f.zone.Name {
+		fail(w, http.StatusBadRequest, "InvalidDomainName.NoExist", "The specified domain name does not exist.")
+		return false
+	}
+	return true
+}
+
+func (f *fakeAlidns) formRecord(w http.ResponseWriter, r *http.Request) (providertest.Record, bool) {
+	ttl, err := strconv.Atoi(r.Form.Get("TTL"))
+	if err != nil || ttl < 600 {
+		fail(w, http.StatusBadRequest, "DomainRecordTTLCheckFailed", "The TTL is smaller than the minimum of your plan.")
+		return providertest.Record{}, false
+	}
+	priority, _ := strconv.Atoi(r.Form.Get("Priority"))
+	return providertest.Record{
+		Name:     f.zone.Relative(r.Form.Get("RR")),
+		Type:     r.Form.Get("Type"),
+		Content:  r.Form.Get("Value"),
+		TTL:      ttl,
+		Priority: priority,
+	}, true
+}
+
+func (f *fakeAlidns) toAlidns(rec providertest.Record) alidns.Record {
+	rr := rec.Name
+	if rr == "" {
+		rr = "@"
+	}
+	status := "ENABLE"
+	if rec.Disabled {
+		status = "DISABLE"
+	}
+	return alidns.Record{
+		RecordId:   rec.ID,
+		DomainName: f.zone.Name,
+		RR:         rr,
+		Type:       rec.Type,
+		Value:      rec.Content,
+		TTL:        int64(rec.TTL),
+		Priority:   int64(rec.Priority),
+		Line:       defaultRecordLine,
+		Status:     status,
+	}
+}
+
+func TestConformance(t *testing.T) {
+	server := httptest.NewServer(&fakeAlidns{zone: providertest.NewZone("example.com")})
+	defer server.Close()
+
+	providertest.Run(t, NewWithEndpoint(server.URL), providertest.Config{
+		APIKey:    testAccessKeyID + "," + testAccessKeySecret,
+		BadAPIKey: "LTAIwrongkey," + testAccessKeySecret,
+		AuthError: "InvalidAccessKeyId.NotFound",
+		Zone:      "example.com",
+	})
+}
+
+func TestValidateAPIKey(t *testing.T) {
+	p := New()
+	for key, valid := range map[string]bool{
+		testAccessKeyID + "," + testAccessKeySecret:         true,
+		" " + testAccessKeyID + " , " + testAccessKeySecret: true,
+		testAccessKeyID + ":" + testAccessKeySecret:         false,
+		testAccessKeyID + ",":                               false,
+		"," + testAccessKeySecret:                           false,
+	} {
+		if err := p.ValidateAPIKey(key); (err == nil) != valid {
+			t.Errorf("ValidateAPIKey(%q) = %v, want valid %v", key, err, valid)
+		}
+	}
+}
+
+func TestListRecordLines(t *testing.T) {
+	server := httptest.NewServer(&fakeAlidns{zone: providertest.NewZone("example.com")})
+	defer server.Close()
+
+	lines, err := NewWithEndpoint(server.URL).ListRecordLines(context.Background(), testAccessKeyID+","+testAccessKeySecret, "example.com")
+	if err != nil {
+		t.Fatalf("ListRecordLines: %v", err)
+	}
+	want := []models.RecordLine{
+		{Code: "default", Name: "默认"},
+		{Code: "telecom", Name: "中国电信"},
+		{Code: "cn_telecom_beijing", Name: "北京", ParentCode: "telecom"},
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("lines = %+v, want %+v", lines, want)
+	}
+}
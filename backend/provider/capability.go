@@ -0,0 +1,166 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"dns-mng/models"
+)
+
+// ErrNotSupported is returned when an optional capability is requested from
+// a provider that does not implement it.
+var ErrNotSupported = errors.New("operation not supported by this provider")
+
+// Capability identifies an optional feature a provider can opt into on top
+// of the core DNSProvider contract.
+type Capability string
+
+const (
+	CapBatchRecords   Capability = "batch_records"
+	CapZoneManagement Capability = "zone_management"
+	CapProxiedRecords Capability = "proxied_records"
+	CapNameservers    Capability = "nameservers"
+	CapDomainRenewal  Capability = "domain_renewal"
+	CapRecordLines    Capability = "record_lines"
+	CapCustomHostname Capability = "custom_hostnames"
+	CapSubdomains     Capability = "subdomain_registration"
+)
+
+// BatchRecordWriter is implemented by providers that can create several
+// records in a single API round-trip.
+type BatchRecordWriter interface {
+	CreateRecords(ctx context.Context, apiKey string, domainID string, records []*models.Record) ([]*models.Record, error)
+}
+
+// ZoneManager is implemented by providers that allow zones to be added to
+// or removed from an account through the API.
+type ZoneManager interface {
+	CreateZone(ctx context.Context, apiKey string, name string) (*models.Domain, error)
+	DeleteZone(ctx context.Context, apiKey string, domainID string) error
+}
+
+// ProxiedRecordSetter is implemented by providers that can toggle CDN
+// proxying on individual records (e.g. Cloudflare's orange cloud).
+type ProxiedRecordSetter interface {
+	SetRecordProxied(ctx context.Context, apiKey string, domainID string, recordID string, proxied bool) (*models.Record, error)
+}
+
+// NameserverReporter is implemented by providers that can report the
+// authoritative nameservers assigned to a zone.
+type NameserverReporter interface {
+	GetNameservers(ctx context.Context, apiKey string, domainID string) ([]string, error)
+}
+
+// DomainRenewer is implemented by providers whose domains expire and can be
+// renewed through the API (e.g. DNSHE free subdomains).
+type DomainRenewer interface {
+	RenewDomain(ctx context.Context, apiKey string, domainID string) error
+}
+
+// RecordLineLister is implemented by providers that answer a record
+// differently per resolver line (e.g. Aliyun ISP and region lines). The
+// line of a record travels in Record.Raw["line"].
+type RecordLineLister interface {
+	ListRecordLines(ctx context.Context, apiKey string, domainID string) ([]models.RecordLine, error)
+}
+
+// CustomHostnameManager is implemented by providers that serve customer
+// hostnames through a zone's CDN with a fallback origin (e.g. Cloudflare
+// for SaaS).
+type CustomHostnameManager interface {
+	CreateCustomHostname(ctx context.Context, apiKey string, domainID string, hostname string, origin string) (*models.CustomHostname, error)
+	GetCustomHostname(ctx context.Context, apiKey string, domainID string, hostnameID string) (*models.CustomHostname, error)
+	DeleteCustomHostname(ctx context.Context, apiKey string, domainID string, hostnameID string) error
+	SetFallbackOrigin(ctx context.Context, apiKey string, domainID string, origin string) error
+	DeleteFallbackOrigin(ctx context.Context, apiKey string, domainID string) error
+}
+
+// SubdomainRegistrar is implemented by providers that hand out subdomains
+// of shared parent domains against a per-account quota (e.g. DNSHE).
+// Registered subdomains are listed as the account's domains.
+type SubdomainRegistrar interface {
+	SubdomainQuota(ctx context.Context, apiKey string) (*models.SubdomainQuota, error)
+	RegisterSubdomain(ctx context.Context, apiKey string, name string, parent string) (*models.Domain, error)
+	DeleteSubdomain(ctx context.Context, apiKey string, domainID string) error
+}
+
+// Capabilities returns the optional capabilities implemented by p, in a
+// stable order.
+func Capabilities(p DNSProvider) []Capability {
+	caps := []Capability{}
+	if _, ok := p.(BatchRecordWriter); ok {
+		caps = append(caps, CapBatchRecords)
+	}
+	if _, ok := p.(ZoneManager); ok {
+		caps = append(caps, CapZoneManagement)
+	}
+	if _, ok := p.(ProxiedRecordSetter); ok {
+		caps = append(caps, CapProxiedRecords)
+	}
+	if _, ok := p.(NameserverReporter); ok {
+		caps = append(caps, CapNameservers)
+	}
+	if _, ok := p.(DomainRenewer); ok {
+		caps = append(caps, CapDomainRenewal)
+	}
+	if _, ok := p.(RecordLineLister); ok {
+		caps = append(caps, CapRecordLines)
+	}
+	if _, ok := p.(CustomHostnameManager); ok {
+		caps = append(caps, CapCustomHostname)
+	}
+	if _, ok := p.(SubdomainRegistrar); ok {
+		caps = append(caps, CapSubdomains)
+	}
+	return caps
+}
+
+// Supports reports whether p implements the given capability.
+func Supports(p DNSProvider, c Capability) bool {
+	for _, have := range Capabilities(p) {
+		if have == c {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordConstraints is implemented by providers whose record rules are
+// stricter (or looser) than plain RFC 1035. It is used to translate records
+// when copying a zone between providers.
+type RecordConstraints interface {
+	// MinTTL is the lowest TTL the provider accepts
+	MinTTL() int
+	// ApexCNAME reports whether a CNAME at the zone apex is accepted
+	// (e.g. Cloudflare's CNAME flattening)
+	ApexCNAME() bool
+}
+
+// ConstraintsOf returns p's record constraints, defaulting to no minimum
+// TTL and no apex CNAME.
+func ConstraintsOf(p DNSProvider) (minTTL int, apexCNAME bool) {
+	if rc, ok := p.(RecordConstraints); ok {
+		return rc.MinTTL(), rc.ApexCNAME()
+	}
+	return 0, false
+}
+
+// APIKeyValidator is implemented by providers whose API key packs several
+// values (e.g. "AccessKeyId,AccessKeySecret"), so that a malformed key is
+// rejected when the account is saved rather than on its first call.
+type APIKeyValidator interface {
+	ValidateAPIKey(apiKey string) error
+}
+
+// ValidateAPIKey checks the format of apiKey for p without calling the
+// provider.
+func ValidateAPIKey(p DNSProvider, apiKey string) error {
+	if strings.TrimSpace(apiKey) == "" {
+		return errors.New("API key is empty")
+	}
+	if v, ok := p.(APIKeyValidator); ok {
+		return v.ValidateAPIKey(apiKey)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package provider_test
+
+import (
+	"reflect"
+	"testing"
+
+	"dns-mng/provider"
+	"dns-mng/provider/aliyun"
+	"dns-mng/provider/cloudflare"
+	"dns-mng/provider/dnshe"
+	"dns-mng/provider/mock"
+)
+
+func TestCapabilities(t *testing.T) {
+	tests := []struct {
+		p    provider.DNSProvider
+		want []provider.Capability
+	}{
+		{cloudflare.New(), []provider.Capability{
+			provider.CapBatchRecords,
+			provider.CapZoneManagement,
+			provider.CapProxiedRecords,
+			provider.CapNameservers,
+			provider.CapCustomHostname,
+		}},
+		{dnshe.New(), []provider.Capability{provider.CapDomainRenewal, provider.CapSubdomains}},
+		{aliyun.New(), []provider.Capability{provider.CapRecordLines}},
+		{mock.New(mock.Options{}), []provider.Capability{provider.CapZoneManagement, provider.CapNameservers}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.p.Name(), func(t *testing.T) {
+			got := provider.Capabilities(tt.p)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Capabilities = %v, want %v", got, tt.want)
+			}
+			for _, c := range []provider.Capability{
+				provider.CapBatchRecords, provider.CapProxiedRecords, provider.CapRecordLines,
+				provider.CapCustomHostname, provider.CapSubdomains,
+			} {
+				want := false
+				for _, w := range tt.want {
+					want = want || w == c
+				}
+				if provider.Supports(tt.p, c) != want {
+					t.Errorf("Supports(%s) = %v, want %v", c, !want, want)
+				}
+			}
+		})
+	}
+}
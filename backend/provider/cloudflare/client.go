@@ -13,11 +13,12 @@ import (
 	"time"
 )
 
-const baseURL = "https://api.cloudflare.com/client/v4"
+const defaultBaseURL = "https://api.cloudflare.com/client/v4"
 
 // Client implements Cloudflare API client
 type Client struct {
 	httpClient *http.Client
+	baseURL    string
 }
 
 func NewClient() *Client {
@@ -25,6 +26,7 @@ func NewClient() *Client {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		baseURL: defaultBaseURL,
 	}
 }
 
@@ -40,7 +42,7 @@ func (c *Client) parseAPIKey(apiKey string) (string, error) {
 }
 
 func (c *Client) doRequest(ctx context.Context, apiToken, method, path string, body io.Reader) (*http.Response, error) {
-	req, err := http.NewRequestWithContext(ctx, method, baseURL+path, body)
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
@@ -549,6 +551,77 @@ func (c *Client) CreateZone(ctx context.Context, apiToken, accountID, domainName
 	return &zone, nil
 }
 
+// DeleteZone removes a zone (and all of its records) from Cloudflare.
+func (c *Client) DeleteZone(ctx context.Context, apiToken, zoneID string) error {
+	resp, err := c.doRequest(ctx, apiToken, "DELETE", "/zones/"+zoneID, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := c.parseResponse(resp); err != nil {
+		return err
+	}
+
+	var apiResp APIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+
+	if !apiResp.Success && len(apiResp.Errors) > 0 {
+		return fmt.Errorf("API error: %s", apiResp.Errors[0].Message)
+	}
+	return nil
+}
+
+// BatchRecord is a single record in a batch create request.
+type BatchRecord struct {
+	Type     string `json:"type"`
+	Name     string `json:"name"`
+	Content  string `json:"content"`
+	TTL      int    `json:"ttl"`
+	Priority *int   `json:"priority,omitempty"`
+	Proxied  *bool  `json:"proxied,omitempty"`
+}
+
+// BatchCreateRecords creates several records in one request via the
+// /dns_records/batch endpoint. Cloudflare applies the batch atomically, so
+// either every record is created or none are.
+func (c *Client) BatchCreateRecords(ctx context.Context, apiToken, zoneID string, records []BatchRecord) ([]Record, error) {
+	data, err := json.Marshal(map[string]interface{}{"posts": records})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, apiToken, "POST", "/zones/"+zoneID+"/dns_records/batch", bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := c.parseResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var apiResp APIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	if !apiResp.Success && len(apiResp.Errors) > 0 {
+		return nil, fmt.Errorf("API error: %s", apiResp.Errors[0].Message)
+	}
+
+	var result struct {
+		Posts []Record `json:"posts"`
+	}
+	resultData, _ := json.Marshal(apiResp.Result)
+	if err := json.Unmarshal(resultData, &result); err != nil {
+		return nil, fmt.Errorf("decode batch result: %w", err)
+	}
+	return result.Posts, nil
+}
+
 // GetRecordByID gets a single record by ID
 func (c *Client) GetRecordByID(ctx context.Context, apiToken, zoneID, recordID string) (*Record, error) {
 	path := "/zones/" + zoneID + "/dns_records/" + recordID
@@ -2,6 +2,7 @@ package cloudflare
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"time"
 
@@ -19,6 +20,14 @@ func New() *Provider {
 	}
 }
 
+// NewWithEndpoint returns a provider that talks to baseURL instead of
+// api.cloudflare.com/client/v4, e.g. a stand-in server in tests
+func NewWithEndpoint(baseURL string) *Provider {
+	p := New()
+	p.client.baseURL = strings.TrimSuffix(baseURL, "/")
+	return p
+}
+
 func (p *Provider) Name() string {
 	return "cloudflare"
 }
@@ -35,6 +44,22 @@ func (p *Provider) DefaultTTL() int {
 	return 1
 }
 
+func (p *Provider) CredentialFields() []models.CredentialField {
+	return []models.CredentialField{
+		{Name: "api_token", Label: "API Token", Secret: true, Required: true},
+	}
+}
+
+// MinTTL is 60 seconds; TTL 1 (automatic) is also accepted.
+func (p *Provider) MinTTL() int {
+	return 60
+}
+
+// ApexCNAME is supported through CNAME flattening.
+func (p *Provider) ApexCNAME() bool {
+	return true
+}
+
 func (p *Provider) ListDomains(ctx context.Context, apiKey string) ([]models.Domain, error) {
 	apiToken, err := p.client.parseAPIKey(apiKey)
 	if err != nil {
@@ -115,10 +140,11 @@ func (p *Provider) ListRecords(ctx context.Context, apiKey string, domainID stri
 			continue
 		}
 
-		// Get TTL - Cloudflare returns 1 for auto
-		ttl := 300 // default
-		if r.TTL > 1 {
-			ttl = r.TTL
+		// Cloudflare returns 1 for automatic TTL. Report it as is, since
+		// that is also DefaultTTL and what an edit should write back.
+		ttl := r.TTL
+		if ttl <= 0 {
+			ttl = TTLAuto
 		}
 
 		// Get priority for MX/SRV records
@@ -257,3 +283,258 @@ func (p *Provider) DeleteRecord(ctx context.Context, apiKey string, domainID str
 
 	return p.client.DeleteRecord(ctx, apiToken, domainID, recordID)
 }
+
+// fqdn builds the full record name Cloudflare expects from a node name.
+func fqdn(nodeName, zoneName string) string {
+	if nodeName == "@" || nodeName == "" {
+		return zoneName
+	}
+	return nodeName + "." + zoneName
+}
+
+// CreateRecords implements provider.BatchRecordWriter using the atomic
+// /dns_records/batch endpoint.
+func (p *Provider) CreateRecords(ctx context.Context, apiKey string, domainID string, records []*models.Record) ([]*models.Record, error) {
+	apiToken, err := p.client.parseAPIKey(apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	zone, err := p.client.GetZone(ctx, apiToken, domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := make([]BatchRecord, 0, len(records))
+	for _, r := range records {
+		br := BatchRecord{
+			Type:    r.RecordType,
+			Name:    fqdn(r.NodeName, zone.Name),
+			Content: r.Content,
+			TTL:     ConvertTTL(r.TTL),
+		}
+		if r.RecordType == "MX" || r.RecordType == "SRV" {
+			priority := r.Priority
+			if priority == 0 {
+				priority = 10
+			}
+			br.Priority = &priority
+		}
+		if r.RecordType == "A" || r.RecordType == "AAAA" || r.RecordType == "CNAME" {
+			proxied := false
+			br.Proxied = &proxied
+		}
+		batch = append(batch, br)
+	}
+
+	created, err := p.client.BatchCreateRecords(ctx, apiToken, domainID, batch)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*models.Record, 0, len(records))
+	for i, r := range records {
+		rec := *r
+		rec.DomainID = domainID
+		rec.DomainName = zone.Name
+		rec.State = true
+		rec.UpdatedOn = time.Now().Format(time.RFC3339)
+		if i < len(created) {
+			rec.ID = created[i].ID
+		}
+		result = append(result, &rec)
+	}
+	return result, nil
+}
+
+// CreateZone implements provider.ZoneManager. The zone is created under the
+// first Cloudflare account the token has access to.
+func (p *Provider) CreateZone(ctx context.Context, apiKey string, name string) (*models.Domain, error) {
+	apiToken, err := p.client.parseAPIKey(apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	accountID, err := p.client.GetAccountID(ctx, apiToken)
+	if err != nil {
+		return nil, err
+	}
+
+	zone, err := p.client.CreateZone(ctx, apiToken, accountID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	status := "Active"
+	if zone.Status != "active" {
+		status = "Inactive"
+	}
+
+	return &models.Domain{
+		ID:          zone.ID,
+		Name:        zone.Name,
+		UnicodeName: zone.Name,
+		State:       status,
+		CreatedOn:   zone.CreatedOn,
+		UpdatedOn:   zone.ModifiedOn,
+	}, nil
+}
+
+// DeleteZone implements provider.ZoneManager.
+func (p *Provider) DeleteZone(ctx context.Context, apiKey string, domainID string) error {
+	apiToken, err := p.client.parseAPIKey(apiKey)
+	if err != nil {
+		return err
+	}
+
+	return p.client.DeleteZone(ctx, apiToken, domainID)
+}
+
+// SetRecordProxied implements provider.ProxiedRecordSetter by toggling the
+// orange-cloud flag while keeping the record's other fields unchanged.
+func (p *Provider) SetRecordProxied(ctx context.Context, apiKey string, domainID string, recordID string, proxied bool) (*models.Record, error) {
+	apiToken, err := p.client.parseAPIKey(apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := p.client.GetRecordByID(ctx, apiToken, domainID, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if proxied && current.Type != "A" && current.Type != "AAAA" && current.Type != "CNAME" {
+		return nil, fmt.Errorf("record type %s cannot be proxied", current.Type)
+	}
+
+	updated, err := p.client.UpdateRecordWithProxied(ctx, apiToken, domainID, recordID, current.Type, current.Name, current.Content, current.TTL, proxied)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeName := "@"
+	if current.ZoneName != "" && current.Name != current.ZoneName {
+		nodeName = strings.TrimSuffix(current.Name, "."+current.ZoneName)
+	}
+
+	return &models.Record{
+		ID:         recordID,
+		DomainID:   domainID,
+		DomainName: current.ZoneName,
+		NodeName:   nodeName,
+		RecordType: current.Type,
+		TTL:        current.TTL,
+		State:      true,
+		Content:    current.Content,
+		UpdatedOn:  updated.ModifiedOn,
+		Raw: map[string]interface{}{
+			"proxied": updated.Proxied,
+			"zone_id": domainID,
+		},
+	}, nil
+}
+
+// GetNameservers implements provider.NameserverReporter.
+func (p *Provider) GetNameservers(ctx context.Context, apiKey string, domainID string) ([]string, error) {
+	apiToken, err := p.client.parseAPIKey(apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	zone, err := p.client.GetZone(ctx, apiToken, domainID)
+	if err != nil {
+		return nil, err
+	}
+	return zone.NameServers, nil
+}
+
+// CreateCustomHostname implements provider.CustomHostnameManager.
+func (p *Provider) CreateCustomHostname(ctx context.Context, apiKey string, domainID string, hostname string, origin string) (*models.CustomHostname, error) {
+	apiToken, err := p.client.parseAPIKey(apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ch, err := p.client.CreateCustomHostname(ctx, apiToken, domainID, hostname, origin)
+	if err != nil {
+		return nil, err
+	}
+	return convertCustomHostname(ch), nil
+}
+
+// GetCustomHostname implements provider.CustomHostnameManager.
+func (p *Provider) GetCustomHostname(ctx context.Context, apiKey string, domainID string, hostnameID string) (*models.CustomHostname, error) {
+	apiToken, err := p.client.parseAPIKey(apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ch, err := p.client.GetCustomHostname(ctx, apiToken, domainID, hostnameID)
+	if err != nil {
+		return nil, err
+	}
+	return convertCustomHostname(ch), nil
+}
+
+// DeleteCustomHostname implements provider.CustomHostnameManager.
+func (p *Provider) DeleteCustomHostname(ctx context.Context, apiKey string, domainID string, hostnameID string) error {
+	apiToken, err := p.client.parseAPIKey(apiKey)
+	if err != nil {
+		return err
+	}
+	return p.client.DeleteCustomHostname(ctx, apiToken, domainID, hostnameID)
+}
+
+// SetFallbackOrigin implements provider.CustomHostnameManager.
+func (p *Provider) SetFallbackOrigin(ctx context.Context, apiKey string, domainID string, origin string) error {
+	apiToken, err := p.client.parseAPIKey(apiKey)
+	if err != nil {
+		return err
+	}
+	return p.client.SetFallbackOrigin(ctx, apiToken, domainID, origin)
+}
+
+// DeleteFallbackOrigin implements provider.CustomHostnameManager.
+func (p *Provider) DeleteFallbackOrigin(ctx context.Context, apiKey string, domainID string) error {
+	apiToken, err := p.client.parseAPIKey(apiKey)
+	if err != nil {
+		return err
+	}
+	return p.client.DeleteFallbackOrigin(ctx, apiToken, domainID)
+}
+
+// convertCustomHostname keeps the ownership and certificate validations
+// that are still pending. Ownership is proven with a TXT record unless
+// Cloudflare asks for a CNAME; DCV delegation targets are CNAMEs.
+func convertCustomHostname(ch *CustomHostname) *models.CustomHostname {
+	out := &models.CustomHostname{
+		ID:        ch.ID,
+		Hostname:  ch.Hostname,
+		Status:    ch.Status,
+		SSLStatus: "pending",
+	}
+	if ov := ch.OwnershipVerification; ov != nil && !validationDone(ov.Status) && ov.Name != "" && ov.Value != "" {
+		recType := "TXT"
+		if strings.EqualFold(ov.Type, "cname") {
+			recType = "CNAME"
+		}
+		out.Validations = append(out.Validations, models.HostnameValidation{Type: recType, Name: ov.Name, Value: ov.Value})
+	}
+	if ch.SSL != nil {
+		out.SSLStatus = ch.SSL.Status
+		for _, v := range ch.SSL.ValidationRecords {
+			if validationDone(v.Status) || v.TxtName == "" || v.TxtValue == "" {
+				continue
+			}
+			recType := "TXT"
+			if strings.Contains(v.TxtValue, "dcv.cloudflare.com") {
+				recType = "CNAME"
+			}
+			out.Validations = append(out.Validations, models.HostnameValidation{Type: recType, Name: v.TxtName, Value: v.TxtValue})
+		}
+	}
+	return out
+}
+
+func validationDone(status string) bool {
+	return status == "active" || status == "verified"
+}
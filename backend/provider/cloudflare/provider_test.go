@@ -0,0 +1,160 @@
+package cloudflare
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"dns-mng/provider/providertest"
+)
+
+const (
+	testToken  = "cf-test-token"
+	testZoneID = "023e105f4ecef8ad9ca31a8372d0c353"
+)
+
+// fakeCloudflare serves the zone and dns_records parts of the v4 API.
+// Record names are fully qualified and TTL 1 means automatic.
+type fakeCloudflare struct {
+	zone *providertest.Zone
+}
+
+func failure(w http.ResponseWriter, status, code int, msg string) {
+	providertest.WriteJSON(w, status, APIResponse{Errors: []APIError{{Code: code, Message: msg}}, Messages: []string{}})
+}
+
+func success(w http.ResponseWriter, result interface{}) {
+	providertest.WriteJSON(w, http.StatusOK, APIResponse{Success: true, Errors: []APIError{}, Messages: []string{}, Result: result})
+}
+
+func (f *fakeCloudflare) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+testToken {
+		failure(w, http.StatusForbidden, 9109, "Invalid access token")
+		return
+	}
+
+	// /zones, /zones/{id}, /zones/{id}/dns_records and /zones/{id}/dns_records/{rid}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) == 1 && parts[0] == "zones" && r.Method == http.MethodGet {
+		providertest.WriteJSON(w, http.StatusOK, APIResponse{
+			Success: true, Errors: []APIError{}, Messages: []string{},
+			Result:     []Zone{f.cfZone()},
+			ResultInfo: &ResultInfo{Page: 1, PerPage: 50, Count: 1, TotalCount: 1, TotalPages: 1},
+		})
+		return
+	}
+	if len(parts) < 2 || parts[0] != "zones" || parts[1] != testZoneID {
+		failure(w, http.StatusNotFound, 7003, "Could not route to "+r.URL.Path+", perhaps your object identifier is invalid?")
+		return
+	}
+
+	switch {
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		success(w, f.cfZone())
+	case len(parts) == 3 && parts[2] == "dns_records" && r.Method == http.MethodGet:
+		records := []Record{}
+		for _, rec := range f.zone.Records() {
+			records = append(records, f.toCF(rec))
+		}
+		success(w, records)
+	case len(parts) == 3 && parts[2] == "dns_records" && r.Method == http.MethodPost:
+		rec, ok := f.decode(w, r)
+		if !ok {
+			return
+		}
+		success(w, f.toCF(f.zone.Add(rec)))
+	case len(parts) == 4 && r.Method == http.MethodGet:
+		rec, ok := f.zone.Get(parts[3])
+		if !ok {
+			failure(w, http.StatusNotFound, 81044, "Record does not exist.")
+			return
+		}
+		success(w, f.toCF(rec))
+	case len(parts) == 4 && r.Method == http.MethodPut:
+		rec, ok := f.decode(w, r)
+		if !ok {
+			return
+		}
+		rec.ID = parts[3]
+		if !f.zone.Update(rec) {
+			failure(w, http.StatusNotFound, 81044, "Record does not exist.")
+			return
+		}
+		success(w, f.toCF(rec))
+	case len(parts) == 4 && r.Method == http.MethodDelete:
+		if !f.zone.Delete(parts[3]) {
+			failure(w, http.StatusNotFound, 81044, "Record does not exist.")
+			return
+		}
+		success(w, map[string]string{"id": parts[3]})
+	default:
+		failure(w, http.StatusMethodNotAllowed, 10000, "Method not allowed")
+	}
+}
+
+func (f *fakeCloudflare) decode(w http.ResponseWriter, r *http.Request) (providertest.Record, bool) {
+	var body struct {
+		Type     string `json:"type"`
+		Name     string `json:"name"`
+		Content  string `json:"content"`
+		TTL      int    `json:"ttl"`
+		Priority *int   `json:"priority"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		failure(w, http.StatusBadRequest, 9207, "Request body is invalid.")
+		return providertest.Record{}, false
+	}
+	if body.TTL != TTLAuto && (body.TTL < 60 || body.TTL > 86400) {
+		failure(w, http.StatusBadRequest, 9021, "Invalid TTL. Must be between 60 and 86400 seconds, or 1 for Automatic.")
+		return providertest.Record{}, false
+	}
+	rec := providertest.Record{Name: f.zone.Relative(body.Name), Type: body.Type, Content: body.Content, TTL: body.TTL}
+	if body.Priority != nil {
+		rec.Priority = *body.Priority
+	}
+	return rec, true
+}
+
+func (f *fakeCloudflare) cfZone() Zone {
+	return Zone{
+		ID:          testZoneID,
+		Name:        f.zone.Name,
+		Status:      "active",
+		NameServers: []string{"ada.ns.cloudflare.com", "bob.ns.cloudflare.com"},
+		CreatedOn:   "2026-01-01T00:00:00Z",
+		ModifiedOn:  "2026-01-01T00:00:00Z",
+	}
+}
+
+func (f *fakeCloudflare) toCF(rec providertest.Record) Record {
+	out := Record{
+		ID:         rec.ID,
+		Type:       rec.Type,
+		Name:       f.zone.FQDN(rec.Name),
+		Content:    rec.Content,
+		TTL:        rec.TTL,
+		ZoneID:     testZoneID,
+		ZoneName:   f.zone.Name,
+		CreatedOn:  "2026-01-01T00:00:00Z",
+		ModifiedOn: "2026-01-01T00:00:00Z",
+	}
+	if rec.Type == "MX" || rec.Type == "SRV" {
+		priority := rec.Priority
+		out.Priority = &priority
+	}
+	return out
+}
+
+func TestConformance(t *testing.T) {
+	server := httptest.NewServer(&fakeCloudflare{zone: providertest.NewZone("example.com")})
+	defer server.Close()
+
+	providertest.Run(t, NewWithEndpoint(server.URL), providertest.Config{
+		APIKey:    testToken,
+		BadAPIKey: "wrong-token",
+		AuthError: "Invalid access token",
+		Zone:      "example.com",
+	})
+}
@@ -0,0 +1,118 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"dns-mng/models"
+)
+
+// CredentialSchema is implemented by providers to declare their credential
+// fields. Providers call their API with the values joined by commas in
+// field order (trailing empty optional fields left out), which is the
+// api_key string every DNSProvider method receives. Providers without a
+// schema take a single secret "api_key".
+type CredentialSchema interface {
+	CredentialFields() []models.CredentialField
+}
+
+// CredentialDecoder is implemented by providers whose legacy api_key
+// strings cannot be split back into fields by position
+type CredentialDecoder interface {
+	DecodeCredentials(apiKey string) (models.Credentials, error)
+}
+
+var defaultCredentialFields = []models.CredentialField{
+	{Name: "api_key", Label: "API Key", Secret: true, Required: true},
+}
+
+// CredentialFieldsOf returns p's credential fields
+func CredentialFieldsOf(p DNSProvider) []models.CredentialField {
+	if cs, ok := p.(CredentialSchema); ok {
+		return cs.CredentialFields()
+	}
+	return defaultCredentialFields
+}
+
+// FieldErrors maps credential field names to what is wrong with them
+type FieldErrors map[string]string
+
+func (e FieldErrors) Error() string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	msgs := make([]string, 0, len(names))
+	for _, name := range names {
+		msgs = append(msgs, name+": "+e[name])
+	}
+	return "invalid credentials: " + strings.Join(msgs, "; ")
+}
+
+// ValidateCredentials checks creds against p's schema and returns
+// FieldErrors for the fields that are missing or malformed
+func ValidateCredentials(p DNSProvider, creds models.Credentials) error {
+	fields := CredentialFieldsOf(p)
+	errs := FieldErrors{}
+	known := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		known[f.Name] = true
+		v := strings.TrimSpace(creds[f.Name])
+		switch {
+		case v == "":
+			if f.Required {
+				errs[f.Name] = "is required"
+			}
+		case len(fields) > 1 && strings.Contains(v, ","):
+			// Values are joined by commas for the provider
+			errs[f.Name] = "must not contain a comma"
+		case f.Pattern != "" && !regexp.MustCompile(f.Pattern).MatchString(v):
+			errs[f.Name] = fmt.Sprintf("must match %s", f.Pattern)
+		}
+	}
+	for name := range creds {
+		if !known[name] {
+			errs[name] = "is not a credential field of " + p.Name()
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// EncodeCredentials joins creds into the api_key string p's methods take
+func EncodeCredentials(p DNSProvider, creds models.Credentials) string {
+	fields := CredentialFieldsOf(p)
+	values := make([]string, len(fields))
+	for i, f := range fields {
+		values[i] = strings.TrimSpace(creds[f.Name])
+	}
+	for len(values) > 1 && values[len(values)-1] == "" {
+		values = values[:len(values)-1]
+	}
+	return strings.Join(values, ",")
+}
+
+// DecodeCredentials splits a legacy api_key string into p's fields
+func DecodeCredentials(p DNSProvider, apiKey string) (models.Credentials, error) {
+	if d, ok := p.(CredentialDecoder); ok {
+		return d.DecodeCredentials(apiKey)
+	}
+	fields := CredentialFieldsOf(p)
+	if len(fields) == 1 {
+		return models.Credentials{fields[0].Name: strings.TrimSpace(apiKey)}, nil
+	}
+	parts := strings.Split(apiKey, ",")
+	if len(parts) > len(fields) {
+		return nil, fmt.Errorf("API key has %d comma-separated values, %s takes at most %d", len(parts), p.Name(), len(fields))
+	}
+	creds := make(models.Credentials, len(fields))
+	for i, part := range parts {
+		creds[fields[i].Name] = strings.TrimSpace(part)
+	}
+	return creds, nil
+}
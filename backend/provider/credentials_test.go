@@ -0,0 +1,73 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+
+	"dns-mng/models"
+	"dns-mng/provider/mock"
+)
+
+// keyPairProvider takes an ID, a secret and an optional region
+type keyPairProvider struct {
+	*mock.Provider
+}
+
+func (keyPairProvider) CredentialFields() []models.CredentialField {
+	return []models.CredentialField{
+		{Name: "id", Label: "ID", Required: true},
+		{Name: "secret", Label: "Secret", Secret: true, Required: true},
+		{Name: "region", Label: "Region", Pattern: `^[a-z]{2}-[a-z0-9-]+$`},
+	}
+}
+
+func TestValidateCredentials(t *testing.T) {
+	p := keyPairProvider{mock.New(mock.Options{})}
+
+	if err := ValidateCredentials(p, models.Credentials{"id": "a", "secret": "b"}); err != nil {
+		t.Errorf("valid credentials: %v", err)
+	}
+
+	err := ValidateCredentials(p, models.Credentials{"secret": "b,c", "region": "Mars", "extra": "x"})
+	var fe FieldErrors
+	if !errors.As(err, &fe) {
+		t.Fatalf("err = %v, want FieldErrors", err)
+	}
+	for _, field := range []string{"id", "secret", "region", "extra"} {
+		if fe[field] == "" {
+			t.Errorf("no error for field %q in %v", field, fe)
+		}
+	}
+}
+
+func TestEncodeDecodeCredentials(t *testing.T) {
+	p := keyPairProvider{mock.New(mock.Options{})}
+
+	for _, tc := range []struct {
+		creds models.Credentials
+		key   string
+	}{
+		{models.Credentials{"id": "a", "secret": "b"}, "a,b"},
+		{models.Credentials{"id": "a", "secret": "b", "region": "eu-west-1"}, "a,b,eu-west-1"},
+	} {
+		if got := EncodeCredentials(p, tc.creds); got != tc.key {
+			t.Errorf("EncodeCredentials(%v) = %q, want %q", tc.creds, got, tc.key)
+		}
+		creds, err := DecodeCredentials(p, tc.key)
+		if err != nil {
+			t.Fatalf("DecodeCredentials(%q): %v", tc.key, err)
+		}
+		if EncodeCredentials(p, creds) != tc.key {
+			t.Errorf("DecodeCredentials(%q) = %v, which does not encode back", tc.key, creds)
+		}
+	}
+	if _, err := DecodeCredentials(p, "a,b,c,d"); err == nil {
+		t.Errorf("DecodeCredentials accepted more values than fields")
+	}
+
+	// A single field takes the whole key, commas included
+	single := mock.New(mock.Options{})
+	if creds, _ := DecodeCredentials(single, "a,b"); creds["api_key"] != "a,b" {
+		t.Errorf("DecodeCredentials for one field = %v, want the whole key", creds)
+	}
+}
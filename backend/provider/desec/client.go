@@ -11,10 +11,11 @@ import (
 	"time"
 )
 
-const baseURL = "https://desec.io/api/v1"
+const defaultBaseURL = "https://desec.io/api/v1"
 
 type Client struct {
 	httpClient *http.Client
+	baseURL    string
 }
 
 func NewClient() *Client {
@@ -22,11 +23,12 @@ func NewClient() *Client {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		baseURL: defaultBaseURL,
 	}
 }
 
 func (c *Client) doRequest(ctx context.Context, token, method, path string, body io.Reader) (*http.Response, error) {
-	req, err := http.NewRequestWithContext(ctx, method, baseURL+path, body)
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
@@ -179,3 +181,50 @@ func (c *Client) DeleteRRSet(ctx context.Context, token, domain, subname, record
 
 	return nil
 }
+
+// CreateDomain creates a new domain
+func (c *Client) CreateDomain(ctx context.Context, token, name string) (*Domain, error) {
+	data, err := json.Marshal(map[string]string{"name": name})
+	if err != nil {
+		return nil, fmt.Errorf("marshal domain: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, token, "POST", "/domains/", bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var domain Domain
+	if err := json.Unmarshal(body, &domain); err != nil {
+		return nil, fmt.Errorf("parse response: %w", err)
+	}
+
+	return &domain, nil
+}
+
+// DeleteDomain deletes a domain and all of its RRSets
+func (c *Client) DeleteDomain(ctx context.Context, token, name string) error {
+	path := fmt.Sprintf("/domains/%s/", url.PathEscape(name))
+	resp, err := c.doRequest(ctx, token, "DELETE", path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
@@ -24,6 +24,23 @@ func New() *Provider {
 	return NewProvider()
 }
 
+// NewWithEndpoint returns a provider that talks to baseURL instead of
+// desec.io, e.g. a stand-in server in tests
+func NewWithEndpoint(baseURL string) *Provider {
+	p := NewProvider()
+	p.client.baseURL = strings.TrimSuffix(baseURL, "/")
+	return p
+}
+
+// subname converts a node name to a deSEC subname, which is empty at the
+// apex; deSEC rejects "@"
+func subname(nodeName string) string {
+	if nodeName == "@" {
+		return ""
+	}
+	return nodeName
+}
+
 func (p *Provider) Name() string {
 	return "desec"
 }
@@ -40,6 +57,21 @@ func (p *Provider) DefaultTTL() int {
 	return 3600
 }
 
+func (p *Provider) CredentialFields() []models.CredentialField {
+	return []models.CredentialField{
+		{Name: "token", Label: "Token", Secret: true, Required: true},
+	}
+}
+
+// deSEC enforces a minimum TTL of one hour.
+func (p *Provider) MinTTL() int {
+	return 3600
+}
+
+func (p *Provider) ApexCNAME() bool {
+	return false
+}
+
 func (p *Provider) ListDomains(ctx context.Context, apiKey string) ([]models.Domain, error) {
 	domains, err := p.client.ListDomains(ctx, apiKey)
 	if err != nil {
@@ -136,7 +168,7 @@ func (p *Provider) CreateRecord(ctx context.Context, apiKey string, domainID str
 	}
 
 	rrset := RRSetRequest{
-		Subname: record.NodeName,
+		Subname: subname(record.NodeName),
 		Type:    record.RecordType,
 		Records: []string{record.Content},
 		TTL:     ttl,
@@ -175,7 +207,7 @@ func (p *Provider) UpdateRecord(ctx context.Context, apiKey string, domainID str
 	}
 
 	rrset := RRSetRequest{
-		Subname: record.NodeName,
+		Subname: subname(record.NodeName),
 		Type:    record.RecordType,
 		Records: []string{record.Content},
 		TTL:     ttl,
@@ -216,3 +248,34 @@ func (p *Provider) GetRecord(ctx context.Context, apiKey string, domainID string
 
 	return nil, fmt.Errorf("record not found: %s", recordID)
 }
+
+// nameservers are deSEC's authoritative servers; every domain is served by
+// the same pair.
+var nameservers = []string{"ns1.desec.io", "ns2.desec.org"}
+
+// CreateZone implements provider.ZoneManager.
+func (p *Provider) CreateZone(ctx context.Context, apiKey string, name string) (*models.Domain, error) {
+	d, err := p.client.CreateDomain(ctx, apiKey, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Domain{
+		ID:          d.Name,
+		Name:        d.Name,
+		UnicodeName: d.Name,
+		State:       "Active",
+		CreatedOn:   d.Created,
+		UpdatedOn:   d.Created,
+	}, nil
+}
+
+// DeleteZone implements provider.ZoneManager.
+func (p *Provider) DeleteZone(ctx context.Context, apiKey string, domainID string) error {
+	return p.client.DeleteDomain(ctx, apiKey, domainID)
+}
+
+// GetNameservers implements provider.NameserverReporter.
+func (p *Provider) GetNameservers(ctx context.Context, apiKey string, domainID string) ([]string, error) {
+	return append([]string(nil), nameservers...), nil
+}
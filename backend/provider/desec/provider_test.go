@@ -0,0 +1,141 @@
+package desec
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"dns-mng/provider/providertest"
+)
+
+const (
+	testToken  = "desec-test-token"
+	minimumTTL = 3600
+)
+
+// fakeDesec serves the deSEC RRset API for one domain. Like deSEC it
+// rejects "@" as a subname and TTLs below the domain minimum.
+type fakeDesec struct {
+	zone *providertest.Zone
+}
+
+func (f *fakeDesec) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Token "+testToken {
+		providertest.WriteJSON(w, http.StatusUnauthorized, map[string]string{"detail": "Invalid token."})
+		return
+	}
+
+	// /domains/, /domains/{name}/rrsets/ and /domains/{name}/rrsets/{subname}/{type}/
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/domains/"), "/"), "/")
+	if r.URL.Path == "/domains/" && r.Method == http.MethodGet {
+		providertest.WriteJSON(w, http.StatusOK, []Domain{{
+			Name: f.zone.Name, Created: "2026-01-01T00:00:00Z", MinimumTTL: minimumTTL,
+		}})
+		return
+	}
+	if len(parts) < 2 || parts[0] != f.zone.Name || parts[1] != "rrsets" {
+		providertest.WriteJSON(w, http.StatusNotFound, map[string]string{"detail": "Not found."})
+		return
+	}
+
+	switch {
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		providertest.WriteJSON(w, http.StatusOK, f.rrsets())
+	case len(parts) == 2 && r.Method == http.MethodPost:
+		var req RRSetRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			providertest.WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": err.Error()})
+			return
+		}
+		if msg := validate(req); msg != "" {
+			providertest.WriteJSON(w, http.StatusBadRequest, map[string][]string{"non_field_errors": {msg}})
+			return
+		}
+		if len(f.zone.Lookup(req.Subname, req.Type)) > 0 {
+			providertest.WriteJSON(w, http.StatusBadRequest, map[string][]string{
+				"non_field_errors": {"Another RRset with the same subdomain and type exists for this domain."},
+			})
+			return
+		}
+		f.put(req)
+		providertest.WriteJSON(w, http.StatusCreated, req)
+	case len(parts) == 2 && r.Method == http.MethodPatch:
+		var reqs []RRSetRequest
+		if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+			providertest.WriteJSON(w, http.StatusBadRequest, map[string]string{"detail": err.Error()})
+			return
+		}
+		for _, req := range reqs {
+			if msg := validate(req); msg != "" {
+				providertest.WriteJSON(w, http.StatusBadRequest, []map[string][]string{{"non_field_errors": {msg}}})
+				return
+			}
+		}
+		for _, req := range reqs {
+			f.put(req)
+		}
+		providertest.WriteJSON(w, http.StatusOK, reqs)
+	case len(parts) == 4 && r.Method == http.MethodDelete:
+		// Deleting an RRset that does not exist also succeeds
+		f.put(RRSetRequest{Subname: parts[2], Type: parts[3]})
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		providertest.WriteJSON(w, http.StatusMethodNotAllowed, map[string]string{"detail": "Method not allowed."})
+	}
+}
+
+func validate(req RRSetRequest) string {
+	if strings.Contains(req.Subname, "@") {
+		return "Subname can only use (lowercase) a-z, 0-9, ., -, and _, may start with a '*.', or just be '*'."
+	}
+	if req.TTL < minimumTTL {
+		return "Ensure this value is greater than or equal to 3600."
+	}
+	return ""
+}
+
+// put replaces the RRset; no records deletes it
+func (f *fakeDesec) put(req RRSetRequest) {
+	for _, rec := range f.zone.Lookup(req.Subname, req.Type) {
+		f.zone.Delete(rec.ID)
+	}
+	for _, content := range req.Records {
+		f.zone.Add(providertest.Record{Name: req.Subname, Type: req.Type, Content: content, TTL: req.TTL})
+	}
+}
+
+func (f *fakeDesec) rrsets() []RRSet {
+	out := []RRSet{}
+	index := map[string]int{}
+	for _, rec := range f.zone.Records() {
+		key := rec.Name + "/" + rec.Type
+		i, ok := index[key]
+		if !ok {
+			i = len(out)
+			index[key] = i
+			out = append(out, RRSet{
+				Domain:  f.zone.Name,
+				Subname: rec.Name,
+				Name:    f.zone.FQDN(rec.Name) + ".",
+				Type:    rec.Type,
+				TTL:     rec.TTL,
+			})
+		}
+		out[i].Records = append(out[i].Records, rec.Content)
+	}
+	return out
+}
+
+func TestConformance(t *testing.T) {
+	server := httptest.NewServer(&fakeDesec{zone: providertest.NewZone("example.dedyn.io")})
+	defer server.Close()
+
+	providertest.Run(t, NewWithEndpoint(server.URL), providertest.Config{
+		APIKey:    testToken,
+		BadAPIKey: "wrong-token",
+		AuthError: "Invalid token",
+		Zone:      "example.dedyn.io",
+	})
+}
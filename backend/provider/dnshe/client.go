@@ -10,10 +10,11 @@ import (
 	"time"
 )
 
-const baseURL = "https://api005.dnshe.com/index.php?m=domain_hub"
+const defaultBaseURL = "https://api005.dnshe.com/index.php?m=domain_hub"
 
 type Client struct {
 	httpClient *http.Client
+	baseURL    string
 }
 
 func NewClient() *Client {
@@ -21,11 +22,12 @@ func NewClient() *Client {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		baseURL: defaultBaseURL,
 	}
 }
 
 func (c *Client) doRequest(ctx context.Context, apiKey, apiSecret, method, endpoint, action string, body io.Reader) (*http.Response, error) {
-	url := fmt.Sprintf("%s&endpoint=%s", baseURL, endpoint)
+	url := fmt.Sprintf("%s&endpoint=%s", c.baseURL, endpoint)
 	if action != "" {
 		url = fmt.Sprintf("%s&action=%s", url, action)
 	}
@@ -70,7 +72,7 @@ func (c *Client) ListSubdomains(ctx context.Context, apiKey, apiSecret string) (
 
 // GetSubdomain gets subdomain details with DNS records
 func (c *Client) GetSubdomain(ctx context.Context, apiKey, apiSecret string, subdomainID int) (*SubdomainDetailResponse, error) {
-	url := fmt.Sprintf("%s&endpoint=subdomains&action=get&subdomain_id=%d", baseURL, subdomainID)
+	url := fmt.Sprintf("%s&endpoint=subdomains&action=get&subdomain_id=%d", c.baseURL, subdomainID)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -106,7 +108,7 @@ func (c *Client) GetSubdomain(ctx context.Context, apiKey, apiSecret string, sub
 
 // ListDNSRecords lists all DNS records for a subdomain
 func (c *Client) ListDNSRecords(ctx context.Context, apiKey, apiSecret string, subdomainID int) (*DNSRecordsResponse, error) {
-	url := fmt.Sprintf("%s&endpoint=dns_records&action=list&subdomain_id=%d", baseURL, subdomainID)
+	url := fmt.Sprintf("%s&endpoint=dns_records&action=list&subdomain_id=%d", c.baseURL, subdomainID)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -25,6 +25,15 @@ func New() *Provider {
 	return NewProvider()
 }
 
+// NewWithEndpoint returns a provider that talks to baseURL instead of
+// api005.dnshe.com. baseURL includes the query that selects the module,
+// e.g. "http://127.0.0.1:8080/index.php?m=domain_hub".
+func NewWithEndpoint(baseURL string) *Provider {
+	p := NewProvider()
+	p.client.baseURL = baseURL
+	return p
+}
+
 func (p *Provider) Name() string {
 	return "dnshe"
 }
@@ -41,13 +50,29 @@ func (p *Provider) DefaultTTL() int {
 	return 600
 }
 
+func (p *Provider) CredentialFields() []models.CredentialField {
+	return []models.CredentialField{
+		{Name: "api_key", Label: "API Key", Required: true},
+		{Name: "api_secret", Label: "API Secret", Secret: true, Required: true},
+	}
+}
+
+func (p *Provider) ValidateAPIKey(apiKey string) error {
+	_, _, err := ParseAPIKey(apiKey)
+	return err
+}
+
 // ParseAPIKey splits the API key format "apikey,apisecret"
 func ParseAPIKey(apiKey string) (string, string, error) {
 	parts := strings.Split(apiKey, ",")
 	if len(parts) != 2 {
 		return "", "", fmt.Errorf("invalid API key format, expected: apikey,apisecret")
 	}
-	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), nil
+	key, secret := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	if key == "" || secret == "" {
+		return "", "", fmt.Errorf("invalid API key: empty apikey or apisecret")
+	}
+	return key, secret, nil
 }
 
 func (p *Provider) ListDomains(ctx context.Context, apiKey string) ([]models.Domain, error) {
@@ -335,3 +360,88 @@ func (p *Provider) GetRecord(ctx context.Context, apiKey string, domainID string
 
 	return nil, fmt.Errorf("record not found: %s", recordID)
 }
+
+// RenewDomain implements provider.DomainRenewer.
+func (p *Provider) RenewDomain(ctx context.Context, apiKey string, domainID string) error {
+	key, secret, err := ParseAPIKey(apiKey)
+	if err != nil {
+		return err
+	}
+
+	id, err := strconv.Atoi(domainID)
+	if err != nil {
+		return fmt.Errorf("invalid domain ID: %w", err)
+	}
+
+	_, err = p.client.RenewSubdomain(ctx, key, secret, id)
+	return err
+}
+
+// SubdomainQuota implements provider.SubdomainRegistrar.
+func (p *Provider) SubdomainQuota(ctx context.Context, apiKey string) (*models.SubdomainQuota, error) {
+	key, secret, err := ParseAPIKey(apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.GetQuota(ctx, key, secret)
+	if err != nil {
+		return nil, err
+	}
+	q := resp.Quota
+	return &models.SubdomainQuota{
+		Used:        q.Used,
+		Base:        q.Base,
+		InviteBonus: q.InviteBonus,
+		Total:       q.Total,
+		Available:   q.Available,
+	}, nil
+}
+
+// RegisterSubdomain implements provider.SubdomainRegistrar.
+func (p *Provider) RegisterSubdomain(ctx context.Context, apiKey string, name string, parent string) (*models.Domain, error) {
+	key, secret, err := ParseAPIKey(apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.RegisterSubdomain(ctx, key, secret, name, parent)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("register subdomain: %s", resp.Message)
+	}
+	fullDomain := resp.FullDomain
+	if fullDomain == "" {
+		fullDomain = name + "." + parent
+	}
+	return &models.Domain{
+		ID:          strconv.Itoa(resp.SubdomainID),
+		Name:        fullDomain,
+		UnicodeName: fullDomain,
+		RenewalURL:  "https://www.dnshe.com",
+	}, nil
+}
+
+// DeleteSubdomain implements provider.SubdomainRegistrar.
+func (p *Provider) DeleteSubdomain(ctx context.Context, apiKey string, domainID string) error {
+	key, secret, err := ParseAPIKey(apiKey)
+	if err != nil {
+		return err
+	}
+
+	id, err := strconv.Atoi(domainID)
+	if err != nil {
+		return fmt.Errorf("invalid domain ID: %w", err)
+	}
+
+	resp, err := p.client.DeleteSubdomain(ctx, key, secret, id)
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("delete subdomain: %s", resp.Message)
+	}
+	return nil
+}
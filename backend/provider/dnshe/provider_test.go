@@ -0,0 +1,137 @@
+package dnshe
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"dns-mng/provider/providertest"
+)
+
+const (
+	testKey         = "dnshe-key"
+	testSecret      = "dnshe-secret"
+	testSubdomainID = 4242
+)
+
+// fakeDNSHE serves the domain_hub module: every call goes to index.php
+// with the endpoint and action in the query string. Record names are
+// fully qualified.
+type fakeDNSHE struct {
+	zone *providertest.Zone
+}
+
+func (f *fakeDNSHE) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if r.URL.Path != "/index.php" || q.Get("m") != "domain_hub" {
+		providertest.WriteJSON(w, http.StatusNotFound, ErrorResponse{Error: "Not found"})
+		return
+	}
+	if r.Header.Get("X-API-Key") != testKey || r.Header.Get("X-API-Secret") != testSecret {
+		providertest.WriteJSON(w, http.StatusUnauthorized, ErrorResponse{Error: "Invalid API key or secret"})
+		return
+	}
+
+	var body struct {
+		SubdomainID int    `json:"subdomain_id"`
+		RecordID    int    `json:"record_id"`
+		Name        string `json:"name"`
+		Type        string `json:"type"`
+		Content     string `json:"content"`
+		TTL         int    `json:"ttl"`
+		Priority    *int   `json:"priority"`
+	}
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			providertest.WriteJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	switch q.Get("endpoint") + "/" + q.Get("action") {
+	case "subdomains/list":
+		providertest.WriteJSON(w, http.StatusOK, SubdomainsResponse{Success: true, Count: 1, Subdomains: []Subdomain{{
+			ID:         testSubdomainID,
+			Subdomain:  "example",
+			RootDomain: "dnshe.net",
+			FullDomain: f.zone.Name,
+			Status:     "active",
+			CreatedAt:  "2026-01-01 00:00:00",
+			ExpiresAt:  "2027-01-01 00:00:00",
+		}}})
+	case "dns_records/list":
+		if q.Get("subdomain_id") != strconv.Itoa(testSubdomainID) {
+			providertest.WriteJSON(w, http.StatusNotFound, ErrorResponse{Error: "Subdomain not found"})
+			return
+		}
+		records := []DNSRecord{}
+		for _, rec := range f.zone.Records() {
+			records = append(records, f.toDNSHE(rec))
+		}
+		providertest.WriteJSON(w, http.StatusOK, DNSRecordsResponse{Success: true, Count: len(records), Records: records})
+	case "dns_records/create":
+		if body.SubdomainID != testSubdomainID {
+			providertest.WriteJSON(w, http.StatusNotFound, ErrorResponse{Error: "Subdomain not found"})
+			return
+		}
+		rec := providertest.Record{Name: f.zone.Relative(body.Name), Type: body.Type, Content: body.Content, TTL: body.TTL}
+		if body.Priority != nil {
+			rec.Priority = *body.Priority
+		}
+		rec = f.zone.Add(rec)
+		id, _ := strconv.Atoi(rec.ID)
+		providertest.WriteJSON(w, http.StatusOK, map[string]interface{}{"success": true, "record_id": id})
+	case "dns_records/update":
+		rec, ok := f.zone.Get(strconv.Itoa(body.RecordID))
+		if !ok {
+			providertest.WriteJSON(w, http.StatusNotFound, ErrorResponse{Error: "Record not found"})
+			return
+		}
+		rec.Content, rec.TTL = body.Content, body.TTL
+		if body.Priority != nil {
+			rec.Priority = *body.Priority
+		}
+		f.zone.Update(rec)
+		providertest.WriteJSON(w, http.StatusOK, map[string]bool{"success": true})
+	case "dns_records/delete":
+		if !f.zone.Delete(strconv.Itoa(body.RecordID)) {
+			providertest.WriteJSON(w, http.StatusNotFound, ErrorResponse{Error: "Record not found"})
+			return
+		}
+		providertest.WriteJSON(w, http.StatusOK, map[string]bool{"success": true})
+	default:
+		providertest.WriteJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Unknown endpoint"})
+	}
+}
+
+func (f *fakeDNSHE) toDNSHE(rec providertest.Record) DNSRecord {
+	id, _ := strconv.Atoi(rec.ID)
+	out := DNSRecord{
+		ID:        id,
+		Name:      f.zone.FQDN(rec.Name),
+		Type:      rec.Type,
+		Content:   rec.Content,
+		TTL:       rec.TTL,
+		Status:    "active",
+		CreatedAt: "2026-01-01 00:00:00",
+	}
+	if rec.Type == "MX" || rec.Type == "SRV" {
+		priority := rec.Priority
+		out.Priority = &priority
+	}
+	return out
+}
+
+func TestConformance(t *testing.T) {
+	server := httptest.NewServer(&fakeDNSHE{zone: providertest.NewZone("example.dnshe.net")})
+	defer server.Close()
+
+	providertest.Run(t, NewWithEndpoint(server.URL+"/index.php?m=domain_hub"), providertest.Config{
+		APIKey:    testKey + "," + testSecret,
+		BadAPIKey: testKey + ",wrong-secret",
+		AuthError: "Invalid API key or secret",
+		Zone:      "example.dnshe.net",
+	})
+}
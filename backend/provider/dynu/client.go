@@ -10,14 +10,16 @@ import (
 	"time"
 )
 
-const baseURL = "https://api.dynu.com/v2"
+const defaultBaseURL = "https://api.dynu.com/v2"
 
 type Client struct {
 	httpClient *http.Client
+	baseURL    string
 }
 
 func NewClient() *Client {
 	return &Client{
+		baseURL: defaultBaseURL,
 		httpClient: &http.Client{
 			Timeout: 15 * time.Second, // Reduced from 30s to 15s
 			Transport: &http.Transport{
@@ -30,45 +32,10 @@ func NewClient() *Client {
 	}
 }
 
+// doRequest makes a single attempt; retries on 429 and 5xx are left to
+// provider.Do, which every call from the app goes through
 func (c *Client) doRequest(ctx context.Context, method, path, apiKey string, body interface{}) ([]byte, error) {
-	return c.doRequestWithRetry(ctx, method, path, apiKey, body, 3)
-}
-
-func (c *Client) doRequestWithRetry(ctx context.Context, method, path, apiKey string, body interface{}, maxRetries int) ([]byte, error) {
-	var lastErr error
-	
-	for attempt := 0; attempt <= maxRetries; attempt++ {
-		if attempt > 0 {
-			// Exponential backoff: 1s, 2s, 4s
-			backoff := time.Duration(1<<uint(attempt-1)) * time.Second
-			select {
-			case <-time.After(backoff):
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			}
-		}
-
-		respBody, err := c.doSingleRequest(ctx, method, path, apiKey, body)
-		if err == nil {
-			return respBody, nil
-		}
-
-		lastErr = err
-		
-		// Don't retry on client errors (4xx) except 429 (rate limit)
-		if httpErr, ok := err.(*HTTPError); ok {
-			if httpErr.StatusCode >= 400 && httpErr.StatusCode < 500 && httpErr.StatusCode != 429 {
-				return nil, err
-			}
-		}
-		
-		// Don't retry on context errors
-		if ctx.Err() != nil {
-			return nil, ctx.Err()
-		}
-	}
-	
-	return nil, fmt.Errorf("request failed after %d attempts: %w", maxRetries+1, lastErr)
+	return c.doSingleRequest(ctx, method, path, apiKey, body)
 }
 
 type HTTPError struct {
@@ -80,6 +47,11 @@ func (e *HTTPError) Error() string {
 	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Message)
 }
 
+// HTTPStatus implements provider.HTTPStatusError
+func (e *HTTPError) HTTPStatus() int {
+	return e.StatusCode
+}
+
 func (c *Client) doSingleRequest(ctx context.Context, method, path, apiKey string, body interface{}) ([]byte, error) {
 	var bodyReader io.Reader
 	if body != nil {
@@ -90,7 +62,7 @@ func (c *Client) doSingleRequest(ctx context.Context, method, path, apiKey strin
 		bodyReader = bytes.NewReader(data)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, baseURL+path, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bodyReader)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
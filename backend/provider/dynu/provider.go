@@ -3,6 +3,7 @@ package dynu
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"dns-mng/models"
 )
@@ -18,6 +19,14 @@ func New() *Provider {
 	}
 }
 
+// NewWithEndpoint returns a provider that calls baseURL instead of
+// api.dynu.com, e.g. a stand-in server in tests
+func NewWithEndpoint(baseURL string) *Provider {
+	p := New()
+	p.client.baseURL = strings.TrimSuffix(baseURL, "/")
+	return p
+}
+
 func (p *Provider) Name() string {
 	return "dynu"
 }
@@ -34,6 +43,12 @@ func (p *Provider) DefaultTTL() int {
 	return 120
 }
 
+func (p *Provider) CredentialFields() []models.CredentialField {
+	return []models.CredentialField{
+		{Name: "api_key", Label: "API Key", Secret: true, Required: true},
+	}
+}
+
 func (p *Provider) ListDomains(ctx context.Context, apiKey string) ([]models.Domain, error) {
 	resp, err := p.client.GetDomains(ctx, apiKey)
 	if err != nil {
@@ -0,0 +1,178 @@
+package dynu
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"dns-mng/provider/providertest"
+)
+
+const (
+	testAPIKey   = "dynu-test-key"
+	testDomainID = 98765
+)
+
+// fakeDynu serves the parts of api.dynu.com/v2 the provider uses. The
+// domain's own IPv4/IPv6 address is the apex A/AAAA record.
+type fakeDynu struct {
+	zone *providertest.Zone
+
+	mu     sync.Mutex
+	domain DynuDomain
+}
+
+func newFakeDynu() *fakeDynu {
+	zone := providertest.NewZone("example.dynu.net")
+	return &fakeDynu{
+		zone: zone,
+		domain: DynuDomain{
+			ID:          testDomainID,
+			Name:        zone.Name,
+			UnicodeName: zone.Name,
+			State:       "Complete",
+			TTL:         120,
+		},
+	}
+}
+
+func (f *fakeDynu) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("API-Key") != testAPIKey {
+		providertest.WriteJSON(w, http.StatusUnauthorized, map[string]interface{}{
+			"statusCode": 401, "type": "Authentication Exception", "message": "Authentication failed.",
+		})
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) == 1 && parts[0] == "dns" && r.Method == http.MethodGet {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		providertest.WriteJSON(w, http.StatusOK, DynuDomainsResponse{StatusCode: 200, Domains: []DynuDomain{f.domain}})
+		return
+	}
+	if len(parts) < 2 || parts[0] != "dns" || parts[1] != strconv.Itoa(testDomainID) {
+		providertest.WriteJSON(w, http.StatusNotFound, map[string]interface{}{
+			"statusCode": 404, "type": "Not Found Exception", "message": "Domain not found.",
+		})
+		return
+	}
+
+	switch {
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		providertest.WriteJSON(w, http.StatusOK, f.domain)
+	case len(parts) == 2 && r.Method == http.MethodPost:
+		var body DynuDomain
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.domain.IPv4Address, f.domain.IPv6Address = body.IPv4Address, body.IPv6Address
+		f.domain.IPv4, f.domain.IPv6 = body.IPv4, body.IPv6
+		f.domain.TTL = body.TTL
+		providertest.WriteJSON(w, http.StatusOK, map[string]int{"statusCode": 200})
+	case len(parts) == 3 && r.Method == http.MethodGet:
+		records := []DynuRecord{}
+		for _, rec := range f.zone.Records() {
+			records = append(records, f.toDynu(rec))
+		}
+		providertest.WriteJSON(w, http.StatusOK, DynuRecordsResponse{StatusCode: 200, DnsRecords: records})
+	case len(parts) == 3 && r.Method == http.MethodPost:
+		rec, ok := f.decodeRecord(w, r)
+		if !ok {
+			return
+		}
+		providertest.WriteJSON(w, http.StatusOK, f.toDynu(f.zone.Add(rec)))
+	case len(parts) == 4 && r.Method == http.MethodPost:
+		rec, ok := f.decodeRecord(w, r)
+		if !ok {
+			return
+		}
+		rec.ID = parts[3]
+		if !f.zone.Update(rec) {
+			providertest.WriteJSON(w, http.StatusNotFound, map[string]interface{}{"statusCode": 404, "message": "Record not found."})
+			return
+		}
+		providertest.WriteJSON(w, http.StatusOK, f.toDynu(rec))
+	case len(parts) == 4 && r.Method == http.MethodDelete:
+		if !f.zone.Delete(parts[3]) {
+			providertest.WriteJSON(w, http.StatusNotFound, map[string]interface{}{"statusCode": 404, "message": "Record not found."})
+			return
+		}
+		providertest.WriteJSON(w, http.StatusOK, map[string]int{"statusCode": 200})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeDynu) decodeRecord(w http.ResponseWriter, r *http.Request) (providertest.Record, bool) {
+	var body DynuRecord
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return providertest.Record{}, false
+	}
+	rec := providertest.Record{
+		Name:     f.zone.Relative(body.NodeName),
+		Type:     body.RecordType,
+		TTL:      body.TTL,
+		Priority: body.Priority,
+		Disabled: !body.State,
+	}
+	switch body.RecordType {
+	case "A":
+		rec.Content = body.IPv4Address
+	case "AAAA":
+		rec.Content = body.IPv6Address
+	case "CNAME", "MX", "SRV":
+		rec.Content = body.Host
+	default:
+		rec.Content = body.TextData
+	}
+	return rec, true
+}
+
+func (f *fakeDynu) toDynu(rec providertest.Record) DynuRecord {
+	out := DynuRecord{
+		DomainID:   testDomainID,
+		DomainName: f.zone.Name,
+		NodeName:   rec.Name,
+		Hostname:   f.zone.FQDN(rec.Name),
+		RecordType: rec.Type,
+		TTL:        rec.TTL,
+		State:      !rec.Disabled,
+		Content:    rec.Content,
+		Priority:   rec.Priority,
+	}
+	out.ID, _ = strconv.ParseInt(rec.ID, 10, 64)
+	switch rec.Type {
+	case "A":
+		out.IPv4Address = rec.Content
+	case "AAAA":
+		out.IPv6Address = rec.Content
+	case "CNAME", "MX", "SRV":
+		out.Host = rec.Content
+	default:
+		out.TextData = rec.Content
+	}
+	return out
+}
+
+func TestConformance(t *testing.T) {
+	server := httptest.NewServer(newFakeDynu())
+	defer server.Close()
+
+	providertest.Run(t, NewWithEndpoint(server.URL), providertest.Config{
+		APIKey:    testAPIKey,
+		BadAPIKey: "wrong-key",
+		AuthError: "Authentication failed",
+		Zone:      "example.dynu.net",
+	})
+}
@@ -0,0 +1,445 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"dns-mng/models"
+)
+
+// Every call the app makes to a provider goes through Do, which applies the
+// same policy to all platforms:
+//   - a token bucket per account, sized by the provider (RateLimited), and
+//     one per provider shared by all its accounts (ProviderRateLimited)
+//   - exponential backoff on rate limiting (429), server errors (5xx) and
+//     network errors
+//   - a circuit breaker per account that fails calls fast after repeated
+//     failures, so a dead or revoked account does not stall every page and
+//     scheduled job. Its state is reported by Health.
+
+// ErrCircuitOpen is returned without calling the provider while an
+// account's circuit breaker is open.
+var ErrCircuitOpen = errors.New("provider temporarily disabled after repeated failures")
+
+// Retry decides which failures of a call are retried
+type Retry int
+
+const (
+	// RetrySafe retries rate limiting, server and network errors. For reads
+	// and for writes that can be repeated without changing the outcome.
+	RetrySafe Retry = iota
+	// RetryThrottled retries rate limiting only, which platforms answer
+	// before acting. For creates, deletes and record updates (some
+	// providers update by delete and re-create), which a repeat after a
+	// lost response would duplicate or fail.
+	RetryThrottled
+)
+
+// RateLimited is implemented by providers whose API allows a different
+// call rate than DefaultCallRate and DefaultCallBurst per account.
+type RateLimited interface {
+	// CallRate returns the sustained calls per second and the burst
+	CallRate() (perSecond float64, burst int)
+}
+
+// ProviderRateLimited is implemented by providers whose API limits all calls
+// from this server together, across accounts, to a different rate than
+// DefaultProviderCallRate and DefaultProviderCallBurst.
+type ProviderRateLimited interface {
+	// ProviderCallRate returns the sustained calls per second and the burst
+	ProviderCallRate() (perSecond float64, burst int)
+}
+
+const (
+	DefaultCallRate  = 5.0
+	DefaultCallBurst = 10
+
+	DefaultProviderCallRate  = 20.0
+	DefaultProviderCallBurst = 40
+
+	maxAttempts = 3
+	// breakerThreshold consecutive failed calls open the breaker for
+	// breakerCooldown; then one trial call decides whether it closes
+	breakerThreshold = 5
+	breakerCooldown  = time.Minute
+)
+
+// Variables so tests can run without real waits
+var (
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 10 * time.Second
+	now            = time.Now
+)
+
+// Account health states reported by Health
+const (
+	HealthUnknown   = "unknown"
+	HealthHealthy   = "healthy"
+	HealthDegraded  = "degraded"
+	HealthUnhealthy = "unhealthy"
+)
+
+// bucket is a token bucket
+type bucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	filled time.Time
+}
+
+func newBucket(rate float64, burst int, defaultRate float64) bucket {
+	if rate <= 0 {
+		rate = defaultRate
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return bucket{rate: rate, burst: float64(burst), tokens: float64(burst), filled: now()}
+}
+
+// reserve takes a token and returns how long to wait until it is available
+func (b *bucket) reserve(t time.Time) time.Duration {
+	b.tokens += t.Sub(b.filled).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.filled = t
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+type accountGuard struct {
+	bucket
+
+	// circuit breaker
+	failures  int
+	openUntil time.Time
+	probing   bool
+
+	lastError   string
+	lastFailure time.Time
+	lastSuccess time.Time
+}
+
+var guards = struct {
+	mu        sync.Mutex
+	accounts  map[int64]*accountGuard
+	providers map[string]*bucket
+}{accounts: make(map[int64]*accountGuard), providers: make(map[string]*bucket)}
+
+// Do runs fn, one call to p on behalf of the account, through the account's
+// token bucket, the retry policy and its circuit breaker.
+func Do(ctx context.Context, p DNSProvider, accountID int64, retry Retry, fn func(ctx context.Context) error) error {
+	trial, err := admit(p, accountID)
+	if err != nil {
+		return err
+	}
+	if trial {
+		// record ends the trial; a panicking call must end it too, or the
+		// account would fail fast for good
+		defer endTrial(accountID)
+	}
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			if sleep(ctx, backoff(attempt)) != nil {
+				break
+			}
+		}
+		if err = take(ctx, p, accountID); err != nil {
+			break
+		}
+		err = fn(ctx)
+		// A trial call after the cooldown gets a single attempt
+		if trial {
+			break
+		}
+		class := classify(ctx, err)
+		if class != failThrottled && (class != failServer || retry != RetrySafe) {
+			break
+		}
+	}
+	record(ctx, accountID, err)
+	return err
+}
+
+// Probe runs fn once on behalf of the account and records the outcome like
+// Do, but also while the account's breaker is open: a connection test is
+// asked for explicitly and must reach the provider. A success closes the
+// breaker.
+func Probe(ctx context.Context, p DNSProvider, accountID int64, fn func(ctx context.Context) error) error {
+	guards.mu.Lock()
+	guardFor(p, accountID)
+	guards.mu.Unlock()
+
+	err := take(ctx, p, accountID)
+	if err == nil {
+		err = fn(ctx)
+	}
+	record(ctx, accountID, err)
+	return err
+}
+
+// CheckCode maps the outcome of a provider call to an account check code
+func CheckCode(ctx context.Context, err error) string {
+	switch classify(ctx, err) {
+	case failNone:
+		return models.AccountCheckOK
+	case failThrottled:
+		return models.AccountCheckRateLimited
+	case failServer:
+		return models.AccountCheckUnreachable
+	case failAuth:
+		return models.AccountCheckUnauthorized
+	}
+	return models.AccountCheckError
+}
+
+// admit fails fast while the account's breaker is open. Once the cooldown
+// is over it lets one call through as a trial and holds back the others
+// until that call has finished.
+func admit(p DNSProvider, accountID int64) (trial bool, err error) {
+	guards.mu.Lock()
+	defer guards.mu.Unlock()
+	g := guardFor(p, accountID)
+	if g.probing {
+		return false, ErrCircuitOpen
+	}
+	if now().Before(g.openUntil) {
+		return false, fmt.Errorf("%w, retrying after %s", ErrCircuitOpen, g.openUntil.Format(time.RFC3339))
+	}
+	if g.failures >= breakerThreshold {
+		g.probing = true
+		return true, nil
+	}
+	return false, nil
+}
+
+// endTrial lets the next call through as a trial if the current one did not
+// get to record its outcome
+func endTrial(accountID int64) {
+	guards.mu.Lock()
+	defer guards.mu.Unlock()
+	if g, ok := guards.accounts[accountID]; ok {
+		g.probing = false
+	}
+}
+
+// take waits for a token of the account's bucket and of p's
+func take(ctx context.Context, p DNSProvider, accountID int64) error {
+	guards.mu.Lock()
+	g, ok := guards.accounts[accountID]
+	if !ok {
+		// Reset while the call was under way
+		guards.mu.Unlock()
+		return ctx.Err()
+	}
+	t := now()
+	wait := g.reserve(t)
+	if pw := providerBucket(p).reserve(t); pw > wait {
+		wait = pw
+	}
+	guards.mu.Unlock()
+
+	return sleep(ctx, wait)
+}
+
+// providerBucket returns p's bucket, creating it with p's provider-wide
+// call rate. The caller holds guards.mu.
+func providerBucket(p DNSProvider) *bucket {
+	b, ok := guards.providers[p.Name()]
+	if !ok {
+		rate, burst := DefaultProviderCallRate, DefaultProviderCallBurst
+		if rl, ok := p.(ProviderRateLimited); ok {
+			rate, burst = rl.ProviderCallRate()
+		}
+		nb := newBucket(rate, burst, DefaultProviderCallRate)
+		b = &nb
+		guards.providers[p.Name()] = b
+	}
+	return b
+}
+
+// guardFor returns the account's state, creating it with p's call rate.
+// The caller holds guards.mu.
+func guardFor(p DNSProvider, accountID int64) *accountGuard {
+	g, ok := guards.accounts[accountID]
+	if !ok {
+		rate, burst := DefaultCallRate, DefaultCallBurst
+		if rl, ok := p.(RateLimited); ok {
+			rate, burst = rl.CallRate()
+		}
+		g = &accountGuard{bucket: newBucket(rate, burst, DefaultCallRate)}
+		guards.accounts[accountID] = g
+	}
+	return g
+}
+
+// record feeds the outcome of a call into the account's breaker. Failures
+// that are the caller's doing (cancellation, invalid input, missing
+// records) neither count against the account nor reset it.
+func record(ctx context.Context, accountID int64, err error) {
+	guards.mu.Lock()
+	defer guards.mu.Unlock()
+	g, ok := guards.accounts[accountID]
+	if !ok {
+		return
+	}
+	g.probing = false
+
+	switch classify(ctx, err) {
+	case failNone:
+		g.failures = 0
+		g.openUntil = time.Time{}
+		g.lastSuccess = now()
+	case failThrottled, failServer, failAuth:
+		g.failures++
+		g.lastError = err.Error()
+		g.lastFailure = now()
+		if g.failures >= breakerThreshold {
+			g.openUntil = now().Add(breakerCooldown)
+		}
+	}
+}
+
+// Health reports the state of recent provider calls for an account
+func Health(accountID int64) *models.AccountHealth {
+	guards.mu.Lock()
+	defer guards.mu.Unlock()
+	g, ok := guards.accounts[accountID]
+	if !ok || (g.lastSuccess.IsZero() && g.lastFailure.IsZero()) {
+		return &models.AccountHealth{Status: HealthUnknown}
+	}
+
+	h := &models.AccountHealth{
+		Status:              HealthHealthy,
+		ConsecutiveFailures: g.failures,
+		LastError:           g.lastError,
+	}
+	if !g.lastSuccess.IsZero() {
+		t := g.lastSuccess
+		h.LastSuccessAt = &t
+	}
+	if !g.lastFailure.IsZero() {
+		t := g.lastFailure
+		h.LastFailureAt = &t
+	}
+	switch {
+	case g.failures >= breakerThreshold:
+		h.Status = HealthUnhealthy
+		if now().Before(g.openUntil) {
+			t := g.openUntil
+			h.RetryAfter = &t
+		}
+	case g.failures > 0:
+		h.Status = HealthDegraded
+	}
+	return h
+}
+
+// ResetAccount forgets an account's bucket and breaker, e.g. after its
+// credentials change or it is deleted
+func ResetAccount(accountID int64) {
+	guards.mu.Lock()
+	defer guards.mu.Unlock()
+	delete(guards.accounts, accountID)
+}
+
+func backoff(attempt int) time.Duration {
+	d := retryBaseDelay << uint(attempt-1)
+	if d > retryMaxDelay || d <= 0 {
+		d = retryMaxDelay
+	}
+	// Jitter so accounts throttled together do not retry in lockstep
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type failure int
+
+const (
+	failNone failure = iota
+	// failOther is an error the platform answered deliberately, such as
+	// invalid input or a missing record, or the caller's cancellation
+	failOther
+	failThrottled
+	failServer
+	failAuth
+)
+
+// HTTPStatusError is implemented by provider errors that carry the HTTP
+// status of the platform's answer
+type HTTPStatusError interface {
+	HTTPStatus() int
+}
+
+var (
+	// Most clients put the status into the message, e.g. "API error
+	// (status 503)", "HTTP 429: ..." or "rate limit exceeded (429)"
+	statusPattern = regexp.MustCompile(`(?i)(?:\bstatus(?:[ _]?code)?"?\s*[:=]?\s*|\bhttp\s+|\()([1-5]\d\d)\b`)
+	// SDK-based providers report platform error codes instead
+	throttledPattern = regexp.MustCompile(`(?i)too many requests|rate.?limit|throttl|RequestLimitExceeded`)
+	authPattern      = regexp.MustCompile(`(?i)unauthori[sz]ed|invalid (?:api key|access token|credentials)|AuthFailure|InvalidAccessKeyId|SignatureDoesNotMatch|APIGW\.030`)
+)
+
+func classify(ctx context.Context, err error) failure {
+	if err == nil {
+		return failNone
+	}
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, ErrNotSupported) {
+		return failOther
+	}
+
+	status := 0
+	var se HTTPStatusError
+	if errors.As(err, &se) {
+		status = se.HTTPStatus()
+	} else if m := statusPattern.FindStringSubmatch(err.Error()); m != nil {
+		status, _ = strconv.Atoi(m[1])
+	}
+	switch {
+	case status == 429:
+		return failThrottled
+	case status >= 500:
+		return failServer
+	case status == 401 || status == 403:
+		return failAuth
+	}
+
+	msg := err.Error()
+	if throttledPattern.MatchString(msg) {
+		return failThrottled
+	}
+	if authPattern.MatchString(msg) {
+		return failAuth
+	}
+	var ne net.Error
+	if errors.As(err, &ne) || errors.Is(err, context.DeadlineExceeded) || strings.Contains(msg, "connection refused") {
+		return failServer
+	}
+	return failOther
+}
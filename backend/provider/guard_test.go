@@ -0,0 +1,307 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"dns-mng/models"
+	"dns-mng/provider/mock"
+)
+
+// limitedProvider allows 50 calls per second with no burst
+type limitedProvider struct {
+	*mock.Provider
+}
+
+func (limitedProvider) CallRate() (float64, int) {
+	return 50, 1
+}
+
+// sharedLimitedProvider allows 50 calls per second with no burst across all
+// its accounts
+type sharedLimitedProvider struct {
+	*mock.Provider
+}
+
+func (sharedLimitedProvider) Name() string { return "shared-limited" }
+
+func (sharedLimitedProvider) ProviderCallRate() (float64, int) {
+	return 50, 1
+}
+
+type statusError int
+
+func (e statusError) Error() string   { return fmt.Sprintf("status %d", int(e)) }
+func (e statusError) HTTPStatus() int { return int(e) }
+
+func setupGuard(t *testing.T) {
+	t.Helper()
+	base, clock := retryBaseDelay, now
+	retryBaseDelay = time.Millisecond
+	t.Cleanup(func() {
+		retryBaseDelay, now = base, clock
+		guards.mu.Lock()
+		guards.accounts = make(map[int64]*accountGuard)
+		guards.providers = make(map[string]*bucket)
+		guards.mu.Unlock()
+	})
+}
+
+// calls returns fn failing with the given errors in turn, then succeeding,
+// and a counter of its calls
+func calls(errs ...error) (func(context.Context) error, *int) {
+	n := 0
+	return func(context.Context) error {
+		n++
+		if n <= len(errs) {
+			return errs[n-1]
+		}
+		return nil
+	}, &n
+}
+
+func TestDoRetries(t *testing.T) {
+	setupGuard(t)
+	ctx := context.Background()
+	p := mock.New(mock.Options{})
+
+	fn, n := calls(statusError(429), errors.New("API error (status 503): unavailable"))
+	if err := Do(ctx, p, 1, RetrySafe, fn); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if *n != 3 {
+		t.Errorf("RetrySafe made %d attempts for 429 then 503, want 3", *n)
+	}
+	if h := Health(1); h.Status != HealthHealthy {
+		t.Errorf("health after a successful retry = %q, want %q", h.Status, HealthHealthy)
+	}
+
+	// A create is repeated on 429, but not on 5xx since it may have applied
+	fn, n = calls(errors.New("rate limit exceeded (429)"), statusError(502))
+	if err := Do(ctx, p, 2, RetryThrottled, fn); err == nil {
+		t.Errorf("RetryThrottled retried a 5xx")
+	}
+	if *n != 2 {
+		t.Errorf("RetryThrottled made %d attempts for 429 then 502, want 2", *n)
+	}
+
+	// Errors the platform answered deliberately are not retried
+	fn, n = calls(statusError(404))
+	if err := Do(ctx, p, 3, RetrySafe, fn); err == nil || *n != 1 {
+		t.Errorf("404: err = %v after %d attempts, want the error after 1", err, *n)
+	}
+	if h := Health(3); h.Status != HealthUnknown {
+		t.Errorf("health after a 404 = %q, want %q", h.Status, HealthUnknown)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	setupGuard(t)
+	ctx := context.Background()
+	p := mock.New(mock.Options{})
+	clock := time.Now()
+	now = func() time.Time { return clock }
+
+	unauthorized := statusError(401)
+	for i := 0; i < breakerThreshold; i++ {
+		fn, _ := calls(unauthorized)
+		if err := Do(ctx, p, 1, RetrySafe, fn); !errors.Is(err, unauthorized) {
+			t.Fatalf("call %d: err = %v, want %v", i, err, unauthorized)
+		}
+		if i == 0 && Health(1).Status != HealthDegraded {
+			t.Errorf("health after one failure = %q, want %q", Health(1).Status, HealthDegraded)
+		}
+	}
+
+	h := Health(1)
+	if h.Status != HealthUnhealthy || h.RetryAfter == nil || h.LastError == "" {
+		t.Fatalf("health after %d failures = %+v, want unhealthy with retry_after and last_error", breakerThreshold, h)
+	}
+	fn, n := calls()
+	if err := Do(ctx, p, 1, RetrySafe, fn); !errors.Is(err, ErrCircuitOpen) || *n != 0 {
+		t.Errorf("open breaker: err = %v after %d calls, want ErrCircuitOpen without calling", err, *n)
+	}
+
+	// After the cooldown one trial call decides; a failure reopens
+	clock = clock.Add(breakerCooldown + time.Second)
+	fn, n = calls(statusError(503), statusError(503))
+	if err := Do(ctx, p, 1, RetrySafe, fn); err == nil || *n != 1 {
+		t.Errorf("trial call: err = %v after %d attempts, want the error after 1", err, *n)
+	}
+	if err := Do(ctx, p, 1, RetrySafe, fn); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("failed trial did not reopen the breaker: err = %v", err)
+	}
+
+	// and a success closes it
+	clock = clock.Add(breakerCooldown + time.Second)
+	fn, _ = calls()
+	if err := Do(ctx, p, 1, RetrySafe, fn); err != nil {
+		t.Fatalf("trial call: %v", err)
+	}
+	if h := Health(1); h.Status != HealthHealthy || h.ConsecutiveFailures != 0 {
+		t.Errorf("health after a successful trial = %+v, want healthy", h)
+	}
+
+	ResetAccount(1)
+	if h := Health(1); h.Status != HealthUnknown {
+		t.Errorf("health after ResetAccount = %q, want %q", h.Status, HealthUnknown)
+	}
+}
+
+func TestTrialPanic(t *testing.T) {
+	setupGuard(t)
+	ctx := context.Background()
+	p := mock.New(mock.Options{})
+	clock := time.Now()
+	now = func() time.Time { return clock }
+
+	for i := 0; i < breakerThreshold; i++ {
+		fn, _ := calls(statusError(503))
+		Do(ctx, p, 1, RetryThrottled, fn)
+	}
+	clock = clock.Add(breakerCooldown + time.Second)
+
+	func() {
+		defer func() { recover() }()
+		Do(ctx, p, 1, RetrySafe, func(context.Context) error { panic("provider bug") })
+	}()
+
+	// The panic did not leave the breaker waiting for the trial to finish
+	fn, n := calls()
+	if err := Do(ctx, p, 1, RetrySafe, fn); err != nil || *n != 1 {
+		t.Errorf("call after a panicking trial: err = %v after %d calls, want success after 1", err, *n)
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	setupGuard(t)
+	ctx := context.Background()
+	p := limitedProvider{mock.New(mock.Options{})}
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		fn, _ := calls()
+		if err := Do(ctx, p, 1, RetrySafe, fn); err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+	}
+	// One call is free, the other three wait 20ms each
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("4 calls at 50/s with burst 1 took %s, want about 60ms", elapsed)
+	}
+
+	// Another account has its own, full bucket
+	fn, _ := calls()
+	if err := Do(ctx, p, 2, RetrySafe, fn); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	guards.mu.Lock()
+	tokens := guards.accounts[2].tokens
+	guards.mu.Unlock()
+	if tokens < 0 {
+		t.Errorf("first call of another account had to wait for a token")
+	}
+}
+
+func TestProviderBucket(t *testing.T) {
+	setupGuard(t)
+	ctx := context.Background()
+	p := sharedLimitedProvider{mock.New(mock.Options{})}
+
+	// Each call uses a fresh account, so only the provider's bucket limits
+	start := time.Now()
+	for i := int64(1); i <= 4; i++ {
+		fn, _ := calls()
+		if err := Do(ctx, p, i, RetrySafe, fn); err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("4 calls of different accounts at 50/s per provider took %s, want about 60ms", elapsed)
+	}
+
+	// Other providers have their own bucket
+	fn, _ := calls()
+	if err := Do(ctx, mock.New(mock.Options{}), 5, RetrySafe, fn); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	guards.mu.Lock()
+	tokens := guards.providers["mock"].tokens
+	guards.mu.Unlock()
+	if tokens < 0 {
+		t.Errorf("first call of another provider had to wait for a token")
+	}
+}
+
+func TestClassify(t *testing.T) {
+	ctx := context.Background()
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	for _, tc := range []struct {
+		ctx  context.Context
+		err  error
+		want failure
+	}{
+		{ctx, nil, failNone},
+		{ctx, errors.New("API error (status 429): slow down"), failThrottled},
+		{ctx, errors.New("rate limit exceeded: NDJP allows max 10 requests per minute"), failThrottled},
+		{ctx, errors.New("[TencentCloudSDKError] Code=RequestLimitExceeded, Message=..."), failThrottled},
+		{ctx, errors.New("HTTP 502: bad gateway"), failServer},
+		{ctx, fmt.Errorf("list records: %w", statusError(500)), failServer},
+		{ctx, errors.New(`{"status_code":503,"error_code":"APIGW.0201"}`), failServer},
+		{ctx, errors.New("API error (status 401): unauthorized"), failAuth},
+		{ctx, errors.New("Invalid access token"), failAuth},
+		{ctx, errors.New("[TencentCloudSDKError] Code=AuthFailure.SecretIdNotFound"), failAuth},
+		{ctx, errors.New("API error (status 400): invalid TTL"), failOther},
+		{ctx, errors.New("record not found: 1001"), failOther},
+		{ctx, ErrNotSupported, failOther},
+		{cancelled, context.Canceled, failOther},
+		{ctx, context.DeadlineExceeded, failServer},
+	} {
+		if got := classify(tc.ctx, tc.err); got != tc.want {
+			t.Errorf("classify(%v) = %d, want %d", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestProbe(t *testing.T) {
+	setupGuard(t)
+	ctx := context.Background()
+	p := mock.New(mock.Options{})
+
+	for i := 0; i < breakerThreshold; i++ {
+		fn, _ := calls(statusError(503))
+		Do(ctx, p, 1, RetryThrottled, fn)
+	}
+	if Health(1).Status != HealthUnhealthy {
+		t.Fatalf("health = %q, want %q", Health(1).Status, HealthUnhealthy)
+	}
+
+	// A probe reaches the provider despite the open breaker and closes it
+	fn, n := calls()
+	if err := Probe(ctx, p, 1, fn); err != nil || *n != 1 {
+		t.Fatalf("Probe: err = %v after %d calls, want success after 1", err, *n)
+	}
+	if h := Health(1); h.Status != HealthHealthy {
+		t.Errorf("health after a successful probe = %q, want %q", h.Status, HealthHealthy)
+	}
+
+	for _, tc := range []struct {
+		err  error
+		want string
+	}{
+		{nil, models.AccountCheckOK},
+		{statusError(401), models.AccountCheckUnauthorized},
+		{statusError(429), models.AccountCheckRateLimited},
+		{statusError(502), models.AccountCheckUnreachable},
+		{statusError(404), models.AccountCheckError},
+	} {
+		if got := CheckCode(ctx, tc.err); got != tc.want {
+			t.Errorf("CheckCode(%v) = %q, want %q", tc.err, got, tc.want)
+		}
+	}
+}
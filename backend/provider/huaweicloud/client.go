@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/huaweicloud/huaweicloud-sdk-go-v3/core/auth/basic"
+	"github.com/huaweicloud/huaweicloud-sdk-go-v3/core/region"
 	hwdns "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/dns/v2"
 	"github.com/huaweicloud/huaweicloud-sdk-go-v3/services/dns/v2/model"
 	hwdnsregion "github.com/huaweicloud/huaweicloud-sdk-go-v3/services/dns/v2/region"
@@ -24,7 +25,10 @@ type apiCredentials struct {
 	RegionID  string
 }
 
-type Client struct{}
+type Client struct {
+	// endpoint overrides the regional DNS endpoint when set, e.g. "http://127.0.0.1:8080"
+	endpoint string
+}
 
 func NewClient() *Client {
 	return &Client{}
@@ -77,9 +81,11 @@ func (c *Client) newDNSClient(apiKey string) (*hwdns.DnsClient, error) {
 	if err != nil {
 		return nil, err
 	}
-	reg, err := hwdnsregion.SafeValueOf(cr.RegionID)
-	if err != nil {
-		return nil, fmt.Errorf("dns region: %w", err)
+	reg := region.NewRegion(cr.RegionID, c.endpoint)
+	if c.endpoint == "" {
+		if reg, err = hwdnsregion.SafeValueOf(cr.RegionID); err != nil {
+			return nil, fmt.Errorf("dns region: %w", err)
+		}
 	}
 	b := basic.NewCredentialsBuilder().WithAk(cr.AK).WithSk(cr.SK)
 	if cr.ProjectID != "" {
@@ -20,6 +20,15 @@ func New() *Provider {
 	return &Provider{client: NewClient()}
 }
 
+// NewWithEndpoint returns a provider that sends DNS calls to endpoint
+// instead of the regional Huawei Cloud endpoint, e.g. a stand-in server
+// in tests. API keys should carry a project ID so no IAM lookup is made.
+func NewWithEndpoint(endpoint string) *Provider {
+	p := New()
+	p.client.endpoint = endpoint
+	return p
+}
+
 func (p *Provider) Name() string {
 	return "huaweicloud"
 }
@@ -36,6 +45,35 @@ func (p *Provider) DefaultTTL() int {
 	return 300
 }
 
+func (p *Provider) CredentialFields() []models.CredentialField {
+	return []models.CredentialField{
+		{Name: "access_key_id", Label: "Access Key ID (AK)", Required: true},
+		{Name: "secret_access_key", Label: "Secret Access Key (SK)", Secret: true, Required: true},
+		{Name: "project_id", Label: "Project ID"},
+		{Name: "region", Label: "Region", Placeholder: defaultRegionID, Pattern: `^[a-z]{2}-[a-z0-9-]+$`},
+	}
+}
+
+// A third value of a legacy API key is a region or a project ID depending
+// on how it looks, so splitting by position would misplace regions.
+func (p *Provider) DecodeCredentials(apiKey string) (models.Credentials, error) {
+	cr, err := parseAPIKey(apiKey)
+	if err != nil {
+		return nil, err
+	}
+	return models.Credentials{
+		"access_key_id":     cr.AK,
+		"secret_access_key": cr.SK,
+		"project_id":        cr.ProjectID,
+		"region":            cr.RegionID,
+	}, nil
+}
+
+func (p *Provider) ValidateAPIKey(apiKey string) error {
+	_, err := parseAPIKey(apiKey)
+	return err
+}
+
 func (p *Provider) ListDomains(ctx context.Context, apiKey string) ([]models.Domain, error) {
 	zones, err := p.client.ListPublicZones(ctx, apiKey)
 	if err != nil {
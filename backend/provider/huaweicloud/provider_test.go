@@ -0,0 +1,201 @@
+package huaweicloud
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"dns-mng/models"
+	"dns-mng/provider/providertest"
+
+	"github.com/huaweicloud/huaweicloud-sdk-go-v3/services/dns/v2/model"
+)
+
+const (
+	testAK        = "HWTESTAK"
+	testSK        = "hw-test-sk"
+	testProjectID = "0123456789abcdef0123456789abcdef"
+	testZoneID    = "ff8080825b8fc86c015b94bc6f8712c3"
+)
+
+// fakeHuaweiDNS serves the DNS v2 zone and record set API. Names are
+// fully qualified with a trailing dot. Each record set created through the
+// provider holds one value, so the stand-in stores one value per set.
+type fakeHuaweiDNS struct {
+	zone *providertest.Zone
+}
+
+func fail(w http.ResponseWriter, status int, code, msg string) {
+	providertest.WriteJSON(w, status, map[string]string{"code": code, "message": msg})
+}
+
+func (f *fakeHuaweiDNS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.Header.Get("Authorization"), "Access="+testAK+",") {
+		providertest.WriteJSON(w, http.StatusUnauthorized, map[string]string{
+			"error_code": "APIGW.0301", "error_msg": "Incorrect IAM authentication information: AK access failed to reach the limit",
+		})
+		return
+	}
+
+	// /v2/zones[/{id}[/recordsets[/{rsid}]]] and /v2.1/recordsets/{rsid}/statuses/set
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) == 4 && parts[0] == "v2.1" && parts[1] == "recordsets" && r.Method == http.MethodPut {
+		f.setStatus(w, r, parts[2])
+		return
+	}
+	if len(parts) < 2 || parts[0] != "v2" || parts[1] != "zones" {
+		fail(w, http.StatusNotFound, "APIGW.0101", "The API does not exist or has not been published in the environment")
+		return
+	}
+	if len(parts) == 2 && r.Method == http.MethodGet {
+		providertest.WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"zones":    []model.PublicZoneResp{f.publicZone()},
+			"metadata": map[string]int{"total_count": 1},
+		})
+		return
+	}
+	if parts[2] != testZoneID {
+		fail(w, http.StatusNotFound, "DNS.0101", "The zone does not exist.")
+		return
+	}
+
+	switch {
+	case len(parts) == 3 && r.Method == http.MethodGet:
+		providertest.WriteJSON(w, http.StatusOK, f.publicZone())
+	case len(parts) == 4 && r.Method == http.MethodGet:
+		sets := []model.ListRecordSets{}
+		for _, rec := range f.zone.Records() {
+			sets = append(sets, f.toRecordSet(rec))
+		}
+		providertest.WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"recordsets": sets,
+			"metadata":   map[string]int{"total_count": len(sets)},
+		})
+	case len(parts) == 4 && r.Method == http.MethodPost:
+		var body model.CreateRecordSetRequestBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.Records) != 1 {
+			fail(w, http.StatusBadRequest, "DNS.0303", "Invalid record set.")
+			return
+		}
+		rec := providertest.Record{
+			Name:    f.zone.Relative(body.Name),
+			Type:    body.Type,
+			Content: body.Records[0],
+			TTL:     300,
+		}
+		if body.Ttl != nil {
+			rec.TTL = int(*body.Ttl)
+		}
+		if body.Status != nil {
+			rec.Disabled = *body.Status == "DISABLE"
+		}
+		if len(f.zone.Lookup(rec.Name, rec.Type)) > 0 {
+			fail(w, http.StatusBadRequest, "DNS.0312", "The record set already exists.")
+			return
+		}
+		providertest.WriteJSON(w, http.StatusAccepted, f.toRecordSet(f.zone.Add(rec)))
+	case len(parts) == 5 && r.Method == http.MethodGet:
+		rec, ok := f.zone.Get(parts[4])
+		if !ok {
+			fail(w, http.StatusNotFound, "DNS.0304", "The record set does not exist.")
+			return
+		}
+		providertest.WriteJSON(w, http.StatusOK, f.toRecordSet(rec))
+	case len(parts) == 5 && r.Method == http.MethodPut:
+		rec, ok := f.zone.Get(parts[4])
+		if !ok {
+			fail(w, http.StatusNotFound, "DNS.0304", "The record set does not exist.")
+			return
+		}
+		var body model.UpdateRecordSetReq
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Records == nil || len(*body.Records) != 1 {
+			fail(w, http.StatusBadRequest, "DNS.0303", "Invalid record set.")
+			return
+		}
+		rec.Content = (*body.Records)[0]
+		if body.Ttl != nil {
+			rec.TTL = int(*body.Ttl)
+		}
+		f.zone.Update(rec)
+		providertest.WriteJSON(w, http.StatusAccepted, f.toRecordSet(rec))
+	case len(parts) == 5 && r.Method == http.MethodDelete:
+		rec, ok := f.zone.Get(parts[4])
+		if !ok || !f.zone.Delete(rec.ID) {
+			fail(w, http.StatusNotFound, "DNS.0304", "The record set does not exist.")
+			return
+		}
+		providertest.WriteJSON(w, http.StatusAccepted, f.toRecordSet(rec))
+	default:
+		fail(w, http.StatusNotFound, "APIGW.0101", "The API does not exist or has not been published in the environment")
+	}
+}
+
+func (f *fakeHuaweiDNS) setStatus(w http.ResponseWriter, r *http.Request, id string) {
+	rec, ok := f.zone.Get(id)
+	if !ok {
+		fail(w, http.StatusNotFound, "DNS.0304", "The record set does not exist.")
+		return
+	}
+	var body model.SetRecordSetsStatusRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		fail(w, http.StatusBadRequest, "DNS.0303", "Invalid status.")
+		return
+	}
+	rec.Disabled = body.Status == "DISABLE"
+	f.zone.Update(rec)
+	providertest.WriteJSON(w, http.StatusAccepted, f.toRecordSet(rec))
+}
+
+func (f *fakeHuaweiDNS) publicZone() model.PublicZoneResp {
+	id, name, status := testZoneID, f.zone.Name+".", "ACTIVE"
+	zoneType, created := "public", "2026-01-01T00:00:00.000"
+	return model.PublicZoneResp{Id: &id, Name: &name, Status: &status, ZoneType: &zoneType, CreatedAt: &created}
+}
+
+func (f *fakeHuaweiDNS) toRecordSet(rec providertest.Record) model.ListRecordSets {
+	id, name, zoneID, zoneName := rec.ID, f.zone.FQDN(rec.Name)+".", testZoneID, f.zone.Name+"."
+	recordType, ttl, records := rec.Type, int32(rec.TTL), []string{rec.Content}
+	status := "ACTIVE"
+	if rec.Disabled {
+		status = "DISABLE"
+	}
+	return model.ListRecordSets{
+		Id: &id, Name: &name, ZoneId: &zoneID, ZoneName: &zoneName,
+		Type: &recordType, Ttl: &ttl, Records: &records, Status: &status,
+	}
+}
+
+func TestConformance(t *testing.T) {
+	server := httptest.NewServer(&fakeHuaweiDNS{zone: providertest.NewZone("example.com")})
+	defer server.Close()
+
+	providertest.Run(t, NewWithEndpoint(server.URL), providertest.Config{
+		APIKey:    testAK + "," + testSK + "," + testProjectID,
+		BadAPIKey: "HWWRONGAK," + testSK + "," + testProjectID,
+		AuthError: "APIGW.0301",
+		Zone:      "example.com",
+	})
+}
+
+func TestDecodeCredentials(t *testing.T) {
+	p := New()
+	for key, want := range map[string]models.Credentials{
+		"ak,sk":                   {"access_key_id": "ak", "secret_access_key": "sk", "project_id": "", "region": defaultRegionID},
+		"ak,sk,ap-southeast-1":    {"access_key_id": "ak", "secret_access_key": "sk", "project_id": "", "region": "ap-southeast-1"},
+		"ak,sk,0a1b2c":            {"access_key_id": "ak", "secret_access_key": "sk", "project_id": "0a1b2c", "region": defaultRegionID},
+		"ak,sk,0a1b2c,cn-south-1": {"access_key_id": "ak", "secret_access_key": "sk", "project_id": "0a1b2c", "region": "cn-south-1"},
+	} {
+		got, err := p.DecodeCredentials(key)
+		if err != nil {
+			t.Errorf("DecodeCredentials(%q): %v", key, err)
+			continue
+		}
+		for name, v := range want {
+			if got[name] != v {
+				t.Errorf("DecodeCredentials(%q)[%s] = %q, want %q", key, name, got[name], v)
+			}
+		}
+	}
+}
@@ -15,10 +15,7 @@ import (
 	"time"
 )
 
-const (
-	baseURL  = "https://dns.he.net"
-	indexCGI = "https://dns.he.net/index.cgi"
-)
+const defaultBaseURL = "https://dns.he.net"
 
 // cleanHTML removes HTML entities from a string
 func cleanHTML(s string) string {
@@ -37,6 +34,7 @@ func cleanHTML(s string) string {
 
 type Client struct {
 	httpClient *http.Client
+	baseURL    string
 	username   string
 	password   string
 	mu         sync.Mutex
@@ -73,12 +71,17 @@ func NewClient(username, password string) *Client {
 				return nil
 			},
 		},
+		baseURL:  defaultBaseURL,
 		username: username,
 		password: password,
 		loggedIn: false,
 	}
 }
 
+func (c *Client) indexCGI() string {
+	return c.baseURL + "/index.cgi"
+}
+
 // isLoginForm checks if the response is a login page (session expired).
 func isLoginForm(body string) bool {
 	return strings.Contains(body, `name="login"`) || strings.Contains(body, `name="email"`)
@@ -86,10 +89,10 @@ func isLoginForm(body string) bool {
 
 // doLogin performs the actual login POST. Caller must hold c.mu.
 func (c *Client) doLogin(ctx context.Context) error {
-	baseURLParsed, _ := url.Parse(baseURL)
+	baseURLParsed, _ := url.Parse(c.baseURL)
 
 	// Step 1: Visit homepage to get initial cookie
-	req1, err := http.NewRequestWithContext(ctx, "GET", baseURL+"/", nil)
+	req1, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/", nil)
 	if err != nil {
 		return fmt.Errorf("create homepage request: %w", err)
 	}
@@ -110,13 +113,13 @@ func (c *Client) doLogin(ctx context.Context) error {
 	data.Set("pass", c.password)
 	data.Set("submit", "Login!")
 
-	req, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/", strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/", strings.NewReader(data.Encode()))
 	if err != nil {
 		return fmt.Errorf("create login request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("User-Agent", "Mozilla/5.0")
-	req.Header.Set("Referer", baseURL+"/")
+	req.Header.Set("Referer", c.baseURL+"/")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -141,10 +144,10 @@ func (c *Client) doLogin(ctx context.Context) error {
 	// If no cookies received, try to fetch the page again to get cookies
 	if len(cookies) == 0 {
 		time.Sleep(100 * time.Millisecond)
-		req2, err := http.NewRequestWithContext(ctx, "GET", baseURL+"/", nil)
+		req2, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/", nil)
 		if err == nil {
 			req2.Header.Set("User-Agent", "Mozilla/5.0")
-			req2.Header.Set("Referer", baseURL+"/")
+			req2.Header.Set("Referer", c.baseURL+"/")
 			resp2, err := c.httpClient.Do(req2)
 			if err == nil {
 				io.Copy(io.Discard, resp2.Body)
@@ -180,7 +183,7 @@ func (c *Client) ListZones(ctx context.Context) ([]Domain, error) {
 	}
 
 	// Fetch the domains page
-	req, err := http.NewRequestWithContext(ctx, "GET", baseURL+"/", nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/", nil)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
@@ -208,7 +211,7 @@ func (c *Client) ListZones(ctx context.Context) ([]Domain, error) {
 		}
 		c.mu.Unlock()
 
-		req2, err := http.NewRequestWithContext(ctx, "GET", baseURL+"/", nil)
+		req2, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/", nil)
 		if err != nil {
 			return nil, fmt.Errorf("create retry request: %w", err)
 		}
@@ -301,14 +304,14 @@ func (c *Client) ListRecords(ctx context.Context, zoneID string) ([]Record, erro
 }
 
 func (c *Client) fetchRecordsPage(ctx context.Context, zoneID string) (string, error) {
-	reqURL := fmt.Sprintf("%s/?hosted_dns_zoneid=%s&menu=edit_zone&hosted_dns_editzone", baseURL, zoneID)
+	reqURL := fmt.Sprintf("%s/?hosted_dns_zoneid=%s&menu=edit_zone&hosted_dns_editzone", c.baseURL, zoneID)
 	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
 		return "", fmt.Errorf("create request: %w", err)
 	}
 	req.Header.Set("User-Agent", "Mozilla/5.0")
-	req.Header.Set("Referer", indexCGI)
-	req.Header.Set("Origin", baseURL)
+	req.Header.Set("Referer", c.indexCGI())
+	req.Header.Set("Origin", c.baseURL)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -490,13 +493,13 @@ func (c *Client) postCreateRecord(ctx context.Context, zoneID string, record Rec
 		data.Set("Priority", "")
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", indexCGI, strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.indexCGI(), strings.NewReader(data.Encode()))
 	if err != nil {
 		return "", fmt.Errorf("create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("User-Agent", "Mozilla/5.0")
-	req.Header.Set("Referer", fmt.Sprintf("%s/?hosted_dns_zoneid=%s&menu=edit_zone&hosted_dns_editzone", baseURL, zoneID))
+	req.Header.Set("Referer", fmt.Sprintf("%s/?hosted_dns_zoneid=%s&menu=edit_zone&hosted_dns_editzone", c.baseURL, zoneID))
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -579,7 +582,7 @@ func (c *Client) postUpdateRecord(ctx context.Context, zoneID string, domainName
 		data.Set("Priority", "-")
 	}
 
-	reqURL := fmt.Sprintf("%s/?hosted_dns_zoneid=%s&menu=edit_zone&hosted_dns_editzone", baseURL, zoneID)
+	reqURL := fmt.Sprintf("%s/?hosted_dns_zoneid=%s&menu=edit_zone&hosted_dns_editzone", c.baseURL, zoneID)
 	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, strings.NewReader(data.Encode()))
 	if err != nil {
 		return "", fmt.Errorf("create request: %w", err)
@@ -649,7 +652,7 @@ func (c *Client) postDeleteRecord(ctx context.Context, zoneID, recordID string)
 	data.Set("hosted_dns_editzone", "1")
 	data.Set("hosted_dns_delrecord", "1")
 
-	req, err := http.NewRequestWithContext(ctx, "POST", indexCGI, strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.indexCGI(), strings.NewReader(data.Encode()))
 	if err != nil {
 		return "", fmt.Errorf("create request: %w", err)
 	}
@@ -14,14 +14,24 @@ import (
 type Provider struct {
 	mu      sync.Mutex
 	clients map[string]*Client // per-account cached clients, keyed by apiKey
+	baseURL string
 }
 
 func New() *Provider {
 	return &Provider{
 		clients: make(map[string]*Client),
+		baseURL: defaultBaseURL,
 	}
 }
 
+// NewWithEndpoint returns a provider that talks to baseURL instead of
+// dns.he.net, e.g. a stand-in server in tests
+func NewWithEndpoint(baseURL string) *Provider {
+	p := New()
+	p.baseURL = strings.TrimSuffix(baseURL, "/")
+	return p
+}
+
 func (p *Provider) Name() string {
 	return "hurricane"
 }
@@ -38,13 +48,38 @@ func (p *Provider) DefaultTTL() int {
 	return 300
 }
 
+func (p *Provider) CredentialFields() []models.CredentialField {
+	return []models.CredentialField{
+		{Name: "username", Label: "Username", Required: true},
+		{Name: "password", Label: "Password", Secret: true, Required: true},
+	}
+}
+
+func (p *Provider) ValidateAPIKey(apiKey string) error {
+	_, _, err := parseAPIKey(apiKey)
+	return err
+}
+
+// Hurricane Electric does not accept TTLs below 300.
+func (p *Provider) MinTTL() int {
+	return 300
+}
+
+func (p *Provider) ApexCNAME() bool {
+	return false
+}
+
 // parseAPIKey splits the API key format "username,password"
 func parseAPIKey(apiKey string) (string, string, error) {
 	parts := strings.Split(apiKey, ",")
 	if len(parts) != 2 {
 		return "", "", fmt.Errorf("invalid API key format, expected: username,password")
 	}
-	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), nil
+	username, password := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	if username == "" || password == "" {
+		return "", "", fmt.Errorf("invalid API key: empty username or password")
+	}
+	return username, password, nil
 }
 
 // getClient returns a cached client for the given apiKey, creating one if needed.
@@ -63,6 +98,7 @@ func (p *Provider) getClient(apiKey string) (*Client, error) {
 	}
 
 	c := NewClient(username, password)
+	c.baseURL = p.baseURL
 	p.clients[apiKey] = c
 	return c, nil
 }
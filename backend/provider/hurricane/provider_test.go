@@ -0,0 +1,161 @@
+package hurricane
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"dns-mng/provider/providertest"
+)
+
+const (
+	testUser     = "he-user"
+	testPassword = "he-password"
+	testZoneID   = "1303724"
+	sessionToken = "he-session"
+)
+
+// fakeHE serves the dns.he.net web interface the client scrapes: a login
+// form, the zone list and the zone editor, with edits posted as forms and
+// failures shown in the dns_err div.
+type fakeHE struct {
+	zone *providertest.Zone
+}
+
+func (f *fakeHE) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.URL.Path == "/" && r.PostForm.Has("email") {
+		if r.PostForm.Get("email") != testUser || r.PostForm.Get("pass") != testPassword {
+			f.loginPage(w, "Incorrect username or password.")
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "CGISESSID", Value: sessionToken, Path: "/"})
+		f.zonesPage(w)
+		return
+	}
+	if cookie, err := r.Cookie("CGISESSID"); err != nil || cookie.Value != sessionToken {
+		f.loginPage(w, "")
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/index.cgi" && r.PostForm.Get("hosted_dns_editrecord") == "Submit":
+		f.zonePage(w, f.create(r))
+	case r.URL.Path == "/index.cgi" && r.PostForm.Has("hosted_dns_delrecord"):
+		f.zonePage(w, f.delete(r))
+	case r.URL.Path == "/" && r.PostForm.Get("hosted_dns_editrecord") == "Update":
+		f.zonePage(w, f.update(r))
+	case r.URL.Path == "/" && r.URL.Query().Get("hosted_dns_zoneid") == testZoneID:
+		f.zonePage(w, "")
+	case r.URL.Path == "/" && r.URL.Query().Has("hosted_dns_zoneid"):
+		fmt.Fprint(w, `<html><body><div id="dns_err">Zone not found</div></body></html>`)
+	case r.URL.Path == "/":
+		f.zonesPage(w)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeHE) create(r *http.Request) string {
+	if r.PostForm.Get("hosted_dns_zoneid") != testZoneID {
+		return "Zone not found"
+	}
+	ttl, err := strconv.Atoi(r.PostForm.Get("TTL"))
+	if err != nil || ttl < 300 {
+		return "Invalid TTL"
+	}
+	priority, _ := strconv.Atoi(r.PostForm.Get("Priority"))
+	f.zone.Add(providertest.Record{
+		Name:     f.zone.Relative(r.PostForm.Get("Name")),
+		Type:     r.PostForm.Get("Type"),
+		Content:  r.PostForm.Get("Content"),
+		TTL:      ttl,
+		Priority: priority,
+	})
+	return ""
+}
+
+func (f *fakeHE) update(r *http.Request) string {
+	rec, ok := f.zone.Get(r.PostForm.Get("hosted_dns_recordid"))
+	if !ok {
+		return "Record not found"
+	}
+	ttl, err := strconv.Atoi(r.PostForm.Get("TTL"))
+	if err != nil || ttl < 300 {
+		return "Invalid TTL"
+	}
+	rec.Name = f.zone.Relative(r.PostForm.Get("Name"))
+	rec.Type = r.PostForm.Get("Type")
+	rec.Content = r.PostForm.Get("Content")
+	rec.TTL = ttl
+	rec.Priority, _ = strconv.Atoi(r.PostForm.Get("Priority"))
+	f.zone.Update(rec)
+	return ""
+}
+
+func (f *fakeHE) delete(r *http.Request) string {
+	if !f.zone.Delete(r.PostForm.Get("hosted_dns_recordid")) {
+		return "Record not found"
+	}
+	return ""
+}
+
+func (f *fakeHE) loginPage(w http.ResponseWriter, msg string) {
+	fmt.Fprintf(w, `<html><body><div id="dns_err">%s</div>
+<form name="login" method="post" action="/"><input name="email"><input name="pass" type="password"></form>
+</body></html>`, html.EscapeString(msg))
+}
+
+func (f *fakeHE) zonesPage(w http.ResponseWriter) {
+	fmt.Fprintf(w, `<html><body><table id="domains_table"><tbody>
+<tr><td><img alt="edit" onclick="javascript:document.location.href='?hosted_dns_zoneid=%s&menu=edit_zone&hosted_dns_editzone'" name="%s"></td><td>%s</td></tr>
+</tbody></table></body></html>`, testZoneID, f.zone.Name, f.zone.Name)
+}
+
+func (f *fakeHE) zonePage(w http.ResponseWriter, errMsg string) {
+	var b strings.Builder
+	b.WriteString("<html><body>")
+	if errMsg != "" {
+		fmt.Fprintf(&b, `<div id="dns_err">%s</div>`, html.EscapeString(errMsg))
+	}
+	b.WriteString(`<table><tbody>`)
+	for _, rec := range f.zone.Records() {
+		priority := "-"
+		if rec.Priority > 0 {
+			priority = strconv.Itoa(rec.Priority)
+		}
+		content := html.EscapeString(rec.Content)
+		fmt.Fprintf(&b, `<tr class="dns_tr" id="%s">
+<td class="hidden">%s</td><td class="hidden">%s</td>
+<td width="95%%" class="dns_view">%s</td>
+<td align="center"><span class="rrlabel %s" data="%s">%s</span></td>
+<td align="left">%d</td>
+<td align="center">%s</td>
+<td align="left" data="%s">%s</td>
+</tr>
+`, rec.ID, testZoneID, rec.ID, f.zone.FQDN(rec.Name), rec.Type, rec.Type, rec.Type, rec.TTL, priority, content, content)
+	}
+	b.WriteString("</tbody></table></body></html>")
+	fmt.Fprint(w, b.String())
+}
+
+func TestConformance(t *testing.T) {
+	server := httptest.NewServer(&fakeHE{zone: providertest.NewZone("example.com")})
+	defer server.Close()
+
+	providertest.Run(t, NewWithEndpoint(server.URL), providertest.Config{
+		APIKey:    testUser + "," + testPassword,
+		BadAPIKey: testUser + ",wrong-password",
+		AuthError: "invalid credentials",
+		Zone:      "example.com",
+	})
+}
@@ -12,14 +12,11 @@ import (
 	"time"
 )
 
-const baseURL = "https://ipv64.net/api.php"
-
-// API Limit: Maximum 5 API requests within 10 seconds
-const rateLimitInterval = 2 * time.Second // Minimum interval between requests
+const defaultBaseURL = "https://ipv64.net/api.php"
 
 type Client struct {
 	httpClient *http.Client
-	lastRequestTime time.Time
+	baseURL    string
 }
 
 func NewClient() *Client {
@@ -27,18 +24,10 @@ func NewClient() *Client {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		baseURL: defaultBaseURL,
 	}
 }
 
-// checkRateLimit ensures we don't exceed rate limits (5 requests per 10 seconds)
-func (c *Client) checkRateLimit() {
-	elapsed := time.Since(c.lastRequestTime)
-	if elapsed < rateLimitInterval {
-		time.Sleep(rateLimitInterval - elapsed)
-	}
-	c.lastRequestTime = time.Now()
-}
-
 // parseAPIResponse parses and validates the API response
 func parseAPIResponse(body []byte, statusCode int) (*APIResponse, error) {
 	// First try to parse as standard API response
@@ -75,9 +64,7 @@ func parseAPIResponse(body []byte, statusCode int) (*APIResponse, error) {
 
 // GetDomains gets all domains and their records
 func (c *Client) GetDomains(ctx context.Context, apiKey string) (*GetDomainsResponse, error) {
-	c.checkRateLimit()
-
-	req, err := http.NewRequestWithContext(ctx, "GET", baseURL+"?get_domains", nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"?get_domains", nil)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
@@ -118,12 +105,10 @@ func (c *Client) GetDomains(ctx context.Context, apiKey string) (*GetDomainsResp
 
 // AddDomain creates a new domain
 func (c *Client) AddDomain(ctx context.Context, apiKey, domain string) error {
-	c.checkRateLimit()
-
 	data := url.Values{}
 	data.Set("add_domain", domain)
 
-	req, err := http.NewRequestWithContext(ctx, "POST", baseURL, strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL, strings.NewReader(data.Encode()))
 	if err != nil {
 		return fmt.Errorf("create request: %w", err)
 	}
@@ -152,12 +137,10 @@ func (c *Client) AddDomain(ctx context.Context, apiKey, domain string) error {
 
 // DeleteDomain deletes a domain
 func (c *Client) DeleteDomain(ctx context.Context, apiKey, domain string) error {
-	c.checkRateLimit()
-
 	data := url.Values{}
 	data.Set("del_domain", domain)
 
-	req, err := http.NewRequestWithContext(ctx, "DELETE", baseURL, strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "DELETE", c.baseURL, strings.NewReader(data.Encode()))
 	if err != nil {
 		return fmt.Errorf("create request: %w", err)
 	}
@@ -186,15 +169,13 @@ func (c *Client) DeleteDomain(ctx context.Context, apiKey, domain string) error
 
 // AddRecord creates a new DNS record
 func (c *Client) AddRecord(ctx context.Context, apiKey, domain, praefix, recordType, content string) error {
-	c.checkRateLimit()
-
 	data := url.Values{}
 	data.Set("add_record", domain)
 	data.Set("praefix", praefix)
 	data.Set("type", recordType)
 	data.Set("content", content)
 
-	req, err := http.NewRequestWithContext(ctx, "POST", baseURL, strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL, strings.NewReader(data.Encode()))
 	if err != nil {
 		return fmt.Errorf("create request: %w", err)
 	}
@@ -224,12 +205,10 @@ func (c *Client) AddRecord(ctx context.Context, apiKey, domain, praefix, recordT
 // DeleteRecord deletes a DNS record by ID
 // According to API docs: [DELETE] del_record => DNS Record ID [Integer Format]
 func (c *Client) DeleteRecordByID(ctx context.Context, apiKey string, recordID int) error {
-	c.checkRateLimit()
-
 	data := url.Values{}
 	data.Set("del_record", strconv.Itoa(recordID))
 
-	req, err := http.NewRequestWithContext(ctx, "DELETE", baseURL, strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "DELETE", c.baseURL, strings.NewReader(data.Encode()))
 	if err != nil {
 		return fmt.Errorf("create request: %w", err)
 	}
@@ -259,15 +238,13 @@ func (c *Client) DeleteRecordByID(ctx context.Context, apiKey string, recordID i
 // DeleteRecord deletes a DNS record by domain, praefix, type, and content
 // According to API docs: [DELETE] del_record => Domainname, praefix, type, content
 func (c *Client) DeleteRecord(ctx context.Context, apiKey, domain, praefix, recordType, content string) error {
-	c.checkRateLimit()
-
 	data := url.Values{}
 	data.Set("del_record", domain)
 	data.Set("praefix", praefix)
 	data.Set("type", recordType)
 	data.Set("content", content)
 
-	req, err := http.NewRequestWithContext(ctx, "DELETE", baseURL, strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "DELETE", c.baseURL, strings.NewReader(data.Encode()))
 	if err != nil {
 		return fmt.Errorf("create request: %w", err)
 	}
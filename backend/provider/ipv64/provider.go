@@ -5,35 +5,25 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"dns-mng/models"
 )
 
 type Provider struct {
-	mu      sync.Mutex
-	clients map[string]*Client // per-account clients, keyed by apiKey
+	client *Client
 }
 
 func New() *Provider {
-	return &Provider{
-		clients: make(map[string]*Client),
-	}
+	return &Provider{client: NewClient()}
 }
 
-// getClient returns a client for the given apiKey, creating one if needed.
-// Each account gets its own rate limiter so they don't block each other.
-func (p *Provider) getClient(apiKey string) *Client {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-
-	if c, ok := p.clients[apiKey]; ok {
-		return c
-	}
-	c := NewClient()
-	p.clients[apiKey] = c
-	return c
+// NewWithEndpoint returns a provider that talks to baseURL (the api.php
+// script) instead of ipv64.net, e.g. a stand-in server in tests
+func NewWithEndpoint(baseURL string) *Provider {
+	p := New()
+	p.client.baseURL = baseURL
+	return p
 }
 
 func (p *Provider) Name() string {
@@ -52,6 +42,19 @@ func (p *Provider) DefaultTTL() int {
 	return 60
 }
 
+// IPv64 allows 5 requests per 10 seconds. Creates and updates make two
+// requests (write, then re-read or re-create), so one call every 5 seconds
+// stays within the limit.
+func (p *Provider) CallRate() (float64, int) {
+	return 0.2, 1
+}
+
+func (p *Provider) CredentialFields() []models.CredentialField {
+	return []models.CredentialField{
+		{Name: "api_token", Label: "API Token", Secret: true, Required: true},
+	}
+}
+
 // parseTime converts IPv64 time format to RFC3339
 // IPv64 format: "2026-04-13 04:16:29"
 // RFC3339 format: "2006-01-02T15:04:05Z07:00"
@@ -71,7 +74,7 @@ func parseTime(ipv64Time string) string {
 }
 
 func (p *Provider) ListDomains(ctx context.Context, apiKey string) ([]models.Domain, error) {
-	client := p.getClient(apiKey)
+	client := p.client
 	resp, err := client.GetDomains(ctx, apiKey)
 	if err != nil {
 		return nil, err
@@ -111,7 +114,7 @@ func (p *Provider) ListDomains(ctx context.Context, apiKey string) ([]models.Dom
 }
 
 func (p *Provider) GetDomain(ctx context.Context, apiKey string, domainID string) (*models.Domain, error) {
-	client := p.getClient(apiKey)
+	client := p.client
 	resp, err := client.GetDomains(ctx, apiKey)
 	if err != nil {
 		return nil, err
@@ -133,7 +136,7 @@ func (p *Provider) GetDomain(ctx context.Context, apiKey string, domainID string
 }
 
 func (p *Provider) ListRecords(ctx context.Context, apiKey, domainID string) ([]models.Record, error) {
-	client := p.getClient(apiKey)
+	client := p.client
 	resp, err := client.GetDomains(ctx, apiKey)
 	if err != nil {
 		return nil, err
@@ -175,7 +178,7 @@ func (p *Provider) CreateRecord(ctx context.Context, apiKey, domainID string, re
 		praefix = ""
 	}
 
-	client := p.getClient(apiKey)
+	client := p.client
 	err := client.AddRecord(ctx, apiKey, domainID, praefix, record.RecordType, record.Content)
 	if err != nil {
 		return nil, err
@@ -237,7 +240,7 @@ func (p *Provider) UpdateRecord(ctx context.Context, apiKey, domainID string, re
 		return nil, fmt.Errorf("invalid record ID: %s", record.ID)
 	}
 
-	client := p.getClient(apiKey)
+	client := p.client
 	// Delete the old record
 	err = client.DeleteRecordByID(ctx, apiKey, recordID)
 	if err != nil {
@@ -259,7 +262,7 @@ func (p *Provider) UpdateRecord(ctx context.Context, apiKey, domainID string, re
 }
 
 func (p *Provider) DeleteRecord(ctx context.Context, apiKey, domainID, recordID string) error {
-	client := p.getClient(apiKey)
+	client := p.client
 	// Try to parse as integer ID first
 	id, err := strconv.Atoi(recordID)
 	if err == nil {
@@ -274,3 +277,25 @@ func (p *Provider) DeleteRecord(ctx context.Context, apiKey, domainID, recordID
 
 	return fmt.Errorf("invalid record ID format: %s", recordID)
 }
+
+// CreateZone implements provider.ZoneManager. IPv64 identifies domains by
+// name, so the returned domain uses the name as its ID.
+func (p *Provider) CreateZone(ctx context.Context, apiKey string, name string) (*models.Domain, error) {
+	client := p.client
+	if err := client.AddDomain(ctx, apiKey, name); err != nil {
+		return nil, err
+	}
+
+	return &models.Domain{
+		ID:        name,
+		Name:      name,
+		State:     "Active",
+		UpdatedOn: time.Now().Format(time.RFC3339),
+	}, nil
+}
+
+// DeleteZone implements provider.ZoneManager.
+func (p *Provider) DeleteZone(ctx context.Context, apiKey string, domainID string) error {
+	client := p.client
+	return client.DeleteDomain(ctx, apiKey, domainID)
+}
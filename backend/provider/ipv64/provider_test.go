@@ -0,0 +1,106 @@
+package ipv64
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"dns-mng/provider/providertest"
+)
+
+const (
+	testAPIKey = "ipv64-test-key"
+	// IPv64 has no per-record TTL; every record is served with this one
+	platformTTL = 60
+)
+
+// fakeIPv64 serves api.php. Reads are GET ?get_domains; writes are
+// form-encoded POST and DELETE bodies, the record prefix is "praefix".
+type fakeIPv64 struct {
+	zone *providertest.Zone
+}
+
+func (f *fakeIPv64) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+testAPIKey {
+		providertest.WriteJSON(w, http.StatusUnauthorized, APIResponse{Info: "Unauthorized", Status: "401 Unauthorized"})
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		if _, ok := r.URL.Query()["get_domains"]; !ok {
+			providertest.WriteJSON(w, http.StatusBadRequest, APIResponse{Info: "unknown call", Status: "400 Bad Request"})
+			return
+		}
+		f.domains(w)
+		return
+	}
+
+	// net/http only parses POST bodies, and deletes carry a form too
+	body, _ := io.ReadAll(r.Body)
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		providertest.WriteJSON(w, http.StatusBadRequest, APIResponse{Info: err.Error(), Status: "400 Bad Request"})
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && form.Has("add_record"):
+		if form.Get("add_record") != f.zone.Name {
+			providertest.WriteJSON(w, http.StatusForbidden, APIResponse{Info: "domain not found", Status: "403 Forbidden"})
+			return
+		}
+		f.zone.Add(providertest.Record{
+			Name:    form.Get("praefix"),
+			Type:    form.Get("type"),
+			Content: form.Get("content"),
+			TTL:     platformTTL,
+		})
+		providertest.WriteJSON(w, http.StatusCreated, APIResponse{Info: "success", Status: "201 Created", AddRecord: "record added"})
+	case r.Method == http.MethodDelete && form.Has("del_record"):
+		if _, err := strconv.Atoi(form.Get("del_record")); err != nil || !f.zone.Delete(form.Get("del_record")) {
+			providertest.WriteJSON(w, http.StatusBadRequest, APIResponse{Info: "record not found", Status: "400 Bad Request"})
+			return
+		}
+		providertest.WriteJSON(w, http.StatusAccepted, APIResponse{Info: "success", Status: "202 Accepted", DelRecord: "record deleted"})
+	default:
+		providertest.WriteJSON(w, http.StatusBadRequest, APIResponse{Info: "unknown call", Status: "400 Bad Request"})
+	}
+}
+
+func (f *fakeIPv64) domains(w http.ResponseWriter) {
+	records := []Record{}
+	for _, rec := range f.zone.Records() {
+		id, _ := strconv.Atoi(rec.ID)
+		records = append(records, Record{
+			RecordID:   id,
+			Praefix:    rec.Name,
+			Type:       rec.Type,
+			Content:    rec.Content,
+			TTL:        rec.TTL,
+			LastUpdate: "2026-01-01 00:00:00",
+		})
+	}
+	providertest.WriteJSON(w, http.StatusOK, GetDomainsResponse{
+		Subdomains: map[string]DomainInfo{f.zone.Name: {Records: records}},
+		Info:       "success",
+		Status:     "200 OK",
+	})
+}
+
+func TestConformance(t *testing.T) {
+	server := httptest.NewServer(&fakeIPv64{zone: providertest.NewZone("example.any64.de")})
+	defer server.Close()
+
+	p := NewWithEndpoint(server.URL + "/api.php")
+
+	providertest.Run(t, p, providertest.Config{
+		APIKey:    testAPIKey,
+		BadAPIKey: "wrong-key",
+		AuthError: "unauthorized (401)",
+		Zone:      "example.any64.de",
+		FixedTTL:  true,
+	})
+}
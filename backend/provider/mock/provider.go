@@ -0,0 +1,337 @@
+// Package mock is an in-memory DNS provider for demos, integration tests and
+// trying the app without real credentials. Every API key is its own sandbox
+// account, seeded with example zones on first use; state is lost on restart.
+package mock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"dns-mng/models"
+)
+
+const (
+	defaultTTL = 300
+	minTTL     = 60
+)
+
+// DefaultZones seed every new sandbox account unless Options.Zones is set.
+var DefaultZones = []string{"example.com", "example.org"}
+
+var (
+	// ErrUnauthorized is returned for an empty API key or one starting with
+	// "invalid", so rejected credentials can be tried out too
+	ErrUnauthorized = errors.New("mock: invalid API key")
+	// ErrInjected is returned by calls failed through Options.FailureRate
+	ErrInjected = errors.New("mock: injected failure")
+)
+
+// Options tunes the provider
+type Options struct {
+	// Latency is added to every call
+	Latency time.Duration
+	// FailureRate is the share of calls, from 0 to 1, that fail with
+	// ErrInjected
+	FailureRate float64
+	// Zones seed every new sandbox account; nil means DefaultZones
+	Zones []string
+}
+
+// Provider implements DNSProvider on top of in-memory zones.
+type Provider struct {
+	opts Options
+
+	mu       sync.Mutex
+	accounts map[string]*account
+	nextID   int64
+	// failNext holds errors the next calls return, see FailNext
+	failNext []error
+}
+
+func New(opts Options) *Provider {
+	if opts.Zones == nil {
+		opts.Zones = DefaultZones
+	}
+	zones := make([]string, 0, len(opts.Zones))
+	for _, z := range opts.Zones {
+		if z = normalizeZone(z); z != "" {
+			zones = append(zones, z)
+		}
+	}
+	opts.Zones = zones
+	return &Provider{
+		opts:     opts,
+		accounts: map[string]*account{},
+	}
+}
+
+// FailNext makes the next call, whatever it is, return err. Queued errors
+// are returned in order, one per call.
+func (p *Provider) FailNext(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failNext = append(p.failNext, err)
+}
+
+// Reset drops all sandbox accounts, so the next call reseeds them
+func (p *Provider) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.accounts = map[string]*account{}
+	p.failNext = nil
+}
+
+func (p *Provider) Name() string {
+	return "mock"
+}
+
+func (p *Provider) DisplayName() string {
+	return "Mock (in-memory sandbox)"
+}
+
+func (p *Provider) WebsiteURL() string {
+	return ""
+}
+
+func (p *Provider) DefaultTTL() int {
+	return defaultTTL
+}
+
+func (p *Provider) CredentialFields() []models.CredentialField {
+	return []models.CredentialField{
+		{Name: "api_key", Label: "Sandbox Name", Required: true, Help: `Any name; names starting with "invalid" are rejected`},
+	}
+}
+
+func (p *Provider) MinTTL() int {
+	return minTTL
+}
+
+func (p *Provider) ApexCNAME() bool {
+	return false
+}
+
+// begin simulates the network part of a call: latency, then injected and
+// authentication failures. On success it returns with p.mu held and the
+// caller's account.
+func (p *Provider) begin(ctx context.Context, apiKey string) (*account, error) {
+	if p.opts.Latency > 0 {
+		timer := time.NewTimer(p.opts.Latency)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	if len(p.failNext) > 0 {
+		err := p.failNext[0]
+		p.failNext = p.failNext[1:]
+		p.mu.Unlock()
+		return nil, err
+	}
+	if p.opts.FailureRate > 0 && rand.Float64() < p.opts.FailureRate {
+		p.mu.Unlock()
+		return nil, ErrInjected
+	}
+	if key := strings.TrimSpace(apiKey); key == "" || strings.HasPrefix(key, "invalid") {
+		p.mu.Unlock()
+		return nil, ErrUnauthorized
+	}
+	return p.account(apiKey), nil
+}
+
+func (p *Provider) ListDomains(ctx context.Context, apiKey string) ([]models.Domain, error) {
+	acc, err := p.begin(ctx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	defer p.mu.Unlock()
+
+	domains := make([]models.Domain, 0, len(acc.zones))
+	for _, z := range acc.zones {
+		domains = append(domains, z.domain())
+	}
+	sort.Slice(domains, func(i, j int) bool { return domains[i].Name < domains[j].Name })
+	return domains, nil
+}
+
+func (p *Provider) GetDomain(ctx context.Context, apiKey string, domainID string) (*models.Domain, error) {
+	acc, err := p.begin(ctx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	defer p.mu.Unlock()
+
+	z, err := acc.zone(domainID)
+	if err != nil {
+		return nil, err
+	}
+	d := z.domain()
+	return &d, nil
+}
+
+func (p *Provider) ListRecords(ctx context.Context, apiKey string, domainID string) ([]models.Record, error) {
+	acc, err := p.begin(ctx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	defer p.mu.Unlock()
+
+	z, err := acc.zone(domainID)
+	if err != nil {
+		return nil, err
+	}
+	return z.sorted(), nil
+}
+
+func (p *Provider) CreateRecord(ctx context.Context, apiKey string, domainID string, record *models.Record) (*models.Record, error) {
+	acc, err := p.begin(ctx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	defer p.mu.Unlock()
+
+	z, err := acc.zone(domainID)
+	if err != nil {
+		return nil, err
+	}
+	r, err := validate(z, *record)
+	if err != nil {
+		return nil, err
+	}
+	created := p.addRecord(z, r)
+	return &created, nil
+}
+
+func (p *Provider) UpdateRecord(ctx context.Context, apiKey string, domainID string, record *models.Record) (*models.Record, error) {
+	acc, err := p.begin(ctx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	defer p.mu.Unlock()
+
+	z, err := acc.zone(domainID)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := z.records[record.ID]; !ok {
+		return nil, fmt.Errorf("mock: record not found: %s", record.ID)
+	}
+	r, err := validate(z, *record)
+	if err != nil {
+		return nil, err
+	}
+	r.ID = record.ID
+	r.DomainID = z.name
+	r.DomainName = z.name
+	r.UpdatedOn = time.Now().Format(time.RFC3339)
+	z.records[r.ID] = r
+	return &r, nil
+}
+
+func (p *Provider) DeleteRecord(ctx context.Context, apiKey string, domainID string, recordID string) error {
+	acc, err := p.begin(ctx, apiKey)
+	if err != nil {
+		return err
+	}
+	defer p.mu.Unlock()
+
+	z, err := acc.zone(domainID)
+	if err != nil {
+		return err
+	}
+	if _, ok := z.records[recordID]; !ok {
+		return fmt.Errorf("mock: record not found: %s", recordID)
+	}
+	delete(z.records, recordID)
+	return nil
+}
+
+// CreateZone adds an empty zone to the sandbox account
+func (p *Provider) CreateZone(ctx context.Context, apiKey string, name string) (*models.Domain, error) {
+	acc, err := p.begin(ctx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	defer p.mu.Unlock()
+
+	name = normalizeZone(name)
+	if !strings.Contains(name, ".") {
+		return nil, fmt.Errorf("mock: invalid zone name: %q", name)
+	}
+	if _, ok := acc.zones[name]; ok {
+		return nil, fmt.Errorf("mock: zone already exists: %s", name)
+	}
+	z := p.newZone(name)
+	acc.zones[name] = z
+	d := z.domain()
+	return &d, nil
+}
+
+func (p *Provider) DeleteZone(ctx context.Context, apiKey string, domainID string) error {
+	acc, err := p.begin(ctx, apiKey)
+	if err != nil {
+		return err
+	}
+	defer p.mu.Unlock()
+
+	z, err := acc.zone(domainID)
+	if err != nil {
+		return err
+	}
+	delete(acc.zones, z.name)
+	return nil
+}
+
+func (p *Provider) GetNameservers(ctx context.Context, apiKey string, domainID string) ([]string, error) {
+	acc, err := p.begin(ctx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	defer p.mu.Unlock()
+
+	if _, err := acc.zone(domainID); err != nil {
+		return nil, err
+	}
+	return []string{"ns1.mock.invalid", "ns2.mock.invalid"}, nil
+}
+
+// validate checks a record the way a strict platform would and returns it
+// normalised: apex as "", upper-case type, the default TTL for 0
+func validate(z *zone, r models.Record) (models.Record, error) {
+	r.NodeName = normalizeNode(r.NodeName, z.name)
+	r.RecordType = strings.ToUpper(strings.TrimSpace(r.RecordType))
+	r.Content = strings.TrimSpace(r.Content)
+	if r.RecordType == "" {
+		return r, fmt.Errorf("mock: record type is required")
+	}
+	if r.Content == "" {
+		return r, fmt.Errorf("mock: record content is required")
+	}
+	if r.TTL == 0 {
+		r.TTL = defaultTTL
+	}
+	if r.TTL < minTTL {
+		return r, fmt.Errorf("mock: TTL %d is below the minimum of %d", r.TTL, minTTL)
+	}
+	if r.RecordType == "CNAME" && r.NodeName == "" {
+		return r, fmt.Errorf("mock: CNAME is not allowed at the zone apex")
+	}
+	if r.RecordType != "MX" && r.RecordType != "SRV" {
+		r.Priority = 0
+	}
+	r.Raw = nil
+	return r, nil
+}
@@ -0,0 +1,92 @@
+package mock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"dns-mng/models"
+	"dns-mng/provider/providertest"
+)
+
+func TestConformance(t *testing.T) {
+	providertest.Run(t, New(Options{}), providertest.Config{
+		APIKey:    "demo",
+		BadAPIKey: "invalid-demo",
+		AuthError: ErrUnauthorized.Error(),
+		Zone:      "example.com",
+	})
+}
+
+func TestAccountsAreIsolated(t *testing.T) {
+	ctx := context.Background()
+	p := New(Options{Zones: []string{"example.com"}})
+
+	if _, err := p.CreateRecord(ctx, "alice", "example.com", &models.Record{
+		NodeName: "only-alice", RecordType: "A", Content: "192.0.2.1", State: true,
+	}); err != nil {
+		t.Fatalf("CreateRecord: %v", err)
+	}
+
+	alice, err := p.ListRecords(ctx, "alice", "example.com")
+	if err != nil {
+		t.Fatalf("ListRecords(alice): %v", err)
+	}
+	bob, err := p.ListRecords(ctx, "bob", "example.com")
+	if err != nil {
+		t.Fatalf("ListRecords(bob): %v", err)
+	}
+	if len(alice) != len(bob)+1 {
+		t.Errorf("alice has %d records and bob %d, want bob unaffected by alice's write", len(alice), len(bob))
+	}
+}
+
+func TestInjectedFailures(t *testing.T) {
+	ctx := context.Background()
+
+	p := New(Options{})
+	want := errors.New("rate limited (429)")
+	p.FailNext(want)
+	if _, err := p.ListDomains(ctx, "demo"); !errors.Is(err, want) {
+		t.Errorf("ListDomains after FailNext: err = %v, want %v", err, want)
+	}
+	if _, err := p.ListDomains(ctx, "demo"); err != nil {
+		t.Errorf("ListDomains after the queued failure: %v", err)
+	}
+
+	p = New(Options{FailureRate: 1})
+	if _, err := p.ListDomains(ctx, "demo"); !errors.Is(err, ErrInjected) {
+		t.Errorf("ListDomains with FailureRate 1: err = %v, want ErrInjected", err)
+	}
+}
+
+func TestLatencyHonoursContext(t *testing.T) {
+	p := New(Options{Latency: time.Minute})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := p.ListDomains(ctx, "demo"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ListDomains: err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("ListDomains took %s despite the cancelled context", elapsed)
+	}
+}
+
+func TestRejectsInvalidRecords(t *testing.T) {
+	ctx := context.Background()
+	p := New(Options{})
+
+	for _, r := range []models.Record{
+		{NodeName: "low", RecordType: "A", Content: "192.0.2.1", TTL: 30},
+		{NodeName: "@", RecordType: "CNAME", Content: "example.net"},
+		{NodeName: "empty", RecordType: "TXT"},
+	} {
+		r := r
+		if _, err := p.CreateRecord(ctx, "demo", "example.com", &r); err == nil {
+			t.Errorf("CreateRecord(%+v) succeeded, want an error", r)
+		}
+	}
+}
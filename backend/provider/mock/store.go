@@ -0,0 +1,118 @@
+package mock
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"dns-mng/models"
+)
+
+// account is the sandbox behind one API key
+type account struct {
+	zones map[string]*zone
+}
+
+type zone struct {
+	name      string
+	createdOn string
+	records   map[string]models.Record
+}
+
+// seedRecords gives a fresh zone something to look at in the UI
+func seedRecords(name string) []models.Record {
+	return []models.Record{
+		{NodeName: "", RecordType: "A", Content: "192.0.2.10"},
+		{NodeName: "www", RecordType: "CNAME", Content: name},
+		{NodeName: "", RecordType: "MX", Content: "mail." + name, Priority: 10},
+		{NodeName: "", RecordType: "TXT", Content: "v=spf1 -all"},
+	}
+}
+
+// account returns the sandbox for apiKey, creating and seeding it on first
+// use. The caller holds p.mu.
+func (p *Provider) account(apiKey string) *account {
+	acc, ok := p.accounts[apiKey]
+	if !ok {
+		acc = &account{zones: map[string]*zone{}}
+		for _, name := range p.opts.Zones {
+			z := p.newZone(name)
+			for _, r := range seedRecords(name) {
+				r.TTL = defaultTTL
+				r.State = true
+				p.addRecord(z, r)
+			}
+			acc.zones[z.name] = z
+		}
+		p.accounts[apiKey] = acc
+	}
+	return acc
+}
+
+func (p *Provider) newZone(name string) *zone {
+	return &zone{
+		name:      name,
+		createdOn: time.Now().Format(time.RFC3339),
+		records:   map[string]models.Record{},
+	}
+}
+
+// addRecord stores r under a new ID. The caller holds p.mu.
+func (p *Provider) addRecord(z *zone, r models.Record) models.Record {
+	p.nextID++
+	r.ID = strconv.FormatInt(p.nextID, 10)
+	r.DomainID = z.name
+	r.DomainName = z.name
+	r.UpdatedOn = time.Now().Format(time.RFC3339)
+	z.records[r.ID] = r
+	return r
+}
+
+func (acc *account) zone(domainID string) (*zone, error) {
+	z, ok := acc.zones[normalizeZone(domainID)]
+	if !ok {
+		return nil, fmt.Errorf("mock: domain not found: %s", domainID)
+	}
+	return z, nil
+}
+
+func (z *zone) domain() models.Domain {
+	return models.Domain{
+		ID:          z.name,
+		Name:        z.name,
+		UnicodeName: z.name,
+		State:       "Active",
+		TTL:         defaultTTL,
+		CreatedOn:   z.createdOn,
+	}
+}
+
+// sorted returns the zone's records in creation order
+func (z *zone) sorted() []models.Record {
+	out := make([]models.Record, 0, len(z.records))
+	for _, r := range z.records {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, _ := strconv.ParseInt(out[i].ID, 10, 64)
+		b, _ := strconv.ParseInt(out[j].ID, 10, 64)
+		return a < b
+	})
+	return out
+}
+
+func normalizeZone(name string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+}
+
+// normalizeNode maps the apex ("@" or a fully qualified zone name) to ""
+// and strips the zone suffix from fully qualified names
+func normalizeNode(node, zoneName string) string {
+	node = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(node), "."))
+	if node == "@" || node == zoneName {
+		return ""
+	}
+	return strings.TrimSuffix(node, "."+zoneName)
+}
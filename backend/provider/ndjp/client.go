@@ -11,10 +11,11 @@ import (
 	"time"
 )
 
-const baseURL = "https://manage.ndjp.net/api"
+const defaultBaseURL = "https://manage.ndjp.net/api"
 
 type Client struct {
 	httpClient *http.Client
+	baseURL    string
 }
 
 func NewClient() *Client {
@@ -22,11 +23,12 @@ func NewClient() *Client {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		baseURL: defaultBaseURL,
 	}
 }
 
 func (c *Client) doRequest(ctx context.Context, apiToken, method, path string, body io.Reader) (*http.Response, error) {
-	req, err := http.NewRequestWithContext(ctx, method, baseURL+path, body)
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
@@ -24,6 +24,14 @@ func New() *Provider {
 	return NewProvider()
 }
 
+// NewWithEndpoint returns a provider that talks to baseURL instead of
+// manage.ndjp.net, e.g. a stand-in server in tests
+func NewWithEndpoint(baseURL string) *Provider {
+	p := NewProvider()
+	p.client.baseURL = strings.TrimSuffix(baseURL, "/")
+	return p
+}
+
 func (p *Provider) Name() string {
 	return "ndjp"
 }
@@ -40,6 +48,17 @@ func (p *Provider) DefaultTTL() int {
 	return 300
 }
 
+func (p *Provider) CredentialFields() []models.CredentialField {
+	return []models.CredentialField{
+		{Name: "token", Label: "Bearer Token", Secret: true, Required: true},
+	}
+}
+
+// NDJP allows 10 requests per minute.
+func (p *Provider) CallRate() (float64, int) {
+	return 10.0 / 60, 5
+}
+
 func (p *Provider) ListDomains(ctx context.Context, apiKey string) ([]models.Domain, error) {
 	resp, err := p.client.ListDomains(ctx, apiKey)
 	if err != nil {
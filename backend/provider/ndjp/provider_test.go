@@ -0,0 +1,107 @@
+package ndjp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"dns-mng/provider/providertest"
+)
+
+const testToken = "ndjp-test-token"
+
+// fakeNDJP serves the NDJP subdomain API. Records are PowerDNS-style
+// RRsets keyed by fully qualified name.
+type fakeNDJP struct {
+	subdomain string
+	zone      *providertest.Zone
+}
+
+func (f *fakeNDJP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+testToken {
+		providertest.WriteJSON(w, http.StatusUnauthorized, ErrorResponse{Status: "error", Message: "Invalid API token"})
+		return
+	}
+
+	if r.URL.Path == "/domains" && r.Method == http.MethodGet {
+		providertest.WriteJSON(w, http.StatusOK, DomainsResponse{Status: "success", Data: []string{f.subdomain}})
+		return
+	}
+	if r.URL.Path != "/domains/"+f.subdomain+"/records" {
+		providertest.WriteJSON(w, http.StatusNotFound, ErrorResponse{Status: "error", Message: "Domain not found"})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		f.list(w)
+	case http.MethodPost, http.MethodPut:
+		var rec Record
+		if err := json.NewDecoder(r.Body).Decode(&rec); err != nil {
+			providertest.WriteJSON(w, http.StatusBadRequest, ErrorResponse{Status: "error", Message: err.Error()})
+			return
+		}
+		name := f.zone.Relative(rec.Name)
+		if r.Method == http.MethodPut {
+			// PUT replaces the whole RRset
+			for _, old := range f.zone.Lookup(name, rec.Type) {
+				f.zone.Delete(old.ID)
+			}
+		}
+		f.zone.Add(providertest.Record{Name: name, Type: rec.Type, Content: rec.Content, TTL: rec.TTL})
+		providertest.WriteJSON(w, http.StatusOK, APIResponse{Status: "success", Message: "Record saved"})
+	case http.MethodDelete:
+		q := r.URL.Query()
+		deleted := 0
+		for _, rec := range f.zone.Lookup(f.zone.Relative(q.Get("name")), q.Get("type")) {
+			if q.Get("content") == "" || q.Get("content") == rec.Content {
+				f.zone.Delete(rec.ID)
+				deleted++
+			}
+		}
+		if deleted == 0 {
+			providertest.WriteJSON(w, http.StatusNotFound, ErrorResponse{Status: "error", Message: "Record not found"})
+			return
+		}
+		providertest.WriteJSON(w, http.StatusOK, APIResponse{Status: "success", Message: "Record deleted"})
+	default:
+		providertest.WriteJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Status: "error", Message: "Method not allowed"})
+	}
+}
+
+// list answers like NDJP: an RRset array, or a string for an empty zone
+func (f *fakeNDJP) list(w http.ResponseWriter) {
+	var rrsets []RRSet
+	index := map[string]int{}
+	for _, rec := range f.zone.Records() {
+		key := rec.Name + "/" + rec.Type
+		i, ok := index[key]
+		if !ok {
+			i = len(rrsets)
+			index[key] = i
+			rrsets = append(rrsets, RRSet{Name: f.zone.FQDN(rec.Name) + ".", Type: rec.Type, TTL: rec.TTL})
+		}
+		rrsets[i].Records = append(rrsets[i].Records, RRSetRecord{Content: rec.Content})
+	}
+	if len(rrsets) == 0 {
+		providertest.WriteJSON(w, http.StatusOK, APIResponse{Status: "success", Data: "No records"})
+		return
+	}
+	providertest.WriteJSON(w, http.StatusOK, APIResponse{Status: "success", Data: rrsets})
+}
+
+func TestConformance(t *testing.T) {
+	server := httptest.NewServer(&fakeNDJP{
+		subdomain: "example",
+		zone:      providertest.NewZone("example.ndjp.net"),
+	})
+	defer server.Close()
+
+	providertest.Run(t, NewWithEndpoint(server.URL), providertest.Config{
+		APIKey:    testToken,
+		BadAPIKey: "wrong-token",
+		AuthError: "Invalid API token",
+		Zone:      "example.ndjp.net",
+	})
+}
@@ -46,4 +46,10 @@ type ProviderInfo struct {
 	DisplayName string `json:"display_name"`
 	WebsiteURL  string `json:"website_url"`
 	DefaultTTL  int    `json:"default_ttl"`
+	// Capabilities lists the optional interfaces (see capability.go) the
+	// provider implements, so clients can enable features without
+	// hard-coding provider names.
+	Capabilities []Capability `json:"capabilities"`
+	// Credentials are the fields of the account form (see credentials.go)
+	Credentials []models.CredentialField `json:"credentials"`
 }
@@ -0,0 +1,254 @@
+// Package providertest is a conformance suite for provider.DNSProvider
+// implementations. Each provider package runs it against a local httptest
+// stand-in of its platform, so behaviour the rest of the app relies on is
+// checked without real credentials.
+package providertest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"dns-mng/models"
+	"dns-mng/provider"
+)
+
+// Config describes the stand-in a provider is tested against
+type Config struct {
+	// APIKey is accepted by the stand-in; BadAPIKey is refused the way the
+	// platform refuses wrong credentials
+	APIKey    string
+	BadAPIKey string
+	// AuthError is text the provider's error for BadAPIKey must contain,
+	// usually the platform's own message. Empty skips the check.
+	AuthError string
+	// Zone is the name of the one zone the stand-in serves
+	Zone string
+	// FixedTTL is set for platforms without per-record TTLs. Records then
+	// list with DefaultTTL whatever TTL they were written with.
+	FixedTTL bool
+}
+
+// TTLs used for writes. Every supported platform accepts them.
+const (
+	createTTL = 3600
+	updateTTL = 7200
+)
+
+// Run checks p against the stand-in described by cfg: zone listing,
+// create/update/delete round-trips, apex records, the default TTL and
+// error reporting.
+func Run(t *testing.T, p provider.DNSProvider, cfg Config) {
+	t.Helper()
+
+	if p.DefaultTTL() <= 0 {
+		t.Fatalf("DefaultTTL() = %d, want a positive TTL", p.DefaultTTL())
+	}
+
+	var domainID string
+	ok := t.Run("Domains", func(t *testing.T) {
+		ctx := testContext(t)
+		domains, err := p.ListDomains(ctx, cfg.APIKey)
+		if err != nil {
+			t.Fatalf("ListDomains: %v", err)
+		}
+		for _, d := range domains {
+			if sameName(d.Name, cfg.Zone) {
+				domainID = d.ID
+			}
+		}
+		if domainID == "" {
+			t.Fatalf("ListDomains returned %+v, want zone %s", domains, cfg.Zone)
+		}
+
+		d, err := p.GetDomain(ctx, cfg.APIKey, domainID)
+		if err != nil {
+			t.Fatalf("GetDomain(%s): %v", domainID, err)
+		}
+		if !sameName(d.Name, cfg.Zone) {
+			t.Errorf("GetDomain(%s).Name = %q, want %q", domainID, d.Name, cfg.Zone)
+		}
+	})
+	if !ok {
+		return
+	}
+
+	t.Run("RecordLifecycle", func(t *testing.T) {
+		ctx := testContext(t)
+		created := create(ctx, t, p, cfg, domainID, "www", "192.0.2.1", createTTL)
+		if created.ID == "" {
+			t.Errorf("CreateRecord returned a record without an ID")
+		}
+
+		listed := mustFind(ctx, t, p, cfg, domainID, "www", "A", "192.0.2.1")
+		checkTTL(t, p, cfg, listed, createTTL)
+		if !listed.State {
+			t.Errorf("created record is listed as disabled")
+		}
+
+		// Update the way DNSService does: from the listed ID plus the new fields
+		updated, err := p.UpdateRecord(ctx, cfg.APIKey, domainID, &models.Record{
+			ID:         listed.ID,
+			NodeName:   listed.NodeName,
+			RecordType: "A",
+			TTL:        updateTTL,
+			State:      true,
+			Content:    "192.0.2.2",
+		})
+		if err != nil {
+			t.Fatalf("UpdateRecord: %v", err)
+		}
+		if updated == nil {
+			t.Fatalf("UpdateRecord returned no record")
+		}
+		if find(list(ctx, t, p, cfg, domainID), "www", "A", "192.0.2.1") != nil {
+			t.Errorf("old content is still listed after UpdateRecord")
+		}
+		listed = mustFind(ctx, t, p, cfg, domainID, "www", "A", "192.0.2.2")
+		checkTTL(t, p, cfg, listed, updateTTL)
+
+		if err := p.DeleteRecord(ctx, cfg.APIKey, domainID, listed.ID); err != nil {
+			t.Fatalf("DeleteRecord: %v", err)
+		}
+		if find(list(ctx, t, p, cfg, domainID), "www", "A", "192.0.2.2") != nil {
+			t.Errorf("record is still listed after DeleteRecord")
+		}
+	})
+
+	// The UI and the DDNS/ACME services spell the apex both ways
+	for _, apex := range []string{"@", ""} {
+		t.Run("ApexRecord/"+apexLabel(apex), func(t *testing.T) {
+			ctx := testContext(t)
+			create(ctx, t, p, cfg, domainID, apex, "192.0.2.53", createTTL)
+
+			listed := mustFind(ctx, t, p, cfg, domainID, "@", "A", "192.0.2.53")
+			if listed.NodeName != "" && listed.NodeName != "@" {
+				t.Errorf("apex record listed with node name %q, want \"\" or \"@\"", listed.NodeName)
+			}
+
+			if err := p.DeleteRecord(ctx, cfg.APIKey, domainID, listed.ID); err != nil {
+				t.Fatalf("DeleteRecord: %v", err)
+			}
+			if find(list(ctx, t, p, cfg, domainID), "@", "A", "192.0.2.53") != nil {
+				t.Errorf("apex record is still listed after DeleteRecord")
+			}
+		})
+	}
+
+	t.Run("DefaultTTL", func(t *testing.T) {
+		ctx := testContext(t)
+		create(ctx, t, p, cfg, domainID, "ttl", "192.0.2.3", p.DefaultTTL())
+
+		// A record written with the advertised default must read back with
+		// it, or editing the record would silently change its TTL
+		listed := mustFind(ctx, t, p, cfg, domainID, "ttl", "A", "192.0.2.3")
+		if listed.TTL != p.DefaultTTL() {
+			t.Errorf("record created with DefaultTTL %d is listed with TTL %d", p.DefaultTTL(), listed.TTL)
+		}
+
+		if err := p.DeleteRecord(ctx, cfg.APIKey, domainID, listed.ID); err != nil {
+			t.Fatalf("DeleteRecord: %v", err)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		ctx := testContext(t)
+		_, err := p.ListDomains(ctx, cfg.BadAPIKey)
+		if err == nil {
+			t.Errorf("ListDomains with a rejected key succeeded")
+		} else if cfg.AuthError != "" && !strings.Contains(err.Error(), cfg.AuthError) {
+			t.Errorf("ListDomains with a rejected key: error %q does not mention %q", err, cfg.AuthError)
+		}
+
+		if _, err := p.GetDomain(ctx, cfg.APIKey, "missing.invalid"); err == nil {
+			t.Errorf("GetDomain of an unknown zone succeeded")
+		}
+	})
+}
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func create(ctx context.Context, t *testing.T, p provider.DNSProvider, cfg Config, domainID, node, content string, ttl int) *models.Record {
+	t.Helper()
+	created, err := p.CreateRecord(ctx, cfg.APIKey, domainID, &models.Record{
+		NodeName:   node,
+		RecordType: "A",
+		TTL:        ttl,
+		State:      true,
+		Content:    content,
+	})
+	if err != nil {
+		t.Fatalf("CreateRecord(%q A %s): %v", node, content, err)
+	}
+	if created == nil {
+		t.Fatalf("CreateRecord(%q A %s) returned no record", node, content)
+	}
+	return created
+}
+
+func list(ctx context.Context, t *testing.T, p provider.DNSProvider, cfg Config, domainID string) []models.Record {
+	t.Helper()
+	records, err := p.ListRecords(ctx, cfg.APIKey, domainID)
+	if err != nil {
+		t.Fatalf("ListRecords: %v", err)
+	}
+	return records
+}
+
+func mustFind(ctx context.Context, t *testing.T, p provider.DNSProvider, cfg Config, domainID, node, recordType, content string) *models.Record {
+	t.Helper()
+	records := list(ctx, t, p, cfg, domainID)
+	r := find(records, node, recordType, content)
+	if r == nil {
+		t.Fatalf("no %q %s %s in ListRecords: %+v", node, recordType, content, records)
+	}
+	return r
+}
+
+// find looks a record up by owner, type and content, since providers
+// backed by RRsets derive record IDs from them
+func find(records []models.Record, node, recordType, content string) *models.Record {
+	for i, r := range records {
+		if sameNode(r.NodeName, node) && strings.EqualFold(r.RecordType, recordType) && r.Content == content {
+			return &records[i]
+		}
+	}
+	return nil
+}
+
+func checkTTL(t *testing.T, p provider.DNSProvider, cfg Config, r *models.Record, want int) {
+	t.Helper()
+	if cfg.FixedTTL {
+		want = p.DefaultTTL()
+	}
+	if r.TTL != want {
+		t.Errorf("record %s is listed with TTL %d, want %d", r.ID, r.TTL, want)
+	}
+}
+
+// sameNode treats "" and "@" as the same apex
+func sameNode(a, b string) bool {
+	if a == "@" {
+		a = ""
+	}
+	if b == "@" {
+		b = ""
+	}
+	return strings.EqualFold(a, b)
+}
+
+func sameName(a, b string) bool {
+	return strings.EqualFold(strings.TrimSuffix(a, "."), strings.TrimSuffix(b, "."))
+}
+
+func apexLabel(apex string) string {
+	if apex == "" {
+		return "empty"
+	}
+	return apex
+}
@@ -0,0 +1,119 @@
+package providertest
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Record is a record held by a stand-in. Name is relative to the zone and
+// empty at the apex.
+type Record struct {
+	ID       string
+	Name     string
+	Type     string
+	Content  string
+	TTL      int
+	Priority int
+	Disabled bool
+}
+
+// Zone is the record store behind a stand-in. It is safe for use by
+// concurrent handlers.
+type Zone struct {
+	Name string
+
+	mu      sync.Mutex
+	records []Record
+	nextID  int
+}
+
+func NewZone(name string) *Zone {
+	return &Zone{Name: strings.TrimSuffix(name, "."), nextID: 1000}
+}
+
+// Relative converts a name as a platform might send it ("@", "", "www",
+// "www.example.com" or "www.example.com.") to one relative to the zone
+func (z *Zone) Relative(name string) string {
+	name = strings.TrimSuffix(name, ".")
+	switch {
+	case name == "@" || strings.EqualFold(name, z.Name):
+		return ""
+	case strings.HasSuffix(strings.ToLower(name), "."+strings.ToLower(z.Name)):
+		return name[:len(name)-len(z.Name)-1]
+	}
+	return name
+}
+
+// FQDN returns the full name of a relative name, without a trailing dot
+func (z *Zone) FQDN(name string) string {
+	if name == "" {
+		return z.Name
+	}
+	return name + "." + z.Name
+}
+
+// Add stores r under a new numeric ID and returns it
+func (z *Zone) Add(r Record) Record {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	z.nextID++
+	r.ID = strconv.Itoa(z.nextID)
+	z.records = append(z.records, r)
+	return r
+}
+
+func (z *Zone) Get(id string) (Record, bool) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	for _, r := range z.records {
+		if r.ID == id {
+			return r, true
+		}
+	}
+	return Record{}, false
+}
+
+// Update replaces the record with r's ID, reporting whether it existed
+func (z *Zone) Update(r Record) bool {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	for i := range z.records {
+		if z.records[i].ID == r.ID {
+			z.records[i] = r
+			return true
+		}
+	}
+	return false
+}
+
+// Delete removes a record, reporting whether it existed
+func (z *Zone) Delete(id string) bool {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	for i, r := range z.records {
+		if r.ID == id {
+			z.records = append(z.records[:i], z.records[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Records returns a copy of every record, in insertion order
+func (z *Zone) Records() []Record {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return append([]Record(nil), z.records...)
+}
+
+// Lookup returns the records with the given relative name and type
+func (z *Zone) Lookup(name, recordType string) []Record {
+	var out []Record
+	for _, r := range z.Records() {
+		if strings.EqualFold(r.Name, name) && strings.EqualFold(r.Type, recordType) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
@@ -40,10 +40,12 @@ func List() []ProviderInfo {
 	var infos []ProviderInfo
 	for _, p := range globalRegistry.providers {
 		infos = append(infos, ProviderInfo{
-			Name:        p.Name(),
-			DisplayName: p.DisplayName(),
-			WebsiteURL:  p.WebsiteURL(),
-			DefaultTTL:  p.DefaultTTL(),
+			Name:         p.Name(),
+			DisplayName:  p.DisplayName(),
+			WebsiteURL:   p.WebsiteURL(),
+			DefaultTTL:   p.DefaultTTL(),
+			Capabilities: Capabilities(p),
+			Credentials:  CredentialFieldsOf(p),
 		})
 	}
 	return infos
@@ -3,6 +3,7 @@ package tencentcloud
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
@@ -10,7 +11,10 @@ import (
 	dnspod "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/dnspod/v20210323"
 )
 
-type Client struct{}
+type Client struct {
+	// endpoint overrides dnspod.tencentcloudapi.com when set, e.g. "http://127.0.0.1:8080"
+	endpoint string
+}
 
 func NewClient() *Client {
 	return &Client{}
@@ -22,7 +26,11 @@ func (c *Client) parseAPIKey(apiKey string) (string, string, error) {
 	if len(parts) != 2 {
 		return "", "", fmt.Errorf("invalid API key format, expected 'SecretId,SecretKey'")
 	}
-	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), nil
+	secretID, secretKey := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	if secretID == "" || secretKey == "" {
+		return "", "", fmt.Errorf("invalid API key: empty SecretId or SecretKey")
+	}
+	return secretID, secretKey, nil
 }
 
 func (c *Client) newDNSPodClient(apiKey string) (*dnspod.Client, error) {
@@ -33,6 +41,14 @@ func (c *Client) newDNSPodClient(apiKey string) (*dnspod.Client, error) {
 
 	credential := common.NewCredential(secretId, secretKey)
 	cpf := profile.NewClientProfile()
+	if c.endpoint != "" {
+		u, err := url.Parse(c.endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid endpoint: %w", err)
+		}
+		cpf.HttpProfile.Endpoint = u.Host
+		cpf.HttpProfile.Scheme = strings.ToUpper(u.Scheme)
+	}
 	return dnspod.NewClient(credential, "", cpf)
 }
 
@@ -20,6 +20,14 @@ func New() *Provider {
 	}
 }
 
+// NewWithEndpoint returns a provider that sends DNSPod calls to endpoint
+// instead of dnspod.tencentcloudapi.com, e.g. a stand-in server in tests
+func NewWithEndpoint(endpoint string) *Provider {
+	p := New()
+	p.client.endpoint = endpoint
+	return p
+}
+
 func (p *Provider) Name() string {
 	return "tencentcloud"
 }
@@ -36,6 +44,27 @@ func (p *Provider) DefaultTTL() int {
 	return 600
 }
 
+func (p *Provider) CredentialFields() []models.CredentialField {
+	return []models.CredentialField{
+		{Name: "secret_id", Label: "SecretId", Required: true},
+		{Name: "secret_key", Label: "SecretKey", Secret: true, Required: true},
+	}
+}
+
+func (p *Provider) ValidateAPIKey(apiKey string) error {
+	_, _, err := p.client.parseAPIKey(apiKey)
+	return err
+}
+
+// The free DNSPod plan does not accept TTLs below 600.
+func (p *Provider) MinTTL() int {
+	return 600
+}
+
+func (p *Provider) ApexCNAME() bool {
+	return false
+}
+
 func (p *Provider) ListDomains(ctx context.Context, apiKey string) ([]models.Domain, error) {
 	domainList, err := p.client.ListDomains(ctx, apiKey)
 	if err != nil {
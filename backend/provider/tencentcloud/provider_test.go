@@ -0,0 +1,168 @@
+package tencentcloud
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"dns-mng/provider/providertest"
+
+	dnspod "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/dnspod/v20210323"
+)
+
+const (
+	testSecretID  = "AKIDtestsecretid"
+	testSecretKey = "test-secret-key"
+)
+
+// fakeDNSPod serves DNSPod API 3.0 calls: a JSON POST with the action in
+// X-TC-Action. Every answer is wrapped in {"Response": ...} with HTTP 200,
+// errors included. Like DNSPod, the zone starts with its NS records.
+type fakeDNSPod struct {
+	zone *providertest.Zone
+}
+
+func newFakeDNSPod() *fakeDNSPod {
+	zone := providertest.NewZone("example.com")
+	for _, ns := range []string{"f1g1ns1.dnspod.net.", "f1g1ns2.dnspod.net."} {
+		zone.Add(providertest.Record{Type: "NS", Content: ns, TTL: 86400})
+	}
+	return &fakeDNSPod{zone: zone}
+}
+
+func respond(w http.ResponseWriter, v map[string]interface{}) {
+	v["RequestId"] = "00000000-0000-0000-0000-000000000000"
+	providertest.WriteJSON(w, http.StatusOK, map[string]interface{}{"Response": v})
+}
+
+func fail(w http.ResponseWriter, code, msg string) {
+	respond(w, map[string]interface{}{"Error": map[string]string{"Code": code, "Message": msg}})
+}
+
+func (f *fakeDNSPod) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.Header.Get("Authorization"), "Credential="+testSecretID+"/") {
+		fail(w, "AuthFailure.SecretIdNotFound", "The SecretId is not found, please ensure that your SecretId is correct.")
+		return
+	}
+
+	var req struct {
+		Domain     string
+		SubDomain  string
+		RecordType string
+		Value      string
+		RecordId   uint64
+		TTL        int
+		MX         int
+		Status     string
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		fail(w, "InvalidParameter", err.Error())
+		return
+	}
+
+	action := r.Header.Get("X-TC-Action")
+	if action == "DescribeDomainList" {
+		name, status, created := f.zone.Name, "enable", "2026-01-01 00:00:00"
+		respond(w, map[string]interface{}{
+			"DomainCountInfo": map[string]int{"AllTotal": 1, "DomainTotal": 1},
+			"DomainList":      []dnspod.DomainListItem{{Name: &name, Status: &status, CreatedOn: &created}},
+		})
+		return
+	}
+	if req.Domain != f.zone.Name {
+		fail(w, "InvalidParameterValue.DomainNotExists", "The current domain does not exist, please check it and try again.")
+		return
+	}
+
+	switch action {
+	case "DescribeDomain":
+		name, status, created := f.zone.Name, "ENABLE", "2026-01-01 00:00:00"
+		respond(w, map[string]interface{}{"DomainInfo": dnspod.DomainInfo{Domain: &name, Status: &status, CreatedOn: &created}})
+	case "DescribeRecordList":
+		records := []dnspod.RecordListItem{}
+		for _, rec := range f.zone.Records() {
+			records = append(records, toDNSPod(rec))
+		}
+		respond(w, map[string]interface{}{
+			"RecordCountInfo": map[string]int{"TotalCount": len(records), "ListCount": len(records)},
+			"RecordList":      records,
+		})
+	case "CreateRecord":
+		rec, ok := f.record(w, req.SubDomain, req.RecordType, req.Value, req.TTL, req.MX, req.Status)
+		if !ok {
+			return
+		}
+		rec = f.zone.Add(rec)
+		id, _ := strconv.ParseUint(rec.ID, 10, 64)
+		respond(w, map[string]interface{}{"RecordId": id})
+	case "ModifyRecord":
+		id := strconv.FormatUint(req.RecordId, 10)
+		if _, ok := f.zone.Get(id); !ok {
+			fail(w, "InvalidParameter.RecordIdInvalid", "The record ID is invalid.")
+			return
+		}
+		rec, ok := f.record(w, req.SubDomain, req.RecordType, req.Value, req.TTL, req.MX, req.Status)
+		if !ok {
+			return
+		}
+		rec.ID = id
+		f.zone.Update(rec)
+		respond(w, map[string]interface{}{"RecordId": req.RecordId})
+	case "DeleteRecord":
+		if !f.zone.Delete(strconv.FormatUint(req.RecordId, 10)) {
+			fail(w, "InvalidParameter.RecordIdInvalid", "The record ID is invalid.")
+			return
+		}
+		respond(w, map[string]interface{}{})
+	default:
+		fail(w, "InvalidAction", "The requested action does not exist.")
+	}
+}
+
+func (f *fakeDNSPod) record(w http.ResponseWriter, sub, recordType, value string, ttl, mx int, status string) (providertest.Record, bool) {
+	if ttl < 600 {
+		fail(w, "InvalidParameter.DomainRecordTTLInvalid", "The TTL is below the minimum allowed by the domain's plan.")
+		return providertest.Record{}, false
+	}
+	return providertest.Record{
+		Name:     f.zone.Relative(sub),
+		Type:     recordType,
+		Content:  value,
+		TTL:      ttl,
+		Priority: mx,
+		Disabled: status == "DISABLE",
+	}, true
+}
+
+func toDNSPod(rec providertest.Record) dnspod.RecordListItem {
+	id, _ := strconv.ParseUint(rec.ID, 10, 64)
+	name := rec.Name
+	if name == "" {
+		name = "@"
+	}
+	status := "ENABLE"
+	if rec.Disabled {
+		status = "DISABLE"
+	}
+	recordType, value, line, updated := rec.Type, rec.Content, "默认", "2026-01-01 00:00:00"
+	ttl, mx := uint64(rec.TTL), uint64(rec.Priority)
+	return dnspod.RecordListItem{
+		RecordId: &id, Name: &name, Type: &recordType, Value: &value, Line: &line,
+		TTL: &ttl, MX: &mx, Status: &status, UpdatedOn: &updated,
+	}
+}
+
+func TestConformance(t *testing.T) {
+	server := httptest.NewServer(newFakeDNSPod())
+	defer server.Close()
+
+	providertest.Run(t, NewWithEndpoint(server.URL), providertest.Config{
+		APIKey:    testSecretID + "," + testSecretKey,
+		BadAPIKey: "AKIDwrongsecretid," + testSecretKey,
+		AuthError: "AuthFailure.SecretIdNotFound",
+		Zone:      "example.com",
+	})
+}
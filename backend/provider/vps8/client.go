@@ -10,10 +10,11 @@ import (
 	"time"
 )
 
-const baseURL = "https://vps8.zz.cd/api/client/dnsopenapi"
+const defaultBaseURL = "https://vps8.zz.cd/api/client/dnsopenapi"
 
 type Client struct {
 	httpClient *http.Client
+	baseURL    string
 }
 
 func NewClient() *Client {
@@ -21,6 +22,7 @@ func NewClient() *Client {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		baseURL: defaultBaseURL,
 	}
 }
 
@@ -34,7 +36,7 @@ func (c *Client) doRequest(ctx context.Context, apiKey, path string, body interf
 		bodyReader = bytes.NewReader(data)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", baseURL+path, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+path, bodyReader)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
@@ -25,6 +25,14 @@ func New() *Provider {
 	return NewProvider()
 }
 
+// NewWithEndpoint returns a provider that talks to baseURL instead of
+// vps8.zz.cd, e.g. a stand-in server in tests
+func NewWithEndpoint(baseURL string) *Provider {
+	p := NewProvider()
+	p.client.baseURL = strings.TrimSuffix(baseURL, "/")
+	return p
+}
+
 func (p *Provider) Name() string {
 	return "vps8"
 }
@@ -41,6 +49,12 @@ func (p *Provider) DefaultTTL() int {
 	return 100
 }
 
+func (p *Provider) CredentialFields() []models.CredentialField {
+	return []models.CredentialField{
+		{Name: "api_key", Label: "API Key", Secret: true, Required: true},
+	}
+}
+
 func (p *Provider) ListDomains(ctx context.Context, apiKey string) ([]models.Domain, error) {
 	domains, err := p.client.ListDomains(ctx, apiKey)
 	if err != nil {
@@ -154,7 +168,22 @@ func (p *Provider) CreateRecord(ctx context.Context, apiKey string, domainID str
 		return nil, err
 	}
 
+	// record_create does not return the new ID, so look it up
+	nodeName := record.NodeName
+	if nodeName == "@" {
+		nodeName = ""
+	}
+	recordID := ""
+	if records, err := p.ListRecords(ctx, apiKey, domainID); err == nil {
+		for _, r := range records {
+			if r.NodeName == nodeName && r.RecordType == record.RecordType && r.Content == record.Content {
+				recordID = r.ID
+			}
+		}
+	}
+
 	return &models.Record{
+		ID:         recordID,
 		DomainID:   domainID,
 		DomainName: domain.Name,
 		NodeName:   record.NodeName,
@@ -0,0 +1,115 @@
+package vps8
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"dns-mng/provider/providertest"
+)
+
+const testAPIKey = "vps8-test-key"
+
+// fakeVPS8 serves the VPS8 DNS open API: JSON POSTs with basic auth and
+// {"result", "error"} envelopes. Hosts are relative, with "@" at the apex.
+type fakeVPS8 struct {
+	zone *providertest.Zone
+}
+
+type envelope struct {
+	Result interface{} `json:"result"`
+	Error  *string     `json:"error"`
+}
+
+func fail(w http.ResponseWriter, status int, msg string) {
+	providertest.WriteJSON(w, status, envelope{Error: &msg})
+}
+
+func (f *fakeVPS8) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if user, pass, ok := r.BasicAuth(); !ok || user != "client" || pass != testAPIKey {
+		fail(w, http.StatusUnauthorized, "Invalid API key")
+		return
+	}
+	if r.Method != http.MethodPost {
+		fail(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req struct {
+		Domain  string `json:"domain"`
+		ID      int    `json:"id"`
+		Name    string `json:"host"`
+		Type    string `json:"type"`
+		Content string `json:"value"`
+		TTL     int    `json:"ttl"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		fail(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if r.URL.Path == "/domain_list" {
+		providertest.WriteJSON(w, http.StatusOK, DomainListResponse{Result: []Domain{{
+			Domain: f.zone.Name, CreatedAt: "2026-01-01 00:00:00", ExpiresAt: "2027-01-01 00:00:00",
+		}}})
+		return
+	}
+	if req.Domain != f.zone.Name {
+		fail(w, http.StatusNotFound, "Domain not found")
+		return
+	}
+
+	switch r.URL.Path {
+	case "/record_list":
+		records := []Record{}
+		for _, rec := range f.zone.Records() {
+			records = append(records, toVPS8(rec))
+		}
+		providertest.WriteJSON(w, http.StatusOK, RecordListResponse{Result: records})
+	case "/record_create":
+		rec := f.zone.Add(providertest.Record{
+			Name: f.zone.Relative(req.Name), Type: req.Type, Content: req.Content, TTL: req.TTL,
+		})
+		providertest.WriteJSON(w, http.StatusOK, envelope{Result: toVPS8(rec)})
+	case "/record_update":
+		rec, ok := f.zone.Get(strconv.Itoa(req.ID))
+		if !ok {
+			fail(w, http.StatusNotFound, "Record not found")
+			return
+		}
+		rec.Content, rec.TTL = req.Content, req.TTL
+		f.zone.Update(rec)
+		providertest.WriteJSON(w, http.StatusOK, envelope{Result: toVPS8(rec)})
+	case "/record_delete":
+		if !f.zone.Delete(strconv.Itoa(req.ID)) {
+			fail(w, http.StatusNotFound, "Record not found")
+			return
+		}
+		providertest.WriteJSON(w, http.StatusOK, envelope{Result: true})
+	default:
+		fail(w, http.StatusNotFound, "Unknown action")
+	}
+}
+
+func toVPS8(rec providertest.Record) Record {
+	id, _ := strconv.Atoi(rec.ID)
+	name := rec.Name
+	if name == "" {
+		name = "@"
+	}
+	return Record{ID: id, Name: name, Type: rec.Type, Content: rec.Content, TTL: rec.TTL, Priority: rec.Priority}
+}
+
+func TestConformance(t *testing.T) {
+	server := httptest.NewServer(&fakeVPS8{zone: providertest.NewZone("example.com")})
+	defer server.Close()
+
+	providertest.Run(t, NewWithEndpoint(server.URL), providertest.Config{
+		APIKey:    testAPIKey,
+		BadAPIKey: "wrong-key",
+		AuthError: "Invalid API key",
+		Zone:      "example.com",
+	})
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"dns-mng/database"
+	"dns-mng/models"
+	"dns-mng/provider"
+)
+
+// accountCheckTimeout bounds one connection test
+const accountCheckTimeout = 30 * time.Second
+
+// ErrUnknownProvider is returned for an account of an unregistered provider
+// type
+var ErrUnknownProvider = errors.New("unknown provider type")
+
+// CredentialError is returned by Create and Update when the credentials are
+// malformed or rejected by the provider; the account is not saved
+type CredentialError struct {
+	Check *models.AccountCheck
+}
+
+func (e *CredentialError) Error() string {
+	return e.Check.Error
+}
+
+// TestCredentials checks credentials that are not saved yet, given as
+// fields or as a legacy api_key: their format, then a domain listing. Only
+// an unknown provider type is an error; every other failure is reported in
+// the check.
+func (s *AccountService) TestCredentials(ctx context.Context, providerType string, creds models.Credentials, apiKey string) (*models.AccountCheck, error) {
+	p, err := provider.Get(providerType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, providerType)
+	}
+	if _, apiKey, err = resolveCredentials(p, creds, apiKey); err != nil {
+		return formatCheck(err), nil
+	}
+	return testCredentials(ctx, p, apiKey), nil
+}
+
+// testCredentials runs the check of credentials that are not saved. No
+// account yet, so no guard to go through.
+func testCredentials(ctx context.Context, p provider.DNSProvider, apiKey string) *models.AccountCheck {
+	return testAccount(ctx, p, apiKey, func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
+	})
+}
+
+// Check tests a saved account's credentials and records the outcome
+func (s *AccountService) Check(ctx context.Context, account *models.Account) (*models.AccountCheck, error) {
+	p, err := provider.Get(account.ProviderType)
+	if err != nil {
+		return nil, err
+	}
+	check := testAccount(ctx, p, account.APIKey, func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return provider.Probe(ctx, p, account.ID, fn)
+	})
+	if err := s.RecordCheck(account.ID, check); err != nil {
+		return nil, err
+	}
+	return check, nil
+}
+
+// TestAccount tests an account's stored credentials, or the replacement
+// credentials or api_key when given, merged like in Update; the latter are
+// not recorded since they are not saved.
+func (s *AccountService) TestAccount(ctx context.Context, userID, accountID int64, creds models.Credentials, apiKey string) (*models.AccountCheck, error) {
+	account, err := s.Get(userID, accountID)
+	if err != nil {
+		return nil, errors.New("account not found")
+	}
+	if len(creds) == 0 && isMaskedOrEmpty(apiKey) {
+		return s.Check(ctx, account)
+	}
+	if _, apiKey, err = updatedCredentials(account, creds, apiKey); err != nil {
+		var ce *CredentialError
+		if errors.As(err, &ce) {
+			return ce.Check, nil
+		}
+		return nil, err
+	}
+	p, _ := provider.Get(account.ProviderType)
+	return testCredentials(ctx, p, apiKey), nil
+}
+
+func testAccount(ctx context.Context, p provider.DNSProvider, apiKey string, call func(ctx context.Context, fn func(ctx context.Context) error) error) *models.AccountCheck {
+	if err := provider.ValidateAPIKey(p, apiKey); err != nil {
+		return formatCheck(err)
+	}
+	check := &models.AccountCheck{CheckedAt: time.Now()}
+
+	// Classified against the caller's context, so that running out of
+	// time counts as the provider being unreachable
+	callCtx, cancel := context.WithTimeout(ctx, accountCheckTimeout)
+	defer cancel()
+	err := call(callCtx, func(ctx context.Context) error {
+		domains, err := p.ListDomains(ctx, apiKey)
+		check.DomainCount = len(domains)
+		return err
+	})
+	check.Code = provider.CheckCode(ctx, err)
+	check.OK = err == nil
+	if err != nil {
+		check.Error = err.Error()
+	}
+	return check
+}
+
+// RecordCheck stores the outcome of a check or listing of the account.
+// failing_since is set by the first failure and cleared by a success;
+// throttling says nothing about the account and leaves it as it is.
+func (s *AccountService) RecordCheck(accountID int64, check *models.AccountCheck) error {
+	var err error
+	switch {
+	case check.OK:
+		_, err = database.DB.Exec(
+			`UPDATE accounts SET check_code = ?, check_error = '', check_domains = ?, checked_at = ?,
+			 failing_since = NULL, failure_notified = 0 WHERE id = ?`,
+			check.Code, check.DomainCount, check.CheckedAt, accountID)
+	case check.Code == models.AccountCheckRateLimited:
+		_, err = database.DB.Exec(
+			`UPDATE accounts SET check_code = ?, check_error = ?, checked_at = ? WHERE id = ?`,
+			check.Code, check.Error, check.CheckedAt, accountID)
+	default:
+		_, err = database.DB.Exec(
+			`UPDATE accounts SET check_code = ?, check_error = ?, checked_at = ?,
+			 failing_since = COALESCE(failing_since, ?) WHERE id = ?`,
+			check.Code, check.Error, check.CheckedAt, check.CheckedAt, accountID)
+	}
+	return err
+}
+
+// UnnotifiedCredentialFailures returns the user's accounts whose
+// credentials were rejected and who have not been told yet
+func (s *AccountService) UnnotifiedCredentialFailures(userID int64) ([]models.Account, error) {
+	rows, err := database.DB.Query(
+		"SELECT "+accountColumns+" FROM accounts WHERE user_id = ? AND check_code IN (?, ?) AND failure_notified = 0",
+		userID, models.AccountCheckInvalidFormat, models.AccountCheckUnauthorized,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []models.Account
+	for rows.Next() {
+		a, err := scanAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, *a)
+	}
+	return accounts, rows.Err()
+}
+
+// MarkFailureNotified records that the user was told about the account's
+// rejected credentials, until its next successful check
+func (s *AccountService) MarkFailureNotified(accountID int64) error {
+	_, err := database.DB.Exec("UPDATE accounts SET failure_notified = 1 WHERE id = ?", accountID)
+	return err
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"dns-mng/database"
+	"dns-mng/models"
+	"dns-mng/provider"
+)
+
+// Accounts store their credentials as encrypted JSON by field name. The
+// api_key column keeps them joined into the string provider methods take
+// (provider.EncodeCredentials), so provider calls and backups are unchanged.
+
+func encryptCredentials(creds models.Credentials) (string, error) {
+	if len(creds) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(creds)
+	if err != nil {
+		return "", err
+	}
+	return EncryptSecret(string(data))
+}
+
+func decryptCredentials(stored string) (models.Credentials, error) {
+	if stored == "" {
+		return nil, nil
+	}
+	plain, err := DecryptSecret(stored)
+	if err != nil {
+		return nil, err
+	}
+	var creds models.Credentials
+	if err := json.Unmarshal([]byte(plain), &creds); err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+// accountCredentials returns the stored credentials, or splits the api_key
+// of accounts saved before credentials were structured
+func accountCredentials(a *models.Account, stored string) models.Credentials {
+	creds, err := decryptCredentials(stored)
+	if err != nil {
+		log.Printf("Warning: account %d has unreadable credentials: %v", a.ID, err)
+	}
+	if len(creds) > 0 {
+		return creds
+	}
+	p, err := provider.Get(a.ProviderType)
+	if err != nil {
+		return nil
+	}
+	creds, _ = provider.DecodeCredentials(p, a.APIKey)
+	return creds
+}
+
+// resolveCredentials validates the credentials of a save or test, given as
+// fields or as a legacy api_key, and returns them with the api_key p takes.
+// Format errors are provider.FieldErrors where they concern a field.
+func resolveCredentials(p provider.DNSProvider, creds models.Credentials, apiKey string) (models.Credentials, string, error) {
+	if len(creds) == 0 {
+		if strings.TrimSpace(apiKey) == "" {
+			return nil, "", provider.ValidateCredentials(p, nil)
+		}
+		var err error
+		if creds, err = provider.DecodeCredentials(p, apiKey); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := provider.ValidateCredentials(p, creds); err != nil {
+		return nil, "", err
+	}
+	apiKey = provider.EncodeCredentials(p, creds)
+	if err := provider.ValidateAPIKey(p, apiKey); err != nil {
+		return nil, "", err
+	}
+	return creds, apiKey, nil
+}
+
+// mergeCredentials applies the fields of an update to the stored ones.
+// Secret fields left empty or masked keep their value; other fields are
+// shown in full, so an empty value clears them.
+func mergeCredentials(p provider.DNSProvider, stored, in models.Credentials) models.Credentials {
+	merged := models.Credentials{}
+	for _, f := range provider.CredentialFieldsOf(p) {
+		v, ok := in[f.Name]
+		if !ok || (f.Secret && isMaskedOrEmpty(v)) {
+			v = stored[f.Name]
+		}
+		if v != "" {
+			merged[f.Name] = v
+		}
+	}
+	// Unknown fields are kept for ValidateCredentials to report
+	for name, v := range in {
+		if _, ok := merged[name]; !ok && v != "" && !isMaskedOrEmpty(v) {
+			merged[name] = v
+		}
+	}
+	return merged
+}
+
+// updatedCredentials applies the credentials or api_key of an update to
+// the account's. Without either, the account's are returned unchanged.
+func updatedCredentials(account *models.Account, in models.Credentials, apiKey string) (models.Credentials, string, error) {
+	if len(in) == 0 && isMaskedOrEmpty(apiKey) {
+		return account.Credentials, account.APIKey, nil
+	}
+	p, err := provider.Get(account.ProviderType)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %s", ErrUnknownProvider, account.ProviderType)
+	}
+	var creds models.Credentials
+	if len(in) > 0 {
+		creds, apiKey, err = resolveCredentials(p, mergeCredentials(p, account.Credentials, in), "")
+	} else {
+		creds, apiKey, err = resolveCredentials(p, nil, apiKey)
+	}
+	if err != nil {
+		return nil, "", &CredentialError{Check: formatCheck(err)}
+	}
+	return creds, apiKey, nil
+}
+
+// MaskCredentials hides the secret fields of an account's credentials
+func MaskCredentials(providerType string, creds models.Credentials) models.Credentials {
+	if len(creds) == 0 {
+		return creds
+	}
+	secret := map[string]bool{}
+	if p, err := provider.Get(providerType); err == nil {
+		for _, f := range provider.CredentialFieldsOf(p) {
+			secret[f.Name] = f.Secret
+		}
+	}
+	masked := make(models.Credentials, len(creds))
+	for name, v := range creds {
+		// Fields the schema does not know are treated as secret
+		if s, ok := secret[name]; !ok || s {
+			v = MaskSecret(v)
+		}
+		masked[name] = v
+	}
+	return masked
+}
+
+// formatCheck is the check of credentials that failed format validation
+func formatCheck(err error) *models.AccountCheck {
+	check := &models.AccountCheck{Code: models.AccountCheckInvalidFormat, Error: err.Error(), CheckedAt: time.Now()}
+	var fe provider.FieldErrors
+	if errors.As(err, &fe) {
+		check.Fields = fe
+	}
+	return check
+}
+
+// MigrateCredentials stores the structured credentials of accounts saved
+// with only an api_key. Accounts of unregistered providers or with keys
+// that do not split into their fields are left for a later start.
+func (s *AccountService) MigrateCredentials() (int, error) {
+	rows, err := database.DB.Query("SELECT id, provider_type, api_key FROM accounts WHERE credentials IS NULL OR credentials = ''")
+	if err != nil {
+		return 0, err
+	}
+	type pending struct {
+		id                   int64
+		providerType, apiKey string
+	}
+	var todo []pending
+	for rows.Next() {
+		var a pending
+		if err := rows.Scan(&a.id, &a.providerType, &a.apiKey); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		todo = append(todo, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+	for _, a := range todo {
+		p, err := provider.Get(a.providerType)
+		if err != nil {
+			continue
+		}
+		apiKey, err := DecryptSecret(a.apiKey)
+		if err != nil {
+			log.Printf("Credential migration: account %d: %v", a.id, err)
+			continue
+		}
+		creds, err := provider.DecodeCredentials(p, apiKey)
+		if err != nil {
+			log.Printf("Credential migration: account %d: %v", a.id, err)
+			continue
+		}
+		stored, err := encryptCredentials(creds)
+		if err != nil {
+			return migrated, err
+		}
+		if _, err := database.DB.Exec("UPDATE accounts SET credentials = ? WHERE id = ?", stored, a.id); err != nil {
+			return migrated, err
+		}
+		migrated++
+	}
+	return migrated, nil
+}
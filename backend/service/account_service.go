@@ -1,9 +1,13 @@
 package service
 
 import (
+	"context"
+	"database/sql"
 	"dns-mng/database"
 	"dns-mng/models"
+	"dns-mng/provider"
 	"errors"
+	"fmt"
 	"log"
 	"time"
 )
@@ -14,9 +18,44 @@ func NewAccountService() *AccountService {
 	return &AccountService{}
 }
 
+// ErrAccountNotFound is returned when the account does not exist or belongs
+// to another user
+var ErrAccountNotFound = errors.New("account not found")
+
+const accountColumns = "id, user_id, name, provider_type, api_key, credentials, created_at, updated_at, check_code, check_error, check_domains, checked_at, failing_since"
+
+func scanAccount(row rowScanner) (*models.Account, error) {
+	var a models.Account
+	var credentials, checkCode, checkError sql.NullString
+	var checkDomains sql.NullInt64
+	var checkedAt, failingSince sql.NullTime
+	if err := row.Scan(&a.ID, &a.UserID, &a.Name, &a.ProviderType, &a.APIKey, &credentials, &a.CreatedAt, &a.UpdatedAt,
+		&checkCode, &checkError, &checkDomains, &checkedAt, &failingSince); err != nil {
+		return nil, err
+	}
+	var err error
+	if a.APIKey, err = DecryptSecret(a.APIKey); err != nil {
+		return nil, fmt.Errorf("account %d: %w", a.ID, err)
+	}
+	a.Credentials = accountCredentials(&a, credentials.String)
+	if checkedAt.Valid {
+		a.LastCheck = &models.AccountCheck{
+			OK:          checkCode.String == models.AccountCheckOK,
+			Code:        checkCode.String,
+			Error:       checkError.String,
+			DomainCount: int(checkDomains.Int64),
+			CheckedAt:   checkedAt.Time,
+		}
+	}
+	if failingSince.Valid {
+		a.FailingSince = &failingSince.Time
+	}
+	return &a, nil
+}
+
 func (s *AccountService) List(userID int64) ([]models.Account, error) {
 	rows, err := database.DB.Query(
-		"SELECT id, user_id, name, provider_type, api_key, created_at, updated_at FROM accounts WHERE user_id = ? ORDER BY created_at DESC",
+		"SELECT "+accountColumns+" FROM accounts WHERE user_id = ? ORDER BY created_at DESC",
 		userID,
 	)
 	if err != nil {
@@ -26,50 +65,66 @@ func (s *AccountService) List(userID int64) ([]models.Account, error) {
 
 	var accounts []models.Account
 	for rows.Next() {
-		var a models.Account
-		if err := rows.Scan(&a.ID, &a.UserID, &a.Name, &a.ProviderType, &a.APIKey, &a.CreatedAt, &a.UpdatedAt); err != nil {
+		a, err := scanAccount(rows)
+		if err != nil {
 			return nil, err
 		}
-		accounts = append(accounts, a)
+		accounts = append(accounts, *a)
 	}
 	return accounts, nil
 }
 
 func (s *AccountService) Get(userID, accountID int64) (*models.Account, error) {
-	var a models.Account
-	err := database.DB.QueryRow(
-		"SELECT id, user_id, name, provider_type, api_key, created_at, updated_at FROM accounts WHERE id = ? AND user_id = ?",
+	return scanAccount(database.DB.QueryRow(
+		"SELECT "+accountColumns+" FROM accounts WHERE id = ? AND user_id = ?",
 		accountID, userID,
-	).Scan(&a.ID, &a.UserID, &a.Name, &a.ProviderType, &a.APIKey, &a.CreatedAt, &a.UpdatedAt)
+	))
+}
+
+// Create tests the credentials and saves the account unless they are
+// malformed or rejected. Other failures, e.g. an unreachable provider, do
+// not block saving and are recorded on the account.
+func (s *AccountService) Create(ctx context.Context, userID int64, req *models.CreateAccountRequest) (*models.Account, error) {
+	p, err := provider.Get(req.ProviderType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, req.ProviderType)
+	}
+	creds, apiKey, err := resolveCredentials(p, req.Credentials, req.APIKey)
+	if err != nil {
+		return nil, &CredentialError{Check: formatCheck(err)}
+	}
+	check := testCredentials(ctx, p, apiKey)
+	if check.CredentialsRejected() {
+		return nil, &CredentialError{Check: check}
+	}
+
+	encKey, err := EncryptSecret(apiKey)
+	if err != nil {
+		return nil, err
+	}
+	encCreds, err := encryptCredentials(creds)
 	if err != nil {
 		return nil, err
 	}
-	return &a, nil
-}
 
-func (s *AccountService) Create(userID int64, req *models.CreateAccountRequest) (*models.Account, error) {
 	now := time.Now()
 	result, err := database.DB.Exec(
-		"INSERT INTO accounts (user_id, name, provider_type, api_key, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
-		userID, req.Name, req.ProviderType, req.APIKey, now, now,
+		"INSERT INTO accounts (user_id, name, provider_type, api_key, credentials, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		userID, req.Name, req.ProviderType, encKey, encCreds, now, now,
 	)
 	if err != nil {
 		return nil, err
 	}
 
 	id, _ := result.LastInsertId()
-	return &models.Account{
-		ID:           id,
-		UserID:       userID,
-		Name:         req.Name,
-		ProviderType: req.ProviderType,
-		APIKey:       req.APIKey,
-		CreatedAt:    now,
-		UpdatedAt:    now,
-	}, nil
+	if err := s.RecordCheck(id, check); err != nil {
+		log.Printf("Warning: failed to record check of account %d: %v", id, err)
+	}
+	return s.Get(userID, id)
 }
 
-func (s *AccountService) Update(userID, accountID int64, req *models.UpdateAccountRequest) (*models.Account, error) {
+// Update saves the account; new credentials are tested like in Create
+func (s *AccountService) Update(ctx context.Context, userID, accountID int64, req *models.UpdateAccountRequest) (*models.Account, error) {
 	// Check ownership
 	account, err := s.Get(userID, accountID)
 	if err != nil {
@@ -79,18 +134,43 @@ func (s *AccountService) Update(userID, accountID int64, req *models.UpdateAccou
 	if req.Name != "" {
 		account.Name = req.Name
 	}
-	if req.APIKey != "" {
-		account.APIKey = req.APIKey
+	creds, apiKey, err := updatedCredentials(account, req.Credentials, req.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	var check *models.AccountCheck
+	if apiKey != account.APIKey {
+		p, _ := provider.Get(account.ProviderType)
+		if check = testCredentials(ctx, p, apiKey); check.CredentialsRejected() {
+			return nil, &CredentialError{Check: check}
+		}
 	}
+	account.Credentials, account.APIKey = creds, apiKey
 	account.UpdatedAt = time.Now()
 
+	encKey, err := EncryptSecret(account.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	encCreds, err := encryptCredentials(account.Credentials)
+	if err != nil {
+		return nil, err
+	}
 	_, err = database.DB.Exec(
-		"UPDATE accounts SET name = ?, api_key = ?, updated_at = ? WHERE id = ? AND user_id = ?",
-		account.Name, account.APIKey, account.UpdatedAt, accountID, userID,
+		"UPDATE accounts SET name = ?, api_key = ?, credentials = ?, updated_at = ? WHERE id = ? AND user_id = ?",
+		account.Name, encKey, encCreds, account.UpdatedAt, accountID, userID,
 	)
 	if err != nil {
 		return nil, err
 	}
+	// New credentials deserve a fresh start rather than an open breaker
+	if check != nil {
+		provider.ResetAccount(accountID)
+		if err := s.RecordCheck(accountID, check); err != nil {
+			log.Printf("Warning: failed to record check of account %d: %v", accountID, err)
+		}
+		return s.Get(userID, accountID)
+	}
 
 	return account, nil
 }
@@ -103,6 +183,18 @@ func (s *AccountService) Delete(userID, accountID int64) error {
 		log.Printf("Warning: failed to delete cf_optimize records for account %d: %v", accountID, err)
 	}
 
+	// Drop cached DDNS values so the fast path never answers for a removed account
+	if _, err := database.DB.Exec("DELETE FROM ddns_record_state WHERE account_id = ? AND user_id = ?", accountID, userID); err != nil {
+		log.Printf("Warning: failed to delete ddns record state for account %d: %v", accountID, err)
+	}
+
+	// Drop the record cache, detected changes and pending domain deletions of the account
+	for _, table := range []string{"record_cache", "record_cache_zones", "record_changes", "domain_pending_deletions"} {
+		if _, err := database.DB.Exec("DELETE FROM "+table+" WHERE account_id = ? AND user_id = ?", accountID, userID); err != nil {
+			log.Printf("Warning: failed to delete %s rows for account %d: %v", table, accountID, err)
+		}
+	}
+
 	// Then delete the account
 	result, err := database.DB.Exec("DELETE FROM accounts WHERE id = ? AND user_id = ?", accountID, userID)
 	if err != nil {
@@ -112,5 +204,6 @@ func (s *AccountService) Delete(userID, accountID int64) error {
 	if rows == 0 {
 		return errors.New("account not found")
 	}
+	provider.ResetAccount(accountID)
 	return nil
 }
@@ -2,7 +2,6 @@ package service
 
 import (
 	"context"
-	"errors"
 	"strings"
 
 	"dns-mng/models"
@@ -16,69 +15,8 @@ func NewAcmeService(dns *DNSService) *AcmeService {
 	return &AcmeService{dns: dns}
 }
 
-type acmeDomainMatch struct {
-	accountID  int64
-	domainID   string
-	domainName string
-	nodeName   string
-}
-
-func normalizeFQDN(fqdn string) string {
-	s := strings.TrimSpace(strings.ToLower(fqdn))
-	s = strings.TrimSuffix(s, ".")
-	return s
-}
-
-func (s *AcmeService) matchDomain(ctx context.Context, userID int64, fqdn string) (*acmeDomainMatch, error) {
-	fqdn = normalizeFQDN(fqdn)
-	if fqdn == "" || !strings.Contains(fqdn, ".") {
-		return nil, errors.New("invalid fqdn")
-	}
-
-	// Prefer cache, but will fallback to provider fetch if cache empty.
-	domains, err := s.dns.ListAllDomainsFromCache(ctx, userID)
-	if err != nil {
-		return nil, err
-	}
-	if len(domains) == 0 {
-		return nil, errors.New("no domains available for user")
-	}
-
-	var best *acmeDomainMatch
-	bestLen := -1
-
-	for _, d := range domains {
-		root := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(d.Name), "."))
-		if root == "" {
-			continue
-		}
-
-		if fqdn == root || strings.HasSuffix(fqdn, "."+root) {
-			if len(root) > bestLen {
-				relative := ""
-				if fqdn != root {
-					relative = strings.TrimSuffix(fqdn, "."+root)
-					relative = strings.TrimSuffix(relative, ".")
-				}
-				best = &acmeDomainMatch{
-					accountID:  d.AccountID,
-					domainID:   d.ID,
-					domainName: root,
-					nodeName:   relative,
-				}
-				bestLen = len(root)
-			}
-		}
-	}
-
-	if best == nil {
-		return nil, errors.New("no matching domain found for fqdn")
-	}
-	return best, nil
-}
-
-func (s *AcmeService) Present(ctx context.Context, userID int64, req *models.AcmeDNS01Request) (*models.AcmeDNS01Response, error) {
-	match, err := s.matchDomain(ctx, userID, req.FQDN)
+func (s *AcmeService) Present(ctx context.Context, userID int64, req *models.AcmeDNS01Request, token *models.APIToken) (*models.AcmeDNS01Response, error) {
+	match, err := s.matchDomain(ctx, userID, req, token)
 	if err != nil {
 		return nil, err
 	}
@@ -91,8 +29,8 @@ func (s *AcmeService) Present(ctx context.Context, userID int64, req *models.Acm
 	}
 
 	state := true
-	_, err = s.dns.CreateRecord(ctx, userID, match.accountID, match.domainID, &models.CreateRecordRequest{
-		NodeName:   match.nodeName,
+	_, err = s.dns.CreateRecord(ctx, userID, match.AccountID, match.DomainID, &models.CreateRecordRequest{
+		NodeName:   match.NodeName,
 		RecordType: "TXT",
 		Content:    req.Value,
 		TTL:        ttl,
@@ -101,16 +39,16 @@ func (s *AcmeService) Present(ctx context.Context, userID int64, req *models.Acm
 	if err != nil {
 		// 如果盲插失败（通常是因为并发重试导致 API 报“记录已存在”或者其他原因），
 		// 降级查询真实记录，如果记录其实已经成功存在了，就忽略插入错误。
-		records, listErr := s.dns.ListRecords(ctx, userID, match.accountID, match.domainID)
+		records, listErr := s.dns.ListRecords(ctx, userID, match.AccountID, match.DomainID)
 		if listErr == nil {
 			for _, r := range records {
 				if strings.EqualFold(r.RecordType, "TXT") &&
-					strings.EqualFold(r.NodeName, match.nodeName) &&
+					strings.EqualFold(r.NodeName, match.NodeName) &&
 					r.Content == req.Value {
 					return &models.AcmeDNS01Response{
 						Status:   "ok",
-						Domain:   match.domainName,
-						NodeName: match.nodeName,
+						Domain:   match.DomainName,
+						NodeName: match.NodeName,
 					}, nil
 				}
 			}
@@ -119,35 +57,66 @@ func (s *AcmeService) Present(ctx context.Context, userID int64, req *models.Acm
 		return nil, err
 	}
 
+	s.emitChallenge(userID, match, models.WebhookEventACMEPresent, req)
 	return &models.AcmeDNS01Response{
 		Status:   "ok",
-		Domain:   match.domainName,
-		NodeName: match.nodeName,
+		Domain:   match.DomainName,
+		NodeName: match.NodeName,
 	}, nil
 }
 
-func (s *AcmeService) Cleanup(ctx context.Context, userID int64, req *models.AcmeDNS01Request) (*models.AcmeDNS01Response, error) {
-	match, err := s.matchDomain(ctx, userID, req.FQDN)
+// matchDomain finds the zone for the challenge FQDN. With an API token the
+// host being validated must be within the token's account and domain
+// restrictions, so a token for foo.example.com may solve its own challenge
+// in the example.com zone.
+func (s *AcmeService) matchDomain(ctx context.Context, userID int64, req *models.AcmeDNS01Request, token *models.APIToken) (*DomainMatch, error) {
+	match, err := s.dns.MatchDomain(ctx, userID, req.FQDN)
+	if err != nil {
+		return nil, err
+	}
+	if !apiTokenAllowsZone(token, match.AccountID, acmeChallengeHost(req.FQDN)) {
+		return nil, ErrAPITokenScope
+	}
+	return match, nil
+}
+
+// acmeChallengeHost returns the host a DNS-01 challenge name validates
+func acmeChallengeHost(fqdn string) string {
+	return strings.TrimPrefix(normalizeFQDN(fqdn), "_acme-challenge.")
+}
+
+// emitChallenge reports a DNS-01 challenge record to the user's webhooks
+func (s *AcmeService) emitChallenge(userID int64, match *DomainMatch, event string, req *models.AcmeDNS01Request) {
+	s.dns.emit(userID, match.AccountID, match.DomainID, match.DomainName, event, map[string]interface{}{
+		"fqdn":      normalizeFQDN(req.FQDN),
+		"node_name": match.NodeName,
+		"value":     req.Value,
+	})
+}
+
+func (s *AcmeService) Cleanup(ctx context.Context, userID int64, req *models.AcmeDNS01Request, token *models.APIToken) (*models.AcmeDNS01Response, error) {
+	match, err := s.matchDomain(ctx, userID, req, token)
 	if err != nil {
 		return nil, err
 	}
 
-	records, err := s.dns.ListRecords(ctx, userID, match.accountID, match.domainID)
+	records, err := s.dns.ListRecords(ctx, userID, match.AccountID, match.DomainID)
 	if err != nil {
 		return nil, err
 	}
 
 	for _, r := range records {
 		if strings.EqualFold(r.RecordType, "TXT") &&
-			strings.EqualFold(r.NodeName, match.nodeName) &&
+			strings.EqualFold(r.NodeName, match.NodeName) &&
 			r.Content == req.Value {
-			_ = s.dns.DeleteRecord(ctx, userID, match.accountID, match.domainID, r.ID)
+			_ = s.dns.DeleteRecord(ctx, userID, match.AccountID, match.DomainID, r.ID)
 		}
 	}
+	s.emitChallenge(userID, match, models.WebhookEventACMECleanup, req)
 
 	return &models.AcmeDNS01Response{
 		Status:   "ok",
-		Domain:   match.domainName,
-		NodeName: match.nodeName,
+		Domain:   match.DomainName,
+		NodeName: match.NodeName,
 	}, nil
 }
@@ -0,0 +1,368 @@
+package service
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"dns-mng/database"
+	"dns-mng/models"
+)
+
+// APITokenPrefix marks personal API tokens so AuthMiddleware can tell them
+// apart from JWTs and ACME Basic Auth from passwords
+const APITokenPrefix = "dnsm_"
+
+var (
+	ErrInvalidAPIToken      = errors.New("invalid api token")
+	ErrAPITokenExpired      = errors.New("api token has expired")
+	ErrAPITokenNotFound     = errors.New("api token not found")
+	ErrAPITokenScope        = errors.New("api token scope does not allow this request")
+	ErrInvalidAPITokenScope = errors.New("invalid api token scope")
+)
+
+// GET routes a read token may use. Routes that return stored secrets,
+// manage tokens or sessions, expose logs, or refresh caches are left out, as
+// is every route added later until it is listed here.
+var apiTokenReadRoutes = map[string]bool{
+	"/api/user/profile":                                   true,
+	"/api/scheduler-logs":                                 true,
+	"/api/scheduler-logs/:taskName":                       true,
+	"/api/scheduler/jobs":                                 true,
+	"/api/domains":                                        true,
+	"/api/domains/pending-deletions":                      true,
+	"/api/accounts":                                       true,
+	"/api/accounts/:id/capabilities":                      true,
+	"/api/accounts/:id/domains":                           true,
+	"/api/accounts/:id/domains/:domainId":                 true,
+	"/api/accounts/:id/domains/:domainId/nameservers":     true,
+	"/api/accounts/:id/domains/:domainId/lines":           true,
+	"/api/accounts/:id/domains/:domainId/notification":    true,
+	"/api/accounts/:id/domains/:domainId/records":         true,
+	"/api/accounts/:id/domains/:domainId/records/changes": true,
+	"/api/accounts/:id/domains/:domainId/zonefile":        true,
+	"/api/cache/stats":                                    true,
+	"/api/notifications":                                  true,
+	"/api/email/config":                                   true,
+	"/api/notification-channel-types":                     true,
+	"/api/notification-channels":                          true,
+	"/api/webhooks":                                       true,
+	"/api/webhook-events":                                 true,
+	"/api/webhooks/:id/deliveries":                        true,
+	"/api/ddns-tokens":                                    true,
+	"/api/ddns-token":                                     true,
+	"/api/ddns-history":                                   true,
+	"/api/ddns-agents":                                    true,
+	"/api/whois/config":                                   true,
+	"/api/whois/query":                                    true,
+	"/api/cf-optimize":                                    true,
+	"/api/dnshe/accounts":                                 true,
+	"/api/dnshe/accounts/:id/quota":                       true,
+	"/api/dnshe/auto-renew":                               true,
+}
+
+// Record routes reachable with records:write, any method. They all carry
+// :id and :domainId, which the account and domain restrictions check.
+var apiTokenRecordRoutes = map[string]bool{
+	"/api/accounts/:id/domains/:domainId/records":                   true,
+	"/api/accounts/:id/domains/:domainId/records/changes":           true,
+	"/api/accounts/:id/domains/:domainId/records/batch":             true,
+	"/api/accounts/:id/domains/:domainId/records/:recordId":         true,
+	"/api/accounts/:id/domains/:domainId/records/:recordId/proxied": true,
+	"/api/accounts/:id/domains/:domainId/zonefile":                  true,
+	"/api/accounts/:id/domains/:domainId/zonefile/import":           true,
+}
+
+type APITokenService struct {
+	userService *UserService
+}
+
+func NewAPITokenService(userService *UserService) *APITokenService {
+	return &APITokenService{userService: userService}
+}
+
+// IsAPIToken reports whether value looks like a personal API token
+func IsAPIToken(value string) bool {
+	return strings.HasPrefix(value, APITokenPrefix)
+}
+
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	return hex.EncodeToString(sum[:])
+}
+
+const apiTokenColumns = `id, user_id, name, prefix, scopes, account_ids, domains,
+	expires_at, last_used_at, last_ip, created_at`
+
+func scanAPIToken(row rowScanner) (*models.APIToken, error) {
+	var token models.APIToken
+	var scopes, accountIDs, domains string
+	var expiresAt, lastUsedAt sql.NullTime
+	err := row.Scan(
+		&token.ID, &token.UserID, &token.Name, &token.Prefix, &scopes, &accountIDs, &domains,
+		&expiresAt, &lastUsedAt, &token.LastIP, &token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	token.Scopes = splitList(scopes)
+	token.Domains = splitList(domains)
+	token.AccountIDs = []int64{}
+	for _, s := range splitList(accountIDs) {
+		if id, err := strconv.ParseInt(s, 10, 64); err == nil {
+			token.AccountIDs = append(token.AccountIDs, id)
+		}
+	}
+	if expiresAt.Valid {
+		token.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+	return &token, nil
+}
+
+// ListTokens returns the user's API tokens, newest first
+func (s *APITokenService) ListTokens(userID int64) ([]models.APIToken, error) {
+	rows, err := database.DB.Query(
+		"SELECT "+apiTokenColumns+" FROM api_tokens WHERE user_id = ? ORDER BY id DESC",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []models.APIToken{}
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *token)
+	}
+	return tokens, rows.Err()
+}
+
+// CreateToken issues a new API token. The token value is only returned here.
+func (s *APITokenService) CreateToken(userID int64, req *models.CreateAPITokenRequest) (*models.APIToken, error) {
+	scopes, err := normalizeAPITokenScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	accountIDs, err := s.normalizeAPITokenAccounts(userID, req.AccountIDs)
+	if err != nil {
+		return nil, err
+	}
+	domains, err := normalizeAPITokenDomains(req.Domains)
+	if err != nil {
+		return nil, err
+	}
+	if req.ExpiresInDays < 0 {
+		return nil, fmt.Errorf("%w: expires_in_days must not be negative", ErrInvalidAPITokenScope)
+	}
+
+	value := APITokenPrefix + generateRandomToken()[:40]
+	now := time.Now()
+	token := &models.APIToken{
+		UserID:     userID,
+		Name:       strings.TrimSpace(req.Name),
+		Token:      value,
+		Prefix:     value[:len(APITokenPrefix)+6],
+		Scopes:     scopes,
+		AccountIDs: accountIDs,
+		Domains:    domains,
+		CreatedAt:  now,
+	}
+	var expiresAt interface{}
+	if req.ExpiresInDays > 0 {
+		t := now.AddDate(0, 0, req.ExpiresInDays)
+		token.ExpiresAt = &t
+		expiresAt = t
+	}
+
+	ids := make([]string, len(accountIDs))
+	for i, id := range accountIDs {
+		ids[i] = strconv.FormatInt(id, 10)
+	}
+	result, err := database.DB.Exec(
+		`INSERT INTO api_tokens (user_id, name, token_hash, prefix, scopes, account_ids, domains, expires_at, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		userID, token.Name, hashAPIToken(value), token.Prefix, strings.Join(scopes, ","),
+		strings.Join(ids, ","), strings.Join(domains, ","), expiresAt, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	token.ID, _ = result.LastInsertId()
+	return token, nil
+}
+
+// DeleteToken revokes one of the user's API tokens
+func (s *APITokenService) DeleteToken(userID, id int64) error {
+	result, err := database.DB.Exec("DELETE FROM api_tokens WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrAPITokenNotFound
+	}
+	return nil
+}
+
+// Authenticate resolves a token value, rejecting expired tokens and
+// disabled users, and records the use
+func (s *APITokenService) Authenticate(value, ip string) (*models.APIToken, *models.User, error) {
+	token, err := scanAPIToken(database.DB.QueryRow(
+		"SELECT "+apiTokenColumns+" FROM api_tokens WHERE token_hash = ?",
+		hashAPIToken(value),
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil, ErrInvalidAPIToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if token.ExpiresAt != nil && !time.Now().Before(*token.ExpiresAt) {
+		return nil, nil, ErrAPITokenExpired
+	}
+
+	user, err := s.userService.GetUser(token.UserID)
+	if err == sql.ErrNoRows {
+		return nil, nil, ErrInvalidAPIToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.Disabled {
+		return nil, nil, ErrUserDisabled
+	}
+
+	if _, err := database.DB.Exec(
+		"UPDATE api_tokens SET last_used_at = ?, last_ip = ? WHERE id = ?",
+		time.Now(), ip, token.ID,
+	); err != nil {
+		return nil, nil, err
+	}
+	return token, user, nil
+}
+
+// HasScope reports whether the token carries scope
+func (s *APITokenService) HasScope(token *models.APIToken, scope string) bool {
+	return slices.Contains(token.Scopes, scope)
+}
+
+// AllowsRequest decides whether a token may call a protected route.
+// fullPath is the route pattern; accountID and domainID are its :id and
+// :domainId parameters.
+func (s *APITokenService) AllowsRequest(token *models.APIToken, method, fullPath, accountID, domainID string) bool {
+	if apiTokenRecordRoutes[fullPath] && s.HasScope(token, models.APITokenScopeRecordsWrite) {
+		id, err := strconv.ParseInt(accountID, 10, 64)
+		if err != nil {
+			return false
+		}
+		return apiTokenAllowsZone(token, id, s.zoneName(token.UserID, id, domainID))
+	}
+	if method == http.MethodGet && s.HasScope(token, models.APITokenScopeRead) {
+		return apiTokenReadRoutes[fullPath]
+	}
+	return false
+}
+
+// zoneName resolves a provider domain ID through the domain cache. Many
+// providers use the zone name as its ID, so that is the fallback.
+func (s *APITokenService) zoneName(userID, accountID int64, domainID string) string {
+	var name string
+	err := database.DB.QueryRow(
+		"SELECT domain_name FROM domain_cache WHERE user_id = ? AND account_id = ? AND domain_id = ?",
+		userID, accountID, domainID,
+	).Scan(&name)
+	if err != nil {
+		return domainID
+	}
+	return name
+}
+
+// apiTokenAllowsZone checks the token's account and domain restrictions for
+// a zone or a host name. A listed domain also covers names below it. A nil
+// token allows everything.
+func apiTokenAllowsZone(token *models.APIToken, accountID int64, zone string) bool {
+	if token == nil {
+		return true
+	}
+	if len(token.AccountIDs) > 0 && !slices.Contains(token.AccountIDs, accountID) {
+		return false
+	}
+	if len(token.Domains) == 0 {
+		return true
+	}
+	zone = normalizeFQDN(zone)
+	for _, d := range token.Domains {
+		if zone == d || strings.HasSuffix(zone, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeAPITokenScopes(in []string) ([]string, error) {
+	out := []string{}
+	for _, scope := range in {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if scope == "" || slices.Contains(out, scope) {
+			continue
+		}
+		if !slices.Contains(models.APITokenScopes, scope) {
+			return nil, fmt.Errorf("%w: unknown scope %s", ErrInvalidAPITokenScope, scope)
+		}
+		out = append(out, scope)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidAPITokenScope)
+	}
+	return out, nil
+}
+
+// normalizeAPITokenAccounts drops duplicates and rejects accounts the user
+// does not own
+func (s *APITokenService) normalizeAPITokenAccounts(userID int64, in []int64) ([]int64, error) {
+	out := []int64{}
+	for _, id := range in {
+		if slices.Contains(out, id) {
+			continue
+		}
+		var count int
+		if err := database.DB.QueryRow(
+			"SELECT COUNT(*) FROM accounts WHERE id = ? AND user_id = ?", id, userID,
+		).Scan(&count); err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, fmt.Errorf("%w: account %d not found", ErrInvalidAPITokenScope, id)
+		}
+		out = append(out, id)
+	}
+	return out, nil
+}
+
+func normalizeAPITokenDomains(in []string) ([]string, error) {
+	out := []string{}
+	for _, d := range in {
+		d = normalizeFQDN(d)
+		if d == "" || slices.Contains(out, d) {
+			continue
+		}
+		if strings.ContainsAny(d, ", *") || !strings.Contains(d, ".") {
+			return nil, fmt.Errorf("%w: invalid domain %s", ErrInvalidAPITokenScope, d)
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
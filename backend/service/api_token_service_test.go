@@ -0,0 +1,139 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+
+	"dns-mng/database"
+	"dns-mng/models"
+)
+
+func TestAPITokenAllowsRequest(t *testing.T) {
+	openTestDB(t)
+	if _, err := database.DB.Exec(`INSERT INTO users (id, username, password_hash) VALUES (1, 'admin', 'x')`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.DB.Exec(
+		`INSERT INTO domain_cache (user_id, account_id, domain_id, domain_name) VALUES (1, 3, 'z1', 'example.com'), (1, 3, 'z2', 'example.net')`,
+	); err != nil {
+		t.Fatal(err)
+	}
+	s := NewAPITokenService(nil)
+
+	const records = "/api/accounts/:id/domains/:domainId/records"
+	read := &models.APIToken{UserID: 1, Scopes: []string{models.APITokenScopeRead}}
+	write := &models.APIToken{UserID: 1, Scopes: []string{models.APITokenScopeRecordsWrite}}
+	acme := &models.APIToken{UserID: 1, Scopes: []string{models.APITokenScopeACME}}
+	restricted := &models.APIToken{
+		UserID:     1,
+		Scopes:     []string{models.APITokenScopeRecordsWrite},
+		AccountIDs: []int64{3},
+		Domains:    []string{"example.com"},
+	}
+
+	tests := []struct {
+		name      string
+		token     *models.APIToken
+		method    string
+		route     string
+		accountID string
+		domainID  string
+		want      bool
+	}{
+		{"read lists domains", read, http.MethodGet, "/api/domains", "", "", true},
+		{"read lists records", read, http.MethodGet, records, "3", "z1", true},
+		{"read exports zone file", read, http.MethodGet, "/api/accounts/:id/domains/:domainId/zonefile", "3", "z1", true},
+		{"read cannot create records", read, http.MethodPost, records, "3", "z1", false},
+		{"read cannot update accounts", read, http.MethodPut, "/api/accounts/:id", "3", "", false},
+		{"read cannot reveal api key", read, http.MethodGet, "/api/accounts/:id/api-key", "3", "", false},
+		{"read cannot reveal ddns token", read, http.MethodGet, "/api/ddns-tokens/:id/token", "1", "", false},
+		{"read cannot export backups", read, http.MethodGet, "/api/backup/export", "", "", false},
+		{"read cannot list api tokens", read, http.MethodGet, "/api/api-tokens", "", "", false},
+		{"read cannot read api logs", read, http.MethodGet, "/api/api-logs", "", "", false},
+		{"read cannot read login logs", read, http.MethodGet, "/api/login-logs", "", "", false},
+		{"read cannot list sessions", read, http.MethodGet, "/api/user/sessions", "", "", false},
+		{"read cannot read totp", read, http.MethodGet, "/api/user/totp", "", "", false},
+		{"read cannot refresh domains", read, http.MethodGet, "/api/domains/refresh", "", "", false},
+		{"read cannot refresh account domains", read, http.MethodGet, "/api/accounts/:id/domains/refresh", "3", "", false},
+		{"read cannot refresh cf optimize", read, http.MethodGet, "/api/cf-optimize/:id/refresh", "1", "", false},
+		{"read denies unknown routes", read, http.MethodGet, "/api/some/new/route", "", "", false},
+		{"read denies admin routes", read, http.MethodGet, "/api/admin/users", "", "", false},
+
+		{"write creates records", write, http.MethodPost, records, "3", "z1", true},
+		{"write deletes records", write, http.MethodDelete, records + "/:recordId", "3", "z1", true},
+		{"write imports zone file", write, http.MethodPost, "/api/accounts/:id/domains/:domainId/zonefile/import", "3", "z1", true},
+		{"write cannot read elsewhere", write, http.MethodGet, "/api/domains", "", "", false},
+		{"write cannot delete zones", write, http.MethodDelete, "/api/accounts/:id/domains/:domainId", "3", "z1", false},
+		{"write needs a numeric account", write, http.MethodPost, records, "x", "z1", false},
+
+		{"restricted zone allowed", restricted, http.MethodPost, records, "3", "z1", true},
+		{"restricted other zone", restricted, http.MethodPost, records, "3", "z2", false},
+		{"restricted other account", restricted, http.MethodPost, records, "4", "z1", false},
+		{"restricted uncached id falls back to name", restricted, http.MethodPost, records, "3", "www.example.com", true},
+
+		{"acme has no api routes", acme, http.MethodGet, "/api/domains", "", "", false},
+		{"acme cannot write records", acme, http.MethodPost, records, "3", "z1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.AllowsRequest(tt.token, tt.method, tt.route, tt.accountID, tt.domainID)
+			if got != tt.want {
+				t.Errorf("AllowsRequest(%s %s) = %v, want %v", tt.method, tt.route, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPITokenAllowsZone(t *testing.T) {
+	token := &models.APIToken{AccountIDs: []int64{3}, Domains: []string{"example.com", "foo.example.net"}}
+	open := &models.APIToken{}
+
+	tests := []struct {
+		name      string
+		token     *models.APIToken
+		accountID int64
+		zone      string
+		want      bool
+	}{
+		{"nil token", nil, 9, "anything.test", true},
+		{"unrestricted token", open, 9, "anything.test", true},
+		{"exact zone", token, 3, "example.com", true},
+		{"case and trailing dot", token, 3, "Example.COM.", true},
+		{"subdomain", token, 3, "www.example.com", true},
+		{"suffix without dot", token, 3, "badexample.com", false},
+		{"other zone", token, 3, "example.org", false},
+		{"other account", token, 4, "example.com", false},
+		{"parent of listed host", token, 3, "example.net", false},
+		{"listed host", token, 3, "foo.example.net", true},
+		{"sibling of listed host", token, 3, "bar.example.net", false},
+	}
+
+	for _, tt := range tests {
+		if got := apiTokenAllowsZone(tt.token, tt.accountID, tt.zone); got != tt.want {
+			t.Errorf("%s: apiTokenAllowsZone(%d, %s) = %v, want %v", tt.name, tt.accountID, tt.zone, got, tt.want)
+		}
+	}
+}
+
+func TestACMEChallengeHostRestriction(t *testing.T) {
+	token := &models.APIToken{Domains: []string{"foo.example.com"}}
+
+	tests := []struct {
+		fqdn string
+		want bool
+	}{
+		{"_acme-challenge.foo.example.com.", true},
+		{"_ACME-Challenge.Foo.Example.com", true},
+		{"_acme-challenge.sub.foo.example.com", true},
+		{"_acme-challenge.bar.example.com", false},
+		{"_acme-challenge.example.com", false},
+	}
+
+	for _, tt := range tests {
+		// The zone is example.com, which the token does not cover by itself
+		if got := apiTokenAllowsZone(token, 1, acmeChallengeHost(tt.fqdn)); got != tt.want {
+			t.Errorf("challenge %s allowed = %v, want %v", tt.fqdn, got, tt.want)
+		}
+	}
+}
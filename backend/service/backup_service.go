@@ -16,7 +16,8 @@ import (
 type backupData struct {
 	Accounts          []backupAccount          `json:"accounts"`
 	DomainCaches      []backupDomainCache      `json:"domain_caches"`
-	DDNSToken         *backupDDNSToken         `json:"ddns_token"`
+	DDNSToken         *backupDDNSToken         `json:"ddns_token"` // 旧版单 token，保留兼容
+	DDNSTokens        []backupDDNSToken        `json:"ddns_tokens,omitempty"`
 	EmailConfig       *backupEmailConfig       `json:"email_config"`
 	WHOISConfig       *backupWHOISConfig       `json:"whois_config,omitempty"`
 	DNSHEAutoRenew    *backupDNSHEAutoRenew    `json:"dnshe_auto_renew,omitempty"`
@@ -46,8 +47,13 @@ type backupDomainCache struct {
 }
 
 type backupDDNSToken struct {
-	Token   string `json:"token"`
-	Enabled bool   `json:"enabled"`
+	Name        string   `json:"name,omitempty"`
+	Token       string   `json:"token"`
+	Enabled     bool     `json:"enabled"`
+	Hostnames   []string `json:"hostnames,omitempty"`
+	RecordTypes []string `json:"record_types,omitempty"`
+	AllowedIPs  []string `json:"allowed_ips,omitempty"`
+	ExpiresAt   string   `json:"expires_at,omitempty"`
 }
 
 type backupEmailConfig struct {
@@ -107,6 +113,8 @@ type ImportResult struct {
 	DomainCachesSkipped    int  `json:"domain_caches_skipped"`
 	DDNSTokenImported      bool `json:"ddns_token_imported"`
 	DDNSTokenSkipped       bool `json:"ddns_token_skipped"`
+	DDNSTokensImported     int  `json:"ddns_tokens_imported"`
+	DDNSTokensSkipped      int  `json:"ddns_tokens_skipped"`
 	EmailConfigImported    bool `json:"email_config_imported"`
 	EmailConfigSkipped     bool `json:"email_config_skipped"`
 	WHOISConfigImported    bool `json:"whois_config_imported"`
@@ -214,13 +222,25 @@ func (s *BackupService) Export(userID int64, password string) ([]byte, error) {
 		data.DomainCaches = append(data.DomainCaches, entry)
 	}
 
-	// 3. DDNS Token
-	token, err := s.ddnsTokenService.GetToken(userID)
+	// 3. DDNS Tokens（ddns_token 字段保留第一个 token，兼容旧版导入）
+	tokens, err := s.ddnsTokenService.ListTokens(userID)
 	if err != nil {
-		return nil, fmt.Errorf("export ddns token: %w", err)
+		return nil, fmt.Errorf("export ddns tokens: %w", err)
+	}
+	for _, token := range tokens {
+		data.DDNSTokens = append(data.DDNSTokens, backupDDNSToken{
+			Name:        token.Name,
+			Token:       token.Token,
+			Enabled:     token.Enabled,
+			Hostnames:   token.Hostnames,
+			RecordTypes: token.RecordTypes,
+			AllowedIPs:  token.AllowedIPs,
+			ExpiresAt:   token.ExpiresAt,
+		})
 	}
-	if token != nil {
-		data.DDNSToken = &backupDDNSToken{Token: token.Token, Enabled: token.Enabled}
+	if len(data.DDNSTokens) > 0 {
+		legacy := data.DDNSTokens[0]
+		data.DDNSToken = &legacy
 	}
 
 	// 4. 邮件配置（含密码）
@@ -324,10 +344,16 @@ func (s *BackupService) Import(userID int64, fileBytes []byte, password string,
 			continue
 		}
 
+		apiKey, err := EncryptSecret(acc.APIKey)
+		if err != nil {
+			return nil, fmt.Errorf("encrypt account %q: %w", acc.Name, err)
+		}
+
 		if existingID > 0 && overwrite {
+			// Structured credentials are derived again from the new api_key
 			_, err := tx.Exec(
-				"UPDATE accounts SET api_key = ?, updated_at = ? WHERE id = ? AND user_id = ?",
-				acc.APIKey, time.Now(), existingID, userID,
+				"UPDATE accounts SET api_key = ?, credentials = '', updated_at = ? WHERE id = ? AND user_id = ?",
+				apiKey, time.Now(), existingID, userID,
 			)
 			if err != nil {
 				return nil, fmt.Errorf("update account %q: %w", acc.Name, err)
@@ -339,7 +365,7 @@ func (s *BackupService) Import(userID int64, fileBytes []byte, password string,
 
 		res, err := tx.Exec(
 			"INSERT INTO accounts (user_id, name, provider_type, api_key, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
-			userID, acc.Name, acc.ProviderType, acc.APIKey, time.Now(), time.Now(),
+			userID, acc.Name, acc.ProviderType, apiKey, time.Now(), time.Now(),
 		)
 		if err != nil {
 			return nil, fmt.Errorf("insert account %q: %w", acc.Name, err)
@@ -410,41 +436,56 @@ func (s *BackupService) Import(userID int64, fileBytes []byte, password string,
 		}
 	}
 
-	if file.Data.DDNSToken != nil {
-		existing, err := s.findDDNSToken(userID, tx)
+	// 新版备份使用 ddns_tokens；旧版只有单个 ddns_token
+	ddnsTokens := file.Data.DDNSTokens
+	if len(ddnsTokens) == 0 && file.Data.DDNSToken != nil {
+		ddnsTokens = []backupDDNSToken{*file.Data.DDNSToken}
+	}
+	for _, t := range ddnsTokens {
+		if strings.TrimSpace(t.Token) == "" {
+			result.DDNSTokensSkipped++
+			continue
+		}
+		ownerID, err := s.findDDNSTokenOwner(t.Token, tx)
 		if err != nil {
-			return nil, fmt.Errorf("check ddns token: %w", err)
+			return nil, fmt.Errorf("check ddns token owner: %w", err)
+		}
+		if (ownerID > 0 && ownerID != userID) || (ownerID == userID && !overwrite) {
+			result.DDNSTokensSkipped++
+			continue
 		}
 
-		if existing != "" && !overwrite {
-			result.DDNSTokenSkipped = true
+		name := t.Name
+		if name == "" {
+			name = "default"
+		}
+		enabled := backupBoolToInt(t.Enabled)
+		hostnames := strings.Join(t.Hostnames, ",")
+		recordTypes := strings.Join(t.RecordTypes, ",")
+		allowedIPs := strings.Join(t.AllowedIPs, ",")
+		if ownerID == userID {
+			_, err = tx.Exec(
+				`UPDATE ddns_tokens SET name = ?, enabled = ?, hostnames = ?, record_types = ?, allowed_ips = ?,
+					expires_at = NULLIF(?, ''), updated_at = datetime('now') WHERE token_hash = ?`,
+				name, enabled, hostnames, recordTypes, allowedIPs, t.ExpiresAt, hashDDNSToken(t.Token),
+			)
 		} else {
-			ownerID, err := s.findDDNSTokenOwner(file.Data.DDNSToken.Token, tx)
-			if err != nil {
-				return nil, fmt.Errorf("check ddns token owner: %w", err)
-			}
-			if ownerID > 0 && ownerID != userID {
-				result.DDNSTokenSkipped = true
-			} else {
-				enabled := backupBoolToInt(file.Data.DDNSToken.Enabled)
-				if existing != "" {
-					_, err = tx.Exec(
-						"UPDATE ddns_tokens SET token = ?, enabled = ?, updated_at = datetime('now') WHERE user_id = ?",
-						file.Data.DDNSToken.Token, enabled, userID,
-					)
-				} else {
-					_, err = tx.Exec(
-						"INSERT INTO ddns_tokens (user_id, token, enabled, created_at, updated_at) VALUES (?, ?, ?, datetime('now'), datetime('now'))",
-						userID, file.Data.DDNSToken.Token, enabled,
-					)
-				}
-				if err != nil {
-					return nil, fmt.Errorf("import ddns token: %w", err)
-				}
-				result.DDNSTokenImported = true
+			var stored string
+			if stored, err = EncryptSecret(t.Token); err == nil {
+				_, err = tx.Exec(
+					`INSERT INTO ddns_tokens (user_id, name, token, token_hash, enabled, hostnames, record_types, allowed_ips, expires_at, created_at, updated_at)
+						VALUES (?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), datetime('now'), datetime('now'))`,
+					userID, name, stored, hashDDNSToken(t.Token), enabled, hostnames, recordTypes, allowedIPs, t.ExpiresAt,
+				)
 			}
 		}
+		if err != nil {
+			return nil, fmt.Errorf("import ddns token %s: %w", name, err)
+		}
+		result.DDNSTokensImported++
 	}
+	result.DDNSTokenImported = result.DDNSTokensImported > 0
+	result.DDNSTokenSkipped = result.DDNSTokensImported == 0 && result.DDNSTokensSkipped > 0
 
 	if file.Data.EmailConfig != nil {
 		ec := file.Data.EmailConfig
@@ -458,12 +499,16 @@ func (s *BackupService) Import(userID int64, fileBytes []byte, password string,
 		} else {
 			enabled := backupBoolToInt(ec.Enabled)
 			now := time.Now()
+			password, err := EncryptSecret(ec.SMTPPassword)
+			if err != nil {
+				return nil, fmt.Errorf("encrypt email config: %w", err)
+			}
 			if existing {
 				_, err = tx.Exec(
 					`UPDATE email_config SET smtp_host=?, smtp_port=?, smtp_username=?, smtp_password=?,
 					 from_email=?, from_name=?, to_email=?, language=?, enabled=?, updated_at=?
 					 WHERE user_id=?`,
-					ec.SMTPHost, ec.SMTPPort, ec.SMTPUsername, ec.SMTPPassword,
+					ec.SMTPHost, ec.SMTPPort, ec.SMTPUsername, password,
 					ec.FromEmail, ec.FromName, ec.ToEmail, ec.Language, enabled, now, userID,
 				)
 			} else {
@@ -471,7 +516,7 @@ func (s *BackupService) Import(userID int64, fileBytes []byte, password string,
 					`INSERT INTO email_config (user_id, smtp_host, smtp_port, smtp_username, smtp_password,
 					 from_email, from_name, to_email, language, enabled, created_at, updated_at)
 					 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-					userID, ec.SMTPHost, ec.SMTPPort, ec.SMTPUsername, ec.SMTPPassword,
+					userID, ec.SMTPHost, ec.SMTPPort, ec.SMTPUsername, password,
 					ec.FromEmail, ec.FromName, ec.ToEmail, ec.Language, enabled, now, now,
 				)
 			}
@@ -551,21 +596,12 @@ func (s *BackupService) findAccountByKey(userID int64, key string, tx *sql.Tx) (
 	return id, err
 }
 
-func (s *BackupService) findDDNSToken(userID int64, tx *sql.Tx) (string, error) {
-	var token string
-	err := tx.QueryRow("SELECT token FROM ddns_tokens WHERE user_id = ?", userID).Scan(&token)
-	if err == sql.ErrNoRows {
-		return "", nil
-	}
-	return token, err
-}
-
 func (s *BackupService) findDDNSTokenOwner(token string, tx *sql.Tx) (int64, error) {
 	if strings.TrimSpace(token) == "" {
 		return 0, nil
 	}
 	var userID int64
-	err := tx.QueryRow("SELECT user_id FROM ddns_tokens WHERE token = ?", token).Scan(&userID)
+	err := tx.QueryRow("SELECT user_id FROM ddns_tokens WHERE token_hash = ?", hashDDNSToken(token)).Scan(&userID)
 	if err == sql.ErrNoRows {
 		return 0, nil
 	}
@@ -651,6 +687,9 @@ func (s *BackupService) getWHOISConfig(userID int64) (*backupWHOISConfig, error)
 	if err != nil {
 		return nil, err
 	}
+	if apiKey, err = DecryptSecret(apiKey); err != nil {
+		return nil, err
+	}
 	return &backupWHOISConfig{APIKey: apiKey}, nil
 }
 
@@ -737,11 +776,15 @@ func (s *BackupService) importWHOISConfig(tx *sql.Tx, userID int64, cfg *backupW
 	if err == nil && !overwrite {
 		return false, true, nil
 	}
+	apiKey, encErr := EncryptSecret(cfg.APIKey)
+	if encErr != nil {
+		return false, false, fmt.Errorf("encrypt whois config: %w", encErr)
+	}
 	now := time.Now()
 	if err == sql.ErrNoRows {
-		_, err = tx.Exec("INSERT INTO whois_config (user_id, api_key, created_at, updated_at) VALUES (?, ?, ?, ?)", userID, cfg.APIKey, now, now)
+		_, err = tx.Exec("INSERT INTO whois_config (user_id, api_key, created_at, updated_at) VALUES (?, ?, ?, ?)", userID, apiKey, now, now)
 	} else if err == nil {
-		_, err = tx.Exec("UPDATE whois_config SET api_key=?, updated_at=? WHERE user_id=?", cfg.APIKey, now, userID)
+		_, err = tx.Exec("UPDATE whois_config SET api_key=?, updated_at=? WHERE user_id=?", apiKey, now, userID)
 	}
 	if err != nil {
 		return false, false, fmt.Errorf("import whois config: %w", err)
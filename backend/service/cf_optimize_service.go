@@ -3,37 +3,164 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"dns-mng/database"
 	"dns-mng/models"
-	"dns-mng/provider/cloudflare"
+	"dns-mng/provider"
 	"fmt"
 	"log"
 	"strings"
 	"time"
 )
 
-// CFOptimizeService handles Cloudflare CDN optimization operations
+// CFOptimizeService handles Cloudflare CDN optimization operations on any
+// provider serving custom hostnames and proxied records. DNS records are
+// written through DNSService, so the record cache, DDNS state and webhooks
+// see them like any other edit.
 type CFOptimizeService struct {
-	client *cloudflare.Client
+	dnsService *DNSService
 }
 
-func NewCFOptimizeService() *CFOptimizeService {
+func NewCFOptimizeService(dnsService *DNSService) *CFOptimizeService {
 	return &CFOptimizeService{
-		client: cloudflare.NewClient(),
+		dnsService: dnsService,
 	}
 }
 
+// cfZone is the zone whose records and custom hostnames a CF optimize
+// config manages
+type cfZone struct {
+	userID, accountID int64
+	id, name          string
+	apiKey            string
+	p                 provider.DNSProvider
+	hostnames         provider.CustomHostnameManager
+}
+
+// newCFZone returns the zone handle for an account, or ErrNotSupported when
+// its provider lacks custom hostnames or proxied records
+func newCFZone(userID int64, account *models.Account, zoneID, zoneName string) (cfZone, error) {
+	p, err := provider.Get(account.ProviderType)
+	if err != nil {
+		return cfZone{}, err
+	}
+	hostnames, ok := p.(provider.CustomHostnameManager)
+	if !ok || !provider.Supports(p, provider.CapProxiedRecords) {
+		return cfZone{}, fmt.Errorf("CDN optimization on %s accounts: %w", account.ProviderType, provider.ErrNotSupported)
+	}
+	return cfZone{
+		userID:    userID,
+		accountID: account.ID,
+		id:        zoneID,
+		name:      zoneName,
+		apiKey:    account.APIKey,
+		p:         p,
+		hostnames: hostnames,
+	}, nil
+}
+
+func (z cfZone) createHostname(ctx context.Context, hostname, origin string) (ch *models.CustomHostname, err error) {
+	err = provider.Do(ctx, z.p, z.accountID, provider.RetryThrottled, func(ctx context.Context) error {
+		ch, err = z.hostnames.CreateCustomHostname(ctx, z.apiKey, z.id, hostname, origin)
+		return err
+	})
+	return ch, err
+}
+
+func (z cfZone) getHostname(ctx context.Context, hostnameID string) (ch *models.CustomHostname, err error) {
+	err = provider.Do(ctx, z.p, z.accountID, provider.RetrySafe, func(ctx context.Context) error {
+		ch, err = z.hostnames.GetCustomHostname(ctx, z.apiKey, z.id, hostnameID)
+		return err
+	})
+	return ch, err
+}
+
+func (z cfZone) deleteHostname(ctx context.Context, hostnameID string) error {
+	return provider.Do(ctx, z.p, z.accountID, provider.RetryThrottled, func(ctx context.Context) error {
+		return z.hostnames.DeleteCustomHostname(ctx, z.apiKey, z.id, hostnameID)
+	})
+}
+
+func (z cfZone) setFallbackOrigin(ctx context.Context, origin string) error {
+	return provider.Do(ctx, z.p, z.accountID, provider.RetrySafe, func(ctx context.Context) error {
+		return z.hostnames.SetFallbackOrigin(ctx, z.apiKey, z.id, origin)
+	})
+}
+
+func (z cfZone) deleteFallbackOrigin(ctx context.Context) error {
+	return provider.Do(ctx, z.p, z.accountID, provider.RetryThrottled, func(ctx context.Context) error {
+		return z.hostnames.DeleteFallbackOrigin(ctx, z.apiKey, z.id)
+	})
+}
+
+// nodeName converts a full record name to a node name in the zone
+func (z cfZone) nodeName(name string) (string, error) {
+	node, ok := relativeName(normalizeFQDN(name), normalizeFQDN(z.name))
+	if !ok {
+		return "", fmt.Errorf("%s is not in zone %s", name, z.name)
+	}
+	if node == "" {
+		node = "@"
+	}
+	return node, nil
+}
+
 // helper to find an existing record by name and type in the slice
-func findRecord(records []cloudflare.Record, name, recordType string) *cloudflare.Record {
-	target := strings.ToLower(strings.TrimSuffix(name, "."))
+func findRecord(records []models.Record, zone cfZone, name, recordType string) *models.Record {
+	node, err := zone.nodeName(name)
+	if err != nil {
+		return nil
+	}
 	for _, r := range records {
-		if strings.ToLower(strings.TrimSuffix(r.Name, ".")) == target && r.Type == recordType {
+		if sameNodeName(r.NodeName, node) && r.RecordType == recordType {
 			return &r
 		}
 	}
 	return nil
 }
 
+// createRecord creates a record with the provider's default TTL, then turns on proxying
+// when asked; the provider creates records unproxied.
+func (s *CFOptimizeService) createRecord(ctx context.Context, zone cfZone, recordType, name, content string, proxied bool) (*models.Record, error) {
+	node, err := zone.nodeName(name)
+	if err != nil {
+		return nil, err
+	}
+	record, err := s.dnsService.CreateRecord(ctx, zone.userID, zone.accountID, zone.id, &models.CreateRecordRequest{
+		NodeName:   node,
+		RecordType: recordType,
+		TTL:        zone.p.DefaultTTL(),
+		Content:    content,
+	})
+	if err != nil || !proxied {
+		return record, err
+	}
+	return s.dnsService.SetRecordProxied(ctx, zone.userID, zone.accountID, zone.id, record.ID, true)
+}
+
+// updateRecord rewrites a record like createRecord. Updates reset proxying
+// at the provider, so it is turned back on afterwards.
+func (s *CFOptimizeService) updateRecord(ctx context.Context, zone cfZone, recordID, recordType, name, content string, proxied bool) (*models.Record, error) {
+	node, err := zone.nodeName(name)
+	if err != nil {
+		return nil, err
+	}
+	record, err := s.dnsService.UpdateRecord(ctx, zone.userID, zone.accountID, zone.id, recordID, &models.UpdateRecordRequest{
+		NodeName:   node,
+		RecordType: recordType,
+		TTL:        zone.p.DefaultTTL(),
+		Content:    content,
+	})
+	if err != nil || !proxied {
+		return record, err
+	}
+	return s.dnsService.SetRecordProxied(ctx, zone.userID, zone.accountID, zone.id, recordID, true)
+}
+
+func (s *CFOptimizeService) deleteRecord(ctx context.Context, zone cfZone, recordID string) error {
+	return s.dnsService.DeleteRecord(ctx, zone.userID, zone.accountID, zone.id, recordID)
+}
+
 // Create performs one-click CDN optimization
 func (s *CFOptimizeService) Create(ctx context.Context, userID int64, accountID int64, req *models.CreateCFOptimizeRequest) (*models.CFOptimize, error) {
 	// 1. Get account and verify ownership
@@ -41,12 +168,11 @@ func (s *CFOptimizeService) Create(ctx context.Context, userID int64, accountID
 	if err != nil {
 		return nil, fmt.Errorf("account not found: %w", err)
 	}
-	if account.ProviderType != "cloudflare" {
-		return nil, fmt.Errorf("account is not a Cloudflare provider (type: %s)", account.ProviderType)
-	}
-
-	apiToken := account.APIKey
 	zoneName := strings.TrimSpace(req.ZoneName)
+	cfz, err := newCFZone(userID, account, "", zoneName)
+	if err != nil {
+		return nil, err
+	}
 	hostname := strings.TrimSpace(req.Hostname)
 	originIP := strings.TrimSpace(req.OriginIP)
 	cnameTarget := strings.TrimSpace(req.CnameTarget)
@@ -92,11 +218,15 @@ func (s *CFOptimizeService) Create(ctx context.Context, userID int64, accountID
 	}
 
 	// 2. Find zone by name
-	zone, err := s.client.GetZoneByName(ctx, apiToken, zoneName)
+	zone, err := s.dnsService.FindZone(ctx, userID, accountID, zoneName)
+	if err == nil && zone == nil {
+		err = errors.New("zone not in account")
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to find zone %s: %w", zoneName, err)
 	}
 	zoneID := zone.ID
+	cfz.id = zoneID
 
 	// 3. Build record names
 	originRecordName := "origin." + zoneName
@@ -114,7 +244,7 @@ func (s *CFOptimizeService) Create(ctx context.Context, userID int64, accountID
 	customHostname := cnameRecordName
 
 	// Fetch all existing records in the zone for smart reuse
-	records, err := s.client.ListRecords(ctx, apiToken, zoneID)
+	records, err := s.dnsService.ListRecords(ctx, userID, account.ID, zoneID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list DNS records: %w", err)
 	}
@@ -122,17 +252,17 @@ func (s *CFOptimizeService) Create(ctx context.Context, userID int64, accountID
 	// 4. Create/Update origin A record (proxied)
 	var originRecordID string
 	var createdOriginID string
-	existingOrigin := findRecord(records, originRecordName, "A")
+	existingOrigin := findRecord(records, cfz, originRecordName, "A")
 	if existingOrigin != nil {
 		log.Printf("[CF Optimize] Updating existing origin A record: %s -> %s", originRecordName, originIP)
-		updated, err := s.client.UpdateRecordWithProxied(ctx, apiToken, zoneID, existingOrigin.ID, "A", originRecordName, originIP, 1, true)
+		updated, err := s.updateRecord(ctx, cfz, existingOrigin.ID, "A", originRecordName, originIP, true)
 		if err != nil {
 			return nil, fmt.Errorf("failed to update origin A record: %w", err)
 		}
 		originRecordID = updated.ID
 	} else {
 		log.Printf("[CF Optimize] Creating origin A record: %s -> %s", originRecordName, originIP)
-		newRec, err := s.client.CreateRecordWithProxied(ctx, apiToken, zoneID, "A", originRecordName, originIP, 1, true)
+		newRec, err := s.createRecord(ctx, cfz, "A", originRecordName, originIP, true)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create origin A record: %w", err)
 		}
@@ -142,9 +272,9 @@ func (s *CFOptimizeService) Create(ctx context.Context, userID int64, accountID
 
 	// 4b. Set the fallback origin for the zone to ensure custom hostnames can be verified
 	log.Printf("[CF Optimize] Setting fallback origin for zone %s: %s", zoneID, originRecordName)
-	if err := s.client.SetFallbackOrigin(ctx, apiToken, zoneID, originRecordName); err != nil {
+	if err := cfz.setFallbackOrigin(ctx, originRecordName); err != nil {
 		if createdOriginID != "" {
-			_ = s.client.DeleteRecord(ctx, apiToken, zoneID, createdOriginID)
+			_ = s.deleteRecord(ctx, cfz, createdOriginID)
 		}
 		return nil, fmt.Errorf("failed to set fallback origin for zone: %w", err)
 	}
@@ -152,23 +282,23 @@ func (s *CFOptimizeService) Create(ctx context.Context, userID int64, accountID
 	// 5. Create/Update intermediate CNAME record (gray cloud)
 	var intermediateRecordID string
 	var createdIntermediateID string
-	existingIntermediate := findRecord(records, intermediateRecordName, "CNAME")
+	existingIntermediate := findRecord(records, cfz, intermediateRecordName, "CNAME")
 	if existingIntermediate != nil {
 		log.Printf("[CF Optimize] Updating existing intermediate CNAME record: %s -> %s", intermediateRecordName, cnameTarget)
-		updated, err := s.client.UpdateRecordWithProxied(ctx, apiToken, zoneID, existingIntermediate.ID, "CNAME", intermediateRecordName, cnameTarget, 1, false)
+		updated, err := s.updateRecord(ctx, cfz, existingIntermediate.ID, "CNAME", intermediateRecordName, cnameTarget, false)
 		if err != nil {
 			if createdOriginID != "" {
-				_ = s.client.DeleteRecord(ctx, apiToken, zoneID, createdOriginID)
+				_ = s.deleteRecord(ctx, cfz, createdOriginID)
 			}
 			return nil, fmt.Errorf("failed to update intermediate CNAME record: %w", err)
 		}
 		intermediateRecordID = updated.ID
 	} else {
 		log.Printf("[CF Optimize] Creating intermediate CNAME record: %s -> %s", intermediateRecordName, cnameTarget)
-		newRec, err := s.client.CreateRecordWithProxied(ctx, apiToken, zoneID, "CNAME", intermediateRecordName, cnameTarget, 1, false)
+		newRec, err := s.createRecord(ctx, cfz, "CNAME", intermediateRecordName, cnameTarget, false)
 		if err != nil {
 			if createdOriginID != "" {
-				_ = s.client.DeleteRecord(ctx, apiToken, zoneID, createdOriginID)
+				_ = s.deleteRecord(ctx, cfz, createdOriginID)
 			}
 			return nil, fmt.Errorf("failed to create intermediate CNAME record: %w", err)
 		}
@@ -179,29 +309,29 @@ func (s *CFOptimizeService) Create(ctx context.Context, userID int64, accountID
 	// 6. Create/Update business CNAME record pointing to intermediate domain
 	var cnameRecordID string
 	var createdCnameID string
-	existingCname := findRecord(records, cnameRecordName, "CNAME")
+	existingCname := findRecord(records, cfz, cnameRecordName, "CNAME")
 	if existingCname != nil {
 		log.Printf("[CF Optimize] Updating existing business CNAME record: %s -> %s", cnameRecordName, intermediateRecordName)
-		updated, err := s.client.UpdateRecordWithProxied(ctx, apiToken, zoneID, existingCname.ID, "CNAME", cnameRecordName, intermediateRecordName, 1, false)
+		updated, err := s.updateRecord(ctx, cfz, existingCname.ID, "CNAME", cnameRecordName, intermediateRecordName, false)
 		if err != nil {
 			if createdOriginID != "" {
-				_ = s.client.DeleteRecord(ctx, apiToken, zoneID, createdOriginID)
+				_ = s.deleteRecord(ctx, cfz, createdOriginID)
 			}
 			if createdIntermediateID != "" {
-				_ = s.client.DeleteRecord(ctx, apiToken, zoneID, createdIntermediateID)
+				_ = s.deleteRecord(ctx, cfz, createdIntermediateID)
 			}
 			return nil, fmt.Errorf("failed to update business CNAME record: %w", err)
 		}
 		cnameRecordID = updated.ID
 	} else {
 		log.Printf("[CF Optimize] Creating business CNAME record: %s -> %s", cnameRecordName, intermediateRecordName)
-		newRec, err := s.client.CreateRecordWithProxied(ctx, apiToken, zoneID, "CNAME", cnameRecordName, intermediateRecordName, 1, false)
+		newRec, err := s.createRecord(ctx, cfz, "CNAME", cnameRecordName, intermediateRecordName, false)
 		if err != nil {
 			if createdOriginID != "" {
-				_ = s.client.DeleteRecord(ctx, apiToken, zoneID, createdOriginID)
+				_ = s.deleteRecord(ctx, cfz, createdOriginID)
 			}
 			if createdIntermediateID != "" {
-				_ = s.client.DeleteRecord(ctx, apiToken, zoneID, createdIntermediateID)
+				_ = s.deleteRecord(ctx, cfz, createdIntermediateID)
 			}
 			return nil, fmt.Errorf("failed to create business CNAME record: %w", err)
 		}
@@ -211,17 +341,17 @@ func (s *CFOptimizeService) Create(ctx context.Context, userID int64, accountID
 
 	// 7. Create custom hostname
 	log.Printf("[CF Optimize] Creating custom hostname: %s (origin: %s)", customHostname, originRecordName)
-	ch, err := s.client.CreateCustomHostname(ctx, apiToken, zoneID, customHostname, originRecordName)
+	ch, err := cfz.createHostname(ctx, customHostname, originRecordName)
 	if err != nil {
 		// Rollback newly created records
 		if createdOriginID != "" {
-			_ = s.client.DeleteRecord(ctx, apiToken, zoneID, createdOriginID)
+			_ = s.deleteRecord(ctx, cfz, createdOriginID)
 		}
 		if createdIntermediateID != "" {
-			_ = s.client.DeleteRecord(ctx, apiToken, zoneID, createdIntermediateID)
+			_ = s.deleteRecord(ctx, cfz, createdIntermediateID)
 		}
 		if createdCnameID != "" {
-			_ = s.client.DeleteRecord(ctx, apiToken, zoneID, createdCnameID)
+			_ = s.deleteRecord(ctx, cfz, createdCnameID)
 		}
 		errMsg := err.Error()
 		if strings.Contains(errMsg, "403") || strings.Contains(errMsg, "Authentication error") {
@@ -231,71 +361,34 @@ func (s *CFOptimizeService) Create(ctx context.Context, userID int64, accountID
 	}
 	customHostnameID := ch.ID
 	status := ch.Status
-	sslStatus := "pending"
-	if ch.SSL != nil {
-		sslStatus = ch.SSL.Status
+	sslStatus := ch.SSLStatus
+	if sslStatus == "" {
+		sslStatus = "pending"
 	}
 
-	// 8. Auto-create validation records on the same Cloudflare zone
+	// 8. Auto-create validation records (ownership and SSL) on the same zone
 	var validationRecordIDs []string
-
-	// Ownership validation record (TXT or CNAME)
-	if ch.OwnershipVerification != nil && ch.OwnershipVerification.Status != "active" && ch.OwnershipVerification.Status != "verified" {
-		txtName := ch.OwnershipVerification.Name
-		txtValue := ch.OwnershipVerification.Value
-		if txtName != "" && txtValue != "" {
-			recType := "TXT"
-			if strings.ToLower(ch.OwnershipVerification.Type) == "cname" {
-				recType = "CNAME"
-			}
-			existingRec := findRecord(records, txtName, recType)
-			var recID string
-			if existingRec != nil {
-				log.Printf("[CF Optimize] Updating existing ownership %s: %s", recType, txtName)
-				updated, err := s.client.UpdateRecordWithProxied(ctx, apiToken, zoneID, existingRec.ID, recType, txtName, txtValue, 1, false)
-				if err == nil {
-					recID = updated.ID
-				}
-			} else {
-				log.Printf("[CF Optimize] Creating ownership %s: %s", recType, txtName)
-				newRec, err := s.client.CreateRecordWithProxied(ctx, apiToken, zoneID, recType, txtName, txtValue, 1, false)
-				if err == nil {
-					recID = newRec.ID
-				}
+	for _, v := range ch.Validations {
+		if v.Name == "" || v.Value == "" {
+			continue
+		}
+		existingRec := findRecord(records, cfz, v.Name, v.Type)
+		var recID string
+		if existingRec != nil {
+			log.Printf("[CF Optimize] Updating existing validation %s: %s", v.Type, v.Name)
+			updated, err := s.updateRecord(ctx, cfz, existingRec.ID, v.Type, v.Name, v.Value, false)
+			if err == nil {
+				recID = updated.ID
 			}
-			if recID != "" {
-				validationRecordIDs = append(validationRecordIDs, recID)
+		} else {
+			log.Printf("[CF Optimize] Creating validation %s: %s", v.Type, v.Name)
+			newRec, err := s.createRecord(ctx, cfz, v.Type, v.Name, v.Value, false)
+			if err == nil {
+				recID = newRec.ID
 			}
 		}
-	}
-
-	// SSL Validation records (e.g. DCV delegation CNAME or TXT)
-	if ch.SSL != nil && len(ch.SSL.ValidationRecords) > 0 {
-		for _, v := range ch.SSL.ValidationRecords {
-			if v.Status != "active" && v.Status != "verified" && v.TxtName != "" && v.TxtValue != "" {
-				recType := "TXT"
-				if strings.Contains(v.TxtValue, "dcv.cloudflare.com") {
-					recType = "CNAME"
-				}
-				existingRec := findRecord(records, v.TxtName, recType)
-				var recID string
-				if existingRec != nil {
-					log.Printf("[CF Optimize] Updating existing SSL verification %s: %s", recType, v.TxtName)
-					updated, err := s.client.UpdateRecordWithProxied(ctx, apiToken, zoneID, existingRec.ID, recType, v.TxtName, v.TxtValue, 1, false)
-					if err == nil {
-						recID = updated.ID
-					}
-				} else {
-					log.Printf("[CF Optimize] Creating SSL verification %s: %s", recType, v.TxtName)
-					newRec, err := s.client.CreateRecordWithProxied(ctx, apiToken, zoneID, recType, v.TxtName, v.TxtValue, 1, false)
-					if err == nil {
-						recID = newRec.ID
-					}
-				}
-				if recID != "" {
-					validationRecordIDs = append(validationRecordIDs, recID)
-				}
-			}
+		if recID != "" {
+			validationRecordIDs = append(validationRecordIDs, recID)
 		}
 	}
 
@@ -318,20 +411,20 @@ func (s *CFOptimizeService) Create(ctx context.Context, userID int64, accountID
 	if err != nil {
 		// Cleanup created validation records
 		for _, rID := range validationRecordIDs {
-			_ = s.client.DeleteRecord(ctx, apiToken, zoneID, rID)
+			_ = s.deleteRecord(ctx, cfz, rID)
 		}
 		// Rollback other newly created records
 		if createdOriginID != "" {
-			_ = s.client.DeleteRecord(ctx, apiToken, zoneID, createdOriginID)
+			_ = s.deleteRecord(ctx, cfz, createdOriginID)
 		}
 		if createdIntermediateID != "" {
-			_ = s.client.DeleteRecord(ctx, apiToken, zoneID, createdIntermediateID)
+			_ = s.deleteRecord(ctx, cfz, createdIntermediateID)
 		}
 		if createdCnameID != "" {
-			_ = s.client.DeleteRecord(ctx, apiToken, zoneID, createdCnameID)
+			_ = s.deleteRecord(ctx, cfz, createdCnameID)
 		}
 		// Delete custom hostname
-		_ = s.client.DeleteCustomHostname(ctx, apiToken, zoneID, customHostnameID)
+		_ = cfz.deleteHostname(ctx, customHostnameID)
 
 		return nil, fmt.Errorf("failed to save config to database: %w", err)
 	}
@@ -406,8 +499,13 @@ func (s *CFOptimizeService) Refresh(ctx context.Context, userID, configID int64)
 		return nil, fmt.Errorf("account not found: %w", err)
 	}
 
+	cfz, err := newCFZone(userID, account, config.ZoneID, config.ZoneName)
+	if err != nil {
+		return nil, err
+	}
+
 	// Query Cloudflare for current status
-	ch, err := s.client.GetCustomHostname(ctx, account.APIKey, config.ZoneID, config.CustomHostnameID)
+	ch, err := cfz.getHostname(ctx, config.CustomHostnameID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get custom hostname status: %w", err)
 	}
@@ -416,18 +514,18 @@ func (s *CFOptimizeService) Refresh(ctx context.Context, userID, configID int64)
 	// This resolves the "zone does not have a fallback origin set" validation blockage.
 	if ch.Status == "pending" {
 		log.Printf("[CF Optimize] Custom hostname %s is pending. Setting fallback origin self-healing to: %s", config.CustomHostname, config.OriginRecordName)
-		_ = s.client.SetFallbackOrigin(ctx, account.APIKey, config.ZoneID, config.OriginRecordName)
+		_ = cfz.setFallbackOrigin(ctx, config.OriginRecordName)
 		// Re-query status immediately to get updated status and clear verification errors
-		if updatedCh, err := s.client.GetCustomHostname(ctx, account.APIKey, config.ZoneID, config.CustomHostnameID); err == nil {
+		if updatedCh, err := cfz.getHostname(ctx, config.CustomHostnameID); err == nil {
 			ch = updatedCh
 		}
 	}
 
 	// Update local status
 	status := ch.Status
-	sslStatus := "pending"
-	if ch.SSL != nil {
-		sslStatus = ch.SSL.Status
+	sslStatus := ch.SSLStatus
+	if sslStatus == "" {
+		sslStatus = "pending"
 	}
 
 	now := time.Now()
@@ -454,12 +552,15 @@ func (s *CFOptimizeService) Delete(ctx context.Context, userID, configID int64,
 
 	if cleanup {
 		account, err := s.getAccount(userID, config.AccountID)
+		var cfz cfZone
+		if err == nil {
+			cfz, err = newCFZone(userID, account, config.ZoneID, config.ZoneName)
+		}
 		if err == nil {
-			apiToken := account.APIKey
 
 			// 1. Delete business CNAME record
 			if config.CnameRecordID != "" {
-				_ = s.client.DeleteRecord(ctx, apiToken, config.ZoneID, config.CnameRecordID)
+				_ = s.deleteRecord(ctx, cfz, config.CnameRecordID)
 			}
 
 			// 2. Delete auto-created validation records
@@ -468,7 +569,7 @@ func (s *CFOptimizeService) Delete(ctx context.Context, userID, configID int64,
 				for _, rID := range vIDs {
 					rID = strings.TrimSpace(rID)
 					if rID != "" {
-						_ = s.client.DeleteRecord(ctx, apiToken, config.ZoneID, rID)
+						_ = s.deleteRecord(ctx, cfz, rID)
 					}
 				}
 			}
@@ -482,7 +583,7 @@ func (s *CFOptimizeService) Delete(ctx context.Context, userID, configID int64,
 				).Scan(&count)
 				if err == nil && count == 0 {
 					log.Printf("[CF Optimize] Cleaning up unused SaaS custom hostname: %s", config.CustomHostname)
-					_ = s.client.DeleteCustomHostname(ctx, apiToken, config.ZoneID, config.CustomHostnameID)
+					_ = cfz.deleteHostname(ctx, config.CustomHostnameID)
 				}
 			}
 
@@ -495,7 +596,7 @@ func (s *CFOptimizeService) Delete(ctx context.Context, userID, configID int64,
 				).Scan(&count)
 				if err == nil && count == 0 {
 					log.Printf("[CF Optimize] Cleaning up unused intermediate CNAME record: %s", config.IntermediateRecordName)
-					_ = s.client.DeleteRecord(ctx, apiToken, config.ZoneID, config.IntermediateRecordID)
+					_ = s.deleteRecord(ctx, cfz, config.IntermediateRecordID)
 				}
 			}
 
@@ -508,9 +609,9 @@ func (s *CFOptimizeService) Delete(ctx context.Context, userID, configID int64,
 				).Scan(&count)
 				if err == nil && count == 0 {
 					log.Printf("[CF Optimize] Cleaning up unused origin A record: %s. Clearing fallback origin first.", config.OriginRecordName)
-					_ = s.client.DeleteFallbackOrigin(ctx, apiToken, config.ZoneID)
+					_ = cfz.deleteFallbackOrigin(ctx)
 					time.Sleep(2 * time.Second)
-					_ = s.client.DeleteRecord(ctx, apiToken, config.ZoneID, config.OriginRecordID)
+					_ = s.deleteRecord(ctx, cfz, config.OriginRecordID)
 				}
 			}
 		}
@@ -555,6 +656,9 @@ func (s *CFOptimizeService) getAccount(userID, accountID int64) (*models.Account
 	if err != nil {
 		return nil, err
 	}
+	if a.APIKey, err = DecryptSecret(a.APIKey); err != nil {
+		return nil, err
+	}
 	return &a, nil
 }
 
@@ -571,9 +675,12 @@ func (s *CFOptimizeService) Update(ctx context.Context, userID, configID int64,
 	if err != nil {
 		return nil, fmt.Errorf("account not found: %w", err)
 	}
-	apiToken := account.APIKey
 	zoneID := config.ZoneID
 	zoneName := config.ZoneName
+	cfz, err := newCFZone(userID, account, zoneID, zoneName)
+	if err != nil {
+		return nil, err
+	}
 
 	// Normalize inputs
 	originIP := strings.TrimSpace(req.OriginIP)
@@ -624,7 +731,7 @@ func (s *CFOptimizeService) Update(ctx context.Context, userID, configID int64,
 	intermediateRecordName := fmt.Sprintf("%s.%s", cleanIntermediate, zoneName)
 
 	// Fetch all existing records in the zone for smart reuse/updating
-	records, err := s.client.ListRecords(ctx, apiToken, zoneID)
+	records, err := s.dnsService.ListRecords(ctx, userID, account.ID, zoneID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list DNS records: %w", err)
 	}
@@ -647,14 +754,14 @@ func (s *CFOptimizeService) Update(ctx context.Context, userID, configID int64,
 				).Scan(&count)
 				if err == nil && count == 0 {
 					log.Printf("[CF Optimize] Cleaning up old unused origin record: %s. Clearing fallback origin first.", config.OriginRecordName)
-					_ = s.client.DeleteFallbackOrigin(ctx, apiToken, zoneID)
+					_ = cfz.deleteFallbackOrigin(ctx)
 					time.Sleep(2 * time.Second)
-					_ = s.client.DeleteRecord(ctx, apiToken, zoneID, config.OriginRecordID)
+					_ = s.deleteRecord(ctx, cfz, config.OriginRecordID)
 				}
 			}
 
 			// Create new origin record
-			newRec, err := s.client.CreateRecordWithProxied(ctx, apiToken, zoneID, "A", originRecordName, originIP, 1, true)
+			newRec, err := s.createRecord(ctx, cfz, "A", originRecordName, originIP, true)
 			if err != nil {
 				return nil, fmt.Errorf("failed to create new origin A record: %w", err)
 			}
@@ -662,12 +769,12 @@ func (s *CFOptimizeService) Update(ctx context.Context, userID, configID int64,
 		} else {
 			// Name is same, IP changed. Update it.
 			if originRecordID != "" {
-				_, err = s.client.UpdateRecordWithProxied(ctx, apiToken, zoneID, originRecordID, "A", originRecordName, originIP, 1, true)
+				_, err = s.updateRecord(ctx, cfz, originRecordID, "A", originRecordName, originIP, true)
 				if err != nil {
 					return nil, fmt.Errorf("failed to update origin A record: %w", err)
 				}
 			} else {
-				newRec, err := s.client.CreateRecordWithProxied(ctx, apiToken, zoneID, "A", originRecordName, originIP, 1, true)
+				newRec, err := s.createRecord(ctx, cfz, "A", originRecordName, originIP, true)
 				if err != nil {
 					return nil, fmt.Errorf("failed to create origin A record: %w", err)
 				}
@@ -676,7 +783,7 @@ func (s *CFOptimizeService) Update(ctx context.Context, userID, configID int64,
 		}
 
 		// Also ensure Fallback Origin is set to the new origin record name
-		_ = s.client.SetFallbackOrigin(ctx, apiToken, zoneID, originRecordName)
+		_ = cfz.setFallbackOrigin(ctx, originRecordName)
 	}
 
 	// 4. Update intermediate CNAME record
@@ -695,16 +802,16 @@ func (s *CFOptimizeService) Update(ctx context.Context, userID, configID int64,
 				).Scan(&count)
 				if err == nil && count == 0 {
 					log.Printf("[CF Optimize] Cleaning up old unused intermediate CNAME record: %s", config.IntermediateRecordName)
-					_ = s.client.DeleteRecord(ctx, apiToken, zoneID, config.IntermediateRecordID)
+					_ = s.deleteRecord(ctx, cfz, config.IntermediateRecordID)
 				}
 			}
 
 			// Create or find the new intermediate CNAME record
-			existingNewInter := findRecord(records, intermediateRecordName, "CNAME")
+			existingNewInter := findRecord(records, cfz, intermediateRecordName, "CNAME")
 			if existingNewInter != nil {
 				log.Printf("[CF Optimize] Reusing existing CNAME record for new intermediate prefix: %s", intermediateRecordName)
 				if existingNewInter.Content != cnameTarget {
-					updated, err := s.client.UpdateRecordWithProxied(ctx, apiToken, zoneID, existingNewInter.ID, "CNAME", intermediateRecordName, cnameTarget, 1, false)
+					updated, err := s.updateRecord(ctx, cfz, existingNewInter.ID, "CNAME", intermediateRecordName, cnameTarget, false)
 					if err != nil {
 						return nil, fmt.Errorf("failed to update intermediate CNAME record: %w", err)
 					}
@@ -714,7 +821,7 @@ func (s *CFOptimizeService) Update(ctx context.Context, userID, configID int64,
 				}
 			} else {
 				log.Printf("[CF Optimize] Creating new intermediate CNAME record: %s -> %s", intermediateRecordName, cnameTarget)
-				newRec, err := s.client.CreateRecordWithProxied(ctx, apiToken, zoneID, "CNAME", intermediateRecordName, cnameTarget, 1, false)
+				newRec, err := s.createRecord(ctx, cfz, "CNAME", intermediateRecordName, cnameTarget, false)
 				if err != nil {
 					return nil, fmt.Errorf("failed to create intermediate CNAME record: %w", err)
 				}
@@ -724,7 +831,7 @@ func (s *CFOptimizeService) Update(ctx context.Context, userID, configID int64,
 			// Prefix is same, but target changed. Just update the existing CNAME record content.
 			if intermediateRecordID != "" {
 				log.Printf("[CF Optimize] CNAME target changed from %s to %s. Updating intermediate record.", config.CnameTarget, cnameTarget)
-				_, err = s.client.UpdateRecordWithProxied(ctx, apiToken, zoneID, intermediateRecordID, "CNAME", intermediateRecordName, cnameTarget, 1, false)
+				_, err = s.updateRecord(ctx, cfz, intermediateRecordID, "CNAME", intermediateRecordName, cnameTarget, false)
 				if err != nil {
 					return nil, fmt.Errorf("failed to update intermediate CNAME record: %w", err)
 				}
@@ -736,7 +843,7 @@ func (s *CFOptimizeService) Update(ctx context.Context, userID, configID int64,
 	cnameRecordID := config.CnameRecordID
 	if intermediateChanged && cnameRecordID != "" {
 		log.Printf("[CF Optimize] Intermediate prefix changed. Updating business CNAME to point to: %s", intermediateRecordName)
-		_, err = s.client.UpdateRecordWithProxied(ctx, apiToken, zoneID, cnameRecordID, "CNAME", config.CnameRecordName, intermediateRecordName, 1, false)
+		_, err = s.updateRecord(ctx, cfz, cnameRecordID, "CNAME", config.CnameRecordName, intermediateRecordName, false)
 		if err != nil {
 			return nil, fmt.Errorf("failed to update business CNAME record: %w", err)
 		}
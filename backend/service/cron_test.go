@@ -0,0 +1,197 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func cronBits(values ...int) uint64 {
+	var mask uint64
+	for _, v := range values {
+		mask |= 1 << uint(v)
+	}
+	return mask
+}
+
+func TestParseCronField(t *testing.T) {
+	tests := []struct {
+		field    string
+		min, max int
+		names    map[string]int
+		want     uint64
+	}{
+		{"*", 0, 6, nil, cronBits(0, 1, 2, 3, 4, 5, 6)},
+		{"?", 1, 3, nil, cronBits(1, 2, 3)},
+		{"5", 0, 59, nil, cronBits(5)},
+		{"1-5", 0, 59, nil, cronBits(1, 2, 3, 4, 5)},
+		{"1,3,5", 0, 59, nil, cronBits(1, 3, 5)},
+		{"*/15", 0, 59, nil, cronBits(0, 15, 30, 45)},
+		{"5/20", 0, 59, nil, cronBits(5, 25, 45)},
+		{"1-10/3", 0, 59, nil, cronBits(1, 4, 7, 10)},
+		{"0-4/2,20", 0, 23, nil, cronBits(0, 2, 4, 20)},
+		{"jan-mar", 1, 12, cronMonthNames, cronBits(1, 2, 3)},
+		{"Dec", 1, 12, cronMonthNames, cronBits(12)},
+		{"MON-fri", 0, 7, cronDayNames, cronBits(1, 2, 3, 4, 5)},
+		{"sat,sun", 0, 7, cronDayNames, cronBits(0, 6)},
+	}
+
+	for _, tt := range tests {
+		got, err := parseCronField(tt.field, tt.min, tt.max, tt.names)
+		if err != nil {
+			t.Errorf("parseCronField(%q): %v", tt.field, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseCronField(%q) = %b, want %b", tt.field, got, tt.want)
+		}
+	}
+}
+
+func TestParseCronFieldInvalid(t *testing.T) {
+	for _, field := range []string{"60", "-1", "5-1", "*/0", "*/x", "1-", "abc", "", "1,,2", "mon"} {
+		if _, err := parseCronField(field, 0, 59, nil); !errors.Is(err, ErrInvalidCron) {
+			t.Errorf("parseCronField(%q) error = %v, want ErrInvalidCron", field, err)
+		}
+	}
+}
+
+func TestParseCron(t *testing.T) {
+	daily, err := ParseCron("@daily")
+	if err != nil {
+		t.Fatalf("ParseCron(@daily): %v", err)
+	}
+	explicit, _ := ParseCron("0 0 * * *")
+	if *daily != *explicit {
+		t.Errorf("@daily = %+v, want %+v", daily, explicit)
+	}
+
+	sunday, err := ParseCron("0 0 * * 7")
+	if err != nil {
+		t.Fatalf("ParseCron(dow 7): %v", err)
+	}
+	if sunday.dow != cronBits(0) {
+		t.Errorf("dow 7 = %b, want Sunday only", sunday.dow)
+	}
+
+	for _, expr := range []string{"", "* * * *", "* * * * * *", "61 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "@often"} {
+		if _, err := ParseCron(expr); !errors.Is(err, ErrInvalidCron) {
+			t.Errorf("ParseCron(%q) error = %v, want ErrInvalidCron", expr, err)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.ParseInLocation("2006-01-02 15:04", s, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		from string
+		want string
+	}{
+		{"step minutes", "*/15 * * * *", "2026-01-16 10:07", "2026-01-16 10:15"},
+		{"strictly after", "0 9 * * *", "2026-01-16 09:00", "2026-01-17 09:00"},
+		{"weekdays skip weekend", "0 9 * * 1-5", "2026-01-16 10:00", "2026-01-19 09:00"},
+		{"month rollover", "0 0 1 * *", "2026-12-15 00:00", "2027-01-01 00:00"},
+		{"leap day", "0 0 29 2 *", "2026-03-01 00:00", "2028-02-29 00:00"},
+		// Both day fields restricted: either one matches
+		{"dom or dow, dom first", "0 0 13 * 5", "2026-04-11 00:00", "2026-04-13 00:00"},
+		{"dom or dow, dow first", "0 0 13 * 5", "2026-01-14 00:00", "2026-01-16 00:00"},
+		// One day field restricted: only that one counts
+		{"dom only", "0 0 13 * *", "2026-01-14 00:00", "2026-02-13 00:00"},
+		{"dow only", "0 0 * * 5", "2026-04-11 00:00", "2026-04-17 00:00"},
+		{"dom star with dow", "0 0 * * sun", "2026-04-11 00:00", "2026-04-12 00:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sched, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+			}
+			got := sched.Next(at(tt.from))
+			if want := at(tt.want); !got.Equal(want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got.Format("2006-01-02 15:04"), tt.want)
+			}
+			if !sched.Matches(got) {
+				t.Errorf("Matches(%s) = false for a Next result", got)
+			}
+		})
+	}
+
+	never, _ := ParseCron("0 0 30 2 *")
+	if next := never.Next(at("2026-01-01 00:00")); !next.IsZero() {
+		t.Errorf("Next for Feb 30 = %s, want zero", next)
+	}
+}
+
+// The scheduler matches wall-clock time in SCHEDULER_TIMEZONE, so a time
+// skipped by a DST jump does not run that day and a repeated hour matches
+// twice.
+func TestCronNextDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	at := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, loc)
+	}
+	// 01:00 EDT on the fall-back day; adding an hour gives 01:00 EST
+	fallBack := at(2026, time.November, 1, 1, 0)
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		// 2026-03-08 02:00 EST jumps to 03:00 EDT
+		{"spring forward skips the missing time", "30 2 * * *", at(2026, time.March, 7, 12, 0), at(2026, time.March, 9, 2, 30)},
+		{"spring forward hourly", "0 * * * *", at(2026, time.March, 8, 1, 30), at(2026, time.March, 8, 3, 0)},
+		{"fall back repeats the hour", "0 * * * *", fallBack.Add(30 * time.Minute), fallBack.Add(time.Hour)},
+		{"fall back daily", "0 3 * * *", at(2026, time.October, 31, 3, 0), at(2026, time.November, 1, 3, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sched, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+			}
+			got := sched.Next(tt.from)
+			if !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+			if got.Location() != loc {
+				t.Errorf("Next location = %s, want %s", got.Location(), loc)
+			}
+		})
+	}
+
+	// São Paulo moved clocks forward at midnight, so 2018-11-04 00:00 did
+	// not exist
+	saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	noon, _ := ParseCron("0 12 * * *")
+	got := noon.Next(time.Date(2018, time.November, 3, 13, 0, 0, 0, saoPaulo))
+	if want := time.Date(2018, time.November, 4, 12, 0, 0, 0, saoPaulo); !got.Equal(want) {
+		t.Errorf("Next across a midnight gap = %s, want %s", got, want)
+	}
+
+	// The fall-back day is 25 hours long
+	daily, _ := ParseCron("0 3 * * *")
+	from := at(2026, time.October, 31, 3, 0)
+	if d := daily.Next(from).Sub(from); d != 25*time.Hour {
+		t.Errorf("daily run across fall back after %s, want 25h", d)
+	}
+}
@@ -0,0 +1,361 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"dns-mng/database"
+	"dns-mng/models"
+)
+
+// ErrInvalidDDNSAgent wraps validation errors for agent settings
+var ErrInvalidDDNSAgent = errors.New("invalid ddns agent")
+
+const defaultDDNSAgentInterval = 5
+
+// DDNSAgentService manages server-side DDNS agents: scheduled jobs that
+// detect this host's public IP and update records through DDNSService.
+type DDNSAgentService struct {
+	ddnsService        *DDNSService
+	ddnsHistoryService *DDNSHistoryService
+}
+
+func NewDDNSAgentService(ddnsService *DDNSService, ddnsHistoryService *DDNSHistoryService) *DDNSAgentService {
+	return &DDNSAgentService{
+		ddnsService:        ddnsService,
+		ddnsHistoryService: ddnsHistoryService,
+	}
+}
+
+const ddnsAgentColumns = `id, user_id, name, enabled, hostnames, ipv4_sources, ipv6_sources,
+	interval_minutes, create_missing,
+	COALESCE(last_run_at, '') as last_run_at,
+	last_ipv4, last_ipv6, last_status, last_error, created_at, updated_at`
+
+func scanDDNSAgent(row rowScanner) (*models.DDNSAgent, error) {
+	var a models.DDNSAgent
+	var enabled, create int
+	var hostnames, ipv4Sources, ipv6Sources string
+	err := row.Scan(
+		&a.ID, &a.UserID, &a.Name, &enabled, &hostnames, &ipv4Sources, &ipv6Sources,
+		&a.IntervalMinutes, &create,
+		&a.LastRunAt, &a.LastIPv4, &a.LastIPv6, &a.LastStatus, &a.LastError, &a.CreatedAt, &a.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	a.Enabled = enabled == 1
+	a.Create = create == 1
+	a.Hostnames = splitList(hostnames)
+	a.IPv4Sources = splitList(ipv4Sources)
+	a.IPv6Sources = splitList(ipv6Sources)
+	return &a, nil
+}
+
+// List returns all agents of a user
+func (s *DDNSAgentService) List(userID int64) ([]models.DDNSAgent, error) {
+	rows, err := database.DB.Query(`SELECT `+ddnsAgentColumns+` FROM ddns_agents WHERE user_id = ? ORDER BY id ASC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	agents := []models.DDNSAgent{}
+	for rows.Next() {
+		a, err := scanDDNSAgent(rows)
+		if err != nil {
+			return nil, err
+		}
+		agents = append(agents, *a)
+	}
+	return agents, rows.Err()
+}
+
+// Get returns one agent, or nil if it does not exist
+func (s *DDNSAgentService) Get(userID, id int64) (*models.DDNSAgent, error) {
+	a, err := scanDDNSAgent(database.DB.QueryRow(
+		`SELECT `+ddnsAgentColumns+` FROM ddns_agents WHERE id = ? AND user_id = ?`, id, userID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return a, err
+}
+
+// Create adds a new agent
+func (s *DDNSAgentService) Create(userID int64, req *models.CreateDDNSAgentRequest) (*models.DDNSAgent, error) {
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	a := &models.DDNSAgent{
+		UserID:          userID,
+		Name:            strings.TrimSpace(req.Name),
+		Enabled:         enabled,
+		Hostnames:       req.Hostnames,
+		IPv4Sources:     req.IPv4Sources,
+		IPv6Sources:     req.IPv6Sources,
+		IntervalMinutes: req.IntervalMinutes,
+		Create:          req.Create,
+	}
+	if err := normalizeDDNSAgent(a); err != nil {
+		return nil, err
+	}
+
+	result, err := database.DB.Exec(
+		`INSERT INTO ddns_agents (user_id, name, enabled, hostnames, ipv4_sources, ipv6_sources, interval_minutes, create_missing, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))`,
+		userID, a.Name, boolToInt(a.Enabled), strings.Join(a.Hostnames, ","),
+		strings.Join(a.IPv4Sources, ","), strings.Join(a.IPv6Sources, ","), a.IntervalMinutes, boolToInt(a.Create),
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(userID, id)
+}
+
+// Update changes an agent. Nil request fields are left unchanged. Returns
+// nil when the agent does not exist.
+func (s *DDNSAgentService) Update(userID, id int64, req *models.UpdateDDNSAgentRequest) (*models.DDNSAgent, error) {
+	a, err := s.Get(userID, id)
+	if err != nil || a == nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		a.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Enabled != nil {
+		a.Enabled = *req.Enabled
+	}
+	if req.Hostnames != nil {
+		a.Hostnames = *req.Hostnames
+	}
+	if req.IPv4Sources != nil {
+		a.IPv4Sources = *req.IPv4Sources
+	}
+	if req.IPv6Sources != nil {
+		a.IPv6Sources = *req.IPv6Sources
+	}
+	if req.IntervalMinutes != nil {
+		a.IntervalMinutes = *req.IntervalMinutes
+	}
+	if req.Create != nil {
+		a.Create = *req.Create
+	}
+	if err := normalizeDDNSAgent(a); err != nil {
+		return nil, err
+	}
+
+	_, err = database.DB.Exec(
+		`UPDATE ddns_agents
+		 SET name = ?, enabled = ?, hostnames = ?, ipv4_sources = ?, ipv6_sources = ?,
+		     interval_minutes = ?, create_missing = ?, updated_at = datetime('now')
+		 WHERE id = ? AND user_id = ?`,
+		a.Name, boolToInt(a.Enabled), strings.Join(a.Hostnames, ","),
+		strings.Join(a.IPv4Sources, ","), strings.Join(a.IPv6Sources, ","),
+		a.IntervalMinutes, boolToInt(a.Create), id, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(userID, id)
+}
+
+// Delete removes an agent
+func (s *DDNSAgentService) Delete(userID, id int64) error {
+	_, err := database.DB.Exec(`DELETE FROM ddns_agents WHERE id = ? AND user_id = ?`, id, userID)
+	return err
+}
+
+func normalizeDDNSAgent(a *models.DDNSAgent) error {
+	if a.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidDDNSAgent)
+	}
+
+	var hostnames []string
+	for _, h := range a.Hostnames {
+		h = normalizeFQDN(h)
+		if h == "" {
+			continue
+		}
+		if strings.Contains(h, ",") || !strings.Contains(h, ".") {
+			return fmt.Errorf("%w: invalid hostname %s", ErrInvalidDDNSAgent, h)
+		}
+		hostnames = append(hostnames, h)
+	}
+	if len(hostnames) == 0 {
+		return fmt.Errorf("%w: at least one hostname is required", ErrInvalidDDNSAgent)
+	}
+	a.Hostnames = hostnames
+
+	var err error
+	if a.IPv4Sources, err = normalizeIPSources(a.IPv4Sources); err != nil {
+		return err
+	}
+	if a.IPv6Sources, err = normalizeIPSources(a.IPv6Sources); err != nil {
+		return err
+	}
+	if len(a.IPv4Sources) == 0 && len(a.IPv6Sources) == 0 {
+		return fmt.Errorf("%w: configure ipv4_sources and/or ipv6_sources", ErrInvalidDDNSAgent)
+	}
+
+	if a.IntervalMinutes == 0 {
+		a.IntervalMinutes = defaultDDNSAgentInterval
+	}
+	if a.IntervalMinutes < 1 {
+		return fmt.Errorf("%w: interval_minutes must be at least 1", ErrInvalidDDNSAgent)
+	}
+	return nil
+}
+
+func normalizeIPSources(in []string) ([]string, error) {
+	out := []string{}
+	for _, src := range in {
+		src = strings.TrimSpace(src)
+		if src == "" {
+			continue
+		}
+		if strings.Contains(src, ",") {
+			return nil, fmt.Errorf("%w: ip source must not contain commas: %s", ErrInvalidDDNSAgent, src)
+		}
+		if err := validateIPSource(src); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidDDNSAgent, err)
+		}
+		out = append(out, src)
+	}
+	return out, nil
+}
+
+// Run detects the public addresses and updates every hostname of the agent.
+// The outcome is stored on the agent and logged through schedulerLogService.
+func (s *DDNSAgentService) Run(ctx context.Context, a *models.DDNSAgent, trigger string, schedulerLogService *SchedulerLogService) *models.DDNSAgentRunResult {
+	var logID int64
+	if schedulerLogService != nil {
+		logID, _ = schedulerLogService.StartTask("ddns_agent", map[string]interface{}{
+			"trigger":  trigger,
+			"agent_id": a.ID,
+			"name":     a.Name,
+		})
+	}
+
+	result := &models.DDNSAgentRunResult{AgentID: a.ID, Hosts: []*models.DDNSHostResult{}}
+	if len(a.IPv4Sources) > 0 {
+		ip, err := detectPublicIP(ctx, a.IPv4Sources, "A")
+		if err != nil {
+			result.Errors = append(result.Errors, "ipv4: "+err.Error())
+		}
+		result.IPv4 = ip
+	}
+	if len(a.IPv6Sources) > 0 {
+		ip, err := detectPublicIP(ctx, a.IPv6Sources, "AAAA")
+		if err != nil {
+			result.Errors = append(result.Errors, "ipv6: "+err.Error())
+		}
+		result.IPv6 = ip
+	}
+
+	failed := 0
+	if result.IPv4 != "" || result.IPv6 != "" {
+		caller := &models.DDNSCaller{
+			UserID:    a.UserID,
+			Protocol:  models.DDNSProtocolAgent,
+			UserAgent: "dns-mng agent/" + a.Name,
+		}
+		for _, hostname := range a.Hostnames {
+			req := &models.DDNSUpdateRequest{
+				Hostname: hostname,
+				IPv4:     result.IPv4,
+				IPv6:     result.IPv6,
+				Create:   a.Create,
+			}
+			host := s.ddnsService.UpdateHost(ctx, a.UserID, req)
+			if err := s.ddnsHistoryService.Record(caller, req, host); err != nil {
+				log.Printf("Failed to record ddns history for %s: %v", hostname, err)
+			}
+			switch host.Status {
+			case models.DDNSStatusNoHost, models.DDNSStatusError:
+				failed++
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %s %s", hostname, host.Status, host.Error))
+			}
+			result.Hosts = append(result.Hosts, host)
+		}
+	} else {
+		failed = len(a.Hostnames)
+	}
+
+	status := "success"
+	switch {
+	case failed == len(a.Hostnames):
+		status = "error"
+	case failed > 0 || len(result.Errors) > 0:
+		status = "partial_success"
+	}
+	result.Status = status
+
+	message := fmt.Sprintf("DDNS agent %s: ipv4=%s ipv6=%s, %d/%d host(s) ok",
+		a.Name, orDash(result.IPv4), orDash(result.IPv6), len(a.Hostnames)-failed, len(a.Hostnames))
+	if len(result.Errors) > 0 {
+		message += " | " + strings.Join(result.Errors, "; ")
+	}
+	if logID > 0 {
+		schedulerLogService.UpdateTask(logID, status, message)
+	}
+
+	_, err := database.DB.Exec(
+		`UPDATE ddns_agents
+		 SET last_run_at = datetime('now'), last_ipv4 = ?, last_ipv6 = ?, last_status = ?, last_error = ?
+		 WHERE id = ?`,
+		result.IPv4, result.IPv6, status, strings.Join(result.Errors, "; "), a.ID,
+	)
+	if err != nil {
+		log.Printf("Failed to update ddns agent %d: %v", a.ID, err)
+	}
+	return result
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// RunDue runs every enabled agent whose interval has elapsed. An agent that
+// starts failing is reported once as a scheduler failure, not on every run.
+func (s *DDNSAgentService) RunDue(ctx context.Context, schedulerLogService *SchedulerLogService, notifierService *NotifierService) {
+	rows, err := database.DB.Query(
+		`SELECT ` + ddnsAgentColumns + `
+		 FROM ddns_agents
+		 WHERE enabled = 1
+		   AND (last_run_at IS NULL OR last_run_at <= datetime('now', '-' || interval_minutes || ' minutes'))
+		 ORDER BY id ASC`,
+	)
+	if err != nil {
+		log.Printf("DDNS agent: failed to query due agents: %v", err)
+		return
+	}
+	var due []*models.DDNSAgent
+	for rows.Next() {
+		a, err := scanDDNSAgent(rows)
+		if err != nil {
+			continue
+		}
+		due = append(due, a)
+	}
+	rows.Close()
+
+	for _, a := range due {
+		result := s.Run(ctx, a, "scheduled", schedulerLogService)
+		if notifierService != nil && result.Status == "error" && a.LastStatus != "error" {
+			notifierService.NotifySchedulerFailure(ctx, a.UserID, "ddns_agent "+a.Name, strings.Join(result.Errors, "\n"))
+		}
+	}
+}
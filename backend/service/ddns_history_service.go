@@ -0,0 +1,184 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"dns-mng/database"
+	"dns-mng/models"
+)
+
+// ErrInvalidDDNSHistoryQuery is returned for malformed history filters
+var ErrInvalidDDNSHistoryQuery = errors.New("invalid ddns history query")
+
+type DDNSHistoryService struct{}
+
+func NewDDNSHistoryService() *DDNSHistoryService {
+	return &DDNSHistoryService{}
+}
+
+// Record persists the outcome of one hostname update. Every changed record
+// gets its own row; hosts without changes get one row per requested address
+// family so nochg/nohost/error attempts are still auditable.
+func (s *DDNSHistoryService) Record(caller *models.DDNSCaller, req *models.DDNSUpdateRequest, result *models.DDNSHostResult) error {
+	base := models.DDNSHistory{
+		UserID:     caller.UserID,
+		TokenID:    caller.TokenID,
+		Protocol:   caller.Protocol,
+		Hostname:   result.Hostname,
+		AccountID:  result.AccountID,
+		DomainID:   result.DomainID,
+		DomainName: result.DomainName,
+		CallerIP:   caller.IP,
+		UserAgent:  caller.UserAgent,
+		Status:     result.Status,
+		Error:      result.Error,
+	}
+
+	var entries []models.DDNSHistory
+	for _, change := range result.Changes {
+		entry := base
+		entry.RecordType = change.RecordType
+		entry.OldIP = change.OldIP
+		entry.NewIP = change.NewIP
+		entry.Status = models.DDNSStatusUpdated
+		if change.Created {
+			entry.Status = models.DDNSStatusCreated
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
+		for _, family := range []struct{ recordType, ip string }{
+			{"A", req.IPv4},
+			{"AAAA", req.IPv6},
+		} {
+			if family.ip == "" {
+				continue
+			}
+			entry := base
+			entry.RecordType = family.recordType
+			entry.NewIP = family.ip
+			if result.Status == models.DDNSStatusNoChg {
+				entry.OldIP = family.ip
+			}
+			entries = append(entries, entry)
+		}
+	}
+	if len(entries) == 0 {
+		entries = append(entries, base)
+	}
+
+	for _, e := range entries {
+		_, err := database.DB.Exec(
+			`INSERT INTO ddns_history (user_id, token_id, protocol, hostname, account_id, domain_id, domain_name,
+			 record_type, old_ip, new_ip, caller_ip, user_agent, status, error)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			e.UserID, e.TokenID, e.Protocol, e.Hostname, e.AccountID, e.DomainID, e.DomainName,
+			e.RecordType, e.OldIP, e.NewIP, e.CallerIP, e.UserAgent, e.Status, e.Error,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List retrieves a user's DDNS history with optional filters and pagination
+func (s *DDNSHistoryService) List(userID int64, q *models.DDNSHistoryQuery) (*models.DDNSHistoryListResponse, error) {
+	page, pageSize := q.Page, q.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+	offset := (page - 1) * pageSize
+
+	where := []string{"h.user_id = ?"}
+	args := []interface{}{userID}
+	if q.TokenID > 0 {
+		where = append(where, "h.token_id = ?")
+		args = append(args, q.TokenID)
+	}
+	if q.Hostname != "" {
+		where = append(where, "h.hostname = ?")
+		args = append(args, normalizeFQDN(q.Hostname))
+	}
+	if q.Status != "" {
+		where = append(where, "h.status = ?")
+		args = append(args, q.Status)
+	}
+	if q.From != "" {
+		from, err := parseDDNSTime(q.From)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid from %s", ErrInvalidDDNSHistoryQuery, q.From)
+		}
+		where = append(where, "h.created_at >= ?")
+		args = append(args, from.Format(ddnsTimeLayout))
+	}
+	if q.To != "" {
+		to, err := parseDDNSTime(q.To)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid to %s", ErrInvalidDDNSHistoryQuery, q.To)
+		}
+		// A bare date covers the whole day
+		if len(q.To) == len(time.DateOnly) {
+			to = to.Add(24*time.Hour - time.Second)
+		}
+		where = append(where, "h.created_at <= ?")
+		args = append(args, to.Format(ddnsTimeLayout))
+	}
+	whereSQL := strings.Join(where, " AND ")
+
+	var total int
+	err := database.DB.QueryRow(
+		`SELECT COUNT(*) FROM ddns_history h WHERE `+whereSQL,
+		args...,
+	).Scan(&total)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.DB.Query(
+		`SELECT h.id, h.user_id, h.token_id, COALESCE(t.name, ''), h.protocol, h.hostname,
+		 h.account_id, h.domain_id, h.domain_name, h.record_type, h.old_ip, h.new_ip,
+		 h.caller_ip, h.user_agent, h.status, h.error, h.created_at
+		 FROM ddns_history h
+		 LEFT JOIN ddns_tokens t ON t.id = h.token_id
+		 WHERE `+whereSQL+`
+		 ORDER BY h.created_at DESC, h.id DESC
+		 LIMIT ? OFFSET ?`,
+		append(args, pageSize, offset)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs := []models.DDNSHistory{}
+	for rows.Next() {
+		var h models.DDNSHistory
+		err := rows.Scan(
+			&h.ID, &h.UserID, &h.TokenID, &h.TokenName, &h.Protocol, &h.Hostname,
+			&h.AccountID, &h.DomainID, &h.DomainName, &h.RecordType, &h.OldIP, &h.NewIP,
+			&h.CallerIP, &h.UserAgent, &h.Status, &h.Error, &h.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &models.DDNSHistoryListResponse{
+		Logs:       logs,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+	}, nil
+}
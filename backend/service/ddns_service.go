@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"dns-mng/database"
+	"dns-mng/models"
+)
+
+// DDNSService points individual hostnames at new IP addresses. Each hostname
+// is resolved to its zone by longest suffix match and only that node's
+// A/AAAA records are touched.
+type DDNSService struct {
+	dns *DNSService
+}
+
+func NewDDNSService(dns *DNSService) *DDNSService {
+	return &DDNSService{dns: dns}
+}
+
+// UpdateHost updates the A/AAAA records of a single hostname. When every
+// requested address equals the last value written for the hostname, nochg
+// is returned straight from ddns_record_state without calling the provider.
+func (s *DDNSService) UpdateHost(ctx context.Context, userID int64, req *models.DDNSUpdateRequest) *models.DDNSHostResult {
+	if cached := cachedNoChange(userID, req); cached != nil {
+		return cached
+	}
+
+	result := &models.DDNSHostResult{Hostname: normalizeFQDN(req.Hostname)}
+	matched := s.updateHost(ctx, userID, req, result)
+	s.rememberResult(userID, req, result, matched)
+	if (result.Status == models.DDNSStatusUpdated || result.Status == models.DDNSStatusCreated) && len(result.Changes) > 0 {
+		s.dns.emit(userID, result.AccountID, result.DomainID, result.DomainName, models.WebhookEventDDNSIPChanged, map[string]interface{}{
+			"hostname": result.Hostname,
+			"changes":  result.Changes,
+		})
+	}
+	return result
+}
+
+// cachedNoChange returns a nochg result if every requested address family
+// has a stored value equal to the requested IP (or is known to be absent).
+func cachedNoChange(userID int64, req *models.DDNSUpdateRequest) *models.DDNSHostResult {
+	hostname := normalizeFQDN(req.Hostname)
+	var hit *ddnsRecordState
+	for _, family := range []struct{ recordType, ip string }{
+		{"A", req.IPv4},
+		{"AAAA", req.IPv6},
+	} {
+		if family.ip == "" {
+			continue
+		}
+		st, err := getDDNSRecordState(userID, hostname, family.recordType)
+		if err != nil || st == nil {
+			return nil
+		}
+		// An empty IP records that the node has no record of this type
+		if st.IP == "" && !req.Create {
+			continue
+		}
+		if st.IP != family.ip {
+			return nil
+		}
+		hit = st
+	}
+	if hit == nil {
+		return nil
+	}
+	return &models.DDNSHostResult{
+		Hostname:   hostname,
+		AccountID:  hit.AccountID,
+		DomainID:   hit.DomainID,
+		DomainName: hit.DomainName,
+		NodeName:   hit.NodeName,
+		Status:     models.DDNSStatusNoChg,
+		Cached:     true,
+	}
+}
+
+// rememberResult stores the addresses a hostname now points at, or forgets
+// them when the outcome is unknown. matched holds the record types that
+// exist (or were created) on the node.
+func (s *DDNSService) rememberResult(userID int64, req *models.DDNSUpdateRequest, result *models.DDNSHostResult, matched map[string]bool) {
+	for _, family := range []struct{ recordType, ip string }{
+		{"A", req.IPv4},
+		{"AAAA", req.IPv6},
+	} {
+		if family.ip == "" {
+			continue
+		}
+		var err error
+		switch result.Status {
+		case models.DDNSStatusUpdated, models.DDNSStatusCreated, models.DDNSStatusNoChg:
+			ip := family.ip
+			if !matched[family.recordType] {
+				ip = ""
+			}
+			err = saveDDNSRecordState(&ddnsRecordState{
+				UserID:     userID,
+				Hostname:   result.Hostname,
+				RecordType: family.recordType,
+				AccountID:  result.AccountID,
+				DomainID:   result.DomainID,
+				DomainName: result.DomainName,
+				NodeName:   result.NodeName,
+				IP:         ip,
+			})
+		default:
+			err = deleteDDNSRecordState(userID, result.Hostname, family.recordType)
+		}
+		if err != nil {
+			log.Printf("Failed to store ddns state for %s %s: %v", result.Hostname, family.recordType, err)
+		}
+	}
+}
+
+// updateHost fills result by querying the provider and returns the record
+// types found on the node.
+func (s *DDNSService) updateHost(ctx context.Context, userID int64, req *models.DDNSUpdateRequest, result *models.DDNSHostResult) map[string]bool {
+	match, err := s.dns.MatchDomain(ctx, userID, req.Hostname)
+	if err != nil {
+		result.Status = models.DDNSStatusNoHost
+		result.Error = err.Error()
+		return nil
+	}
+	result.AccountID = match.AccountID
+	result.DomainID = match.DomainID
+	result.DomainName = match.DomainName
+	result.NodeName = match.NodeName
+
+	records, err := s.dns.ListRecords(ctx, userID, match.AccountID, match.DomainID)
+	if err != nil {
+		result.Status = models.DDNSStatusError
+		result.Error = err.Error()
+		return nil
+	}
+
+	found := map[string]bool{}
+	for _, family := range []struct{ recordType, ip string }{
+		{"A", req.IPv4},
+		{"AAAA", req.IPv6},
+	} {
+		if family.ip == "" {
+			continue
+		}
+
+		matched := false
+		for _, record := range records {
+			if !strings.EqualFold(record.RecordType, family.recordType) || !sameNodeName(record.NodeName, match.NodeName) {
+				continue
+			}
+			matched = true
+			if record.Content == family.ip {
+				continue
+			}
+
+			state := record.State
+			_, err := s.dns.UpdateRecord(ctx, userID, match.AccountID, match.DomainID, record.ID, &models.UpdateRecordRequest{
+				NodeName:   record.NodeName,
+				RecordType: record.RecordType,
+				TTL:        record.TTL,
+				State:      &state,
+				Content:    family.ip,
+				Priority:   record.Priority,
+			})
+			if err != nil {
+				result.Status = models.DDNSStatusError
+				result.Error = err.Error()
+				return nil
+			}
+			result.Changes = append(result.Changes, models.DDNSRecordChange{
+				RecordID:   record.ID,
+				RecordType: record.RecordType,
+				OldIP:      record.Content,
+				NewIP:      family.ip,
+			})
+		}
+
+		if !matched && req.Create {
+			state := true
+			created, err := s.dns.CreateRecord(ctx, userID, match.AccountID, match.DomainID, &models.CreateRecordRequest{
+				NodeName:   match.NodeName,
+				RecordType: family.recordType,
+				State:      &state,
+				Content:    family.ip,
+			})
+			if err != nil {
+				result.Status = models.DDNSStatusError
+				result.Error = err.Error()
+				return nil
+			}
+			matched = true
+			result.Changes = append(result.Changes, models.DDNSRecordChange{
+				RecordID:   created.ID,
+				RecordType: family.recordType,
+				NewIP:      family.ip,
+				Created:    true,
+			})
+		}
+		if matched {
+			found[family.recordType] = true
+		}
+	}
+
+	switch {
+	case len(found) == 0:
+		result.Status = models.DDNSStatusNoHost
+		result.Error = "no A/AAAA record found for hostname"
+	case len(result.Changes) == 0:
+		result.Status = models.DDNSStatusNoChg
+	default:
+		result.Status = models.DDNSStatusUpdated
+		for _, c := range result.Changes {
+			if c.Created {
+				result.Status = models.DDNSStatusCreated
+				break
+			}
+		}
+	}
+	return found
+}
+
+// ReconcileState compares every cached DDNS value with the provider and
+// corrects drift, e.g. records edited directly at the provider. Each zone is
+// listed once. It returns the number of entries checked and corrected.
+func (s *DDNSService) ReconcileState(ctx context.Context) (checked, drifted int, err error) {
+	rows, err := database.DB.Query(
+		`SELECT user_id, hostname, record_type, account_id, domain_id, domain_name, node_name, ip
+		 FROM ddns_record_state
+		 ORDER BY account_id, domain_id`,
+	)
+	if err != nil {
+		return 0, 0, err
+	}
+	var states []ddnsRecordState
+	for rows.Next() {
+		var st ddnsRecordState
+		if err := rows.Scan(&st.UserID, &st.Hostname, &st.RecordType, &st.AccountID, &st.DomainID, &st.DomainName, &st.NodeName, &st.IP); err != nil {
+			rows.Close()
+			return 0, 0, err
+		}
+		states = append(states, st)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	zones := map[string][]models.Record{}
+	failed := map[string]bool{}
+	for i := range states {
+		st := &states[i]
+		key := cacheKey(st.AccountID, st.DomainID)
+		if failed[key] {
+			continue
+		}
+		records, ok := zones[key]
+		if !ok {
+			records, err = s.dns.ListRecords(ctx, st.UserID, st.AccountID, st.DomainID)
+			if err != nil {
+				// Leave the zone alone; a provider outage is not drift
+				log.Printf("DDNS reconcile: list records for account %d domain %s: %v", st.AccountID, st.DomainID, err)
+				failed[key] = true
+				continue
+			}
+			zones[key] = records
+		}
+		checked++
+
+		actual, consistent := "", true
+		for _, record := range records {
+			if !strings.EqualFold(record.RecordType, st.RecordType) || !sameNodeName(record.NodeName, st.NodeName) {
+				continue
+			}
+			if actual != "" && actual != record.Content {
+				consistent = false
+				break
+			}
+			actual = record.Content
+		}
+
+		switch {
+		case !consistent:
+			drifted++
+			err = deleteDDNSRecordState(st.UserID, st.Hostname, st.RecordType)
+		case actual != st.IP:
+			drifted++
+			st.IP = actual
+			err = saveDDNSRecordState(st)
+		default:
+			_, err = database.DB.Exec(
+				`UPDATE ddns_record_state SET checked_at = datetime('now') WHERE user_id = ? AND hostname = ? AND record_type = ?`,
+				st.UserID, st.Hostname, st.RecordType,
+			)
+		}
+		if err != nil {
+			return checked, drifted, err
+		}
+	}
+	return checked, drifted, nil
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"database/sql"
+	"log"
+
+	"dns-mng/database"
+)
+
+// ddnsRecordState is the last-known value of one hostname's A or AAAA
+// records, as written by a successful DDNS update.
+type ddnsRecordState struct {
+	UserID     int64
+	Hostname   string
+	RecordType string
+	AccountID  int64
+	DomainID   string
+	DomainName string
+	NodeName   string
+	IP         string
+}
+
+func getDDNSRecordState(userID int64, hostname, recordType string) (*ddnsRecordState, error) {
+	var st ddnsRecordState
+	err := database.DB.QueryRow(
+		`SELECT user_id, hostname, record_type, account_id, domain_id, domain_name, node_name, ip
+		 FROM ddns_record_state
+		 WHERE user_id = ? AND hostname = ? AND record_type = ?`,
+		userID, hostname, recordType,
+	).Scan(&st.UserID, &st.Hostname, &st.RecordType, &st.AccountID, &st.DomainID, &st.DomainName, &st.NodeName, &st.IP)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+func saveDDNSRecordState(st *ddnsRecordState) error {
+	_, err := database.DB.Exec(
+		`INSERT INTO ddns_record_state (user_id, hostname, record_type, account_id, domain_id, domain_name, node_name, ip, checked_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))
+		 ON CONFLICT(user_id, hostname, record_type) DO UPDATE SET
+		   account_id = excluded.account_id, domain_id = excluded.domain_id, domain_name = excluded.domain_name,
+		   node_name = excluded.node_name, ip = excluded.ip, checked_at = excluded.checked_at, updated_at = excluded.updated_at`,
+		st.UserID, st.Hostname, st.RecordType, st.AccountID, st.DomainID, st.DomainName, st.NodeName, st.IP,
+	)
+	return err
+}
+
+func deleteDDNSRecordState(userID int64, hostname, recordType string) error {
+	_, err := database.DB.Exec(
+		`DELETE FROM ddns_record_state WHERE user_id = ? AND hostname = ? AND record_type = ?`,
+		userID, hostname, recordType,
+	)
+	return err
+}
+
+// invalidateDDNSZoneState forgets every cached DDNS value in a zone. It is
+// called whenever records are changed outside the DDNS fast path, so a
+// manual edit is never masked by a stale nochg.
+func invalidateDDNSZoneState(accountID int64, domainID string) {
+	if database.DB == nil {
+		return
+	}
+	if _, err := database.DB.Exec(
+		`DELETE FROM ddns_record_state WHERE account_id = ? AND domain_id = ?`,
+		accountID, domainID,
+	); err != nil {
+		log.Printf("Failed to invalidate ddns state for account %d domain %s: %v", accountID, domainID, err)
+	}
+}
@@ -4,12 +4,41 @@ import (
 	"crypto/rand"
 	"database/sql"
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
 	"time"
 
 	"dns-mng/database"
 	"dns-mng/models"
 )
 
+// ddnsTimeLayout matches SQLite's datetime('now') output (UTC).
+const ddnsTimeLayout = "2006-01-02 15:04:05"
+
+var (
+	// ErrDDNSTokenDisabled is returned when a disabled token is used
+	ErrDDNSTokenDisabled = errors.New("ddns token is disabled")
+	// ErrDDNSTokenExpired is returned when a token is used after its expiry
+	ErrDDNSTokenExpired = errors.New("ddns token has expired")
+	// ErrDDNSTokenIPNotAllowed is returned when the caller IP is outside the token's allow-list
+	ErrDDNSTokenIPNotAllowed = errors.New("caller ip is not allowed to use this ddns token")
+	// ErrInvalidDDNSTokenScope wraps validation errors for token restrictions
+	ErrInvalidDDNSTokenScope = errors.New("invalid ddns token scope")
+	// ErrInvalidDDNSToken is returned for a custom token value that is too
+	// short or uses characters outside the URL-safe set
+	ErrInvalidDDNSToken = fmt.Errorf("invalid ddns token: use at least %d characters from A-Z a-z 0-9 - _ . ~", minDDNSTokenLength)
+	// ErrDDNSTokenNotFound is returned when the token does not exist or
+	// belongs to another user
+	ErrDDNSTokenNotFound = errors.New("ddns token not found")
+)
+
+// minDDNSTokenLength is the shortest custom token accepted. Tokens travel
+// in URLs and Basic Auth, so they are also limited to unreserved URL
+// characters.
+const minDDNSTokenLength = 16
+
 type DDNSTokenService struct{}
 
 func NewDDNSTokenService() *DDNSTokenService {
@@ -22,6 +51,22 @@ func generateRandomToken() string {
 	return hex.EncodeToString(b)
 }
 
+// validateDDNSToken checks a custom token value
+func validateDDNSToken(token string) error {
+	if len(token) < minDDNSTokenLength {
+		return ErrInvalidDDNSToken
+	}
+	for _, r := range token {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-' || r == '_' || r == '.' || r == '~':
+		default:
+			return ErrInvalidDDNSToken
+		}
+	}
+	return nil
+}
+
 func boolToInt(b bool) int {
 	if b {
 		return 1
@@ -29,67 +74,165 @@ func boolToInt(b bool) int {
 	return 0
 }
 
-// GetTokenByValue retrieves a token by its value
-func (s *DDNSTokenService) GetTokenByValue(tokenValue string) (*models.DDNSToken, error) {
+const ddnsTokenColumns = `id, user_id, name, token, enabled,
+	hostnames, record_types, allowed_ips,
+	COALESCE(expires_at, '') as expires_at,
+	COALESCE(last_used_at, '') as last_used_at,
+	COALESCE(last_ip, '') as last_ip,
+	created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanDDNSToken(row rowScanner) (*models.DDNSToken, error) {
 	var token models.DDNSToken
 	var enabled int
-	err := database.DB.QueryRow(`
-		SELECT id, user_id, token, enabled, 
-			COALESCE(last_used_at, '') as last_used_at, 
-			COALESCE(last_ip, '') as last_ip, 
-			created_at, updated_at
-		FROM ddns_tokens
-		WHERE token = ?
-	`, tokenValue).Scan(
-		&token.ID, &token.UserID, &token.Token, &enabled,
-		&token.LastUsedAt, &token.LastIP, &token.CreatedAt, &token.UpdatedAt,
+	var hostnames, recordTypes, allowedIPs string
+	err := row.Scan(
+		&token.ID, &token.UserID, &token.Name, &token.Token, &enabled,
+		&hostnames, &recordTypes, &allowedIPs,
+		&token.ExpiresAt, &token.LastUsedAt, &token.LastIP, &token.CreatedAt, &token.UpdatedAt,
 	)
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
 	if err != nil {
 		return nil, err
 	}
+	if token.Token, err = DecryptSecret(token.Token); err != nil {
+		return nil, err
+	}
 	token.Enabled = enabled == 1
+	token.Hostnames = splitList(hostnames)
+	token.RecordTypes = splitList(recordTypes)
+	token.AllowedIPs = splitList(allowedIPs)
 	return &token, nil
 }
 
-// GetToken retrieves a token for a user
+// splitList decodes a comma-joined column into a non-nil slice.
+func splitList(s string) []string {
+	out := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// GetTokenByValue retrieves a token by its value. Tokens are stored
+// encrypted, so the lookup goes through token_hash.
+func (s *DDNSTokenService) GetTokenByValue(tokenValue string) (*models.DDNSToken, error) {
+	token, err := scanDDNSToken(database.DB.QueryRow(`
+		SELECT `+ddnsTokenColumns+`
+		FROM ddns_tokens
+		WHERE token_hash = ?
+	`, hashDDNSToken(tokenValue)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return token, err
+}
+
+// GetToken retrieves the user's oldest token. It backs the legacy
+// single-token endpoints (/ddns-token).
 func (s *DDNSTokenService) GetToken(userID int64) (*models.DDNSToken, error) {
-	var token models.DDNSToken
-	var enabled int
-	err := database.DB.QueryRow(`
-		SELECT id, user_id, token, enabled, 
-			COALESCE(last_used_at, '') as last_used_at, 
-			COALESCE(last_ip, '') as last_ip, 
-			created_at, updated_at
+	token, err := scanDDNSToken(database.DB.QueryRow(`
+		SELECT `+ddnsTokenColumns+`
 		FROM ddns_tokens
 		WHERE user_id = ?
-	`, userID).Scan(
-		&token.ID, &token.UserID, &token.Token, &enabled,
-		&token.LastUsedAt, &token.LastIP, &token.CreatedAt, &token.UpdatedAt,
-	)
+		ORDER BY id ASC
+		LIMIT 1
+	`, userID))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
+	return token, err
+}
+
+// GetTokenByID retrieves one of the user's tokens
+func (s *DDNSTokenService) GetTokenByID(userID, id int64) (*models.DDNSToken, error) {
+	token, err := scanDDNSToken(database.DB.QueryRow(`
+		SELECT `+ddnsTokenColumns+`
+		FROM ddns_tokens
+		WHERE id = ? AND user_id = ?
+	`, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return token, err
+}
+
+// ListTokens returns all tokens of a user
+func (s *DDNSTokenService) ListTokens(userID int64) ([]models.DDNSToken, error) {
+	rows, err := database.DB.Query(`
+		SELECT `+ddnsTokenColumns+`
+		FROM ddns_tokens
+		WHERE user_id = ?
+		ORDER BY id ASC
+	`, userID)
 	if err != nil {
 		return nil, err
 	}
-	token.Enabled = enabled == 1
-	return &token, nil
+	defer rows.Close()
+
+	tokens := []models.DDNSToken{}
+	for rows.Next() {
+		token, err := scanDDNSToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *token)
+	}
+	return tokens, rows.Err()
 }
 
-// CreateToken creates a new token for a user
+// CreateToken creates an unrestricted token for a user (legacy endpoint)
 func (s *DDNSTokenService) CreateToken(userID int64, customToken string) (*models.DDNSToken, error) {
-	token := customToken
+	return s.CreateScopedToken(userID, &models.CreateDDNSTokenRequest{
+		Name:  "default",
+		Token: customToken,
+	})
+}
+
+// CreateScopedToken creates a named token with optional restrictions
+func (s *DDNSTokenService) CreateScopedToken(userID int64, req *models.CreateDDNSTokenRequest) (*models.DDNSToken, error) {
+	token := strings.TrimSpace(req.Token)
 	if token == "" {
 		token = generateRandomToken()
+	} else if err := validateDDNSToken(token); err != nil {
+		return nil, err
+	}
+
+	hostnames, err := normalizeDDNSHostnames(req.Hostnames)
+	if err != nil {
+		return nil, err
+	}
+	recordTypes, err := normalizeDDNSRecordTypes(req.RecordTypes)
+	if err != nil {
+		return nil, err
+	}
+	allowedIPs, err := normalizeDDNSAllowedIPs(req.AllowedIPs)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt, err := normalizeDDNSExpiry(req.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	stored, err := EncryptSecret(token)
+	if err != nil {
+		return nil, err
 	}
 
 	result, err := database.DB.Exec(`
-		INSERT INTO ddns_tokens (user_id, token, enabled, created_at, updated_at)
-		VALUES (?, ?, 1, datetime('now'), datetime('now'))
-	`, userID, token)
+		INSERT INTO ddns_tokens (user_id, name, token, token_hash, enabled, hostnames, record_types, allowed_ips, expires_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), datetime('now'), datetime('now'))
+	`, userID, strings.TrimSpace(req.Name), stored, hashDDNSToken(token), boolToInt(enabled),
+		strings.Join(hostnames, ","), strings.Join(recordTypes, ","), strings.Join(allowedIPs, ","), expiresAt)
 	if err != nil {
 		return nil, err
 	}
@@ -99,63 +242,110 @@ func (s *DDNSTokenService) CreateToken(userID int64, customToken string) (*model
 		return nil, err
 	}
 
-	return &models.DDNSToken{
-		ID:        id,
-		UserID:    userID,
-		Token:     token,
-		Enabled:   true,
-		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
-		UpdatedAt: time.Now().Format("2006-01-02 15:04:05"),
-	}, nil
+	return s.GetTokenByID(userID, id)
 }
 
-// UpdateToken updates an existing token
+// UpdateToken updates the user's oldest token (legacy endpoint)
 func (s *DDNSTokenService) UpdateToken(userID int64, enabled *bool, customToken string) (*models.DDNSToken, error) {
-	var token string
-	var id int64
-	var currentEnabled int
-
-	err := database.DB.QueryRow(`
-		SELECT id, token, enabled FROM ddns_tokens WHERE user_id = ?
-	`, userID).Scan(&id, &token, &currentEnabled)
-	if err == sql.ErrNoRows {
-		return nil, nil
+	existing, err := s.GetToken(userID)
+	if err != nil || existing == nil {
+		return nil, err
 	}
-	if err != nil {
+
+	return s.UpdateScopedToken(userID, existing.ID, &models.UpdateScopedDDNSTokenRequest{
+		Token:   customToken,
+		Enabled: enabled,
+	})
+}
+
+// UpdateScopedToken updates one of the user's tokens. Nil request fields are
+// left unchanged. Returns nil when the token does not exist.
+func (s *DDNSTokenService) UpdateScopedToken(userID, id int64, req *models.UpdateScopedDDNSTokenRequest) (*models.DDNSToken, error) {
+	existing, err := s.GetTokenByID(userID, id)
+	if err != nil || existing == nil {
 		return nil, err
 	}
 
-	if customToken != "" {
-		token = customToken
+	name := existing.Name
+	if req.Name != nil {
+		name = strings.TrimSpace(*req.Name)
+	}
+	token := existing.Token
+	if !isMaskedOrEmpty(req.Token) {
+		token = strings.TrimSpace(req.Token)
+		if err := validateDDNSToken(token); err != nil {
+			return nil, err
+		}
+	}
+	enabled := existing.Enabled
+	if req.Enabled != nil {
+		enabled = *req.Enabled
 	}
 
-	enabledVal := currentEnabled
-	if enabled != nil {
-		enabledVal = boolToInt(*enabled)
+	hostnames := existing.Hostnames
+	if req.Hostnames != nil {
+		if hostnames, err = normalizeDDNSHostnames(*req.Hostnames); err != nil {
+			return nil, err
+		}
+	}
+	recordTypes := existing.RecordTypes
+	if req.RecordTypes != nil {
+		if recordTypes, err = normalizeDDNSRecordTypes(*req.RecordTypes); err != nil {
+			return nil, err
+		}
+	}
+	allowedIPs := existing.AllowedIPs
+	if req.AllowedIPs != nil {
+		if allowedIPs, err = normalizeDDNSAllowedIPs(*req.AllowedIPs); err != nil {
+			return nil, err
+		}
+	}
+	expiresAt := existing.ExpiresAt
+	if req.ExpiresAt != nil {
+		if expiresAt, err = normalizeDDNSExpiry(*req.ExpiresAt); err != nil {
+			return nil, err
+		}
+	}
+
+	stored, err := EncryptSecret(token)
+	if err != nil {
+		return nil, err
 	}
 
 	_, err = database.DB.Exec(`
 		UPDATE ddns_tokens
-		SET token = ?, enabled = ?, updated_at = datetime('now')
-		WHERE user_id = ?
-	`, token, enabledVal, userID)
+		SET name = ?, token = ?, token_hash = ?, enabled = ?, hostnames = ?, record_types = ?, allowed_ips = ?,
+			expires_at = NULLIF(?, ''), updated_at = datetime('now')
+		WHERE id = ? AND user_id = ?
+	`, name, stored, hashDDNSToken(token), boolToInt(enabled),
+		strings.Join(hostnames, ","), strings.Join(recordTypes, ","), strings.Join(allowedIPs, ","),
+		expiresAt, id, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	return &models.DDNSToken{
-		ID:        id,
-		UserID:    userID,
-		Token:     token,
-		Enabled:   enabledVal == 1,
-		UpdatedAt: time.Now().Format("2006-01-02 15:04:05"),
-	}, nil
+	return s.GetTokenByID(userID, id)
 }
 
-// DeleteToken deletes a token for a user
+// DeleteToken deletes the user's oldest token (legacy endpoint)
 func (s *DDNSTokenService) DeleteToken(userID int64) error {
-	_, err := database.DB.Exec(`DELETE FROM ddns_tokens WHERE user_id = ?`, userID)
-	return err
+	existing, err := s.GetToken(userID)
+	if err != nil || existing == nil {
+		return err
+	}
+	return s.DeleteTokenByID(userID, existing.ID)
+}
+
+// DeleteTokenByID deletes one of the user's tokens
+func (s *DDNSTokenService) DeleteTokenByID(userID, id int64) error {
+	result, err := database.DB.Exec(`DELETE FROM ddns_tokens WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrDDNSTokenNotFound
+	}
+	return nil
 }
 
 // UpdateLastUsed updates the last used timestamp and IP
@@ -163,7 +353,142 @@ func (s *DDNSTokenService) UpdateLastUsed(tokenValue string, ip string) error {
 	_, err := database.DB.Exec(`
 		UPDATE ddns_tokens
 		SET last_used_at = datetime('now'), last_ip = ?, updated_at = datetime('now')
-		WHERE token = ?
-	`, ip, tokenValue)
+		WHERE token_hash = ?
+	`, ip, hashDDNSToken(tokenValue))
 	return err
 }
+
+// Authorize checks that a token may be used right now from callerIP.
+func (s *DDNSTokenService) Authorize(token *models.DDNSToken, callerIP string) error {
+	if !token.Enabled {
+		return ErrDDNSTokenDisabled
+	}
+	if token.ExpiresAt != "" {
+		expires, err := parseDDNSTime(token.ExpiresAt)
+		if err != nil || !time.Now().UTC().Before(expires) {
+			return ErrDDNSTokenExpired
+		}
+	}
+	if len(token.AllowedIPs) == 0 {
+		return nil
+	}
+
+	ip := net.ParseIP(callerIP)
+	if ip == nil {
+		return ErrDDNSTokenIPNotAllowed
+	}
+	for _, allowed := range token.AllowedIPs {
+		if _, cidr, err := net.ParseCIDR(allowed); err == nil {
+			if cidr.Contains(ip) {
+				return nil
+			}
+		} else if allowedIP := net.ParseIP(allowed); allowedIP != nil && allowedIP.Equal(ip) {
+			return nil
+		}
+	}
+	return ErrDDNSTokenIPNotAllowed
+}
+
+// AllowsHostname reports whether the token may update the given hostname.
+// "*.example.com" matches any name below example.com but not the apex.
+func (s *DDNSTokenService) AllowsHostname(token *models.DDNSToken, hostname string) bool {
+	if len(token.Hostnames) == 0 {
+		return true
+	}
+	hostname = normalizeFQDN(hostname)
+	for _, pattern := range token.Hostnames {
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+			if strings.HasSuffix(hostname, "."+suffix) {
+				return true
+			}
+		} else if hostname == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsRecordType reports whether the token may update records of the given type.
+func (s *DDNSTokenService) AllowsRecordType(token *models.DDNSToken, recordType string) bool {
+	if len(token.RecordTypes) == 0 {
+		return true
+	}
+	for _, t := range token.RecordTypes {
+		if strings.EqualFold(t, recordType) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeDDNSHostnames(in []string) ([]string, error) {
+	out := []string{}
+	for _, h := range in {
+		h = normalizeFQDN(h)
+		if h == "" {
+			continue
+		}
+		if strings.Contains(h, ",") || !strings.Contains(strings.TrimPrefix(h, "*."), ".") {
+			return nil, fmt.Errorf("%w: invalid hostname %s", ErrInvalidDDNSTokenScope, h)
+		}
+		out = append(out, h)
+	}
+	return out, nil
+}
+
+func normalizeDDNSRecordTypes(in []string) ([]string, error) {
+	out := []string{}
+	for _, t := range in {
+		t = strings.ToUpper(strings.TrimSpace(t))
+		if t == "" {
+			continue
+		}
+		if t != "A" && t != "AAAA" {
+			return nil, fmt.Errorf("%w: invalid record type %s (expected A or AAAA)", ErrInvalidDDNSTokenScope, t)
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+func normalizeDDNSAllowedIPs(in []string) ([]string, error) {
+	out := []string{}
+	for _, a := range in {
+		a = strings.TrimSpace(a)
+		if a == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(a); err != nil && net.ParseIP(a) == nil {
+			return nil, fmt.Errorf("%w: invalid ip or cidr %s", ErrInvalidDDNSTokenScope, a)
+		}
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+// parseDDNSTime parses a timestamp read back from a DATETIME column, which
+// the SQLite drivers may return either in SQLite's own layout or as RFC3339.
+// A bare date is also accepted and means midnight UTC.
+func parseDDNSTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UTC(), nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(ddnsTimeLayout, s)
+}
+
+// normalizeDDNSExpiry converts an RFC3339 or "2006-01-02 15:04:05" time to
+// the UTC layout stored in the database. Empty means no expiry.
+func normalizeDDNSExpiry(in string) (string, error) {
+	in = strings.TrimSpace(in)
+	if in == "" {
+		return "", nil
+	}
+	t, err := parseDDNSTime(in)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid expires_at %s", ErrInvalidDDNSTokenScope, in)
+	}
+	return t.Format(ddnsTimeLayout), nil
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"dns-mng/database"
+	"dns-mng/models"
+)
+
+func TestDDNSTokenAuthorize(t *testing.T) {
+	s := NewDDNSTokenService()
+	past := time.Now().UTC().Add(-time.Hour).Format(ddnsTimeLayout)
+	future := time.Now().UTC().Add(time.Hour).Format(ddnsTimeLayout)
+	allowList := []string{"192.0.2.10", "198.51.100.0/24", "2001:db8::/32"}
+
+	tests := []struct {
+		name     string
+		token    models.DDNSToken
+		callerIP string
+		want     error
+	}{
+		{"unrestricted", models.DDNSToken{Enabled: true}, "203.0.113.1", nil},
+		{"unrestricted without caller ip", models.DDNSToken{Enabled: true}, "", nil},
+		{"disabled", models.DDNSToken{Enabled: false}, "203.0.113.1", ErrDDNSTokenDisabled},
+		{"expired", models.DDNSToken{Enabled: true, ExpiresAt: past}, "203.0.113.1", ErrDDNSTokenExpired},
+		{"not yet expired", models.DDNSToken{Enabled: true, ExpiresAt: future}, "203.0.113.1", nil},
+		{"unparseable expiry", models.DDNSToken{Enabled: true, ExpiresAt: "soon"}, "203.0.113.1", ErrDDNSTokenExpired},
+		{"disabled wins over expiry", models.DDNSToken{Enabled: false, ExpiresAt: past}, "203.0.113.1", ErrDDNSTokenDisabled},
+		{"allowed exact ip", models.DDNSToken{Enabled: true, AllowedIPs: allowList}, "192.0.2.10", nil},
+		{"allowed cidr", models.DDNSToken{Enabled: true, AllowedIPs: allowList}, "198.51.100.77", nil},
+		{"allowed ipv6 cidr", models.DDNSToken{Enabled: true, AllowedIPs: allowList}, "2001:db8::1", nil},
+		{"outside allow-list", models.DDNSToken{Enabled: true, AllowedIPs: allowList}, "192.0.2.11", ErrDDNSTokenIPNotAllowed},
+		{"unverified caller ip", models.DDNSToken{Enabled: true, AllowedIPs: allowList}, "", ErrDDNSTokenIPNotAllowed},
+		{"garbage caller ip", models.DDNSToken{Enabled: true, AllowedIPs: allowList}, "192.0.2.10:80", ErrDDNSTokenIPNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.Authorize(&tt.token, tt.callerIP); !errors.Is(err, tt.want) {
+				t.Errorf("Authorize = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDDNSTokenAllowsHostname(t *testing.T) {
+	s := NewDDNSTokenService()
+	scoped := &models.DDNSToken{Hostnames: []string{"home.example.com", "*.lab.example.net"}}
+
+	tests := []struct {
+		token    *models.DDNSToken
+		hostname string
+		want     bool
+	}{
+		{&models.DDNSToken{}, "anything.example.org", true},
+		{scoped, "home.example.com", true},
+		{scoped, "HOME.Example.com.", true},
+		{scoped, "nas.example.com", false},
+		{scoped, "sub.home.example.com", false},
+		{scoped, "nas.lab.example.net", true},
+		{scoped, "a.b.lab.example.net", true},
+		{scoped, "lab.example.net", false},
+		{scoped, "evillab.example.net", false},
+	}
+
+	for _, tt := range tests {
+		if got := s.AllowsHostname(tt.token, tt.hostname); got != tt.want {
+			t.Errorf("AllowsHostname(%v, %q) = %v, want %v", tt.token.Hostnames, tt.hostname, got, tt.want)
+		}
+	}
+}
+
+func TestDDNSTokenCustomValueAndDelete(t *testing.T) {
+	resetSecretStore(t)
+	openTestDB(t)
+	if _, err := database.DB.Exec(`INSERT INTO users (id, username, password_hash) VALUES (1, 'admin', 'x'), (2, 'other', 'x')`); err != nil {
+		t.Fatal(err)
+	}
+	s := NewDDNSTokenService()
+
+	for _, value := range []string{"short", "has space in the value", "router/token/value", "ünïcödé-token-value"} {
+		if _, err := s.CreateToken(1, value); !errors.Is(err, ErrInvalidDDNSToken) {
+			t.Errorf("CreateToken(%q) = %v, want ErrInvalidDDNSToken", value, err)
+		}
+	}
+	token, err := s.CreateToken(1, "Router-1_home.lan~x")
+	if err != nil {
+		t.Fatalf("CreateToken: %v", err)
+	}
+	if _, err := s.UpdateScopedToken(1, token.ID, &models.UpdateScopedDDNSTokenRequest{Token: "too-short"}); !errors.Is(err, ErrInvalidDDNSToken) {
+		t.Errorf("UpdateScopedToken with a short value = %v, want ErrInvalidDDNSToken", err)
+	}
+	generated, err := s.CreateToken(1, "")
+	if err != nil || len(generated.Token) != 64 {
+		t.Fatalf("generated token = %+v, %v", generated, err)
+	}
+
+	if err := s.DeleteTokenByID(2, token.ID); !errors.Is(err, ErrDDNSTokenNotFound) {
+		t.Errorf("deleting another user's token = %v, want ErrDDNSTokenNotFound", err)
+	}
+	if err := s.DeleteTokenByID(1, token.ID); err != nil {
+		t.Fatalf("DeleteTokenByID: %v", err)
+	}
+	if err := s.DeleteTokenByID(1, token.ID); !errors.Is(err, ErrDDNSTokenNotFound) {
+		t.Errorf("deleting twice = %v, want ErrDDNSTokenNotFound", err)
+	}
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+)
+
+// ErrUnsupportedLookupType is returned by LookupDNS for record types it cannot query
+var ErrUnsupportedLookupType = errors.New("unsupported record type")
+
+// PublicDNSServers are queried to avoid local DNS pollution
+var PublicDNSServers = []string{
+	"8.8.8.8:53",        // Google DNS
+	"1.1.1.1:53",        // Cloudflare DNS
+	"208.67.222.222:53", // OpenDNS
+}
+
+// LookupDNS queries domain on each server in turn and returns the first
+// non-empty answer together with the server that gave it.
+func LookupDNS(ctx context.Context, servers []string, domain, recordType string) ([]string, string, error) {
+	var values []string
+	var err error
+	for _, dnsServer := range servers {
+		dnsServer := dnsServer
+		resolver := &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				d := net.Dialer{
+					Timeout: 5 * time.Second,
+				}
+				return d.DialContext(ctx, network, dnsServer)
+			},
+		}
+
+		switch recordType {
+		case "A":
+			values, err = lookupAWithResolver(ctx, resolver, domain)
+		case "AAAA":
+			values, err = lookupAAAAWithResolver(ctx, resolver, domain)
+		case "CNAME":
+			values, err = lookupCNAMEWithResolver(ctx, resolver, domain)
+		case "MX":
+			values, err = lookupMXWithResolver(ctx, resolver, domain)
+		case "TXT":
+			values, err = lookupTXTWithResolver(ctx, resolver, domain)
+		case "NS":
+			values, err = lookupNSWithResolver(ctx, resolver, domain)
+		default:
+			return nil, "", ErrUnsupportedLookupType
+		}
+
+		// If successful, use this result
+		if err == nil && len(values) > 0 {
+			return values, dnsServer, nil
+		}
+	}
+	return values, "", err
+}
+
+// NormalizeDNSValue normalizes DNS values for comparison
+func NormalizeDNSValue(value string) string {
+	// Trim spaces
+	value = strings.TrimSpace(value)
+	// Remove surrounding double quotes (common in DNS provider TXT record display)
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		value = value[1 : len(value)-1]
+	}
+	// Convert to lowercase
+	value = strings.ToLower(value)
+	// Remove trailing dot (common in DNS responses)
+	value = strings.TrimSuffix(value, ".")
+	return value
+}
+
+// lookupAWithResolver queries A records with custom resolver
+func lookupAWithResolver(ctx context.Context, resolver *net.Resolver, domain string) ([]string, error) {
+	ips, err := resolver.LookupIP(ctx, "ip4", domain)
+	if err != nil {
+		return nil, err
+	}
+	var results []string
+	for _, ip := range ips {
+		results = append(results, ip.String())
+	}
+	return results, nil
+}
+
+// lookupAAAAWithResolver queries AAAA records with custom resolver
+func lookupAAAAWithResolver(ctx context.Context, resolver *net.Resolver, domain string) ([]string, error) {
+	ips, err := resolver.LookupIP(ctx, "ip6", domain)
+	if err != nil {
+		return nil, err
+	}
+	var results []string
+	for _, ip := range ips {
+		results = append(results, ip.String())
+	}
+	return results, nil
+}
+
+// lookupCNAMEWithResolver queries CNAME records with custom resolver
+func lookupCNAMEWithResolver(ctx context.Context, resolver *net.Resolver, domain string) ([]string, error) {
+	cname, err := resolver.LookupCNAME(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	return []string{strings.TrimSuffix(cname, ".")}, nil
+}
+
+// lookupMXWithResolver queries MX records with custom resolver
+func lookupMXWithResolver(ctx context.Context, resolver *net.Resolver, domain string) ([]string, error) {
+	mxs, err := resolver.LookupMX(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	var results []string
+	for _, mx := range mxs {
+		results = append(results, strings.TrimSuffix(mx.Host, "."))
+	}
+	return results, nil
+}
+
+// lookupTXTWithResolver queries TXT records with custom resolver
+func lookupTXTWithResolver(ctx context.Context, resolver *net.Resolver, domain string) ([]string, error) {
+	return resolver.LookupTXT(ctx, domain)
+}
+
+// lookupNSWithResolver queries NS records with custom resolver
+func lookupNSWithResolver(ctx context.Context, resolver *net.Resolver, domain string) ([]string, error) {
+	nss, err := resolver.LookupNS(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	var results []string
+	for _, ns := range nss {
+		results = append(results, strings.TrimSuffix(ns.Host, "."))
+	}
+	return results, nil
+}
@@ -2,9 +2,13 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"dns-mng/models"
 	"dns-mng/provider"
+	"errors"
 	"fmt"
+	"log"
+	"strings"
 	"sync"
 	"time"
 )
@@ -12,12 +16,16 @@ import (
 type DNSService struct {
 	accountService     *AccountService
 	domainCacheService *DomainCacheService
+	recordCacheService *RecordCacheService
+	webhookService     *WebhookService
 }
 
-func NewDNSService(accountService *AccountService, domainCacheService *DomainCacheService) *DNSService {
+func NewDNSService(accountService *AccountService, domainCacheService *DomainCacheService, recordCacheService *RecordCacheService, webhookService *WebhookService) *DNSService {
 	return &DNSService{
 		accountService:     accountService,
 		domainCacheService: domainCacheService,
+		recordCacheService: recordCacheService,
+		webhookService:     webhookService,
 	}
 }
 
@@ -90,13 +98,47 @@ func (s *DNSService) ListAllDomainsFromCache(ctx context.Context, userID int64)
 
 // ListAllDomainsFromProvider fetches domains from DNS providers and updates cache
 func (s *DNSService) ListAllDomainsFromProvider(ctx context.Context, userID int64) ([]models.Domain, error) {
+	domains, _, err := s.listAllDomainsFromProvider(ctx, userID)
+	return domains, err
+}
+
+// recordListing marks an account whose listing failed as failing, and
+// clears the mark once a listing succeeds again. An open breaker or a
+// cancelled request says nothing new about the account.
+func (s *DNSService) recordListing(ctx context.Context, accounts []models.Account, accountID int64, domainCount int, err error) {
+	if ctx.Err() != nil || errors.Is(err, provider.ErrCircuitOpen) {
+		return
+	}
+	for _, acc := range accounts {
+		if acc.ID != accountID {
+			continue
+		}
+		if err == nil && acc.FailingSince == nil {
+			return
+		}
+		check := &models.AccountCheck{OK: err == nil, Code: provider.CheckCode(ctx, err), DomainCount: domainCount, CheckedAt: time.Now()}
+		if err != nil {
+			check.Error = err.Error()
+		}
+		if rerr := s.accountService.RecordCheck(accountID, check); rerr != nil {
+			log.Printf("Failed to record listing of account %d: %v", accountID, rerr)
+		}
+		return
+	}
+}
+
+// listAllDomainsFromProvider is ListAllDomainsFromProvider that also returns
+// the accounts whose provider call failed, keyed by account ID. Their cached
+// domains must not be taken as removed.
+func (s *DNSService) listAllDomainsFromProvider(ctx context.Context, userID int64) ([]models.Domain, map[int64]error, error) {
 	accounts, err := s.accountService.List(userID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Use goroutines to fetch domains concurrently
 	type result struct {
+		accountID       int64
 		domains         []models.Domain
 		domainsToDelete []string
 		err             error
@@ -112,11 +154,11 @@ func (s *DNSService) ListAllDomainsFromProvider(ctx context.Context, userID int6
 
 			domains, domainsToDelete, err := s.listDomainsFromProviderForAccount(ctx, userID, acc)
 			if err != nil {
-				results <- result{err: err}
+				results <- result{accountID: acc.ID, err: err}
 				return
 			}
 
-			results <- result{domains: domains, domainsToDelete: domainsToDelete}
+			results <- result{accountID: acc.ID, domains: domains, domainsToDelete: domainsToDelete}
 		}(account)
 	}
 
@@ -128,11 +170,14 @@ func (s *DNSService) ListAllDomainsFromProvider(ctx context.Context, userID int6
 
 	// Collect all results
 	var allDomains []models.Domain
+	failed := make(map[int64]error)
 	for res := range results {
-		if res.err == nil && res.domains != nil {
+		if res.err != nil {
+			failed[res.accountID] = res.err
+		} else {
 			allDomains = append(allDomains, res.domains...)
 		}
-		// Silently ignore errors from individual accounts
+		s.recordListing(ctx, accounts, res.accountID, len(res.domains), res.err)
 	}
 
 	// Merge domain cache data and save provider's renewal date to cache
@@ -192,7 +237,7 @@ func (s *DNSService) ListAllDomainsFromProvider(ctx context.Context, userID int6
 		filtered = append(filtered, d)
 	}
 
-	return filtered, nil
+	return filtered, failed, nil
 }
 func (s *DNSService) listDomainsFromProviderForAccount(ctx context.Context, userID int64, account models.Account) ([]models.Domain, []string, error) {
 	p, err := provider.Get(account.ProviderType)
@@ -200,7 +245,11 @@ func (s *DNSService) listDomainsFromProviderForAccount(ctx context.Context, user
 		return nil, nil, err
 	}
 
-	domains, err := p.ListDomains(ctx, account.APIKey)
+	var domains []models.Domain
+	err = provider.Do(ctx, p, account.ID, provider.RetrySafe, func(ctx context.Context) (err error) {
+		domains, err = p.ListDomains(ctx, account.APIKey)
+		return err
+	})
 	if err != nil {
 		return nil, nil, err
 	}
@@ -229,6 +278,7 @@ func (s *DNSService) listDomainsFromProviderForAccount(ctx context.Context, user
 					domainsToDelete = append(domainsToDelete, cache.DomainID)
 				}
 			}
+			s.emitDomainChanges(userID, &account, domains, cacheMap)
 		}
 	}
 
@@ -374,7 +424,11 @@ func (s *DNSService) ListDomainsFromProvider(ctx context.Context, userID, accoun
 		return nil, nil, err
 	}
 
-	domains, err := p.ListDomains(ctx, account.APIKey)
+	var domains []models.Domain
+	err = provider.Do(ctx, p, account.ID, provider.RetrySafe, func(ctx context.Context) (err error) {
+		domains, err = p.ListDomains(ctx, account.APIKey)
+		return err
+	})
 	if err != nil {
 		return nil, nil, err
 	}
@@ -403,6 +457,7 @@ func (s *DNSService) ListDomainsFromProvider(ctx context.Context, userID, accoun
 					domainsToDelete = append(domainsToDelete, cache.DomainID)
 				}
 			}
+			s.emitDomainChanges(userID, account, domains, cacheMap)
 
 			// 跨账户回填（前置）：当前账户非 DNSHE 时，用 DNSHE 缓存中的 renewal_date + renewal_url
 			// 回填到本账户同名域名（DNSHE 域名解析至第三方后，第三方域名无过期时间/续费地址）。
@@ -487,7 +542,11 @@ func (s *DNSService) GetDomain(ctx context.Context, userID, accountID int64, dom
 		return nil, err
 	}
 
-	domain, err := p.GetDomain(ctx, account.APIKey, domainID)
+	var domain *models.Domain
+	err = provider.Do(ctx, p, account.ID, provider.RetrySafe, func(ctx context.Context) (err error) {
+		domain, err = p.GetDomain(ctx, account.APIKey, domainID)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -509,18 +568,239 @@ func (s *DNSService) GetDomain(ctx context.Context, userID, accountID int64, dom
 	return domain, nil
 }
 
+// ListRecords always asks the provider and re-syncs the record cache on
+// the way. Internal callers (DDNS, ACME) rely on it being live.
 func (s *DNSService) ListRecords(ctx context.Context, userID, accountID int64, domainID string) ([]models.Record, error) {
+	records, _, err := s.listRecordsLive(ctx, userID, accountID, domainID)
+	return records, err
+}
+
+func (s *DNSService) listRecordsLive(ctx context.Context, userID, accountID int64, domainID string) ([]models.Record, []models.RecordChange, error) {
 	account, err := s.accountService.Get(userID, accountID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	p, err := provider.Get(account.ProviderType)
 	if err != nil {
+		return nil, nil, err
+	}
+	return s.syncZone(ctx, userID, account, p, domainID)
+}
+
+// syncZone lists a zone at the provider and re-syncs its record cache
+func (s *DNSService) syncZone(ctx context.Context, userID int64, account *models.Account, p provider.DNSProvider, domainID string) ([]models.Record, []models.RecordChange, error) {
+	var records []models.Record
+	err := provider.Do(ctx, p, account.ID, provider.RetrySafe, func(ctx context.Context) (err error) {
+		records, err = p.ListRecords(ctx, account.APIKey, domainID)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var changes []models.RecordChange
+	if s.recordCacheService != nil {
+		changes, err = s.recordCacheService.Sync(userID, account.ID, domainID, records)
+		if err != nil {
+			log.Printf("record cache: sync %d/%s failed: %v", account.ID, domainID, err)
+		} else if len(changes) > 0 {
+			log.Printf("record cache: %d external change(s) in %d/%s", len(changes), account.ID, domainID)
+		}
+	}
+	return records, changes, nil
+}
+
+// SyncRecordCaches re-syncs the record cache of every listed zone, so
+// external edits are recorded even in zones nobody opens. Failed zones are
+// logged and skipped.
+func (s *DNSService) SyncRecordCaches(ctx context.Context, userID int64, domains []models.Domain) {
+	if s.recordCacheService == nil {
+		return
+	}
+	accounts := make(map[int64]*models.Account)
+	providers := make(map[int64]provider.DNSProvider)
+	synced, changed := 0, 0
+	for _, d := range domains {
+		if ctx.Err() != nil {
+			break
+		}
+		account, seen := accounts[d.AccountID]
+		if !seen {
+			if acc, err := s.accountService.Get(userID, d.AccountID); err == nil {
+				if p, err := provider.Get(acc.ProviderType); err == nil {
+					account = acc
+					providers[acc.ID] = p
+				}
+			}
+			accounts[d.AccountID] = account
+		}
+		if account == nil {
+			continue
+		}
+		_, changes, err := s.syncZone(ctx, userID, account, providers[account.ID], d.ID)
+		if err != nil {
+			log.Printf("record cache: sync %d/%s failed: %v", account.ID, d.ID, err)
+			continue
+		}
+		synced++
+		changed += len(changes)
+	}
+	log.Printf("record cache: synced %d/%d zone(s) of user %d, %d external change(s)", synced, len(domains), userID, changed)
+}
+
+// ListRecordsCached serves records from the local cache while it is younger
+// than recordCacheMaxAge, otherwise (or when refresh is set) re-syncs from
+// the provider. If the provider is unreachable an existing cache is served
+// as stale instead of failing.
+func (s *DNSService) ListRecordsCached(ctx context.Context, userID, accountID int64, domainID string, refresh bool) (*models.RecordListResult, error) {
+	if s.recordCacheService == nil {
+		records, err := s.ListRecords(ctx, userID, accountID, domainID)
+		if err != nil {
+			return nil, err
+		}
+		return &models.RecordListResult{Records: records, CacheStatus: models.RecordCacheMiss}, nil
+	}
+
+	// Ownership check before touching the cache
+	if _, err := s.accountService.Get(userID, accountID); err != nil {
+		return nil, err
+	}
+
+	cached, syncedAt, ok, err := s.recordCacheService.Get(accountID, domainID)
+	if err != nil {
+		log.Printf("record cache: read %d/%s failed: %v", accountID, domainID, err)
+		ok = false
+	}
+	if ok && !refresh && time.Since(syncedAt) < recordCacheMaxAge {
+		return &models.RecordListResult{Records: cached, CacheStatus: models.RecordCacheHit, SyncedAt: &syncedAt}, nil
+	}
+
+	records, changes, err := s.listRecordsLive(ctx, userID, accountID, domainID)
+	if err != nil {
+		if ok {
+			return &models.RecordListResult{Records: cached, CacheStatus: models.RecordCacheStale, SyncedAt: &syncedAt}, nil
+		}
 		return nil, err
 	}
+	now := time.Now()
+	return &models.RecordListResult{Records: records, CacheStatus: models.RecordCacheMiss, SyncedAt: &now, Changes: changes}, nil
+}
 
-	return p.ListRecords(ctx, account.APIKey, domainID)
+// ListRecordChanges returns record edits detected outside dns-mng
+func (s *DNSService) ListRecordChanges(userID, accountID int64, domainID string, page, pageSize int) (*models.RecordChangeListResponse, error) {
+	if s.recordCacheService == nil {
+		return nil, fmt.Errorf("record cache service not available")
+	}
+	if _, err := s.accountService.Get(userID, accountID); err != nil {
+		return nil, err
+	}
+	return s.recordCacheService.ListChanges(userID, accountID, domainID, page, pageSize)
+}
+
+// recordWriteResync is how old a zone's cached copy may be before a write
+// re-syncs it first. DDNS and ACME list the zone right before writing, so
+// they do not pay for a second listing.
+const recordWriteResync = 30 * time.Second
+
+// syncBeforeWrite re-syncs a cached zone before we change it, so external
+// edits made since the last sync are recorded before cacheInvalidate drops
+// the baseline. A zone without a cached copy has no baseline to lose. A
+// failed listing is logged and does not block the write.
+func (s *DNSService) syncBeforeWrite(ctx context.Context, userID int64, account *models.Account, p provider.DNSProvider, domainID string) {
+	if s.recordCacheService == nil {
+		return
+	}
+	syncedAt, ok, err := s.recordCacheService.SyncedAt(account.ID, domainID)
+	if err != nil || !ok || time.Since(syncedAt) < recordWriteResync {
+		return
+	}
+	if _, _, err := s.syncZone(ctx, userID, account, p, domainID); err != nil {
+		log.Printf("record cache: sync %d/%s before write failed: %v", account.ID, domainID, err)
+	}
+}
+
+// cacheInvalidate drops a zone's record cache after we changed it. Several
+// providers return IDs from writes that differ from what ListRecords reports
+// (synthetic or position-based), so writing the result through would make
+// the next sync report our own change as external; the next listing sets a
+// fresh baseline instead.
+func (s *DNSService) cacheInvalidate(accountID int64, domainID string) {
+	if s.recordCacheService == nil {
+		return
+	}
+	if err := s.recordCacheService.Invalidate(accountID, domainID); err != nil {
+		log.Printf("record cache: invalidate %d/%s failed: %v", accountID, domainID, err)
+	}
+}
+
+// emit sends a change event to the user's webhooks
+func (s *DNSService) emit(userID, accountID int64, domainID, domainName, event string, data interface{}) {
+	if s.webhookService == nil {
+		return
+	}
+	if domainName == "" && s.domainCacheService != nil {
+		if cache, err := s.domainCacheService.GetCache(userID, accountID, domainID); err == nil && cache != nil {
+			domainName = cache.DomainName
+		}
+	}
+	s.webhookService.Emit(userID, &models.WebhookEvent{
+		Event:     event,
+		AccountID: accountID,
+		DomainID:  domainID,
+		Domain:    domainName,
+		Data:      data,
+	})
+}
+
+// cachedRecord returns the cached copy of a record before we change it
+func (s *DNSService) cachedRecord(accountID int64, domainID, recordID string) *models.Record {
+	if s.recordCacheService == nil {
+		return nil
+	}
+	return s.recordCacheService.GetRecord(accountID, domainID, recordID)
+}
+
+// emitDomainChanges reports zones that appeared at or disappeared from the
+// provider since the last refresh. cacheMap is the domain cache before the
+// refresh; an account without cached zones is a first sync and only sets
+// the baseline.
+func (s *DNSService) emitDomainChanges(userID int64, account *models.Account, domains []models.Domain, cacheMap map[string]*models.DomainCache) {
+	if s.webhookService == nil {
+		return
+	}
+	hasCache := false
+	for _, cache := range cacheMap {
+		if cache.AccountID == account.ID {
+			hasCache = true
+			break
+		}
+	}
+	if !hasCache {
+		return
+	}
+
+	seen := make(map[string]bool, len(domains))
+	for _, d := range domains {
+		key := cacheKey(account.ID, d.ID)
+		seen[key] = true
+		if _, ok := cacheMap[key]; !ok {
+			s.emit(userID, account.ID, d.ID, d.Name, models.WebhookEventDomainAdded, map[string]interface{}{"domain": d})
+		}
+	}
+	for key, cache := range cacheMap {
+		if cache.AccountID != account.ID || seen[key] {
+			continue
+		}
+		// DNSHE 第三方解析的域名不参与删除检测，与刷新接口一致
+		if account.ProviderType == "dnshe" && !cache.UsesDNSHEDNS {
+			continue
+		}
+		s.emit(userID, account.ID, cache.DomainID, cache.DomainName, models.WebhookEventDomainRemoved, map[string]interface{}{
+			"domain_id":   cache.DomainID,
+			"domain_name": cache.DomainName,
+		})
+	}
 }
 
 func (s *DNSService) CreateRecord(ctx context.Context, userID, accountID int64, domainID string, req *models.CreateRecordRequest) (*models.Record, error) {
@@ -539,6 +819,10 @@ func (s *DNSService) CreateRecord(ctx context.Context, userID, accountID int64,
 		state = *req.State
 	}
 
+	raw, err := recordLine(p, req.Line)
+	if err != nil {
+		return nil, err
+	}
 	record := &models.Record{
 		NodeName:   req.NodeName,
 		RecordType: req.RecordType,
@@ -546,13 +830,26 @@ func (s *DNSService) CreateRecord(ctx context.Context, userID, accountID int64,
 		State:      state,
 		Content:    req.Content,
 		Priority:   req.Priority,
+		Raw:        raw,
 	}
 
 	if record.TTL == 0 {
 		record.TTL = p.DefaultTTL()
 	}
 
-	return p.CreateRecord(ctx, account.APIKey, domainID, record)
+	s.syncBeforeWrite(ctx, userID, account, p, domainID)
+	var created *models.Record
+	err = provider.Do(ctx, p, account.ID, provider.RetryThrottled, func(ctx context.Context) (err error) {
+		created, err = p.CreateRecord(ctx, account.APIKey, domainID, record)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	invalidateDDNSZoneState(account.ID, domainID)
+	s.cacheInvalidate(account.ID, domainID)
+	s.emit(userID, account.ID, domainID, "", models.WebhookEventRecordCreated, map[string]interface{}{"record": created})
+	return created, nil
 }
 
 func (s *DNSService) UpdateRecord(ctx context.Context, userID, accountID int64, domainID, recordID string, req *models.UpdateRecordRequest) (*models.Record, error) {
@@ -571,6 +868,10 @@ func (s *DNSService) UpdateRecord(ctx context.Context, userID, accountID int64,
 		state = *req.State
 	}
 
+	raw, err := recordLine(p, req.Line)
+	if err != nil {
+		return nil, err
+	}
 	record := &models.Record{
 		ID:         recordID,
 		NodeName:   req.NodeName,
@@ -579,15 +880,28 @@ func (s *DNSService) UpdateRecord(ctx context.Context, userID, accountID int64,
 		State:      state,
 		Content:    req.Content,
 		Priority:   req.Priority,
+		Raw:        raw,
 	}
 
-	updatedRecord, err := p.UpdateRecord(ctx, account.APIKey, domainID, record)
+	s.syncBeforeWrite(ctx, userID, account, p, domainID)
+	previous := s.cachedRecord(account.ID, domainID, recordID)
+	var updatedRecord *models.Record
+	err = provider.Do(ctx, p, account.ID, provider.RetryThrottled, func(ctx context.Context) (err error) {
+		updatedRecord, err = p.UpdateRecord(ctx, account.APIKey, domainID, record)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
+	invalidateDDNSZoneState(account.ID, domainID)
 	if updatedRecord != nil && updatedRecord.UpdatedOn == "" {
 		updatedRecord.UpdatedOn = time.Now().Format(time.RFC3339)
 	}
+	s.cacheInvalidate(account.ID, domainID)
+	s.emit(userID, account.ID, domainID, "", models.WebhookEventRecordUpdated, map[string]interface{}{
+		"record":   updatedRecord,
+		"previous": previous,
+	})
 
 	return updatedRecord, nil
 }
@@ -603,7 +917,21 @@ func (s *DNSService) DeleteRecord(ctx context.Context, userID, accountID int64,
 		return err
 	}
 
-	return p.DeleteRecord(ctx, account.APIKey, domainID, recordID)
+	s.syncBeforeWrite(ctx, userID, account, p, domainID)
+	previous := s.cachedRecord(account.ID, domainID, recordID)
+	err = provider.Do(ctx, p, account.ID, provider.RetryThrottled, func(ctx context.Context) error {
+		return p.DeleteRecord(ctx, account.APIKey, domainID, recordID)
+	})
+	if err != nil {
+		return err
+	}
+	invalidateDDNSZoneState(account.ID, domainID)
+	s.cacheInvalidate(account.ID, domainID)
+	s.emit(userID, account.ID, domainID, "", models.WebhookEventRecordDeleted, map[string]interface{}{
+		"record_id": recordID,
+		"previous":  previous,
+	})
+	return nil
 }
 
 // UpdateDomainCache updates the renewal info for a domain
@@ -664,3 +992,377 @@ func (s *DNSService) BatchRestoreDomains(ctx context.Context, userID int64, item
 
 	return s.domainCacheService.BatchRestoreCache(userID, items)
 }
+
+// accountProvider loads the account and its registered provider.
+func (s *DNSService) accountProvider(userID, accountID int64) (*models.Account, provider.DNSProvider, error) {
+	account, err := s.accountService.Get(userID, accountID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, ErrAccountNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p, err := provider.Get(account.ProviderType)
+	if err != nil {
+		return nil, nil, err
+	}
+	return account, p, nil
+}
+
+// GetCapabilities returns the optional capabilities of the account's provider.
+func (s *DNSService) GetCapabilities(userID, accountID int64) (*models.AccountCapabilities, error) {
+	account, p, err := s.accountProvider(userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	caps := make([]string, 0)
+	for _, c := range provider.Capabilities(p) {
+		caps = append(caps, string(c))
+	}
+	return &models.AccountCapabilities{
+		AccountID:    account.ID,
+		ProviderType: account.ProviderType,
+		Capabilities: caps,
+	}, nil
+}
+
+// BatchCreateRecords creates several records at once. Providers implementing
+// provider.BatchRecordWriter get a single API call; others fall back to
+// creating the records one by one, stopping at the first failure.
+func (s *DNSService) BatchCreateRecords(ctx context.Context, userID, accountID int64, domainID string, reqs []models.CreateRecordRequest) ([]*models.Record, error) {
+	account, p, err := s.accountProvider(userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*models.Record, 0, len(reqs))
+	for _, req := range reqs {
+		state := true
+		if req.State != nil {
+			state = *req.State
+		}
+		raw, err := recordLine(p, req.Line)
+		if err != nil {
+			return nil, err
+		}
+		record := &models.Record{
+			NodeName:   req.NodeName,
+			RecordType: req.RecordType,
+			TTL:        req.TTL,
+			State:      state,
+			Content:    req.Content,
+			Priority:   req.Priority,
+			Raw:        raw,
+		}
+		if record.TTL == 0 {
+			record.TTL = p.DefaultTTL()
+		}
+		records = append(records, record)
+	}
+
+	s.syncBeforeWrite(ctx, userID, account, p, domainID)
+	defer invalidateDDNSZoneState(account.ID, domainID)
+	defer s.cacheInvalidate(account.ID, domainID)
+
+	if bw, ok := p.(provider.BatchRecordWriter); ok {
+		var created []*models.Record
+		err := provider.Do(ctx, p, account.ID, provider.RetryThrottled, func(ctx context.Context) (err error) {
+			created, err = bw.CreateRecords(ctx, account.APIKey, domainID, records)
+			return err
+		})
+		for _, r := range created {
+			s.emit(userID, account.ID, domainID, "", models.WebhookEventRecordCreated, map[string]interface{}{"record": r})
+		}
+		return created, err
+	}
+
+	created := make([]*models.Record, 0, len(records))
+	for _, record := range records {
+		var r *models.Record
+		err := provider.Do(ctx, p, account.ID, provider.RetryThrottled, func(ctx context.Context) (err error) {
+			r, err = p.CreateRecord(ctx, account.APIKey, domainID, record)
+			return err
+		})
+		if err != nil {
+			return created, fmt.Errorf("create %s %s: %w", record.RecordType, record.NodeName, err)
+		}
+		s.emit(userID, account.ID, domainID, "", models.WebhookEventRecordCreated, map[string]interface{}{"record": r})
+		created = append(created, r)
+	}
+	return created, nil
+}
+
+// CreateZone adds a new zone to the account and caches it.
+func (s *DNSService) CreateZone(ctx context.Context, userID, accountID int64, name string) (*models.Domain, error) {
+	account, p, err := s.accountProvider(userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	zm, ok := p.(provider.ZoneManager)
+	if !ok {
+		return nil, provider.ErrNotSupported
+	}
+
+	var domain *models.Domain
+	err = provider.Do(ctx, p, account.ID, provider.RetryThrottled, func(ctx context.Context) (err error) {
+		domain, err = zm.CreateZone(ctx, account.APIKey, name)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	domain.AccountID = account.ID
+	domain.AccountName = account.Name
+
+	if s.domainCacheService != nil {
+		s.domainCacheService.UpsertCache(userID, account.ID, domain.ID, domain.Name, &models.UpdateDomainCacheRequest{})
+	}
+	s.emit(userID, account.ID, domain.ID, domain.Name, models.WebhookEventDomainAdded, map[string]interface{}{"domain": domain})
+	return domain, nil
+}
+
+// DeleteZone removes a zone from the account and soft-deletes its cache entry.
+func (s *DNSService) DeleteZone(ctx context.Context, userID, accountID int64, domainID string) error {
+	account, p, err := s.accountProvider(userID, accountID)
+	if err != nil {
+		return err
+	}
+
+	zm, ok := p.(provider.ZoneManager)
+	if !ok {
+		return provider.ErrNotSupported
+	}
+
+	var domainName string
+	if s.domainCacheService != nil {
+		if cache, err := s.domainCacheService.GetCache(userID, accountID, domainID); err == nil && cache != nil {
+			domainName = cache.DomainName
+		}
+	}
+	err = provider.Do(ctx, p, account.ID, provider.RetryThrottled, func(ctx context.Context) error {
+		return zm.DeleteZone(ctx, account.APIKey, domainID)
+	})
+	if err != nil {
+		return err
+	}
+	invalidateDDNSZoneState(account.ID, domainID)
+	s.cacheInvalidate(account.ID, domainID)
+
+	if s.domainCacheService != nil {
+		s.domainCacheService.DeleteCache(userID, accountID, domainID)
+	}
+	s.emit(userID, account.ID, domainID, domainName, models.WebhookEventDomainRemoved, map[string]interface{}{
+		"domain_id":   domainID,
+		"domain_name": domainName,
+	})
+	return nil
+}
+
+// SetRecordProxied toggles CDN proxying on a record.
+func (s *DNSService) SetRecordProxied(ctx context.Context, userID, accountID int64, domainID, recordID string, proxied bool) (*models.Record, error) {
+	account, p, err := s.accountProvider(userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	ps, ok := p.(provider.ProxiedRecordSetter)
+	if !ok {
+		return nil, provider.ErrNotSupported
+	}
+	s.syncBeforeWrite(ctx, userID, account, p, domainID)
+	var updated *models.Record
+	err = provider.Do(ctx, p, account.ID, provider.RetrySafe, func(ctx context.Context) (err error) {
+		updated, err = ps.SetRecordProxied(ctx, account.APIKey, domainID, recordID, proxied)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.cacheInvalidate(account.ID, domainID)
+	s.emit(userID, account.ID, domainID, "", models.WebhookEventRecordUpdated, map[string]interface{}{"record": updated})
+	return updated, nil
+}
+
+// GetNameservers returns the authoritative nameservers assigned to a zone.
+func (s *DNSService) GetNameservers(ctx context.Context, userID, accountID int64, domainID string) ([]string, error) {
+	account, p, err := s.accountProvider(userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	nr, ok := p.(provider.NameserverReporter)
+	if !ok {
+		return nil, provider.ErrNotSupported
+	}
+	var hosts []string
+	err = provider.Do(ctx, p, account.ID, provider.RetrySafe, func(ctx context.Context) (err error) {
+		hosts, err = nr.GetNameservers(ctx, account.APIKey, domainID)
+		return err
+	})
+	return hosts, err
+}
+
+// RenewDomain renews a domain with providers that sell expiring domains.
+func (s *DNSService) RenewDomain(ctx context.Context, userID, accountID int64, domainID string) error {
+	account, p, err := s.accountProvider(userID, accountID)
+	if err != nil {
+		return err
+	}
+
+	dr, ok := p.(provider.DomainRenewer)
+	if !ok {
+		return provider.ErrNotSupported
+	}
+	return provider.Do(ctx, p, account.ID, provider.RetryThrottled, func(ctx context.Context) error {
+		return dr.RenewDomain(ctx, account.APIKey, domainID)
+	})
+}
+
+// ListRecordLines returns the resolver lines records in a zone may use.
+func (s *DNSService) ListRecordLines(ctx context.Context, userID, accountID int64, domainID string) ([]models.RecordLine, error) {
+	account, p, err := s.accountProvider(userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	ll, ok := p.(provider.RecordLineLister)
+	if !ok {
+		return nil, provider.ErrNotSupported
+	}
+	var lines []models.RecordLine
+	err = provider.Do(ctx, p, account.ID, provider.RetrySafe, func(ctx context.Context) (err error) {
+		lines, err = ll.ListRecordLines(ctx, account.APIKey, domainID)
+		return err
+	})
+	return lines, err
+}
+
+// recordLine carries a requested resolver line in Record.Raw, where
+// providers implementing provider.RecordLineLister read it.
+func recordLine(p provider.DNSProvider, line string) (map[string]interface{}, error) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil, nil
+	}
+	if _, ok := p.(provider.RecordLineLister); !ok {
+		return nil, provider.ErrNotSupported
+	}
+	return map[string]interface{}{"line": line}, nil
+}
+
+// FindZone returns the account's zone called name, asking the provider
+// when the domain cache does not know it. It returns nil when the account
+// has no such zone.
+func (s *DNSService) FindZone(ctx context.Context, userID, accountID int64, name string) (*models.Domain, error) {
+	name = normalizeFQDN(name)
+	if cached, err := s.ListDomainsFromCache(ctx, userID, accountID); err == nil {
+		for i := range cached {
+			if normalizeFQDN(cached[i].Name) == name {
+				return &cached[i], nil
+			}
+		}
+	}
+
+	account, p, err := s.accountProvider(userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	var domains []models.Domain
+	err = provider.Do(ctx, p, account.ID, provider.RetrySafe, func(ctx context.Context) (err error) {
+		domains, err = p.ListDomains(ctx, account.APIKey)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range domains {
+		if normalizeFQDN(domains[i].Name) == name {
+			domains[i].AccountID = account.ID
+			domains[i].AccountName = account.Name
+			return &domains[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// DomainMatch is the zone a fully-qualified hostname belongs to.
+type DomainMatch struct {
+	AccountID  int64
+	DomainID   string
+	DomainName string
+	// NodeName is the hostname relative to the zone; empty for the apex.
+	NodeName string
+}
+
+func normalizeFQDN(fqdn string) string {
+	s := strings.TrimSpace(strings.ToLower(fqdn))
+	s = strings.TrimSuffix(s, ".")
+	return s
+}
+
+// sameNodeName reports whether two record node names refer to the same
+// host. Providers disagree on how the apex is spelled ("", "@"), so both
+// are treated as equal.
+func sameNodeName(a, b string) bool {
+	norm := func(n string) string {
+		n = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(n), "."))
+		if n == "@" {
+			return ""
+		}
+		return n
+	}
+	return norm(a) == norm(b)
+}
+
+// MatchDomain resolves a fully-qualified hostname to the user's zone with
+// the longest matching suffix, across all accounts.
+func (s *DNSService) MatchDomain(ctx context.Context, userID int64, fqdn string) (*DomainMatch, error) {
+	fqdn = normalizeFQDN(fqdn)
+	if fqdn == "" || !strings.Contains(fqdn, ".") {
+		return nil, errors.New("invalid fqdn")
+	}
+
+	// Prefer cache, but will fallback to provider fetch if cache empty.
+	domains, err := s.ListAllDomainsFromCache(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(domains) == 0 {
+		return nil, errors.New("no domains available for user")
+	}
+
+	var best *DomainMatch
+	bestLen := -1
+
+	for _, d := range domains {
+		root := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(d.Name), "."))
+		if root == "" {
+			continue
+		}
+
+		if fqdn == root || strings.HasSuffix(fqdn, "."+root) {
+			if len(root) > bestLen {
+				relative := ""
+				if fqdn != root {
+					relative = strings.TrimSuffix(fqdn, "."+root)
+					relative = strings.TrimSuffix(relative, ".")
+				}
+				best = &DomainMatch{
+					AccountID:  d.AccountID,
+					DomainID:   d.ID,
+					DomainName: root,
+					NodeName:   relative,
+				}
+				bestLen = len(root)
+			}
+		}
+	}
+
+	if best == nil {
+		return nil, errors.New("no matching domain found for fqdn")
+	}
+	return best, nil
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"dns-mng/database"
+	"dns-mng/models"
+	"dns-mng/provider"
+	"dns-mng/provider/mock"
+)
+
+// newMockDNSService returns a DNSService for user 1 with one sandbox account
+// of the mock provider serving example.com.
+func newMockDNSService(t *testing.T) (*DNSService, *mock.Provider, *models.Account, string) {
+	t.Helper()
+	resetSecretStore(t)
+	openTestDB(t)
+	if _, err := database.DB.Exec(`INSERT INTO users (id, username, password_hash) VALUES (1, 'admin', 'x')`); err != nil {
+		t.Fatal(err)
+	}
+	mp := mock.New(mock.Options{Zones: []string{"example.com"}})
+	provider.Register(mp)
+
+	accounts := NewAccountService()
+	account, err := accounts.Create(context.Background(), 1, &models.CreateAccountRequest{Name: "sandbox", ProviderType: "mock", APIKey: "sandbox"})
+	if err != nil {
+		t.Fatalf("Create account: %v", err)
+	}
+	domains, err := mp.ListDomains(context.Background(), "sandbox")
+	if err != nil || len(domains) != 1 {
+		t.Fatalf("ListDomains = %v, %v", domains, err)
+	}
+	return NewDNSService(accounts, NewDomainCacheService(), NewRecordCacheService(), nil), mp, account, domains[0].ID
+}
+
+func TestCapabilityFallbacks(t *testing.T) {
+	dns, mp, account, zone := newMockDNSService(t)
+	ctx := context.Background()
+
+	// Without BatchRecordWriter records are created one by one
+	created, err := dns.BatchCreateRecords(ctx, 1, account.ID, zone, []models.CreateRecordRequest{
+		{NodeName: "a", RecordType: "A", Content: "192.0.2.1"},
+		{NodeName: "b", RecordType: "A", Content: "192.0.2.2"},
+	})
+	if err != nil || len(created) != 2 {
+		t.Fatalf("BatchCreateRecords = %d record(s), %v; want 2", len(created), err)
+	}
+
+	// Optional features the mock lacks fail with ErrNotSupported and write nothing
+	notSupported := map[string]func() error{
+		"record line on create": func() error {
+			_, err := dns.CreateRecord(ctx, 1, account.ID, zone, &models.CreateRecordRequest{NodeName: "c", RecordType: "A", Content: "192.0.2.3", Line: "telecom"})
+			return err
+		},
+		"record line in batch": func() error {
+			_, err := dns.BatchCreateRecords(ctx, 1, account.ID, zone, []models.CreateRecordRequest{
+				{NodeName: "c", RecordType: "A", Content: "192.0.2.3"},
+				{NodeName: "d", RecordType: "A", Content: "192.0.2.4", Line: "telecom"},
+			})
+			return err
+		},
+		"list record lines": func() error {
+			_, err := dns.ListRecordLines(ctx, 1, account.ID, zone)
+			return err
+		},
+		"set proxied": func() error {
+			_, err := dns.SetRecordProxied(ctx, 1, account.ID, zone, created[0].ID, true)
+			return err
+		},
+		"renew domain": func() error {
+			return dns.RenewDomain(ctx, 1, account.ID, zone)
+		},
+	}
+	for name, fn := range notSupported {
+		if err := fn(); !errors.Is(err, provider.ErrNotSupported) {
+			t.Errorf("%s: err = %v, want ErrNotSupported", name, err)
+		}
+	}
+	records, err := mp.ListRecords(ctx, "sandbox", zone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range records {
+		if r.NodeName == "c" || r.NodeName == "d" {
+			t.Errorf("record %s created despite an unsupported line", r.NodeName)
+		}
+	}
+
+	// Services built on capabilities refuse the account the same way
+	if _, err := NewCFOptimizeService(dns).Create(ctx, 1, account.ID, &models.CreateCFOptimizeRequest{ZoneName: "example.com", OriginIP: "192.0.2.1"}); !errors.Is(err, provider.ErrNotSupported) {
+		t.Errorf("CF optimize Create: err = %v, want ErrNotSupported", err)
+	}
+	dnshe := NewDNSHEService(NewAccountService(), NewDomainCacheService(), dns)
+	if _, err := dnshe.GetQuota(ctx, 1, account.ID); !errors.Is(err, provider.ErrNotSupported) {
+		t.Errorf("subdomain quota: err = %v, want ErrNotSupported", err)
+	}
+	if accounts, err := dnshe.ListAccounts(1); err != nil || len(accounts) != 0 {
+		t.Errorf("subdomain accounts = %v, %v; want none", accounts, err)
+	}
+}
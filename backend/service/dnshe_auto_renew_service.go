@@ -5,7 +5,6 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
-	"strconv"
 	"strings"
 	"time"
 
@@ -176,21 +175,14 @@ func (s *DNSHEAutoRenewService) runForUser(ctx context.Context, userID int64, da
 				continue
 			}
 
-			subdomainID, perr := strconv.Atoi(d.ID)
-			if perr != nil {
-				result.Failed++
-				result.FailedDomains = append(result.FailedDomains, d.Name)
-				continue
-			}
-
-			resp, rerr := s.dnsheService.RenewSubdomain(ctx, userID, account.ID, subdomainID)
+			rerr := s.dnsheService.RenewSubdomain(ctx, userID, account.ID, d.ID)
 			if rerr != nil {
 				log.Printf("DNSHE auto-renew: failed to renew %s (account %d): %v", d.Name, account.ID, rerr)
 				result.Failed++
 				result.FailedDomains = append(result.FailedDomains, d.Name)
 				continue
 			}
-			log.Printf("DNSHE auto-renew: renewed %s (charged %.2f, new expiry %s)", d.Name, resp.ChargedAmount, resp.NewExpiresAt)
+			log.Printf("DNSHE auto-renew: renewed %s (account %d)", d.Name, account.ID)
 			result.Renewed++
 			result.RenewedDomains = append(result.RenewedDomains, d.Name)
 		}
@@ -209,8 +201,8 @@ func (s *DNSHEAutoRenewService) UpdateLastRunAt(userID int64) {
 }
 
 // RunAll iterates all users with auto-renew enabled and runs the job. Used by the scheduler.
-func (s *DNSHEAutoRenewService) RunAll(ctx context.Context, schedulerLogService *SchedulerLogService) {
-	logID, _ := schedulerLogService.StartTask("dnshe_auto_renew", map[string]interface{}{"trigger": "scheduled"})
+func (s *DNSHEAutoRenewService) RunAll(ctx context.Context, trigger string, schedulerLogService *SchedulerLogService, notifierService *NotifierService) error {
+	logID, _ := schedulerLogService.StartTask("dnshe_auto_renew", map[string]interface{}{"trigger": trigger})
 
 	rows, err := database.DB.Query(
 		`SELECT user_id, days_before FROM dnshe_auto_renew_config WHERE enabled = 1`,
@@ -220,7 +212,10 @@ func (s *DNSHEAutoRenewService) RunAll(ctx context.Context, schedulerLogService
 		if logID > 0 {
 			schedulerLogService.UpdateTask(logID, "error", err.Error())
 		}
-		return
+		if notifierService != nil {
+			notifierService.NotifySchedulerFailure(ctx, 0, "dnshe_auto_renew", err.Error())
+		}
+		return err
 	}
 	type userCfg struct {
 		userID     int64
@@ -245,6 +240,9 @@ func (s *DNSHEAutoRenewService) RunAll(ctx context.Context, schedulerLogService
 		allRenewed = append(allRenewed, res.RenewedDomains...)
 		allFailed = append(allFailed, res.FailedDomains...)
 		s.UpdateLastRunAt(uc.userID)
+		if notifierService != nil {
+			notifierService.NotifyDNSHEAutoRenew(ctx, uc.userID, res)
+		}
 	}
 
 	status := "success"
@@ -264,4 +262,8 @@ func (s *DNSHEAutoRenewService) RunAll(ctx context.Context, schedulerLogService
 		schedulerLogService.UpdateTask(logID, status, message)
 	}
 	log.Printf("DNSHE auto-renew: %s", message)
+	if status == "error" {
+		return fmt.Errorf("all %d renewal(s) failed", totalFailed)
+	}
+	return nil
 }
\ No newline at end of file
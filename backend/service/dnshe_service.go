@@ -3,32 +3,31 @@ package service
 import (
 	"context"
 	"fmt"
-	"strconv"
 	"strings"
 
 	"dns-mng/models"
 	"dns-mng/provider"
-	"dns-mng/provider/cloudflare"
-	"dns-mng/provider/dnshe"
 )
 
-// DNSHEService provides DNSHE-specific operations (register/delete/renew/quota)
-// that are not part of the generic DNSProvider interface.
+// DNSHEService provides subdomain registration (register/delete/renew/quota)
+// and delegation to Cloudflare on top of the generic DNSProvider interface,
+// through provider.SubdomainRegistrar and the other capabilities. Record
+// writes go through DNSService.
 type DNSHEService struct {
 	accountService     *AccountService
 	domainCacheService *DomainCacheService
-	client             *dnshe.Client
+	dnsService         *DNSService
 }
 
-func NewDNSHEService(accountService *AccountService, domainCacheService *DomainCacheService) *DNSHEService {
+func NewDNSHEService(accountService *AccountService, domainCacheService *DomainCacheService, dnsService *DNSService) *DNSHEService {
 	return &DNSHEService{
 		accountService:     accountService,
 		domainCacheService: domainCacheService,
-		client:             dnshe.NewClient(),
+		dnsService:         dnsService,
 	}
 }
 
-// ListAccounts returns all DNSHE accounts for the user.
+// ListAccounts returns the user's accounts whose provider registers subdomains.
 func (s *DNSHEService) ListAccounts(userID int64) ([]models.Account, error) {
 	accounts, err := s.accountService.List(userID)
 	if err != nil {
@@ -36,66 +35,77 @@ func (s *DNSHEService) ListAccounts(userID int64) ([]models.Account, error) {
 	}
 	var dnsheAccounts []models.Account
 	for _, acc := range accounts {
-		if acc.ProviderType == "dnshe" {
+		p, err := provider.Get(acc.ProviderType)
+		if err == nil && provider.Supports(p, provider.CapSubdomains) {
 			dnsheAccounts = append(dnsheAccounts, acc)
 		}
 	}
 	return dnsheAccounts, nil
 }
 
-// getAccountCredentials fetches the account and parses its API key/secret.
-func (s *DNSHEService) getAccountCredentials(userID, accountID int64) (string, string, error) {
-	account, err := s.accountService.Get(userID, accountID)
+// registrar returns the account and its provider, or ErrNotSupported when
+// the provider does not register subdomains.
+func (s *DNSHEService) registrar(userID, accountID int64) (*models.Account, provider.DNSProvider, provider.SubdomainRegistrar, error) {
+	account, p, err := s.dnsService.accountProvider(userID, accountID)
 	if err != nil {
-		return "", "", fmt.Errorf("account not found: %w", err)
+		return nil, nil, nil, err
 	}
-	if account.ProviderType != "dnshe" {
-		return "", "", fmt.Errorf("account %d is not a DNSHE account", accountID)
+	sr, ok := p.(provider.SubdomainRegistrar)
+	if !ok {
+		return nil, nil, nil, provider.ErrNotSupported
 	}
-	return dnshe.ParseAPIKey(account.APIKey)
+	return account, p, sr, nil
 }
 
-// GetQuota queries the quota for a DNSHE account.
-func (s *DNSHEService) GetQuota(ctx context.Context, userID, accountID int64) (*dnshe.QuotaResponse, error) {
-	key, secret, err := s.getAccountCredentials(userID, accountID)
+// GetQuota queries the subdomain quota of an account.
+func (s *DNSHEService) GetQuota(ctx context.Context, userID, accountID int64) (*models.SubdomainQuota, error) {
+	account, p, sr, err := s.registrar(userID, accountID)
 	if err != nil {
 		return nil, err
 	}
-	return s.client.GetQuota(ctx, key, secret)
+	var quota *models.SubdomainQuota
+	err = provider.Do(ctx, p, account.ID, provider.RetrySafe, func(ctx context.Context) (err error) {
+		quota, err = sr.SubdomainQuota(ctx, account.APIKey)
+		return err
+	})
+	return quota, err
 }
 
-// RegisterSubdomain registers a new subdomain under a DNSHE account.
-func (s *DNSHEService) RegisterSubdomain(ctx context.Context, userID, accountID int64, subdomain, rootdomain string) (*dnshe.RegisterSubdomainResponse, error) {
-	key, secret, err := s.getAccountCredentials(userID, accountID)
+// RegisterSubdomain registers subdomain under rootdomain and returns the new domain.
+func (s *DNSHEService) RegisterSubdomain(ctx context.Context, userID, accountID int64, subdomain, rootdomain string) (*models.Domain, error) {
+	account, p, sr, err := s.registrar(userID, accountID)
 	if err != nil {
 		return nil, err
 	}
-	return s.client.RegisterSubdomain(ctx, key, secret, subdomain, rootdomain)
+	var domain *models.Domain
+	err = provider.Do(ctx, p, account.ID, provider.RetryThrottled, func(ctx context.Context) (err error) {
+		domain, err = sr.RegisterSubdomain(ctx, account.APIKey, subdomain, rootdomain)
+		return err
+	})
+	return domain, err
 }
 
-// DeleteSubdomain deletes a subdomain from a DNSHE account and soft-deletes the
+// DeleteSubdomain deletes a subdomain from an account and soft-deletes the
 // corresponding domain cache entry so it no longer appears in lists.
-func (s *DNSHEService) DeleteSubdomain(ctx context.Context, userID, accountID int64, subdomainID int) (*dnshe.DeleteSubdomainResponse, error) {
-	key, secret, err := s.getAccountCredentials(userID, accountID)
+func (s *DNSHEService) DeleteSubdomain(ctx context.Context, userID, accountID int64, domainID string) error {
+	account, p, sr, err := s.registrar(userID, accountID)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	resp, err := s.client.DeleteSubdomain(ctx, key, secret, subdomainID)
+	err = provider.Do(ctx, p, account.ID, provider.RetryThrottled, func(ctx context.Context) error {
+		return sr.DeleteSubdomain(ctx, account.APIKey, domainID)
+	})
 	if err != nil {
-		return nil, err
+		return err
 	}
 	// Soft-delete the domain cache entry (if any) to avoid stale records.
-	_ = s.domainCacheService.DeleteCache(userID, accountID, strconv.Itoa(subdomainID))
-	return resp, nil
+	_ = s.domainCacheService.DeleteCache(userID, accountID, domainID)
+	return nil
 }
 
-// RenewSubdomain renews a subdomain under a DNSHE account.
-func (s *DNSHEService) RenewSubdomain(ctx context.Context, userID, accountID int64, subdomainID int) (*dnshe.RenewSubdomainResponse, error) {
-	key, secret, err := s.getAccountCredentials(userID, accountID)
-	if err != nil {
-		return nil, err
-	}
-	return s.client.RenewSubdomain(ctx, key, secret, subdomainID)
+// RenewSubdomain renews a subdomain through provider.DomainRenewer.
+func (s *DNSHEService) RenewSubdomain(ctx context.Context, userID, accountID int64, domainID string) error {
+	return s.dnsService.RenewDomain(ctx, userID, accountID, domainID)
 }
 
 // SetDomainResolution updates the uses_dnshe_dns flag on a domain cache entry.
@@ -110,86 +120,76 @@ func (s *DNSHEService) SetDomainResolution(userID, accountID int64, domainID str
 // It creates/reuses a Cloudflare zone for the domain's root, then writes the
 // Cloudflare-assigned nameservers as NS records on the DNSHE side.
 func (s *DNSHEService) ResolveToCloudflare(ctx context.Context, userID, dnsheAccountID int64, domainID string, cfAccountID int64) (*models.ResolveToCloudflareResult, error) {
-	// 1. DNSHE credentials
-	key, secret, err := s.getAccountCredentials(userID, dnsheAccountID)
-	if err != nil {
-		return nil, err
-	}
-
-	// 2. Get subdomain info (rootdomain)
-	subdomainID, err := strconv.Atoi(domainID)
-	if err != nil {
-		return nil, fmt.Errorf("invalid domain id: %w", err)
-	}
-	subResp, err := s.client.GetSubdomain(ctx, key, secret, subdomainID)
+	// 1. Domain name on the DNSHE side
+	domain, err := s.dnsService.GetDomain(ctx, userID, dnsheAccountID, domainID)
 	if err != nil {
 		return nil, fmt.Errorf("get subdomain: %w", err)
 	}
-	domainName := subResp.Subdomain.FullDomain
-	if domainName == "" {
-		domainName = subResp.Subdomain.Subdomain + "." + subResp.Subdomain.RootDomain
-	}
+	domainName := domain.Name
 	if domainName == "" {
 		return nil, fmt.Errorf("could not determine domain name for domain id %s", domainID)
 	}
 
-	// 3. Cloudflare account
-	cfAccount, err := s.accountService.Get(userID, cfAccountID)
+	// 2. The Cloudflare account must create zones and report their nameservers
+	_, cfp, err := s.dnsService.accountProvider(userID, cfAccountID)
 	if err != nil {
 		return nil, fmt.Errorf("cloudflare account not found: %w", err)
 	}
-	if cfAccount.ProviderType != "cloudflare" {
-		return nil, fmt.Errorf("account %d is not a Cloudflare account", cfAccountID)
+	if !provider.Supports(cfp, provider.CapZoneManagement) || !provider.Supports(cfp, provider.CapNameservers) {
+		return nil, fmt.Errorf("account %d cannot host the zone: %w", cfAccountID, provider.ErrNotSupported)
 	}
-	cfClient := cloudflare.NewClient()
-	apiToken := cfAccount.APIKey
 
-	// 4. Get Cloudflare account ID, then find or create zone
+	// 3. Find or create the zone
 	// 使用完整域名作为 zone（DNSHE 的 rootdomain 是共享 TLD，不能直接建 zone）
-	cfAccountIDStr, err := cfClient.GetAccountID(ctx, apiToken)
+	zone, err := s.dnsService.FindZone(ctx, userID, cfAccountID, domainName)
 	if err != nil {
-		return nil, fmt.Errorf("get cloudflare account id: %w", err)
+		return nil, fmt.Errorf("find cloudflare zone: %w", err)
 	}
-
-	// Try to find existing zone by name
-	zone, err := cfClient.GetZoneByName(ctx, apiToken, domainName)
-	if err != nil {
-		// Zone not found, create a new one
-		zone, err = cfClient.CreateZone(ctx, apiToken, cfAccountIDStr, domainName)
+	if zone == nil {
+		zone, err = s.dnsService.CreateZone(ctx, userID, cfAccountID, domainName)
 		if err != nil {
 			return nil, fmt.Errorf("create cloudflare zone: %w", err)
 		}
 	}
 
-	if len(zone.NameServers) == 0 {
+	nameServers, err := s.dnsService.GetNameservers(ctx, userID, cfAccountID, zone.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get cloudflare nameservers: %w", err)
+	}
+	if len(nameServers) == 0 {
 		return nil, fmt.Errorf("cloudflare zone %s has no nameservers assigned", domainName)
 	}
 
-	// 5. Create new NS records on DNSHE side FIRST (safer than deleting first)
-	for _, ns := range zone.NameServers {
-		if err := s.client.CreateDNSRecord(ctx, key, secret, subdomainID, "", "NS", ns, 86400, nil); err != nil {
+	// 4. Create new NS records on DNSHE side FIRST (safer than deleting first)
+	for _, ns := range nameServers {
+		_, err := s.dnsService.CreateRecord(ctx, userID, dnsheAccountID, domainID, &models.CreateRecordRequest{
+			RecordType: "NS",
+			TTL:        86400,
+			Content:    ns,
+		})
+		if err != nil {
 			return nil, fmt.Errorf("create NS record %s on DNSHE: %w", ns, err)
 		}
 	}
 
-	// 6. Delete old NS records (that are not the ones we just created)
-	listResp, err := s.client.ListDNSRecords(ctx, key, secret, subdomainID)
+	// 5. Delete old NS records (that are not the ones we just created)
+	records, err := s.dnsService.ListRecords(ctx, userID, dnsheAccountID, domainID)
 	if err != nil {
 		return nil, fmt.Errorf("list DNSHE dns records: %w", err)
 	}
 	newNSSet := make(map[string]bool)
-	for _, ns := range zone.NameServers {
+	for _, ns := range nameServers {
 		newNSSet[strings.ToLower(ns)] = true
 	}
-	for _, r := range listResp.Records {
-		if r.Type == "NS" && !newNSSet[strings.ToLower(r.Content)] {
-			if err := s.client.DeleteDNSRecord(ctx, key, secret, r.ID); err != nil {
+	for _, r := range records {
+		if r.RecordType == "NS" && !newNSSet[strings.ToLower(r.Content)] {
+			if err := s.dnsService.DeleteRecord(ctx, userID, dnsheAccountID, domainID, r.ID); err != nil {
 				// non-fatal: old record may linger, but new NS are already in place
 			}
 		}
 	}
 
-	// 7. Mark domain as third-party resolution
+	// 6. Mark domain as third-party resolution
 	usesDNSHE := false
 	_, _ = s.domainCacheService.UpsertCache(userID, dnsheAccountID, domainID, "", &models.UpdateDomainCacheRequest{
 		UsesDNSHEDNS: &usesDNSHE,
@@ -198,7 +198,7 @@ func (s *DNSHEService) ResolveToCloudflare(ctx context.Context, userID, dnsheAcc
 	return &models.ResolveToCloudflareResult{
 		DomainName:  domainName,
 		ZoneName:    zone.Name,
-		NameServers: zone.NameServers,
+		NameServers: nameServers,
 	}, nil
 }
 
@@ -208,5 +208,10 @@ func (s *DNSHEService) domainListForAutoRenew(ctx context.Context, account model
 	if err != nil {
 		return nil, err
 	}
-	return p.ListDomains(ctx, account.APIKey)
+	var domains []models.Domain
+	err = provider.Do(ctx, p, account.ID, provider.RetrySafe, func(ctx context.Context) (err error) {
+		domains, err = p.ListDomains(ctx, account.APIKey)
+		return err
+	})
+	return domains, err
 }
\ No newline at end of file
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"dns-mng/database"
+	"dns-mng/models"
+	"sort"
+	"time"
+)
+
+// DomainRefreshService syncs domain lists from the providers in the
+// background. Restores and new domains are applied right away; domains
+// missing at the provider are queued in domain_pending_deletions until the
+// user approves or dismisses them.
+type DomainRefreshService struct {
+	dnsService         *DNSService
+	accountService     *AccountService
+	domainCacheService *DomainCacheService
+}
+
+func NewDomainRefreshService(dnsService *DNSService, accountService *AccountService, domainCacheService *DomainCacheService) *DomainRefreshService {
+	return &DomainRefreshService{
+		dnsService:         dnsService,
+		accountService:     accountService,
+		domainCacheService: domainCacheService,
+	}
+}
+
+// Refresh re-lists every account of the user and applies the changes
+func (s *DomainRefreshService) Refresh(ctx context.Context, userID int64) (*models.DomainRefreshSummary, error) {
+	summary := &models.DomainRefreshSummary{UserID: userID}
+
+	accounts, err := s.accountService.List(userID)
+	if err != nil {
+		return nil, err
+	}
+	accountNames := make(map[int64]string, len(accounts))
+	for _, acc := range accounts {
+		accountNames[acc.ID] = acc.Name
+	}
+	dnsheAccountIDs := dnsheAccountIDsFrom(accounts)
+
+	// Snapshot the cache before the provider call upserts into it
+	active, err := s.domainCacheService.GetCacheByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	softDeleted, err := s.domainCacheService.GetSoftDeletedDomains(userID)
+	if err != nil {
+		return nil, err
+	}
+	activeMap := make(map[string]bool, len(active))
+	for _, c := range active {
+		activeMap[cacheKey(c.AccountID, c.DomainID)] = true
+	}
+	softDeletedMap := make(map[string]bool, len(softDeleted))
+	for _, c := range softDeleted {
+		softDeletedMap[cacheKey(c.AccountID, c.DomainID)] = true
+	}
+
+	domains, failed, err := s.dnsService.listAllDomainsFromProvider(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	summary.Total = len(domains)
+	for accountID, ferr := range failed {
+		if summary.FailedAccounts == nil {
+			summary.FailedAccounts = make(map[string]string)
+		}
+		summary.FailedAccounts[accountNames[accountID]] = ferr.Error()
+	}
+
+	present := make(map[string]bool, len(domains))
+	syncTime := time.Now()
+	for _, d := range domains {
+		key := cacheKey(d.AccountID, d.ID)
+		present[key] = true
+		switch {
+		case softDeletedMap[key]:
+			s.domainCacheService.BatchRestoreCache(userID, []models.BatchCacheDeleteItem{
+				{AccountID: d.AccountID, DomainID: d.ID},
+			})
+			summary.Restored = append(summary.Restored, d.Name)
+		case !activeMap[key]:
+			summary.Added = append(summary.Added, d.Name)
+		}
+		s.domainCacheService.UpdateLastSyncTime(userID, d.AccountID, d.ID, parseProviderUpdatedOn(d.UpdatedOn))
+	}
+	sort.Strings(summary.Added)
+	sort.Strings(summary.Restored)
+	s.dnsService.SyncRecordCaches(ctx, userID, domains)
+
+	// Domains back at the provider leave the review queue
+	if err := s.clearPresent(userID, present); err != nil {
+		return nil, err
+	}
+
+	for _, c := range active {
+		key := cacheKey(c.AccountID, c.DomainID)
+		if present[key] || failed[c.AccountID] != nil {
+			continue
+		}
+		if _, ok := accountNames[c.AccountID]; !ok {
+			continue
+		}
+		// DNSHE 第三方解析的域名不在列表中，不参与删除检测
+		if dnsheAccountIDs[c.AccountID] && !c.UsesDNSHEDNS {
+			continue
+		}
+		result, err := database.DB.Exec(
+			`INSERT INTO domain_pending_deletions (user_id, account_id, domain_id, domain_name, detected_at)
+			 VALUES (?, ?, ?, ?, ?)
+			 ON CONFLICT(user_id, account_id, domain_id) DO NOTHING`,
+			userID, c.AccountID, c.DomainID, c.DomainName, syncTime,
+		)
+		if err != nil {
+			return nil, err
+		}
+		// only report domains that were not already waiting for review
+		if n, _ := result.RowsAffected(); n > 0 {
+			summary.PendingDeletion = append(summary.PendingDeletion, models.BatchCacheDeleteItem{
+				AccountID:   c.AccountID,
+				AccountName: accountNames[c.AccountID],
+				DomainID:    c.DomainID,
+				DomainName:  c.DomainName,
+			})
+		}
+	}
+
+	return summary, nil
+}
+
+func (s *DomainRefreshService) clearPresent(userID int64, present map[string]bool) error {
+	pending, err := s.listPending(userID)
+	if err != nil {
+		return err
+	}
+	for _, p := range pending {
+		if !present[cacheKey(p.AccountID, p.DomainID)] {
+			continue
+		}
+		if _, err := database.DB.Exec(`DELETE FROM domain_pending_deletions WHERE id = ?`, p.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *DomainRefreshService) listPending(userID int64) ([]models.DomainPendingDeletion, error) {
+	// Entries whose cache row was soft-deleted meanwhile are no longer pending
+	rows, err := database.DB.Query(
+		`SELECT p.id, p.account_id, COALESCE(a.name, ''), p.domain_id, p.domain_name, p.detected_at, p.dismissed_at
+		 FROM domain_pending_deletions p
+		 JOIN domain_cache c ON c.user_id = p.user_id AND c.account_id = p.account_id AND c.domain_id = p.domain_id AND c.deleted_at IS NULL
+		 LEFT JOIN accounts a ON a.id = p.account_id
+		 WHERE p.user_id = ?
+		 ORDER BY p.detected_at DESC, p.domain_name`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.DomainPendingDeletion, 0)
+	for rows.Next() {
+		var p models.DomainPendingDeletion
+		var dismissedAt sql.NullTime
+		if err := rows.Scan(&p.ID, &p.AccountID, &p.AccountName, &p.DomainID, &p.DomainName, &p.DetectedAt, &dismissedAt); err != nil {
+			return nil, err
+		}
+		if dismissedAt.Valid {
+			p.DismissedAt = &dismissedAt.Time
+		}
+		items = append(items, p)
+	}
+	return items, rows.Err()
+}
+
+// ListPendingDeletions returns the user's review queue. Dismissed entries
+// are only included when includeDismissed is set.
+func (s *DomainRefreshService) ListPendingDeletions(userID int64, includeDismissed bool) ([]models.DomainPendingDeletion, error) {
+	items, err := s.listPending(userID)
+	if err != nil || includeDismissed {
+		return items, err
+	}
+	filtered := make([]models.DomainPendingDeletion, 0, len(items))
+	for _, p := range items {
+		if p.DismissedAt == nil {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered, nil
+}
+
+// ApprovePendingDeletions soft-deletes the given domains and removes them
+// from the queue
+func (s *DomainRefreshService) ApprovePendingDeletions(userID int64, items []models.BatchCacheDeleteItem) error {
+	if err := s.domainCacheService.BatchDeleteCache(userID, items); err != nil {
+		return err
+	}
+	for _, item := range items {
+		if _, err := database.DB.Exec(
+			`DELETE FROM domain_pending_deletions WHERE user_id = ? AND account_id = ? AND domain_id = ?`,
+			userID, item.AccountID, item.DomainID,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DismissPendingDeletions keeps the given domains. They stay in the queue
+// as dismissed, so later refreshes do not report them again, until the
+// provider lists them again.
+func (s *DomainRefreshService) DismissPendingDeletions(userID int64, items []models.BatchCacheDeleteItem) error {
+	now := time.Now()
+	for _, item := range items {
+		if _, err := database.DB.Exec(
+			`UPDATE domain_pending_deletions SET dismissed_at = ?
+			 WHERE user_id = ? AND account_id = ? AND domain_id = ? AND dismissed_at IS NULL`,
+			now, userID, item.AccountID, item.DomainID,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseProviderUpdatedOn parses the provider's last-modified value; the
+// formats match the refresh handlers
+func parseProviderUpdatedOn(v string) *time.Time {
+	if v == "" {
+		return nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05Z", "2006-01-02"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
@@ -21,6 +21,30 @@ type EmailTranslations struct {
 	TestCongrats     string
 	TestConfirmation string
 	TestFooter       string
+	// Channel notifications
+	AutoRenewTitle        string
+	AutoRenewRenewed      string
+	AutoRenewFailed       string
+	SchedulerFailureTitle string
+	SchedulerFailureTask  string
+	DomainChangesTitle    string
+	DomainChangesAdded    string
+	DomainChangesRestored string
+	DomainChangesPending  string
+	ChannelTestTitle      string
+	ChannelTestText       string
+	NewLoginTitle         string
+	NewLoginNewDevice     string
+	NewLoginNewCountry    string
+	NewLoginDevice        string
+	NewLoginIP            string
+	NewLoginLocation      string
+	NewLoginHint          string
+
+	// Account credential failure
+	AccountFailingTitle string
+	AccountFailingIntro string
+	AccountFailingHint  string
 }
 
 var emailTranslations = map[string]EmailTranslations{
@@ -45,6 +69,29 @@ var emailTranslations = map[string]EmailTranslations{
 		TestCongrats:     "恭喜！您的邮件配置已正确设置。",
 		TestConfirmation: "DNS Manager 现在可以向您发送域名到期提醒通知。",
 		TestFooter:       "此邮件由 DNS Manager 系统发送。",
+		// Channel notifications
+		AutoRenewTitle:        "DNSHE 自动续期结果",
+		AutoRenewRenewed:      "续期成功：",
+		AutoRenewFailed:       "续期失败：",
+		SchedulerFailureTitle: "定时任务执行失败",
+		SchedulerFailureTask:  "任务：",
+		DomainChangesTitle:    "域名列表变更",
+		DomainChangesAdded:    "新增域名：",
+		DomainChangesRestored: "已恢复：",
+		DomainChangesPending:  "服务商已不存在（待确认删除）：",
+		ChannelTestTitle:      "DNS Manager - 通知渠道测试",
+		ChannelTestText:       "恭喜！该通知渠道已配置成功，DNS Manager 现在可以通过它向您发送通知。",
+		NewLoginTitle:         "账户登录提醒",
+		NewLoginNewDevice:     "您的账户在新设备上登录。",
+		NewLoginNewCountry:    "您的账户在新的国家或地区登录。",
+		NewLoginDevice:        "设备：",
+		NewLoginIP:            "IP：",
+		NewLoginLocation:      "位置：",
+		NewLoginHint:          "如果这不是您本人的操作，请立即修改密码并启用两步验证。",
+
+		AccountFailingTitle: "服务商账户凭据失效",
+		AccountFailingIntro: "以下账户的凭据已被服务商拒绝，可能已过期或被撤销：",
+		AccountFailingHint:  "请在「账户」页面更新凭据并测试连接。",
 	},
 	"en": {
 		ExpirySubject: func(domain string, days int) string {
@@ -67,6 +114,29 @@ var emailTranslations = map[string]EmailTranslations{
 		TestCongrats:     "Congratulations! Your email configuration is set up correctly.",
 		TestConfirmation: "DNS Manager can now send you domain expiry reminder notifications.",
 		TestFooter:       "This email was sent by DNS Manager.",
+		// Channel notifications
+		AutoRenewTitle:        "DNSHE Auto-Renew Result",
+		AutoRenewRenewed:      "Renewed: ",
+		AutoRenewFailed:       "Failed: ",
+		SchedulerFailureTitle: "Scheduled Task Failed",
+		SchedulerFailureTask:  "Task: ",
+		DomainChangesTitle:    "Domain List Changes",
+		DomainChangesAdded:    "New domains: ",
+		DomainChangesRestored: "Restored: ",
+		DomainChangesPending:  "No longer at provider (pending deletion review): ",
+		ChannelTestTitle:      "DNS Manager - Notification Channel Test",
+		ChannelTestText:       "Congratulations! This channel is set up correctly and DNS Manager can now send you notifications through it.",
+		NewLoginTitle:         "New Sign-in to Your Account",
+		NewLoginNewDevice:     "Your account was signed in from a new device.",
+		NewLoginNewCountry:    "Your account was signed in from a new country or region.",
+		NewLoginDevice:        "Device: ",
+		NewLoginIP:            "IP: ",
+		NewLoginLocation:      "Location: ",
+		NewLoginHint:          "If this wasn't you, change your password right away and turn on two-factor authentication.",
+
+		AccountFailingTitle: "Provider Account Credentials Rejected",
+		AccountFailingIntro: "The provider rejected the credentials of these accounts; they may have expired or been revoked:",
+		AccountFailingHint:  "Update the credentials on the Accounts page and test the connection.",
 	},
 }
 
@@ -6,7 +6,9 @@ import (
 	"dns-mng/database"
 	"dns-mng/models"
 	"fmt"
+	"html"
 	"net/smtp"
+	"strings"
 	"time"
 )
 
@@ -20,14 +22,15 @@ func NewEmailService() *EmailService {
 func (s *EmailService) GetEmailConfig(userID int64) (*models.EmailConfig, error) {
 	var config models.EmailConfig
 	var enabled int
+	var events string
 
 	err := database.DB.QueryRow(
-		`SELECT id, user_id, smtp_host, smtp_port, smtp_username, smtp_password, from_email, from_name, to_email, language, enabled, created_at, updated_at
+		`SELECT id, user_id, smtp_host, smtp_port, smtp_username, smtp_password, from_email, from_name, to_email, language, enabled, notify_events, created_at, updated_at
 		 FROM email_config WHERE user_id = ?`,
 		userID,
 	).Scan(&config.ID, &config.UserID, &config.SMTPHost, &config.SMTPPort,
 		&config.SMTPUsername, &config.SMTPPassword, &config.FromEmail, &config.FromName,
-		&config.ToEmail, &config.Language, &enabled, &config.CreatedAt, &config.UpdatedAt)
+		&config.ToEmail, &config.Language, &enabled, &events, &config.CreatedAt, &config.UpdatedAt)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -37,6 +40,7 @@ func (s *EmailService) GetEmailConfig(userID int64) (*models.EmailConfig, error)
 	}
 
 	config.Enabled = enabled == 1
+	config.Events = splitList(events)
 	// Don't return password in response
 	config.SMTPPassword = ""
 
@@ -47,14 +51,15 @@ func (s *EmailService) GetEmailConfig(userID int64) (*models.EmailConfig, error)
 func (s *EmailService) getEmailConfigWithPassword(userID int64) (*models.EmailConfig, error) {
 	var config models.EmailConfig
 	var enabled int
+	var events string
 
 	err := database.DB.QueryRow(
-		`SELECT id, user_id, smtp_host, smtp_port, smtp_username, smtp_password, from_email, from_name, to_email, language, enabled, created_at, updated_at
+		`SELECT id, user_id, smtp_host, smtp_port, smtp_username, smtp_password, from_email, from_name, to_email, language, enabled, notify_events, created_at, updated_at
 		 FROM email_config WHERE user_id = ?`,
 		userID,
 	).Scan(&config.ID, &config.UserID, &config.SMTPHost, &config.SMTPPort,
 		&config.SMTPUsername, &config.SMTPPassword, &config.FromEmail, &config.FromName,
-		&config.ToEmail, &config.Language, &enabled, &config.CreatedAt, &config.UpdatedAt)
+		&config.ToEmail, &config.Language, &enabled, &events, &config.CreatedAt, &config.UpdatedAt)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -64,6 +69,10 @@ func (s *EmailService) getEmailConfigWithPassword(userID int64) (*models.EmailCo
 	}
 
 	config.Enabled = enabled == 1
+	config.Events = splitList(events)
+	if config.SMTPPassword, err = DecryptSecret(config.SMTPPassword); err != nil {
+		return nil, err
+	}
 	return &config, nil
 }
 
@@ -75,18 +84,27 @@ func (s *EmailService) UpsertEmailConfig(userID int64, req *models.UpdateEmailCo
 		enabled = 1
 	}
 
+	events, err := normalizeNotifyEvents(req.Events)
+	if err != nil {
+		return nil, err
+	}
+	password, err := EncryptSecret(req.SMTPPassword)
+	if err != nil {
+		return nil, err
+	}
+
 	// Check if config exists
 	var existingID int64
-	err := database.DB.QueryRow(`SELECT id FROM email_config WHERE user_id = ?`, userID).Scan(&existingID)
+	err = database.DB.QueryRow(`SELECT id FROM email_config WHERE user_id = ?`, userID).Scan(&existingID)
 
 	switch err {
 	case sql.ErrNoRows:
 		// Insert new config
 		_, err = database.DB.Exec(
-			`INSERT INTO email_config (user_id, smtp_host, smtp_port, smtp_username, smtp_password, from_email, from_name, to_email, language, enabled, created_at, updated_at)
-			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-			userID, req.SMTPHost, req.SMTPPort, req.SMTPUsername, req.SMTPPassword,
-			req.FromEmail, req.FromName, req.ToEmail, req.Language, enabled, now, now,
+			`INSERT INTO email_config (user_id, smtp_host, smtp_port, smtp_username, smtp_password, from_email, from_name, to_email, language, enabled, notify_events, created_at, updated_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			userID, req.SMTPHost, req.SMTPPort, req.SMTPUsername, password,
+			req.FromEmail, req.FromName, req.ToEmail, req.Language, enabled, emailNotifyEvents(events), now, now,
 		)
 	case nil:
 		// Update existing config
@@ -95,7 +113,7 @@ func (s *EmailService) UpsertEmailConfig(userID int64, req *models.UpdateEmailCo
 			_, err = database.DB.Exec(
 				`UPDATE email_config SET smtp_host = ?, smtp_port = ?, smtp_username = ?, smtp_password = ?,
 				 from_email = ?, from_name = ?, to_email = ?, language = ?, enabled = ?, updated_at = ? WHERE user_id = ?`,
-				req.SMTPHost, req.SMTPPort, req.SMTPUsername, req.SMTPPassword,
+				req.SMTPHost, req.SMTPPort, req.SMTPUsername, password,
 				req.FromEmail, req.FromName, req.ToEmail, req.Language, enabled, now, userID,
 			)
 		} else {
@@ -107,6 +125,9 @@ func (s *EmailService) UpsertEmailConfig(userID int64, req *models.UpdateEmailCo
 				req.FromEmail, req.FromName, req.ToEmail, req.Language, enabled, now, userID,
 			)
 		}
+		if err == nil && req.Events != nil {
+			_, err = database.DB.Exec(`UPDATE email_config SET notify_events = ? WHERE user_id = ?`, strings.Join(events, ","), userID)
+		}
 	}
 
 	if err != nil {
@@ -136,6 +157,9 @@ func (s *EmailService) SendEmail(userID int64, to, subject, body string) error {
 	if enabled == 0 {
 		return fmt.Errorf("email notifications are disabled")
 	}
+	if config.SMTPPassword, err = DecryptSecret(config.SMTPPassword); err != nil {
+		return err
+	}
 
 	// Build email message
 	from := config.FromEmail
@@ -310,3 +334,33 @@ func (s *EmailService) TestEmailConfig(userID int64) error {
 `, t.TestSuccessTitle, t.TestCongrats, t.TestConfirmation, t.TestFooter)
 	return s.SendEmail(userID, toEmail, subject, body)
 }
+
+// SendNotification emails a channel-neutral notification to the configured recipient
+func (s *EmailService) SendNotification(userID int64, msg *models.NotificationMessage) error {
+	var toEmail string
+	err := database.DB.QueryRow(`SELECT to_email FROM email_config WHERE user_id = ?`, userID).Scan(&toEmail)
+	if err != nil {
+		return fmt.Errorf("email configuration not found")
+	}
+
+	link := ""
+	if msg.URL != "" {
+		link = fmt.Sprintf(`<p><a href="%s">%s</a></p>`, html.EscapeString(msg.URL), html.EscapeString(msg.URL))
+	}
+	body := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+</head>
+<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333;">
+    <div style="max-width: 600px; margin: 0 auto; padding: 20px;">
+        <h2 style="color: #1f2937;">%s</h2>
+        <p>%s</p>
+        %s
+    </div>
+</body>
+</html>
+`, html.EscapeString(msg.Title), strings.ReplaceAll(html.EscapeString(msg.Text), "\n", "<br>"), link)
+	return s.SendEmail(userID, toEmail, msg.Title, body)
+}
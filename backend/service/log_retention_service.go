@@ -0,0 +1,189 @@
+package service
+
+import (
+	"database/sql"
+	"dns-mng/database"
+	"dns-mng/models"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// ErrInvalidRetentionPolicy is returned for unknown tables or negative limits
+var ErrInvalidRetentionPolicy = errors.New("invalid retention policy")
+
+// defaultLogRetention applies to tables without a stored override.
+// api_call_logs keeps full request/response bodies, so it is capped hardest.
+var defaultLogRetention = map[string]models.LogRetentionPolicy{
+	"api_call_logs":  {Table: "api_call_logs", MaxDays: 30, MaxRows: 100000},
+	"login_logs":     {Table: "login_logs", MaxDays: 180},
+	"scheduler_logs": {Table: "scheduler_logs", MaxDays: 90, MaxRows: 10000},
+}
+
+type LogRetentionService struct{}
+
+func NewLogRetentionService() *LogRetentionService {
+	return &LogRetentionService{}
+}
+
+// ListPolicies returns the effective policy of every log table
+func (s *LogRetentionService) ListPolicies() ([]models.LogRetentionPolicy, error) {
+	rows, err := database.DB.Query(`SELECT table_name, max_days, max_rows FROM log_retention_policies`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byTable := make(map[string]models.LogRetentionPolicy, len(defaultLogRetention))
+	for table, p := range defaultLogRetention {
+		byTable[table] = p
+	}
+	for rows.Next() {
+		var table string
+		var days, maxRows sql.NullInt64
+		if err := rows.Scan(&table, &days, &maxRows); err != nil {
+			return nil, err
+		}
+		p, ok := byTable[table]
+		if !ok {
+			continue
+		}
+		if days.Valid {
+			p.MaxDays = int(days.Int64)
+		}
+		if maxRows.Valid {
+			p.MaxRows = int(maxRows.Int64)
+		}
+		byTable[table] = p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	policies := make([]models.LogRetentionPolicy, 0, len(database.LogTables))
+	for _, table := range database.LogTables {
+		p := byTable[table]
+		p.Table = table
+		policies = append(policies, p)
+	}
+	return policies, nil
+}
+
+func (s *LogRetentionService) getPolicy(table string) (*models.LogRetentionPolicy, error) {
+	policies, err := s.ListPolicies()
+	if err != nil {
+		return nil, err
+	}
+	for i := range policies {
+		if policies[i].Table == table {
+			return &policies[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unknown table %s", ErrInvalidRetentionPolicy, table)
+}
+
+// UpdatePolicy stores a table's retention limits
+func (s *LogRetentionService) UpdatePolicy(table string, req *models.UpdateLogRetentionRequest) (*models.LogRetentionPolicy, error) {
+	if !database.IsLogTable(table) {
+		return nil, fmt.Errorf("%w: unknown table %s", ErrInvalidRetentionPolicy, table)
+	}
+	p, err := s.getPolicy(table)
+	if err != nil {
+		return nil, err
+	}
+	if req.MaxDays != nil {
+		p.MaxDays = *req.MaxDays
+	}
+	if req.MaxRows != nil {
+		p.MaxRows = *req.MaxRows
+	}
+	if p.MaxDays < 0 || p.MaxRows < 0 {
+		return nil, fmt.Errorf("%w: limits must not be negative", ErrInvalidRetentionPolicy)
+	}
+
+	_, err = database.DB.Exec(
+		`INSERT INTO log_retention_policies (table_name, max_days, max_rows, updated_at)
+		 VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		 ON CONFLICT(table_name) DO UPDATE SET max_days = excluded.max_days,
+		   max_rows = excluded.max_rows, updated_at = CURRENT_TIMESTAMP`,
+		table, p.MaxDays, p.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Enforce applies every table's policy. A failing table does not stop the
+// others; the returned error joins the failures.
+func (s *LogRetentionService) Enforce() ([]models.LogCleanupResult, error) {
+	policies, err := s.ListPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]models.LogCleanupResult, 0, len(policies))
+	var errs []error
+	for _, p := range policies {
+		deleted, err := database.CleanupLogTable(p.Table, p.MaxDays, p.MaxRows)
+		res := models.LogCleanupResult{Table: p.Table, Deleted: deleted}
+		if err != nil {
+			res.Error = err.Error()
+			errs = append(errs, fmt.Errorf("%s: %w", p.Table, err))
+		} else if deleted > 0 {
+			log.Printf("Log retention: deleted %d row(s) from %s", deleted, p.Table)
+		}
+		results = append(results, res)
+	}
+	return results, errors.Join(errs...)
+}
+
+// Summary formats cleanup results for the scheduler log
+func (s *LogRetentionService) Summary(results []models.LogCleanupResult) string {
+	parts := make([]string, 0, len(results))
+	for _, r := range results {
+		parts = append(parts, fmt.Sprintf("%s: %d", r.Table, r.Deleted))
+	}
+	return "Deleted rows - " + strings.Join(parts, ", ")
+}
+
+// Stats returns row counts and policies of the log tables and the size of
+// the database file
+func (s *LogRetentionService) Stats() (map[string]interface{}, error) {
+	policies, err := s.ListPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make(map[string]interface{}, len(policies))
+	for _, p := range policies {
+		stats, err := database.GetLogTableStats(p.Table)
+		if err != nil {
+			return nil, err
+		}
+		stats["policy"] = p
+		tables[p.Table] = stats
+	}
+
+	apiStats, err := database.GetAPILogsStats()
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"tables":         tables,
+		"api_call_logs":  apiStats,
+		"database":       database.GetDatabaseSize(),
+		"vacuum_allowed": !database.IsLibSQL(),
+	}, nil
+}
+
+// Vacuum compacts a local SQLite database. It reports skipped=true under
+// libSQL, which does not support VACUUM.
+func (s *LogRetentionService) Vacuum() (bool, error) {
+	if database.IsLibSQL() {
+		return true, nil
+	}
+	return false, database.VacuumDatabase()
+}
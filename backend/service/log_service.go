@@ -97,12 +97,33 @@ func (s *LogService) GetAPICallLogs(userID int64, page, pageSize int) (*models.A
 // CreateLoginLog creates a new login log entry
 func (s *LogService) CreateLoginLog(log *models.LoginLog) error {
 	_, err := database.DB.Exec(
-		`INSERT INTO login_logs (user_id, username, ip_address, ip_location, user_agent, device, status, message) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-		log.UserID, log.Username, log.IPAddress, log.IPLocation, log.UserAgent, log.Device, log.Status, log.Message,
+		`INSERT INTO login_logs (user_id, username, ip_address, ip_location, country, user_agent, device, status, message, session_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		log.UserID, log.Username, log.IPAddress, log.IPLocation, log.Country, log.UserAgent, log.Device, log.Status, log.Message, log.SessionID,
 	)
 	return err
 }
 
+// UnfamiliarLogin reports whether device and country are missing from the
+// user's earlier successful logins. The first login is never unfamiliar,
+// and countries are only compared once earlier logins recorded one.
+func (s *LogService) UnfamiliarLogin(userID int64, device, country string) (newDevice, newCountry bool, err error) {
+	var total, sameDevice, withCountry, sameCountry int
+	err = database.DB.QueryRow(
+		`SELECT COUNT(*),
+		        COALESCE(SUM(device = ?), 0),
+		        COALESCE(SUM(COALESCE(country, '') != ''), 0),
+		        COALESCE(SUM(country = ?), 0)
+		 FROM login_logs WHERE user_id = ? AND status = 'success'`,
+		device, country, userID,
+	).Scan(&total, &sameDevice, &withCountry, &sameCountry)
+	if err != nil || total == 0 {
+		return false, false, err
+	}
+	newDevice = device != "" && sameDevice == 0
+	newCountry = country != "" && withCountry > 0 && sameCountry == 0
+	return newDevice, newCountry, nil
+}
+
 // GetLoginLogs retrieves login logs for a user with pagination
 func (s *LogService) GetLoginLogs(userID int64, page, pageSize int) (*models.LoginLogListResponse, error) {
 	if page <= 0 {
@@ -125,7 +146,7 @@ func (s *LogService) GetLoginLogs(userID int64, page, pageSize int) (*models.Log
 
 	rows, err := database.DB.Query(
 		`SELECT id, user_id, username, COALESCE(ip_address, '') as ip_address,
-		        COALESCE(ip_location, '') as ip_location,
+		        COALESCE(ip_location, '') as ip_location, COALESCE(country, '') as country,
 		        COALESCE(user_agent, '') as user_agent, COALESCE(device, '') as device,
 		        status, COALESCE(message, '') as message, created_at
 		 FROM login_logs
@@ -144,7 +165,7 @@ func (s *LogService) GetLoginLogs(userID int64, page, pageSize int) (*models.Log
 		var log models.LoginLog
 		err := rows.Scan(
 			&log.ID, &log.UserID, &log.Username, &log.IPAddress,
-			&log.IPLocation, &log.UserAgent, &log.Device, &log.Status, &log.Message, &log.CreatedAt,
+			&log.IPLocation, &log.Country, &log.UserAgent, &log.Device, &log.Status, &log.Message, &log.CreatedAt,
 		)
 		if err != nil {
 			continue
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// lockoutPolicy locks a key once failures reach threshold. Each further
+// failure doubles the lock, starting at base and capped at max.
+type lockoutPolicy struct {
+	threshold int
+	base      time.Duration
+	max       time.Duration
+}
+
+// throttlePolicy spaces out attempts on a key once failures reach threshold.
+// Each further failure doubles the spacing, starting at base and capped at
+// max. An attempt that would wait longer than maxWait is refused.
+type throttlePolicy struct {
+	threshold int
+	base      time.Duration
+	max       time.Duration
+	maxWait   time.Duration
+}
+
+// Shared NATs put many users behind one IP, so the IP limit is looser
+var ipLockout = lockoutPolicy{threshold: 20, base: time.Minute, max: time.Hour}
+
+// Usernames are throttled rather than locked, so guesses from many
+// addresses slow down without locking the owner out of their account
+var userThrottle = throttlePolicy{threshold: 5, base: time.Second, max: 8 * time.Second, maxWait: 30 * time.Second}
+
+func (p throttlePolicy) delay(failures int) time.Duration {
+	if failures < p.threshold {
+		return 0
+	}
+	if shift := failures - p.threshold; shift < 16 {
+		return min(p.base<<shift, p.max)
+	}
+	return p.max
+}
+
+// Failures are forgotten after this long without a new failure, counted
+// from the end of the last lock so a long lock does not reset the growth
+const loginFailureWindow = 30 * time.Minute
+
+// LoginLockedError is returned while an IP is locked out or a username has
+// more attempts queued than its throttle allows
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, try again in %s", e.RetryAfter.Round(time.Second))
+}
+
+type loginFailures struct {
+	count       int
+	lastFailure time.Time
+	lockedUntil time.Time
+	nextAttempt time.Time
+}
+
+func (e *loginFailures) expired(now time.Time) bool {
+	last := e.lastFailure
+	if e.lockedUntil.After(last) {
+		last = e.lockedUntil
+	}
+	if e.nextAttempt.After(last) {
+		last = e.nextAttempt
+	}
+	return now.Sub(last) > loginFailureWindow
+}
+
+// LoginGuard counts failed logins per IP and per username in memory.
+// Counters reset on restart, which only shortens a running lockout.
+type LoginGuard struct {
+	mu      sync.Mutex
+	entries map[string]*loginFailures
+	now     func() time.Time
+	sleep   func(time.Duration)
+}
+
+func NewLoginGuard() *LoginGuard {
+	return &LoginGuard{entries: map[string]*loginFailures{}, now: time.Now, sleep: time.Sleep}
+}
+
+func ipKey(ip string) string { return "ip:" + ip }
+
+// userKey folds case so "Admin" and "admin" share one throttle
+func userKey(username string) string {
+	return "user:" + strings.ToLower(strings.TrimSpace(username))
+}
+
+// Admit waits until username may be tried again and returns a
+// *LoginLockedError if ip is locked or the wait would exceed the throttle's
+// limit. An empty ip is one that could not be verified and is not checked.
+func (g *LoginGuard) Admit(ip, username string) error {
+	g.mu.Lock()
+	now := g.now()
+	if ip != "" {
+		if e := g.entries[ipKey(ip)]; e != nil && now.Before(e.lockedUntil) {
+			g.mu.Unlock()
+			return &LoginLockedError{RetryAfter: e.lockedUntil.Sub(now)}
+		}
+	}
+
+	// Attempts on a throttled username take turns, each spaced by the
+	// current delay, so parallel guesses queue up instead of racing
+	var wait time.Duration
+	if e := g.entries[userKey(username)]; e != nil && !e.expired(now) {
+		start := now
+		if e.nextAttempt.After(start) {
+			start = e.nextAttempt
+		}
+		wait = start.Sub(now)
+		if wait > userThrottle.maxWait {
+			g.mu.Unlock()
+			return &LoginLockedError{RetryAfter: wait - userThrottle.maxWait}
+		}
+		e.nextAttempt = start.Add(userThrottle.delay(e.count))
+	}
+	g.mu.Unlock()
+
+	if wait > 0 {
+		g.sleep(wait)
+	}
+	return nil
+}
+
+// Fail records a failed attempt against ip, if known, and username
+func (g *LoginGuard) Fail(ip, username string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	if ip != "" {
+		e := g.fail(ipKey(ip), now)
+		if e.count >= ipLockout.threshold {
+			lock := ipLockout.max
+			if shift := e.count - ipLockout.threshold; shift < 16 {
+				lock = min(ipLockout.base<<shift, ipLockout.max)
+			}
+			e.lockedUntil = now.Add(lock)
+		}
+	}
+	g.fail(userKey(username), now)
+	if len(g.entries) > 10000 {
+		g.prune(now)
+	}
+}
+
+func (g *LoginGuard) fail(key string, now time.Time) *loginFailures {
+	e := g.entries[key]
+	if e == nil || e.expired(now) {
+		e = &loginFailures{}
+		g.entries[key] = e
+	}
+	e.count++
+	e.lastFailure = now
+	return e
+}
+
+// Succeed clears the username counter. The IP counter is kept so one valid
+// account cannot reset the limit for guesses against others.
+func (g *LoginGuard) Succeed(username string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.entries, userKey(username))
+}
+
+func (g *LoginGuard) prune(now time.Time) {
+	for key, e := range g.entries {
+		if e.expired(now) {
+			delete(g.entries, key)
+		}
+	}
+}
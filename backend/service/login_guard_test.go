@@ -0,0 +1,181 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"dns-mng/database"
+)
+
+// newTestLoginGuard returns a guard with a clock the test moves by hand.
+// Sleeping advances the clock and adds to slept.
+func newTestLoginGuard() (*LoginGuard, *time.Time, *time.Duration) {
+	clock := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	var slept time.Duration
+	g := NewLoginGuard()
+	g.now = func() time.Time { return clock }
+	g.sleep = func(d time.Duration) {
+		clock = clock.Add(d)
+		slept += d
+	}
+	return g, &clock, &slept
+}
+
+func retryAfter(t *testing.T, err error) time.Duration {
+	t.Helper()
+	var locked *LoginLockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("error = %v, want *LoginLockedError", err)
+	}
+	return locked.RetryAfter
+}
+
+// admit runs Admit and returns how long it slept
+func admit(t *testing.T, g *LoginGuard, slept *time.Duration, ip, username string) time.Duration {
+	t.Helper()
+	before := *slept
+	if err := g.Admit(ip, username); err != nil {
+		t.Fatalf("Admit(%q, %q): %v", ip, username, err)
+	}
+	return *slept - before
+}
+
+func TestLoginGuardUserThrottle(t *testing.T) {
+	g, _, slept := newTestLoginGuard()
+
+	for i := 1; i < userThrottle.threshold; i++ {
+		admit(t, g, slept, fmt.Sprintf("192.0.2.%d", i), "alice")
+		g.Fail(fmt.Sprintf("192.0.2.%d", i), "alice")
+	}
+	if d := admit(t, g, slept, "192.0.2.100", "alice"); d != 0 {
+		t.Fatalf("throttled before the threshold: slept %s", d)
+	}
+
+	// Each failure from the threshold on doubles the spacing up to the cap
+	want := userThrottle.base
+	for i := 0; i < 6; i++ {
+		g.Fail("192.0.2.100", "alice")
+		admit(t, g, slept, "192.0.2.200", "alice")
+		if d := admit(t, g, slept, "192.0.2.201", "alice"); d != want {
+			t.Fatalf("failure %d: slept %s, want %s", userThrottle.threshold+i, d, want)
+		}
+		want = min(want*2, userThrottle.max)
+	}
+	if want != userThrottle.max {
+		t.Fatalf("spacing never reached the cap, last %s", want)
+	}
+}
+
+func TestLoginGuardUserThrottleQueue(t *testing.T) {
+	g, clock, _ := newTestLoginGuard()
+
+	for i := 0; i < userThrottle.threshold; i++ {
+		g.Fail(fmt.Sprintf("192.0.2.%d", i), "Alice ")
+	}
+	// Parallel guesses reserve consecutive slots until the queue is full.
+	// Admit is not allowed to sleep here, as the callers would in parallel.
+	g.sleep = func(time.Duration) {}
+	queued := 0
+	var err error
+	for ; queued < 100; queued++ {
+		if err = g.Admit("", "ALICE"); err != nil {
+			break
+		}
+	}
+	if want := int(userThrottle.maxWait/userThrottle.base) + 1; queued != want {
+		t.Errorf("queued %d attempts, want %d", queued, want)
+	}
+	wait := retryAfter(t, err)
+
+	// The owner is only refused while the queue is full, not locked out
+	*clock = clock.Add(wait)
+	if err := g.Admit("198.51.100.1", "alice"); err != nil {
+		t.Errorf("refused once the queue drained: %v", err)
+	}
+}
+
+func TestLoginGuardIPLockout(t *testing.T) {
+	g, _, _ := newTestLoginGuard()
+
+	for i := 0; i < ipLockout.threshold; i++ {
+		g.Fail("198.51.100.7", fmt.Sprintf("user%d", i))
+	}
+	if got := retryAfter(t, g.Admit("198.51.100.7", "someone-else")); got != ipLockout.base {
+		t.Errorf("ip locked for %s, want %s", got, ipLockout.base)
+	}
+	if err := g.Admit("198.51.100.8", "someone-else"); err != nil {
+		t.Errorf("other ip locked: %v", err)
+	}
+}
+
+func TestLoginGuardSucceed(t *testing.T) {
+	g, _, slept := newTestLoginGuard()
+
+	for i := 0; i < ipLockout.threshold-1; i++ {
+		g.Fail("198.51.100.7", "bob")
+	}
+	g.Succeed("Bob")
+	admit(t, g, slept, "198.51.100.9", "bob")
+	if d := admit(t, g, slept, "198.51.100.9", "bob"); d != 0 {
+		t.Errorf("username still throttled after success: slept %s", d)
+	}
+	// The IP counter survives, so one more failure locks the IP
+	g.Fail("198.51.100.7", "carol")
+	if err := g.Admit("198.51.100.7", "dave"); err == nil {
+		t.Error("success reset the ip counter")
+	}
+}
+
+func TestLoginGuardWindow(t *testing.T) {
+	g, clock, slept := newTestLoginGuard()
+
+	for i := 1; i < userThrottle.threshold; i++ {
+		g.Fail("192.0.2.1", "erin")
+	}
+	*clock = clock.Add(loginFailureWindow + time.Second)
+	g.Fail("192.0.2.1", "erin")
+	admit(t, g, slept, "192.0.2.1", "erin")
+	if d := admit(t, g, slept, "192.0.2.1", "erin"); d != 0 {
+		t.Errorf("old failures still counted: slept %s", d)
+	}
+
+	// After an IP lock the window starts when the lock ends
+	for i := 0; i < ipLockout.threshold; i++ {
+		g.Fail("192.0.2.2", fmt.Sprintf("user%d", i))
+	}
+	*clock = clock.Add(ipLockout.base + loginFailureWindow - time.Second)
+	g.Fail("192.0.2.2", "erin")
+	if got := retryAfter(t, g.Admit("192.0.2.2", "erin")); got != 2*ipLockout.base {
+		t.Errorf("ip locked for %s after the lock, want %s", got, 2*ipLockout.base)
+	}
+}
+
+func TestLoginGuardUnverifiedIP(t *testing.T) {
+	g, _, _ := newTestLoginGuard()
+
+	for i := 0; i < ipLockout.threshold; i++ {
+		g.Fail("", fmt.Sprintf("user%d", i))
+	}
+	if err := g.Admit("", "frank"); err != nil {
+		t.Errorf("empty ip was counted: %v", err)
+	}
+}
+
+func TestVerifyCredentialsUnknownUser(t *testing.T) {
+	openTestDB(t)
+	if _, err := database.DB.Exec(`INSERT INTO users (id, username, password_hash) VALUES (1, 'admin', ?)`, string(dummyPasswordHash())); err != nil {
+		t.Fatal(err)
+	}
+	s := &UserService{guard: NewLoginGuard()}
+
+	_, unknown := s.VerifyCredentials("nobody", "dns-mng-unknown-user")
+	_, wrong := s.VerifyCredentials("admin", "wrong password")
+	if unknown == nil || wrong == nil || unknown.Error() != wrong.Error() {
+		t.Errorf("unknown user = %v, wrong password = %v; want the same error", unknown, wrong)
+	}
+	if _, err := s.VerifyCredentials("admin", "dns-mng-unknown-user"); err != nil {
+		t.Errorf("VerifyCredentials: %v", err)
+	}
+}
@@ -130,16 +130,23 @@ func (s *NotificationService) GetExpiringDomains() ([]models.ExpiringDomain, err
 			dc.renewal_url,
 			ns.days_before,
 			ns.last_notified_at,
-			ec.to_email,
-			ec.language
+			COALESCE(ec.to_email, ''),
+			COALESCE(ec.language, '')
 		FROM domain_cache dc
 		INNER JOIN notification_settings ns ON 
 			dc.user_id = ns.user_id AND 
 			dc.account_id = ns.account_id AND 
 			dc.domain_id = ns.domain_id
-		INNER JOIN email_config ec ON dc.user_id = ec.user_id
+		LEFT JOIN email_config ec ON dc.user_id = ec.user_id
 		WHERE ns.enabled = 1 
-			AND ec.enabled = 1
+			AND (
+				(ec.enabled = 1 AND (ec.notify_events = '' OR ',' || ec.notify_events || ',' LIKE '%,domain_expiry,%'))
+				OR EXISTS (
+					SELECT 1 FROM notification_channels nc
+					WHERE nc.user_id = dc.user_id AND nc.enabled = 1
+						AND (nc.events = '' OR ',' || nc.events || ',' LIKE '%,domain_expiry,%')
+				)
+			)
 			AND dc.renewal_date != '' 
 			AND dc.renewal_date != 'permanent'
 			AND dc.deleted_at IS NULL
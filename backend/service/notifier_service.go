@@ -0,0 +1,599 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+
+	"dns-mng/database"
+	"dns-mng/models"
+)
+
+// ErrInvalidNotificationChannel is returned for malformed channel configs
+var ErrInvalidNotificationChannel = errors.New("invalid notification channel")
+
+const maskedSecret = "******"
+
+// NotifierService routes notifications to the SMTP email config and every
+// enabled notification channel of a user that subscribes to the event.
+type NotifierService struct {
+	emailService *EmailService
+}
+
+func NewNotifierService(emailService *EmailService) *NotifierService {
+	return &NotifierService{emailService: emailService}
+}
+
+// ChannelTypes lists the supported channel types and their config keys
+func (s *NotifierService) ChannelTypes() []models.NotificationChannelType {
+	types := make([]models.NotificationChannelType, 0, len(notifiers))
+	for name, n := range notifiers {
+		types = append(types, models.NotificationChannelType{
+			Type:     name,
+			Required: n.Required(),
+			Optional: append([]string{}, n.Optional()...),
+		})
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].Type < types[j].Type })
+	return types
+}
+
+const notificationChannelColumns = `id, user_id, name, type, config, events, enabled, created_at, updated_at`
+
+func scanNotificationChannel(row rowScanner) (*models.NotificationChannel, error) {
+	var ch models.NotificationChannel
+	var config, events string
+	var enabled int
+	if err := row.Scan(&ch.ID, &ch.UserID, &ch.Name, &ch.Type, &config, &events, &enabled, &ch.CreatedAt, &ch.UpdatedAt); err != nil {
+		return nil, err
+	}
+	ch.Config = map[string]string{}
+	config, err := DecryptSecret(config)
+	if err != nil {
+		return nil, fmt.Errorf("notification channel %d: %w", ch.ID, err)
+	}
+	if err := json.Unmarshal([]byte(config), &ch.Config); err != nil {
+		log.Printf("notification channel %d: invalid config: %v", ch.ID, err)
+	}
+	ch.Events = splitList(events)
+	ch.Enabled = enabled == 1
+	return &ch, nil
+}
+
+// encryptChannelConfig stores the whole config encrypted, not only the keys
+// masked in responses
+func encryptChannelConfig(config map[string]string) (string, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	return EncryptSecret(string(data))
+}
+
+func maskChannel(ch *models.NotificationChannel) *models.NotificationChannel {
+	masked := *ch
+	masked.Config = make(map[string]string, len(ch.Config))
+	for k, v := range ch.Config {
+		if secretChannelKeys[k] && v != "" {
+			v = maskedSecret
+		}
+		masked.Config[k] = v
+	}
+	return &masked
+}
+
+func (s *NotifierService) listChannels(userID int64) ([]*models.NotificationChannel, error) {
+	rows, err := database.DB.Query(
+		`SELECT `+notificationChannelColumns+` FROM notification_channels WHERE user_id = ? ORDER BY id ASC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var channels []*models.NotificationChannel
+	for rows.Next() {
+		ch, err := scanNotificationChannel(rows)
+		if err != nil {
+			return nil, err
+		}
+		channels = append(channels, ch)
+	}
+	return channels, rows.Err()
+}
+
+func (s *NotifierService) getChannel(userID, id int64) (*models.NotificationChannel, error) {
+	ch, err := scanNotificationChannel(database.DB.QueryRow(
+		`SELECT `+notificationChannelColumns+` FROM notification_channels WHERE id = ? AND user_id = ?`,
+		id, userID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return ch, err
+}
+
+// ListChannels returns a user's channels with secrets masked
+func (s *NotifierService) ListChannels(userID int64) ([]models.NotificationChannel, error) {
+	channels, err := s.listChannels(userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]models.NotificationChannel, 0, len(channels))
+	for _, ch := range channels {
+		out = append(out, *maskChannel(ch))
+	}
+	return out, nil
+}
+
+// GetChannel returns one channel with secrets masked, nil if not found
+func (s *NotifierService) GetChannel(userID, id int64) (*models.NotificationChannel, error) {
+	ch, err := s.getChannel(userID, id)
+	if err != nil || ch == nil {
+		return nil, err
+	}
+	return maskChannel(ch), nil
+}
+
+// CreateChannel adds a notification channel
+func (s *NotifierService) CreateChannel(userID int64, req *models.CreateNotificationChannelRequest) (*models.NotificationChannel, error) {
+	ch := &models.NotificationChannel{
+		Name:    strings.TrimSpace(req.Name),
+		Type:    strings.ToLower(strings.TrimSpace(req.Type)),
+		Config:  req.Config,
+		Enabled: req.Enabled == nil || *req.Enabled,
+	}
+	events, err := normalizeNotifyEvents(req.Events)
+	if err != nil {
+		return nil, err
+	}
+	ch.Events = events
+	if err := validateNotificationChannel(ch); err != nil {
+		return nil, err
+	}
+
+	config, err := encryptChannelConfig(ch.Config)
+	if err != nil {
+		return nil, err
+	}
+	res, err := database.DB.Exec(
+		`INSERT INTO notification_channels (user_id, name, type, config, events, enabled) VALUES (?, ?, ?, ?, ?, ?)`,
+		userID, ch.Name, ch.Type, config, strings.Join(ch.Events, ","), boolToInt(ch.Enabled),
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, _ := res.LastInsertId()
+	return s.GetChannel(userID, id)
+}
+
+// UpdateChannel changes a channel; nil request fields are kept
+func (s *NotifierService) UpdateChannel(userID, id int64, req *models.UpdateNotificationChannelRequest) (*models.NotificationChannel, error) {
+	ch, err := s.getChannel(userID, id)
+	if err != nil || ch == nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		ch.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Config != nil {
+		config := make(map[string]string, len(req.Config))
+		for k, v := range req.Config {
+			// Masked or omitted secrets keep the stored value
+			if secretChannelKeys[k] && (v == "" || v == maskedSecret) {
+				v = ch.Config[k]
+			}
+			config[k] = v
+		}
+		ch.Config = config
+	}
+	if req.Events != nil {
+		if ch.Events, err = normalizeNotifyEvents(req.Events); err != nil {
+			return nil, err
+		}
+	}
+	if req.Enabled != nil {
+		ch.Enabled = *req.Enabled
+	}
+	if err := validateNotificationChannel(ch); err != nil {
+		return nil, err
+	}
+
+	config, err := encryptChannelConfig(ch.Config)
+	if err != nil {
+		return nil, err
+	}
+	_, err = database.DB.Exec(
+		`UPDATE notification_channels SET name = ?, config = ?, events = ?, enabled = ?, updated_at = datetime('now')
+		 WHERE id = ? AND user_id = ?`,
+		ch.Name, config, strings.Join(ch.Events, ","), boolToInt(ch.Enabled), id, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetChannel(userID, id)
+}
+
+// DeleteChannel removes a channel, reporting whether it existed
+func (s *NotifierService) DeleteChannel(userID, id int64) (bool, error) {
+	res, err := database.DB.Exec(`DELETE FROM notification_channels WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// TestChannel sends a test message through one channel, enabled or not
+func (s *NotifierService) TestChannel(ctx context.Context, userID, id int64) (bool, error) {
+	ch, err := s.getChannel(userID, id)
+	if err != nil || ch == nil {
+		return false, err
+	}
+	t := GetEmailTranslations(s.userLanguage(userID), "zh")
+	return true, notifiers[ch.Type].Send(ctx, ch.Config, &models.NotificationMessage{
+		Event: "test",
+		Title: t.ChannelTestTitle,
+		Text:  t.ChannelTestText,
+	})
+}
+
+func validateNotificationChannel(ch *models.NotificationChannel) error {
+	if ch.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidNotificationChannel)
+	}
+	n, ok := notifiers[ch.Type]
+	if !ok {
+		return fmt.Errorf("%w: unsupported type %s", ErrInvalidNotificationChannel, ch.Type)
+	}
+	allowed := map[string]bool{}
+	for _, k := range append(n.Required(), n.Optional()...) {
+		allowed[k] = true
+	}
+	config := map[string]string{}
+	for k, v := range ch.Config {
+		if !allowed[k] {
+			return fmt.Errorf("%w: unknown config key %s for %s", ErrInvalidNotificationChannel, k, ch.Type)
+		}
+		if v = strings.TrimSpace(v); v != "" {
+			config[k] = v
+		}
+	}
+	for _, k := range n.Required() {
+		if config[k] == "" {
+			return fmt.Errorf("%w: %s requires %s", ErrInvalidNotificationChannel, ch.Type, k)
+		}
+	}
+	for _, k := range []string{"webhook_url", "url", "api_base"} {
+		if v := config[k]; v != "" {
+			u, err := url.Parse(v)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("%w: %s must be an http(s) URL", ErrInvalidNotificationChannel, k)
+			}
+		}
+	}
+	ch.Config = config
+	return nil
+}
+
+// normalizeNotifyEvents validates an event list; nil stays nil
+func normalizeNotifyEvents(in []string) ([]string, error) {
+	if in == nil {
+		return nil, nil
+	}
+	known := map[string]bool{}
+	for _, e := range models.NotifyEvents {
+		known[e] = true
+	}
+	out := []string{}
+	seen := map[string]bool{}
+	for _, e := range in {
+		e = strings.TrimSpace(e)
+		if e == "" || seen[e] {
+			continue
+		}
+		if !known[e] {
+			return nil, fmt.Errorf("%w: unknown event %s", ErrInvalidNotificationChannel, e)
+		}
+		seen[e] = true
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+// emailNotifyEvents is the notify_events value for a new email config
+func emailNotifyEvents(events []string) string {
+	if events == nil {
+		return models.NotifyEventDomainExpiry
+	}
+	return strings.Join(events, ",")
+}
+
+func subscribes(events []string, event string) bool {
+	if len(events) == 0 {
+		return true
+	}
+	for _, e := range events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *NotifierService) userLanguage(userID int64) string {
+	var language string
+	database.DB.QueryRow(`SELECT COALESCE(language, '') FROM email_config WHERE user_id = ?`, userID).Scan(&language)
+	return language
+}
+
+func (s *NotifierService) emailSubscribed(userID int64, event string) bool {
+	var enabled int
+	var events string
+	err := database.DB.QueryRow(
+		`SELECT enabled, notify_events FROM email_config WHERE user_id = ?`, userID,
+	).Scan(&enabled, &events)
+	return err == nil && enabled == 1 && subscribes(splitList(events), event)
+}
+
+// Notify sends msg to the user's email (when subscribed) and every enabled
+// channel subscribed to msg.Event. It returns how many deliveries succeeded
+// and the joined errors of the failed ones.
+func (s *NotifierService) Notify(ctx context.Context, userID int64, msg *models.NotificationMessage) (int, error) {
+	return s.notify(ctx, userID, msg, func() error {
+		return s.emailService.SendNotification(userID, msg)
+	})
+}
+
+func (s *NotifierService) notify(ctx context.Context, userID int64, msg *models.NotificationMessage, sendEmail func() error) (int, error) {
+	sent := 0
+	var errs []error
+	if s.emailSubscribed(userID, msg.Event) {
+		if err := sendEmail(); err != nil {
+			errs = append(errs, fmt.Errorf("email: %w", err))
+		} else {
+			sent++
+		}
+	}
+
+	channels, err := s.listChannels(userID)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, ch := range channels {
+		if !ch.Enabled || !subscribes(ch.Events, msg.Event) {
+			continue
+		}
+		n, ok := notifiers[ch.Type]
+		if !ok {
+			continue
+		}
+		if err := n.Send(ctx, ch.Config, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s (%s): %w", ch.Name, ch.Type, err))
+			continue
+		}
+		sent++
+	}
+	return sent, errors.Join(errs...)
+}
+
+// NotifyExpiry sends a domain expiry alert; email keeps its HTML template
+func (s *NotifierService) NotifyExpiry(ctx context.Context, domain models.ExpiringDomain) (int, error) {
+	t := GetEmailTranslations(domain.Language, "zh")
+	msg := &models.NotificationMessage{
+		Event: models.NotifyEventDomainExpiry,
+		Title: t.ExpirySubject(domain.DomainName, domain.DaysRemaining),
+		Text: strings.Join([]string{
+			t.ExpiryMessage,
+			t.DomainLabel + domain.DomainName,
+			t.ExpiryDateLabel + domain.RenewalDate,
+			t.DaysRemainingFmt(domain.DaysRemaining),
+		}, "\n"),
+		URL: domain.RenewalURL,
+		Data: map[string]interface{}{
+			"account_id":     domain.AccountID,
+			"domain_id":      domain.DomainID,
+			"domain":         domain.DomainName,
+			"renewal_date":   domain.RenewalDate,
+			"days_remaining": domain.DaysRemaining,
+		},
+	}
+	return s.notify(ctx, domain.UserID, msg, func() error {
+		return s.emailService.SendExpiryNotification(domain.UserID, domain)
+	})
+}
+
+// NotifyDNSHEAutoRenew reports the outcome of a scheduled DNSHE auto-renew
+// run. Runs that neither renewed nor failed anything are not reported.
+func (s *NotifierService) NotifyDNSHEAutoRenew(ctx context.Context, userID int64, result *AutoRenewRunResult) {
+	if result.Renewed == 0 && result.Failed == 0 {
+		return
+	}
+	t := GetEmailTranslations(s.userLanguage(userID), "zh")
+	var lines []string
+	if len(result.RenewedDomains) > 0 {
+		lines = append(lines, t.AutoRenewRenewed+strings.Join(result.RenewedDomains, ", "))
+	}
+	if len(result.FailedDomains) > 0 {
+		lines = append(lines, t.AutoRenewFailed+strings.Join(result.FailedDomains, ", "))
+	}
+	msg := &models.NotificationMessage{
+		Event: models.NotifyEventDNSHEAutoRenew,
+		Title: t.AutoRenewTitle,
+		Text:  strings.Join(lines, "\n"),
+		Data: map[string]interface{}{
+			"renewed": result.RenewedDomains,
+			"failed":  result.FailedDomains,
+		},
+	}
+	if _, err := s.Notify(ctx, userID, msg); err != nil {
+		log.Printf("Failed to send DNSHE auto-renew notification to user %d: %v", userID, err)
+	}
+}
+
+// NotifyDomainChanges reports what a scheduled domain refresh added,
+// restored or queued for deletion
+func (s *NotifierService) NotifyDomainChanges(ctx context.Context, summary *models.DomainRefreshSummary) {
+	if !summary.HasChanges() {
+		return
+	}
+	t := GetEmailTranslations(s.userLanguage(summary.UserID), "zh")
+	var lines []string
+	if len(summary.Added) > 0 {
+		lines = append(lines, t.DomainChangesAdded+strings.Join(summary.Added, ", "))
+	}
+	if len(summary.Restored) > 0 {
+		lines = append(lines, t.DomainChangesRestored+strings.Join(summary.Restored, ", "))
+	}
+	if len(summary.PendingDeletion) > 0 {
+		names := make([]string, 0, len(summary.PendingDeletion))
+		for _, item := range summary.PendingDeletion {
+			names = append(names, item.DomainName)
+		}
+		lines = append(lines, t.DomainChangesPending+strings.Join(names, ", "))
+	}
+	msg := &models.NotificationMessage{
+		Event: models.NotifyEventDomainChanges,
+		Title: t.DomainChangesTitle,
+		Text:  strings.Join(lines, "\n"),
+		Data: map[string]interface{}{
+			"added":            summary.Added,
+			"restored":         summary.Restored,
+			"pending_deletion": summary.PendingDeletion,
+		},
+	}
+	if _, err := s.Notify(ctx, summary.UserID, msg); err != nil {
+		log.Printf("Failed to send domain change notification to user %d: %v", summary.UserID, err)
+	}
+}
+
+// NotifyNewLogin warns a user about a successful login from a device or
+// country not seen in their earlier logins
+func (s *NotifierService) NotifyNewLogin(ctx context.Context, entry *models.LoginLog, newDevice, newCountry bool) {
+	if !newDevice && !newCountry {
+		return
+	}
+	t := GetEmailTranslations(s.userLanguage(entry.UserID), "zh")
+	var lines []string
+	if newDevice {
+		lines = append(lines, t.NewLoginNewDevice)
+	}
+	if newCountry {
+		lines = append(lines, t.NewLoginNewCountry)
+	}
+	lines = append(lines, t.NewLoginDevice+entry.Device, t.NewLoginIP+entry.IPAddress)
+	if entry.IPLocation != "" {
+		lines = append(lines, t.NewLoginLocation+entry.IPLocation)
+	}
+	lines = append(lines, "", t.NewLoginHint)
+	msg := &models.NotificationMessage{
+		Event: models.NotifyEventNewLogin,
+		Title: t.NewLoginTitle,
+		Text:  strings.Join(lines, "\n"),
+		Data: map[string]interface{}{
+			"username":    entry.Username,
+			"device":      entry.Device,
+			"ip":          entry.IPAddress,
+			"location":    entry.IPLocation,
+			"country":     entry.Country,
+			"new_device":  newDevice,
+			"new_country": newCountry,
+		},
+	}
+	if _, err := s.Notify(ctx, entry.UserID, msg); err != nil {
+		log.Printf("Failed to send new login notification to user %d: %v", entry.UserID, err)
+	}
+}
+
+// NotifyAccountFailing tells a user that the credentials of accounts were
+// rejected by their providers, e.g. after they expired or were revoked
+func (s *NotifierService) NotifyAccountFailing(ctx context.Context, userID int64, accounts []models.Account) {
+	t := GetEmailTranslations(s.userLanguage(userID), "zh")
+	lines := []string{t.AccountFailingIntro}
+	items := make([]map[string]interface{}, 0, len(accounts))
+	for _, acc := range accounts {
+		item := map[string]interface{}{
+			"account_id":    acc.ID,
+			"name":          acc.Name,
+			"provider_type": acc.ProviderType,
+		}
+		line := fmt.Sprintf("- %s (%s)", acc.Name, acc.ProviderType)
+		if acc.LastCheck != nil {
+			line += ": " + acc.LastCheck.Error
+			item["code"] = acc.LastCheck.Code
+			item["error"] = acc.LastCheck.Error
+		}
+		lines = append(lines, line)
+		items = append(items, item)
+	}
+	lines = append(lines, "", t.AccountFailingHint)
+	msg := &models.NotificationMessage{
+		Event: models.NotifyEventAccountFailing,
+		Title: t.AccountFailingTitle,
+		Text:  strings.Join(lines, "\n"),
+		Data:  map[string]interface{}{"accounts": items},
+	}
+	if _, err := s.Notify(ctx, userID, msg); err != nil {
+		log.Printf("Failed to send account failure notification to user %d: %v", userID, err)
+	}
+}
+
+// NotifySchedulerFailure reports a failed scheduled task. userID 0 means a
+// system-wide task, reported to every user subscribed to scheduler failures.
+func (s *NotifierService) NotifySchedulerFailure(ctx context.Context, userID int64, task, message string) {
+	userIDs := []int64{userID}
+	if userID == 0 {
+		var err error
+		if userIDs, err = s.subscribedUsers(models.NotifyEventSchedulerFailure); err != nil {
+			log.Printf("Failed to list users for scheduler failure notification: %v", err)
+			return
+		}
+	}
+	for _, uid := range userIDs {
+		t := GetEmailTranslations(s.userLanguage(uid), "zh")
+		msg := &models.NotificationMessage{
+			Event: models.NotifyEventSchedulerFailure,
+			Title: t.SchedulerFailureTitle,
+			Text:  t.SchedulerFailureTask + task + "\n" + message,
+			Data:  map[string]interface{}{"task": task, "message": message},
+		}
+		if _, err := s.Notify(ctx, uid, msg); err != nil {
+			log.Printf("Failed to send scheduler failure notification to user %d: %v", uid, err)
+		}
+	}
+}
+
+// subscribedUsers returns users with email or an enabled channel for event
+func (s *NotifierService) subscribedUsers(event string) ([]int64, error) {
+	rows, err := database.DB.Query(
+		`SELECT user_id, notify_events FROM email_config WHERE enabled = 1
+		 UNION ALL
+		 SELECT user_id, events FROM notification_channels WHERE enabled = 1`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seen := map[int64]bool{}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		var events string
+		if err := rows.Scan(&id, &events); err != nil {
+			return nil, err
+		}
+		if !seen[id] && subscribes(splitList(events), event) {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, rows.Err()
+}
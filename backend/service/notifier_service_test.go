@@ -0,0 +1,90 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"dns-mng/database"
+	"dns-mng/models"
+)
+
+// Config keys that are not credentials and may be shown as stored
+var publicChannelKeys = map[string]bool{"chat_id": true, "api_base": true}
+
+func TestNotificationChannelsMaskCredentials(t *testing.T) {
+	resetSecretStore(t)
+	openTestDB(t)
+	if _, err := database.DB.Exec(`INSERT INTO users (id, username, password_hash) VALUES (1, 'admin', 'x')`); err != nil {
+		t.Fatal(err)
+	}
+	s := NewNotifierService(nil)
+
+	var credentials []string
+	stored := map[string]map[string]string{}
+	for typ, n := range notifiers {
+		config := map[string]string{}
+		for _, k := range append(n.Required(), n.Optional()...) {
+			switch {
+			case publicChannelKeys[k]:
+				config[k] = "https://api.telegram.org"
+				if k == "chat_id" {
+					config[k] = "12345"
+				}
+			case k == "url" || k == "webhook_url":
+				config[k] = "https://hooks.example.com/cred-" + typ + "-" + k
+			default:
+				config[k] = "cred-" + typ + "-" + k
+			}
+			if !publicChannelKeys[k] {
+				credentials = append(credentials, config[k])
+			}
+		}
+		stored[typ] = config
+		if _, err := s.CreateChannel(1, &models.CreateNotificationChannelRequest{Name: typ, Type: typ, Config: config}); err != nil {
+			t.Fatalf("CreateChannel(%s): %v", typ, err)
+		}
+	}
+
+	channels, err := s.ListChannels(1)
+	if err != nil {
+		t.Fatalf("ListChannels: %v", err)
+	}
+	if len(channels) != len(notifiers) {
+		t.Fatalf("got %d channels, want %d", len(channels), len(notifiers))
+	}
+	var responses []interface{}
+	for _, ch := range channels {
+		got, err := s.GetChannel(1, ch.ID)
+		if err != nil || got == nil {
+			t.Fatalf("GetChannel(%d) = %v, %v", ch.ID, got, err)
+		}
+		responses = append(responses, ch, got)
+		for k, v := range got.Config {
+			if !publicChannelKeys[k] && v != maskedSecret {
+				t.Errorf("%s: %s = %q, want masked", ch.Type, k, v)
+			}
+		}
+	}
+	body, _ := json.Marshal(responses)
+	for _, cred := range credentials {
+		if strings.Contains(string(body), cred) {
+			t.Errorf("response contains credential %q", cred)
+		}
+	}
+
+	// Sending the masked values back keeps the stored credentials
+	for _, ch := range channels {
+		if _, err := s.UpdateChannel(1, ch.ID, &models.UpdateNotificationChannelRequest{Config: ch.Config}); err != nil {
+			t.Fatalf("UpdateChannel(%s): %v", ch.Type, err)
+		}
+		got, err := s.getChannel(1, ch.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got.Config, stored[ch.Type]) {
+			t.Errorf("%s: stored config = %v, want %v", ch.Type, got.Config, stored[ch.Type])
+		}
+	}
+}
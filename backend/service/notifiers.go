@@ -0,0 +1,257 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"dns-mng/models"
+)
+
+// Notifier delivers a notification over one channel type. New channel types
+// implement it and register in notifiers.
+type Notifier interface {
+	// Required and Optional list the config keys of the channel
+	Required() []string
+	Optional() []string
+	Send(ctx context.Context, cfg map[string]string, msg *models.NotificationMessage) error
+}
+
+var notifiers = map[string]Notifier{
+	"telegram": telegramNotifier{},
+	"slack":    slackNotifier{},
+	"dingtalk": dingtalkNotifier{},
+	"wecom":    wecomNotifier{},
+	"feishu":   feishuNotifier{},
+	"webhook":  webhookNotifier{},
+}
+
+// secretChannelKeys are masked in API responses. Webhook URLs count as
+// secrets because Slack, DingTalk, WeCom and Feishu embed the token in them.
+var secretChannelKeys = map[string]bool{
+	"bot_token":   true,
+	"secret":      true,
+	"token":       true,
+	"webhook_url": true,
+	"url":         true,
+}
+
+const notifierTimeout = 10 * time.Second
+
+var notifierClient = newPublicHTTPClient(notifierTimeout, "")
+
+// postJSON posts payload and returns the response body for 2xx responses
+func postJSON(ctx context.Context, endpoint string, headers map[string]string, payload interface{}) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := notifierClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return respBody, nil
+}
+
+// plainText renders a message as title, text and link lines
+func plainText(msg *models.NotificationMessage) string {
+	text := msg.Title + "\n\n" + msg.Text
+	if msg.URL != "" {
+		text += "\n" + msg.URL
+	}
+	return text
+}
+
+func markdownText(msg *models.NotificationMessage) string {
+	text := "### " + msg.Title + "\n\n" + strings.ReplaceAll(msg.Text, "\n", "\n\n")
+	if msg.URL != "" {
+		text += "\n\n[" + msg.URL + "](" + msg.URL + ")"
+	}
+	return text
+}
+
+// telegramNotifier sends through the Bot API
+type telegramNotifier struct{}
+
+func (telegramNotifier) Required() []string { return []string{"bot_token", "chat_id"} }
+func (telegramNotifier) Optional() []string { return []string{"api_base"} }
+
+func (telegramNotifier) Send(ctx context.Context, cfg map[string]string, msg *models.NotificationMessage) error {
+	base := strings.TrimSuffix(cfg["api_base"], "/")
+	if base == "" {
+		base = "https://api.telegram.org"
+	}
+	body, err := postJSON(ctx, base+"/bot"+cfg["bot_token"]+"/sendMessage", nil, map[string]interface{}{
+		"chat_id":                  cfg["chat_id"],
+		"text":                     plainText(msg),
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || !resp.OK {
+		return fmt.Errorf("telegram: %s", resp.Description)
+	}
+	return nil
+}
+
+// slackNotifier posts to an incoming webhook
+type slackNotifier struct{}
+
+func (slackNotifier) Required() []string { return []string{"webhook_url"} }
+func (slackNotifier) Optional() []string { return nil }
+
+func (slackNotifier) Send(ctx context.Context, cfg map[string]string, msg *models.NotificationMessage) error {
+	text := "*" + msg.Title + "*\n" + msg.Text
+	if msg.URL != "" {
+		text += "\n" + msg.URL
+	}
+	_, err := postJSON(ctx, cfg["webhook_url"], nil, map[string]string{"text": text})
+	return err
+}
+
+// dingtalkNotifier posts to a DingTalk robot, signing the URL when a secret is set
+type dingtalkNotifier struct{}
+
+func (dingtalkNotifier) Required() []string { return []string{"webhook_url"} }
+func (dingtalkNotifier) Optional() []string { return []string{"secret"} }
+
+func (dingtalkNotifier) Send(ctx context.Context, cfg map[string]string, msg *models.NotificationMessage) error {
+	endpoint := cfg["webhook_url"]
+	if secret := cfg["secret"]; secret != "" {
+		ts := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(ts + "\n" + secret))
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return err
+		}
+		q := u.Query()
+		q.Set("timestamp", ts)
+		q.Set("sign", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+		u.RawQuery = q.Encode()
+		endpoint = u.String()
+	}
+	body, err := postJSON(ctx, endpoint, nil, map[string]interface{}{
+		"msgtype":  "markdown",
+		"markdown": map[string]string{"title": msg.Title, "text": markdownText(msg)},
+	})
+	if err != nil {
+		return err
+	}
+	return checkErrcode("dingtalk", body)
+}
+
+// wecomNotifier posts to a WeCom (企业微信) group robot
+type wecomNotifier struct{}
+
+func (wecomNotifier) Required() []string { return []string{"webhook_url"} }
+func (wecomNotifier) Optional() []string { return nil }
+
+func (wecomNotifier) Send(ctx context.Context, cfg map[string]string, msg *models.NotificationMessage) error {
+	body, err := postJSON(ctx, cfg["webhook_url"], nil, map[string]interface{}{
+		"msgtype":  "markdown",
+		"markdown": map[string]string{"content": markdownText(msg)},
+	})
+	if err != nil {
+		return err
+	}
+	return checkErrcode("wecom", body)
+}
+
+func checkErrcode(name string, body []byte) error {
+	var resp struct {
+		Errcode int    `json:"errcode"`
+		Errmsg  string `json:"errmsg"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("%s: invalid response", name)
+	}
+	if resp.Errcode != 0 {
+		return fmt.Errorf("%s: %d %s", name, resp.Errcode, resp.Errmsg)
+	}
+	return nil
+}
+
+// feishuNotifier posts to a Feishu/Lark custom bot, signing when a secret is set
+type feishuNotifier struct{}
+
+func (feishuNotifier) Required() []string { return []string{"webhook_url"} }
+func (feishuNotifier) Optional() []string { return []string{"secret"} }
+
+func (feishuNotifier) Send(ctx context.Context, cfg map[string]string, msg *models.NotificationMessage) error {
+	payload := map[string]interface{}{
+		"msg_type": "text",
+		"content":  map[string]string{"text": plainText(msg)},
+	}
+	if secret := cfg["secret"]; secret != "" {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(ts+"\n"+secret))
+		payload["timestamp"] = ts
+		payload["sign"] = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+	body, err := postJSON(ctx, cfg["webhook_url"], nil, payload)
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("feishu: invalid response")
+	}
+	if resp.Code != 0 {
+		return fmt.Errorf("feishu: %d %s", resp.Code, resp.Msg)
+	}
+	return nil
+}
+
+// webhookNotifier posts the message as JSON to any URL
+type webhookNotifier struct{}
+
+func (webhookNotifier) Required() []string { return []string{"url"} }
+func (webhookNotifier) Optional() []string { return []string{"token"} }
+
+func (webhookNotifier) Send(ctx context.Context, cfg map[string]string, msg *models.NotificationMessage) error {
+	var headers map[string]string
+	if token := cfg["token"]; token != "" {
+		headers = map[string]string{"Authorization": "Bearer " + token}
+	}
+	_, err := postJSON(ctx, cfg["url"], headers, map[string]interface{}{
+		"event":     msg.Event,
+		"title":     msg.Title,
+		"text":      msg.Text,
+		"url":       msg.URL,
+		"data":      msg.Data,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	return err
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateDestination is returned when an outbound request configured by a
+// user would reach a loopback, private or link-local address
+var ErrPrivateDestination = errors.New("destination is not a public address")
+
+// Carrier-grade NAT and the IPv4 "this network" block, not covered by net.IP
+var nonPublicNets = []*net.IPNet{
+	mustCIDR("0.0.0.0/8"),
+	mustCIDR("100.64.0.0/10"),
+}
+
+func mustCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, n := range nonPublicNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// rejectPrivateAddress is a net.Dialer Control hook. It sees the resolved
+// address, so a hostname that resolves (or rebinds) to an internal address
+// is refused as well.
+func rejectPrivateAddress(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("%w: %s", ErrPrivateDestination, host)
+	}
+	return nil
+}
+
+// publicDialer only connects to public addresses
+func publicDialer() *net.Dialer {
+	return &net.Dialer{Timeout: 30 * time.Second, Control: rejectPrivateAddress}
+}
+
+// newPublicHTTPClient returns a client for user-supplied URLs (webhooks,
+// notification channels, IP echo services). Connections, including
+// redirects, may only reach public addresses. A non-empty network ("tcp4",
+// "tcp6") pins the address family.
+//
+// HTTP(S)_PROXY is ignored: through a proxy the dialer would only see the
+// proxy's address and the proxy could reach internal hosts for us.
+func newPublicHTTPClient(timeout time.Duration, network string) *http.Client {
+	dialer := publicDialer()
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy: nil,
+			DialContext: func(ctx context.Context, defaultNetwork, addr string) (net.Conn, error) {
+				if network != "" {
+					defaultNetwork = network
+				}
+				return dialer.DialContext(ctx, defaultNetwork, addr)
+			},
+			ForceAttemptHTTP2:   true,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+	}
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	tests := map[string]bool{
+		"8.8.8.8":         true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"::1":             false,
+		"fe80::1":         false,
+		"fd00::1":         false,
+		"::ffff:10.0.0.1": false,
+		"224.0.0.1":       false,
+	}
+	for addr, want := range tests {
+		if got := isPublicIP(net.ParseIP(addr)); got != want {
+			t.Errorf("isPublicIP(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestPublicHTTPClientRefusesLoopback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	_, err := newPublicHTTPClient(time.Second, "").Get(srv.URL)
+	if !errors.Is(err, ErrPrivateDestination) {
+		t.Errorf("Get(%s) error = %v, want ErrPrivateDestination", srv.URL, err)
+	}
+}
+
+func TestPublicHTTPClientIgnoresProxy(t *testing.T) {
+	var proxied bool
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = true
+	}))
+	defer proxy.Close()
+	t.Setenv("HTTP_PROXY", proxy.URL)
+	t.Setenv("NO_PROXY", "")
+
+	// Through the proxy an internal address would be reached unchecked
+	_, err := newPublicHTTPClient(time.Second, "").Get("http://169.254.169.254/latest/meta-data/")
+	if !errors.Is(err, ErrPrivateDestination) || proxied {
+		t.Errorf("Get via HTTP_PROXY: error = %v, proxied = %v; want ErrPrivateDestination and no proxy", err, proxied)
+	}
+}
@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Public IP sources used by DDNS agents. A source is one of:
+//   - an http(s) URL that echoes the caller's address as plain text
+//   - "iface:<name>", the first global address on a local interface
+//   - "stun:<host>:<port>", a STUN binding request (RFC 5389)
+const (
+	ipSourceIfacePrefix = "iface:"
+	ipSourceSTUNPrefix  = "stun:"
+)
+
+const publicIPTimeout = 10 * time.Second
+
+// detectPublicIP tries each source in order and returns the first address
+// of the requested family ("A" for IPv4, "AAAA" for IPv6).
+func detectPublicIP(ctx context.Context, sources []string, recordType string) (string, error) {
+	var errs []string
+	for _, source := range sources {
+		ip, err := detectIPFromSource(ctx, source, recordType)
+		if err == nil {
+			return ip, nil
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", source, err))
+	}
+	if len(errs) == 0 {
+		return "", errors.New("no ip source configured")
+	}
+	return "", errors.New(strings.Join(errs, "; "))
+}
+
+func detectIPFromSource(ctx context.Context, source, recordType string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, publicIPTimeout)
+	defer cancel()
+
+	var ip net.IP
+	var err error
+	switch {
+	case strings.HasPrefix(source, "http://"), strings.HasPrefix(source, "https://"):
+		ip, err = ipFromHTTP(ctx, source, recordType)
+	case strings.HasPrefix(source, ipSourceIfacePrefix):
+		ip, err = ipFromInterface(strings.TrimPrefix(source, ipSourceIfacePrefix), recordType)
+	case strings.HasPrefix(source, ipSourceSTUNPrefix):
+		ip, err = ipFromSTUN(ctx, strings.TrimPrefix(source, ipSourceSTUNPrefix), recordType)
+	default:
+		return "", fmt.Errorf("unsupported ip source")
+	}
+	if err != nil {
+		return "", err
+	}
+	if !ipMatchesType(ip, recordType) {
+		return "", fmt.Errorf("got %s, not an %s address", ip, recordType)
+	}
+	return ip.String(), nil
+}
+
+func ipMatchesType(ip net.IP, recordType string) bool {
+	if ip == nil {
+		return false
+	}
+	if recordType == "A" {
+		return ip.To4() != nil
+	}
+	return ip.To4() == nil
+}
+
+// validateIPSource checks the syntax of a source without contacting it
+func validateIPSource(source string) error {
+	switch {
+	case strings.HasPrefix(source, "http://"), strings.HasPrefix(source, "https://"):
+		return nil
+	case strings.HasPrefix(source, ipSourceIfacePrefix):
+		if strings.TrimPrefix(source, ipSourceIfacePrefix) == "" {
+			return fmt.Errorf("missing interface name in %s", source)
+		}
+		return nil
+	case strings.HasPrefix(source, ipSourceSTUNPrefix):
+		if _, _, err := net.SplitHostPort(strings.TrimPrefix(source, ipSourceSTUNPrefix)); err != nil {
+			return fmt.Errorf("invalid stun address in %s: %v", source, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported ip source: %s", source)
+}
+
+// ipFromHTTP reads an address from an echo service such as
+// https://api.ipify.org. The dial is pinned to the requested family so
+// dual-stack hosts report the right address, and internal hosts are refused.
+func ipFromHTTP(ctx context.Context, url, recordType string) (net.IP, error) {
+	network := "tcp4"
+	if recordType == "AAAA" {
+		network = "tcp6"
+	}
+	client := newPublicHTTPClient(publicIPTimeout, network)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return nil, err
+	}
+	ip := net.ParseIP(strings.TrimSpace(string(body)))
+	if ip == nil {
+		return nil, fmt.Errorf("response is not an ip address")
+	}
+	return ip, nil
+}
+
+// ipFromInterface returns the first global unicast address of the family
+// on the named interface. Useful when the host holds the public address
+// itself (PPPoE, IPv6 SLAAC).
+func ipFromInterface(name, recordType string) (net.IP, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || !ipNet.IP.IsGlobalUnicast() || !ipMatchesType(ipNet.IP, recordType) {
+			continue
+		}
+		return ipNet.IP, nil
+	}
+	return nil, fmt.Errorf("no global %s address on %s", recordType, name)
+}
+
+const (
+	stunMagicCookie       = 0x2112A442
+	stunBindingRequest    = 0x0001
+	stunBindingSuccess    = 0x0101
+	stunAttrMappedAddress = 0x0001
+	stunAttrXORMappedAddr = 0x0020
+)
+
+// ipFromSTUN sends a STUN binding request and returns the reflexive
+// address reported by the server.
+func ipFromSTUN(ctx context.Context, addr, recordType string) (net.IP, error) {
+	network := "udp4"
+	if recordType == "AAAA" {
+		network = "udp6"
+	}
+	conn, err := publicDialer().DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	req := make([]byte, 20)
+	binary.BigEndian.PutUint16(req[0:2], stunBindingRequest)
+	binary.BigEndian.PutUint32(req[4:8], stunMagicCookie)
+	txID := req[8:20]
+	if _, err := rand.Read(txID); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(req); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 1024)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return parseSTUNResponse(buf[:n], txID)
+}
+
+func parseSTUNResponse(msg, txID []byte) (net.IP, error) {
+	if len(msg) < 20 || binary.BigEndian.Uint16(msg[0:2]) != stunBindingSuccess {
+		return nil, errors.New("invalid stun response")
+	}
+	if binary.BigEndian.Uint32(msg[4:8]) != stunMagicCookie || string(msg[8:20]) != string(txID) {
+		return nil, errors.New("stun transaction mismatch")
+	}
+
+	length := int(binary.BigEndian.Uint16(msg[2:4]))
+	attrs := msg[20:]
+	if len(attrs) < length {
+		return nil, errors.New("truncated stun response")
+	}
+	attrs = attrs[:length]
+
+	var mapped net.IP
+	for len(attrs) >= 4 {
+		attrType := binary.BigEndian.Uint16(attrs[0:2])
+		attrLen := int(binary.BigEndian.Uint16(attrs[2:4]))
+		if len(attrs) < 4+attrLen {
+			break
+		}
+		value := attrs[4 : 4+attrLen]
+		switch attrType {
+		case stunAttrXORMappedAddr:
+			if ip := stunAddress(value, msg[4:20], true); ip != nil {
+				return ip, nil
+			}
+		case stunAttrMappedAddress:
+			mapped = stunAddress(value, nil, false)
+		}
+		// attributes are padded to 4 bytes
+		next := 4 + (attrLen+3)&^3
+		if next > len(attrs) {
+			break
+		}
+		attrs = attrs[next:]
+	}
+	if mapped != nil {
+		return mapped, nil
+	}
+	return nil, errors.New("no mapped address in stun response")
+}
+
+// stunAddress decodes a (XOR-)MAPPED-ADDRESS value. For XOR decoding, key is
+// the magic cookie followed by the transaction ID.
+func stunAddress(value, key []byte, xor bool) net.IP {
+	if len(value) < 4 {
+		return nil
+	}
+	var size int
+	switch value[1] {
+	case 0x01:
+		size = net.IPv4len
+	case 0x02:
+		size = net.IPv6len
+	default:
+		return nil
+	}
+	if len(value) < 4+size {
+		return nil
+	}
+	ip := make(net.IP, size)
+	copy(ip, value[4:4+size])
+	if xor {
+		for i := range ip {
+			ip[i] ^= key[i]
+		}
+	}
+	return ip
+}
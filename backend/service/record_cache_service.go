@@ -0,0 +1,308 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"dns-mng/database"
+	"dns-mng/models"
+)
+
+// recordCacheMaxAge is how long a synced zone is served without asking the provider
+const recordCacheMaxAge = 5 * time.Minute
+
+// RecordCacheService keeps a local copy of each zone's records. Every live
+// listing and every domain refresh re-syncs the zone and diffs it against
+// the previous copy. Before our own writes DNSService re-syncs the zone, so
+// external edits up to then are recorded, and afterwards it invalidates the
+// zone; the next sync sets a fresh baseline that includes our change.
+type RecordCacheService struct{}
+
+func NewRecordCacheService() *RecordCacheService {
+	return &RecordCacheService{}
+}
+
+// Get returns the cached records of a zone and when it was last synced.
+// ok is false when the zone has never been synced.
+func (s *RecordCacheService) Get(accountID int64, domainID string) (records []models.Record, syncedAt time.Time, ok bool, err error) {
+	syncedAt, ok, err = s.SyncedAt(accountID, domainID)
+	if err != nil || !ok {
+		return nil, time.Time{}, false, err
+	}
+
+	cached, err := s.load(database.DB, accountID, domainID)
+	if err != nil {
+		return nil, time.Time{}, false, err
+	}
+	records = make([]models.Record, 0, len(cached))
+	for _, r := range cached {
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].NodeName != records[j].NodeName {
+			return records[i].NodeName < records[j].NodeName
+		}
+		if records[i].RecordType != records[j].RecordType {
+			return records[i].RecordType < records[j].RecordType
+		}
+		return records[i].ID < records[j].ID
+	})
+	return records, syncedAt, true, nil
+}
+
+// SyncedAt returns when a zone was last synced. ok is false when the zone
+// has no cached copy.
+func (s *RecordCacheService) SyncedAt(accountID int64, domainID string) (syncedAt time.Time, ok bool, err error) {
+	err = database.DB.QueryRow(
+		`SELECT synced_at FROM record_cache_zones WHERE account_id = ? AND domain_id = ?`,
+		accountID, domainID,
+	).Scan(&syncedAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return syncedAt, true, nil
+}
+
+// GetRecord returns one cached record, nil if it is not cached
+func (s *RecordCacheService) GetRecord(accountID int64, domainID, recordID string) *models.Record {
+	var raw string
+	err := database.DB.QueryRow(
+		`SELECT record_json FROM record_cache WHERE account_id = ? AND domain_id = ? AND record_id = ?`,
+		accountID, domainID, recordID,
+	).Scan(&raw)
+	if err != nil {
+		return nil
+	}
+	return parseRecordJSON(raw)
+}
+
+type recordCacheQuerier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func (s *RecordCacheService) load(q recordCacheQuerier, accountID int64, domainID string) (map[string]models.Record, error) {
+	rows, err := q.Query(
+		`SELECT record_id, record_json FROM record_cache WHERE account_id = ? AND domain_id = ?`,
+		accountID, domainID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := make(map[string]models.Record)
+	for rows.Next() {
+		var id, raw string
+		if err := rows.Scan(&id, &raw); err != nil {
+			return nil, err
+		}
+		var r models.Record
+		if err := json.Unmarshal([]byte(raw), &r); err != nil {
+			continue
+		}
+		records[id] = r
+	}
+	return records, rows.Err()
+}
+
+// Sync replaces the cached copy of a zone with a fresh provider listing and
+// returns the differences. The first sync of a zone is a baseline and
+// reports no changes.
+func (s *RecordCacheService) Sync(userID, accountID int64, domainID string, records []models.Record) ([]models.RecordChange, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var zoneID int64
+	err = tx.QueryRow(
+		`SELECT id FROM record_cache_zones WHERE account_id = ? AND domain_id = ?`,
+		accountID, domainID,
+	).Scan(&zoneID)
+	baseline := err == sql.ErrNoRows
+	if err != nil && !baseline {
+		return nil, err
+	}
+
+	old, err := s.load(tx, accountID, domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	changes := []models.RecordChange{}
+	if !baseline {
+		changes = diffRecords(old, records)
+		for i := range changes {
+			ch := &changes[i]
+			ch.UserID, ch.AccountID, ch.DomainID, ch.DetectedAt = userID, accountID, domainID, now
+			res, err := tx.Exec(
+				`INSERT INTO record_changes (user_id, account_id, domain_id, record_id, change_type, old_json, new_json, detected_at)
+				 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+				userID, accountID, domainID, ch.RecordID, ch.ChangeType, recordJSON(ch.Old), recordJSON(ch.New), now,
+			)
+			if err != nil {
+				return nil, err
+			}
+			ch.ID, _ = res.LastInsertId()
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM record_cache WHERE account_id = ? AND domain_id = ?`, accountID, domainID); err != nil {
+		return nil, err
+	}
+	for i := range records {
+		if _, err := tx.Exec(
+			`INSERT INTO record_cache (user_id, account_id, domain_id, record_id, record_json, updated_at) VALUES (?, ?, ?, ?, ?, ?)
+			 ON CONFLICT(account_id, domain_id, record_id) DO UPDATE SET record_json = excluded.record_json, updated_at = excluded.updated_at`,
+			userID, accountID, domainID, records[i].ID, recordJSON(&records[i]), now,
+		); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := tx.Exec(
+		`INSERT INTO record_cache_zones (user_id, account_id, domain_id, synced_at) VALUES (?, ?, ?, ?)
+		 ON CONFLICT(account_id, domain_id) DO UPDATE SET synced_at = excluded.synced_at`,
+		userID, accountID, domainID, now,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// Invalidate forgets a zone entirely; the next listing is a fresh baseline
+func (s *RecordCacheService) Invalidate(accountID int64, domainID string) error {
+	if _, err := database.DB.Exec(`DELETE FROM record_cache WHERE account_id = ? AND domain_id = ?`, accountID, domainID); err != nil {
+		return err
+	}
+	_, err := database.DB.Exec(`DELETE FROM record_cache_zones WHERE account_id = ? AND domain_id = ?`, accountID, domainID)
+	return err
+}
+
+// ListChanges returns the externally detected changes of a zone, newest first
+func (s *RecordCacheService) ListChanges(userID, accountID int64, domainID string, page, pageSize int) (*models.RecordChangeListResponse, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+	offset := (page - 1) * pageSize
+
+	var total int
+	err := database.DB.QueryRow(
+		`SELECT COUNT(*) FROM record_changes WHERE user_id = ? AND account_id = ? AND domain_id = ?`,
+		userID, accountID, domainID,
+	).Scan(&total)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.DB.Query(
+		`SELECT id, user_id, account_id, domain_id, record_id, change_type, old_json, new_json, detected_at
+		 FROM record_changes
+		 WHERE user_id = ? AND account_id = ? AND domain_id = ?
+		 ORDER BY detected_at DESC, id DESC
+		 LIMIT ? OFFSET ?`,
+		userID, accountID, domainID, pageSize, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []models.RecordChange{}
+	for rows.Next() {
+		var ch models.RecordChange
+		var oldJSON, newJSON string
+		if err := rows.Scan(&ch.ID, &ch.UserID, &ch.AccountID, &ch.DomainID, &ch.RecordID, &ch.ChangeType, &oldJSON, &newJSON, &ch.DetectedAt); err != nil {
+			return nil, err
+		}
+		ch.Old = parseRecordJSON(oldJSON)
+		ch.New = parseRecordJSON(newJSON)
+		changes = append(changes, ch)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &models.RecordChangeListResponse{
+		Changes:    changes,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+	}, nil
+}
+
+// diffRecords compares the cached copy with a fresh listing by record ID.
+// Provider bookkeeping (updated_on, raw) is ignored.
+func diffRecords(old map[string]models.Record, fresh []models.Record) []models.RecordChange {
+	var changes []models.RecordChange
+	seen := make(map[string]bool, len(fresh))
+	for i := range fresh {
+		r := fresh[i]
+		seen[r.ID] = true
+		prev, ok := old[r.ID]
+		switch {
+		case !ok:
+			changes = append(changes, models.RecordChange{RecordID: r.ID, ChangeType: models.RecordChangeAdded, New: &r})
+		case !sameRecord(prev, r):
+			changes = append(changes, models.RecordChange{RecordID: r.ID, ChangeType: models.RecordChangeModified, Old: &prev, New: &r})
+		}
+	}
+
+	var removed []string
+	for id := range old {
+		if !seen[id] {
+			removed = append(removed, id)
+		}
+	}
+	sort.Strings(removed)
+	for _, id := range removed {
+		prev := old[id]
+		changes = append(changes, models.RecordChange{RecordID: id, ChangeType: models.RecordChangeRemoved, Old: &prev})
+	}
+	return changes
+}
+
+func sameRecord(a, b models.Record) bool {
+	return sameNodeName(a.NodeName, b.NodeName) &&
+		a.RecordType == b.RecordType &&
+		a.TTL == b.TTL &&
+		a.State == b.State &&
+		a.Content == b.Content &&
+		a.Priority == b.Priority
+}
+
+func recordJSON(r *models.Record) string {
+	if r == nil {
+		return ""
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func parseRecordJSON(raw string) *models.Record {
+	if raw == "" {
+		return nil
+	}
+	var r models.Record
+	if err := json.Unmarshal([]byte(raw), &r); err != nil {
+		return nil
+	}
+	return &r
+}
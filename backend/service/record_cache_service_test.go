@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dns-mng/database"
+	"dns-mng/models"
+)
+
+func TestDiffRecords(t *testing.T) {
+	www := models.Record{ID: "1", NodeName: "www", RecordType: "A", TTL: 600, State: true, Content: "192.0.2.1"}
+	mail := models.Record{ID: "2", NodeName: "@", RecordType: "MX", TTL: 600, State: true, Content: "mail.example.com", Priority: 10}
+	old := map[string]models.Record{"1": www, "2": mail}
+
+	bookkeeping := www
+	bookkeeping.UpdatedOn = "2026-01-01T00:00:00Z"
+	bookkeeping.Raw = map[string]interface{}{"proxied": true}
+	apex := mail
+	apex.NodeName = ""
+	moved := www
+	moved.Content = "192.0.2.2"
+	paused := mail
+	paused.State = false
+	added := models.Record{ID: "3", NodeName: "api", RecordType: "CNAME", TTL: 600, State: true, Content: "www.example.com"}
+
+	tests := []struct {
+		name  string
+		fresh []models.Record
+		want  map[string]string
+	}{
+		{"unchanged", []models.Record{www, mail}, map[string]string{}},
+		{"bookkeeping ignored", []models.Record{bookkeeping, mail}, map[string]string{}},
+		{"apex spelling ignored", []models.Record{www, apex}, map[string]string{}},
+		{"content modified", []models.Record{moved, mail}, map[string]string{"1": models.RecordChangeModified}},
+		{"state modified", []models.Record{www, paused}, map[string]string{"2": models.RecordChangeModified}},
+		{"added", []models.Record{www, mail, added}, map[string]string{"3": models.RecordChangeAdded}},
+		{"removed", []models.Record{mail}, map[string]string{"1": models.RecordChangeRemoved}},
+		{"all at once", []models.Record{moved, added}, map[string]string{
+			"1": models.RecordChangeModified,
+			"2": models.RecordChangeRemoved,
+			"3": models.RecordChangeAdded,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := diffRecords(old, tt.fresh)
+			got := make(map[string]string, len(changes))
+			for _, ch := range changes {
+				got[ch.RecordID] = ch.ChangeType
+				switch ch.ChangeType {
+				case models.RecordChangeAdded:
+					if ch.Old != nil || ch.New == nil {
+						t.Errorf("added %s: old = %v, new = %v", ch.RecordID, ch.Old, ch.New)
+					}
+				case models.RecordChangeRemoved:
+					if ch.Old == nil || ch.New != nil {
+						t.Errorf("removed %s: old = %v, new = %v", ch.RecordID, ch.Old, ch.New)
+					}
+				case models.RecordChangeModified:
+					if ch.Old == nil || ch.New == nil {
+						t.Errorf("modified %s: old = %v, new = %v", ch.RecordID, ch.Old, ch.New)
+					}
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("changes = %v, want %v", got, tt.want)
+			}
+			for id, typ := range tt.want {
+				if got[id] != typ {
+					t.Errorf("record %s: change = %q, want %q", id, got[id], typ)
+				}
+			}
+		})
+	}
+}
+
+func TestRecordCacheSync(t *testing.T) {
+	openTestDB(t)
+	if _, err := database.DB.Exec(`INSERT INTO users (id, username, password_hash) VALUES (1, 'admin', 'x')`); err != nil {
+		t.Fatal(err)
+	}
+	s := NewRecordCacheService()
+
+	www := models.Record{ID: "1", NodeName: "www", RecordType: "A", TTL: 600, State: true, Content: "192.0.2.1"}
+	txt := models.Record{ID: "2", NodeName: "@", RecordType: "TXT", TTL: 600, State: true, Content: "v=spf1 -all"}
+
+	changes, err := s.Sync(1, 3, "z1", []models.Record{www, txt})
+	if err != nil {
+		t.Fatalf("baseline Sync: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("baseline reported %d change(s), want none", len(changes))
+	}
+
+	moved := www
+	moved.Content = "192.0.2.2"
+	added := models.Record{ID: "3", NodeName: "api", RecordType: "A", TTL: 600, State: true, Content: "192.0.2.3"}
+	changes, err = s.Sync(1, 3, "z1", []models.Record{moved, added})
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if len(changes) != 3 {
+		t.Fatalf("Sync reported %d change(s), want 3", len(changes))
+	}
+
+	cached, _, ok, err := s.Get(3, "z1")
+	if err != nil || !ok {
+		t.Fatalf("Get = %v, %v", ok, err)
+	}
+	if len(cached) != 2 || cached[0].ID != "3" || cached[1].Content != "192.0.2.2" {
+		t.Errorf("cached records = %+v, want the fresh listing", cached)
+	}
+
+	list, err := s.ListChanges(1, 3, "z1", 1, 20)
+	if err != nil {
+		t.Fatalf("ListChanges: %v", err)
+	}
+	if list.Total != 3 {
+		t.Errorf("stored %d change(s), want 3", list.Total)
+	}
+
+	// After Invalidate the next sync is a baseline again
+	if err := s.Invalidate(3, "z1"); err != nil {
+		t.Fatal(err)
+	}
+	changes, err = s.Sync(1, 3, "z1", []models.Record{www})
+	if err != nil || len(changes) != 0 {
+		t.Errorf("Sync after Invalidate = %d change(s), %v; want a baseline", len(changes), err)
+	}
+}
+
+func TestDNSServiceRecordsExternalEditBeforeWrite(t *testing.T) {
+	dns, mp, account, zone := newMockDNSService(t)
+	ctx := context.Background()
+
+	// Baseline, then an edit made at the provider outside dns-mng
+	if _, err := dns.ListRecords(ctx, 1, account.ID, zone); err != nil {
+		t.Fatalf("ListRecords: %v", err)
+	}
+	if _, err := mp.CreateRecord(ctx, "sandbox", zone, &models.Record{NodeName: "external", RecordType: "A", TTL: 600, State: true, Content: "192.0.2.9"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.DB.Exec(`UPDATE record_cache_zones SET synced_at = ?`, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := dns.CreateRecord(ctx, 1, account.ID, zone, &models.CreateRecordRequest{NodeName: "www", RecordType: "A", Content: "192.0.2.1"}); err != nil {
+		t.Fatalf("CreateRecord: %v", err)
+	}
+
+	list, err := dns.ListRecordChanges(1, account.ID, zone, 1, 20)
+	if err != nil {
+		t.Fatalf("ListRecordChanges: %v", err)
+	}
+	if list.Total != 1 || list.Changes[0].ChangeType != models.RecordChangeAdded || list.Changes[0].New.NodeName != "external" {
+		t.Fatalf("changes = %+v, want the external record only", list.Changes)
+	}
+
+	// Our own write is not reported on the next sync
+	if _, ok, _ := dns.recordCacheService.SyncedAt(account.ID, zone); ok {
+		t.Error("zone still cached after our write")
+	}
+	if _, err := dns.ListRecords(ctx, 1, account.ID, zone); err != nil {
+		t.Fatalf("ListRecords: %v", err)
+	}
+	if list, _ := dns.ListRecordChanges(1, account.ID, zone, 1, 20); list.Total != 1 {
+		t.Errorf("stored %d change(s) after our write, want 1", list.Total)
+	}
+}
@@ -0,0 +1,605 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"dns-mng/database"
+	"dns-mng/models"
+)
+
+var (
+	ErrUnknownJob  = errors.New("unknown scheduler job")
+	ErrJobRunning  = errors.New("job is already running")
+	ErrJobDisabled = errors.New("job is disabled")
+)
+
+// schedulerJob is one entry of the job registry
+type schedulerJob struct {
+	name            string
+	description     string
+	defaultSchedule string
+	defaultEnabled  bool
+	// selfLogging jobs write their own scheduler_logs entry and failure
+	// notification; for the others runJob does it with the returned message
+	selfLogging bool
+	run         func(ctx context.Context, trigger string) (string, error)
+
+	running  atomic.Bool
+	schedule *CronSchedule // nil while disabled
+}
+
+// jobState is a scheduler_jobs row
+type jobState struct {
+	schedule   string
+	enabled    sql.NullInt64
+	lastRunAt  sql.NullTime
+	lastStatus string
+	lastError  string
+}
+
+// registerJobs builds the job registry. Jobs whose dependency is missing
+// are left out.
+func (s *SchedulerService) registerJobs() {
+	add := func(job *schedulerJob) {
+		s.jobs = append(s.jobs, job)
+	}
+
+	add(&schedulerJob{
+		name:            models.JobDomainExpiry,
+		description:     "Send domain expiry notifications",
+		defaultSchedule: "0 9 * * *",
+		defaultEnabled:  true,
+		selfLogging:     true,
+		run: func(ctx context.Context, trigger string) (string, error) {
+			return "", s.checkExpiringDomains(trigger)
+		},
+	})
+	if s.dnsheAutoRenewService != nil {
+		add(&schedulerJob{
+			name:            models.JobDNSHEAutoRenew,
+			description:     "Renew DNSHE subdomains for users with auto-renew enabled",
+			defaultSchedule: "0 9 * * *",
+			defaultEnabled:  true,
+			selfLogging:     true,
+			run: func(ctx context.Context, trigger string) (string, error) {
+				return "", s.dnsheAutoRenewService.RunAll(ctx, trigger, s.schedulerLogService, s.notifierService)
+			},
+		})
+	}
+	if s.ddnsService != nil {
+		add(&schedulerJob{
+			name:            models.JobDDNSReconcile,
+			description:     "Check cached DDNS values against the providers",
+			defaultSchedule: "0 * * * *",
+			defaultEnabled:  true,
+			selfLogging:     true,
+			run: func(ctx context.Context, trigger string) (string, error) {
+				return "", s.reconcileDDNSState(trigger)
+			},
+		})
+	}
+	if s.domainRefreshService != nil {
+		add(&schedulerJob{
+			name:            models.JobDomainRefresh,
+			description:     "Refresh every user's domain list and queue removed domains for review",
+			defaultSchedule: "0 */6 * * *",
+			defaultEnabled:  true,
+			run:             s.refreshDomains,
+		})
+	}
+	if s.logRetentionService != nil {
+		add(&schedulerJob{
+			name:            models.JobLogCleanup,
+			description:     "Apply the retention policies of API, login and scheduler logs",
+			defaultSchedule: "30 3 * * *",
+			defaultEnabled:  true,
+			run: func(ctx context.Context, trigger string) (string, error) {
+				results, err := s.logRetentionService.Enforce()
+				if err != nil {
+					return "", err
+				}
+				return s.logRetentionService.Summary(results), nil
+			},
+		})
+	}
+	if s.accountService != nil {
+		add(&schedulerJob{
+			name:            models.JobAccountCheck,
+			description:     "Test every account's credentials and report rejected ones",
+			defaultSchedule: "15 */6 * * *",
+			defaultEnabled:  true,
+			run:             s.checkAccounts,
+		})
+	}
+	if s.backupService != nil {
+		add(&schedulerJob{
+			name:            models.JobBackup,
+			description:     "Write a backup of every user to the backup directory",
+			defaultSchedule: "0 4 * * *",
+			run:             s.backupAll,
+		})
+	}
+}
+
+func (s *SchedulerService) findJob(name string) *schedulerJob {
+	for _, job := range s.jobs {
+		if job.name == name {
+			return job
+		}
+	}
+	return nil
+}
+
+func loadJobStates() (map[string]jobState, error) {
+	rows, err := database.DB.Query(
+		`SELECT name, schedule, enabled, last_run_at, last_status, last_error FROM scheduler_jobs`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	states := make(map[string]jobState)
+	for rows.Next() {
+		var name string
+		var st jobState
+		if err := rows.Scan(&name, &st.schedule, &st.enabled, &st.lastRunAt, &st.lastStatus, &st.lastError); err != nil {
+			return nil, err
+		}
+		states[name] = st
+	}
+	return states, rows.Err()
+}
+
+// effective resolves a job's schedule and enabled flag from its defaults
+// and the stored overrides
+func (job *schedulerJob) effective(st jobState) (string, bool) {
+	schedule, enabled := job.defaultSchedule, job.defaultEnabled
+	if st.schedule != "" {
+		schedule = st.schedule
+	}
+	if st.enabled.Valid {
+		enabled = st.enabled.Int64 != 0
+	}
+	return schedule, enabled
+}
+
+// loadSchedules applies the stored overrides to the registry. An invalid
+// stored expression disables the job rather than blocking startup.
+func (s *SchedulerService) loadSchedules() {
+	states, err := loadJobStates()
+	if err != nil {
+		log.Printf("Failed to load scheduler jobs, using defaults: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range s.jobs {
+		expr, enabled := job.effective(states[job.name])
+		job.schedule = nil
+		if !enabled {
+			continue
+		}
+		sched, err := ParseCron(expr)
+		if err != nil {
+			log.Printf("Scheduler job %s disabled: %v", job.name, err)
+			continue
+		}
+		job.schedule = sched
+	}
+}
+
+// scheduleJobs wakes at the start of every minute and starts the jobs whose
+// cron expression matches, evaluated in the scheduler time zone
+func (s *SchedulerService) scheduleJobs() {
+	for _, job := range s.jobs {
+		if job.schedule != nil {
+			log.Printf("Scheduler job %s next run at %s", job.name,
+				job.schedule.Next(time.Now().In(s.location)).Format("2006-01-02 15:04 MST"))
+		}
+	}
+
+	go func() {
+		for {
+			now := time.Now()
+			timer := time.NewTimer(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+			select {
+			case t := <-timer.C:
+				s.runDueJobs(t.In(s.location))
+			case <-s.done:
+				timer.Stop()
+				return
+			}
+		}
+	}()
+}
+
+func (s *SchedulerService) runDueJobs(now time.Time) {
+	// the timer may fire a little early; round to the nearest minute
+	now = now.Add(30 * time.Second).Truncate(time.Minute)
+
+	s.mu.Lock()
+	due := make([]*schedulerJob, 0)
+	for _, job := range s.jobs {
+		if job.schedule != nil && job.schedule.Matches(now) {
+			due = append(due, job)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, job := range due {
+		if !s.startJob(job, "scheduled") {
+			s.recordSkipped(job)
+		}
+	}
+}
+
+// startJob runs job in the background unless a run is already in progress
+func (s *SchedulerService) startJob(job *schedulerJob, trigger string) bool {
+	if !job.running.CompareAndSwap(false, true) {
+		return false
+	}
+	go func() {
+		defer job.running.Store(false)
+		s.runJob(job, trigger)
+	}()
+	return true
+}
+
+func (s *SchedulerService) runJob(job *schedulerJob, trigger string) {
+	ctx := context.Background()
+
+	var logID int64
+	if !job.selfLogging {
+		var err error
+		logID, err = s.schedulerLogService.StartTask(job.name, map[string]interface{}{"trigger": trigger})
+		if err != nil {
+			log.Printf("Failed to create scheduler log: %v", err)
+		}
+	}
+
+	message, err := job.run(ctx, trigger)
+
+	status, lastError := "success", ""
+	if err != nil {
+		status, lastError = "error", err.Error()
+		log.Printf("Scheduler job %s failed: %v", job.name, err)
+	} else if message != "" {
+		log.Printf("Scheduler job %s: %s", job.name, message)
+	}
+
+	if !job.selfLogging {
+		if logID > 0 {
+			if err != nil {
+				message = err.Error()
+			}
+			s.schedulerLogService.UpdateTask(logID, status, message)
+		}
+		if err != nil {
+			s.notifierService.NotifySchedulerFailure(ctx, 0, job.name, err.Error())
+		}
+	}
+
+	_, dbErr := database.DB.Exec(
+		`INSERT INTO scheduler_jobs (name, last_run_at, last_status, last_error, updated_at)
+		 VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+		 ON CONFLICT(name) DO UPDATE SET last_run_at = excluded.last_run_at,
+		   last_status = excluded.last_status, last_error = excluded.last_error`,
+		job.name, time.Now(), status, lastError,
+	)
+	if dbErr != nil {
+		log.Printf("Failed to record run of scheduler job %s: %v", job.name, dbErr)
+	}
+}
+
+// recordSkipped logs a scheduled run dropped because the previous one is
+// still going
+func (s *SchedulerService) recordSkipped(job *schedulerJob) {
+	log.Printf("Scheduler job %s skipped: previous run still in progress", job.name)
+	logID, err := s.schedulerLogService.StartTask(job.name, map[string]interface{}{"trigger": "scheduled"})
+	if err == nil && logID > 0 {
+		s.schedulerLogService.UpdateTask(logID, "skipped", "Previous run still in progress")
+	}
+}
+
+// TriggerJob starts the named job immediately unless it is disabled
+func (s *SchedulerService) TriggerJob(name string) error {
+	job := s.findJob(name)
+	if job == nil {
+		return fmt.Errorf("%w: %s", ErrUnknownJob, name)
+	}
+	states, err := loadJobStates()
+	if err != nil {
+		return err
+	}
+	if _, enabled := job.effective(states[name]); !enabled {
+		return fmt.Errorf("%w: %s", ErrJobDisabled, name)
+	}
+	if !s.startJob(job, "manual") {
+		return ErrJobRunning
+	}
+	return nil
+}
+
+// ListJobs returns every registered job with its schedule and last run
+func (s *SchedulerService) ListJobs() ([]models.SchedulerJob, error) {
+	states, err := loadJobStates()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().In(s.location)
+	jobs := make([]models.SchedulerJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		st := states[job.name]
+		expr, enabled := job.effective(st)
+		item := models.SchedulerJob{
+			Name:        job.name,
+			Description: job.description,
+			Schedule:    expr,
+			Enabled:     enabled,
+			Running:     job.running.Load(),
+			Timezone:    s.location.String(),
+			LastStatus:  st.lastStatus,
+			LastError:   st.lastError,
+		}
+		if st.lastRunAt.Valid {
+			t := st.lastRunAt.Time
+			item.LastRunAt = &t
+		}
+		if enabled {
+			if sched, err := ParseCron(expr); err == nil {
+				if next := sched.Next(now); !next.IsZero() {
+					item.NextRunAt = &next
+				}
+			}
+		}
+		jobs = append(jobs, item)
+	}
+	return jobs, nil
+}
+
+// UpdateJob changes a job's cron expression and/or enabled flag. It returns
+// nil when the job does not exist.
+func (s *SchedulerService) UpdateJob(name string, req *models.UpdateSchedulerJobRequest) (*models.SchedulerJob, error) {
+	job := s.findJob(name)
+	if job == nil {
+		return nil, nil
+	}
+
+	states, err := loadJobStates()
+	if err != nil {
+		return nil, err
+	}
+	st := states[name]
+	if req.Schedule != nil {
+		expr := strings.TrimSpace(*req.Schedule)
+		if _, err := ParseCron(expr); err != nil {
+			return nil, err
+		}
+		st.schedule = expr
+	}
+	if req.Enabled != nil {
+		st.enabled = sql.NullInt64{Int64: int64(boolToInt(*req.Enabled)), Valid: true}
+	}
+
+	_, err = database.DB.Exec(
+		`INSERT INTO scheduler_jobs (name, schedule, enabled, updated_at)
+		 VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		 ON CONFLICT(name) DO UPDATE SET schedule = excluded.schedule,
+		   enabled = excluded.enabled, updated_at = CURRENT_TIMESTAMP`,
+		name, st.schedule, st.enabled,
+	)
+	if err != nil {
+		return nil, err
+	}
+	s.loadSchedules()
+
+	jobs, err := s.ListJobs()
+	if err != nil {
+		return nil, err
+	}
+	for i := range jobs {
+		if jobs[i].Name == name {
+			return &jobs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// schedulerUserIDs lists the users a system-wide job iterates over
+func schedulerUserIDs(query string) ([]int64, error) {
+	rows, err := database.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// refreshDomains re-lists the domains of every user that has accounts,
+// applying restores and new domains and queueing removals for review
+func (s *SchedulerService) refreshDomains(ctx context.Context, trigger string) (string, error) {
+	userIDs, err := schedulerUserIDs(`SELECT DISTINCT user_id FROM accounts`)
+	if err != nil {
+		return "", err
+	}
+
+	added, restored, pending, failedAccounts, failedUsers := 0, 0, 0, 0, 0
+	var lastErr error
+	for _, userID := range userIDs {
+		summary, err := s.domainRefreshService.Refresh(ctx, userID)
+		if err != nil {
+			log.Printf("Domain refresh failed for user %d: %v", userID, err)
+			failedUsers++
+			lastErr = err
+			continue
+		}
+		added += len(summary.Added)
+		restored += len(summary.Restored)
+		pending += len(summary.PendingDeletion)
+		failedAccounts += len(summary.FailedAccounts)
+		for name, msg := range summary.FailedAccounts {
+			log.Printf("Domain refresh: account %q of user %d failed: %s", name, userID, msg)
+		}
+		s.notifierService.NotifyDomainChanges(ctx, summary)
+		s.notifyCredentialFailures(ctx, userID)
+	}
+
+	if failedUsers > 0 && failedUsers == len(userIDs) {
+		return "", fmt.Errorf("domain refresh failed for all %d user(s): %v", failedUsers, lastErr)
+	}
+	message := fmt.Sprintf("Refreshed %d user(s): %d new, %d restored, %d queued for deletion review",
+		len(userIDs)-failedUsers, added, restored, pending)
+	if failedAccounts > 0 {
+		message += fmt.Sprintf("; %d account(s) failed", failedAccounts)
+	}
+	if failedUsers > 0 {
+		message += fmt.Sprintf("; %d user(s) failed", failedUsers)
+	}
+	return message, nil
+}
+
+// checkAccounts tests the credentials of every account. Failing accounts
+// are marked, and users are told once about credentials that are rejected.
+func (s *SchedulerService) checkAccounts(ctx context.Context, trigger string) (string, error) {
+	userIDs, err := schedulerUserIDs(`SELECT DISTINCT user_id FROM accounts`)
+	if err != nil {
+		return "", err
+	}
+
+	checked, failing, rejected := 0, 0, 0
+	for _, userID := range userIDs {
+		accounts, err := s.accountService.List(userID)
+		if err != nil {
+			log.Printf("Account check: failed to list accounts of user %d: %v", userID, err)
+			continue
+		}
+		for i := range accounts {
+			check, err := s.accountService.Check(ctx, &accounts[i])
+			if err != nil {
+				log.Printf("Account check: account %d of user %d: %v", accounts[i].ID, userID, err)
+				continue
+			}
+			checked++
+			if !check.OK {
+				failing++
+				if check.CredentialsRejected() {
+					rejected++
+				}
+			}
+		}
+		s.notifyCredentialFailures(ctx, userID)
+	}
+
+	message := fmt.Sprintf("Checked %d account(s)", checked)
+	if failing > 0 {
+		message += fmt.Sprintf(": %d failing, %d with rejected credentials", failing, rejected)
+	}
+	return message, nil
+}
+
+// notifyCredentialFailures tells the user about accounts whose credentials
+// were rejected since the last notification
+func (s *SchedulerService) notifyCredentialFailures(ctx context.Context, userID int64) {
+	accounts, err := s.accountService.UnnotifiedCredentialFailures(userID)
+	if err != nil {
+		log.Printf("Failed to list failing accounts of user %d: %v", userID, err)
+		return
+	}
+	if len(accounts) == 0 {
+		return
+	}
+	s.notifierService.NotifyAccountFailing(ctx, userID, accounts)
+	for _, acc := range accounts {
+		if err := s.accountService.MarkFailureNotified(acc.ID); err != nil {
+			log.Printf("Failed to mark account %d as notified: %v", acc.ID, err)
+		}
+	}
+}
+
+// backupAll writes one backup file per user to BackupDir/user-<id> and
+// keeps the newest BackupKeep files there. Backups hold decrypted provider
+// credentials, so it refuses to run without BACKUP_PASSWORD.
+func (s *SchedulerService) backupAll(ctx context.Context, trigger string) (string, error) {
+	if s.cfg == nil || s.cfg.BackupDir == "" {
+		return "", errors.New("BACKUP_DIR is not configured")
+	}
+	if s.cfg.BackupPassword == "" {
+		return "", errors.New("BACKUP_PASSWORD is not set; refusing to write unencrypted backups")
+	}
+	keep, err := strconv.Atoi(s.cfg.BackupKeep)
+	if err != nil || keep < 1 {
+		keep = 7
+	}
+
+	userIDs, err := schedulerUserIDs(`SELECT id FROM users`)
+	if err != nil {
+		return "", err
+	}
+
+	written := 0
+	var failures []string
+	for _, userID := range userIDs {
+		if err := s.backupUser(userID, keep); err != nil {
+			log.Printf("Backup failed for user %d: %v", userID, err)
+			failures = append(failures, fmt.Sprintf("user %d: %v", userID, err))
+			continue
+		}
+		written++
+	}
+
+	if len(failures) > 0 {
+		return "", fmt.Errorf("%d of %d backup(s) failed: %s", len(failures), len(userIDs), strings.Join(failures, "; "))
+	}
+	return fmt.Sprintf("Wrote %d backup(s) to %s", written, s.cfg.BackupDir), nil
+}
+
+func (s *SchedulerService) backupUser(userID int64, keep int) error {
+	data, err := s.backupService.Export(userID, s.cfg.BackupPassword)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(s.cfg.BackupDir, fmt.Sprintf("user-%d", userID))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("dns-mng-backup-%s.json", time.Now().Format("20060102-150405"))
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+		return err
+	}
+
+	// the timestamped names sort chronologically
+	files, err := filepath.Glob(filepath.Join(dir, "dns-mng-backup-*.json"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+	for len(files) > keep {
+		if err := os.Remove(files[0]); err != nil {
+			return err
+		}
+		files = files[1:]
+	}
+	return nil
+}
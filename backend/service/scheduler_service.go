@@ -2,96 +2,172 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"sync"
 	"time"
+
+	"dns-mng/config"
+	"dns-mng/models"
 )
 
+// ddnsAgentTick is how often DDNS agents are checked for being due; each
+// agent runs on its own interval_minutes.
+const ddnsAgentTick = time.Minute
+
+// webhookRetryTick is how often failed webhook deliveries are checked for
+// a due retry.
+const webhookRetryTick = time.Minute
+
 type SchedulerService struct {
 	notificationService   *NotificationService
-	emailService          *EmailService
+	notifierService       *NotifierService
 	schedulerLogService   *SchedulerLogService
 	dnsheAutoRenewService *DNSHEAutoRenewService
-	ticker                *time.Ticker
-	done                  chan bool
+	domainRefreshService  *DomainRefreshService
+	ddnsService           *DDNSService
+	ddnsAgentService      *DDNSAgentService
+	webhookService        *WebhookService
+	backupService         *BackupService
+	logRetentionService   *LogRetentionService
+	accountService        *AccountService
+	cfg                   *config.Config
+
+	// jobs is the cron job registry; mu guards the parsed schedules
+	jobs     []*schedulerJob
+	mu       sync.Mutex
+	location *time.Location
+	done     chan bool
 }
 
-func NewSchedulerService(notificationService *NotificationService, emailService *EmailService, schedulerLogService *SchedulerLogService, dnsheAutoRenewService *DNSHEAutoRenewService) *SchedulerService {
-	return &SchedulerService{
+func NewSchedulerService(cfg *config.Config, notificationService *NotificationService, notifierService *NotifierService, schedulerLogService *SchedulerLogService, dnsheAutoRenewService *DNSHEAutoRenewService, domainRefreshService *DomainRefreshService, ddnsService *DDNSService, ddnsAgentService *DDNSAgentService, webhookService *WebhookService, backupService *BackupService, logRetentionService *LogRetentionService, accountService *AccountService) *SchedulerService {
+	location := time.Local
+	if cfg != nil && cfg.SchedulerTimezone != "" {
+		loc, err := time.LoadLocation(cfg.SchedulerTimezone)
+		if err != nil {
+			log.Printf("Invalid SCHEDULER_TIMEZONE %q, using server local time: %v", cfg.SchedulerTimezone, err)
+		} else {
+			location = loc
+		}
+	}
+
+	s := &SchedulerService{
 		notificationService:   notificationService,
-		emailService:          emailService,
+		notifierService:       notifierService,
 		schedulerLogService:   schedulerLogService,
 		dnsheAutoRenewService: dnsheAutoRenewService,
+		domainRefreshService:  domainRefreshService,
+		ddnsService:           ddnsService,
+		ddnsAgentService:      ddnsAgentService,
+		webhookService:        webhookService,
+		backupService:         backupService,
+		logRetentionService:   logRetentionService,
+		accountService:        accountService,
+		cfg:                   cfg,
+		location:              location,
 		done:                  make(chan bool),
 	}
+	s.registerJobs()
+	return s
 }
 
 // Start starts the scheduler
 func (s *SchedulerService) Start() {
-	log.Println("Starting domain expiry notification scheduler...")
+	log.Printf("Starting scheduler (time zone %s)...", s.location)
+
+	// Cron jobs: expiry notification, DNSHE renewal, DDNS reconcile, ...
+	s.loadSchedules()
+	s.scheduleJobs()
+
+	// Run server-side DDNS agents
+	s.scheduleDDNSAgents()
 
-	// Schedule to run daily at 9:00 AM
-	s.scheduleDaily()
+	// Retry failed webhook deliveries
+	s.scheduleWebhookRetries()
 }
 
 // Stop stops the scheduler
 func (s *SchedulerService) Stop() {
-	if s.ticker != nil {
-		s.ticker.Stop()
-	}
-	s.done <- true
+	// close rather than send: several loops wait on done
+	close(s.done)
 	log.Println("Scheduler stopped")
 }
 
-// scheduleDaily schedules the task to run daily at 9:00 AM
-func (s *SchedulerService) scheduleDaily() {
-	now := time.Now()
-	nextRun := time.Date(now.Year(), now.Month(), now.Day(), 9, 0, 0, 0, now.Location())
-
-	// If it's past 9 AM today, schedule for tomorrow
-	if now.After(nextRun) {
-		nextRun = nextRun.Add(24 * time.Hour)
+// scheduleDDNSAgents checks every minute for DDNS agents that are due
+func (s *SchedulerService) scheduleDDNSAgents() {
+	if s.ddnsAgentService == nil {
+		return
 	}
-
-	// Calculate duration until next run
-	duration := nextRun.Sub(now)
-	log.Printf("Next notification check scheduled at: %s (in %v)", nextRun.Format("2006-01-02 15:04:05"), duration)
-
-	// Wait until the scheduled time
-	time.AfterFunc(duration, func() {
-		s.checkExpiringDomains()
-		s.runDNSHEAutoRenew()
-		// Schedule next run (every 24 hours)
-		s.ticker = time.NewTicker(24 * time.Hour)
-		go func() {
-			for {
-				select {
-				case <-s.ticker.C:
-					s.checkExpiringDomains()
-					s.runDNSHEAutoRenew()
-				case <-s.done:
-					return
-				}
+	ticker := time.NewTicker(ddnsAgentTick)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.ddnsAgentService.RunDue(context.Background(), s.schedulerLogService, s.notifierService)
+			case <-s.done:
+				return
 			}
-		}()
-	})
+		}
+	}()
 }
 
-// runDNSHEAutoRenew runs the DNSHE auto-renew job for all enabled users.
-func (s *SchedulerService) runDNSHEAutoRenew() {
-	if s.dnsheAutoRenewService == nil {
+// scheduleWebhookRetries re-sends webhook deliveries whose backoff elapsed
+func (s *SchedulerService) scheduleWebhookRetries() {
+	if s.webhookService == nil {
 		return
 	}
-	s.dnsheAutoRenewService.RunAll(context.Background(), s.schedulerLogService)
+	ticker := time.NewTicker(webhookRetryTick)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.webhookService.RetryDue(context.Background())
+			case <-s.done:
+				return
+			}
+		}
+	}()
+}
+
+// reconcileDDNSState compares the DDNS fast-path cache with the providers
+func (s *SchedulerService) reconcileDDNSState(trigger string) error {
+	taskName := models.JobDDNSReconcile
+
+	logID, err := s.schedulerLogService.StartTask(taskName, map[string]interface{}{
+		"trigger": trigger,
+	})
+	if err != nil {
+		log.Printf("Failed to create scheduler log: %v", err)
+	}
+
+	checked, drifted, err := s.ddnsService.ReconcileState(context.Background())
+	if err != nil {
+		log.Printf("DDNS reconcile failed: %v", err)
+		if logID > 0 {
+			s.schedulerLogService.UpdateTask(logID, "error", err.Error())
+		}
+		s.notifierService.NotifySchedulerFailure(context.Background(), 0, taskName, err.Error())
+		return err
+	}
+
+	message := fmt.Sprintf("Checked %d cached DDNS record(s), corrected %d", checked, drifted)
+	log.Println(message)
+	if logID > 0 {
+		s.schedulerLogService.UpdateTask(logID, "success", message)
+	}
+	return nil
 }
 
 // checkExpiringDomains checks for expiring domains and sends notifications
-func (s *SchedulerService) checkExpiringDomains() {
+func (s *SchedulerService) checkExpiringDomains(trigger string) error {
 	log.Println("Checking for expiring domains...")
 
-	taskName := "domain_expiry_notification"
+	taskName := models.JobDomainExpiry
 
 	details := map[string]interface{}{
-		"trigger": "scheduled",
+		"trigger": trigger,
 	}
 
 	logID, err := s.schedulerLogService.StartTask(taskName, details)
@@ -105,7 +181,8 @@ func (s *SchedulerService) checkExpiringDomains() {
 		if logID > 0 {
 			s.schedulerLogService.UpdateTask(logID, "error", err.Error())
 		}
-		return
+		s.notifierService.NotifySchedulerFailure(context.Background(), 0, taskName, err.Error())
+		return err
 	}
 
 	if len(domains) == 0 {
@@ -113,7 +190,7 @@ func (s *SchedulerService) checkExpiringDomains() {
 		if logID > 0 {
 			s.schedulerLogService.UpdateTask(logID, "success", "No domains need notification")
 		}
-		return
+		return nil
 	}
 
 	log.Printf("Found %d domain(s) that need notification", len(domains))
@@ -125,9 +202,12 @@ func (s *SchedulerService) checkExpiringDomains() {
 	errorDetails := make([]map[string]string, 0)
 
 	for _, domain := range domains {
-		// Send email notification
-		err := s.emailService.SendExpiryNotification(domain.UserID, domain)
-		if err != nil {
+		// Send through email and the user's notification channels; a domain
+		// counts as notified once any of them succeeded
+		sent, err := s.notifierService.NotifyExpiry(context.Background(), domain)
+		if err != nil && sent > 0 {
+			log.Printf("Some notifications for domain %s failed: %v", domain.DomainName, err)
+		} else if err != nil {
 			log.Printf("Failed to send notification for domain %s: %v", domain.DomainName, err)
 			errorCount++
 			errorDetails = append(errorDetails, map[string]string{
@@ -174,9 +254,9 @@ func (s *SchedulerService) checkExpiringDomains() {
 
 		s.schedulerLogService.UpdateTask(logID, status, message)
 	}
-}
 
-// TriggerManualCheck manually triggers domain expiry check (for testing)
-func (s *SchedulerService) TriggerManualCheck() {
-	go s.checkExpiringDomains()
+	if successCount == 0 {
+		return fmt.Errorf("all %d notification(s) failed", errorCount)
+	}
+	return nil
 }
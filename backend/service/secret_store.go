@@ -0,0 +1,517 @@
+package service
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"dns-mng/database"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 凭据静态加密（信封加密）：
+//   - MASTER_KEY 经 PBKDF2 派生出 KEK，仅用于包裹 encryption_keys 中的数据密钥
+//   - 数据密钥（随机 AES-256）加密各凭据列，密文格式 enc:v1:<key id>:<base64(nonce|ciphertext)>
+//   - 不带前缀的值视为明文，未配置 MASTER_KEY 时保持明文存储
+const secretPrefix = "enc:v1:"
+
+var (
+	ErrMasterKeyRequired = errors.New("MASTER_KEY is required: stored secrets are encrypted")
+	ErrMasterKeyMismatch = errors.New("MASTER_KEY does not match the stored data keys")
+	ErrServerRunning     = errors.New("a dns-mng server is using the database: stop it before rotating the master key (a crashed server is ignored after 90s)")
+)
+
+// A running server keeps its data keys in memory and cannot unwrap a key
+// made by RotateMasterKey without the new master key, so it refreshes a
+// heartbeat in app_settings and rotation refuses to run while it is fresh.
+const (
+	serverHeartbeatKey      = "server_heartbeat"
+	serverHeartbeatInterval = 30 * time.Second
+	serverHeartbeatTimeout  = 3 * serverHeartbeatInterval
+)
+
+// secretColumn is a credential column encrypted at rest
+type secretColumn struct {
+	table  string
+	column string
+}
+
+var secretColumns = []secretColumn{
+	{"accounts", "api_key"},
+	{"accounts", "credentials"},
+	{"email_config", "smtp_password"},
+	{"whois_config", "api_key"},
+	{"ddns_tokens", "token"},
+	{"webhooks", "secret"},
+	{"notification_channels", "config"},
+	{"users", "totp_secret"},
+}
+
+type secretStore struct {
+	mu       sync.RWMutex
+	keys     map[int64][]byte
+	activeID int64
+	hashKey  []byte // keys token_hash lookups, derived from the active data key
+}
+
+var secrets = &secretStore{keys: map[int64][]byte{}}
+
+// InitSecretStore loads the data keys and encrypts any credential still
+// stored in plaintext. Without a master key secrets stay in plaintext, which
+// is refused once encrypted values exist.
+func InitSecretStore(masterKey string) error {
+	keys, activeID, err := loadDataKeys(masterKey)
+	if err != nil {
+		return err
+	}
+
+	// The first data key and the values moved onto it commit together, so
+	// a failure part-way leaves the database as it was
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if masterKey == "" {
+		log.Println("Warning: MASTER_KEY is not set, credentials are stored in plaintext")
+	} else if activeID == 0 {
+		id, key, err := createDataKey(tx, masterKey, true)
+		if err != nil {
+			return fmt.Errorf("create data key: %w", err)
+		}
+		keys[id] = key
+		activeID = id
+	}
+
+	secrets.set(keys, activeID)
+	if err := reencryptSecrets(tx, activeID, keys[activeID]); err != nil {
+		secrets.set(map[int64][]byte{}, 0)
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		secrets.set(map[int64][]byte{}, 0)
+		return err
+	}
+	return nil
+}
+
+// StartServerHeartbeat marks the database as held by this server process
+// for as long as it runs.
+func StartServerHeartbeat() {
+	beat := func() {
+		if _, err := database.DB.Exec(
+			`INSERT INTO app_settings (key, value, updated_at) VALUES (?, ?, ?)
+			 ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+			serverHeartbeatKey, time.Now().UTC().Format(time.RFC3339), time.Now(),
+		); err != nil {
+			log.Printf("Failed to write server heartbeat: %v", err)
+		}
+	}
+	beat()
+	go func() {
+		for range time.Tick(serverHeartbeatInterval) {
+			beat()
+		}
+	}()
+}
+
+// serverRunning reports whether a server heartbeat is fresh
+func serverRunning(tx *sql.Tx) (bool, error) {
+	var value string
+	err := tx.QueryRow(`SELECT value FROM app_settings WHERE key = ?`, serverHeartbeatKey).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	beat, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return false, nil
+	}
+	return time.Since(beat) < serverHeartbeatTimeout, nil
+}
+
+// RotateMasterKey re-encrypts every stored credential under a fresh data
+// key wrapped by newMasterKey, then drops the old data keys. The store must
+// have been initialised with the current master key, and no server may be
+// running on the database (ErrServerRunning).
+func RotateMasterKey(newMasterKey string) error {
+	if newMasterKey == "" {
+		return errors.New("new master key must not be empty")
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	running, err := serverRunning(tx)
+	if err != nil {
+		return err
+	}
+	if running {
+		return ErrServerRunning
+	}
+
+	id, key, err := createDataKey(tx, newMasterKey, false)
+	if err != nil {
+		return fmt.Errorf("create data key: %w", err)
+	}
+	secrets.addKey(id, key)
+
+	if err := reencryptSecrets(tx, id, key); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM encryption_keys WHERE id != ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE encryption_keys SET active = 1 WHERE id = ?`, id); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	secrets.set(map[int64][]byte{id: key}, id)
+	return nil
+}
+
+// EncryptSecret encrypts a credential with the active data key. Empty
+// values and a store without master key pass through unchanged.
+func EncryptSecret(plain string) (string, error) {
+	secrets.mu.RLock()
+	id, key := secrets.activeID, secrets.keys[secrets.activeID]
+	secrets.mu.RUnlock()
+	if plain == "" || id == 0 {
+		return plain, nil
+	}
+	return sealSecret(id, key, plain)
+}
+
+// DecryptSecret reverses EncryptSecret; plaintext values are returned as is
+func DecryptSecret(value string) (string, error) {
+	if !strings.HasPrefix(value, secretPrefix) {
+		return value, nil
+	}
+	rest := strings.TrimPrefix(value, secretPrefix)
+	idPart, payload, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", errors.New("malformed encrypted value")
+	}
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil {
+		return "", errors.New("malformed encrypted value")
+	}
+
+	secrets.mu.RLock()
+	key := secrets.keys[id]
+	secrets.mu.RUnlock()
+	if key == nil {
+		return "", fmt.Errorf("data key %d is not available", id)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil || len(raw) < nonceLen {
+		return "", errors.New("malformed encrypted value")
+	}
+	plain, err := gcmOpen(key, raw[:nonceLen], raw[nonceLen:])
+	if err != nil {
+		return "", fmt.Errorf("decrypt secret: %w", err)
+	}
+	return string(plain), nil
+}
+
+// MaskSecret hides a credential in API responses
+func MaskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	return maskedSecret
+}
+
+// isMaskedOrEmpty reports whether an update leaves the stored secret as is
+func isMaskedOrEmpty(value string) bool {
+	value = strings.TrimSpace(value)
+	return value == "" || value == maskedSecret
+}
+
+// hashDDNSToken returns the lookup hash stored in ddns_tokens.token_hash
+func hashDDNSToken(token string) string {
+	secrets.mu.RLock()
+	hashKey := secrets.hashKey
+	secrets.mu.RUnlock()
+	return tokenHash(hashKey, token)
+}
+
+func tokenHash(hashKey []byte, token string) string {
+	mac := hmac.New(sha256.New, hashKey)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *secretStore) set(keys map[int64][]byte, activeID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+	s.activeID = activeID
+	s.hashKey = deriveHashKey(keys[activeID])
+}
+
+func (s *secretStore) addKey(id int64, key []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[id] = key
+}
+
+// deriveHashKey keys token lookups with the active data key, so a leaked
+// database alone does not allow guessing tokens offline
+func deriveHashKey(dataKey []byte) []byte {
+	if dataKey == nil {
+		return nil
+	}
+	mac := hmac.New(sha256.New, dataKey)
+	mac.Write([]byte("ddns-token-lookup"))
+	return mac.Sum(nil)
+}
+
+// dbExecer is implemented by *sql.DB and *sql.Tx
+type dbExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// loadDataKeys unwraps all stored data keys with masterKey
+func loadDataKeys(masterKey string) (map[int64][]byte, int64, error) {
+	rows, err := database.DB.Query(`SELECT id, salt, wrapped_key, active FROM encryption_keys ORDER BY id`)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	keys := map[int64][]byte{}
+	var activeID int64
+	for rows.Next() {
+		var id int64
+		var salt, wrapped string
+		var active int
+		if err := rows.Scan(&id, &salt, &wrapped, &active); err != nil {
+			return nil, 0, err
+		}
+		if masterKey == "" {
+			return nil, 0, ErrMasterKeyRequired
+		}
+		key, err := unwrapDataKey(masterKey, salt, wrapped)
+		if err != nil {
+			return nil, 0, ErrMasterKeyMismatch
+		}
+		keys[id] = key
+		if active == 1 {
+			activeID = id
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	if masterKey != "" && activeID == 0 && len(keys) > 0 {
+		return nil, 0, errors.New("no active data key")
+	}
+	return keys, activeID, nil
+}
+
+func createDataKey(db dbExecer, masterKey string, active bool) (int64, []byte, error) {
+	key := make([]byte, keyLen)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return 0, nil, err
+	}
+	salt := make([]byte, saltLen)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return 0, nil, err
+	}
+	kek, err := deriveKey(masterKey, salt, pbkdf2Iterations)
+	if err != nil {
+		return 0, nil, err
+	}
+	nonce := make([]byte, nonceLen)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return 0, nil, err
+	}
+	wrapped, err := gcmSeal(kek, nonce, key)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	result, err := db.Exec(
+		`INSERT INTO encryption_keys (salt, wrapped_key, active) VALUES (?, ?, ?)`,
+		hex.EncodeToString(salt), base64.StdEncoding.EncodeToString(append(nonce, wrapped...)), boolToInt(active),
+	)
+	if err != nil {
+		return 0, nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, nil, err
+	}
+	return id, key, nil
+}
+
+func unwrapDataKey(masterKey, saltHex, wrapped string) ([]byte, error) {
+	salt, err := hex.DecodeString(saltHex)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil || len(raw) < nonceLen {
+		return nil, errors.New("malformed wrapped key")
+	}
+	kek, err := deriveKey(masterKey, salt, pbkdf2Iterations)
+	if err != nil {
+		return nil, err
+	}
+	return gcmOpen(kek, raw[:nonceLen], raw[nonceLen:])
+}
+
+// reencryptSecrets moves every credential onto the given data key and
+// refreshes the DDNS token lookup hashes. With id 0 (no master key) only the
+// hashes are refreshed.
+func reencryptSecrets(db dbExecer, id int64, key []byte) error {
+	prefix := secretPrefix + strconv.FormatInt(id, 10) + ":"
+	for _, col := range secretColumns {
+		if id == 0 {
+			break
+		}
+		values, err := readSecretColumn(db, col)
+		if err != nil {
+			return fmt.Errorf("read %s.%s: %w", col.table, col.column, err)
+		}
+		changed := 0
+		for rowID, stored := range values {
+			if stored == "" || strings.HasPrefix(stored, prefix) {
+				continue
+			}
+			plain, err := DecryptSecret(stored)
+			if err != nil {
+				return fmt.Errorf("%s.%s row %d: %w", col.table, col.column, rowID, err)
+			}
+			value, err := sealSecret(id, key, plain)
+			if err != nil {
+				return err
+			}
+			if _, err := db.Exec(`UPDATE `+col.table+` SET `+col.column+` = ? WHERE id = ?`, value, rowID); err != nil {
+				return err
+			}
+			changed++
+		}
+		if changed > 0 {
+			log.Printf("Encrypted %d value(s) in %s.%s", changed, col.table, col.column)
+		}
+	}
+	return refreshTokenHashes(db, deriveHashKey(key))
+}
+
+func readSecretColumn(db dbExecer, col secretColumn) (map[int64]string, error) {
+	rows, err := db.Query(`SELECT id, COALESCE(` + col.column + `, '') FROM ` + col.table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := map[int64]string{}
+	for rows.Next() {
+		var id int64
+		var value string
+		if err := rows.Scan(&id, &value); err != nil {
+			return nil, err
+		}
+		values[id] = value
+	}
+	return values, rows.Err()
+}
+
+func refreshTokenHashes(db dbExecer, hashKey []byte) error {
+	rows, err := db.Query(`SELECT id, token, token_hash FROM ddns_tokens`)
+	if err != nil {
+		return err
+	}
+	type tokenRow struct {
+		id          int64
+		token, hash string
+	}
+	var tokens []tokenRow
+	for rows.Next() {
+		var t tokenRow
+		if err := rows.Scan(&t.id, &t.token, &t.hash); err != nil {
+			rows.Close()
+			return err
+		}
+		tokens = append(tokens, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, t := range tokens {
+		plain, err := DecryptSecret(t.token)
+		if err != nil {
+			return fmt.Errorf("ddns_tokens row %d: %w", t.id, err)
+		}
+		hash := tokenHash(hashKey, plain)
+		if hash == t.hash {
+			continue
+		}
+		if _, err := db.Exec(`UPDATE ddns_tokens SET token_hash = ? WHERE id = ?`, hash, t.id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sealSecret(id int64, key []byte, plain string) (string, error) {
+	nonce := make([]byte, nonceLen)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	ciphertext, err := gcmSeal(key, nonce, []byte(plain))
+	if err != nil {
+		return "", err
+	}
+	return secretPrefix + strconv.FormatInt(id, 10) + ":" + base64.StdEncoding.EncodeToString(append(nonce, ciphertext...)), nil
+}
+
+func gcmSeal(key, nonce, plain []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nil, nonce, plain, nil), nil
+}
+
+func gcmOpen(key, nonce, ciphertext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
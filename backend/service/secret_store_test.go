@@ -0,0 +1,215 @@
+package service
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"dns-mng/database"
+)
+
+// resetSecretStore drops all data keys, as on a fresh start
+func resetSecretStore(t *testing.T) {
+	t.Helper()
+	secrets.set(map[int64][]byte{}, 0)
+	t.Cleanup(func() { secrets.set(map[int64][]byte{}, 0) })
+}
+
+func TestSealOpenSecret(t *testing.T) {
+	resetSecretStore(t)
+	key := make([]byte, keyLen)
+	secrets.set(map[int64][]byte{7: key}, 7)
+
+	sealed, err := EncryptSecret("s3cret")
+	if err != nil {
+		t.Fatalf("EncryptSecret: %v", err)
+	}
+	if !strings.HasPrefix(sealed, secretPrefix+"7:") {
+		t.Fatalf("sealed value %q lacks the key 7 prefix", sealed)
+	}
+	again, _ := EncryptSecret("s3cret")
+	if again == sealed {
+		t.Error("sealing twice gave the same ciphertext")
+	}
+	if plain, err := DecryptSecret(sealed); err != nil || plain != "s3cret" {
+		t.Errorf("DecryptSecret = %q, %v; want s3cret", plain, err)
+	}
+
+	if empty, _ := EncryptSecret(""); empty != "" {
+		t.Errorf("EncryptSecret(\"\") = %q, want empty", empty)
+	}
+
+	// Corrupt the ciphertext near the end of the payload
+	payload := []byte(sealed)
+	payload[len(payload)-2] ^= 1
+	tests := map[string]string{
+		"tampered":    string(payload),
+		"unknown key": strings.Replace(sealed, secretPrefix+"7:", secretPrefix+"8:", 1),
+		"no key id":   secretPrefix + "abc",
+		"bad base64":  secretPrefix + "7:%%%",
+		"short":       secretPrefix + "7:AAAA",
+	}
+	for name, value := range tests {
+		if _, err := DecryptSecret(value); err == nil {
+			t.Errorf("%s: DecryptSecret succeeded", name)
+		}
+	}
+}
+
+func TestSecretPlaintextPassthrough(t *testing.T) {
+	resetSecretStore(t)
+
+	sealed, err := EncryptSecret("plain")
+	if err != nil || sealed != "plain" {
+		t.Errorf("EncryptSecret without key = %q, %v; want plain", sealed, err)
+	}
+	for _, value := range []string{"", "plain", "enc:v2:1:abc"} {
+		if got, err := DecryptSecret(value); err != nil || got != value {
+			t.Errorf("DecryptSecret(%q) = %q, %v; want unchanged", value, got, err)
+		}
+	}
+}
+
+// openTestDB initialises a fresh SQLite database for this test
+func openTestDB(t *testing.T) {
+	t.Helper()
+	database.Init(filepath.Join(t.TempDir(), "test.db"))
+	t.Cleanup(database.Close)
+}
+
+func storedValue(t *testing.T, query string) string {
+	t.Helper()
+	var v string
+	if err := database.DB.QueryRow(query).Scan(&v); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return v
+}
+
+func TestSecretStoreMigrationAndRotation(t *testing.T) {
+	resetSecretStore(t)
+	openTestDB(t)
+
+	mustExec := func(query string, args ...interface{}) {
+		t.Helper()
+		if _, err := database.DB.Exec(query, args...); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+	}
+	mustExec(`INSERT INTO users (id, username, password_hash, totp_secret) VALUES (1, 'admin', 'x', 'TOTPSECRET')`)
+	mustExec(`INSERT INTO accounts (user_id, name, provider_type, api_key) VALUES (1, 'cf', 'cloudflare', 'api-key-1')`)
+	mustExec(`INSERT INTO ddns_tokens (user_id, token) VALUES (1, 'ddns-token-1')`)
+
+	const (
+		apiKeyQuery = `SELECT api_key FROM accounts`
+		totpQuery   = `SELECT totp_secret FROM users`
+		tokenQuery  = `SELECT token FROM ddns_tokens`
+		hashQuery   = `SELECT token_hash FROM ddns_tokens`
+	)
+
+	// Without a master key values stay in plaintext
+	if err := InitSecretStore(""); err != nil {
+		t.Fatalf("InitSecretStore without key: %v", err)
+	}
+	if got := storedValue(t, apiKeyQuery); got != "api-key-1" {
+		t.Fatalf("api_key = %q, want plaintext", got)
+	}
+
+	// The first start with a key encrypts everything in place
+	if err := InitSecretStore("master-1"); err != nil {
+		t.Fatalf("InitSecretStore: %v", err)
+	}
+	sealed := map[string]string{}
+	for query, want := range map[string]string{apiKeyQuery: "api-key-1", totpQuery: "TOTPSECRET", tokenQuery: "ddns-token-1"} {
+		stored := storedValue(t, query)
+		if !strings.HasPrefix(stored, secretPrefix) {
+			t.Fatalf("%s = %q, want encrypted", query, stored)
+		}
+		if plain, err := DecryptSecret(stored); err != nil || plain != want {
+			t.Fatalf("%s decrypts to %q, %v; want %q", query, plain, err, want)
+		}
+		sealed[query] = stored
+	}
+	if got := storedValue(t, hashQuery); got != hashDDNSToken("ddns-token-1") {
+		t.Errorf("token_hash = %q, want the keyed hash", got)
+	}
+
+	// Once encrypted, a missing or wrong key is refused
+	if err := InitSecretStore(""); !errors.Is(err, ErrMasterKeyRequired) {
+		t.Errorf("InitSecretStore without key = %v, want ErrMasterKeyRequired", err)
+	}
+	if err := InitSecretStore("wrong"); !errors.Is(err, ErrMasterKeyMismatch) {
+		t.Errorf("InitSecretStore with wrong key = %v, want ErrMasterKeyMismatch", err)
+	}
+
+	// A restart with the same key leaves the ciphertext alone
+	if err := InitSecretStore("master-1"); err != nil {
+		t.Fatalf("InitSecretStore restart: %v", err)
+	}
+	if got := storedValue(t, apiKeyQuery); got != sealed[apiKeyQuery] {
+		t.Error("restart re-encrypted an up-to-date value")
+	}
+
+	// A running server keeps the old data keys in memory, so rotation waits
+	// until its heartbeat is stale
+	mustExec(`INSERT INTO app_settings (key, value) VALUES (?, ?)`, serverHeartbeatKey, time.Now().UTC().Format(time.RFC3339))
+	if err := RotateMasterKey("master-2"); !errors.Is(err, ErrServerRunning) {
+		t.Fatalf("RotateMasterKey with a live server = %v, want ErrServerRunning", err)
+	}
+	if got := storedValue(t, apiKeyQuery); got != sealed[apiKeyQuery] {
+		t.Error("refused rotation changed a stored value")
+	}
+	mustExec(`UPDATE app_settings SET value = ? WHERE key = ?`, time.Now().Add(-serverHeartbeatTimeout).UTC().Format(time.RFC3339), serverHeartbeatKey)
+
+	if err := RotateMasterKey("master-2"); err != nil {
+		t.Fatalf("RotateMasterKey: %v", err)
+	}
+	var keys int
+	database.DB.QueryRow(`SELECT COUNT(*) FROM encryption_keys`).Scan(&keys)
+	if keys != 1 {
+		t.Errorf("%d data keys after rotation, want 1", keys)
+	}
+	for query, old := range sealed {
+		stored := storedValue(t, query)
+		if stored == old || strings.SplitN(stored, ":", 4)[2] == strings.SplitN(old, ":", 4)[2] {
+			t.Errorf("%s still uses the old data key", query)
+		}
+	}
+
+	if err := InitSecretStore("master-1"); !errors.Is(err, ErrMasterKeyMismatch) {
+		t.Errorf("old key after rotation = %v, want ErrMasterKeyMismatch", err)
+	}
+	if err := InitSecretStore("master-2"); err != nil {
+		t.Fatalf("InitSecretStore with new key: %v", err)
+	}
+	if plain, err := DecryptSecret(storedValue(t, apiKeyQuery)); err != nil || plain != "api-key-1" {
+		t.Errorf("api_key after rotation = %q, %v; want api-key-1", plain, err)
+	}
+	if got := storedValue(t, hashQuery); got != hashDDNSToken("ddns-token-1") {
+		t.Errorf("token_hash not refreshed for the new key")
+	}
+}
+
+func TestInitSecretStoreRollsBack(t *testing.T) {
+	resetSecretStore(t)
+	openTestDB(t)
+	if _, err := database.DB.Exec(`INSERT INTO users (id, username, password_hash) VALUES (1, 'admin', 'x')`); err != nil {
+		t.Fatal(err)
+	}
+	// api_key is encrypted first, then credentials fails on an unknown key
+	if _, err := database.DB.Exec(`INSERT INTO accounts (user_id, name, provider_type, api_key, credentials) VALUES (1, 'cf', 'cloudflare', 'api-key-1', 'enc:v1:99:broken')`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := InitSecretStore("master-1"); err == nil {
+		t.Fatal("InitSecretStore succeeded with an undecryptable value")
+	}
+	if got := storedValue(t, `SELECT api_key FROM accounts`); got != "api-key-1" {
+		t.Errorf("api_key = %q after a failed start, want it untouched", got)
+	}
+	if got := storedValue(t, `SELECT COUNT(*) FROM encryption_keys`); got != "0" {
+		t.Errorf("%s data key(s) left after a failed start, want 0", got)
+	}
+}
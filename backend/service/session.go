@@ -0,0 +1,224 @@
+package service
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"dns-mng/database"
+	"dns-mng/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Access tokens are short-lived; the refresh token keeps a session alive
+// and each refresh pushes its expiry out again
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrSessionRevoked      = errors.New("session has expired or was revoked")
+)
+
+const jwtSecretKey = "jwt_secret"
+
+// legacyJWTSecret was the built-in JWT_SECRET default. It is public, so it
+// is treated as unset.
+const legacyJWTSecret = "dns-mng-secret-key-change-in-production"
+
+// EnsureJWTSecret fills in a signing secret when JWT_SECRET is not set. A
+// random secret is generated once and kept in app_settings so restarts do
+// not sign everyone out.
+func (s *UserService) EnsureJWTSecret() error {
+	if s.cfg.JWTSecret == legacyJWTSecret {
+		log.Println("Warning: JWT_SECRET is the published default and is ignored; using a generated secret")
+		s.cfg.JWTSecret = ""
+	}
+	if s.cfg.JWTSecret != "" {
+		return nil
+	}
+
+	if _, err := database.DB.Exec(
+		`INSERT INTO app_settings (key, value, updated_at) VALUES (?, ?, ?)
+		 ON CONFLICT(key) DO NOTHING`,
+		jwtSecretKey, generateRandomToken(), time.Now(),
+	); err != nil {
+		return err
+	}
+	return database.DB.QueryRow(
+		"SELECT value FROM app_settings WHERE key = ?", jwtSecretKey,
+	).Scan(&s.cfg.JWTSecret)
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// startSession opens a session for user and signs its first tokens
+func (s *UserService) startSession(user *models.User, client *models.SessionClient) (*models.AuthResponse, error) {
+	refresh := generateRandomToken()
+	now := time.Now()
+	result, err := database.DB.Exec(
+		`INSERT INTO user_sessions (user_id, refresh_hash, device, ip_address, user_agent, created_at, last_used_at, expires_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		user.ID, hashRefreshToken(refresh), client.Device, client.IPAddress, client.UserAgent,
+		now, now, now.Add(refreshTokenTTL),
+	)
+	if err != nil {
+		return nil, err
+	}
+	sessionID, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	// Expired sessions are only ever read back to be rejected
+	if _, err := database.DB.Exec("DELETE FROM user_sessions WHERE user_id = ? AND expires_at <= ?", user.ID, now); err != nil {
+		log.Printf("Failed to prune expired sessions of user %d: %v", user.ID, err)
+	}
+	return s.authResponse(user, sessionID, refresh)
+}
+
+func (s *UserService) authResponse(user *models.User, sessionID int64, refresh string) (*models.AuthResponse, error) {
+	token, err := s.generateToken(user.ID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.AuthResponse{
+		Token:        token,
+		RefreshToken: refresh,
+		ExpiresIn:    int(accessTokenTTL.Seconds()),
+		User:         *user,
+		SessionID:    sessionID,
+	}, nil
+}
+
+// Refresh trades a refresh token for a new access token. The refresh token
+// is rotated, so each one works only once.
+func (s *UserService) Refresh(refreshToken, ip string) (*models.AuthResponse, error) {
+	var sessionID, userID int64
+	var expiresAt time.Time
+	err := database.DB.QueryRow(
+		"SELECT id, user_id, expires_at FROM user_sessions WHERE refresh_hash = ?",
+		hashRefreshToken(refreshToken),
+	).Scan(&sessionID, &userID, &expiresAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !now.Before(expiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+
+	refresh := generateRandomToken()
+	result, err := database.DB.Exec(
+		`UPDATE user_sessions SET refresh_hash = ?, ip_address = ?, last_used_at = ?, expires_at = ?
+		 WHERE id = ? AND refresh_hash = ?`,
+		hashRefreshToken(refresh), ip, now, now.Add(refreshTokenTTL), sessionID, hashRefreshToken(refreshToken),
+	)
+	if err != nil {
+		return nil, err
+	}
+	// A concurrent refresh with the same token already rotated it
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, ErrInvalidRefreshToken
+	}
+	return s.authResponse(user, sessionID, refresh)
+}
+
+// CheckSession returns ErrSessionRevoked unless the session is still open
+func (s *UserService) CheckSession(userID, sessionID int64) error {
+	var expiresAt time.Time
+	err := database.DB.QueryRow(
+		"SELECT expires_at FROM user_sessions WHERE id = ? AND user_id = ?",
+		sessionID, userID,
+	).Scan(&expiresAt)
+	if err == sql.ErrNoRows || (err == nil && !time.Now().Before(expiresAt)) {
+		return ErrSessionRevoked
+	}
+	return err
+}
+
+// ListSessions returns the user's open sessions, most recently used first.
+// currentID marks the session making the request.
+func (s *UserService) ListSessions(userID, currentID int64) ([]models.UserSession, error) {
+	rows, err := database.DB.Query(
+		`SELECT s.id, s.device, s.ip_address, COALESCE(MAX(l.ip_location), ''), s.user_agent,
+		        s.created_at, s.last_used_at, s.expires_at
+		 FROM user_sessions s
+		 LEFT JOIN login_logs l ON l.session_id = s.id AND l.user_id = s.user_id
+		 WHERE s.user_id = ? AND s.expires_at > ?
+		 GROUP BY s.id
+		 ORDER BY s.last_used_at DESC`,
+		userID, time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.UserSession{}
+	for rows.Next() {
+		var session models.UserSession
+		if err := rows.Scan(
+			&session.ID, &session.Device, &session.IPAddress, &session.IPLocation, &session.UserAgent,
+			&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		session.Current = session.ID == currentID
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// RevokeSession signs one session out; its access token stops working
+// on the next request
+func (s *UserService) RevokeSession(userID, sessionID int64) error {
+	result, err := database.DB.Exec("DELETE FROM user_sessions WHERE id = ? AND user_id = ?", sessionID, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeSessions signs the user out everywhere except keepID (0 keeps none)
+func (s *UserService) RevokeSessions(userID, keepID int64) error {
+	_, err := database.DB.Exec("DELETE FROM user_sessions WHERE user_id = ? AND id != ?", userID, keepID)
+	return err
+}
+
+func (s *UserService) generateToken(userID, sessionID int64) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"user_id": userID,
+		"sid":     sessionID,
+		"exp":     now.Add(accessTokenTTL).Unix(),
+		"iat":     now.Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(s.cfg.JWTSecret))
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"dns-mng/config"
+	"dns-mng/database"
+	"dns-mng/models"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newSessionTestService returns a UserService with one user, "alice", and
+// a session opened by logging in as her
+func newSessionTestService(t *testing.T) (*UserService, *models.AuthResponse) {
+	t.Helper()
+	openTestDB(t)
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.DB.Exec(`INSERT INTO users (id, username, password_hash) VALUES (1, 'alice', ?)`, string(hash)); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewUserService(&config.Config{JWTSecret: "session-test-secret"})
+	resp, err := s.Login(&models.LoginRequest{Username: "alice", Password: "correct horse"}, &models.SessionClient{IPAddress: "192.0.2.1"})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	return s, resp
+}
+
+func TestSessionRefreshRotatesToken(t *testing.T) {
+	s, login := newSessionTestService(t)
+
+	refreshed, err := s.Refresh(login.RefreshToken, "192.0.2.2")
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if refreshed.SessionID != login.SessionID {
+		t.Errorf("refresh opened session %d, want %d", refreshed.SessionID, login.SessionID)
+	}
+	if refreshed.RefreshToken == login.RefreshToken || refreshed.Token == "" {
+		t.Errorf("refresh did not issue new tokens: %+v", refreshed)
+	}
+	if err := s.CheckSession(1, refreshed.SessionID); err != nil {
+		t.Errorf("CheckSession after refresh: %v", err)
+	}
+
+	// The rotated token is spent; only its replacement works
+	if _, err := s.Refresh(login.RefreshToken, "192.0.2.2"); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("reusing a rotated token = %v, want ErrInvalidRefreshToken", err)
+	}
+	if _, err := s.Refresh(refreshed.RefreshToken, "192.0.2.2"); err != nil {
+		t.Errorf("Refresh with the new token: %v", err)
+	}
+}
+
+func TestSessionRevoked(t *testing.T) {
+	s, login := newSessionTestService(t)
+	other, err := s.Login(&models.LoginRequest{Username: "alice", Password: "correct horse"}, &models.SessionClient{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Logging out revokes the current session only
+	if err := s.RevokeSession(1, login.SessionID); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	if err := s.CheckSession(1, login.SessionID); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("access after logout = %v, want ErrSessionRevoked", err)
+	}
+	if _, err := s.Refresh(login.RefreshToken, ""); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("refresh after logout = %v, want ErrInvalidRefreshToken", err)
+	}
+	if err := s.RevokeSession(1, login.SessionID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("revoking twice = %v, want ErrSessionNotFound", err)
+	}
+	if err := s.CheckSession(1, other.SessionID); err != nil {
+		t.Errorf("other session revoked: %v", err)
+	}
+
+	// Sessions of one user are not visible to another
+	if err := s.CheckSession(2, other.SessionID); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("CheckSession for another user = %v, want ErrSessionRevoked", err)
+	}
+	if err := s.RevokeSession(2, other.SessionID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("RevokeSession for another user = %v, want ErrSessionNotFound", err)
+	}
+
+	if err := s.RevokeSessions(1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Refresh(other.RefreshToken, ""); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("refresh after signing out everywhere = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestSessionExpiredOrDisabled(t *testing.T) {
+	s, login := newSessionTestService(t)
+
+	if _, err := database.DB.Exec("UPDATE users SET disabled = 1 WHERE id = 1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Refresh(login.RefreshToken, ""); !errors.Is(err, ErrUserDisabled) {
+		t.Errorf("refresh by a disabled user = %v, want ErrUserDisabled", err)
+	}
+	if _, err := database.DB.Exec("UPDATE users SET disabled = 0 WHERE id = 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := database.DB.Exec("UPDATE user_sessions SET expires_at = ? WHERE id = ?", time.Now().Add(-time.Minute), login.SessionID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Refresh(login.RefreshToken, ""); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("refresh of an expired session = %v, want ErrInvalidRefreshToken", err)
+	}
+	if err := s.CheckSession(1, login.SessionID); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("access to an expired session = %v, want ErrSessionRevoked", err)
+	}
+}
@@ -0,0 +1,354 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"dns-mng/database"
+	"dns-mng/models"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TOTP 参数与常见验证器 App 默认值一致（RFC 6238：SHA1、6 位、30 秒）
+const (
+	totpIssuer        = "DNS Manager"
+	totpDigits        = 6
+	totpPeriod        = 30
+	totpSkew          = 1 // 允许前后各一个时间步的时钟偏差
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrTOTPRequired       = errors.New("two-factor code required")
+	ErrInvalidTOTP        = errors.New("invalid two-factor code")
+	ErrTOTPNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotSetUp       = errors.New("start two-factor setup first")
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func generateTOTPSecret() string {
+	b := make([]byte, 20)
+	rand.Read(b)
+	return totpEncoding.EncodeToString(b)
+}
+
+func totpCode(key []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// matchTOTP returns the time step code matches. Steps at or before
+// lastStep are rejected so a code cannot be used twice.
+func matchTOTP(secret, code string, now time.Time, lastStep int64) (int64, bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+func totpURI(username, secret string) string {
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", totpIssuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", fmt.Sprint(totpDigits))
+	v.Set("period", fmt.Sprint(totpPeriod))
+	label := url.PathEscape(totpIssuer + ":" + username)
+	return "otpauth://totp/" + label + "?" + v.Encode()
+}
+
+// normalizeRecoveryCode drops separators and case so "ABCDE-12345" matches
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(normalizeRecoveryCode(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+type totpState struct {
+	secret   string
+	enabled  bool
+	lastStep int64
+}
+
+func loadTOTPState(db queryRower, userID int64) (*totpState, error) {
+	var st totpState
+	var stored string
+	var enabled int
+	err := db.QueryRow(
+		"SELECT totp_secret, totp_enabled, totp_last_step FROM users WHERE id = ?",
+		userID,
+	).Scan(&stored, &enabled, &st.lastStep)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if st.secret, err = DecryptSecret(stored); err != nil {
+		return nil, err
+	}
+	st.enabled = enabled == 1
+	return &st, nil
+}
+
+// checkSecondFactor accepts a current authenticator code or an unused
+// recovery code. Each is consumed on success.
+func checkSecondFactor(tx *sql.Tx, userID int64, code string) error {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return ErrTOTPRequired
+	}
+	st, err := loadTOTPState(tx, userID)
+	if err != nil {
+		return err
+	}
+	if !st.enabled {
+		return ErrTOTPNotEnabled
+	}
+
+	if step, ok := matchTOTP(st.secret, code, time.Now(), st.lastStep); ok {
+		_, err := tx.Exec("UPDATE users SET totp_last_step = ? WHERE id = ?", step, userID)
+		return err
+	}
+
+	result, err := tx.Exec(
+		`UPDATE user_recovery_codes SET used_at = ?
+		 WHERE id = (SELECT id FROM user_recovery_codes WHERE user_id = ? AND code_hash = ? AND used_at IS NULL LIMIT 1)`,
+		time.Now(), userID, hashRecoveryCode(code),
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrInvalidTOTP
+	}
+	return nil
+}
+
+// replaceRecoveryCodes drops the user's recovery codes and issues a new set
+func replaceRecoveryCodes(tx *sql.Tx, userID int64) ([]string, error) {
+	if _, err := tx.Exec("DELETE FROM user_recovery_codes WHERE user_id = ?", userID); err != nil {
+		return nil, err
+	}
+	codes := make([]string, recoveryCodeCount)
+	now := time.Now()
+	for i := range codes {
+		raw := generateRandomToken()[:10]
+		codes[i] = raw[:5] + "-" + raw[5:]
+		if _, err := tx.Exec(
+			"INSERT INTO user_recovery_codes (user_id, code_hash, created_at) VALUES (?, ?, ?)",
+			userID, hashRecoveryCode(codes[i]), now,
+		); err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
+
+// verifySecondFactor checks code in its own transaction
+func (s *UserService) verifySecondFactor(userID int64, code string) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkSecondFactor(tx, userID, code); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *UserService) TOTPStatus(userID int64) (*models.TOTPStatus, error) {
+	st, err := loadTOTPState(database.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+	status := &models.TOTPStatus{Enabled: st.enabled, Pending: !st.enabled && st.secret != ""}
+	if st.enabled {
+		if err := database.DB.QueryRow(
+			"SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = ? AND used_at IS NULL",
+			userID,
+		).Scan(&status.RecoveryCodesLeft); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// SetupTOTP stores a new pending secret. 2FA is only enforced after
+// EnableTOTP confirms a code from it.
+func (s *UserService) SetupTOTP(userID int64) (*models.TOTPSetup, error) {
+	user, err := s.GetUser(userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+
+	secret := generateTOTPSecret()
+	stored, err := EncryptSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := database.DB.Exec(
+		"UPDATE users SET totp_secret = ?, totp_last_step = 0 WHERE id = ?",
+		stored, userID,
+	); err != nil {
+		return nil, err
+	}
+	return &models.TOTPSetup{Secret: secret, URI: totpURI(user.Username, secret)}, nil
+}
+
+// EnableTOTP confirms the pending secret and returns the recovery codes
+func (s *UserService) EnableTOTP(userID int64, code string) (*models.RecoveryCodes, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	st, err := loadTOTPState(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if st.enabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	if st.secret == "" {
+		return nil, ErrTOTPNotSetUp
+	}
+	step, ok := matchTOTP(st.secret, strings.TrimSpace(code), time.Now(), st.lastStep)
+	if !ok {
+		return nil, ErrInvalidTOTP
+	}
+
+	if _, err := tx.Exec(
+		"UPDATE users SET totp_enabled = 1, totp_last_step = ? WHERE id = ?",
+		step, userID,
+	); err != nil {
+		return nil, err
+	}
+	codes, err := replaceRecoveryCodes(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &models.RecoveryCodes{Codes: codes}, nil
+}
+
+// DisableTOTP turns 2FA off after checking the password and a second factor
+func (s *UserService) DisableTOTP(userID int64, req *models.DisableTOTPRequest) error {
+	var passwordHash string
+	if err := database.DB.QueryRow("SELECT password_hash FROM users WHERE id = ?", userID).Scan(&passwordHash); err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(req.Password)); err != nil {
+		return errors.New("password is incorrect")
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkSecondFactor(tx, userID, req.Code); err != nil {
+		return err
+	}
+	if err := clearTOTP(tx, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes; code must be valid
+func (s *UserService) RegenerateRecoveryCodes(userID int64, code string) (*models.RecoveryCodes, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := checkSecondFactor(tx, userID, code); err != nil {
+		return nil, err
+	}
+	codes, err := replaceRecoveryCodes(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &models.RecoveryCodes{Codes: codes}, nil
+}
+
+// ResetTOTP lets an admin turn off 2FA for a user who lost their device
+func (s *UserService) ResetTOTP(userID int64) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := loadTOTPState(tx, userID); err != nil {
+		return err
+	}
+	if err := clearTOTP(tx, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func clearTOTP(tx *sql.Tx, userID int64) error {
+	if _, err := tx.Exec(
+		"UPDATE users SET totp_secret = '', totp_enabled = 0, totp_last_step = 0 WHERE id = ?",
+		userID,
+	); err != nil {
+		return err
+	}
+	_, err := tx.Exec("DELETE FROM user_recovery_codes WHERE user_id = ?", userID)
+	return err
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B key for SHA1, "12345678901234567890" in base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	// The RFC lists 8-digit codes; the last 6 digits are the 6-digit code
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	key, err := totpEncoding.DecodeString(rfc6238Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		if got := totpCode(key, tt.unix/totpPeriod); got != tt.want {
+			t.Errorf("totpCode at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestMatchTOTP(t *testing.T) {
+	key, _ := totpEncoding.DecodeString(rfc6238Secret)
+	now := time.Unix(1111111111, 0)
+	current := now.Unix() / totpPeriod
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		ok     bool
+	}{
+		{"current step", rfc6238Secret, totpCode(key, current), true},
+		{"previous step", rfc6238Secret, totpCode(key, current-1), true},
+		{"next step", rfc6238Secret, totpCode(key, current+1), true},
+		{"outside skew", rfc6238Secret, totpCode(key, current-2), false},
+		{"lower-case secret", "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", totpCode(key, current), true},
+		{"wrong length", rfc6238Secret, "12345", false},
+		{"bad secret", "not base32!", totpCode(key, current), false},
+	}
+
+	for _, tt := range tests {
+		if _, ok := matchTOTP(tt.secret, tt.code, now, 0); ok != tt.ok {
+			t.Errorf("%s: ok = %v, want %v", tt.name, ok, tt.ok)
+		}
+	}
+}
+
+func TestMatchTOTPReplay(t *testing.T) {
+	key, _ := totpEncoding.DecodeString(rfc6238Secret)
+	now := time.Unix(1111111111, 0)
+	current := now.Unix() / totpPeriod
+	code := totpCode(key, current)
+
+	step, ok := matchTOTP(rfc6238Secret, code, now, 0)
+	if !ok || step != current {
+		t.Fatalf("matchTOTP = %d, %v; want %d, true", step, ok, current)
+	}
+	// The same code within its window is a replay
+	if _, ok := matchTOTP(rfc6238Secret, code, now.Add(10*time.Second), step); ok {
+		t.Error("reused code was accepted")
+	}
+	// So is an older code still inside the skew
+	if _, ok := matchTOTP(rfc6238Secret, totpCode(key, current-1), now, step); ok {
+		t.Error("code before the last used step was accepted")
+	}
+	// The next step's code is still fine
+	if next, ok := matchTOTP(rfc6238Secret, totpCode(key, current+1), now.Add(totpPeriod*time.Second), step); !ok || next != current+1 {
+		t.Errorf("next code = %d, %v; want %d, true", next, ok, current+1)
+	}
+}
+
+func TestHashRecoveryCode(t *testing.T) {
+	want := hashRecoveryCode("abcde-12345")
+	for _, code := range []string{"ABCDE-12345", " abcde12345 ", "abcde 12345"} {
+		if got := hashRecoveryCode(code); got != want {
+			t.Errorf("hashRecoveryCode(%q) differs from the canonical form", code)
+		}
+	}
+}
@@ -0,0 +1,405 @@
+package service
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"dns-mng/database"
+	"dns-mng/models"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrUserNotFound            = errors.New("user not found")
+	ErrLastAdmin               = errors.New("at least one active admin is required")
+	ErrModifySelf              = errors.New("cannot disable, demote or delete your own account")
+	ErrInvalidRole             = errors.New("role must be admin or user")
+	ErrInvalidRegistrationMode = errors.New("registration mode must be open, invite or disabled")
+	ErrInviteNotFound          = errors.New("invite not found")
+)
+
+const registrationModeKey = "registration_mode"
+
+// userDataTables hold per-user rows removed together with the user. Login
+// and API call logs are kept for auditing and expire via log retention.
+var userDataTables = []string{
+	"accounts", "domain_cache", "domain_pending_deletions", "notification_settings",
+	"email_config", "notification_channels", "webhooks", "webhook_deliveries",
+	"whois_config", "dnshe_auto_renew_config", "record_cache", "record_cache_zones",
+	"record_changes", "ddns_tokens", "ddns_history", "ddns_record_state", "ddns_agents",
+	"cf_optimize", "user_recovery_codes", "api_tokens", "user_sessions",
+}
+
+const userColumns = `id, username, role, disabled, totp_enabled, created_at`
+
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func scanUser(row rowScanner) (*models.User, error) {
+	var user models.User
+	var disabled, totpEnabled int
+	if err := row.Scan(&user.ID, &user.Username, &user.Role, &disabled, &totpEnabled, &user.CreatedAt); err != nil {
+		return nil, err
+	}
+	user.Disabled = disabled == 1
+	user.TOTPEnabled = totpEnabled == 1
+	return &user, nil
+}
+
+func insertUser(db dbExecer, username, password, role string) (*models.User, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result, err := db.Exec(
+		"INSERT INTO users (username, password_hash, role, created_at) VALUES (?, ?, ?, ?)",
+		username, string(hash), role, now,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, ErrUsernameTaken
+		}
+		return nil, err
+	}
+
+	id, _ := result.LastInsertId()
+	return &models.User{ID: id, Username: username, Role: role, CreatedAt: now}, nil
+}
+
+// EnsureAdmin promotes the oldest user when no admin exists, so instances
+// created before roles existed keep an administrator
+func (s *UserService) EnsureAdmin() error {
+	var exists bool
+	if err := database.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE role = ?)", models.RoleAdmin).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	result, err := database.DB.Exec(
+		"UPDATE users SET role = ?, disabled = 0 WHERE id = (SELECT MIN(id) FROM users)",
+		models.RoleAdmin,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		log.Println("No admin found, promoted the first user to admin")
+	}
+	return nil
+}
+
+// registrationMode returns the admin override or the configured default
+func (s *UserService) registrationMode(db queryRower) string {
+	var mode string
+	err := db.QueryRow("SELECT value FROM app_settings WHERE key = ?", registrationModeKey).Scan(&mode)
+	if err != nil {
+		mode = s.cfg.RegistrationMode
+	}
+	if !validRegistrationMode(mode) {
+		return models.RegistrationDisabled
+	}
+	return mode
+}
+
+func validRegistrationMode(mode string) bool {
+	switch mode {
+	case models.RegistrationOpen, models.RegistrationInvite, models.RegistrationDisabled:
+		return true
+	}
+	return false
+}
+
+// RegistrationStatus is shown on the login page before signing in
+func (s *UserService) RegistrationStatus() (*models.RegistrationStatus, error) {
+	var userCount int
+	if err := database.DB.QueryRow("SELECT COUNT(*) FROM users").Scan(&userCount); err != nil {
+		return nil, err
+	}
+	return &models.RegistrationStatus{
+		Mode:      s.registrationMode(database.DB),
+		Bootstrap: userCount == 0,
+	}, nil
+}
+
+func (s *UserService) SetRegistrationMode(mode string) (*models.RegistrationStatus, error) {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	if !validRegistrationMode(mode) {
+		return nil, ErrInvalidRegistrationMode
+	}
+	_, err := database.DB.Exec(
+		`INSERT INTO app_settings (key, value, updated_at) VALUES (?, ?, ?)
+		 ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+		registrationModeKey, mode, time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return s.RegistrationStatus()
+}
+
+// ListUsers returns all users, oldest first
+func (s *UserService) ListUsers() ([]models.User, error) {
+	rows, err := database.DB.Query("SELECT " + userColumns + " FROM users ORDER BY id ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+	return users, rows.Err()
+}
+
+// CreateUser adds a user regardless of the registration mode
+func (s *UserService) CreateUser(req *models.CreateUserRequest) (*models.User, error) {
+	role := req.Role
+	if role == "" {
+		role = models.RoleUser
+	}
+	if role != models.RoleAdmin && role != models.RoleUser {
+		return nil, ErrInvalidRole
+	}
+	return insertUser(database.DB, req.Username, req.Password, role)
+}
+
+// UpdateUser changes role and disabled state. actorID is the admin making
+// the change, who cannot lock themselves out.
+func (s *UserService) UpdateUser(actorID, userID int64, req *models.UpdateUserRequest) (*models.User, error) {
+	user, err := s.GetUser(userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	role := user.Role
+	if req.Role != nil {
+		role = *req.Role
+		if role != models.RoleAdmin && role != models.RoleUser {
+			return nil, ErrInvalidRole
+		}
+	}
+	disabled := user.Disabled
+	if req.Disabled != nil {
+		disabled = *req.Disabled
+	}
+
+	losesAdmin := user.Role == models.RoleAdmin && !user.Disabled && (role != models.RoleAdmin || disabled)
+	if losesAdmin {
+		if userID == actorID {
+			return nil, ErrModifySelf
+		}
+		if err := s.checkOtherAdmin(userID); err != nil {
+			return nil, err
+		}
+	}
+	if disabled && userID == actorID {
+		return nil, ErrModifySelf
+	}
+
+	if _, err := database.DB.Exec(
+		"UPDATE users SET role = ?, disabled = ? WHERE id = ?",
+		role, boolToInt(disabled), userID,
+	); err != nil {
+		return nil, err
+	}
+	if disabled {
+		if err := s.RevokeSessions(userID, 0); err != nil {
+			return nil, err
+		}
+	}
+	return s.GetUser(userID)
+}
+
+// ResetPassword sets a new password without asking for the old one and
+// signs the user out everywhere
+func (s *UserService) ResetPassword(userID int64, newPassword string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	result, err := database.DB.Exec("UPDATE users SET password_hash = ? WHERE id = ?", string(hash), userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrUserNotFound
+	}
+	return s.RevokeSessions(userID, 0)
+}
+
+// DeleteUser removes a user together with all of their data
+func (s *UserService) DeleteUser(actorID, userID int64) error {
+	if userID == actorID {
+		return ErrModifySelf
+	}
+	user, err := s.GetUser(userID)
+	if err == sql.ErrNoRows {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if user.Role == models.RoleAdmin && !user.Disabled {
+		if err := s.checkOtherAdmin(userID); err != nil {
+			return err
+		}
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range userDataTables {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE user_id = ?", userID); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec("DELETE FROM users WHERE id = ?", userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *UserService) checkOtherAdmin(userID int64) error {
+	var others int
+	err := database.DB.QueryRow(
+		"SELECT COUNT(*) FROM users WHERE role = ? AND disabled = 0 AND id != ?",
+		models.RoleAdmin, userID,
+	).Scan(&others)
+	if err != nil {
+		return err
+	}
+	if others == 0 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+func hashInviteCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+// claimInvite marks an unused, unexpired invite as used and returns its id
+func claimInvite(tx *sql.Tx, code string) (int64, error) {
+	if strings.TrimSpace(code) == "" {
+		return 0, ErrInvalidInvite
+	}
+
+	var id int64
+	var expiresAt, usedAt sql.NullTime
+	err := tx.QueryRow(
+		"SELECT id, expires_at, used_at FROM user_invites WHERE code_hash = ?",
+		hashInviteCode(code),
+	).Scan(&id, &expiresAt, &usedAt)
+	if err == sql.ErrNoRows {
+		return 0, ErrInvalidInvite
+	}
+	if err != nil {
+		return 0, err
+	}
+	if usedAt.Valid || (expiresAt.Valid && !time.Now().Before(expiresAt.Time)) {
+		return 0, ErrInvalidInvite
+	}
+
+	result, err := tx.Exec("UPDATE user_invites SET used_at = ? WHERE id = ? AND used_at IS NULL", time.Now(), id)
+	if err != nil {
+		return 0, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return 0, ErrInvalidInvite
+	}
+	return id, nil
+}
+
+// ListInvites returns all invites, newest first
+func (s *UserService) ListInvites() ([]models.UserInvite, error) {
+	rows, err := database.DB.Query(
+		"SELECT id, note, created_by, expires_at, used_by, used_at, created_at FROM user_invites ORDER BY id DESC",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invites := []models.UserInvite{}
+	for rows.Next() {
+		var inv models.UserInvite
+		var expiresAt, usedAt sql.NullTime
+		var usedBy sql.NullInt64
+		if err := rows.Scan(&inv.ID, &inv.Note, &inv.CreatedBy, &expiresAt, &usedBy, &usedAt, &inv.CreatedAt); err != nil {
+			return nil, err
+		}
+		if expiresAt.Valid {
+			inv.ExpiresAt = &expiresAt.Time
+		}
+		if usedBy.Valid {
+			inv.UsedBy = &usedBy.Int64
+		}
+		if usedAt.Valid {
+			inv.UsedAt = &usedAt.Time
+		}
+		invites = append(invites, inv)
+	}
+	return invites, rows.Err()
+}
+
+// CreateInvite issues a single-use invite code. The code is only returned
+// here.
+func (s *UserService) CreateInvite(actorID int64, req *models.CreateInviteRequest) (*models.UserInvite, error) {
+	code := generateRandomToken()[:24]
+	now := time.Now()
+	invite := &models.UserInvite{
+		Code:      code,
+		Note:      strings.TrimSpace(req.Note),
+		CreatedBy: actorID,
+		CreatedAt: now,
+	}
+	var expiresAt interface{}
+	if req.ExpiresInHours > 0 {
+		t := now.Add(time.Duration(req.ExpiresInHours) * time.Hour)
+		invite.ExpiresAt = &t
+		expiresAt = t
+	}
+
+	result, err := database.DB.Exec(
+		"INSERT INTO user_invites (code_hash, note, created_by, expires_at, created_at) VALUES (?, ?, ?, ?, ?)",
+		hashInviteCode(code), invite.Note, actorID, expiresAt, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	invite.ID, _ = result.LastInsertId()
+	return invite, nil
+}
+
+func (s *UserService) DeleteInvite(id int64) error {
+	result, err := database.DB.Exec("DELETE FROM user_invites WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrInviteNotFound
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"dns-mng/config"
+	"dns-mng/database"
+	"dns-mng/models"
+)
+
+func newUserManagementTestService(t *testing.T, mode string) *UserService {
+	t.Helper()
+	openTestDB(t)
+	return NewUserService(&config.Config{JWTSecret: "user-test-secret", RegistrationMode: mode})
+}
+
+func register(s *UserService, username, invite string) error {
+	_, err := s.Register(&models.RegisterRequest{Username: username, Password: "secret-password", InviteCode: invite}, &models.SessionClient{})
+	return err
+}
+
+func TestRegistrationModes(t *testing.T) {
+	s := newUserManagementTestService(t, models.RegistrationDisabled)
+
+	// The first user can always register and becomes admin
+	resp, err := s.Register(&models.RegisterRequest{Username: "root", Password: "secret-password"}, &models.SessionClient{})
+	if err != nil {
+		t.Fatalf("bootstrap Register: %v", err)
+	}
+	if resp.User.Role != models.RoleAdmin {
+		t.Errorf("first user role = %s, want admin", resp.User.Role)
+	}
+	if err := register(s, "closed", ""); !errors.Is(err, ErrRegistrationDisabled) {
+		t.Errorf("Register while disabled = %v, want ErrRegistrationDisabled", err)
+	}
+
+	if _, err := s.SetRegistrationMode(" Open "); err != nil {
+		t.Fatalf("SetRegistrationMode: %v", err)
+	}
+	if err := register(s, "opened", ""); err != nil {
+		t.Errorf("Register while open: %v", err)
+	}
+	if err := register(s, "opened", ""); !errors.Is(err, ErrUsernameTaken) {
+		t.Errorf("Register a taken username = %v, want ErrUsernameTaken", err)
+	}
+
+	if _, err := s.SetRegistrationMode(models.RegistrationInvite); err != nil {
+		t.Fatal(err)
+	}
+	if err := register(s, "uninvited", ""); !errors.Is(err, ErrInvalidInvite) {
+		t.Errorf("Register without an invite = %v, want ErrInvalidInvite", err)
+	}
+	if err := register(s, "forged", "not-a-real-invite-code"); !errors.Is(err, ErrInvalidInvite) {
+		t.Errorf("Register with an unknown invite = %v, want ErrInvalidInvite", err)
+	}
+
+	if _, err := s.SetRegistrationMode("everyone"); !errors.Is(err, ErrInvalidRegistrationMode) {
+		t.Errorf("SetRegistrationMode(everyone) = %v, want ErrInvalidRegistrationMode", err)
+	}
+	if status, err := s.RegistrationStatus(); err != nil || status.Mode != models.RegistrationInvite || status.Bootstrap {
+		t.Errorf("RegistrationStatus = %+v, %v", status, err)
+	}
+}
+
+func TestInviteConsumption(t *testing.T) {
+	s := newUserManagementTestService(t, models.RegistrationInvite)
+	if err := register(s, "root", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	invite, err := s.CreateInvite(1, &models.CreateInviteRequest{Note: "for bob"})
+	if err != nil {
+		t.Fatalf("CreateInvite: %v", err)
+	}
+	if err := register(s, "bob", " "+invite.Code+" "); err != nil {
+		t.Fatalf("Register with an invite: %v", err)
+	}
+	if err := register(s, "mallory", invite.Code); !errors.Is(err, ErrInvalidInvite) {
+		t.Errorf("reusing an invite = %v, want ErrInvalidInvite", err)
+	}
+	invites, err := s.ListInvites()
+	if err != nil || len(invites) != 1 || invites[0].UsedAt == nil || invites[0].UsedBy == nil || *invites[0].UsedBy != 2 {
+		t.Errorf("ListInvites = %+v, %v; want the invite used by user 2", invites, err)
+	}
+
+	// A failed sign-up leaves the invite unused
+	retry, err := s.CreateInvite(1, &models.CreateInviteRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := register(s, "bob", retry.Code); !errors.Is(err, ErrUsernameTaken) {
+		t.Fatalf("Register a taken username = %v, want ErrUsernameTaken", err)
+	}
+	if err := register(s, "carol", retry.Code); err != nil {
+		t.Errorf("invite spent by a failed sign-up: %v", err)
+	}
+
+	expired, err := s.CreateInvite(1, &models.CreateInviteRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.DB.Exec("UPDATE user_invites SET expires_at = '2000-01-01 00:00:00' WHERE id = ?", expired.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := register(s, "dave", expired.Code); !errors.Is(err, ErrInvalidInvite) {
+		t.Errorf("Register with an expired invite = %v, want ErrInvalidInvite", err)
+	}
+}
+
+func TestInviteConcurrentRedemption(t *testing.T) {
+	s := newUserManagementTestService(t, models.RegistrationInvite)
+	if err := register(s, "root", ""); err != nil {
+		t.Fatal(err)
+	}
+	invite, err := s.CreateInvite(1, &models.CreateInviteRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const attempts = 8
+	errs := make([]error, attempts)
+	var wg sync.WaitGroup
+	for i := range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = register(s, fmt.Sprintf("racer%d", i), invite.Code)
+		}()
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+		}
+	}
+	var users int
+	if err := database.DB.QueryRow("SELECT COUNT(*) FROM users WHERE username LIKE 'racer%'").Scan(&users); err != nil {
+		t.Fatal(err)
+	}
+	if succeeded != 1 || users != 1 {
+		t.Errorf("%d sign-up(s) succeeded and %d user(s) created with one invite, want 1: %v", succeeded, users, errs)
+	}
+}
+
+func TestLastAdmin(t *testing.T) {
+	s := newUserManagementTestService(t, models.RegistrationOpen)
+	if err := register(s, "root", ""); err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.CreateUser(&models.CreateUserRequest{Username: "second", Password: "secret-password", Role: models.RoleAdmin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	role, disabled := models.RoleUser, true
+	demote := &models.UpdateUserRequest{Role: &role}
+	disable := &models.UpdateUserRequest{Disabled: &disabled}
+
+	// Admins cannot demote, disable or delete themselves
+	if _, err := s.UpdateUser(1, 1, demote); !errors.Is(err, ErrModifySelf) {
+		t.Errorf("demoting yourself = %v, want ErrModifySelf", err)
+	}
+	if err := s.DeleteUser(1, 1); !errors.Is(err, ErrModifySelf) {
+		t.Errorf("deleting yourself = %v, want ErrModifySelf", err)
+	}
+
+	// With two admins one can be demoted; then the other is the last one
+	if _, err := s.UpdateUser(1, second.ID, demote); err != nil {
+		t.Fatalf("demoting the second admin: %v", err)
+	}
+	if _, err := database.DB.Exec("UPDATE users SET role = 'admin' WHERE id = ?", second.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.UpdateUser(1, second.ID, disable); err != nil {
+		t.Fatalf("disabling the second admin: %v", err)
+	}
+	for name, fn := range map[string]func() error{
+		"demote":  func() error { _, err := s.UpdateUser(second.ID, 1, demote); return err },
+		"disable": func() error { _, err := s.UpdateUser(second.ID, 1, disable); return err },
+		"delete":  func() error { return s.DeleteUser(second.ID, 1) },
+	} {
+		if err := fn(); !errors.Is(err, ErrLastAdmin) {
+			t.Errorf("%s the last active admin = %v, want ErrLastAdmin", name, err)
+		}
+	}
+	if user, err := s.GetUser(1); err != nil || user.Role != models.RoleAdmin || user.Disabled {
+		t.Errorf("last admin = %+v, %v; want an active admin", user, err)
+	}
+
+	// A disabled admin can be deleted
+	if err := s.DeleteUser(1, second.ID); err != nil {
+		t.Errorf("deleting a disabled admin: %v", err)
+	}
+}
@@ -3,108 +3,138 @@ package service
 import (
 	"database/sql"
 	"errors"
-	"time"
+	"sync"
 
 	"dns-mng/config"
 	"dns-mng/database"
 	"dns-mng/models"
 
-	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserService struct {
-	cfg *config.Config
+	cfg   *config.Config
+	guard *LoginGuard
 }
 
 func NewUserService(cfg *config.Config) *UserService {
-	return &UserService{cfg: cfg}
+	return &UserService{cfg: cfg, guard: NewLoginGuard()}
 }
 
-func (s *UserService) Register(req *models.RegisterRequest) (*models.AuthResponse, error) {
-	// Check if username exists
-	var exists bool
-	err := database.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE username = ?)", req.Username).Scan(&exists)
-	if err != nil {
-		return nil, err
-	}
-	if exists {
-		return nil, errors.New("username already exists")
-	}
+var (
+	ErrRegistrationDisabled = errors.New("registration is disabled")
+	ErrInvalidInvite        = errors.New("invalid or expired invite code")
+	ErrUserDisabled         = errors.New("account is disabled")
+	ErrUsernameTaken        = errors.New("username already exists")
+)
 
-	// Hash password
-	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+// Register signs up a new user according to the registration mode. While
+// no user exists registration is always open and the first user becomes
+// admin.
+func (s *UserService) Register(req *models.RegisterRequest, client *models.SessionClient) (*models.AuthResponse, error) {
+	tx, err := database.DB.Begin()
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	// Insert user
-	result, err := database.DB.Exec(
-		"INSERT INTO users (username, password_hash) VALUES (?, ?)",
-		req.Username, string(hash),
-	)
-	if err != nil {
+	var userCount int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM users").Scan(&userCount); err != nil {
 		return nil, err
 	}
 
-	id, _ := result.LastInsertId()
-	user := models.User{
-		ID:        id,
-		Username:  req.Username,
-		CreatedAt: time.Now(),
+	role := models.RoleUser
+	var inviteID int64
+	if userCount == 0 {
+		role = models.RoleAdmin
+	} else {
+		switch s.registrationMode(tx) {
+		case models.RegistrationOpen:
+		case models.RegistrationInvite:
+			if inviteID, err = claimInvite(tx, req.InviteCode); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, ErrRegistrationDisabled
+		}
 	}
 
-	token, err := s.generateToken(user.ID)
+	user, err := insertUser(tx, req.Username, req.Password, role)
 	if err != nil {
 		return nil, err
 	}
+	if inviteID > 0 {
+		if _, err := tx.Exec("UPDATE user_invites SET used_by = ? WHERE id = ?", user.ID, inviteID); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 
-	return &models.AuthResponse{Token: token, User: user}, nil
+	return s.startSession(user, client)
 }
 
-func (s *UserService) Login(req *models.LoginRequest) (*models.AuthResponse, error) {
-	var user models.User
-	var passwordHash string
-
-	err := database.DB.QueryRow(
-		"SELECT id, username, password_hash, created_at FROM users WHERE username = ?",
-		req.Username,
-	).Scan(&user.ID, &user.Username, &passwordHash, &user.CreatedAt)
-
-	// 如果用户不存在，自动注册
-	if err == sql.ErrNoRows {
-		return s.Register(&models.RegisterRequest{
-			Username: req.Username,
-			Password: req.Password,
-		})
-	}
+// Login checks the credentials of an existing user. Unknown usernames are
+// rejected; accounts are created through Register or by an admin. Users
+// with 2FA must also send a TOTP or recovery code.
+func (s *UserService) Login(req *models.LoginRequest, client *models.SessionClient) (*models.AuthResponse, error) {
+	user, err := s.guarded(client.TrustedIP, req.Username, func() (*models.User, error) {
+		user, err := s.VerifyCredentials(req.Username, req.Password)
+		if err != nil {
+			return nil, err
+		}
+		if user.TOTPEnabled {
+			if err := s.verifySecondFactor(user.ID, req.TOTPCode); err != nil {
+				return nil, err
+			}
+		}
+		return user, nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(req.Password)); err != nil {
-		return nil, errors.New("invalid credentials")
-	}
+	return s.startSession(user, client)
+}
 
-	token, err := s.generateToken(user.ID)
+// guarded runs a login attempt through the login guard. A missing 2FA
+// code or a disabled account does not count as a failure.
+func (s *UserService) guarded(ip, username string, attempt func() (*models.User, error)) (*models.User, error) {
+	if err := s.guard.Admit(ip, username); err != nil {
+		return nil, err
+	}
+	user, err := attempt()
 	if err != nil {
+		if !errors.Is(err, ErrTOTPRequired) && !errors.Is(err, ErrUserDisabled) {
+			s.guard.Fail(ip, username)
+		}
 		return nil, err
 	}
-
-	return &models.AuthResponse{Token: token, User: user}, nil
+	s.guard.Succeed(username)
+	return user, nil
 }
 
+// dummyPasswordHash is compared against for unknown usernames so they take
+// as long to reject as a wrong password
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("dns-mng-unknown-user"), bcrypt.DefaultCost)
+	return hash
+})
+
 // VerifyCredentials checks username/password against existing users.
-// Unlike Login, it will NOT auto-register a new user.
+// Disabled users are rejected with ErrUserDisabled.
 func (s *UserService) VerifyCredentials(username, password string) (*models.User, error) {
 	var user models.User
 	var passwordHash string
+	var disabled, totpEnabled int
 
 	err := database.DB.QueryRow(
-		"SELECT id, username, password_hash, created_at FROM users WHERE username = ?",
+		"SELECT id, username, password_hash, role, disabled, totp_enabled, created_at FROM users WHERE username = ?",
 		username,
-	).Scan(&user.ID, &user.Username, &passwordHash, &user.CreatedAt)
+	).Scan(&user.ID, &user.Username, &passwordHash, &user.Role, &disabled, &totpEnabled, &user.CreatedAt)
 	if err == sql.ErrNoRows {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(password))
 		return nil, errors.New("invalid credentials")
 	}
 	if err != nil {
@@ -113,35 +143,22 @@ func (s *UserService) VerifyCredentials(username, password string) (*models.User
 	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)); err != nil {
 		return nil, errors.New("invalid credentials")
 	}
-	return &user, nil
-}
-
-func (s *UserService) generateToken(userID int64) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(7 * 24 * time.Hour).Unix(),
-		"iat":     time.Now().Unix(),
+	if disabled == 1 {
+		return nil, ErrUserDisabled
 	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.cfg.JWTSecret))
+	user.TOTPEnabled = totpEnabled == 1
+	return &user, nil
 }
 
 func (s *UserService) GetUser(userID int64) (*models.User, error) {
-	var user models.User
-	err := database.DB.QueryRow(
-		"SELECT id, username, created_at FROM users WHERE id = ?",
+	return scanUser(database.DB.QueryRow(
+		"SELECT "+userColumns+" FROM users WHERE id = ?",
 		userID,
-	).Scan(&user.ID, &user.Username, &user.CreatedAt)
-
-	if err != nil {
-		return nil, err
-	}
-
-	return &user, nil
+	))
 }
 
-func (s *UserService) UpdatePassword(userID int64, req *models.UpdatePasswordRequest) error {
+// UpdatePassword changes the password and signs out every other session
+func (s *UserService) UpdatePassword(userID, sessionID int64, req *models.UpdatePasswordRequest) error {
 	// Get current password hash
 	var currentHash string
 	err := database.DB.QueryRow(
@@ -169,6 +186,9 @@ func (s *UserService) UpdatePassword(userID int64, req *models.UpdatePasswordReq
 		"UPDATE users SET password_hash = ? WHERE id = ?",
 		string(newHash), userID,
 	)
+	if err != nil {
+		return err
+	}
 
-	return err
+	return s.RevokeSessions(userID, sessionID)
 }
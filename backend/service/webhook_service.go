@@ -0,0 +1,586 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"dns-mng/database"
+	"dns-mng/models"
+)
+
+// ErrInvalidWebhook is returned for malformed webhook configs
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+// webhookBackoff is the wait before each retry; a delivery is attempted
+// len(webhookBackoff)+1 times before it is marked failed.
+var webhookBackoff = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	2 * time.Hour,
+	6 * time.Hour,
+}
+
+// webhookLease keeps a delivery from being picked up by RetryDue while an
+// attempt is in flight
+const webhookLease = 2 * time.Minute
+
+const webhookTimeout = 10 * time.Second
+
+var webhookClient = newPublicHTTPClient(webhookTimeout, "")
+
+// WebhookService manages outbound webhooks and delivers change events to
+// them. Payloads are signed with HMAC-SHA256 over "<timestamp>.<body>"
+// using the webhook secret, sent in the X-DNS-Mng-Signature header.
+type WebhookService struct{}
+
+func NewWebhookService() *WebhookService {
+	return &WebhookService{}
+}
+
+const webhookColumns = `id, user_id, name, url, secret, events, domains, enabled, created_at, updated_at`
+
+func scanWebhook(row rowScanner) (*models.Webhook, error) {
+	var w models.Webhook
+	var events, domains string
+	var enabled int
+	if err := row.Scan(&w.ID, &w.UserID, &w.Name, &w.URL, &w.Secret, &events, &domains, &enabled, &w.CreatedAt, &w.UpdatedAt); err != nil {
+		return nil, err
+	}
+	w.Events = splitList(events)
+	w.Domains = splitList(domains)
+	w.Enabled = enabled == 1
+	var err error
+	if w.Secret, err = DecryptSecret(w.Secret); err != nil {
+		return nil, fmt.Errorf("webhook %d: %w", w.ID, err)
+	}
+	return &w, nil
+}
+
+func maskWebhook(w *models.Webhook) *models.Webhook {
+	masked := *w
+	masked.Secret = maskedSecret
+	return &masked
+}
+
+func (s *WebhookService) listWebhooks(userID int64) ([]*models.Webhook, error) {
+	rows, err := database.DB.Query(
+		`SELECT `+webhookColumns+` FROM webhooks WHERE user_id = ? ORDER BY id ASC`, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hooks []*models.Webhook
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, w)
+	}
+	return hooks, rows.Err()
+}
+
+func (s *WebhookService) getWebhook(userID, id int64) (*models.Webhook, error) {
+	w, err := scanWebhook(database.DB.QueryRow(
+		`SELECT `+webhookColumns+` FROM webhooks WHERE id = ? AND user_id = ?`, id, userID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return w, err
+}
+
+// List returns a user's webhooks with secrets masked
+func (s *WebhookService) List(userID int64) ([]models.Webhook, error) {
+	hooks, err := s.listWebhooks(userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]models.Webhook, 0, len(hooks))
+	for _, w := range hooks {
+		out = append(out, *maskWebhook(w))
+	}
+	return out, nil
+}
+
+// Create adds a webhook. The secret is only returned in full here.
+func (s *WebhookService) Create(userID int64, req *models.CreateWebhookRequest) (*models.Webhook, error) {
+	w := &models.Webhook{
+		Name:    strings.TrimSpace(req.Name),
+		URL:     strings.TrimSpace(req.URL),
+		Secret:  strings.TrimSpace(req.Secret),
+		Events:  req.Events,
+		Domains: req.Domains,
+		Enabled: req.Enabled == nil || *req.Enabled,
+	}
+	if w.Secret == "" {
+		w.Secret = generateRandomToken()
+	}
+	if err := validateWebhook(w); err != nil {
+		return nil, err
+	}
+	secret, err := EncryptSecret(w.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := database.DB.Exec(
+		`INSERT INTO webhooks (user_id, name, url, secret, events, domains, enabled) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		userID, w.Name, w.URL, secret, strings.Join(w.Events, ","), strings.Join(w.Domains, ","), boolToInt(w.Enabled),
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, _ := res.LastInsertId()
+	return s.getWebhook(userID, id)
+}
+
+// Update changes a webhook; nil request fields are kept. An empty or masked
+// secret keeps the stored one.
+func (s *WebhookService) Update(userID, id int64, req *models.UpdateWebhookRequest) (*models.Webhook, error) {
+	w, err := s.getWebhook(userID, id)
+	if err != nil || w == nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		w.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.URL != nil {
+		w.URL = strings.TrimSpace(*req.URL)
+	}
+	if req.Secret != nil {
+		if secret := strings.TrimSpace(*req.Secret); secret != "" && secret != maskedSecret {
+			w.Secret = secret
+		}
+	}
+	if req.Events != nil {
+		w.Events = req.Events
+	}
+	if req.Domains != nil {
+		w.Domains = req.Domains
+	}
+	if req.Enabled != nil {
+		w.Enabled = *req.Enabled
+	}
+	if err := validateWebhook(w); err != nil {
+		return nil, err
+	}
+	secret, err := EncryptSecret(w.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = database.DB.Exec(
+		`UPDATE webhooks SET name = ?, url = ?, secret = ?, events = ?, domains = ?, enabled = ?, updated_at = datetime('now')
+		 WHERE id = ? AND user_id = ?`,
+		w.Name, w.URL, secret, strings.Join(w.Events, ","), strings.Join(w.Domains, ","), boolToInt(w.Enabled), id, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return maskWebhook(w), nil
+}
+
+// Delete removes a webhook and its delivery log
+func (s *WebhookService) Delete(userID, id int64) (bool, error) {
+	res, err := database.DB.Exec(`DELETE FROM webhooks WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		return false, nil
+	}
+	_, err = database.DB.Exec(`DELETE FROM webhook_deliveries WHERE webhook_id = ?`, id)
+	return true, err
+}
+
+func validateWebhook(w *models.Webhook) error {
+	if w.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidWebhook)
+	}
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an http(s) URL", ErrInvalidWebhook)
+	}
+
+	known := map[string]bool{}
+	for _, e := range models.WebhookEvents {
+		known[e] = true
+	}
+	events := []string{}
+	for _, e := range w.Events {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+		if !known[e] {
+			return fmt.Errorf("%w: unknown event %s", ErrInvalidWebhook, e)
+		}
+		events = append(events, e)
+	}
+	w.Events = events
+
+	domains := []string{}
+	for _, d := range w.Domains {
+		if d = normalizeFQDN(d); d != "" {
+			domains = append(domains, d)
+		}
+	}
+	w.Domains = domains
+	return nil
+}
+
+// webhookMatches reports whether w subscribes to ev. A domain filter also
+// matches subdomains, so "example.com" covers events of "dev.example.com".
+func webhookMatches(w *models.Webhook, ev *models.WebhookEvent) bool {
+	if !w.Enabled || !subscribes(w.Events, ev.Event) {
+		return false
+	}
+	if len(w.Domains) == 0 {
+		return true
+	}
+	domain := normalizeFQDN(ev.Domain)
+	for _, d := range w.Domains {
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// Emit queues ev for every matching webhook of the user and attempts the
+// deliveries in the background. Failed attempts are retried by RetryDue.
+func (s *WebhookService) Emit(userID int64, ev *models.WebhookEvent) {
+	hooks, err := s.listWebhooks(userID)
+	if err != nil {
+		log.Printf("webhook: failed to list webhooks for user %d: %v", userID, err)
+		return
+	}
+
+	var matched []*models.Webhook
+	for _, w := range hooks {
+		if webhookMatches(w, ev) && !s.duplicateDomainEvent(w.ID, ev) {
+			matched = append(matched, w)
+		}
+	}
+	if len(matched) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(struct {
+		ID        string `json:"id"`
+		Timestamp string `json:"timestamp"`
+		*models.WebhookEvent
+	}{
+		ID:           generateRandomToken()[:32],
+		Timestamp:    time.Now().UTC().Format(time.RFC3339),
+		WebhookEvent: ev,
+	})
+	if err != nil {
+		log.Printf("webhook: failed to encode %s payload: %v", ev.Event, err)
+		return
+	}
+
+	for _, w := range matched {
+		id, err := s.queue(w.ID, userID, ev.Event, normalizeFQDN(ev.Domain), payload)
+		if err != nil {
+			log.Printf("webhook: failed to queue %s for webhook %d: %v", ev.Event, w.ID, err)
+			continue
+		}
+		go s.attempt(context.Background(), id)
+	}
+}
+
+// duplicateDomainEvent suppresses repeated domain.added/domain.removed
+// events: a domain missing from the provider is reported on every refresh
+// until its cache entry is deleted, but the webhook should see it once.
+func (s *WebhookService) duplicateDomainEvent(webhookID int64, ev *models.WebhookEvent) bool {
+	if ev.Event != models.WebhookEventDomainAdded && ev.Event != models.WebhookEventDomainRemoved {
+		return false
+	}
+	var last string
+	err := database.DB.QueryRow(
+		`SELECT event FROM webhook_deliveries
+		 WHERE webhook_id = ? AND domain = ? AND event IN (?, ?)
+		 ORDER BY id DESC LIMIT 1`,
+		webhookID, normalizeFQDN(ev.Domain), models.WebhookEventDomainAdded, models.WebhookEventDomainRemoved,
+	).Scan(&last)
+	return err == nil && last == ev.Event
+}
+
+// RetryDue re-attempts pending deliveries whose backoff has elapsed. Called
+// by the scheduler.
+func (s *WebhookService) RetryDue(ctx context.Context) {
+	rows, err := database.DB.Query(
+		`SELECT id FROM webhook_deliveries
+		 WHERE status = ? AND next_attempt_at <= datetime('now')
+		 ORDER BY id ASC LIMIT 100`,
+		models.WebhookDeliveryPending,
+	)
+	if err != nil {
+		log.Printf("webhook: failed to query due deliveries: %v", err)
+		return
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if rows.Scan(&id) == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		// Claim the delivery so a concurrent run does not send it twice
+		res, err := database.DB.Exec(
+			`UPDATE webhook_deliveries SET next_attempt_at = datetime('now', ?)
+			 WHERE id = ? AND status = ? AND next_attempt_at <= datetime('now')`,
+			sqliteOffset(webhookLease), id, models.WebhookDeliveryPending,
+		)
+		if err != nil {
+			continue
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			continue
+		}
+		s.attempt(ctx, id)
+	}
+}
+
+// attempt sends one delivery and records the outcome
+func (s *WebhookService) attempt(ctx context.Context, deliveryID int64) {
+	var webhookID int64
+	var event, payload string
+	var attempts int
+	err := database.DB.QueryRow(
+		`SELECT webhook_id, event, payload, attempts FROM webhook_deliveries WHERE id = ?`, deliveryID,
+	).Scan(&webhookID, &event, &payload, &attempts)
+	if err != nil {
+		log.Printf("webhook: delivery %d not found: %v", deliveryID, err)
+		return
+	}
+
+	var endpoint, secret string
+	var enabled int
+	err = database.DB.QueryRow(`SELECT url, secret, enabled FROM webhooks WHERE id = ?`, webhookID).Scan(&endpoint, &secret, &enabled)
+	if err != nil || enabled != 1 {
+		s.finish(deliveryID, attempts, models.WebhookDeliveryFailed, 0, "webhook deleted or disabled")
+		return
+	}
+	if secret, err = DecryptSecret(secret); err != nil {
+		s.finish(deliveryID, attempts, models.WebhookDeliveryFailed, 0, "webhook secret unreadable")
+		return
+	}
+
+	attempts++
+	status, err := sendWebhook(ctx, endpoint, secret, deliveryID, event, []byte(payload))
+	switch {
+	case err == nil:
+		s.finish(deliveryID, attempts, models.WebhookDeliverySuccess, status, "")
+	case attempts > len(webhookBackoff):
+		s.finish(deliveryID, attempts, models.WebhookDeliveryFailed, status, err.Error())
+	default:
+		_, dbErr := database.DB.Exec(
+			`UPDATE webhook_deliveries
+			 SET attempts = ?, response_status = ?, error = ?, next_attempt_at = datetime('now', ?)
+			 WHERE id = ?`,
+			attempts, status, err.Error(), sqliteOffset(webhookBackoff[attempts-1]), deliveryID,
+		)
+		if dbErr != nil {
+			log.Printf("webhook: failed to update delivery %d: %v", deliveryID, dbErr)
+		}
+	}
+}
+
+func (s *WebhookService) finish(deliveryID int64, attempts int, status string, responseStatus int, errMsg string) {
+	delivered := "NULL"
+	if status == models.WebhookDeliverySuccess {
+		delivered = "datetime('now')"
+	}
+	_, err := database.DB.Exec(
+		`UPDATE webhook_deliveries
+		 SET status = ?, attempts = ?, response_status = ?, error = ?, next_attempt_at = NULL, delivered_at = `+delivered+`
+		 WHERE id = ?`,
+		status, attempts, responseStatus, errMsg, deliveryID,
+	)
+	if err != nil {
+		log.Printf("webhook: failed to update delivery %d: %v", deliveryID, err)
+	}
+}
+
+// sendWebhook posts a signed payload, returning the HTTP status code
+func sendWebhook(ctx context.Context, endpoint, secret string, deliveryID int64, event string, payload []byte) (int, error) {
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dns-mng-webhook")
+	req.Header.Set("X-DNS-Mng-Event", event)
+	req.Header.Set("X-DNS-Mng-Delivery", strconv.FormatInt(deliveryID, 10))
+	req.Header.Set("X-DNS-Mng-Timestamp", ts)
+	req.Header.Set("X-DNS-Mng-Signature", "sha256="+WebhookSignature(secret, ts, payload))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp.StatusCode, nil
+}
+
+// WebhookSignature is the hex HMAC-SHA256 of "<timestamp>.<payload>".
+// Receivers recompute it with their copy of the secret.
+func WebhookSignature(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func sqliteOffset(d time.Duration) string {
+	return fmt.Sprintf("+%d seconds", int(d.Seconds()))
+}
+
+// Test queues a ping event for one webhook, enabled or not
+func (s *WebhookService) Test(userID, id int64) (bool, error) {
+	w, err := s.getWebhook(userID, id)
+	if err != nil || w == nil {
+		return false, err
+	}
+	payload, _ := json.Marshal(map[string]interface{}{
+		"id":        generateRandomToken()[:32],
+		"event":     "ping",
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	deliveryID, err := s.queue(w.ID, userID, "ping", "", payload)
+	if err != nil {
+		return true, err
+	}
+	// The ping is sent synchronously so the caller sees the result
+	status, err := sendWebhook(context.Background(), w.URL, w.Secret, deliveryID, "ping", payload)
+	if err != nil {
+		s.finish(deliveryID, 1, models.WebhookDeliveryFailed, status, err.Error())
+		return true, err
+	}
+	s.finish(deliveryID, 1, models.WebhookDeliverySuccess, status, "")
+	return true, nil
+}
+
+func (s *WebhookService) queue(webhookID, userID int64, event, domain string, payload []byte) (int64, error) {
+	res, err := database.DB.Exec(
+		`INSERT INTO webhook_deliveries (webhook_id, user_id, event, domain, payload, status, next_attempt_at)
+		 VALUES (?, ?, ?, ?, ?, ?, datetime('now', ?))`,
+		webhookID, userID, event, domain, string(payload), models.WebhookDeliveryPending, sqliteOffset(webhookLease),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// Redeliver queues a copy of an earlier delivery and sends it now
+func (s *WebhookService) Redeliver(userID, webhookID, deliveryID int64) (bool, error) {
+	var event, domain, payload string
+	err := database.DB.QueryRow(
+		`SELECT event, domain, payload FROM webhook_deliveries WHERE id = ? AND webhook_id = ? AND user_id = ?`,
+		deliveryID, webhookID, userID,
+	).Scan(&event, &domain, &payload)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	id, err := s.queue(webhookID, userID, event, domain, []byte(payload))
+	if err != nil {
+		return true, err
+	}
+	go s.attempt(context.Background(), id)
+	return true, nil
+}
+
+// ListDeliveries returns a webhook's delivery log, newest first
+func (s *WebhookService) ListDeliveries(userID, webhookID int64, page, pageSize int) (*models.WebhookDeliveryListResponse, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+	offset := (page - 1) * pageSize
+
+	var total int
+	err := database.DB.QueryRow(
+		`SELECT COUNT(*) FROM webhook_deliveries WHERE webhook_id = ? AND user_id = ?`,
+		webhookID, userID,
+	).Scan(&total)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.DB.Query(
+		`SELECT id, webhook_id, event, domain, payload, status, attempts, response_status, error, next_attempt_at, delivered_at, created_at
+		 FROM webhook_deliveries
+		 WHERE webhook_id = ? AND user_id = ?
+		 ORDER BY id DESC
+		 LIMIT ? OFFSET ?`,
+		webhookID, userID, pageSize, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var d models.WebhookDelivery
+		var nextAttempt, delivered sql.NullTime
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.Event, &d.Domain, &d.Payload, &d.Status, &d.Attempts,
+			&d.ResponseStatus, &d.Error, &nextAttempt, &delivered, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		if nextAttempt.Valid {
+			d.NextAttemptAt = &nextAttempt.Time
+		}
+		if delivered.Valid {
+			d.DeliveredAt = &delivered.Time
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	totalPages := (total + pageSize - 1) / pageSize
+	return &models.WebhookDeliveryListResponse{
+		Deliveries: deliveries,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	}, nil
+}
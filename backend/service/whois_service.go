@@ -43,10 +43,8 @@ func NewWHOISService() *WHOISService {
 	}
 }
 
-// GetConfig returns the user's WHOIS configuration, including the API key in
-// plaintext. The key belongs to the user themselves (same pattern as the DDNS
-// token: returned in clear text so the user can view/edit it). Returns
-// (nil, nil) when no row exists — the handler maps this to
+// GetConfig returns the user's WHOIS configuration with the API key masked.
+// Returns (nil, nil) when no row exists — the handler maps this to
 // `{"configured": false}`.
 func (s *WHOISService) GetConfig(userID int64) (*models.WHOISConfig, error) {
 	var cfg models.WHOISConfig
@@ -64,6 +62,7 @@ func (s *WHOISService) GetConfig(userID int64) (*models.WHOISConfig, error) {
 		return nil, err
 	}
 
+	cfg.APIKey = MaskSecret(cfg.APIKey)
 	return &cfg, nil
 }
 
@@ -85,39 +84,47 @@ func (s *WHOISService) getConfigWithKey(userID int64) (*models.WHOISConfig, erro
 		return nil, err
 	}
 
+	if cfg.APIKey, err = DecryptSecret(cfg.APIKey); err != nil {
+		return nil, err
+	}
 	return &cfg, nil
 }
 
 // UpsertConfig creates or updates the user's WHOIS configuration. When
-// req.APIKey is empty, the existing key is preserved (leave-blank-keep-current
-// contract, matching EmailService.UpsertEmailConfig). Returns the updated
-// config (including the API key in plaintext, same as GetConfig).
+// req.APIKey is empty or masked, the existing key is preserved
+// (leave-blank-keep-current contract, matching EmailService.UpsertEmailConfig).
+// Returns the updated config (API key masked, same as GetConfig).
 func (s *WHOISService) UpsertConfig(userID int64, req *models.UpdateWHOISConfigRequest) (*models.WHOISConfig, error) {
 	now := time.Now()
+	keepKey := isMaskedOrEmpty(req.APIKey)
+	apiKey, err := EncryptSecret(req.APIKey)
+	if err != nil {
+		return nil, err
+	}
 
 	// Check if config exists
 	var existingID int64
-	err := database.DB.QueryRow(`SELECT id FROM whois_config WHERE user_id = ?`, userID).Scan(&existingID)
+	err = database.DB.QueryRow(`SELECT id FROM whois_config WHERE user_id = ?`, userID).Scan(&existingID)
 
 	switch err {
 	case sql.ErrNoRows:
 		// Insert new config — an initial config must include an API key.
 		// An empty key would otherwise create a permanently unusable config.
-		if strings.TrimSpace(req.APIKey) == "" {
+		if keepKey {
 			return nil, ErrWHOISAPIKeyRequired
 		}
 		_, err = database.DB.Exec(
 			`INSERT INTO whois_config (user_id, api_key, created_at, updated_at)
 			 VALUES (?, ?, ?, ?)`,
-			userID, req.APIKey, now, now,
+			userID, apiKey, now, now,
 		)
 	case nil:
 		// Update existing config
-		if req.APIKey != "" {
+		if !keepKey {
 			// Update with new key
 			_, err = database.DB.Exec(
 				`UPDATE whois_config SET api_key = ?, updated_at = ? WHERE user_id = ?`,
-				req.APIKey, now, userID,
+				apiKey, now, userID,
 			)
 		} else {
 			// No changes requested — simply touch updated_at
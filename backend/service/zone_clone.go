@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"dns-mng/models"
+	"dns-mng/provider"
+)
+
+// ErrInvalidZoneClone is returned for clone requests that cannot be carried out
+var ErrInvalidZoneClone = errors.New("invalid zone clone")
+
+// CloneZone copies every record of a zone into another account, usually on
+// another provider. Records are translated to the target's conventions
+// (apex spelling, minimum TTL, apex CNAME support); records already present
+// in the target are left alone and nothing is ever deleted there.
+func (s *DNSService) CloneZone(ctx context.Context, userID, accountID int64, domainID string, req *models.ZoneCloneRequest) (*models.ZoneCloneResult, error) {
+	if req.TargetAccountID == accountID && (req.TargetDomainID == "" || req.TargetDomainID == domainID) {
+		return nil, fmt.Errorf("%w: source and target are the same zone", ErrInvalidZoneClone)
+	}
+
+	source, err := s.GetDomain(ctx, userID, accountID, domainID)
+	if err != nil {
+		return nil, err
+	}
+	records, err := s.ListRecords(ctx, userID, accountID, domainID)
+	if err != nil {
+		return nil, fmt.Errorf("list source records: %w", err)
+	}
+	targetAccount, p, err := s.accountProvider(userID, req.TargetAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	zone := normalizeFQDN(source.Name)
+	result := &models.ZoneCloneResult{
+		SourceAccountID: accountID,
+		SourceDomainID:  domainID,
+		TargetAccountID: targetAccount.ID,
+		Zone:            zone,
+		DryRun:          req.DryRun,
+		Records:         []models.ZoneCloneRecord{},
+	}
+
+	targetDomainID, err := s.cloneTargetZone(ctx, userID, targetAccount, p, zone, req, result)
+	if err != nil {
+		return nil, err
+	}
+	result.TargetDomainID = targetDomainID
+
+	var existing []models.Record
+	if targetDomainID != "" {
+		existing, err = s.ListRecords(ctx, userID, targetAccount.ID, targetDomainID)
+		if err != nil {
+			return nil, fmt.Errorf("list target records: %w", err)
+		}
+	}
+
+	minTTL, apexCNAME := provider.ConstraintsOf(p)
+	for _, src := range records {
+		line := models.ZoneCloneRecord{Source: src}
+		target, notes, skip := translateCloneRecord(src, p, minTTL, apexCNAME)
+		line.Notes = notes
+		switch {
+		case skip:
+			line.Status = models.ZoneCloneSkipped
+			result.Skipped++
+		case cloneRecordExists(target, existing):
+			line.Target = &target
+			line.Status = models.ZoneCloneExists
+			result.Existing++
+		case req.DryRun:
+			line.Target = &target
+			line.Status = models.ZoneClonePlanned
+		default:
+			line.Target = &target
+			if err := s.createCloneRecord(ctx, userID, targetAccount.ID, targetDomainID, &target, &line); err != nil {
+				line.Status = models.ZoneCloneFailed
+				line.Error = err.Error()
+				result.Failed++
+			} else {
+				line.Status = models.ZoneCloneCreated
+				result.Created++
+			}
+		}
+		result.Records = append(result.Records, line)
+	}
+
+	if req.Verify && !req.DryRun && targetDomainID != "" {
+		result.VerifyServers = s.cloneVerifyServers(ctx, targetAccount, p, targetDomainID)
+		for i := range result.Records {
+			line := &result.Records[i]
+			if line.Status == models.ZoneCloneCreated || line.Status == models.ZoneCloneExists {
+				verifyCloneRecord(ctx, result.VerifyServers, zone, line)
+			}
+		}
+	}
+	return result, nil
+}
+
+// cloneTargetZone finds the target zone by ID or name, creating it when asked
+func (s *DNSService) cloneTargetZone(ctx context.Context, userID int64, account *models.Account, p provider.DNSProvider, zone string, req *models.ZoneCloneRequest, result *models.ZoneCloneResult) (string, error) {
+	if req.TargetDomainID != "" {
+		return req.TargetDomainID, nil
+	}
+
+	domains, err := s.ListDomains(ctx, userID, account.ID)
+	if err != nil {
+		return "", fmt.Errorf("list target zones: %w", err)
+	}
+	for _, d := range domains {
+		if normalizeFQDN(d.Name) == zone {
+			return d.ID, nil
+		}
+	}
+
+	if !req.CreateZone {
+		return "", fmt.Errorf("%w: zone %s not found in target account, set create_zone to create it", ErrInvalidZoneClone, zone)
+	}
+	if _, ok := p.(provider.ZoneManager); !ok {
+		return "", fmt.Errorf("%w: %s cannot create zones, add %s there first", ErrInvalidZoneClone, p.DisplayName(), zone)
+	}
+	result.ZoneCreated = true
+	if req.DryRun {
+		return "", nil
+	}
+	created, err := s.CreateZone(ctx, userID, account.ID, zone)
+	if err != nil {
+		return "", fmt.Errorf("create target zone: %w", err)
+	}
+	return created.ID, nil
+}
+
+// translateCloneRecord adapts a source record to the target provider.
+// skip is set when the record cannot (or should not) be copied.
+func translateCloneRecord(src models.Record, p provider.DNSProvider, minTTL int, apexCNAME bool) (models.Record, []string, bool) {
+	var notes []string
+	rtype := strings.ToUpper(src.RecordType)
+	apex := zoneOwner(src.NodeName) == "@"
+
+	if zoneFileSkipTypes[rtype] {
+		return models.Record{}, []string{rtype + " records are managed by the provider"}, true
+	}
+	if rtype == "NS" && apex {
+		return models.Record{}, []string{"apex NS records are assigned by the target provider"}, true
+	}
+	if rtype == "CNAME" && apex && !apexCNAME {
+		return models.Record{}, []string{fmt.Sprintf("%s does not support a CNAME at the zone apex; replace it with A/AAAA records", p.DisplayName())}, true
+	}
+
+	target := models.Record{
+		NodeName:   src.NodeName,
+		RecordType: rtype,
+		TTL:        src.TTL,
+		State:      src.State,
+		Content:    src.Content,
+		Priority:   src.Priority,
+	}
+	if apex {
+		target.NodeName = ""
+	}
+	if target.TTL <= 0 {
+		target.TTL = p.DefaultTTL()
+	} else if target.TTL < minTTL {
+		notes = append(notes, fmt.Sprintf("ttl raised from %d to %d, the minimum of %s", target.TTL, minTTL, p.DisplayName()))
+		target.TTL = minTTL
+	}
+	if proxied, _ := src.Raw["proxied"].(bool); proxied {
+		if _, ok := p.(provider.ProxiedRecordSetter); ok {
+			target.Raw = map[string]interface{}{"proxied": true}
+		} else {
+			notes = append(notes, "proxied flag dropped, not supported by "+p.DisplayName())
+		}
+	}
+	if !src.State {
+		notes = append(notes, "copied disabled")
+	}
+	return target, notes, false
+}
+
+func cloneRecordExists(target models.Record, existing []models.Record) bool {
+	for _, r := range existing {
+		if sameNodeName(r.NodeName, target.NodeName) &&
+			strings.EqualFold(r.RecordType, target.RecordType) &&
+			zoneContentKey(r) == zoneContentKey(target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *DNSService) createCloneRecord(ctx context.Context, userID, accountID int64, domainID string, target *models.Record, line *models.ZoneCloneRecord) error {
+	state := target.State
+	created, err := s.CreateRecord(ctx, userID, accountID, domainID, &models.CreateRecordRequest{
+		NodeName:   target.NodeName,
+		RecordType: target.RecordType,
+		TTL:        target.TTL,
+		State:      &state,
+		Content:    target.Content,
+		Priority:   target.Priority,
+	})
+	if err != nil {
+		return err
+	}
+	if proxied, _ := target.Raw["proxied"].(bool); proxied && created != nil && created.ID != "" {
+		if _, err := s.SetRecordProxied(ctx, userID, accountID, domainID, created.ID, true); err != nil {
+			line.Notes = append(line.Notes, "record created but enabling proxy failed: "+err.Error())
+		}
+	}
+	if created != nil {
+		target.ID = created.ID
+	}
+	return nil
+}
+
+// cloneVerifyServers prefers the target zone's own nameservers so records
+// can be checked before the domain's delegation is switched over.
+func (s *DNSService) cloneVerifyServers(ctx context.Context, account *models.Account, p provider.DNSProvider, domainID string) []string {
+	nr, ok := p.(provider.NameserverReporter)
+	if !ok {
+		return PublicDNSServers
+	}
+	var hosts []string
+	err := provider.Do(ctx, p, account.ID, provider.RetrySafe, func(ctx context.Context) (err error) {
+		hosts, err = nr.GetNameservers(ctx, account.APIKey, domainID)
+		return err
+	})
+	if err != nil || len(hosts) == 0 {
+		return PublicDNSServers
+	}
+
+	var servers []string
+	for _, host := range hosts {
+		addrs, err := net.DefaultResolver.LookupHost(ctx, strings.TrimSuffix(host, "."))
+		if err != nil || len(addrs) == 0 {
+			continue
+		}
+		servers = append(servers, net.JoinHostPort(addrs[0], "53"))
+	}
+	if len(servers) == 0 {
+		return PublicDNSServers
+	}
+	return servers
+}
+
+func verifyCloneRecord(ctx context.Context, servers []string, zone string, line *models.ZoneCloneRecord) {
+	target := line.Target
+	if !target.State {
+		return
+	}
+	if proxied, _ := target.Raw["proxied"].(bool); proxied {
+		line.VerifyMessage = "proxied records resolve to the CDN, not verified"
+		return
+	}
+	fqdn := zone
+	if zoneOwner(target.NodeName) != "@" {
+		fqdn = target.NodeName + "." + zone
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	values, server, err := LookupDNS(ctx, servers, fqdn, target.RecordType)
+	verified := false
+	switch {
+	case errors.Is(err, ErrUnsupportedLookupType):
+		line.VerifyMessage = "verification not supported for " + target.RecordType
+		return
+	case err != nil:
+		line.VerifyMessage = "DNS query failed: " + err.Error()
+	case len(values) == 0:
+		line.VerifyMessage = "No DNS records found"
+	default:
+		expected := NormalizeDNSValue(zoneContentKey(*target))
+		for _, v := range values {
+			if NormalizeDNSValue(v) == expected {
+				verified = true
+				break
+			}
+		}
+		if verified {
+			line.VerifyMessage = "DNS record matches expected value (" + server + ")"
+		} else {
+			line.VerifyMessage = "DNS record does not match expected value (" + server + ")"
+		}
+	}
+	line.Verified = &verified
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+
+	"dns-mng/models"
+)
+
+// ExportZoneFile renders a domain's live records as a BIND zone file
+func (s *DNSService) ExportZoneFile(ctx context.Context, userID, accountID int64, domainID string) (string, []byte, error) {
+	domain, err := s.GetDomain(ctx, userID, accountID, domainID)
+	if err != nil {
+		return "", nil, err
+	}
+	records, err := s.ListRecords(ctx, userID, accountID, domainID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := WriteZoneFile(&buf, domain.Name, records); err != nil {
+		return "", nil, err
+	}
+	return normalizeFQDN(domain.Name), buf.Bytes(), nil
+}
+
+// ImportZoneFile diffs a zone file against the domain's current records and,
+// unless it is a dry run, applies the deletes, updates and creates through
+// the account's provider. Apex NS records are left to the provider, and
+// disabled records are never deleted.
+func (s *DNSService) ImportZoneFile(ctx context.Context, userID, accountID int64, domainID string, req *models.ZoneImportRequest) (*models.ZoneImportResult, error) {
+	domain, err := s.GetDomain(ctx, userID, accountID, domainID)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := ParseZoneFile(strings.NewReader(req.Content), domain.Name)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.ListRecords(ctx, userID, accountID, domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := planZoneImport(parsed, current)
+	result.Zone = normalizeFQDN(domain.Name)
+	result.DryRun = req.DryRun == nil || *req.DryRun
+	if result.DryRun {
+		return result, nil
+	}
+
+	// Deletes first so CNAMEs and replacements do not collide
+	for _, action := range []string{models.ZoneChangeDelete, models.ZoneChangeUpdate, models.ZoneChangeCreate} {
+		for i := range result.Changes {
+			ch := &result.Changes[i]
+			if ch.Action != action {
+				continue
+			}
+			if err := s.applyZoneChange(ctx, userID, accountID, domainID, ch); err != nil {
+				ch.Error = err.Error()
+				result.Failed++
+				continue
+			}
+			result.Applied++
+		}
+	}
+	return result, nil
+}
+
+func (s *DNSService) applyZoneChange(ctx context.Context, userID, accountID int64, domainID string, ch *models.ZoneChange) error {
+	switch ch.Action {
+	case models.ZoneChangeDelete:
+		return s.DeleteRecord(ctx, userID, accountID, domainID, ch.Current.ID)
+	case models.ZoneChangeUpdate:
+		r := ch.Record
+		state := true
+		_, err := s.UpdateRecord(ctx, userID, accountID, domainID, ch.Current.ID, &models.UpdateRecordRequest{
+			NodeName: r.NodeName, RecordType: r.RecordType, TTL: r.TTL, State: &state, Content: r.Content, Priority: r.Priority,
+		})
+		return err
+	default:
+		r := ch.Record
+		state := true
+		_, err := s.CreateRecord(ctx, userID, accountID, domainID, &models.CreateRecordRequest{
+			NodeName: r.NodeName, RecordType: r.RecordType, TTL: r.TTL, State: &state, Content: r.Content, Priority: r.Priority,
+		})
+		return err
+	}
+}
+
+// planZoneImport matches parsed and current records per (name, type):
+// identical content is kept (or updated if TTL/priority differ), leftovers
+// are paired up as updates, and the rest become creates or deletes.
+func planZoneImport(parsed, current []models.Record) *models.ZoneImportResult {
+	result := &models.ZoneImportResult{Changes: []models.ZoneChange{}, Ignored: []models.Record{}}
+
+	type group struct {
+		want []models.Record
+		have []models.Record
+	}
+	groups := make(map[string]*group)
+	var order []string
+	groupOf := func(r models.Record) *group {
+		key := zoneOwner(strings.ToLower(r.NodeName)) + " " + strings.ToUpper(r.RecordType)
+		g, ok := groups[key]
+		if !ok {
+			g = &group{}
+			groups[key] = g
+			order = append(order, key)
+		}
+		return g
+	}
+	isApexNS := func(r models.Record) bool {
+		return strings.EqualFold(r.RecordType, "NS") && zoneOwner(r.NodeName) == "@"
+	}
+
+	for _, r := range parsed {
+		if isApexNS(r) {
+			result.Ignored = append(result.Ignored, r)
+			continue
+		}
+		g := groupOf(r)
+		g.want = append(g.want, r)
+	}
+	for _, r := range current {
+		if isApexNS(r) || zoneFileSkipTypes[strings.ToUpper(r.RecordType)] {
+			continue
+		}
+		g := groupOf(r)
+		g.have = append(g.have, r)
+	}
+
+	for _, key := range order {
+		g := groups[key]
+		used := make([]bool, len(g.have))
+		var pending []models.Record
+		for _, w := range g.want {
+			matched := false
+			for i, h := range g.have {
+				if used[i] || zoneContentKey(w) != zoneContentKey(h) {
+					continue
+				}
+				used[i], matched = true, true
+				if zoneRecordSettled(w, h) {
+					result.Unchanged++
+				} else {
+					result.Changes = append(result.Changes, zoneUpdate(w, h))
+				}
+				break
+			}
+			if !matched {
+				pending = append(pending, w)
+			}
+		}
+		for _, w := range pending {
+			paired := false
+			for i, h := range g.have {
+				if used[i] {
+					continue
+				}
+				used[i], paired = true, true
+				result.Changes = append(result.Changes, zoneUpdate(w, h))
+				break
+			}
+			if !paired {
+				w := w
+				result.Changes = append(result.Changes, models.ZoneChange{Action: models.ZoneChangeCreate, Record: &w})
+			}
+		}
+		for i, h := range g.have {
+			if used[i] || !h.State {
+				continue
+			}
+			h := h
+			result.Changes = append(result.Changes, models.ZoneChange{Action: models.ZoneChangeDelete, Current: &h})
+		}
+	}
+	return result
+}
+
+func zoneUpdate(want, have models.Record) models.ZoneChange {
+	// Keep the provider's spelling of the node name and its TTL when the
+	// zone file did not set one
+	want.NodeName = have.NodeName
+	if want.TTL == 0 {
+		want.TTL = have.TTL
+	}
+	return models.ZoneChange{Action: models.ZoneChangeUpdate, Record: &want, Current: &have}
+}
+
+// zoneRecordSettled reports whether a content-matched record needs no update
+func zoneRecordSettled(want, have models.Record) bool {
+	if !have.State {
+		return false
+	}
+	if want.TTL != 0 && want.TTL != have.TTL {
+		return false
+	}
+	switch strings.ToUpper(want.RecordType) {
+	case "MX", "SRV":
+		return want.Priority == have.Priority
+	}
+	return true
+}
+
+// zoneContentKey normalizes record content so provider formatting quirks
+// (trailing dots, case, quoted TXT, IPv6 spelling) do not show as changes.
+func zoneContentKey(r models.Record) string {
+	rtype := strings.ToUpper(r.RecordType)
+	content := strings.TrimSpace(r.Content)
+	switch {
+	case rtype == "A" || rtype == "AAAA":
+		if ip := net.ParseIP(content); ip != nil {
+			return ip.String()
+		}
+	case rtype == "TXT" || rtype == "SPF":
+		if strings.HasPrefix(content, `"`) && strings.HasSuffix(content, `"`) && len(content) > 1 {
+			if entries, err := zoneFileEntries(strings.NewReader(content)); err == nil && len(entries) == 1 {
+				var sb strings.Builder
+				for _, t := range entries[0].tokens {
+					sb.WriteString(t.text)
+				}
+				return sb.String()
+			}
+		}
+	case rtype == "SRV":
+		fields := strings.Fields(content)
+		if n := len(fields); n > 0 {
+			fields[n-1] = normalizeFQDN(fields[n-1])
+		}
+		return strings.Join(fields, " ")
+	case hostnameRecordTypes[rtype]:
+		return normalizeFQDN(content)
+	}
+	return content
+}
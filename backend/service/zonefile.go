@@ -0,0 +1,384 @@
+package service
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"dns-mng/models"
+)
+
+// ErrInvalidZoneFile is returned when a zone file cannot be parsed
+var ErrInvalidZoneFile = errors.New("invalid zone file")
+
+// zoneFileSkipTypes are managed by the provider and never exported/imported
+var zoneFileSkipTypes = map[string]bool{"SOA": true}
+
+// hostnameRecordTypes carry a domain name as (the tail of) their content.
+// In models.Record it is stored fully qualified without the trailing dot.
+var hostnameRecordTypes = map[string]bool{"CNAME": true, "NS": true, "MX": true, "SRV": true, "PTR": true}
+
+// WriteZoneFile renders records as a BIND zone file (RFC 1035 master file).
+// Disabled records are written as comments since the format has no such flag.
+func WriteZoneFile(w io.Writer, zone string, records []models.Record) error {
+	zone = normalizeFQDN(zone)
+	sorted := make([]models.Record, len(records))
+	copy(sorted, records)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := zoneOwner(sorted[i].NodeName), zoneOwner(sorted[j].NodeName)
+		if a != b {
+			if a == "@" || b == "@" {
+				return a == "@"
+			}
+			return a < b
+		}
+		return sorted[i].RecordType < sorted[j].RecordType
+	})
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "; Zone file for %s, exported by dns-mng at %s\n", zone, time.Now().UTC().Format(time.RFC3339))
+	fmt.Fprintf(bw, "$ORIGIN %s.\n\n", zone)
+	for _, r := range sorted {
+		rtype := strings.ToUpper(r.RecordType)
+		if zoneFileSkipTypes[rtype] {
+			continue
+		}
+		line := fmt.Sprintf("%s\t%d\tIN\t%s\t%s", zoneOwner(r.NodeName), r.TTL, rtype, zoneRData(r))
+		if !r.State {
+			line = "; [disabled] " + line
+		}
+		fmt.Fprintln(bw, line)
+	}
+	return bw.Flush()
+}
+
+func zoneOwner(nodeName string) string {
+	n := strings.TrimSuffix(strings.TrimSpace(nodeName), ".")
+	if n == "" {
+		return "@"
+	}
+	return n
+}
+
+func zoneRData(r models.Record) string {
+	rtype := strings.ToUpper(r.RecordType)
+	content := strings.TrimSpace(r.Content)
+	switch rtype {
+	case "TXT", "SPF":
+		return quoteTXT(content)
+	case "MX":
+		return fmt.Sprintf("%d %s", r.Priority, absoluteName(content))
+	case "SRV":
+		// content is "weight port target"
+		fields := strings.Fields(content)
+		if len(fields) == 3 {
+			fields[2] = absoluteName(fields[2])
+		}
+		return fmt.Sprintf("%d %s", r.Priority, strings.Join(fields, " "))
+	case "CNAME", "NS", "PTR":
+		return absoluteName(content)
+	}
+	return content
+}
+
+func absoluteName(name string) string {
+	if name == "" || name == "." || strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+// quoteTXT quotes TXT content, splitting it into 255-byte strings. Content
+// already in quoted form (as some providers return it) is kept as is.
+func quoteTXT(content string) string {
+	if strings.HasPrefix(content, `"`) && strings.HasSuffix(content, `"`) && len(content) > 1 {
+		return content
+	}
+	var parts []string
+	for len(content) > 255 {
+		parts = append(parts, content[:255])
+		content = content[255:]
+	}
+	parts = append(parts, content)
+	for i, p := range parts {
+		p = strings.ReplaceAll(p, `\`, `\\`)
+		parts[i] = `"` + strings.ReplaceAll(p, `"`, `\"`) + `"`
+	}
+	return strings.Join(parts, " ")
+}
+
+// ParseZoneFile parses a BIND zone file for zone. Supported: $ORIGIN, $TTL,
+// parentheses, comments, quoted strings, inherited owners and TTL units.
+// SOA records are skipped. Owner names are returned relative to zone, with
+// "" for the apex; hostname targets are fully qualified without the dot.
+func ParseZoneFile(r io.Reader, zone string) ([]models.Record, error) {
+	zone = normalizeFQDN(zone)
+	origin := zone
+	defaultTTL := 0
+	lastOwner := ""
+	haveOwner := false
+
+	entries, err := zoneFileEntries(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []models.Record
+	for _, e := range entries {
+		toks := e.tokens
+		if !e.indented && strings.HasPrefix(toks[0].text, "$") {
+			switch strings.ToUpper(toks[0].text) {
+			case "$ORIGIN":
+				if len(toks) < 2 {
+					return nil, zoneFileError(e.line, "$ORIGIN without name")
+				}
+				origin = expandName(toks[1].text, origin)
+			case "$TTL":
+				if len(toks) < 2 {
+					return nil, zoneFileError(e.line, "$TTL without value")
+				}
+				ttl, err := parseZoneTTL(toks[1].text)
+				if err != nil {
+					return nil, zoneFileError(e.line, err.Error())
+				}
+				defaultTTL = ttl
+			default:
+				return nil, zoneFileError(e.line, "unsupported directive "+toks[0].text)
+			}
+			continue
+		}
+
+		i := 0
+		owner := lastOwner
+		if !e.indented {
+			owner = expandName(toks[0].text, origin)
+			i++
+		} else if !haveOwner {
+			return nil, zoneFileError(e.line, "record without owner name")
+		}
+		lastOwner, haveOwner = owner, true
+
+		ttl := defaultTTL
+		for n := 0; n < 2 && i < len(toks); n++ {
+			t := toks[i].text
+			if strings.EqualFold(t, "IN") {
+				i++
+			} else if v, err := parseZoneTTL(t); err == nil && !toks[i].quoted {
+				ttl = v
+				i++
+			}
+		}
+		if i >= len(toks) {
+			return nil, zoneFileError(e.line, "missing record type")
+		}
+		rtype := strings.ToUpper(toks[i].text)
+		rdata := toks[i+1:]
+		if zoneFileSkipTypes[rtype] {
+			continue
+		}
+		if len(rdata) == 0 {
+			return nil, zoneFileError(e.line, "missing data for "+rtype)
+		}
+
+		node, ok := relativeName(owner, zone)
+		if !ok {
+			return nil, zoneFileError(e.line, fmt.Sprintf("%s is outside zone %s", owner, zone))
+		}
+		rec := models.Record{NodeName: node, RecordType: rtype, TTL: ttl, State: true}
+		if err := setZoneRData(&rec, rdata, origin); err != nil {
+			return nil, zoneFileError(e.line, err.Error())
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+func setZoneRData(rec *models.Record, rdata []zoneToken, origin string) error {
+	texts := make([]string, len(rdata))
+	for i, t := range rdata {
+		texts[i] = t.text
+	}
+	switch rec.RecordType {
+	case "TXT", "SPF":
+		rec.Content = strings.Join(texts, "")
+	case "MX":
+		if len(texts) != 2 {
+			return fmt.Errorf("MX needs preference and exchange")
+		}
+		p, err := strconv.Atoi(texts[0])
+		if err != nil {
+			return fmt.Errorf("invalid MX preference %s", texts[0])
+		}
+		rec.Priority = p
+		rec.Content = expandName(texts[1], origin)
+	case "SRV":
+		if len(texts) != 4 {
+			return fmt.Errorf("SRV needs priority, weight, port and target")
+		}
+		p, err := strconv.Atoi(texts[0])
+		if err != nil {
+			return fmt.Errorf("invalid SRV priority %s", texts[0])
+		}
+		rec.Priority = p
+		rec.Content = strings.Join([]string{texts[1], texts[2], expandName(texts[3], origin)}, " ")
+	case "CNAME", "NS", "PTR":
+		if len(texts) != 1 {
+			return fmt.Errorf("%s needs exactly one target", rec.RecordType)
+		}
+		rec.Content = expandName(texts[0], origin)
+	default:
+		rec.Content = strings.Join(texts, " ")
+	}
+	return nil
+}
+
+// expandName makes a zone-file name fully qualified (without trailing dot)
+func expandName(name, origin string) string {
+	switch {
+	case name == "@":
+		return origin
+	case strings.HasSuffix(name, "."):
+		return strings.ToLower(strings.TrimSuffix(name, "."))
+	case origin == "":
+		return strings.ToLower(name)
+	}
+	return strings.ToLower(name) + "." + origin
+}
+
+// relativeName converts a fully qualified owner to a node name in zone
+func relativeName(fqdn, zone string) (string, bool) {
+	if fqdn == zone {
+		return "", true
+	}
+	if strings.HasSuffix(fqdn, "."+zone) {
+		return strings.TrimSuffix(fqdn, "."+zone), true
+	}
+	return "", false
+}
+
+// parseZoneTTL accepts plain seconds or BIND units such as 1h30m, 2d, 1w
+func parseZoneTTL(s string) (int, error) {
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 {
+		return n, nil
+	}
+	total, num, seen := 0, -1, false
+	for _, c := range strings.ToLower(s) {
+		if c >= '0' && c <= '9' {
+			if num < 0 {
+				num = 0
+			}
+			num = num*10 + int(c-'0')
+			continue
+		}
+		if num < 0 {
+			return 0, fmt.Errorf("invalid ttl %s", s)
+		}
+		unit := map[rune]int{'s': 1, 'm': 60, 'h': 3600, 'd': 86400, 'w': 604800}[c]
+		if unit == 0 {
+			return 0, fmt.Errorf("invalid ttl %s", s)
+		}
+		total += num * unit
+		num, seen = -1, true
+	}
+	if !seen || num >= 0 {
+		return 0, fmt.Errorf("invalid ttl %s", s)
+	}
+	return total, nil
+}
+
+type zoneToken struct {
+	text   string
+	quoted bool
+}
+
+type zoneEntry struct {
+	line     int
+	indented bool
+	tokens   []zoneToken
+}
+
+// zoneFileEntries splits a zone file into logical entries, joining lines
+// inside parentheses and dropping comments.
+func zoneFileEntries(r io.Reader) ([]zoneEntry, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var entries []zoneEntry
+	var cur *zoneEntry
+	depth := 0
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if depth == 0 {
+			cur = &zoneEntry{line: lineNo, indented: len(line) > 0 && (line[0] == ' ' || line[0] == '\t')}
+		}
+
+		for i := 0; i < len(line); {
+			c := line[i]
+			switch {
+			case c == ';':
+				i = len(line)
+			case c == ' ' || c == '\t' || c == '\r':
+				i++
+			case c == '(':
+				depth++
+				i++
+			case c == ')':
+				if depth == 0 {
+					return nil, zoneFileError(lineNo, "unbalanced parenthesis")
+				}
+				depth--
+				i++
+			case c == '"':
+				var sb strings.Builder
+				i++
+				closed := false
+				for i < len(line) {
+					if line[i] == '\\' && i+1 < len(line) {
+						sb.WriteByte(line[i+1])
+						i += 2
+						continue
+					}
+					if line[i] == '"' {
+						closed = true
+						i++
+						break
+					}
+					sb.WriteByte(line[i])
+					i++
+				}
+				if !closed {
+					return nil, zoneFileError(lineNo, "unterminated string")
+				}
+				cur.tokens = append(cur.tokens, zoneToken{text: sb.String(), quoted: true})
+			default:
+				start := i
+				for i < len(line) && !strings.ContainsRune(" \t\r;()\"", rune(line[i])) {
+					i++
+				}
+				cur.tokens = append(cur.tokens, zoneToken{text: line[start:i]})
+			}
+		}
+
+		if depth == 0 && len(cur.tokens) > 0 {
+			entries = append(entries, *cur)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if depth != 0 {
+		return nil, zoneFileError(lineNo, "unbalanced parenthesis")
+	}
+	return entries, nil
+}
+
+func zoneFileError(line int, msg string) error {
+	return fmt.Errorf("%w: line %d: %s", ErrInvalidZoneFile, line, msg)
+}
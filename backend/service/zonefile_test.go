@@ -0,0 +1,205 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"dns-mng/models"
+)
+
+func TestParseZoneFile(t *testing.T) {
+	tests := []struct {
+		name string
+		zone string
+		want []models.Record
+	}{
+		{
+			name: "origin, ttl and owners",
+			zone: `$TTL 1h
+$ORIGIN example.com.
+@            IN A     192.0.2.1
+www      300 IN CNAME @
+mail.example.com. A   192.0.2.2
+$ORIGIN sub.example.com.
+host         IN A     192.0.2.3
+             IN AAAA  2001:db8::1
+`,
+			want: []models.Record{
+				{NodeName: "", RecordType: "A", TTL: 3600, State: true, Content: "192.0.2.1"},
+				{NodeName: "www", RecordType: "CNAME", TTL: 300, State: true, Content: "example.com"},
+				{NodeName: "mail", RecordType: "A", TTL: 3600, State: true, Content: "192.0.2.2"},
+				{NodeName: "host.sub", RecordType: "A", TTL: 3600, State: true, Content: "192.0.2.3"},
+				{NodeName: "host.sub", RecordType: "AAAA", TTL: 3600, State: true, Content: "2001:db8::1"},
+			},
+		},
+		{
+			name: "quoted txt",
+			zone: `@ 60 TXT "v=spf1 " "-all" ; split strings are joined
+quote 60 TXT "say \"hi\"; ok"
+`,
+			want: []models.Record{
+				{NodeName: "", RecordType: "TXT", TTL: 60, State: true, Content: "v=spf1 -all"},
+				{NodeName: "quote", RecordType: "TXT", TTL: 60, State: true, Content: `say "hi"; ok`},
+			},
+		},
+		{
+			name: "mx and srv priority",
+			zone: `@ 3600 IN MX 10 mail
+@ 3600 IN MX 20 backup.example.net.
+_sip._tcp 3600 IN SRV 5 20 5060 sip
+`,
+			want: []models.Record{
+				{NodeName: "", RecordType: "MX", TTL: 3600, State: true, Content: "mail.example.com", Priority: 10},
+				{NodeName: "", RecordType: "MX", TTL: 3600, State: true, Content: "backup.example.net", Priority: 20},
+				{NodeName: "_sip._tcp", RecordType: "SRV", TTL: 3600, State: true, Content: "20 5060 sip.example.com", Priority: 5},
+			},
+		},
+		{
+			name: "parentheses and soa",
+			zone: `@ IN SOA ns1.example.com. admin.example.com. (
+	2026010101 ; serial
+	3600 600 86400 300 )
+@ 1d IN NS ns1.example.com.
+`,
+			want: []models.Record{
+				{NodeName: "", RecordType: "NS", TTL: 86400, State: true, Content: "ns1.example.com"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseZoneFile(strings.NewReader(tt.zone), "example.com")
+			if err != nil {
+				t.Fatalf("ParseZoneFile: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseZoneFileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		zone string
+	}{
+		{"outside zone", "www.example.net. A 192.0.2.1"},
+		{"no owner", "  A 192.0.2.1"},
+		{"unbalanced parenthesis", "@ TXT ( \"a\""},
+		{"unterminated string", `@ TXT "abc`},
+		{"unsupported directive", "$INCLUDE other.zone"},
+		{"bad mx preference", "@ MX ten mail"},
+		{"srv missing fields", "_sip._tcp SRV 10 20 sip"},
+		{"bad ttl", "$TTL 1x"},
+		{"missing type", "www 300 IN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseZoneFile(strings.NewReader(tt.zone), "example.com")
+			if !errors.Is(err, ErrInvalidZoneFile) {
+				t.Errorf("error = %v, want ErrInvalidZoneFile", err)
+			}
+		})
+	}
+}
+
+func TestParseZoneTTL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+		ok   bool
+	}{
+		{"300", 300, true},
+		{"1h30m", 5400, true},
+		{"2d", 172800, true},
+		{"1W", 604800, true},
+		{"1h30", 0, false},
+		{"h", 0, false},
+		{"-5", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, err := parseZoneTTL(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parseZoneTTL(%q) = %d, %v; want %d, ok=%v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestZoneFileRoundtrip(t *testing.T) {
+	records := []models.Record{
+		{NodeName: "www", RecordType: "CNAME", TTL: 300, State: true, Content: "example.com"},
+		{NodeName: "", RecordType: "A", TTL: 600, State: true, Content: "192.0.2.1"},
+		{NodeName: "", RecordType: "MX", TTL: 3600, State: true, Content: "mail.example.com", Priority: 10},
+		{NodeName: "_sip._tcp", RecordType: "SRV", TTL: 3600, State: true, Content: "20 5060 sip.example.com", Priority: 5},
+		{NodeName: "txt", RecordType: "TXT", TTL: 60, State: true, Content: `a "quoted" \ value`},
+		{NodeName: "long", RecordType: "TXT", TTL: 60, State: true, Content: strings.Repeat("k", 300)},
+		{NodeName: "off", RecordType: "A", TTL: 60, State: false, Content: "192.0.2.9"},
+		{NodeName: "", RecordType: "SOA", TTL: 3600, State: true, Content: "ns1.example.com. admin.example.com. 1 2 3 4 5"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteZoneFile(&buf, "example.com.", records); err != nil {
+		t.Fatalf("WriteZoneFile: %v", err)
+	}
+	got, err := ParseZoneFile(&buf, "example.com")
+	if err != nil {
+		t.Fatalf("ParseZoneFile: %v\n%s", err, buf.String())
+	}
+
+	// Sorted with the apex first, disabled and SOA records left out
+	want := []models.Record{records[1], records[2], records[3], records[5], records[4], records[0]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestPlanZoneImport(t *testing.T) {
+	current := []models.Record{
+		{ID: "1", NodeName: "@", RecordType: "A", TTL: 600, State: true, Content: "192.0.2.1"},
+		{ID: "2", NodeName: "www", RecordType: "CNAME", TTL: 300, State: true, Content: "example.com."},
+		{ID: "3", NodeName: "txt", RecordType: "TXT", TTL: 60, State: true, Content: `"hello world"`},
+		{ID: "4", NodeName: "old", RecordType: "A", TTL: 60, State: true, Content: "192.0.2.4"},
+		{ID: "5", NodeName: "@", RecordType: "NS", TTL: 3600, State: true, Content: "ns1.provider.net"},
+		{ID: "6", NodeName: "@", RecordType: "MX", TTL: 3600, State: true, Content: "mail.example.com", Priority: 10},
+	}
+	parsed := []models.Record{
+		{NodeName: "", RecordType: "A", TTL: 600, State: true, Content: "192.0.2.1"},
+		{NodeName: "www", RecordType: "CNAME", TTL: 0, State: true, Content: "example.com"},
+		{NodeName: "txt", RecordType: "TXT", TTL: 60, State: true, Content: "hello world"},
+		{NodeName: "", RecordType: "NS", TTL: 3600, State: true, Content: "ns1.example.com"},
+		{NodeName: "", RecordType: "MX", TTL: 3600, State: true, Content: "mail.example.com", Priority: 20},
+		{NodeName: "new", RecordType: "A", TTL: 60, State: true, Content: "192.0.2.5"},
+	}
+
+	result := planZoneImport(parsed, current)
+	if result.Unchanged != 3 {
+		t.Errorf("unchanged = %d, want 3", result.Unchanged)
+	}
+	if len(result.Ignored) != 1 || result.Ignored[0].RecordType != "NS" {
+		t.Errorf("ignored = %+v, want the apex NS", result.Ignored)
+	}
+
+	actions := map[string]string{}
+	for _, ch := range result.Changes {
+		r := ch.Record
+		if r == nil {
+			r = ch.Current
+		}
+		actions[zoneOwner(r.NodeName)+" "+r.RecordType] = ch.Action
+	}
+	want := map[string]string{
+		"@ MX":  models.ZoneChangeUpdate,
+		"new A": models.ZoneChangeCreate,
+		"old A": models.ZoneChangeDelete,
+	}
+	if !reflect.DeepEqual(actions, want) {
+		t.Errorf("changes = %v, want %v", actions, want)
+	}
+}
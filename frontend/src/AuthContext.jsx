@@ -20,28 +20,34 @@ export const AuthProvider = ({ children }) => {
         setLoading(false);
     }, []);
 
-    const login = async (username, password) => {
-        const data = await api.login(username, password);
+    const login = async (username, password, totpCode) => {
+        const data = await api.login(username, password, totpCode);
         setToken(data.token);
         setUser(data.user);
         localStorage.setItem('token', data.token);
+        localStorage.setItem('refresh_token', data.refresh_token);
         localStorage.setItem('user', JSON.stringify(data.user));
         return data;
     };
 
-    const register = async (username, password) => {
-        const data = await api.register(username, password);
+    const register = async (username, password, inviteCode) => {
+        const data = await api.register(username, password, inviteCode);
         setToken(data.token);
         setUser(data.user);
         localStorage.setItem('token', data.token);
+        localStorage.setItem('refresh_token', data.refresh_token);
         localStorage.setItem('user', JSON.stringify(data.user));
         return data;
     };
 
     const logout = () => {
+        if (localStorage.getItem('token')) {
+            api.logout().catch(() => {});
+        }
         setToken(null);
         setUser(null);
         localStorage.removeItem('token');
+        localStorage.removeItem('refresh_token');
         localStorage.removeItem('user');
     };
 
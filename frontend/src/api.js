@@ -19,6 +19,43 @@ const getHeaders = () => {
     return headers;
 };
 
+// 并发请求共用同一次刷新，避免刷新令牌被轮换后其他请求失效
+let refreshing = null;
+
+const refreshAccessToken = async () => {
+    const refreshToken = localStorage.getItem('refresh_token');
+    if (!refreshToken) return false;
+    if (!refreshing) {
+        refreshing = window.fetch(`${API_BASE}/auth/refresh`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ refresh_token: refreshToken }),
+        }).then(async (response) => {
+            if (!response.ok) return false;
+            const data = await response.json();
+            localStorage.setItem('token', data.token);
+            localStorage.setItem('refresh_token', data.refresh_token);
+            return true;
+        }).catch(() => false).finally(() => {
+            refreshing = null;
+        });
+    }
+    return refreshing;
+};
+
+// 模块内的 fetch：访问令牌过期（401）时用刷新令牌换新后重试一次
+const fetch = async (url, options = {}) => {
+    const response = await window.fetch(url, options);
+    const authHeader = options.headers && options.headers['Authorization'];
+    if (response.status !== 401 || !authHeader || !(await refreshAccessToken())) {
+        return response;
+    }
+    return window.fetch(url, {
+        ...options,
+        headers: { ...options.headers, Authorization: `Bearer ${localStorage.getItem('token')}` },
+    });
+};
+
 const handleResponse = async (response) => {
     const contentType = response.headers.get('content-type');
     const isJson = contentType && contentType.includes('application/json');
@@ -27,34 +64,58 @@ const handleResponse = async (response) => {
     if (!response.ok) {
         if (response.status === 401) {
             localStorage.removeItem('token');
+            localStorage.removeItem('refresh_token');
             window.location.href = '/login';
         }
-        const error = (data && data.error) || response.statusText;
-        throw new Error(error);
+        const error = new Error((data && data.error) || response.statusText);
+        // Credential errors name the fields they concern
+        if (data && data.fields) error.fields = data.fields;
+        throw error;
     }
     return data;
 };
 
 export const api = {
     // Auth
-    login: async (username, password) => {
+    // 登录失败不走 handleResponse 的 401 跳转，以便登录页显示错误或继续输入两步验证码
+    login: async (username, password, totpCode = '') => {
         const response = await fetch(`${API_BASE}/auth/login`, {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
-            body: JSON.stringify({ username, password }),
+            body: JSON.stringify({ username, password, totp_code: totpCode }),
         });
-        return handleResponse(response);
+        const data = await response.json().catch(() => ({}));
+        if (!response.ok) {
+            const error = new Error(data.error || response.statusText);
+            error.totpRequired = !!data.totp_required;
+            throw error;
+        }
+        return data;
     },
 
-    register: async (username, password) => {
+    register: async (username, password, inviteCode = '') => {
         const response = await fetch(`${API_BASE}/auth/register`, {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
-            body: JSON.stringify({ username, password }),
+            body: JSON.stringify({ username, password, invite_code: inviteCode }),
         });
         return handleResponse(response);
     },
 
+    // 注销当前会话；失败也不影响前端清除登录状态
+    logout: async () => {
+        const response = await fetch(`${API_BASE}/auth/logout`, {
+            method: 'POST',
+            headers: getHeaders(),
+        });
+        return response.ok;
+    },
+
+    getRegistrationStatus: async () => {
+        const response = await fetch(`${API_BASE}/auth/registration`);
+        return handleResponse(response);
+    },
+
     // User Profile
     getProfile: async () => {
         const response = await fetch(`${API_BASE}/user/profile`, {
@@ -63,6 +124,30 @@ export const api = {
         return handleResponse(response);
     },
 
+    // Sessions
+    listSessions: async () => {
+        const response = await fetch(`${API_BASE}/user/sessions`, {
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    revokeSession: async (id) => {
+        const response = await fetch(`${API_BASE}/user/sessions/${id}`, {
+            method: 'DELETE',
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    revokeAllSessions: async () => {
+        const response = await fetch(`${API_BASE}/user/sessions/revoke-all`, {
+            method: 'POST',
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
     updatePassword: async (data) => {
         const response = await fetch(`${API_BASE}/user/password`, {
             method: 'PUT',
@@ -137,6 +222,34 @@ export const api = {
         return handleResponse(response);
     },
 
+    // Tests credentials before an account is saved
+    testAccountCredentials: async (data) => {
+        const response = await fetch(`${API_BASE}/accounts/test`, {
+            method: 'POST',
+            headers: getHeaders(),
+            body: JSON.stringify(data),
+        });
+        return handleResponse(response);
+    },
+
+    // Tests a saved account, or the replacement api_key when one is given
+    testAccount: async (id, data = {}) => {
+        const response = await fetch(`${API_BASE}/accounts/${id}/test`, {
+            method: 'POST',
+            headers: getHeaders(),
+            body: JSON.stringify(data),
+        });
+        return handleResponse(response);
+    },
+
+    // Reveals the API key of one account; the list only returns it masked
+    getAccountAPIKey: async (id) => {
+        const response = await fetch(`${API_BASE}/accounts/${id}/api-key`, {
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
     deleteAccount: async (id) => {
         const response = await fetch(`${API_BASE}/accounts/${id}`, {
             method: 'DELETE',
@@ -145,6 +258,14 @@ export const api = {
         return handleResponse(response);
     },
 
+    // Optional provider capabilities (zone management, proxied records, ...)
+    getAccountCapabilities: async (id) => {
+        const response = await fetch(`${API_BASE}/accounts/${id}/capabilities`, {
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
     // All Domains
     getAllDomains: async () => {
         const response = await fetch(`${API_BASE}/domains`, {
@@ -187,9 +308,80 @@ export const api = {
         return handleResponse(response);
     },
 
+    createZone: async (accountId, name) => {
+        const response = await fetch(`${API_BASE}/accounts/${accountId}/domains`, {
+            method: 'POST',
+            headers: getHeaders(),
+            body: JSON.stringify({ name }),
+        });
+        return handleResponse(response);
+    },
+
+    deleteZone: async (accountId, domainId) => {
+        const response = await fetch(`${API_BASE}/accounts/${accountId}/domains/${domainId}`, {
+            method: 'DELETE',
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    getNameservers: async (accountId, domainId) => {
+        const response = await fetch(`${API_BASE}/accounts/${accountId}/domains/${domainId}/nameservers`, {
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    renewDomain: async (accountId, domainId) => {
+        const response = await fetch(`${API_BASE}/accounts/${accountId}/domains/${domainId}/renew`, {
+            method: 'POST',
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
     // Records
-    getRecords: async (accountId, domainId) => {
-        const response = await fetch(`${API_BASE}/accounts/${accountId}/domains/${domainId}/records`, {
+    getRecords: async (accountId, domainId, refresh = false) => {
+        const query = refresh ? '?refresh=true' : '';
+        const response = await fetch(`${API_BASE}/accounts/${accountId}/domains/${domainId}/records${query}`, {
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    exportZoneFile: async (accountId, domainId) => {
+        const response = await fetch(`${API_BASE}/accounts/${accountId}/domains/${domainId}/zonefile`, {
+            headers: getHeaders(),
+        });
+        if (!response.ok) {
+            return handleResponse(response);
+        }
+        const disposition = response.headers.get('content-disposition') || '';
+        const filenameMatch = disposition.match(/filename="?([^";]+)"?/i);
+        const blob = await response.blob();
+        return { blob, filename: filenameMatch?.[1] || `${domainId}.zone` };
+    },
+
+    importZoneFile: async (accountId, domainId, content, dryRun = true) => {
+        const response = await fetch(`${API_BASE}/accounts/${accountId}/domains/${domainId}/zonefile/import`, {
+            method: 'POST',
+            headers: getHeaders(),
+            body: JSON.stringify({ content, dry_run: dryRun }),
+        });
+        return handleResponse(response);
+    },
+
+    cloneZone: async (accountId, domainId, data) => {
+        const response = await fetch(`${API_BASE}/accounts/${accountId}/domains/${domainId}/clone`, {
+            method: 'POST',
+            headers: getHeaders(),
+            body: JSON.stringify(data),
+        });
+        return handleResponse(response);
+    },
+
+    getRecordChanges: async (accountId, domainId, page = 1, pageSize = 20) => {
+        const response = await fetch(`${API_BASE}/accounts/${accountId}/domains/${domainId}/records/changes?page=${page}&page_size=${pageSize}`, {
             headers: getHeaders(),
         });
         return handleResponse(response);
@@ -221,6 +413,24 @@ export const api = {
         return handleResponse(response);
     },
 
+    batchCreateRecords: async (accountId, domainId, records) => {
+        const response = await fetch(`${API_BASE}/accounts/${accountId}/domains/${domainId}/records/batch`, {
+            method: 'POST',
+            headers: getHeaders(),
+            body: JSON.stringify({ records }),
+        });
+        return handleResponse(response);
+    },
+
+    setRecordProxied: async (accountId, domainId, recordId, proxied) => {
+        const response = await fetch(`${API_BASE}/accounts/${accountId}/domains/${domainId}/records/${recordId}/proxied`, {
+            method: 'PUT',
+            headers: getHeaders(),
+            body: JSON.stringify({ proxied }),
+        });
+        return handleResponse(response);
+    },
+
     // DNS Check
     checkDNS: async (data) => {
         const response = await fetch(`${API_BASE}/dns/check`, {
@@ -318,11 +528,340 @@ export const api = {
         return handleResponse(response);
     },
 
-    // Trigger manual scheduler check
-    triggerSchedulerCheck: async () => {
+    // Notification channels
+    getNotificationChannelTypes: async () => {
+        const response = await fetch(`${API_BASE}/notification-channel-types`, {
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    getNotificationChannels: async () => {
+        const response = await fetch(`${API_BASE}/notification-channels`, {
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    createNotificationChannel: async (data) => {
+        const response = await fetch(`${API_BASE}/notification-channels`, {
+            method: 'POST',
+            headers: getHeaders(),
+            body: JSON.stringify(data),
+        });
+        return handleResponse(response);
+    },
+
+    updateNotificationChannel: async (id, data) => {
+        const response = await fetch(`${API_BASE}/notification-channels/${id}`, {
+            method: 'PUT',
+            headers: getHeaders(),
+            body: JSON.stringify(data),
+        });
+        return handleResponse(response);
+    },
+
+    deleteNotificationChannel: async (id) => {
+        const response = await fetch(`${API_BASE}/notification-channels/${id}`, {
+            method: 'DELETE',
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    testNotificationChannel: async (id) => {
+        const response = await fetch(`${API_BASE}/notification-channels/${id}/test`, {
+            method: 'POST',
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    // Outbound webhooks
+    getWebhookEvents: async () => {
+        const response = await fetch(`${API_BASE}/webhook-events`, {
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    getWebhooks: async () => {
+        const response = await fetch(`${API_BASE}/webhooks`, {
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    createWebhook: async (data) => {
+        const response = await fetch(`${API_BASE}/webhooks`, {
+            method: 'POST',
+            headers: getHeaders(),
+            body: JSON.stringify(data),
+        });
+        return handleResponse(response);
+    },
+
+    updateWebhook: async (id, data) => {
+        const response = await fetch(`${API_BASE}/webhooks/${id}`, {
+            method: 'PUT',
+            headers: getHeaders(),
+            body: JSON.stringify(data),
+        });
+        return handleResponse(response);
+    },
+
+    deleteWebhook: async (id) => {
+        const response = await fetch(`${API_BASE}/webhooks/${id}`, {
+            method: 'DELETE',
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    testWebhook: async (id) => {
+        const response = await fetch(`${API_BASE}/webhooks/${id}/test`, {
+            method: 'POST',
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    getWebhookDeliveries: async (id, page = 1, pageSize = 20) => {
+        const response = await fetch(`${API_BASE}/webhooks/${id}/deliveries?page=${page}&page_size=${pageSize}`, {
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    redeliverWebhook: async (id, deliveryId) => {
+        const response = await fetch(`${API_BASE}/webhooks/${id}/deliveries/${deliveryId}/redeliver`, {
+            method: 'POST',
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    // Trigger a scheduler job now (default: domain expiry check)
+    triggerSchedulerCheck: async (job) => {
         const response = await fetch(`${API_BASE}/scheduler/trigger`, {
             method: 'POST',
             headers: getHeaders(),
+            body: job ? JSON.stringify({ job }) : undefined,
+        });
+        return handleResponse(response);
+    },
+
+    getSchedulerJobs: async () => {
+        const response = await fetch(`${API_BASE}/scheduler/jobs`, {
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    updateSchedulerJob: async (name, data) => {
+        const response = await fetch(`${API_BASE}/scheduler/jobs/${name}`, {
+            method: 'PUT',
+            headers: getHeaders(),
+            body: JSON.stringify(data),
+        });
+        return handleResponse(response);
+    },
+
+    // Two-factor authentication
+    getTOTPStatus: async () => {
+        const response = await fetch(`${API_BASE}/user/totp`, {
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    setupTOTP: async () => {
+        const response = await fetch(`${API_BASE}/user/totp/setup`, {
+            method: 'POST',
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    enableTOTP: async (code) => {
+        const response = await fetch(`${API_BASE}/user/totp/enable`, {
+            method: 'POST',
+            headers: getHeaders(),
+            body: JSON.stringify({ code }),
+        });
+        return handleResponse(response);
+    },
+
+    disableTOTP: async (password, code) => {
+        const response = await fetch(`${API_BASE}/user/totp/disable`, {
+            method: 'POST',
+            headers: getHeaders(),
+            body: JSON.stringify({ password, code }),
+        });
+        return handleResponse(response);
+    },
+
+    regenerateRecoveryCodes: async (code) => {
+        const response = await fetch(`${API_BASE}/user/totp/recovery-codes`, {
+            method: 'POST',
+            headers: getHeaders(),
+            body: JSON.stringify({ code }),
+        });
+        return handleResponse(response);
+    },
+
+    // Personal API tokens
+    listAPITokens: async () => {
+        const response = await fetch(`${API_BASE}/api-tokens`, {
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    createAPIToken: async (data) => {
+        const response = await fetch(`${API_BASE}/api-tokens`, {
+            method: 'POST',
+            headers: getHeaders(),
+            body: JSON.stringify(data),
+        });
+        return handleResponse(response);
+    },
+
+    deleteAPIToken: async (id) => {
+        const response = await fetch(`${API_BASE}/api-tokens/${id}`, {
+            method: 'DELETE',
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    // User management (admin only)
+    listUsers: async () => {
+        const response = await fetch(`${API_BASE}/admin/users`, {
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    createUser: async (data) => {
+        const response = await fetch(`${API_BASE}/admin/users`, {
+            method: 'POST',
+            headers: getHeaders(),
+            body: JSON.stringify(data),
+        });
+        return handleResponse(response);
+    },
+
+    updateUser: async (id, data) => {
+        const response = await fetch(`${API_BASE}/admin/users/${id}`, {
+            method: 'PUT',
+            headers: getHeaders(),
+            body: JSON.stringify(data),
+        });
+        return handleResponse(response);
+    },
+
+    resetUserPassword: async (id, newPassword) => {
+        const response = await fetch(`${API_BASE}/admin/users/${id}/reset-password`, {
+            method: 'POST',
+            headers: getHeaders(),
+            body: JSON.stringify({ new_password: newPassword }),
+        });
+        return handleResponse(response);
+    },
+
+    deleteUser: async (id) => {
+        const response = await fetch(`${API_BASE}/admin/users/${id}`, {
+            method: 'DELETE',
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    resetUserTOTP: async (id) => {
+        const response = await fetch(`${API_BASE}/admin/users/${id}/totp`, {
+            method: 'DELETE',
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    getRegistrationMode: async () => {
+        const response = await fetch(`${API_BASE}/admin/registration`, {
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    updateRegistrationMode: async (mode) => {
+        const response = await fetch(`${API_BASE}/admin/registration`, {
+            method: 'PUT',
+            headers: getHeaders(),
+            body: JSON.stringify({ mode }),
+        });
+        return handleResponse(response);
+    },
+
+    listInvites: async () => {
+        const response = await fetch(`${API_BASE}/admin/invites`, {
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    createInvite: async (data) => {
+        const response = await fetch(`${API_BASE}/admin/invites`, {
+            method: 'POST',
+            headers: getHeaders(),
+            body: JSON.stringify(data),
+        });
+        return handleResponse(response);
+    },
+
+    deleteInvite: async (id) => {
+        const response = await fetch(`${API_BASE}/admin/invites/${id}`, {
+            method: 'DELETE',
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    // Log retention and database maintenance
+    getLogRetention: async () => {
+        const response = await fetch(`${API_BASE}/admin/log-retention`, {
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    updateLogRetention: async (table, data) => {
+        const response = await fetch(`${API_BASE}/admin/log-retention/${table}`, {
+            method: 'PUT',
+            headers: getHeaders(),
+            body: JSON.stringify(data),
+        });
+        return handleResponse(response);
+    },
+
+    runLogCleanup: async () => {
+        const response = await fetch(`${API_BASE}/admin/log-retention/cleanup`, {
+            method: 'POST',
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    getDatabaseStats: async () => {
+        const response = await fetch(`${API_BASE}/admin/database/stats`, {
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    vacuumDatabase: async () => {
+        const response = await fetch(`${API_BASE}/admin/database/vacuum`, {
+            method: 'POST',
+            headers: getHeaders(),
         });
         return handleResponse(response);
     },
@@ -347,7 +886,34 @@ export const api = {
         return handleResponse(response);
     },
 
-    // DDNS Token API (user-level, one token per user)
+    // Domains queued for deletion review by the scheduled refresh
+    getPendingDomainDeletions: async (includeDismissed = false) => {
+        const query = includeDismissed ? '?include_dismissed=true' : '';
+        const response = await fetch(`${API_BASE}/domains/pending-deletions${query}`, {
+            headers: getHeaders(),
+        });
+        return handleResponse(response);
+    },
+
+    approvePendingDomainDeletions: async (items) => {
+        const response = await fetch(`${API_BASE}/domains/pending-deletions/approve`, {
+            method: 'POST',
+            headers: getHeaders(),
+            body: JSON.stringify({ items }),
+        });
+        return handleResponse(response);
+    },
+
+    dismissPendingDomainDeletions: async (items) => {
+        const response = await fetch(`${API_BASE}/domains/pending-deletions/dismiss`, {
+            method: 'POST',
+            headers: getHeaders(),
+            body: JSON.stringify({ items }),
+        });
+        return handleResponse(response);
+    },
+
+    // DDNS Token API (legacy: operates on the user's oldest token)
     getDDNSToken: async () => {
         const response = await fetch(`${API_BASE}/ddns-token`, {
             headers: getHeaders()
@@ -372,6 +938,104 @@ export const api = {
         return handleResponse(response);
     },
 
+    // Scoped DDNS tokens (multiple per user)
+    listDDNSTokens: async () => {
+        const response = await fetch(`${API_BASE}/ddns-tokens`, {
+            headers: getHeaders()
+        });
+        return handleResponse(response);
+    },
+
+    createDDNSTokenScoped: async (data) => {
+        const response = await fetch(`${API_BASE}/ddns-tokens`, {
+            method: 'POST',
+            headers: getHeaders(),
+            body: JSON.stringify(data)
+        });
+        return handleResponse(response);
+    },
+
+    updateDDNSTokenByID: async (id, data) => {
+        const response = await fetch(`${API_BASE}/ddns-tokens/${id}`, {
+            method: 'PUT',
+            headers: getHeaders(),
+            body: JSON.stringify(data)
+        });
+        return handleResponse(response);
+    },
+
+    // Reveals a token value; list and get responses only return it masked
+    revealDDNSToken: async (id) => {
+        const response = await fetch(`${API_BASE}/ddns-tokens/${id}/token`, {
+            headers: getHeaders()
+        });
+        return handleResponse(response);
+    },
+
+    deleteDDNSTokenByID: async (id) => {
+        const response = await fetch(`${API_BASE}/ddns-tokens/${id}`, {
+            method: 'DELETE',
+            headers: getHeaders()
+        });
+        return handleResponse(response);
+    },
+
+    // filters: { token_id, hostname, status, from, to }
+    getDDNSHistory: async (page = 1, pageSize = 20, filters = {}) => {
+        const params = new URLSearchParams({ page, page_size: pageSize });
+        Object.entries(filters).forEach(([key, value]) => {
+            if (value !== undefined && value !== null && value !== '') {
+                params.append(key, value);
+            }
+        });
+        const response = await fetch(`${API_BASE}/ddns-history?${params}`, {
+            headers: getHeaders()
+        });
+        return handleResponse(response);
+    },
+
+    // Server-side DDNS agents
+    getDDNSAgents: async () => {
+        const response = await fetch(`${API_BASE}/ddns-agents`, {
+            headers: getHeaders()
+        });
+        return handleResponse(response);
+    },
+
+    createDDNSAgent: async (data) => {
+        const response = await fetch(`${API_BASE}/ddns-agents`, {
+            method: 'POST',
+            headers: getHeaders(),
+            body: JSON.stringify(data)
+        });
+        return handleResponse(response);
+    },
+
+    updateDDNSAgent: async (id, data) => {
+        const response = await fetch(`${API_BASE}/ddns-agents/${id}`, {
+            method: 'PUT',
+            headers: getHeaders(),
+            body: JSON.stringify(data)
+        });
+        return handleResponse(response);
+    },
+
+    deleteDDNSAgent: async (id) => {
+        const response = await fetch(`${API_BASE}/ddns-agents/${id}`, {
+            method: 'DELETE',
+            headers: getHeaders()
+        });
+        return handleResponse(response);
+    },
+
+    runDDNSAgent: async (id) => {
+        const response = await fetch(`${API_BASE}/ddns-agents/${id}/run`, {
+            method: 'POST',
+            headers: getHeaders()
+        });
+        return handleResponse(response);
+    },
+
     // Backup & Restore
     exportBackup: async (password = '') => {
         const response = await fetch(`${API_BASE}/backup/export`, {
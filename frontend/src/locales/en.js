@@ -24,7 +24,6 @@ export const en = {
     selected: 'Selected',
     keepCurrentIfBlank: 'Leave blank to keep current {field}',
     fields: {
-      apiKey: 'key',
       password: 'password',
     },
     noSearchResults: 'No results match your search',
@@ -55,6 +54,46 @@ export const en = {
     viewOnGitHub: 'View on GitHub',
     usernamePlaceholder: 'Enter username',
     passwordPlaceholder: 'Enter password',
+    noAccount: "Don't have an account?",
+    haveAccount: 'Already have an account?',
+    register: 'Sign up',
+    firstUserHint: 'No users yet. The first account registered becomes the administrator',
+    inviteCode: 'Invite Code',
+    inviteCodePlaceholder: 'Enter your invite code',
+    totpCode: 'Two-Factor Code',
+    totpCodePlaceholder: '6-digit code from your authenticator or a recovery code',
+  },
+
+  sessions: {
+    title: 'Signed-in Devices',
+    subtitle: 'Sessions that can use this account. Changing your password signs out all other devices.',
+    current: 'This device',
+    lastUsed: 'Last active',
+    revoke: 'Sign Out',
+    revokeAll: 'Log Out Everywhere',
+    revokedMsg: 'Session signed out',
+  },
+
+  twoFactor: {
+    title: 'Two-Factor Authentication',
+    subtitle: 'Require a code from an authenticator app in addition to your password.',
+    statusEnabled: 'Enabled',
+    statusDisabled: 'Not enabled',
+    codesLeft: '{count} recovery codes left',
+    setup: 'Set Up Two-Factor',
+    setupHint: 'Add the secret or otpauth link below to your authenticator app (e.g. Google Authenticator, 1Password), then enter the 6-digit code it shows.',
+    secret: 'Secret',
+    uri: 'otpauth Link',
+    code: 'Code',
+    codePlaceholder: '6-digit code or recovery code',
+    enable: 'Verify and Enable',
+    disable: 'Turn Off',
+    regenerate: 'New Recovery Codes',
+    recoveryCodes: 'Recovery Codes',
+    recoveryCodesHint: 'Each recovery code works once and lets you sign in if you lose your authenticator. They are only shown now, so store them safely.',
+    enabledMsg: 'Two-factor authentication enabled',
+    disabledMsg: 'Two-factor authentication turned off',
+    regeneratedMsg: 'New recovery codes generated; the old ones no longer work',
   },
 
   layout: {
@@ -81,8 +120,6 @@ export const en = {
     provider: 'Provider',
     selectProvider: 'Select a provider',
     providerCannotChange: 'Provider cannot be changed',
-    apiKey: 'API Key',
-    apiKeyPlaceholder: 'Enter your provider API key',
     addedOn: 'Added on',
     viewDomains: 'View Domains',
     editAccount: 'Edit Account',
@@ -93,16 +130,52 @@ export const en = {
     linkFirst: 'Link your first account',
     deleteAccountTitle: 'Confirm Delete Account',
     deleteAccountMessage: 'Are you sure you want to delete account "{name}"? This will also delete all domains and records under this account and cannot be undone.',
-    tencentcloudFormat: 'Format: SecretId,SecretKey (separated by comma)',
-    aliyunFormat: 'Format: AccessKeyId,AccessKeySecret (comma separated; RAM must allow DNS APIs including DescribeDomainNs). Domain list only includes zones whose registrar NS are all delegated to Alibaba Cloud DNS.',
-    huaweicloudFormat: 'Format: AccessKeyId,SecretAccessKey; optional 3rd field ProjectId; optional 4th RegionId (e.g. cn-south-1, default cn-north-4). If there are only 3 parts and the 3rd looks like a region (cn-/ap-/…), it is treated as RegionId. Multi-value record sets appear as multiple rows with ids like uuid|index.',
-    cloudflareFormat: 'Format: API Token (from Cloudflare Dashboard → My Profile → API Tokens)',
-    ndjpFormat: 'Format: Bearer Token (from NDJP NET Dashboard)',
-    desecFormat: 'Format: Token (from deSEC Dashboard → Token Management)',
-    dnsheFormat: 'Format: API Key,API Secret (comma separated, from DNSHE Dashboard → API Management)',
-    ipv64Format: 'Format: API Token (from IPv64.net Dashboard → API Settings)',
-    hurricaneFormat: 'Format: username,password (comma separated, from Hurricane Electric login page)',
-    vps8Format: 'Format: API Key (from VPS8 Client Area → Profile → API Key)',
+    optional: '(optional)',
+    // Labels of credential fields; others show the label the provider declares
+    credentialFields: {
+      username: 'Username',
+      password: 'Password',
+      project_id: 'Project ID',
+      region: 'Region',
+    },
+    // Help by provider and field, in place of the help the provider declares
+    credentialHelp: {
+      huaweicloud: {
+        project_id: 'Only needed for IAM users limited to one project',
+        region: 'e.g. cn-south-1, defaults to cn-north-4',
+      },
+      mock: {
+        api_key: 'Any name. Each name is its own in-memory sandbox; names starting with "invalid" are rejected. Data is lost on restart.',
+      },
+    },
+    providerHints: {
+      tencentcloud: 'From Tencent Cloud Console → Access Management → API Keys',
+      aliyun: 'The RAM user must be allowed DNS APIs including DescribeDomainNs. The domain list only includes zones whose registrar NS are all delegated to Alibaba Cloud DNS.',
+      huaweicloud: 'Multi-value record sets appear as multiple rows with ids like uuid|index.',
+      cloudflare: 'From Cloudflare Dashboard → My Profile → API Tokens',
+      ndjp: 'From NDJP NET Dashboard',
+      desec: 'From deSEC Dashboard → Token Management',
+      dnshe: 'From DNSHE Dashboard → API Management',
+      ipv64: 'From IPv64.net Dashboard → API Settings',
+      hurricane: 'Your Hurricane Electric login',
+      vps8: 'From VPS8 Client Area → Profile → API Key',
+    },
+    health: {
+      healthy: 'Healthy',
+      degraded: 'Recent errors',
+      unhealthy: 'Paused after repeated failures',
+    },
+    testConnection: 'Test Connection',
+    connectionOk: 'Connection OK, {count} domain(s) found',
+    failing: 'Failing',
+    failingSince: 'Failing since {time}',
+    checkCodes: {
+      invalid_format: 'Invalid credential format',
+      unauthorized: 'Credentials rejected',
+      rate_limited: 'Rate limited by the provider',
+      unreachable: 'Provider unreachable',
+      error: 'Provider error',
+    },
     goToProvider: 'Go to Provider Console',
     ddns: {
       label: 'DDNS Token',
@@ -292,6 +365,9 @@ export const en = {
     taskNames: {
       domain_expiry_notification: 'Domain Expiry Notification',
       dnshe_auto_renew: 'DNSHE Auto Renew',
+      domain_refresh: 'Domain Refresh',
+      log_cleanup: 'Log Cleanup',
+      account_check: 'Account Check',
     },
     schedulerDetails: {
       totalDomains: 'Total',
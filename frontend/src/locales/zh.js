@@ -24,7 +24,6 @@ export const zh = {
     selected: '已选择',
     keepCurrentIfBlank: '留空则保持当前{field}不变',
     fields: {
-      apiKey: '密钥',
       password: '密码',
     },
     noSearchResults: '未找到匹配的结果',
@@ -55,6 +54,46 @@ export const zh = {
     viewOnGitHub: '在 GitHub 上查看',
     usernamePlaceholder: '请输入用户名',
     passwordPlaceholder: '请输入密码',
+    noAccount: '还没有账户？',
+    haveAccount: '已有账户？',
+    register: '注册',
+    firstUserHint: '系统中还没有用户，首个注册的账户将成为管理员',
+    inviteCode: '邀请码',
+    inviteCodePlaceholder: '请输入邀请码',
+    totpCode: '两步验证码',
+    totpCodePlaceholder: '验证器中的 6 位数字或恢复码',
+  },
+
+  sessions: {
+    title: '登录设备',
+    subtitle: '当前可使用此账户的会话。修改密码会退出其他所有设备。',
+    current: '当前设备',
+    lastUsed: '最近活动',
+    revoke: '退出',
+    revokeAll: '退出所有设备',
+    revokedMsg: '已退出该会话',
+  },
+
+  twoFactor: {
+    title: '两步验证',
+    subtitle: '登录时除密码外还需输入验证器 App 生成的动态码。',
+    statusEnabled: '已启用',
+    statusDisabled: '未启用',
+    codesLeft: '剩余 {count} 个恢复码',
+    setup: '设置两步验证',
+    setupHint: '在验证器 App（如 Google Authenticator、1Password）中添加以下密钥或 otpauth 链接，然后输入生成的 6 位数字确认。',
+    secret: '密钥',
+    uri: 'otpauth 链接',
+    code: '验证码',
+    codePlaceholder: '6 位数字或恢复码',
+    enable: '确认并启用',
+    disable: '关闭两步验证',
+    regenerate: '重新生成恢复码',
+    recoveryCodes: '恢复码',
+    recoveryCodesHint: '每个恢复码只能使用一次，丢失验证器时可用来登录。它们只显示这一次，请妥善保存。',
+    enabledMsg: '两步验证已启用',
+    disabledMsg: '两步验证已关闭',
+    regeneratedMsg: '已生成新的恢复码，旧恢复码已失效',
   },
 
   layout: {
@@ -81,8 +120,6 @@ export const zh = {
     provider: '服务商',
     selectProvider: '选择服务商',
     providerCannotChange: '服务商创建后不可更改',
-    apiKey: 'API 密钥',
-    apiKeyPlaceholder: '输入您的服务商 API 密钥',
     addedOn: '添加于',
     viewDomains: '查看域名',
     editAccount: '编辑账户',
@@ -93,16 +130,52 @@ export const zh = {
     linkFirst: '关联您的第一个账户',
     deleteAccountTitle: '确认删除账户',
     deleteAccountMessage: '确定要删除账户 "{name}" 吗？这将同时删除该账户下的所有域名和记录，且无法撤销。',
-    tencentcloudFormat: '格式：SecretId,SecretKey（用英文逗号分隔）',
-    aliyunFormat: '格式：AccessKeyId,AccessKeySecret（用英文逗号分隔，RAM 需授权云解析 DNS）。域名列表仅包含注册商 NS 已全部指向阿里云解析的域名。',
-    huaweicloudFormat: '格式：AccessKeyId,SecretAccessKey；可选第三段 ProjectId；可选第四段 RegionId（如 cn-south-1，默认 cn-north-4）。若仅三段且为 cn-/ap- 等区域前缀则视为 Region。多值记录集在列表中拆成多条，记录 ID 含 |索引。',
-    cloudflareFormat: '格式：API Token（从 Cloudflare Dashboard → My Profile → API Tokens 获取）',
-    ndjpFormat: '格式：Bearer Token（从 NDJP NET 控制台获取）',
-    desecFormat: '格式：Token（从 deSEC 控制台 → Token Management 获取）',
-    dnsheFormat: '格式：API Key,API Secret（用英文逗号分隔，从 DNSHE 控制台 → API 管理获取）',
-    ipv64Format: '格式：API Token（从 IPv64.net 控制台 → API 设置获取）',
-    hurricaneFormat: '格式：账号,密码（用英文逗号分隔，从 Hurricane Electric 登录页面获取）',
-    vps8Format: '格式：API Key（从 VPS8 客户区 → 个人资料 → API 密钥获取）',
+    optional: '（可选）',
+    // 凭据字段名称；未列出的字段使用服务商声明的名称
+    credentialFields: {
+      username: '账号',
+      password: '密码',
+      project_id: '项目 ID',
+      region: '区域',
+    },
+    // 按服务商和字段的说明，替代服务商声明的说明
+    credentialHelp: {
+      huaweicloud: {
+        project_id: '仅当 IAM 用户限定于某个项目时需要',
+        region: '如 cn-south-1，默认 cn-north-4',
+      },
+      mock: {
+        api_key: '任意名称。每个名称对应独立的内存沙盒，以 "invalid" 开头的名称会被拒绝；重启后数据丢失。',
+      },
+    },
+    providerHints: {
+      tencentcloud: '从腾讯云控制台 → 访问管理 → API 密钥获取',
+      aliyun: 'RAM 需授权云解析 DNS（含 DescribeDomainNs）。域名列表仅包含注册商 NS 已全部指向阿里云解析的域名。',
+      huaweicloud: '多值记录集在列表中拆成多条，记录 ID 含 |索引。',
+      cloudflare: '从 Cloudflare Dashboard → My Profile → API Tokens 获取',
+      ndjp: '从 NDJP NET 控制台获取',
+      desec: '从 deSEC 控制台 → Token Management 获取',
+      dnshe: '从 DNSHE 控制台 → API 管理获取',
+      ipv64: '从 IPv64.net 控制台 → API 设置获取',
+      hurricane: 'Hurricane Electric 的登录账号和密码',
+      vps8: '从 VPS8 客户区 → 个人资料 → API 密钥获取',
+    },
+    health: {
+      healthy: '正常',
+      degraded: '近期有错误',
+      unhealthy: '连续失败，已暂停调用',
+    },
+    testConnection: '测试连接',
+    connectionOk: '连接正常，找到 {count} 个域名',
+    failing: '连接失败',
+    failingSince: '自 {time} 起连接失败',
+    checkCodes: {
+      invalid_format: '凭据格式错误',
+      unauthorized: '凭据被拒绝',
+      rate_limited: '服务商限流',
+      unreachable: '无法连接服务商',
+      error: '服务商返回错误',
+    },
     goToProvider: '前往服务商控制台',
     ddns: {
       label: 'DDNS Token',
@@ -292,6 +365,9 @@ export const zh = {
     taskNames: {
       domain_expiry_notification: '域名到期通知',
       dnshe_auto_renew: 'DNSHE 自动续期',
+      domain_refresh: '域名定时刷新',
+      log_cleanup: '日志清理',
+      account_check: '账户连接检测',
     },
     schedulerDetails: {
       totalDomains: '总计',
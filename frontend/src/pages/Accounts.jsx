@@ -1,7 +1,7 @@
 import { useState, useEffect, useRef } from 'react';
 import { api, getBackendBaseURL } from '../api';
 import { Link } from 'react-router-dom';
-import { Plus, Settings, Trash2, ExternalLink, Eye, EyeOff, Copy, Key, RefreshCw } from 'lucide-react';
+import { Plus, Settings, Trash2, ExternalLink, Eye, EyeOff, Copy, Key, RefreshCw, Activity } from 'lucide-react';
 import Modal from '../components/Modal';
 import ConfirmDialog from '../components/ConfirmDialog';
 import { useLanguage } from '../LanguageContext';
@@ -27,14 +27,22 @@ const Accounts = () => {
     const [formData, setFormData] = useState({
         name: '',
         provider_type: '',
-        api_key: ''
+        credentials: {}
     });
     const [formError, setFormError] = useState('');
+    // Errors by credential field, from a failed save or test
+    const [fieldErrors, setFieldErrors] = useState({});
     const [submitting, setSubmitting] = useState(false);
 
+    // Connection tests: per card, and of the credentials in the modal
+    const [testResults, setTestResults] = useState({});
+    const [testingId, setTestingId] = useState(null);
+    const [modalTest, setModalTest] = useState(null);
+    const [modalTesting, setModalTesting] = useState(false);
+
     // Visibility state
     const [visibleKeys, setVisibleKeys] = useState({});
-    const [showModalApiKey, setShowModalApiKey] = useState(false);
+    const [visibleSecrets, setVisibleSecrets] = useState({});
 
     // Delete confirmation dialog state
     const [showDeleteConfirm, setShowDeleteConfirm] = useState(false);
@@ -52,13 +60,39 @@ const Accounts = () => {
     const [ddnsTokenDeleteLoading, setDdnsTokenDeleteLoading] = useState(false);
     const [showDdnsTokenValue, setShowDdnsTokenValue] = useState(false);
 
-    const toggleKeyVisibility = (id) => {
+    // API keys are masked in the account list and fetched on demand
+    const [revealedKeys, setRevealedKeys] = useState({});
+
+    const revealAccountKey = async (id) => {
+        if (revealedKeys[id] !== undefined) return revealedKeys[id];
+        const { api_key } = await api.getAccountAPIKey(id);
+        setRevealedKeys(prev => ({ ...prev, [id]: api_key }));
+        return api_key;
+    };
+
+    const toggleKeyVisibility = async (id) => {
+        if (!visibleKeys[id]) {
+            try {
+                await revealAccountKey(id);
+            } catch (err) {
+                alert(err.message);
+                return;
+            }
+        }
         setVisibleKeys(prev => ({
             ...prev,
             [id]: !prev[id]
         }));
     };
 
+    const copyAccountKey = async (id) => {
+        try {
+            navigator.clipboard.writeText(await revealAccountKey(id));
+        } catch (err) {
+            alert(err.message);
+        }
+    };
+
     useEffect(() => {
         // Prevent duplicate requests in React StrictMode
         if (fetchedRef.current) return;
@@ -82,47 +116,122 @@ const Accounts = () => {
         }
     };
 
-    const openCreateModal = () => {
-        setModalMode('create');
-        setFormData({ name: '', provider_type: providers[0]?.name || '', api_key: '' });
+    // Credential fields of the selected provider, as declared by the backend
+    const credentialFields = providers.find(p => p.name === formData.provider_type)?.credentials || [];
+
+    const resetModal = () => {
         setFormError('');
-        setShowModalApiKey(false);
+        setFieldErrors({});
+        setModalTest(null);
+        setVisibleSecrets({});
         setIsModalOpen(true);
     };
 
+    const openCreateModal = () => {
+        setModalMode('create');
+        setFormData({ name: '', provider_type: providers[0]?.name || '', credentials: {} });
+        resetModal();
+    };
+
     const openEditModal = (account) => {
         setModalMode('edit');
         setCurrentAccount(account);
-        setFormData({ name: account.name, provider_type: account.provider_type, api_key: account.api_key });
-        setFormError('');
-        setShowModalApiKey(false);
-        setIsModalOpen(true);
+        // Secret fields are masked in the list; left blank they keep their value
+        const fields = providers.find(p => p.name === account.provider_type)?.credentials || [];
+        const credentials = {};
+        fields.filter(f => !f.secret).forEach(f => {
+            credentials[f.name] = account.credentials?.[f.name] || '';
+        });
+        setFormData({ name: account.name, provider_type: account.provider_type, credentials });
+        resetModal();
+    };
+
+    const setCredential = (name, value) => {
+        setFormData(prev => ({ ...prev, credentials: { ...prev.credentials, [name]: value } }));
+        setFieldErrors(prev => ({ ...prev, [name]: undefined }));
+    };
+
+    // Only the fields of the selected provider, e.g. after switching providers
+    const formCredentials = () => {
+        const credentials = {};
+        credentialFields.forEach(f => {
+            const value = (formData.credentials[f.name] || '').trim();
+            if (value !== '' || (modalMode === 'edit' && !f.secret)) {
+                credentials[f.name] = value;
+            }
+        });
+        return credentials;
     };
 
     const handleSubmit = async (e) => {
         e.preventDefault();
         setSubmitting(true);
         setFormError('');
+        setFieldErrors({});
 
         try {
             if (modalMode === 'create') {
-                const newAccount = await api.createAccount(formData);
+                const newAccount = await api.createAccount({
+                    name: formData.name,
+                    provider_type: formData.provider_type,
+                    credentials: formCredentials()
+                });
                 setAccounts([newAccount, ...accounts]);
             } else {
                 const updatedAccount = await api.updateAccount(currentAccount.id, {
                     name: formData.name,
-                    api_key: formData.api_key
+                    credentials: formCredentials()
                 });
                 setAccounts(accounts.map(acc => acc.id === updatedAccount.id ? updatedAccount : acc));
+                setRevealedKeys(prev => ({ ...prev, [updatedAccount.id]: undefined }));
+                setVisibleKeys(prev => ({ ...prev, [updatedAccount.id]: false }));
             }
             setIsModalOpen(false);
         } catch (err) {
             setFormError(err.message);
+            setFieldErrors(err.fields || {});
         } finally {
             setSubmitting(false);
         }
     };
 
+    const describeCheck = (check) => check.ok
+        ? t.accounts.connectionOk.replace('{count}', check.domain_count)
+        : `${t.accounts.checkCodes[check.code] || check.code}: ${check.error}`;
+
+    const handleTestAccount = async (account) => {
+        setTestingId(account.id);
+        try {
+            const check = await api.testAccount(account.id);
+            setTestResults(prev => ({ ...prev, [account.id]: check }));
+            // The test is recorded on the account, which may change its failing mark
+            const data = await api.getAccounts();
+            setAccounts(data || []);
+        } catch (err) {
+            setError(err.message);
+        } finally {
+            setTestingId(null);
+        }
+    };
+
+    const handleModalTest = async () => {
+        setModalTesting(true);
+        setModalTest(null);
+        setFormError('');
+        setFieldErrors({});
+        try {
+            const check = modalMode === 'create'
+                ? await api.testAccountCredentials({ provider_type: formData.provider_type, credentials: formCredentials() })
+                : await api.testAccount(currentAccount.id, { credentials: formCredentials() });
+            setModalTest(check);
+            setFieldErrors(check.fields || {});
+        } catch (err) {
+            setFormError(err.message);
+        } finally {
+            setModalTesting(false);
+        }
+    };
+
     const handleDelete = async (id) => {
         const account = accounts.find(acc => acc.id === id);
         setDeletingAccount(account);
@@ -152,8 +261,28 @@ const Accounts = () => {
 
     // DDNS Token functions (user-level, single token)
     const [ddnsToken, setDdnsToken] = useState(null);
+    const [ddnsTokenValue, setDdnsTokenValue] = useState(null); // revealed on demand
     const ddnsTokenLoadingRef = useRef(false);
 
+    const revealDdnsTokenValue = async () => {
+        if (ddnsTokenValue !== null) return ddnsTokenValue;
+        const { token } = await api.revealDDNSToken(ddnsToken.token.id);
+        setDdnsTokenValue(token);
+        return token;
+    };
+
+    const toggleDdnsTokenValue = async () => {
+        if (!showDdnsTokenValue) {
+            try {
+                await revealDdnsTokenValue();
+            } catch (err) {
+                alert(err.message);
+                return;
+            }
+        }
+        setShowDdnsTokenValue(!showDdnsTokenValue);
+    };
+
     const openDdnsTokenModal = async () => {
         setDdnsTokenModalOpen(true);
         if (ddnsTokenLoadingRef.current) return;
@@ -162,6 +291,7 @@ const Accounts = () => {
         try {
             const result = await api.getDDNSToken();
             setDdnsToken(result);
+            setDdnsTokenValue(null);
         } catch (err) {
             console.error('Failed to load DDNS token:', err);
         } finally {
@@ -177,6 +307,7 @@ const Accounts = () => {
         try {
             const result = await api.getDDNSToken();
             setDdnsToken(result);
+            setDdnsTokenValue(null);
         } catch (err) {
             console.error('Failed to load DDNS token:', err);
         } finally {
@@ -185,9 +316,16 @@ const Accounts = () => {
         }
     };
 
-    const openDdnsTokenEdit = () => {
+    const openDdnsTokenEdit = async () => {
+        let token = '';
+        try {
+            token = await revealDdnsTokenValue();
+        } catch (err) {
+            alert(err.message);
+            return;
+        }
         setDdnsTokenForm({
-            token: ddnsToken?.token?.token || '',
+            token,
             enabled: ddnsToken?.token?.enabled ?? true
         });
         setDdnsTokenFormError('');
@@ -242,8 +380,16 @@ const Accounts = () => {
         }
     };
 
-    const copyDdnsToken = (token) => {
-        navigator.clipboard.writeText(token);
+    // withURL copies the full update URL instead of the bare token
+    const copyDdnsToken = async (withURL) => {
+        try {
+            const token = await revealDdnsTokenValue();
+            navigator.clipboard.writeText(withURL
+                ? `${getBackendBaseURL()}/api/ddns/update?domains=<domain>&token=${token}`
+                : token);
+        } catch (err) {
+            alert(err.message);
+        }
     };
 
     if (loading) return (
@@ -306,8 +452,38 @@ const Accounts = () => {
                                     <span className="badge badge-neutral" style={{ fontSize: '11px', height: '20px' }}>
                                         {providers.find(p => p.name === account.provider_type)?.display_name || account.provider_type}
                                     </span>
+                                    {account.health && account.health.status !== 'unknown' && (
+                                        <span
+                                            className={`badge ${{ healthy: 'badge-success', degraded: 'badge-warning', unhealthy: 'badge-danger' }[account.health.status] || 'badge-neutral'}`}
+                                            style={{ fontSize: '11px', height: '20px', marginLeft: '6px' }}
+                                            title={account.health.last_error || ''}
+                                        >
+                                            {t.accounts.health[account.health.status] || account.health.status}
+                                        </span>
+                                    )}
+                                    {account.failing_since && (
+                                        <span
+                                            className="badge badge-danger"
+                                            style={{ fontSize: '11px', height: '20px', marginLeft: '6px' }}
+                                            title={[
+                                                t.accounts.failingSince.replace('{time}', new Date(account.failing_since).toLocaleString()),
+                                                account.last_check && !account.last_check.ok ? describeCheck(account.last_check) : ''
+                                            ].filter(Boolean).join('\n')}
+                                        >
+                                            {t.accounts.failing}
+                                        </span>
+                                    )}
                                 </div>
                                 <div className="account-card-actions" style={{ display: 'flex', gap: '2px', marginLeft: '8px' }}>
+                                    <button
+                                        onClick={() => handleTestAccount(account)}
+                                        className="btn btn-ghost"
+                                        title={t.accounts.testConnection}
+                                        disabled={testingId === account.id}
+                                        style={{ padding: '4px', minWidth: 'auto', height: 'auto', color: 'var(--text-secondary)' }}
+                                    >
+                                        <Activity size={13} />
+                                    </button>
                                     <button 
                                         onClick={() => openEditModal(account)} 
                                         className="btn btn-ghost" 
@@ -331,6 +507,17 @@ const Accounts = () => {
                                 {t.accounts.addedOn} {new Date(account.created_at).toLocaleDateString()}
                             </div>
 
+                            {testResults[account.id] && (
+                                <div style={{
+                                    fontSize: '12px',
+                                    color: testResults[account.id].ok ? 'var(--success)' : 'var(--danger)',
+                                    marginBottom: '0.75rem',
+                                    wordBreak: 'break-word'
+                                }}>
+                                    {describeCheck(testResults[account.id])}
+                                </div>
+                            )}
+
                             <div className="account-key-box" style={{
                                 display: 'flex', 
                                 alignItems: 'center', 
@@ -350,7 +537,7 @@ const Accounts = () => {
                                     textOverflow: 'ellipsis',
                                     whiteSpace: 'nowrap'
                                 }}>
-                                    {visibleKeys[account.id] ? account.api_key : '••••••••••••••••'}
+                                    {visibleKeys[account.id] ? revealedKeys[account.id] : '••••••••••••••••'}
                                 </div>
                                 <button
                                     onClick={() => toggleKeyVisibility(account.id)}
@@ -361,7 +548,7 @@ const Accounts = () => {
                                     {visibleKeys[account.id] ? <EyeOff size={12} /> : <Eye size={12} />}
                                 </button>
                                 <button
-                                    onClick={() => navigator.clipboard.writeText(account.api_key)}
+                                    onClick={() => copyAccountKey(account.id)}
                                     className="btn btn-ghost"
                                     style={{ padding: '3px', minWidth: 'auto', height: 'auto', color: 'var(--text-tertiary)' }}
                                     title={t.common.copy}
@@ -482,108 +669,95 @@ const Accounts = () => {
                         )}
                     </div>
 
-                    <div className="form-group">
-                        <label className="form-label">{t.accounts.apiKey}</label>
-                        <div style={{ position: 'relative' }}>
-                            <input
-                                type={showModalApiKey ? "text" : "password"}
-                                className="form-input"
-                                placeholder={
-                                    formData.provider_type === 'tencentcloud'
-                                        ? 'SecretId,SecretKey'
-                                        : formData.provider_type === 'aliyun'
-                                            ? 'AccessKeyId,AccessKeySecret'
-                                            : formData.provider_type === 'huaweicloud'
-                                                ? 'AccessKeyId,SecretAccessKey'
-                                                : formData.provider_type === 'dnshe'
-                                            ? 'API Key,API Secret'
-                                            : formData.provider_type === 'cloudflare' || formData.provider_type === 'ndjp' || formData.provider_type === 'desec' || formData.provider_type === 'ipv64'
-                                                ? 'API Token'
-                                                : t.accounts.apiKeyPlaceholder
-                                }
-                                value={formData.api_key}
-                                onChange={e => setFormData({ ...formData, api_key: e.target.value })}
-                                required={modalMode === 'create'}
-                                style={{ paddingRight: '40px' }}
-                            />
-                            <button
-                                type="button"
-                                onClick={() => setShowModalApiKey(!showModalApiKey)}
-                                style={{
-                                    position: 'absolute',
-                                    right: '8px',
-                                    top: '50%',
-                                    transform: 'translateY(-50%)',
-                                    background: 'none',
-                                    border: 'none',
-                                    color: 'var(--text-tertiary)',
-                                    cursor: 'pointer',
-                                    padding: '4px',
-                                    display: 'flex',
-                                    alignItems: 'center'
-                                }}
-                            >
-                                {showModalApiKey ? <EyeOff size={16} /> : <Eye size={16} />}
-                            </button>
+                    {credentialFields.map(field => {
+                        const secretVisible = !field.secret || visibleSecrets[field.name];
+                        const help = t.accounts.credentialHelp[formData.provider_type]?.[field.name] || field.help;
+                        return (
+                            <div className="form-group" key={field.name}>
+                                <label className="form-label">
+                                    {t.accounts.credentialFields[field.name] || field.label}
+                                    {!field.required && (
+                                        <span style={{ color: 'var(--text-tertiary)', fontWeight: 'normal' }}> {t.accounts.optional}</span>
+                                    )}
+                                </label>
+                                <div style={{ position: 'relative' }}>
+                                    <input
+                                        type={secretVisible ? 'text' : 'password'}
+                                        className="form-input"
+                                        placeholder={field.placeholder || ''}
+                                        value={formData.credentials[field.name] || ''}
+                                        onChange={e => setCredential(field.name, e.target.value)}
+                                        required={field.required && (modalMode === 'create' || !field.secret)}
+                                        style={field.secret ? { paddingRight: '40px' } : undefined}
+                                    />
+                                    {field.secret && (
+                                        <button
+                                            type="button"
+                                            onClick={() => setVisibleSecrets(prev => ({ ...prev, [field.name]: !prev[field.name] }))}
+                                            style={{
+                                                position: 'absolute',
+                                                right: '8px',
+                                                top: '50%',
+                                                transform: 'translateY(-50%)',
+                                                background: 'none',
+                                                border: 'none',
+                                                color: 'var(--text-tertiary)',
+                                                cursor: 'pointer',
+                                                padding: '4px',
+                                                display: 'flex',
+                                                alignItems: 'center'
+                                            }}
+                                        >
+                                            {secretVisible ? <EyeOff size={16} /> : <Eye size={16} />}
+                                        </button>
+                                    )}
+                                </div>
+                                {fieldErrors[field.name] && (
+                                    <p style={{ fontSize: '12px', color: 'var(--danger)', marginTop: '6px', margin: 0 }}>
+                                        {fieldErrors[field.name]}
+                                    </p>
+                                )}
+                                {modalMode === 'edit' && field.secret && (
+                                    <p style={{ fontSize: '12px', color: 'var(--text-tertiary)', marginTop: '6px', margin: 0 }}>
+                                        {t.common.keepCurrentIfBlank.replace('{field}', t.accounts.credentialFields[field.name] || field.label)}
+                                    </p>
+                                )}
+                                {help && (
+                                    <p style={{ fontSize: '12px', color: 'var(--text-secondary)', marginTop: '6px', margin: 0 }}>
+                                        {help}
+                                    </p>
+                                )}
+                            </div>
+                        );
+                    })}
+
+                    {t.accounts.providerHints[formData.provider_type] && (
+                        <p style={{ fontSize: '12px', color: 'var(--text-secondary)', margin: 0 }}>
+                            {t.accounts.providerHints[formData.provider_type]}
+                        </p>
+                    )}
+
+                    {modalTest && (
+                        <div style={{
+                            fontSize: '13px',
+                            color: modalTest.ok ? 'var(--success)' : 'var(--danger)',
+                            marginTop: '16px',
+                            wordBreak: 'break-word'
+                        }}>
+                            {describeCheck(modalTest)}
                         </div>
-                        {modalMode === 'edit' && (
-                            <p style={{ fontSize: '12px', color: 'var(--text-tertiary)', marginTop: '6px', margin: 0 }}>
-                                {t.common.keepCurrentIfBlank.replace('{field}', t.common.fields.apiKey)}
-                            </p>
-                        )}
-                        {formData.provider_type === 'tencentcloud' && (
-                            <p style={{ fontSize: '12px', color: 'var(--text-secondary)', marginTop: '6px', margin: 0 }}>
-                                {t.accounts.tencentcloudFormat}
-                            </p>
-                        )}
-                        {formData.provider_type === 'aliyun' && (
-                            <p style={{ fontSize: '12px', color: 'var(--text-secondary)', marginTop: '6px', margin: 0 }}>
-                                {t.accounts.aliyunFormat}
-                            </p>
-                        )}
-                        {formData.provider_type === 'huaweicloud' && (
-                            <p style={{ fontSize: '12px', color: 'var(--text-secondary)', marginTop: '6px', margin: 0 }}>
-                                {t.accounts.huaweicloudFormat}
-                            </p>
-                        )}
-                        {formData.provider_type === 'cloudflare' && (
-                            <p style={{ fontSize: '12px', color: 'var(--text-secondary)', marginTop: '6px', margin: 0 }}>
-                                {t.accounts.cloudflareFormat}
-                            </p>
-                        )}
-                        {formData.provider_type === 'ndjp' && (
-                            <p style={{ fontSize: '12px', color: 'var(--text-secondary)', marginTop: '6px', margin: 0 }}>
-                                {t.accounts.ndjpFormat}
-                            </p>
-                        )}
-                        {formData.provider_type === 'desec' && (
-                            <p style={{ fontSize: '12px', color: 'var(--text-secondary)', marginTop: '6px', margin: 0 }}>
-                                {t.accounts.desecFormat}
-                            </p>
-                        )}
-                        {formData.provider_type === 'dnshe' && (
-                            <p style={{ fontSize: '12px', color: 'var(--text-secondary)', marginTop: '6px', margin: 0 }}>
-                                {t.accounts.dnsheFormat}
-                            </p>
-                        )}
-                        {formData.provider_type === 'ipv64' && (
-                            <p style={{ fontSize: '12px', color: 'var(--text-secondary)', marginTop: '6px', margin: 0 }}>
-                                {t.accounts.ipv64Format}
-                            </p>
-                        )}
-                        {formData.provider_type === 'hurricane' && (
-                            <p style={{ fontSize: '12px', color: 'var(--text-secondary)', marginTop: '6px', margin: 0 }}>
-                                {t.accounts.hurricaneFormat}
-                            </p>
-                        )}
-                        {formData.provider_type === 'vps8' && (
-                            <p style={{ fontSize: '12px', color: 'var(--text-secondary)', marginTop: '6px', margin: 0 }}>
-                                {t.accounts.vps8Format}
-                            </p>
-                        )}
-                    </div>
+                    )}
 
                     <div style={{ display: 'flex', justifyContent: 'flex-end', gap: '8px', marginTop: '24px', paddingTop: '20px', borderTop: '1px solid var(--border-color)' }}>
+                        <button
+                            type="button"
+                            onClick={handleModalTest}
+                            className="btn btn-secondary"
+                            disabled={modalTesting || (modalMode === 'create' && credentialFields.some(f => f.required && !(formData.credentials[f.name] || '').trim()))}
+                            style={{ marginRight: 'auto' }}
+                        >
+                            {modalTesting ? <div className="spinner"></div> : t.accounts.testConnection}
+                        </button>
                         <button type="button" onClick={() => setIsModalOpen(false)} className="btn btn-secondary">
                             {t.common.cancel}
                         </button>
@@ -639,7 +813,7 @@ const Accounts = () => {
                                             {t.accounts.ddns.usageExample}
                                         </div>
                                         <code style={{ fontSize: '11px', color: '#3b82f6', wordBreak: 'break-all' }}>
-                                            {`${getBackendBaseURL()}/api/ddns/update?domains=example.com&token=${ddnsToken.token.token.substring(0, 8)}...`}
+                                            {`${getBackendBaseURL()}/api/ddns/update?domains=example.com&token=${(ddnsTokenValue || ddnsToken.token.token).substring(0, 8)}...`}
                                         </code>
                                     </div>
                                     <div style={{ display: 'flex', justifyContent: 'space-between', alignItems: 'flex-start', marginBottom: '12px' }}>
@@ -680,10 +854,10 @@ const Accounts = () => {
                                                 wordBreak: 'break-all',
                                                 lineHeight: '1.5'
                                             }}>
-                                                {showDdnsTokenValue ? ddnsToken.token.token : '••••••••••••••••••••••••••••••••'}
+                                                {showDdnsTokenValue ? ddnsTokenValue : '••••••••••••••••••••••••••••••••'}
                                             </div>
                                             <button
-                                                onClick={() => setShowDdnsTokenValue(!showDdnsTokenValue)}
+                                                onClick={toggleDdnsTokenValue}
                                                 className="btn btn-ghost"
                                                 style={{ padding: '4px', minWidth: 'auto', height: 'auto', flexShrink: 0 }}
                                                 title={showDdnsTokenValue ? t.common.hide : t.common.show}
@@ -695,7 +869,7 @@ const Accounts = () => {
 
                                     <div style={{ display: 'flex', gap: '6px', flexWrap: 'wrap', marginBottom: '8px' }}>
                                         <button
-                                            onClick={() => copyDdnsToken(ddnsToken.token.token)}
+                                            onClick={() => copyDdnsToken(false)}
                                             className="btn btn-ghost"
                                             style={{ fontSize: '11px', padding: '4px 8px' }}
                                             title={t.accounts.ddns.copy}
@@ -703,7 +877,7 @@ const Accounts = () => {
                                             <Copy size={12} /> {t.accounts.ddns.copy}
                                         </button>
                                         <button
-                                            onClick={() => copyDdnsToken(`${getBackendBaseURL()}/api/ddns/update?domains=<domain>&token=${ddnsToken.token.token}`)}
+                                            onClick={() => copyDdnsToken(true)}
                                             className="btn btn-ghost"
                                             style={{ fontSize: '11px', padding: '4px 8px' }}
                                             title={t.accounts.ddns.copyUrl}
@@ -1,7 +1,8 @@
-import { useState } from 'react';
+import { useState, useEffect } from 'react';
 import { useAuth } from '../AuthContext';
 import { useLanguage } from '../LanguageContext';
 import { useNavigate } from 'react-router-dom';
+import { api } from '../api';
 import { Eye, EyeOff, Github } from 'lucide-react';
 import ThemeSwitcher from '../components/ThemeSwitcher';
 import LanguageSelect from '../components/LanguageSelect';
@@ -10,13 +11,31 @@ const Login = () => {
     const [username, setUsername] = useState('');
     const [password, setPassword] = useState('');
     const [showPassword, setShowPassword] = useState(false);
-    const { login } = useAuth();
+    const [inviteCode, setInviteCode] = useState('');
+    const [registration, setRegistration] = useState({ mode: 'disabled', bootstrap: false });
+    const [isRegister, setIsRegister] = useState(false);
+    const [totpRequired, setTotpRequired] = useState(false);
+    const [totpCode, setTotpCode] = useState('');
+    const { login, register } = useAuth();
     const { t } = useLanguage();
     const navigate = useNavigate();
     const [error, setError] = useState('');
     const [loading, setLoading] = useState(false);
     const [touched, setTouched] = useState({ username: false, password: false });
 
+    useEffect(() => {
+        api.getRegistrationStatus()
+            .then((status) => {
+                setRegistration(status);
+                // 尚无用户时直接进入注册，首个账户即管理员
+                if (status.bootstrap) setIsRegister(true);
+            })
+            .catch(() => {});
+    }, []);
+
+    const canRegister = registration.bootstrap || registration.mode !== 'disabled';
+    const needInvite = isRegister && !registration.bootstrap && registration.mode === 'invite';
+
     // 实时验证
     const validateUsername = (value) => {
         if (!value) return t.login.usernamePlaceholder;
@@ -34,11 +53,14 @@ const Login = () => {
 
     const usernameError = touched.username ? validateUsername(username) : '';
     const passwordError = touched.password ? validatePassword(password) : '';
-    const isFormValid = username && password && !validateUsername(username) && !validatePassword(password);
+    const isFormValid = username && password && !validateUsername(username) && !validatePassword(password)
+        && (!needInvite || inviteCode.trim()) && (!totpRequired || totpCode.trim());
 
     const handleUsernameChange = (e) => {
         setUsername(e.target.value);
         setError('');
+        setTotpRequired(false);
+        setTotpCode('');
     };
 
     const handlePasswordChange = (e) => {
@@ -70,9 +92,18 @@ const Login = () => {
         setError('');
 
         try {
-            await login(username.trim(), password);
+            if (isRegister) {
+                await register(username.trim(), password, inviteCode.trim());
+            } else {
+                await login(username.trim(), password, totpCode.trim());
+            }
             navigate('/domains');
         } catch (err) {
+            if (err.totpRequired) {
+                // 密码正确，继续输入两步验证码
+                setTotpRequired(true);
+                return;
+            }
             setError(err.message);
         } finally {
             setLoading(false);
@@ -168,10 +199,16 @@ const Login = () => {
                         margin: '8px 0 0',
                         fontWeight: '400'
                     }}>
-                        {t.login.title}
+                        {isRegister ? t.login.register : t.login.title}
                     </p>
                 </div>
 
+                {isRegister && registration.bootstrap && (
+                    <div className="login-field-error" style={{ color: 'var(--text-secondary)', marginBottom: '16px' }}>
+                        {t.login.firstUserHint}
+                    </div>
+                )}
+
                 {/* 错误提示 */}
                 {error && (
                     <div className="login-error">
@@ -206,7 +243,7 @@ const Login = () => {
                                 value={password}
                                 onChange={handlePasswordChange}
                                 onBlur={handlePasswordBlur}
-                                autoComplete="current-password"
+                                autoComplete={isRegister ? "new-password" : "current-password"}
                                 placeholder={t.login.passwordPlaceholder}
                                 style={{ paddingRight: '44px' }}
                             />
@@ -222,14 +259,54 @@ const Login = () => {
                             <div className="login-field-error">{passwordError}</div>
                         )}
                     </div>
+                    {totpRequired && !isRegister && (
+                        <div className="form-group">
+                            <label className="form-label">{t.login.totpCode}</label>
+                            <input
+                                type="text"
+                                className="form-input"
+                                value={totpCode}
+                                onChange={(e) => { setTotpCode(e.target.value); setError(''); }}
+                                autoComplete="one-time-code"
+                                autoFocus
+                                placeholder={t.login.totpCodePlaceholder}
+                            />
+                        </div>
+                    )}
+                    {needInvite && (
+                        <div className="form-group">
+                            <label className="form-label">{t.login.inviteCode}</label>
+                            <input
+                                type="text"
+                                className="form-input"
+                                value={inviteCode}
+                                onChange={(e) => { setInviteCode(e.target.value); setError(''); }}
+                                placeholder={t.login.inviteCodePlaceholder}
+                            />
+                        </div>
+                    )}
                     <button
                         type="submit"
                         className="btn btn-primary login-submit-btn"
                         disabled={loading || !isFormValid}
                     >
-                        {loading ? <div className="spinner"></div> : t.login.title}
+                        {loading ? <div className="spinner"></div> : (isRegister ? t.login.register : t.login.title)}
                     </button>
                 </form>
+
+                {canRegister && !registration.bootstrap && (
+                    <div style={{ textAlign: 'center', marginTop: '16px', fontSize: '13px', color: 'var(--text-secondary)' }}>
+                        {isRegister ? t.login.haveAccount : t.login.noAccount}{' '}
+                        <button
+                            type="button"
+                           
+                            onClick={() => { setIsRegister(!isRegister); setError(''); }}
+                            style={{ background: 'none', border: 'none', padding: 0, color: 'var(--accent-primary)', cursor: 'pointer', fontSize: '13px' }}
+                        >
+                            {isRegister ? t.login.title : t.login.register}
+                        </button>
+                    </div>
+                )}
             </div>
         </div>
     );
@@ -1,9 +1,13 @@
-import { useState } from 'react';
+import { useState, useEffect } from 'react';
 import { api } from '../api';
 import { useLanguage } from '../LanguageContext';
+import { useAuth } from '../AuthContext';
+import { useNavigate } from 'react-router-dom';
 
 export default function Profile() {
     const { t } = useLanguage();
+    const { logout } = useAuth();
+    const navigate = useNavigate();
     const [updating, setUpdating] = useState(false);
     const [error, setError] = useState('');
     const [success, setSuccess] = useState('');
@@ -12,6 +16,103 @@ export default function Profile() {
         new_password: '',
         confirm_password: ''
     });
+    const [totpStatus, setTotpStatus] = useState(null);
+    const [totpSetup, setTotpSetup] = useState(null);
+    const [totpCode, setTotpCode] = useState('');
+    const [totpPassword, setTotpPassword] = useState('');
+    const [recoveryCodes, setRecoveryCodes] = useState(null);
+    const [totpBusy, setTotpBusy] = useState(false);
+    const [sessions, setSessions] = useState([]);
+
+    const loadTotpStatus = async () => {
+        try {
+            setTotpStatus(await api.getTOTPStatus());
+        } catch (err) {
+            setError(err.message);
+        }
+    };
+
+    const loadSessions = async () => {
+        try {
+            setSessions(await api.listSessions());
+        } catch (err) {
+            setError(err.message);
+        }
+    };
+
+    useEffect(() => {
+        loadTotpStatus();
+        loadSessions();
+    }, []);
+
+    const handleRevokeSession = async (id) => {
+        setError('');
+        setSuccess('');
+        try {
+            await api.revokeSession(id);
+            setSuccess(t.sessions.revokedMsg);
+            await loadSessions();
+        } catch (err) {
+            setError(err.message);
+        }
+    };
+
+    // 退出所有设备也包括当前设备，完成后回到登录页
+    const handleRevokeAllSessions = async () => {
+        setError('');
+        try {
+            await api.revokeAllSessions();
+            logout();
+            navigate('/login');
+        } catch (err) {
+            setError(err.message);
+        }
+    };
+
+    // 两步验证的各项操作共用提示与加载状态
+    const runTotpAction = async (action, message) => {
+        setError('');
+        setSuccess('');
+        setTotpBusy(true);
+        try {
+            await action();
+            if (message) setSuccess(message);
+            setTotpCode('');
+            setTotpPassword('');
+            await loadTotpStatus();
+        } catch (err) {
+            setError(err.message);
+        } finally {
+            setTotpBusy(false);
+        }
+    };
+
+    const handleTotpSetup = () => runTotpAction(async () => {
+        setRecoveryCodes(null);
+        setTotpSetup(await api.setupTOTP());
+    });
+
+    const handleTotpEnable = (e) => {
+        e.preventDefault();
+        runTotpAction(async () => {
+            const data = await api.enableTOTP(totpCode.trim());
+            setRecoveryCodes(data.codes);
+            setTotpSetup(null);
+        }, t.twoFactor.enabledMsg);
+    };
+
+    const handleTotpDisable = (e) => {
+        e.preventDefault();
+        runTotpAction(async () => {
+            await api.disableTOTP(totpPassword, totpCode.trim());
+            setRecoveryCodes(null);
+        }, t.twoFactor.disabledMsg);
+    };
+
+    const handleRegenerateCodes = () => runTotpAction(async () => {
+        const data = await api.regenerateRecoveryCodes(totpCode.trim());
+        setRecoveryCodes(data.codes);
+    }, t.twoFactor.regeneratedMsg);
 
     const handlePasswordChange = async (e) => {
         e.preventDefault();
@@ -36,6 +137,7 @@ export default function Profile() {
             });
             setSuccess(t.common.password.updated);
             setPasswordForm({ old_password: '', new_password: '', confirm_password: '' });
+            await loadSessions();
         } catch (err) {
             setError(err.message);
         } finally {
@@ -129,6 +231,118 @@ export default function Profile() {
                     </button>
                 </form>
             </div>
+
+            <div style={{ margin: '2rem 0 1rem' }}>
+                <h2 style={{ fontSize: '1.5rem', fontWeight: 'bold', letterSpacing: '-0.02em', margin: 0 }}>
+                    {t.twoFactor.title}
+                </h2>
+                <p style={{ color: 'var(--text-secondary)', fontSize: '13px', marginTop: '0.25rem', marginBottom: 0 }}>
+                    {t.twoFactor.subtitle}
+                </p>
+            </div>
+
+            <div className="domain-list-card" style={{ padding: '1.25rem', cursor: 'default', display: 'flex', flexDirection: 'column', gap: '1rem' }}>
+                {totpStatus && (
+                    <div style={{ fontSize: '14px' }}>
+                        {totpStatus.enabled
+                            ? `${t.twoFactor.statusEnabled} · ${t.twoFactor.codesLeft.replace('{count}', totpStatus.recovery_codes_left)}`
+                            : t.twoFactor.statusDisabled}
+                    </div>
+                )}
+
+                {recoveryCodes && (
+                    <div>
+                        <div className="form-label">{t.twoFactor.recoveryCodes}</div>
+                        <p style={{ color: 'var(--text-secondary)', fontSize: '13px', margin: '0 0 0.5rem' }}>
+                            {t.twoFactor.recoveryCodesHint}
+                        </p>
+                        <pre style={{ margin: 0, padding: '0.75rem', background: 'var(--bg-secondary)', borderRadius: 'var(--radius-sm)', fontSize: '13px' }}>
+                            {recoveryCodes.join('\n')}
+                        </pre>
+                    </div>
+                )}
+
+                {totpStatus && !totpStatus.enabled && !totpSetup && (
+                    <button type="button" className="btn btn-primary" disabled={totpBusy} onClick={handleTotpSetup} style={{ height: '34px', fontSize: '13px' }}>
+                        {t.twoFactor.setup}
+                    </button>
+                )}
+
+                {totpSetup && (
+                    <form onSubmit={handleTotpEnable} style={{ display: 'flex', flexDirection: 'column', gap: '1rem' }}>
+                        <p style={{ color: 'var(--text-secondary)', fontSize: '13px', margin: 0 }}>{t.twoFactor.setupHint}</p>
+                        <div>
+                            <label className="form-label">{t.twoFactor.secret}</label>
+                            <input className="form-input" readOnly value={totpSetup.secret} onFocus={(e) => e.target.select()} />
+                        </div>
+                        <div>
+                            <label className="form-label">{t.twoFactor.uri}</label>
+                            <input className="form-input" readOnly value={totpSetup.uri} onFocus={(e) => e.target.select()} />
+                        </div>
+                        <div>
+                            <label className="form-label">{t.twoFactor.code}</label>
+                            <input className="form-input" value={totpCode} onChange={(e) => setTotpCode(e.target.value)} autoComplete="one-time-code" required />
+                        </div>
+                        <button type="submit" className="btn btn-primary" disabled={totpBusy} style={{ height: '34px', fontSize: '13px' }}>
+                            {t.twoFactor.enable}
+                        </button>
+                    </form>
+                )}
+
+                {totpStatus?.enabled && (
+                    <form onSubmit={handleTotpDisable} style={{ display: 'flex', flexDirection: 'column', gap: '1rem' }}>
+                        <div>
+                            <label className="form-label">{t.twoFactor.code}</label>
+                            <input className="form-input" value={totpCode} onChange={(e) => setTotpCode(e.target.value)} autoComplete="one-time-code" placeholder={t.twoFactor.codePlaceholder} />
+                        </div>
+                        <div>
+                            <label className="form-label">{t.common.password.current}</label>
+                            <input type="password" className="form-input" value={totpPassword} onChange={(e) => setTotpPassword(e.target.value)} />
+                        </div>
+                        <div style={{ display: 'flex', gap: '0.5rem' }}>
+                            <button type="button" className="btn btn-secondary" disabled={totpBusy || !totpCode.trim()} onClick={handleRegenerateCodes} style={{ flex: 1, height: '34px', fontSize: '13px' }}>
+                                {t.twoFactor.regenerate}
+                            </button>
+                            <button type="submit" className="btn btn-danger" disabled={totpBusy || !totpCode.trim() || !totpPassword} style={{ flex: 1, height: '34px', fontSize: '13px' }}>
+                                {t.twoFactor.disable}
+                            </button>
+                        </div>
+                    </form>
+                )}
+            </div>
+
+            <div style={{ margin: '2rem 0 1rem' }}>
+                <h2 style={{ fontSize: '1.5rem', fontWeight: 'bold', letterSpacing: '-0.02em', margin: 0 }}>
+                    {t.sessions.title}
+                </h2>
+                <p style={{ color: 'var(--text-secondary)', fontSize: '13px', marginTop: '0.25rem', marginBottom: 0 }}>
+                    {t.sessions.subtitle}
+                </p>
+            </div>
+
+            <div className="domain-list-card" style={{ padding: '1.25rem', cursor: 'default', display: 'flex', flexDirection: 'column', gap: '0.75rem' }}>
+                {sessions.map((session) => (
+                    <div key={session.id} style={{ display: 'flex', alignItems: 'center', justifyContent: 'space-between', gap: '1rem', fontSize: '13px' }}>
+                        <div>
+                            <div style={{ fontWeight: 500 }}>
+                                {session.device}{session.current && ` · ${t.sessions.current}`}
+                            </div>
+                            <div style={{ color: 'var(--text-secondary)' }}>
+                                {[session.ip_address, session.ip_location].filter(Boolean).join(' · ')}
+                                {' · '}{t.sessions.lastUsed} {new Date(session.last_used_at).toLocaleString()}
+                            </div>
+                        </div>
+                        {!session.current && (
+                            <button type="button" className="btn btn-secondary" onClick={() => handleRevokeSession(session.id)} style={{ height: '30px', fontSize: '12px' }}>
+                                {t.sessions.revoke}
+                            </button>
+                        )}
+                    </div>
+                ))}
+                <button type="button" className="btn btn-danger" onClick={handleRevokeAllSessions} style={{ height: '34px', fontSize: '13px' }}>
+                    {t.sessions.revokeAll}
+                </button>
+            </div>
         </div>
     );
 }
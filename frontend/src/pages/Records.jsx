@@ -105,7 +105,7 @@ const Records = () => {
         setLoading(true);
         try {
             const [recordsData, domainData] = await Promise.all([
-                api.getRecords(accountId, domainId),
+                api.getRecords(accountId, domainId, true),
                 api.getDomain(accountId, domainId)
             ]);
             setRecords(recordsData || []);